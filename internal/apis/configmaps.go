@@ -2,11 +2,11 @@ package apis
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"k8s.io/apimachinery/pkg/util/validation"
 	"mockernetes/internal/resources" // custom structs for mock control (no corev1)
 	"mockernetes/internal/storage"
 )
@@ -46,15 +46,136 @@ func CreateConfigMap(c *gin.Context) {
 		WriteError(c, http.StatusBadRequest, "invalid configmap")
 		return
 	}
-	// validator for name
-	if errs := validation.IsDNS1123Label(cm.GetName()); len(errs) != 0 {
-		WriteError(c, http.StatusBadRequest, errs[0])
+	// validator for name (or generateName prefix)
+	if !validateCreateName(c, &cm.Metadata) {
+		return
+	}
+	if !admitNamespace(c, &cm.Metadata) {
+		return
+	}
+	dryRun, err := isDryRun(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	// dryRun=All: run defaulting/validation only, no storage write or controller hooks
+	if dryRun {
+		dryRunCreate(c, "ConfigMap", &cm)
 		return
 	}
 	// store; uses KubeObject impl from custom struct
-	if err := storage.DefaultStore.CreateConfigMap(cm); err != nil {
+	if err := storage.DefaultStore.CreateConfigMap(&cm); err != nil {
 		WriteError(c, http.StatusConflict, err.Error())
 		return
 	}
 	c.JSON(http.StatusCreated, cm)
 }
+
+// GetConfigMap handles GET /api/v1/namespaces/:namespace/configmaps/:name
+func GetConfigMap(c *gin.Context) {
+	cmName := c.Param("name")
+
+	cm, err := storage.DefaultStore.GetConfigMap(cmName)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("configmaps \"%s\" not found", cmName))
+		return
+	}
+
+	c.JSON(http.StatusOK, cm)
+}
+
+// UpdateConfigMap handles PUT /api/v1/namespaces/:namespace/configmaps/:name
+func UpdateConfigMap(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	var cm resources.ConfigMap
+	if err := json.Unmarshal(body, &cm); err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	replaceConfigMap(c, cm)
+}
+
+// PatchConfigMap handles PATCH /api/v1/namespaces/:namespace/configmaps/:name
+func PatchConfigMap(c *gin.Context) {
+	cmName := c.Param("name")
+	existing, err := storage.DefaultStore.GetConfigMap(cmName)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("configmaps \"%s\" not found", cmName))
+		return
+	}
+	var cm resources.ConfigMap
+	if !readPatchedObject(c, existing, &cm) {
+		return
+	}
+	replaceConfigMap(c, cm)
+}
+
+// replaceConfigMap runs the update pipeline shared by PUT and PATCH.
+func replaceConfigMap(c *gin.Context, cm resources.ConfigMap) {
+	existing, err := storage.DefaultStore.GetConfigMap(c.Param("name"))
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("configmaps \"%s\" not found", c.Param("name")))
+		return
+	}
+	if cm.Kind == "" {
+		WriteError(c, http.StatusBadRequest, "invalid configmap")
+		return
+	}
+	if !checkUpdateName(c, cm.GetName()) {
+		return
+	}
+	preserveMetadata(&cm.Metadata, existing)
+
+	dryRun, err := isDryRun(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if dryRun {
+		dryRunUpdate(c, "ConfigMap", &cm)
+		return
+	}
+
+	stored, err := storage.DefaultStore.UpdateObject("ConfigMap", &cm)
+	if err != nil {
+		WriteError(c, http.StatusConflict, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, stored)
+}
+
+// DeleteConfigMap handles DELETE /api/v1/namespaces/:namespace/configmaps/:name
+func DeleteConfigMap(c *gin.Context) {
+	cmName := c.Param("name")
+
+	opts, err := parseDeleteOptions(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	cm, err := storage.DefaultStore.GetConfigMap(cmName)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("configmaps \"%s\" not found", cmName))
+		return
+	}
+	if isDeleteDryRun(opts) {
+		c.JSON(http.StatusOK, cm)
+		return
+	}
+
+	if err := setPropagationFinalizers("ConfigMap", cmName, opts); err != nil {
+		WriteError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := storage.DefaultStore.DeleteConfigMap(cmName); err != nil {
+		WriteError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, deletedObject(storage.DefaultStore.GetConfigMap, cmName, cm))
+}
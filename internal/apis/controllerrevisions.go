@@ -0,0 +1,76 @@
+package apis
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"mockernetes/internal/storage"
+)
+
+// ControllerRevisions are written by the workload controllers (StatefulSet history);
+// clients only read them (kubectl rollout history) or delete them.
+
+// buildControllerRevisionList wraps store items into K8s list.
+func buildControllerRevisionList(items []interface{}) string {
+	list := map[string]interface{}{
+		"kind":       "ControllerRevisionList",
+		"apiVersion": "apps/v1",
+		"metadata":   map[string]string{"resourceVersion": "1"},
+		"items":      items,
+	}
+	b, _ := json.Marshal(list)
+	return string(b)
+}
+
+// ListControllerRevisions handles GET /apis/apps/v1/namespaces/:namespace/controllerrevisions
+func ListControllerRevisions(c *gin.Context) {
+	fields, err := parseFieldSelector(c.Query("fieldSelector"))
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	items := filterByFields(storage.DefaultStore.ListControllerRevisions(), fields)
+	c.Data(http.StatusOK, "application/json", []byte(buildControllerRevisionList(items)))
+}
+
+// GetControllerRevision handles GET /apis/apps/v1/namespaces/:namespace/controllerrevisions/:name
+func GetControllerRevision(c *gin.Context) {
+	name := c.Param("name")
+	cr, err := storage.DefaultStore.GetControllerRevision(name)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("controllerrevisions.apps \"%s\" not found", name))
+		return
+	}
+	c.JSON(http.StatusOK, cr)
+}
+
+// DeleteControllerRevision handles DELETE /apis/apps/v1/namespaces/:namespace/controllerrevisions/:name
+func DeleteControllerRevision(c *gin.Context) {
+	name := c.Param("name")
+
+	opts, err := parseDeleteOptions(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	cr, err := storage.DefaultStore.GetControllerRevision(name)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("controllerrevisions.apps \"%s\" not found", name))
+		return
+	}
+	if isDeleteDryRun(opts) {
+		c.JSON(http.StatusOK, cr)
+		return
+	}
+	if err := setPropagationFinalizers("ControllerRevision", name, opts); err != nil {
+		WriteError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := storage.DefaultStore.DeleteControllerRevision(name); err != nil {
+		WriteError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, deletedObject(storage.DefaultStore.GetControllerRevision, name, cr))
+}
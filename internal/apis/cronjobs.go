@@ -0,0 +1,236 @@
+package apis
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"mockernetes/internal/controllers"
+	"mockernetes/internal/resources" // custom structs for mock control (no batchv1)
+	"mockernetes/internal/storage"
+)
+
+// buildCronJobList wraps store items into K8s list (like daemonsets; uses custom resources.CronJob structs).
+func buildCronJobList(items []interface{}) string {
+	list := map[string]interface{}{
+		"kind":       "CronJobList",
+		"apiVersion": "batch/v1",
+		"metadata":   map[string]string{"resourceVersion": "1"},
+		"items":      items,
+	}
+	b, _ := json.Marshal(list)
+	return string(b)
+}
+
+// ListCronJobs also serves watch=true and filters by fieldSelector.
+func ListCronJobs(c *gin.Context) {
+	fields, err := parseFieldSelector(c.Query("fieldSelector"))
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if c.Query("watch") == "true" {
+		watchObjects(c, "CronJob", storage.DefaultStore.ListCronJobs, fields)
+		return
+	}
+	// ignore :namespace if present (mock simplification like pods)
+	items := filterByFields(storage.DefaultStore.ListCronJobs(), fields)
+	c.Data(http.StatusOK, "application/json", []byte(buildCronJobList(items)))
+}
+
+// GetCronJob handles GET /apis/batch/v1/namespaces/:namespace/cronjobs/:name
+func GetCronJob(c *gin.Context) {
+	cjName := c.Param("name")
+
+	cj, err := storage.DefaultStore.GetCronJob(cjName)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("cronjobs.batch \"%s\" not found", cjName))
+		return
+	}
+
+	c.JSON(http.StatusOK, cj)
+}
+
+// CreateCronJob parses POST to custom resources.CronJob struct (for mock control, no batchv1/scheme).
+// Validates, stores if not exists, and triggers the controller to manage pods.
+func CreateCronJob(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	// Unmarshal to custom struct
+	var cj resources.CronJob
+	if err := json.Unmarshal(body, &cj); err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if cj.Kind == "" {
+		WriteError(c, http.StatusBadRequest, "invalid cronjob")
+		return
+	}
+	// validator for name (or generateName prefix)
+	if !validateCreateName(c, &cj.Metadata) {
+		return
+	}
+	if !validateCronJobSpec(c, cj.Spec) {
+		return
+	}
+	// apply upstream defaults (concurrency policy, history limits, template)
+	resources.SetCronJobDefaults(&cj)
+	if !admitNamespace(c, &cj.Metadata) {
+		return
+	}
+	dryRun, err := isDryRun(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	// dryRun=All: run defaulting/validation only, no storage write or controller hooks
+	if dryRun {
+		dryRunCreate(c, "CronJob", &cj)
+		return
+	}
+	// store; uses KubeObject impl from custom struct
+	if err := storage.DefaultStore.CreateCronJob(&cj); err != nil {
+		WriteError(c, http.StatusConflict, err.Error())
+		return
+	}
+
+	// Return the stored CronJob with any status updates
+	storedCronJob, err := storage.DefaultStore.GetCronJob(cj.GetName())
+	if err != nil {
+		c.JSON(http.StatusCreated, cj)
+		return
+	}
+	c.JSON(http.StatusCreated, storedCronJob)
+}
+
+// UpdateCronJob handles PUT /apis/batch/v1/namespaces/:namespace/cronjobs/:name
+func UpdateCronJob(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	var cj resources.CronJob
+	if err := json.Unmarshal(body, &cj); err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	replaceCronJob(c, cj)
+}
+
+// PatchCronJob handles PATCH /apis/batch/v1/namespaces/:namespace/cronjobs/:name
+func PatchCronJob(c *gin.Context) {
+	cjName := c.Param("name")
+	existing, err := storage.DefaultStore.GetCronJob(cjName)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("cronjobs.batch \"%s\" not found", cjName))
+		return
+	}
+	var cj resources.CronJob
+	if !readPatchedObject(c, existing, &cj) {
+		return
+	}
+	replaceCronJob(c, cj)
+}
+
+// replaceCronJob runs the update pipeline shared by PUT and PATCH.
+func replaceCronJob(c *gin.Context, cj resources.CronJob) {
+	existing, err := storage.DefaultStore.GetCronJob(c.Param("name"))
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("cronjobs.batch \"%s\" not found", c.Param("name")))
+		return
+	}
+	if cj.Kind == "" {
+		WriteError(c, http.StatusBadRequest, "invalid cronjob")
+		return
+	}
+	if !checkUpdateName(c, cj.GetName()) {
+		return
+	}
+	if !validateCronJobSpec(c, cj.Spec) {
+		return
+	}
+	resources.SetCronJobDefaults(&cj)
+	preserveMetadata(&cj.Metadata, existing)
+	// status is owned by the controller (status subresource), not the main resource
+	cj.Status = existing["status"]
+
+	dryRun, err := isDryRun(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if dryRun {
+		dryRunUpdate(c, "CronJob", &cj)
+		return
+	}
+
+	stored, err := storage.DefaultStore.UpdateObject("CronJob", &cj)
+	if err != nil {
+		WriteError(c, http.StatusConflict, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, stored)
+}
+
+// DeleteCronJob handles DELETE /apis/batch/v1/namespaces/:namespace/cronjobs/:name
+func DeleteCronJob(c *gin.Context) {
+	cjName := c.Param("name")
+
+	opts, err := parseDeleteOptions(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Check if CronJob exists
+	cj, err := storage.DefaultStore.GetCronJob(cjName)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("cronjobs.batch \"%s\" not found", cjName))
+		return
+	}
+	if isDeleteDryRun(opts) {
+		c.JSON(http.StatusOK, cj)
+		return
+	}
+
+	// Delete the CronJob
+	if err := setPropagationFinalizers("CronJob", cjName, opts); err != nil {
+		WriteError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := storage.DefaultStore.DeleteCronJob(cjName); err != nil {
+		WriteError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, deletedObject(storage.DefaultStore.GetCronJob, cjName, cj))
+}
+
+// validateCronJobSpec checks the schedule, time zone and the job template's restartPolicy
+func validateCronJobSpec(c *gin.Context, spec interface{}) bool {
+	specMap, _ := spec.(map[string]interface{})
+	schedule, _ := resources.NestedString(specMap, "schedule")
+	if schedule == "" {
+		WriteError(c, http.StatusUnprocessableEntity, "spec.schedule: Required value")
+		return false
+	}
+	if _, err := controllers.ParseSchedule(schedule); err != nil {
+		WriteError(c, http.StatusUnprocessableEntity, fmt.Sprintf("spec.schedule: Invalid value: %q: %v", schedule, err))
+		return false
+	}
+	if tz, ok := resources.NestedString(specMap, "timeZone"); ok {
+		if _, err := time.LoadLocation(tz); err != nil || tz == "" {
+			WriteError(c, http.StatusUnprocessableEntity, fmt.Sprintf("spec.timeZone: Invalid value: %q: unknown time zone", tz))
+			return false
+		}
+	}
+	jobSpec, _ := resources.NestedMap(specMap, "jobTemplate", "spec")
+	return validateJobRestartPolicy(c, jobSpec, "spec.jobTemplate.spec.template.spec.restartPolicy")
+}
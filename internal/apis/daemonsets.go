@@ -0,0 +1,206 @@
+package apis
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"mockernetes/internal/resources" // custom structs for mock control (no appsv1)
+	"mockernetes/internal/storage"
+)
+
+// buildDaemonSetList wraps store items into K8s list (like statefulsets; uses custom resources.DaemonSet structs).
+func buildDaemonSetList(items []interface{}) string {
+	list := map[string]interface{}{
+		"kind":       "DaemonSetList",
+		"apiVersion": "apps/v1",
+		"metadata":   map[string]string{"resourceVersion": "1"},
+		"items":      items,
+	}
+	b, _ := json.Marshal(list)
+	return string(b)
+}
+
+// ListDaemonSets also serves watch=true and filters by fieldSelector (kubectl rollout status).
+func ListDaemonSets(c *gin.Context) {
+	fields, err := parseFieldSelector(c.Query("fieldSelector"))
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if c.Query("watch") == "true" {
+		watchObjects(c, "DaemonSet", storage.DefaultStore.ListDaemonSets, fields)
+		return
+	}
+	// ignore :namespace if present (mock simplification like pods)
+	items := filterByFields(storage.DefaultStore.ListDaemonSets(), fields)
+	c.Data(http.StatusOK, "application/json", []byte(buildDaemonSetList(items)))
+}
+
+// GetDaemonSet handles GET /apis/apps/v1/namespaces/:namespace/daemonsets/:name
+func GetDaemonSet(c *gin.Context) {
+	dsName := c.Param("name")
+
+	ds, err := storage.DefaultStore.GetDaemonSet(dsName)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("daemonsets.apps \"%s\" not found", dsName))
+		return
+	}
+
+	c.JSON(http.StatusOK, ds)
+}
+
+// CreateDaemonSet parses POST to custom resources.DaemonSet struct (for mock control, no appsv1/scheme).
+// Validates, stores if not exists, and triggers the controller to manage pods.
+func CreateDaemonSet(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	// Unmarshal to custom struct
+	var ds resources.DaemonSet
+	if err := json.Unmarshal(body, &ds); err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if ds.Kind == "" {
+		WriteError(c, http.StatusBadRequest, "invalid daemonset")
+		return
+	}
+	// apply upstream defaults (update strategy, history, template)
+	resources.SetDaemonSetDefaults(&ds)
+	// validator for name (or generateName prefix)
+	if !validateCreateName(c, &ds.Metadata) {
+		return
+	}
+	if !admitNamespace(c, &ds.Metadata) {
+		return
+	}
+	dryRun, err := isDryRun(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	// dryRun=All: run defaulting/validation only, no storage write or controller hooks
+	if dryRun {
+		dryRunCreate(c, "DaemonSet", &ds)
+		return
+	}
+	// store; uses KubeObject impl from custom struct
+	if err := storage.DefaultStore.CreateDaemonSet(&ds); err != nil {
+		WriteError(c, http.StatusConflict, err.Error())
+		return
+	}
+
+	// Return the stored DaemonSet with any status updates
+	storedDS, err := storage.DefaultStore.GetDaemonSet(ds.GetName())
+	if err != nil {
+		c.JSON(http.StatusCreated, ds)
+		return
+	}
+	c.JSON(http.StatusCreated, storedDS)
+}
+
+// UpdateDaemonSet handles PUT /apis/apps/v1/namespaces/:namespace/daemonsets/:name
+func UpdateDaemonSet(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	var ds resources.DaemonSet
+	if err := json.Unmarshal(body, &ds); err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	replaceDaemonSet(c, ds)
+}
+
+// PatchDaemonSet handles PATCH /apis/apps/v1/namespaces/:namespace/daemonsets/:name
+func PatchDaemonSet(c *gin.Context) {
+	dsName := c.Param("name")
+	existing, err := storage.DefaultStore.GetDaemonSet(dsName)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("daemonsets.apps \"%s\" not found", dsName))
+		return
+	}
+	var ds resources.DaemonSet
+	if !readPatchedObject(c, existing, &ds) {
+		return
+	}
+	replaceDaemonSet(c, ds)
+}
+
+// replaceDaemonSet runs the update pipeline shared by PUT and PATCH.
+func replaceDaemonSet(c *gin.Context, ds resources.DaemonSet) {
+	existing, err := storage.DefaultStore.GetDaemonSet(c.Param("name"))
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("daemonsets.apps \"%s\" not found", c.Param("name")))
+		return
+	}
+	if ds.Kind == "" {
+		WriteError(c, http.StatusBadRequest, "invalid daemonset")
+		return
+	}
+	if !checkUpdateName(c, ds.GetName()) {
+		return
+	}
+	resources.SetDaemonSetDefaults(&ds)
+	preserveMetadata(&ds.Metadata, existing)
+	// status is owned by the controller (status subresource), not the main resource
+	ds.Status = existing["status"]
+
+	dryRun, err := isDryRun(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if dryRun {
+		dryRunUpdate(c, "DaemonSet", &ds)
+		return
+	}
+
+	stored, err := storage.DefaultStore.UpdateObject("DaemonSet", &ds)
+	if err != nil {
+		WriteError(c, http.StatusConflict, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, stored)
+}
+
+// DeleteDaemonSet handles DELETE /apis/apps/v1/namespaces/:namespace/daemonsets/:name
+func DeleteDaemonSet(c *gin.Context) {
+	dsName := c.Param("name")
+
+	opts, err := parseDeleteOptions(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Check if DaemonSet exists
+	ds, err := storage.DefaultStore.GetDaemonSet(dsName)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("daemonsets.apps \"%s\" not found", dsName))
+		return
+	}
+	if isDeleteDryRun(opts) {
+		c.JSON(http.StatusOK, ds)
+		return
+	}
+
+	// Delete the DaemonSet
+	if err := setPropagationFinalizers("DaemonSet", dsName, opts); err != nil {
+		WriteError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := storage.DefaultStore.DeleteDaemonSet(dsName); err != nil {
+		WriteError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, deletedObject(storage.DefaultStore.GetDaemonSet, dsName, ds))
+}
@@ -2,12 +2,11 @@ package apis
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"k8s.io/apimachinery/pkg/util/validation"
-	"mockernetes/internal/controllers"
 	"mockernetes/internal/resources" // custom structs for mock control (no appsv1)
 	"mockernetes/internal/storage"
 )
@@ -24,9 +23,19 @@ func buildDeploymentList(items []interface{}) string {
 	return string(b)
 }
 
+// ListDeployments also serves watch=true and filters by fieldSelector (kubectl rollout status).
 func ListDeployments(c *gin.Context) {
+	fields, err := parseFieldSelector(c.Query("fieldSelector"))
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if c.Query("watch") == "true" {
+		watchObjects(c, "Deployment", storage.DefaultStore.ListDeployments, fields)
+		return
+	}
 	// ignore :namespace if present (mock simplification like pods)
-	items := storage.DefaultStore.ListDeployments()
+	items := filterByFields(storage.DefaultStore.ListDeployments(), fields)
 	c.Data(http.StatusOK, "application/json", []byte(buildDeploymentList(items)))
 }
 
@@ -48,25 +57,31 @@ func CreateDeployment(c *gin.Context) {
 		WriteError(c, http.StatusBadRequest, "invalid deployment")
 		return
 	}
-	// basic name validation (DNS label like pods)
-	if errs := validation.IsDNS1123Label(deploy.GetName()); len(errs) != 0 {
-		WriteError(c, http.StatusBadRequest, errs[0])
+	// apply upstream defaults (replicas, strategy, revisionHistoryLimit, pod template, ...)
+	resources.SetDeploymentDefaults(&deploy)
+	// validator for name (or generateName prefix)
+	if !validateCreateName(c, &deploy.Metadata) {
+		return
+	}
+	if !admitNamespace(c, &deploy.Metadata) {
+		return
+	}
+	dryRun, err := isDryRun(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	// dryRun=All: run defaulting/validation only, no storage write or controller hooks
+	if dryRun {
+		dryRunCreate(c, "Deployment", &deploy)
 		return
 	}
 	// store; uses KubeObject impl from custom struct
-	if err := storage.DefaultStore.CreateDeployment(deploy); err != nil {
+	if err := storage.DefaultStore.CreateDeployment(&deploy); err != nil {
 		WriteError(c, http.StatusConflict, err.Error())
 		return
 	}
 
-	// Trigger deployment controller for immediate reconciliation
-	if controllers.DefaultDeploymentController != nil {
-		if err := controllers.DefaultDeploymentController.OnDeploymentCreated(deploy); err != nil {
-			// Log error but don't fail the creation - the deployment is already stored
-			_ = err
-		}
-	}
-
 	// Return the deployment with status from storage
 	storedDeploy, err := storage.DefaultStore.GetDeployment(deploy.GetName())
 	if err != nil {
@@ -76,3 +91,117 @@ func CreateDeployment(c *gin.Context) {
 	}
 	c.JSON(http.StatusCreated, storedDeploy)
 }
+
+// GetDeployment handles GET /apis/apps/v1/namespaces/:namespace/deployments/:name
+func GetDeployment(c *gin.Context) {
+	deployName := c.Param("name")
+
+	deploy, err := storage.DefaultStore.GetDeployment(deployName)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("deployments.apps \"%s\" not found", deployName))
+		return
+	}
+
+	c.JSON(http.StatusOK, deploy)
+}
+
+// UpdateDeployment handles PUT /apis/apps/v1/namespaces/:namespace/deployments/:name
+func UpdateDeployment(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	var deploy resources.Deployment
+	if err := json.Unmarshal(body, &deploy); err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	replaceDeployment(c, deploy)
+}
+
+// PatchDeployment handles PATCH /apis/apps/v1/namespaces/:namespace/deployments/:name
+// (json, merge and strategic merge patches; used by kubectl apply/scale/rollout).
+func PatchDeployment(c *gin.Context) {
+	deployName := c.Param("name")
+	existing, err := storage.DefaultStore.GetDeployment(deployName)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("deployments.apps \"%s\" not found", deployName))
+		return
+	}
+	var deploy resources.Deployment
+	if !readPatchedObject(c, existing, &deploy) {
+		return
+	}
+	replaceDeployment(c, deploy)
+}
+
+// replaceDeployment runs the update pipeline shared by PUT and PATCH:
+// defaulting, validation, dryRun, storage write and controller notification.
+func replaceDeployment(c *gin.Context, deploy resources.Deployment) {
+	existing, err := storage.DefaultStore.GetDeployment(c.Param("name"))
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("deployments.apps \"%s\" not found", c.Param("name")))
+		return
+	}
+	if deploy.Kind == "" {
+		WriteError(c, http.StatusBadRequest, "invalid deployment")
+		return
+	}
+	if !checkUpdateName(c, deploy.GetName()) {
+		return
+	}
+	resources.SetDeploymentDefaults(&deploy)
+	preserveMetadata(&deploy.Metadata, existing)
+	// status is owned by the controller (status subresource), not the main resource
+	deploy.Status = existing["status"]
+
+	dryRun, err := isDryRun(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if dryRun {
+		dryRunUpdate(c, "Deployment", &deploy)
+		return
+	}
+
+	stored, err := storage.DefaultStore.UpdateObject("Deployment", &deploy)
+	if err != nil {
+		WriteError(c, http.StatusConflict, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, stored)
+}
+
+// DeleteDeployment handles DELETE /apis/apps/v1/namespaces/:namespace/deployments/:name
+func DeleteDeployment(c *gin.Context) {
+	deployName := c.Param("name")
+
+	opts, err := parseDeleteOptions(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	deploy, err := storage.DefaultStore.GetDeployment(deployName)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("deployments.apps \"%s\" not found", deployName))
+		return
+	}
+	if isDeleteDryRun(opts) {
+		c.JSON(http.StatusOK, deploy)
+		return
+	}
+
+	if err := setPropagationFinalizers("Deployment", deployName, opts); err != nil {
+		WriteError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := storage.DefaultStore.DeleteDeployment(deployName); err != nil {
+		WriteError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, deletedObject(storage.DefaultStore.GetDeployment, deployName, deploy))
+}
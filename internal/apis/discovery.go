@@ -9,15 +9,24 @@ import (
 // Discovery responses (hardcoded valid K8s shapes)
 const (
 	apiJSON  = `{"kind":"APIVersions","versions":["v1"]}`
-	apisJSON = `{"kind":"APIGroupList","groups":[{"name":"apps","versions":[{"groupVersion":"apps/v1","version":"v1"}],"preferredVersion":{"groupVersion":"apps/v1","version":"v1"}}]}`
+	apisJSON = `{"kind":"APIGroupList","groups":[{"name":"apps","versions":[{"groupVersion":"apps/v1","version":"v1"}],"preferredVersion":{"groupVersion":"apps/v1","version":"v1"}},{"name":"batch","versions":[{"groupVersion":"batch/v1","version":"v1"}],"preferredVersion":{"groupVersion":"batch/v1","version":"v1"}},{"name":"coordination.k8s.io","versions":[{"groupVersion":"coordination.k8s.io/v1","version":"v1"}],"preferredVersion":{"groupVersion":"coordination.k8s.io/v1","version":"v1"}},{"name":"discovery.k8s.io","versions":[{"groupVersion":"discovery.k8s.io/v1","version":"v1"}],"preferredVersion":{"groupVersion":"discovery.k8s.io/v1","version":"v1"}}]}`
 	// namespaces with canonical form + shortNames["ns"] for kubectl get ns; plus common resources
-	apiV1JSON = `{"kind":"APIResourceList","groupVersion":"v1","resources":[{"name":"namespaces","singularName":"namespace","namespaced":false,"kind":"Namespace","verbs":["create","delete","get","list","patch","update","watch"],"shortNames":["ns"],"categories":["all"]},{"name":"pods","singularName":"pod","namespaced":true,"kind":"Pod","verbs":["get","list"],"shortNames":["po"]},{"name":"configmaps","singularName":"configmap","namespaced":true,"kind":"ConfigMap","verbs":["get","list"],"shortNames":["cm"]}]}`
+	apiV1JSON = `{"kind":"APIResourceList","groupVersion":"v1","resources":[{"name":"namespaces","singularName":"namespace","namespaced":false,"kind":"Namespace","verbs":["create","delete","get","list","patch","update","watch"],"shortNames":["ns"],"categories":["all"]},{"name":"pods","singularName":"pod","namespaced":true,"kind":"Pod","verbs":["create","delete","get","list","patch","update","watch"],"shortNames":["po"]},{"name":"configmaps","singularName":"configmap","namespaced":true,"kind":"ConfigMap","verbs":["create","delete","get","list","patch","update"],"shortNames":["cm"]},{"name":"persistentvolumeclaims","singularName":"persistentvolumeclaim","namespaced":true,"kind":"PersistentVolumeClaim","verbs":["create","delete","get","list","patch","update"],"shortNames":["pvc"]},{"name":"nodes","singularName":"node","namespaced":false,"kind":"Node","verbs":["create","delete","get","list","patch","update","watch"],"shortNames":["no"]},{"name":"events","singularName":"event","namespaced":true,"kind":"Event","verbs":["create","delete","get","list","watch"],"shortNames":["ev"]},{"name":"services","singularName":"service","namespaced":true,"kind":"Service","verbs":["create","delete","get","list","patch","update","watch"],"shortNames":["svc"],"categories":["all"]}]}`
 
-	// apps/v1 resources (deployments + replicasets; expanded for full kubectl discovery compat.
+	// apps/v1 resources (deployments, replicasets, statefulsets, daemonsets and their controllerrevisions; expanded for full kubectl discovery compat.
 	// shortNames, verbs mirror pods/cm; enables `kubectl get deploy,rs` without errors.
 	// Uses custom struct JSON shapes from k8s pkg for mock control.
 	// Note: lists create verbs too for POST support, though mock focuses list/create like pods).
-	appsV1JSON = `{"kind":"APIResourceList","groupVersion":"apps/v1","resources":[{"name":"deployments","singularName":"deployment","namespaced":true,"kind":"Deployment","verbs":["create","get","list"],"shortNames":["deploy"]},{"name":"replicasets","singularName":"replicaset","namespaced":true,"kind":"ReplicaSet","verbs":["create","get","list"],"shortNames":["rs"]}]}`
+	appsV1JSON = `{"kind":"APIResourceList","groupVersion":"apps/v1","resources":[{"name":"deployments","singularName":"deployment","namespaced":true,"kind":"Deployment","verbs":["create","delete","get","list","patch","update","watch"],"shortNames":["deploy"]},{"name":"replicasets","singularName":"replicaset","namespaced":true,"kind":"ReplicaSet","verbs":["create","delete","get","list","patch","update","watch"],"shortNames":["rs"]},{"name":"statefulsets","singularName":"statefulset","namespaced":true,"kind":"StatefulSet","verbs":["create","delete","get","list","patch","update","watch"],"shortNames":["sts"]},{"name":"controllerrevisions","singularName":"controllerrevision","namespaced":true,"kind":"ControllerRevision","verbs":["delete","get","list"]},{"name":"daemonsets","singularName":"daemonset","namespaced":true,"kind":"DaemonSet","verbs":["create","delete","get","list","patch","update","watch"],"shortNames":["ds"]}]}`
+
+	// batch/v1 resources (jobs run pods to completion, cronjobs create jobs on a schedule)
+	batchV1JSON = `{"kind":"APIResourceList","groupVersion":"batch/v1","resources":[{"name":"jobs","singularName":"job","namespaced":true,"kind":"Job","verbs":["create","delete","get","list","patch","update","watch"],"categories":["all"]},{"name":"cronjobs","singularName":"cronjob","namespaced":true,"kind":"CronJob","verbs":["create","delete","get","list","patch","update","watch"],"shortNames":["cj"],"categories":["all"]}]}`
+
+	// coordination.k8s.io/v1 resources (node heartbeat leases in kube-node-lease)
+	coordinationV1JSON = `{"kind":"APIResourceList","groupVersion":"coordination.k8s.io/v1","resources":[{"name":"leases","singularName":"lease","namespaced":true,"kind":"Lease","verbs":["create","delete","get","list","update","watch"]}]}`
+
+	// discovery.k8s.io/v1 resources (endpointslices of Services, kept by the endpointslice controller)
+	discoveryV1JSON = `{"kind":"APIResourceList","groupVersion":"discovery.k8s.io/v1","resources":[{"name":"endpointslices","singularName":"endpointslice","namespaced":true,"kind":"EndpointSlice","verbs":["create","delete","get","list","patch","update","watch"]}]}`
 )
 
 func APIHandler(c *gin.Context) {
@@ -35,3 +44,15 @@ func APIV1Handler(c *gin.Context) {
 func AppsV1Handler(c *gin.Context) {
 	c.Data(http.StatusOK, "application/json", []byte(appsV1JSON))
 }
+
+func BatchV1Handler(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json", []byte(batchV1JSON))
+}
+
+func CoordinationV1Handler(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json", []byte(coordinationV1JSON))
+}
+
+func DiscoveryV1Handler(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json", []byte(discoveryV1JSON))
+}
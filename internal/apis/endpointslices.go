@@ -0,0 +1,219 @@
+package apis
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"mockernetes/internal/resources" // custom structs for mock control (no discoveryv1)
+	"mockernetes/internal/storage"
+)
+
+// EndpointSlices of Services with a selector are kept by the endpointslice controller (labeled
+// endpointslice.kubernetes.io/managed-by); clients may add their own for selector-less Services.
+
+// buildEndpointSliceList wraps store items into K8s list (like services; uses custom resources.EndpointSlice).
+func buildEndpointSliceList(items []interface{}) string {
+	list := map[string]interface{}{
+		"kind":       "EndpointSliceList",
+		"apiVersion": "discovery.k8s.io/v1",
+		"metadata":   map[string]string{"resourceVersion": "1"},
+		"items":      items,
+	}
+	b, _ := json.Marshal(list)
+	return string(b)
+}
+
+// ListEndpointSlices also serves watch=true and filters by fieldSelector and the URL namespace.
+func ListEndpointSlices(c *gin.Context) {
+	fields, err := parseFieldSelector(c.Query("fieldSelector"))
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if c.Query("watch") == "true" {
+		watchObjects(c, "EndpointSlice", storage.DefaultStore.ListEndpointSlices, fields)
+		return
+	}
+	namespace := c.Param("namespace")
+	items := []interface{}{}
+	for _, item := range filterByFields(storage.DefaultStore.ListEndpointSlices(), fields) {
+		if obj, ok := item.(map[string]interface{}); ok && inNamespace(obj, namespace) {
+			items = append(items, item)
+		}
+	}
+	c.Data(http.StatusOK, "application/json", []byte(buildEndpointSliceList(items)))
+}
+
+// GetEndpointSlice handles GET /apis/discovery.k8s.io/v1/namespaces/:namespace/slices/:name
+func GetEndpointSlice(c *gin.Context) {
+	sliceName := c.Param("name")
+
+	slice, err := storage.DefaultStore.GetEndpointSlice(sliceName)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("endpointslices.discovery.k8s.io \"%s\" not found", sliceName))
+		return
+	}
+
+	c.JSON(http.StatusOK, slice)
+}
+
+// CreateEndpointSlice parses POST to custom resources.EndpointSlice struct (for mock control, no discoveryv1/scheme).
+func CreateEndpointSlice(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	var slice resources.EndpointSlice
+	if err := json.Unmarshal(body, &slice); err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if slice.Kind == "" {
+		WriteError(c, http.StatusBadRequest, "invalid endpointslice")
+		return
+	}
+	if !validateAddressType(c, slice.AddressType) {
+		return
+	}
+	// validator for name (or generateName prefix)
+	if !validateCreateName(c, &slice.Metadata) {
+		return
+	}
+	if !admitNamespace(c, &slice.Metadata) {
+		return
+	}
+	dryRun, err := isDryRun(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	// dryRun=All: run defaulting/validation only, no storage write
+	if dryRun {
+		dryRunCreate(c, "EndpointSlice", &slice)
+		return
+	}
+	if err := storage.DefaultStore.CreateEndpointSlice(&slice); err != nil {
+		WriteError(c, http.StatusConflict, err.Error())
+		return
+	}
+
+	storedEndpointSlice, err := storage.DefaultStore.GetEndpointSlice(slice.GetName())
+	if err != nil {
+		c.JSON(http.StatusCreated, slice)
+		return
+	}
+	c.JSON(http.StatusCreated, storedEndpointSlice)
+}
+
+// UpdateEndpointSlice handles PUT /apis/discovery.k8s.io/v1/namespaces/:namespace/endpointslices/:name
+func UpdateEndpointSlice(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	var slice resources.EndpointSlice
+	if err := json.Unmarshal(body, &slice); err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	replaceEndpointSlice(c, slice)
+}
+
+// PatchEndpointSlice handles PATCH /apis/discovery.k8s.io/v1/namespaces/:namespace/endpointslices/:name
+func PatchEndpointSlice(c *gin.Context) {
+	sliceName := c.Param("name")
+	existing, err := storage.DefaultStore.GetEndpointSlice(sliceName)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("endpointslices.discovery.k8s.io \"%s\" not found", sliceName))
+		return
+	}
+	var slice resources.EndpointSlice
+	if !readPatchedObject(c, existing, &slice) {
+		return
+	}
+	replaceEndpointSlice(c, slice)
+}
+
+// replaceEndpointSlice runs the update pipeline shared by PUT and PATCH (addressType is immutable).
+func replaceEndpointSlice(c *gin.Context, slice resources.EndpointSlice) {
+	existing, err := storage.DefaultStore.GetEndpointSlice(c.Param("name"))
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("endpointslices.discovery.k8s.io \"%s\" not found", c.Param("name")))
+		return
+	}
+	if slice.Kind == "" {
+		WriteError(c, http.StatusBadRequest, "invalid endpointslice")
+		return
+	}
+	if !checkUpdateName(c, slice.GetName()) {
+		return
+	}
+	if slice.AddressType != existing["addressType"] {
+		WriteError(c, http.StatusUnprocessableEntity, fmt.Sprintf("addressType: Invalid value: %q: field is immutable", slice.AddressType))
+		return
+	}
+	preserveMetadata(&slice.Metadata, existing)
+
+	dryRun, err := isDryRun(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if dryRun {
+		dryRunUpdate(c, "EndpointSlice", &slice)
+		return
+	}
+
+	stored, err := storage.DefaultStore.UpdateObject("EndpointSlice", &slice)
+	if err != nil {
+		WriteError(c, http.StatusConflict, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, stored)
+}
+
+// DeleteEndpointSlice handles DELETE /apis/discovery.k8s.io/v1/namespaces/:namespace/slices/:name
+func DeleteEndpointSlice(c *gin.Context) {
+	sliceName := c.Param("name")
+
+	opts, err := parseDeleteOptions(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	slice, err := storage.DefaultStore.GetEndpointSlice(sliceName)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("endpointslices.discovery.k8s.io \"%s\" not found", sliceName))
+		return
+	}
+	if isDeleteDryRun(opts) {
+		c.JSON(http.StatusOK, slice)
+		return
+	}
+
+	if err := storage.DefaultStore.DeleteEndpointSlice(sliceName); err != nil {
+		WriteError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, deletedObject(storage.DefaultStore.GetEndpointSlice, sliceName, slice))
+}
+
+// validateAddressType rejects EndpointSlice address types other than IPv4, IPv6 and FQDN.
+func validateAddressType(c *gin.Context, addressType string) bool {
+	switch addressType {
+	case "IPv4", "IPv6", "FQDN":
+		return true
+	case "":
+		WriteError(c, http.StatusUnprocessableEntity, "addressType: Required value")
+	default:
+		WriteError(c, http.StatusUnprocessableEntity, fmt.Sprintf(`addressType: Unsupported value: %q: supported values: "FQDN", "IPv4", "IPv6"`, addressType))
+	}
+	return false
+}
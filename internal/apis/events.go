@@ -0,0 +1,169 @@
+package apis
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"mockernetes/internal/resources" // custom structs for mock control (no corev1)
+	"mockernetes/internal/storage"
+)
+
+// Events are written by the scheduler and controllers (and by clients that report their own);
+// kubectl describe lists the ones about an object through an involvedObject fieldSelector.
+
+// eventFields are the field labels Events support besides metadata.name/namespace.
+var eventFields = map[string][]string{
+	"involvedObject.kind":       {"involvedObject", "kind"},
+	"involvedObject.namespace":  {"involvedObject", "namespace"},
+	"involvedObject.name":       {"involvedObject", "name"},
+	"involvedObject.uid":        {"involvedObject", "uid"},
+	"involvedObject.apiVersion": {"involvedObject", "apiVersion"},
+	"involvedObject.fieldPath":  {"involvedObject", "fieldPath"},
+	"reason":                    {"reason"},
+	"reportingComponent":        {"reportingComponent"},
+	"source":                    {"source", "component"},
+	"type":                      {"type"},
+}
+
+// buildEventList wraps store items into K8s list (like configmaps; uses custom resources.Event).
+func buildEventList(items []interface{}) string {
+	list := map[string]interface{}{
+		"kind":       "EventList",
+		"apiVersion": "v1",
+		"metadata":   map[string]string{"resourceVersion": "1"},
+		"items":      items,
+	}
+	b, _ := json.Marshal(list)
+	return string(b)
+}
+
+// ListEvents also serves watch=true (kubectl get events -w) and filters by fieldSelector.
+func ListEvents(c *gin.Context) {
+	fields, err := parseFieldSelector(c.Query("fieldSelector"), eventFields)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if c.Query("watch") == "true" {
+		watchObjects(c, "Event", storage.DefaultStore.ListEvents, fields)
+		return
+	}
+	namespace := c.Param("namespace")
+	var items []interface{}
+	for _, item := range filterByFields(storage.DefaultStore.ListEvents(), fields) {
+		if obj, ok := item.(map[string]interface{}); ok && inNamespace(obj, namespace) {
+			items = append(items, item)
+		}
+	}
+	if items == nil {
+		items = []interface{}{}
+	}
+	c.Data(http.StatusOK, "application/json", []byte(buildEventList(items)))
+}
+
+// GetEvent handles GET /api/v1/namespaces/:namespace/events/:name
+func GetEvent(c *gin.Context) {
+	eventName := c.Param("name")
+
+	event, err := storage.DefaultStore.GetEvent(eventName)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("events \"%s\" not found", eventName))
+		return
+	}
+
+	c.JSON(http.StatusOK, event)
+}
+
+// CreateEvent parses POST to custom resources.Event struct (for mock control, no corev1/scheme).
+// An Event without timestamps is stamped with the time it was received.
+func CreateEvent(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	var event resources.Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if event.Kind == "" {
+		WriteError(c, http.StatusBadRequest, "invalid event")
+		return
+	}
+	// validator for name (or generateName prefix)
+	if !validateCreateName(c, &event.Metadata) {
+		return
+	}
+	if !admitNamespace(c, &event.Metadata) {
+		return
+	}
+	if event.InvolvedObject.Namespace != "" && event.InvolvedObject.Namespace != event.Metadata.Namespace {
+		WriteError(c, http.StatusUnprocessableEntity, fmt.Sprintf("Event \"%s\" is invalid: involvedObject.namespace: Invalid value: %q: does not match event.namespace",
+			event.Metadata.Name, event.InvolvedObject.Namespace))
+		return
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	if event.FirstTimestamp == "" {
+		event.FirstTimestamp = now
+	}
+	if event.LastTimestamp == "" {
+		event.LastTimestamp = event.FirstTimestamp
+	}
+	if event.Count == 0 {
+		event.Count = 1
+	}
+	dryRun, err := isDryRun(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	// dryRun=All: run defaulting/validation only, no storage write
+	if dryRun {
+		dryRunCreate(c, "Event", &event)
+		return
+	}
+	if err := storage.DefaultStore.CreateEvent(&event); err != nil {
+		WriteError(c, http.StatusConflict, err.Error())
+		return
+	}
+
+	storedEvent, err := storage.DefaultStore.GetEvent(event.GetName())
+	if err != nil {
+		c.JSON(http.StatusCreated, event)
+		return
+	}
+	c.JSON(http.StatusCreated, storedEvent)
+}
+
+// DeleteEvent handles DELETE /api/v1/namespaces/:namespace/events/:name
+func DeleteEvent(c *gin.Context) {
+	eventName := c.Param("name")
+
+	opts, err := parseDeleteOptions(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	event, err := storage.DefaultStore.GetEvent(eventName)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("events \"%s\" not found", eventName))
+		return
+	}
+	if isDeleteDryRun(opts) {
+		c.JSON(http.StatusOK, event)
+		return
+	}
+
+	if err := storage.DefaultStore.DeleteEvent(eventName); err != nil {
+		WriteError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, deletedObject(storage.DefaultStore.GetEvent, eventName, event))
+}
@@ -0,0 +1,233 @@
+package apis
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"mockernetes/internal/resources" // custom structs for mock control (no batchv1)
+	"mockernetes/internal/storage"
+)
+
+// buildJobList wraps store items into K8s list (like daemonsets; uses custom resources.Job structs).
+func buildJobList(items []interface{}) string {
+	list := map[string]interface{}{
+		"kind":       "JobList",
+		"apiVersion": "batch/v1",
+		"metadata":   map[string]string{"resourceVersion": "1"},
+		"items":      items,
+	}
+	b, _ := json.Marshal(list)
+	return string(b)
+}
+
+// ListJobs also serves watch=true and filters by fieldSelector (kubectl wait --for=condition=complete).
+func ListJobs(c *gin.Context) {
+	fields, err := parseFieldSelector(c.Query("fieldSelector"))
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if c.Query("watch") == "true" {
+		watchObjects(c, "Job", storage.DefaultStore.ListJobs, fields)
+		return
+	}
+	// ignore :namespace if present (mock simplification like pods)
+	items := filterByFields(storage.DefaultStore.ListJobs(), fields)
+	c.Data(http.StatusOK, "application/json", []byte(buildJobList(items)))
+}
+
+// GetJob handles GET /apis/batch/v1/namespaces/:namespace/jobs/:name
+func GetJob(c *gin.Context) {
+	jobName := c.Param("name")
+
+	job, err := storage.DefaultStore.GetJob(jobName)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("jobs.batch \"%s\" not found", jobName))
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// CreateJob parses POST to custom resources.Job struct (for mock control, no batchv1/scheme).
+// Validates, stores if not exists, and triggers the controller to manage pods.
+func CreateJob(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	// Unmarshal to custom struct
+	var job resources.Job
+	if err := json.Unmarshal(body, &job); err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if job.Kind == "" {
+		WriteError(c, http.StatusBadRequest, "invalid job")
+		return
+	}
+	// validator for name (or generateName prefix)
+	if !validateCreateName(c, &job.Metadata) {
+		return
+	}
+	if !validateJobRestartPolicy(c, job.Spec, "spec.template.spec.restartPolicy") {
+		return
+	}
+	// the generated selector and pod labels need the uid and name up front
+	job.Metadata.UID = storage.NewUID()
+	if job.Metadata.Name == "" {
+		job.Metadata.Name = storage.GenerateName(job.Metadata.GenerateName)
+	}
+	// apply upstream defaults (completions, backoff limit, completion mode, selector)
+	resources.SetJobDefaults(&job)
+	if !admitNamespace(c, &job.Metadata) {
+		return
+	}
+	dryRun, err := isDryRun(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	// dryRun=All: run defaulting/validation only, no storage write or controller hooks
+	if dryRun {
+		dryRunCreate(c, "Job", &job)
+		return
+	}
+	// store; uses KubeObject impl from custom struct
+	if err := storage.DefaultStore.CreateJob(&job); err != nil {
+		WriteError(c, http.StatusConflict, err.Error())
+		return
+	}
+
+	// Return the stored Job with any status updates
+	storedJob, err := storage.DefaultStore.GetJob(job.GetName())
+	if err != nil {
+		c.JSON(http.StatusCreated, job)
+		return
+	}
+	c.JSON(http.StatusCreated, storedJob)
+}
+
+// UpdateJob handles PUT /apis/batch/v1/namespaces/:namespace/jobs/:name
+func UpdateJob(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	var job resources.Job
+	if err := json.Unmarshal(body, &job); err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	replaceJob(c, job)
+}
+
+// PatchJob handles PATCH /apis/batch/v1/namespaces/:namespace/jobs/:name
+func PatchJob(c *gin.Context) {
+	jobName := c.Param("name")
+	existing, err := storage.DefaultStore.GetJob(jobName)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("jobs.batch \"%s\" not found", jobName))
+		return
+	}
+	var job resources.Job
+	if !readPatchedObject(c, existing, &job) {
+		return
+	}
+	replaceJob(c, job)
+}
+
+// replaceJob runs the update pipeline shared by PUT and PATCH.
+func replaceJob(c *gin.Context, job resources.Job) {
+	existing, err := storage.DefaultStore.GetJob(c.Param("name"))
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("jobs.batch \"%s\" not found", c.Param("name")))
+		return
+	}
+	if job.Kind == "" {
+		WriteError(c, http.StatusBadRequest, "invalid job")
+		return
+	}
+	if !checkUpdateName(c, job.GetName()) {
+		return
+	}
+	if !validateJobRestartPolicy(c, job.Spec, "spec.template.spec.restartPolicy") {
+		return
+	}
+	preserveMetadata(&job.Metadata, existing)
+	resources.SetJobDefaults(&job)
+	// status is owned by the controller (status subresource), not the main resource
+	job.Status = existing["status"]
+
+	dryRun, err := isDryRun(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if dryRun {
+		dryRunUpdate(c, "Job", &job)
+		return
+	}
+
+	stored, err := storage.DefaultStore.UpdateObject("Job", &job)
+	if err != nil {
+		WriteError(c, http.StatusConflict, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, stored)
+}
+
+// DeleteJob handles DELETE /apis/batch/v1/namespaces/:namespace/jobs/:name
+func DeleteJob(c *gin.Context) {
+	jobName := c.Param("name")
+
+	opts, err := parseDeleteOptions(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Check if Job exists
+	job, err := storage.DefaultStore.GetJob(jobName)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("jobs.batch \"%s\" not found", jobName))
+		return
+	}
+	if isDeleteDryRun(opts) {
+		c.JSON(http.StatusOK, job)
+		return
+	}
+
+	// Delete the Job
+	if err := setPropagationFinalizers("Job", jobName, opts); err != nil {
+		WriteError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := storage.DefaultStore.DeleteJob(jobName); err != nil {
+		WriteError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, deletedObject(storage.DefaultStore.GetJob, jobName, job))
+}
+
+// validateJobRestartPolicy rejects pod templates whose restartPolicy isn't Never or OnFailure
+// (Job pods run to completion). spec is the Job spec, field the path used in the error.
+func validateJobRestartPolicy(c *gin.Context, spec interface{}, field string) bool {
+	specMap, _ := spec.(map[string]interface{})
+	policy, _ := resources.NestedString(specMap, "template", "spec", "restartPolicy")
+	if policy == "Never" || policy == "OnFailure" {
+		return true
+	}
+	if policy == "" {
+		WriteError(c, http.StatusUnprocessableEntity, field+`: Required value: valid values: "OnFailure", "Never"`)
+	} else {
+		WriteError(c, http.StatusUnprocessableEntity, fmt.Sprintf(`%s: Unsupported value: %q: supported values: "OnFailure", "Never"`, field, policy))
+	}
+	return false
+}
@@ -0,0 +1,185 @@
+package apis
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"mockernetes/internal/resources" // custom structs for mock control (no coordinationv1)
+	"mockernetes/internal/storage"
+)
+
+// Leases are renewed by the simulated kubelets (one per Node in kube-node-lease, see the node
+// lifecycle controller); clients may keep their own for leader election.
+
+// buildLeaseList wraps store items into K8s list (like events; uses custom resources.Lease).
+func buildLeaseList(items []interface{}) string {
+	list := map[string]interface{}{
+		"kind":       "LeaseList",
+		"apiVersion": "coordination.k8s.io/v1",
+		"metadata":   map[string]string{"resourceVersion": "1"},
+		"items":      items,
+	}
+	b, _ := json.Marshal(list)
+	return string(b)
+}
+
+// ListLeases also serves watch=true and filters by fieldSelector and the URL namespace.
+func ListLeases(c *gin.Context) {
+	fields, err := parseFieldSelector(c.Query("fieldSelector"))
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if c.Query("watch") == "true" {
+		watchObjects(c, "Lease", storage.DefaultStore.ListLeases, fields)
+		return
+	}
+	namespace := c.Param("namespace")
+	items := []interface{}{}
+	for _, item := range filterByFields(storage.DefaultStore.ListLeases(), fields) {
+		if obj, ok := item.(map[string]interface{}); ok && inNamespace(obj, namespace) {
+			items = append(items, item)
+		}
+	}
+	c.Data(http.StatusOK, "application/json", []byte(buildLeaseList(items)))
+}
+
+// GetLease handles GET /apis/coordination.k8s.io/v1/namespaces/:namespace/leases/:name
+func GetLease(c *gin.Context) {
+	leaseName := c.Param("name")
+
+	lease, err := storage.DefaultStore.GetLease(leaseName)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("leases.coordination.k8s.io \"%s\" not found", leaseName))
+		return
+	}
+
+	c.JSON(http.StatusOK, lease)
+}
+
+// CreateLease parses POST to custom resources.Lease struct (for mock control, no coordinationv1/scheme).
+func CreateLease(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	var lease resources.Lease
+	if err := json.Unmarshal(body, &lease); err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if lease.Kind == "" {
+		WriteError(c, http.StatusBadRequest, "invalid lease")
+		return
+	}
+	// validator for name (or generateName prefix)
+	if !validateCreateName(c, &lease.Metadata) {
+		return
+	}
+	if !admitNamespace(c, &lease.Metadata) {
+		return
+	}
+	dryRun, err := isDryRun(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	// dryRun=All: run defaulting/validation only, no storage write
+	if dryRun {
+		dryRunCreate(c, "Lease", &lease)
+		return
+	}
+	if err := storage.DefaultStore.CreateLease(&lease); err != nil {
+		WriteError(c, http.StatusConflict, err.Error())
+		return
+	}
+
+	storedLease, err := storage.DefaultStore.GetLease(lease.GetName())
+	if err != nil {
+		c.JSON(http.StatusCreated, lease)
+		return
+	}
+	c.JSON(http.StatusCreated, storedLease)
+}
+
+// UpdateLease handles PUT /apis/coordination.k8s.io/v1/namespaces/:namespace/leases/:name
+// (how leader-election clients renew).
+func UpdateLease(c *gin.Context) {
+	existing, err := storage.DefaultStore.GetLease(c.Param("name"))
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("leases.coordination.k8s.io \"%s\" not found", c.Param("name")))
+		return
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	var lease resources.Lease
+	if err := json.Unmarshal(body, &lease); err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if lease.Kind == "" {
+		WriteError(c, http.StatusBadRequest, "invalid lease")
+		return
+	}
+	if !checkUpdateName(c, lease.GetName()) {
+		return
+	}
+	preserveMetadata(&lease.Metadata, existing)
+
+	dryRun, err := isDryRun(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if dryRun {
+		c.JSON(http.StatusOK, lease)
+		return
+	}
+
+	if err := storage.DefaultStore.UpdateLease(lease); err != nil {
+		WriteError(c, http.StatusConflict, err.Error())
+		return
+	}
+
+	storedLease, err := storage.DefaultStore.GetLease(lease.GetName())
+	if err != nil {
+		c.JSON(http.StatusOK, lease)
+		return
+	}
+	c.JSON(http.StatusOK, storedLease)
+}
+
+// DeleteLease handles DELETE /apis/coordination.k8s.io/v1/namespaces/:namespace/leases/:name
+func DeleteLease(c *gin.Context) {
+	leaseName := c.Param("name")
+
+	opts, err := parseDeleteOptions(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	lease, err := storage.DefaultStore.GetLease(leaseName)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("leases.coordination.k8s.io \"%s\" not found", leaseName))
+		return
+	}
+	if isDeleteDryRun(opts) {
+		c.JSON(http.StatusOK, lease)
+		return
+	}
+
+	if err := storage.DefaultStore.DeleteLease(leaseName); err != nil {
+		WriteError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, deletedObject(storage.DefaultStore.GetLease, leaseName, lease))
+}
@@ -0,0 +1,31 @@
+package apis
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"mockernetes/internal/resources"
+)
+
+// validateCreateName checks metadata.name, or the generateName prefix when no name is set
+// (storage appends the random suffix). Writes a 400 Status and returns false when invalid.
+func validateCreateName(c *gin.Context, meta *resources.ObjectMeta) bool {
+	// uid is server-assigned; a uid sent by the client is dropped
+	meta.UID = ""
+	name := meta.Name
+	if name == "" && meta.GenerateName != "" {
+		// like upstream's prefix validation: a trailing dash is allowed in the prefix
+		name = strings.TrimSuffix(meta.GenerateName, "-")
+		if name == "" {
+			WriteError(c, http.StatusBadRequest, "metadata.generateName: Invalid value: "+meta.GenerateName)
+			return false
+		}
+	}
+	if errs := validation.IsDNS1123Label(name); len(errs) != 0 {
+		WriteError(c, http.StatusBadRequest, errs[0])
+		return false
+	}
+	return true
+}
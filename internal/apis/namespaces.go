@@ -2,12 +2,12 @@ package apis
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/validation"
 	"mockernetes/internal/resources" // custom structs for mock control (no corev1)
 	"mockernetes/internal/storage"
 )
@@ -47,24 +47,199 @@ func CreateNamespace(c *gin.Context) {
 		WriteError(c, http.StatusBadRequest, "invalid namespace")
 		return
 	}
-	// validator for name (catches invalid like "Invalid-NS")
-	if errs := validation.IsDNS1123Label(ns.GetName()); len(errs) != 0 {
-		WriteError(c, http.StatusBadRequest, errs[0])
+	// apply upstream defaults (kubernetes finalizer, Active phase)
+	resources.SetNamespaceDefaults(&ns)
+	// validator for name (or generateName prefix)
+	if !validateCreateName(c, &ns.Metadata) {
+		return
+	}
+	dryRun, err := isDryRun(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	// dryRun=All: run defaulting/validation only, no storage write or controller hooks
+	if dryRun {
+		dryRunCreate(c, "Namespace", &ns)
 		return
 	}
 	// store; error if exists (uses KubeObject impl)
-	if err := storage.DefaultStore.CreateNamespace(ns); err != nil {
+	if err := storage.DefaultStore.CreateNamespace(&ns); err != nil {
 		WriteError(c, http.StatusConflict, err.Error()) // 409 for exists
 		return
 	}
 	c.JSON(http.StatusCreated, ns)
 }
 
+// GetNamespace handles GET /api/v1/namespaces/:name
+func GetNamespace(c *gin.Context) {
+	nsName := c.Param("name")
+
+	ns, err := storage.DefaultStore.GetNamespace(nsName)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("namespaces \"%s\" not found", nsName))
+		return
+	}
+
+	c.JSON(http.StatusOK, ns)
+}
+
+// UpdateNamespace handles PUT /api/v1/namespaces/:name
+func UpdateNamespace(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	var ns resources.Namespace
+	if err := json.Unmarshal(body, &ns); err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	replaceNamespace(c, ns)
+}
+
+// PatchNamespace handles PATCH /api/v1/namespaces/:name
+func PatchNamespace(c *gin.Context) {
+	nsName := c.Param("name")
+	existing, err := storage.DefaultStore.GetNamespace(nsName)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("namespaces \"%s\" not found", nsName))
+		return
+	}
+	var ns resources.Namespace
+	if !readPatchedObject(c, existing, &ns) {
+		return
+	}
+	replaceNamespace(c, ns)
+}
+
+// replaceNamespace runs the update pipeline shared by PUT and PATCH.
+func replaceNamespace(c *gin.Context, ns resources.Namespace) {
+	existing, err := storage.DefaultStore.GetNamespace(c.Param("name"))
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("namespaces \"%s\" not found", c.Param("name")))
+		return
+	}
+	if ns.Kind == "" {
+		WriteError(c, http.StatusBadRequest, "invalid namespace")
+		return
+	}
+	if !checkUpdateName(c, ns.GetName()) {
+		return
+	}
+	// spec.finalizers and status are only writable through the finalize/status subresources
+	ns.Spec = existing["spec"]
+	ns.Status = existing["status"]
+	preserveMetadata(&ns.Metadata, existing)
+
+	dryRun, err := isDryRun(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if dryRun {
+		dryRunUpdate(c, "Namespace", &ns)
+		return
+	}
+
+	stored, err := storage.DefaultStore.UpdateObject("Namespace", &ns)
+	if err != nil {
+		WriteError(c, http.StatusConflict, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, stored)
+}
+
+// immortalNamespaces can't be deleted (NamespaceLifecycle admission).
+var immortalNamespaces = map[string]bool{"default": true, "kube-system": true, "kube-public": true}
+
+// DeleteNamespace handles DELETE /api/v1/namespaces/:name
+// The namespace turns Terminating; the namespace controller deletes its content and
+// removes the "kubernetes" finalizer, after which it is gone.
+func DeleteNamespace(c *gin.Context) {
+	nsName := c.Param("name")
+
+	opts, err := parseDeleteOptions(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ns, err := storage.DefaultStore.GetNamespace(nsName)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("namespaces \"%s\" not found", nsName))
+		return
+	}
+	if immortalNamespaces[nsName] {
+		WriteError(c, http.StatusForbidden, fmt.Sprintf("namespaces \"%s\" is forbidden: this namespace may not be deleted", nsName))
+		return
+	}
+	if isNamespaceTerminating(ns) {
+		WriteError(c, http.StatusConflict, fmt.Sprintf("Operation cannot be fulfilled on namespaces \"%s\": The system is ensuring all content is removed from this namespace.  Upon completion, this namespace will automatically be purged by the system.", nsName))
+		return
+	}
+	if isDeleteDryRun(opts) {
+		c.JSON(http.StatusOK, ns)
+		return
+	}
+
+	if err := setPropagationFinalizers("Namespace", nsName, opts); err != nil {
+		WriteError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := storage.DefaultStore.DeleteNamespace(nsName); err != nil {
+		WriteError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	// Terminating right away, as the namespace registry does
+	storage.DefaultStore.MutateNamespace(nsName, func(ns map[string]interface{}) error {
+		status, _ := ns["status"].(map[string]interface{})
+		if status == nil {
+			status = map[string]interface{}{}
+			ns["status"] = status
+		}
+		status["phase"] = "Terminating"
+		return nil
+	})
+
+	c.JSON(http.StatusOK, deletedObject(storage.DefaultStore.GetNamespace, nsName, ns))
+}
+
+// isNamespaceTerminating reports whether a stored namespace has been marked for deletion.
+func isNamespaceTerminating(ns map[string]interface{}) bool {
+	ts, _ := resources.NestedString(ns, "metadata", "deletionTimestamp")
+	return ts != ""
+}
+
+// admitNamespace fills metadata.namespace from the URL (rejecting a mismatching body) and
+// refuses new content in a Terminating namespace (NamespaceLifecycle admission).
+func admitNamespace(c *gin.Context, meta *resources.ObjectMeta) bool {
+	if urlNS := c.Param("namespace"); urlNS != "" {
+		if meta.Namespace != "" && meta.Namespace != urlNS {
+			WriteError(c, http.StatusBadRequest, "the namespace of the provided object does not match the namespace sent on the request")
+			return false
+		}
+		meta.Namespace = urlNS
+	}
+	if meta.Namespace == "" {
+		meta.Namespace = "default"
+	}
+	if ns, err := storage.DefaultStore.GetNamespace(meta.Namespace); err == nil && isNamespaceTerminating(ns) {
+		WriteError(c, http.StatusForbidden, fmt.Sprintf("unable to create new content in namespace %s because it is being terminated", meta.Namespace))
+		return false
+	}
+	return true
+}
+
 // WriteError returns K8s Status for kubectl to parse/display error (e.g. on invalid ns).
 func WriteError(c *gin.Context, code int, msg string) {
 	reason := metav1.StatusReasonInvalid
-	if code == http.StatusNotFound {
+	switch code {
+	case http.StatusNotFound:
 		reason = metav1.StatusReasonNotFound
+	case http.StatusForbidden:
+		reason = metav1.StatusReasonForbidden
 	}
 	status := metav1.Status{
 		TypeMeta: metav1.TypeMeta{
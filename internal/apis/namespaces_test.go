@@ -0,0 +1,67 @@
+package apis
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDeleteNamespace(t *testing.T) {
+	ns := resources.Namespace{Kind: "Namespace", APIVersion: "v1", Metadata: resources.ObjectMeta{Name: "tenant-delete"}}
+	resources.SetNamespaceDefaults(&ns)
+	if err := storage.DefaultStore.CreateNamespace(ns); err != nil {
+		t.Fatalf("Failed to create namespace: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("DELETE", "/api/v1/namespaces/tenant-delete", nil)
+	c.Params = gin.Params{{Key: "name", Value: "tenant-delete"}}
+
+	DeleteNamespace(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if phase, _ := resources.NestedString(response, "status", "phase"); phase != "Terminating" {
+		t.Errorf("Expected phase Terminating, got %q", phase)
+	}
+
+	// New content is refused while the namespace terminates
+	body := `{"kind":"Pod","apiVersion":"v1","metadata":{"name":"late-pod"},"spec":{"containers":[{"name":"app","image":"busybox"}]}}`
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/api/v1/namespaces/tenant-delete/pods", strings.NewReader(body))
+	c.Params = gin.Params{{Key: "namespace", Value: "tenant-delete"}}
+
+	CreatePod(c)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 creating in a Terminating namespace, got %d", w.Code)
+	}
+}
+
+func TestDeleteDefaultNamespaceForbidden(t *testing.T) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("DELETE", "/api/v1/namespaces/default", nil)
+	c.Params = gin.Params{{Key: "name", Value: "default"}}
+
+	DeleteNamespace(c)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403, got %d", w.Code)
+	}
+	if _, err := storage.DefaultStore.GetNamespace("default"); err != nil {
+		t.Errorf("Expected default namespace to remain: %v", err)
+	}
+}
@@ -0,0 +1,206 @@
+package apis
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"mockernetes/internal/controllers"
+	"mockernetes/internal/resources" // custom structs for mock control (no corev1)
+	"mockernetes/internal/storage"
+)
+
+// Nodes are cluster-scoped and fake: creating one registers a Ready node that the scheduler
+// and DaemonSets place pods on. There is no kubelet behind it.
+
+// buildNodeList wraps store items into K8s list (uses custom resources.Node structs).
+func buildNodeList(items []interface{}) string {
+	list := map[string]interface{}{
+		"kind":       "NodeList",
+		"apiVersion": "v1",
+		"metadata":   map[string]string{"resourceVersion": "1"},
+		"items":      items,
+	}
+	b, _ := json.Marshal(list)
+	return string(b)
+}
+
+// ListNodes also serves watch=true and filters by fieldSelector.
+func ListNodes(c *gin.Context) {
+	fields, err := parseFieldSelector(c.Query("fieldSelector"))
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if c.Query("watch") == "true" {
+		watchObjects(c, "Node", storage.DefaultStore.ListNodes, fields)
+		return
+	}
+	items := filterByFields(storage.DefaultStore.ListNodes(), fields)
+	c.Data(http.StatusOK, "application/json", []byte(buildNodeList(items)))
+}
+
+// GetNode handles GET /api/v1/nodes/:name
+func GetNode(c *gin.Context) {
+	nodeName := c.Param("name")
+
+	node, err := storage.DefaultStore.GetNode(nodeName)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("nodes \"%s\" not found", nodeName))
+		return
+	}
+
+	c.JSON(http.StatusOK, node)
+}
+
+// CreateNode parses POST to custom resources.Node struct (for mock control, no corev1/scheme).
+// A node created without status is reported Ready.
+func CreateNode(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	var node resources.Node
+	if err := json.Unmarshal(body, &node); err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if node.Kind == "" {
+		WriteError(c, http.StatusBadRequest, "invalid node")
+		return
+	}
+	// nodes are cluster-scoped
+	node.Metadata.Namespace = ""
+	resources.SetNodeDefaults(&node)
+	// validator for name (or generateName prefix)
+	if !validateCreateName(c, &node.Metadata) {
+		return
+	}
+	// the kubelet would report the node's addresses; hand out a free InternalIP
+	controllers.AssignNodeAddress(storage.DefaultStore, &node)
+	// and the node IPAM controller a podCIDR per cluster CIDR
+	controllers.AssignPodCIDRs(storage.DefaultStore, &node)
+	dryRun, err := isDryRun(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	// dryRun=All: run defaulting/validation only, no storage write or controller hooks
+	if dryRun {
+		dryRunCreate(c, "Node", &node)
+		return
+	}
+	if err := storage.DefaultStore.CreateNode(&node); err != nil {
+		WriteError(c, http.StatusConflict, err.Error())
+		return
+	}
+
+	storedNode, err := storage.DefaultStore.GetNode(node.GetName())
+	if err != nil {
+		c.JSON(http.StatusCreated, node)
+		return
+	}
+	c.JSON(http.StatusCreated, storedNode)
+}
+
+// UpdateNode handles PUT /api/v1/nodes/:name
+func UpdateNode(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	var node resources.Node
+	if err := json.Unmarshal(body, &node); err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	replaceNode(c, node)
+}
+
+// PatchNode handles PATCH /api/v1/nodes/:name (kubectl label/taint/cordon)
+func PatchNode(c *gin.Context) {
+	nodeName := c.Param("name")
+	existing, err := storage.DefaultStore.GetNode(nodeName)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("nodes \"%s\" not found", nodeName))
+		return
+	}
+	var node resources.Node
+	if !readPatchedObject(c, existing, &node) {
+		return
+	}
+	replaceNode(c, node)
+}
+
+// replaceNode runs the update pipeline shared by PUT and PATCH.
+func replaceNode(c *gin.Context, node resources.Node) {
+	existing, err := storage.DefaultStore.GetNode(c.Param("name"))
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("nodes \"%s\" not found", c.Param("name")))
+		return
+	}
+	if node.Kind == "" {
+		WriteError(c, http.StatusBadRequest, "invalid node")
+		return
+	}
+	if !checkUpdateName(c, node.GetName()) {
+		return
+	}
+	node.Metadata.Namespace = ""
+	preserveMetadata(&node.Metadata, existing)
+	// status is owned by the (fake) kubelet, not the main resource
+	node.Status = existing["status"]
+
+	dryRun, err := isDryRun(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if dryRun {
+		dryRunUpdate(c, "Node", &node)
+		return
+	}
+
+	stored, err := storage.DefaultStore.UpdateObject("Node", &node)
+	if err != nil {
+		WriteError(c, http.StatusConflict, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, stored)
+}
+
+// DeleteNode handles DELETE /api/v1/nodes/:name
+func DeleteNode(c *gin.Context) {
+	nodeName := c.Param("name")
+
+	opts, err := parseDeleteOptions(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	node, err := storage.DefaultStore.GetNode(nodeName)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("nodes \"%s\" not found", nodeName))
+		return
+	}
+	if isDeleteDryRun(opts) {
+		c.JSON(http.StatusOK, node)
+		return
+	}
+
+	if err := setPropagationFinalizers("Node", nodeName, opts); err != nil {
+		WriteError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := storage.DefaultStore.DeleteNode(nodeName); err != nil {
+		WriteError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, deletedObject(storage.DefaultStore.GetNode, nodeName, node))
+}
@@ -0,0 +1,115 @@
+package apis
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+// Request options shared by the mutating handlers (dryRun on create/update/patch,
+// DeleteOptions on delete). kubectl sends dryRun as a query param on writes and
+// inside the DeleteOptions body on deletes, so both are accepted.
+
+// validateDryRun reports whether dryRun=All was requested; any other value is rejected like upstream.
+func validateDryRun(values []string) (bool, error) {
+	for _, v := range values {
+		if v != metav1.DryRunAll {
+			return false, fmt.Errorf("unsupported dry run option %q, only %q is supported", v, metav1.DryRunAll)
+		}
+	}
+	return len(values) > 0, nil
+}
+
+// isDryRun checks the dryRun query parameter of a create/update/patch request.
+func isDryRun(c *gin.Context) (bool, error) {
+	return validateDryRun(c.QueryArray("dryRun"))
+}
+
+// dryRunCreate answers a dryRun=All create of obj with what creating it would store, or with
+// the conflict a real create would hit.
+func dryRunCreate(c *gin.Context, kind string, obj resources.KubeObject) {
+	stored, err := storage.DefaultStore.DryRunCreate(kind, obj)
+	if err != nil {
+		WriteError(c, http.StatusConflict, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, stored)
+}
+
+// dryRunUpdate answers a dryRun=All replace with obj as it would be stored, generation included.
+func dryRunUpdate(c *gin.Context, kind string, obj resources.KubeObject) {
+	stored, err := storage.DefaultStore.DryRunUpdate(kind, obj)
+	if err != nil {
+		WriteError(c, http.StatusConflict, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, stored)
+}
+
+// parseDeleteOptions merges the DeleteOptions body (if any) with the equivalent query params.
+func parseDeleteOptions(c *gin.Context) (metav1.DeleteOptions, error) {
+	var opts metav1.DeleteOptions
+	if c.Request.Body != nil {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			return opts, err
+		}
+		if len(body) > 0 {
+			if err := json.Unmarshal(body, &opts); err != nil {
+				return opts, fmt.Errorf("invalid DeleteOptions: %w", err)
+			}
+		}
+	}
+	if values := c.QueryArray("dryRun"); len(values) > 0 {
+		opts.DryRun = values
+	}
+	if grace := c.Query("gracePeriodSeconds"); grace != "" {
+		seconds, err := strconv.ParseInt(grace, 10, 64)
+		if err != nil {
+			return opts, fmt.Errorf("invalid gracePeriodSeconds %q", grace)
+		}
+		opts.GracePeriodSeconds = &seconds
+	}
+	if policy := c.Query("propagationPolicy"); policy != "" {
+		p := metav1.DeletionPropagation(policy)
+		opts.PropagationPolicy = &p
+	}
+	if _, err := validateDryRun(opts.DryRun); err != nil {
+		return opts, err
+	}
+	if err := validatePropagation(opts); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
+// isDeleteDryRun reports whether the parsed DeleteOptions ask for a dry run.
+func isDeleteDryRun(opts metav1.DeleteOptions) bool {
+	dryRun, _ := validateDryRun(opts.DryRun)
+	return dryRun
+}
+
+// deletedObject is what DELETE responds with: the object as still stored (marked with
+// deletionTimestamp while finalizers remain), else the copy read before deleting.
+func deletedObject(get func(name string) (map[string]interface{}, error), name string, before map[string]interface{}) map[string]interface{} {
+	if obj, err := get(name); err == nil {
+		return obj
+	}
+	return before
+}
+
+// remarshal converts a generic map (e.g. a patched object) into one of the resources structs.
+func remarshal(in interface{}, out interface{}) error {
+	b, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
@@ -0,0 +1,432 @@
+package apis
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// PATCH support for the mock (no typed scheme, so strategic merge is approximated
+// with a table of list merge keys instead of struct tags).
+
+// Patch content types accepted on PATCH.
+const (
+	jsonPatchType           = "application/json-patch+json"
+	mergePatchType          = "application/merge-patch+json"
+	strategicMergePatchType = "application/strategic-merge-patch+json"
+)
+
+// errUnsupportedPatch is returned for content types we don't implement (e.g. server-side apply).
+var errUnsupportedPatch = errors.New("unsupported patch type")
+
+// strategicMergeKeys maps list field names to the key their items are merged on
+// (patchMergeKey in the upstream types). Lists not listed here are replaced.
+var strategicMergeKeys = map[string]string{
+	"containers":          "name",
+	"initContainers":      "name",
+	"ephemeralContainers": "name",
+	"volumes":             "name",
+	"env":                 "name",
+	"imagePullSecrets":    "name",
+	"volumeMounts":        "mountPath",
+	"volumeDevices":       "devicePath",
+	"ports":               "containerPort",
+	"ownerReferences":     "uid",
+	"conditions":          "type",
+	"hostAliases":         "ip",
+}
+
+// strategicMergePrimitiveLists are primitive lists with patchStrategy=merge (union of values).
+var strategicMergePrimitiveLists = map[string]bool{
+	"finalizers": true,
+}
+
+// applyPatch applies a patch body of the given content type to a copy of original.
+func applyPatch(contentType string, original map[string]interface{}, patch []byte) (map[string]interface{}, error) {
+	// strip parameters like "; charset=utf-8"
+	if idx := strings.Index(contentType, ";"); idx >= 0 {
+		contentType = contentType[:idx]
+	}
+	contentType = strings.TrimSpace(contentType)
+
+	target := deepCopyJSON(original).(map[string]interface{})
+
+	switch contentType {
+	case jsonPatchType:
+		var ops []map[string]interface{}
+		if err := json.Unmarshal(patch, &ops); err != nil {
+			return nil, fmt.Errorf("invalid json patch: %w", err)
+		}
+		result, err := applyJSONPatch(target, ops)
+		if err != nil {
+			return nil, err
+		}
+		obj, ok := result.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("json patch must produce an object")
+		}
+		return obj, nil
+	case mergePatchType, strategicMergePatchType:
+		var p map[string]interface{}
+		if err := json.Unmarshal(patch, &p); err != nil {
+			return nil, fmt.Errorf("invalid merge patch: %w", err)
+		}
+		return mergeObjects(target, p, contentType == strategicMergePatchType), nil
+	}
+	return nil, fmt.Errorf("%w: %s", errUnsupportedPatch, contentType)
+}
+
+// mergeObjects implements RFC 7386 merge patch; with strategic set, lists with a known
+// merge key are merged item by item and $patch/$deleteFromPrimitiveList directives are honored.
+func mergeObjects(target, patch map[string]interface{}, strategic bool) map[string]interface{} {
+	if target == nil {
+		target = map[string]interface{}{}
+	}
+	if strategic {
+		if directive, _ := patch["$patch"].(string); directive == "replace" {
+			return stripDirectives(patch).(map[string]interface{})
+		}
+	}
+	for key, value := range patch {
+		if strings.HasPrefix(key, "$") {
+			if strategic && strings.HasPrefix(key, "$deleteFromPrimitiveList/") {
+				field := strings.TrimPrefix(key, "$deleteFromPrimitiveList/")
+				target[field] = removePrimitives(target[field], value)
+			}
+			continue
+		}
+		if value == nil {
+			delete(target, key)
+			continue
+		}
+		switch pv := value.(type) {
+		case map[string]interface{}:
+			tv, _ := target[key].(map[string]interface{})
+			target[key] = mergeObjects(tv, pv, strategic)
+		case []interface{}:
+			if strategic {
+				target[key] = mergeLists(key, target[key], pv)
+			} else {
+				target[key] = pv
+			}
+		default:
+			target[key] = pv
+		}
+	}
+	return target
+}
+
+// mergeLists merges a strategic patch list into the target list.
+func mergeLists(field string, target interface{}, patch []interface{}) interface{} {
+	existing, _ := target.([]interface{})
+	if strategicMergePrimitiveLists[field] {
+		out := append([]interface{}{}, existing...)
+		for _, v := range patch {
+			if !containsValue(out, v) {
+				out = append(out, v)
+			}
+		}
+		return out
+	}
+	mergeKey := strategicMergeKeys[field]
+	if field == "ports" && !listHasKey(patch, mergeKey) {
+		// Service ports are keyed by port rather than containerPort
+		mergeKey = "port"
+	}
+	if mergeKey == "" || !listHasKey(patch, mergeKey) {
+		return stripDirectives(patch)
+	}
+
+	out := append([]interface{}{}, existing...)
+	for _, item := range patch {
+		pm, _ := item.(map[string]interface{})
+		idx := -1
+		for i, e := range out {
+			if em, ok := e.(map[string]interface{}); ok && reflect.DeepEqual(em[mergeKey], pm[mergeKey]) {
+				idx = i
+				break
+			}
+		}
+		if directive, _ := pm["$patch"].(string); directive == "delete" {
+			if idx >= 0 {
+				out = append(out[:idx], out[idx+1:]...)
+			}
+			continue
+		}
+		if idx >= 0 {
+			em, _ := out[idx].(map[string]interface{})
+			out[idx] = mergeObjects(em, pm, true)
+		} else {
+			out = append(out, mergeObjects(nil, pm, true))
+		}
+	}
+	return out
+}
+
+// listHasKey reports whether every item of the list is an object carrying key.
+func listHasKey(list []interface{}, key string) bool {
+	if key == "" || len(list) == 0 {
+		return false
+	}
+	for _, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if _, ok := m[key]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// removePrimitives removes every value listed in del from the primitive list target.
+func removePrimitives(target interface{}, del interface{}) interface{} {
+	existing, _ := target.([]interface{})
+	toDelete, _ := del.([]interface{})
+	out := make([]interface{}, 0, len(existing))
+	for _, v := range existing {
+		if !containsValue(toDelete, v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// stripDirectives removes $-prefixed directive keys from a patch fragment.
+func stripDirectives(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			if strings.HasPrefix(k, "$") {
+				continue
+			}
+			out[k] = stripDirectives(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, 0, len(val))
+		for _, item := range val {
+			out = append(out, stripDirectives(item))
+		}
+		return out
+	}
+	return v
+}
+
+func containsValue(list []interface{}, v interface{}) bool {
+	for _, item := range list {
+		if reflect.DeepEqual(item, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyJSONPatch implements RFC 6902 (add, remove, replace, move, copy, test).
+func applyJSONPatch(doc interface{}, ops []map[string]interface{}) (interface{}, error) {
+	var err error
+	for _, op := range ops {
+		name, _ := op["op"].(string)
+		path, _ := op["path"].(string)
+		switch name {
+		case "add":
+			doc, err = jsonPointerSet(doc, path, deepCopyJSON(op["value"]), true)
+		case "replace":
+			if _, err = jsonPointerGet(doc, path); err == nil {
+				doc, err = jsonPointerSet(doc, path, deepCopyJSON(op["value"]), false)
+			}
+		case "remove":
+			doc, _, err = jsonPointerRemove(doc, path)
+		case "move":
+			from, _ := op["from"].(string)
+			var v interface{}
+			if doc, v, err = jsonPointerRemove(doc, from); err == nil {
+				doc, err = jsonPointerSet(doc, path, v, true)
+			}
+		case "copy":
+			from, _ := op["from"].(string)
+			var v interface{}
+			if v, err = jsonPointerGet(doc, from); err == nil {
+				doc, err = jsonPointerSet(doc, path, deepCopyJSON(v), true)
+			}
+		case "test":
+			var v interface{}
+			if v, err = jsonPointerGet(doc, path); err == nil && !reflect.DeepEqual(v, op["value"]) {
+				err = fmt.Errorf("test operation failed at %s", path)
+			}
+		default:
+			err = fmt.Errorf("unsupported json patch op %q", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+// splitPointer decodes an RFC 6901 pointer into its reference tokens.
+func splitPointer(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("invalid json pointer %q", path)
+	}
+	parts := strings.Split(path[1:], "/")
+	for i, p := range parts {
+		parts[i] = strings.ReplaceAll(strings.ReplaceAll(p, "~1", "/"), "~0", "~")
+	}
+	return parts, nil
+}
+
+func jsonPointerGet(doc interface{}, path string) (interface{}, error) {
+	parts, err := splitPointer(path)
+	if err != nil {
+		return nil, err
+	}
+	cur := doc
+	for _, p := range parts {
+		switch node := cur.(type) {
+		case map[string]interface{}:
+			v, ok := node[p]
+			if !ok {
+				return nil, fmt.Errorf("path %s not found", path)
+			}
+			cur = v
+		case []interface{}:
+			i, err := strconv.Atoi(p)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, fmt.Errorf("path %s not found", path)
+			}
+			cur = node[i]
+		default:
+			return nil, fmt.Errorf("path %s not found", path)
+		}
+	}
+	return cur, nil
+}
+
+// jsonPointerSet sets (or, for insert on arrays, inserts) value at path and returns the new doc.
+func jsonPointerSet(doc interface{}, path string, value interface{}, insert bool) (interface{}, error) {
+	parts, err := splitPointer(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) == 0 {
+		return value, nil
+	}
+	return setIn(doc, parts, value, insert, path)
+}
+
+func setIn(node interface{}, parts []string, value interface{}, insert bool, path string) (interface{}, error) {
+	key := parts[0]
+	last := len(parts) == 1
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if last {
+			n[key] = value
+			return n, nil
+		}
+		child, ok := n[key]
+		if !ok {
+			return nil, fmt.Errorf("path %s not found", path)
+		}
+		updated, err := setIn(child, parts[1:], value, insert, path)
+		if err != nil {
+			return nil, err
+		}
+		n[key] = updated
+		return n, nil
+	case []interface{}:
+		i := len(n)
+		if key != "-" {
+			var err error
+			if i, err = strconv.Atoi(key); err != nil || i < 0 || i > len(n) {
+				return nil, fmt.Errorf("invalid array index in %s", path)
+			}
+		}
+		if last {
+			if insert {
+				n = append(n, nil)
+				copy(n[i+1:], n[i:])
+				n[i] = value
+				return n, nil
+			}
+			if i >= len(n) {
+				return nil, fmt.Errorf("path %s not found", path)
+			}
+			n[i] = value
+			return n, nil
+		}
+		if i >= len(n) {
+			return nil, fmt.Errorf("path %s not found", path)
+		}
+		updated, err := setIn(n[i], parts[1:], value, insert, path)
+		if err != nil {
+			return nil, err
+		}
+		n[i] = updated
+		return n, nil
+	}
+	return nil, fmt.Errorf("path %s not found", path)
+}
+
+// jsonPointerRemove removes the value at path, returning the new doc and the removed value.
+func jsonPointerRemove(doc interface{}, path string) (interface{}, interface{}, error) {
+	parts, err := splitPointer(path)
+	if err != nil || len(parts) == 0 {
+		return nil, nil, fmt.Errorf("invalid remove path %q", path)
+	}
+	parentPath := ""
+	for _, p := range parts[:len(parts)-1] {
+		parentPath += "/" + strings.ReplaceAll(strings.ReplaceAll(p, "~", "~0"), "/", "~1")
+	}
+	parent, err := jsonPointerGet(doc, parentPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	key := parts[len(parts)-1]
+	switch n := parent.(type) {
+	case map[string]interface{}:
+		v, ok := n[key]
+		if !ok {
+			return nil, nil, fmt.Errorf("path %s not found", path)
+		}
+		delete(n, key)
+		return doc, v, nil
+	case []interface{}:
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 0 || i >= len(n) {
+			return nil, nil, fmt.Errorf("path %s not found", path)
+		}
+		v := n[i]
+		shrunk := append(n[:i:i], n[i+1:]...)
+		doc, err = jsonPointerSet(doc, parentPath, shrunk, false)
+		return doc, v, err
+	}
+	return nil, nil, fmt.Errorf("path %s not found", path)
+}
+
+// deepCopyJSON copies a json-decoded value tree.
+func deepCopyJSON(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[k] = deepCopyJSON(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = deepCopyJSON(item)
+		}
+		return out
+	}
+	return v
+}
@@ -0,0 +1,92 @@
+package apis
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func decodeJSON(t *testing.T, s string) map[string]interface{} {
+	t.Helper()
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		t.Fatalf("Failed to unmarshal %s: %v", s, err)
+	}
+	return m
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	original := decodeJSON(t, `{"metadata":{"name":"web","labels":{"app":"web","tier":"fe"}},"spec":{"replicas":1}}`)
+	patch := []byte(`{"metadata":{"labels":{"tier":null}},"spec":{"replicas":3}}`)
+
+	patched, err := applyPatch("application/merge-patch+json", original, patch)
+	if err != nil {
+		t.Fatalf("applyPatch failed: %v", err)
+	}
+
+	if patched["spec"].(map[string]interface{})["replicas"] != float64(3) {
+		t.Errorf("Expected replicas 3, got %v", patched["spec"])
+	}
+	labels := patched["metadata"].(map[string]interface{})["labels"].(map[string]interface{})
+	if _, ok := labels["tier"]; ok {
+		t.Errorf("Expected tier label removed, got %v", labels)
+	}
+	// original must not be mutated
+	if original["spec"].(map[string]interface{})["replicas"] != float64(1) {
+		t.Errorf("Expected original to be untouched, got %v", original["spec"])
+	}
+}
+
+func TestApplyStrategicMergePatchMergesContainersByName(t *testing.T) {
+	original := decodeJSON(t, `{"spec":{"template":{"spec":{"containers":[
+		{"name":"app","image":"app:v1"},{"name":"sidecar","image":"proxy:v1"}]}}}}`)
+	patch := []byte(`{"spec":{"template":{"spec":{"$setElementOrder/containers":[{"name":"app"},{"name":"sidecar"}],
+		"containers":[{"name":"app","image":"app:v2"}]}}}}`)
+
+	patched, err := applyPatch("application/strategic-merge-patch+json; charset=utf-8", original, patch)
+	if err != nil {
+		t.Fatalf("applyPatch failed: %v", err)
+	}
+
+	podSpec := patched["spec"].(map[string]interface{})["template"].(map[string]interface{})["spec"].(map[string]interface{})
+	containers := podSpec["containers"].([]interface{})
+	if len(containers) != 2 {
+		t.Fatalf("Expected 2 containers after merge, got %d", len(containers))
+	}
+	if image := containers[0].(map[string]interface{})["image"]; image != "app:v2" {
+		t.Errorf("Expected app image app:v2, got %v", image)
+	}
+	if _, ok := podSpec["$setElementOrder/containers"]; ok {
+		t.Error("Expected directive keys to be stripped")
+	}
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	original := decodeJSON(t, `{"metadata":{"annotations":{"a":"1"}},"spec":{"items":["x","y"]}}`)
+	patch := []byte(`[
+		{"op":"replace","path":"/metadata/annotations/a","value":"2"},
+		{"op":"add","path":"/spec/items/1","value":"z"},
+		{"op":"remove","path":"/spec/items/0"},
+		{"op":"test","path":"/metadata/annotations/a","value":"2"}]`)
+
+	patched, err := applyPatch("application/json-patch+json", original, patch)
+	if err != nil {
+		t.Fatalf("applyPatch failed: %v", err)
+	}
+
+	items := patched["spec"].(map[string]interface{})["items"].([]interface{})
+	if len(items) != 2 || items[0] != "z" || items[1] != "y" {
+		t.Errorf("Expected items [z y], got %v", items)
+	}
+
+	if _, err := applyPatch("application/json-patch+json", original, []byte(`[{"op":"replace","path":"/missing","value":1}]`)); err == nil {
+		t.Error("Expected replace of a missing path to fail")
+	}
+}
+
+func TestApplyPatchUnsupportedType(t *testing.T) {
+	_, err := applyPatch("application/apply-patch+yaml", map[string]interface{}{}, []byte(`{}`))
+	if !errors.Is(err, errUnsupportedPatch) {
+		t.Errorf("Expected errUnsupportedPatch, got %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+package apis
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"mockernetes/internal/resources" // custom structs for mock control (no corev1)
+	"mockernetes/internal/storage"
+)
+
+// buildPersistentVolumeClaimList wraps store items into K8s list (like configmaps; uses custom resources.PersistentVolumeClaim).
+func buildPersistentVolumeClaimList(items []interface{}) string {
+	list := map[string]interface{}{
+		"kind":       "PersistentVolumeClaimList",
+		"apiVersion": "v1",
+		"metadata":   map[string]string{"resourceVersion": "1"},
+		"items":      items,
+	}
+	b, _ := json.Marshal(list)
+	return string(b)
+}
+
+func ListPersistentVolumeClaims(c *gin.Context) {
+	items := storage.DefaultStore.ListPersistentVolumeClaims()
+	c.Data(http.StatusOK, "application/json", []byte(buildPersistentVolumeClaimList(items)))
+}
+
+// CreatePersistentVolumeClaim parses POST to custom resources.PersistentVolumeClaim struct (for mock control, no corev1/scheme).
+// Validates, stores if not exists.
+func CreatePersistentVolumeClaim(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	// Unmarshal to custom struct
+	var pvc resources.PersistentVolumeClaim
+	if err := json.Unmarshal(body, &pvc); err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if pvc.Kind == "" {
+		WriteError(c, http.StatusBadRequest, "invalid persistentvolumeclaim")
+		return
+	}
+	// apply defaults; claims are bound right away (no provisioner to wait for)
+	resources.SetPersistentVolumeClaimDefaults(&pvc)
+	// validator for name (or generateName prefix)
+	if !validateCreateName(c, &pvc.Metadata) {
+		return
+	}
+	if !admitNamespace(c, &pvc.Metadata) {
+		return
+	}
+	dryRun, err := isDryRun(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	// dryRun=All: run defaulting/validation only, no storage write or controller hooks
+	if dryRun {
+		dryRunCreate(c, "PersistentVolumeClaim", &pvc)
+		return
+	}
+	// store; uses KubeObject impl from custom struct
+	if err := storage.DefaultStore.CreatePersistentVolumeClaim(&pvc); err != nil {
+		WriteError(c, http.StatusConflict, err.Error())
+		return
+	}
+	if stored, err := storage.DefaultStore.GetPersistentVolumeClaim(pvc.GetName()); err == nil {
+		c.JSON(http.StatusCreated, stored)
+		return
+	}
+	c.JSON(http.StatusCreated, pvc)
+}
+
+// GetPersistentVolumeClaim handles GET /api/v1/namespaces/:namespace/persistentvolumeclaims/:name
+func GetPersistentVolumeClaim(c *gin.Context) {
+	pvcName := c.Param("name")
+
+	pvc, err := storage.DefaultStore.GetPersistentVolumeClaim(pvcName)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("persistentvolumeclaims \"%s\" not found", pvcName))
+		return
+	}
+
+	c.JSON(http.StatusOK, pvc)
+}
+
+// UpdatePersistentVolumeClaim handles PUT /api/v1/namespaces/:namespace/persistentvolumeclaims/:name
+func UpdatePersistentVolumeClaim(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	var pvc resources.PersistentVolumeClaim
+	if err := json.Unmarshal(body, &pvc); err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	replacePersistentVolumeClaim(c, pvc)
+}
+
+// PatchPersistentVolumeClaim handles PATCH /api/v1/namespaces/:namespace/persistentvolumeclaims/:name
+func PatchPersistentVolumeClaim(c *gin.Context) {
+	pvcName := c.Param("name")
+	existing, err := storage.DefaultStore.GetPersistentVolumeClaim(pvcName)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("persistentvolumeclaims \"%s\" not found", pvcName))
+		return
+	}
+	var pvc resources.PersistentVolumeClaim
+	if !readPatchedObject(c, existing, &pvc) {
+		return
+	}
+	replacePersistentVolumeClaim(c, pvc)
+}
+
+// replacePersistentVolumeClaim runs the update pipeline shared by PUT and PATCH.
+func replacePersistentVolumeClaim(c *gin.Context, pvc resources.PersistentVolumeClaim) {
+	existing, err := storage.DefaultStore.GetPersistentVolumeClaim(c.Param("name"))
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("persistentvolumeclaims \"%s\" not found", c.Param("name")))
+		return
+	}
+	if pvc.Kind == "" {
+		WriteError(c, http.StatusBadRequest, "invalid persistentvolumeclaim")
+		return
+	}
+	if !checkUpdateName(c, pvc.GetName()) {
+		return
+	}
+	resources.SetPersistentVolumeClaimDefaults(&pvc)
+	preserveMetadata(&pvc.Metadata, existing)
+	// status is owned by the (mock) volume binder, not the main resource
+	pvc.Status = existing["status"]
+
+	dryRun, err := isDryRun(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if dryRun {
+		dryRunUpdate(c, "PersistentVolumeClaim", &pvc)
+		return
+	}
+
+	stored, err := storage.DefaultStore.UpdateObject("PersistentVolumeClaim", &pvc)
+	if err != nil {
+		WriteError(c, http.StatusConflict, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, stored)
+}
+
+// DeletePersistentVolumeClaim handles DELETE /api/v1/namespaces/:namespace/persistentvolumeclaims/:name
+func DeletePersistentVolumeClaim(c *gin.Context) {
+	pvcName := c.Param("name")
+
+	opts, err := parseDeleteOptions(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	pvc, err := storage.DefaultStore.GetPersistentVolumeClaim(pvcName)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("persistentvolumeclaims \"%s\" not found", pvcName))
+		return
+	}
+	if isDeleteDryRun(opts) {
+		c.JSON(http.StatusOK, pvc)
+		return
+	}
+
+	if err := setPropagationFinalizers("PersistentVolumeClaim", pvcName, opts); err != nil {
+		WriteError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := storage.DefaultStore.DeletePersistentVolumeClaim(pvcName); err != nil {
+		WriteError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, deletedObject(storage.DefaultStore.GetPersistentVolumeClaim, pvcName, pvc))
+}
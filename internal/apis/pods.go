@@ -9,7 +9,6 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"k8s.io/apimachinery/pkg/util/validation"
 	"mockernetes/internal/controllers" // pod lifecycle controller
 	"mockernetes/internal/resources"    // custom structs for mock control (no corev1)
 	"mockernetes/internal/storage"
@@ -109,6 +108,7 @@ func buildPodCells(pod map[string]interface{}) []interface{} {
 	readyCount := 0
 	totalContainers := 0
 	restartCount := int64(0)
+	var lastRestart time.Time
 
 	if status, ok := pod["status"].(map[string]interface{}); ok {
 		if p, ok := status["phase"].(string); ok {
@@ -118,11 +118,81 @@ func buildPodCells(pod map[string]interface{}) []interface{} {
 			podIP = ip
 		}
 
-		// Count containers and ready status
-		if containerStatuses, ok := status["containerStatuses"].([]interface{}); ok {
-			totalContainers = len(containerStatuses)
-			for _, cs := range containerStatuses {
-				if containerStatus, ok := cs.(map[string]interface{}); ok {
+		// Like kubectl's printer, the first init container that isn't done shows as Init:N/M,
+		// or Init:<reason> when it waits or failed; native sidecars count among the containers
+		sidecars := map[string]bool{}
+		initContainers, _ := resources.NestedSlice(pod, "spec", "initContainers")
+		for _, item := range initContainers {
+			if container, ok := item.(map[string]interface{}); ok && container["restartPolicy"] == "Always" {
+				name, _ := container["name"].(string)
+				sidecars[name] = true
+				totalContainers++
+			}
+		}
+		initializing := false
+		sidecarRestarts := int64(0)
+		var lastSidecarRestart time.Time
+		initContainerStatuses, _ := status["initContainerStatuses"].([]interface{})
+		for i, cs := range initContainerStatuses {
+			containerStatus, _ := cs.(map[string]interface{})
+			name, _ := containerStatus["name"].(string)
+			rc, _ := resources.ToInt64(containerStatus["restartCount"])
+			restartCount += rc
+			var finished time.Time
+			if finishedAt, ok := resources.NestedString(containerStatus, "lastState", "terminated", "finishedAt"); ok {
+				finished, _ = time.Parse(time.RFC3339, finishedAt)
+			}
+			if finished.After(lastRestart) {
+				lastRestart = finished
+			}
+			if sidecars[name] {
+				sidecarRestarts += rc
+				if finished.After(lastSidecarRestart) {
+					lastSidecarRestart = finished
+				}
+			}
+			terminated, isTerminated := resources.NestedMap(containerStatus, "state", "terminated")
+			exitCode, _ := resources.ToInt64(terminated["exitCode"])
+			waitingReason, _ := resources.NestedString(containerStatus, "state", "waiting", "reason")
+			switch {
+			case isTerminated && exitCode == 0:
+				continue
+			case sidecars[name] && containerStatus["started"] == true:
+				if containerStatus["ready"] == true {
+					readyCount++
+				}
+				continue
+			case isTerminated:
+				if reason, _ := terminated["reason"].(string); reason != "" {
+					phase = "Init:" + reason
+				} else {
+					phase = fmt.Sprintf("Init:ExitCode:%d", exitCode)
+				}
+			case waitingReason != "" && waitingReason != "PodInitializing":
+				phase = "Init:" + waitingReason
+			default:
+				phase = fmt.Sprintf("Init:%d/%d", i, len(initContainers))
+			}
+			initializing = true
+			break
+		}
+		initialized := false
+		conditions, _ := status["conditions"].([]interface{})
+		for _, c := range conditions {
+			if cond, ok := c.(map[string]interface{}); ok && cond["type"] == "Initialized" && cond["status"] == "True" {
+				initialized = true
+			}
+		}
+
+		// Count containers and ready status; like kubectl's printer, a waiting or terminated
+		// container's reason (CrashLoopBackOff, Error, ...) replaces the phase
+		containerStatuses, _ := status["containerStatuses"].([]interface{})
+		totalContainers += len(containerStatuses)
+		if len(containerStatuses) > 0 && (!initializing || initialized) {
+			restartCount, lastRestart = sidecarRestarts, lastSidecarRestart
+			hasRunning := false
+			for i := len(containerStatuses) - 1; i >= 0; i-- {
+				if containerStatus, ok := containerStatuses[i].(map[string]interface{}); ok {
 					if ready, ok := containerStatus["ready"].(bool); ok && ready {
 						readyCount++
 					}
@@ -131,8 +201,35 @@ func buildPodCells(pod map[string]interface{}) []interface{} {
 					} else if rcFloat, ok := containerStatus["restartCount"].(float64); ok {
 						restartCount += int64(rcFloat)
 					}
+					if finishedAt, ok := resources.NestedString(containerStatus, "lastState", "terminated", "finishedAt"); ok {
+						if t, err := time.Parse(time.RFC3339, finishedAt); err == nil && t.After(lastRestart) {
+							lastRestart = t
+						}
+					}
+					_, running := resources.NestedMap(containerStatus, "state", "running")
+					if reason, _ := resources.NestedString(containerStatus, "state", "waiting", "reason"); reason != "" {
+						phase = reason
+					} else if terminated, ok := resources.NestedMap(containerStatus, "state", "terminated"); ok {
+						if reason, _ := terminated["reason"].(string); reason != "" {
+							phase = reason
+						} else {
+							phase = fmt.Sprintf("ExitCode:%v", terminated["exitCode"])
+						}
+					} else if running && containerStatus["ready"] == true {
+						hasRunning = true
+					}
 				}
 			}
+			if phase == "Completed" && hasRunning {
+				phase = "Running"
+			}
+		}
+	}
+
+	// Like kubectl's printer: pods marked for deletion show as Terminating
+	if meta, ok := pod["metadata"].(map[string]interface{}); ok {
+		if ts, ok := meta["deletionTimestamp"].(string); ok && ts != "" {
+			phase = "Terminating"
 		}
 	}
 
@@ -147,16 +244,28 @@ func buildPodCells(pod map[string]interface{}) []interface{} {
 	// Build ready string
 	ready := fmt.Sprintf("%d/%d", readyCount, totalContainers)
 
+	// Restarts, with how long ago the last one was
+	restarts := fmt.Sprint(restartCount)
+	if restartCount > 0 && !lastRestart.IsZero() {
+		restarts = fmt.Sprintf("%d (%s ago)", restartCount, formatAge(time.Since(lastRestart)))
+	}
+
+	// Node the scheduler bound the pod to
+	node := "<none>"
+	if nodeName, _ := resources.NestedString(pod, "spec", "nodeName"); nodeName != "" {
+		node = nodeName
+	}
+
 	// Return cells matching column definitions
 	return []interface{}{
 		name,           // Name
 		ready,          // Ready
 		phase,          // Status
-		restartCount,   // Restarts
+		restarts,       // Restarts
 		age,            // Age
 		podIP,          // IP
-		"",             // Node
-		"",             // Nominated Node
+		node,           // Node
+		"<none>",       // Nominated Node
 		"",             // Readiness Gates
 	}
 }
@@ -193,6 +302,12 @@ type WatchEvent struct {
 	Object interface{} `json:"object"`
 }
 
+// podFields are the pod field labels besides metadata.name/namespace (kubectl get pods --field-selector spec.nodeName=node-1)
+var podFields = map[string][]string{
+	"spec.nodeName": {"spec", "nodeName"},
+	"status.phase":  {"status", "phase"},
+}
+
 func ListPods(c *gin.Context) {
 	// Check if this is a watch request
 	if c.Query("watch") == "true" {
@@ -200,7 +315,12 @@ func ListPods(c *gin.Context) {
 		return
 	}
 
-	items := storage.DefaultStore.ListPods()
+	fields, err := parseFieldSelector(c.Query("fieldSelector"), podFields)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	items := filterByFields(storage.DefaultStore.ListPods(), fields)
 
 	// Check if client requests Table format (kubectl get)
 	acceptHeader := c.GetHeader("Accept")
@@ -240,91 +360,21 @@ func buildWatchEventObject(item interface{}, useTable bool) interface{} {
 }
 
 // WatchPods handles watch requests for pods
-// Returns a stream of watch events (ADDED, MODIFIED, DELETED)
+// Returns a stream of watch events (ADDED, MODIFIED, DELETED) as the store changes; pods are
+// sent as single-row Tables when the client asks for them (kubectl get -w)
 func WatchPods(c *gin.Context) {
-	// Set headers for streaming response
-	c.Header("Content-Type", "application/json")
-	c.Header("Transfer-Encoding", "chunked")
-	c.Header("Cache-Control", "no-cache")
-	c.Header("Connection", "keep-alive")
-
-	// Get the response writer for streaming
-	w := c.Writer
-
-	// Flush helper
-	flusher, ok := w.(http.Flusher)
-	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "streaming not supported"})
+	fields, err := parseFieldSelector(c.Query("fieldSelector"), podFields)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
 		return
 	}
-
-	// Check if client requests Table format (kubectl get -w sends this)
-	acceptHeader := c.GetHeader("Accept")
-	useTable := isTableRequest(acceptHeader)
-
-	// Get namespace filter if provided
-	namespace := c.Param("namespace")
-
-	// Send initial ADDED events for all existing pods
-	items := storage.DefaultStore.ListPods()
-	for _, item := range items {
-		// Filter by namespace if specified
-		if namespace != "" {
-			if obj, ok := item.(map[string]interface{}); ok {
-				if meta, ok := obj["metadata"].(map[string]interface{}); ok {
-					if ns, ok := meta["namespace"].(string); ok && ns != namespace {
-						continue
-					}
-				}
-			}
-		}
-
-		event := WatchEvent{
-			Type:   "ADDED",
-			Object: buildWatchEventObject(item, useTable),
-		}
-		data, _ := json.Marshal(event)
-		fmt.Fprintf(w, "%s\n", data)
-		flusher.Flush()
-	}
-
-	// Keep the connection alive and send periodic updates
-	// In a real implementation, this would watch for actual changes
-	ticker := time.NewTicker(5 * time.Second)
-	defer ticker.Stop()
-
-	// Send periodic MODIFIED events to keep the connection alive
-	// and simulate status updates
-	for {
-		select {
-		case <-c.Request.Context().Done():
-			// Client disconnected
-			return
-		case <-ticker.C:
-			// Send MODIFIED events for all pods with updated status
-			items := storage.DefaultStore.ListPods()
-			for _, item := range items {
-				// Filter by namespace if specified
-				if namespace != "" {
-					if obj, ok := item.(map[string]interface{}); ok {
-						if meta, ok := obj["metadata"].(map[string]interface{}); ok {
-							if ns, ok := meta["namespace"].(string); ok && ns != namespace {
-								continue
-							}
-						}
-					}
-				}
-
-				event := WatchEvent{
-					Type:   "MODIFIED",
-					Object: buildWatchEventObject(item, useTable),
-				}
-				data, _ := json.Marshal(event)
-				fmt.Fprintf(w, "%s\n", data)
-				flusher.Flush()
-			}
+	var render func(obj map[string]interface{}) interface{}
+	if isTableRequest(c.GetHeader("Accept")) {
+		render = func(obj map[string]interface{}) interface{} {
+			return buildWatchEventObject(obj, true)
 		}
 	}
+	watchObjectsAs(c, "Pod", storage.DefaultStore.ListPods, fields, render)
 }
 
 // CreatePod parses POST to custom resources.Pod struct (for mock control, no corev1/scheme).
@@ -345,52 +395,34 @@ func CreatePod(c *gin.Context) {
 		WriteError(c, http.StatusBadRequest, "invalid pod")
 		return
 	}
-	// validator for name
-	if errs := validation.IsDNS1123Label(pod.GetName()); len(errs) != 0 {
-		WriteError(c, http.StatusBadRequest, errs[0])
+	// apply upstream defaults (restartPolicy, dnsPolicy, imagePullPolicy, ...)
+	resources.SetPodDefaults(&pod)
+	// validator for name (or generateName prefix)
+	if !validateCreateName(c, &pod.Metadata) {
+		return
+	}
+	if !admitNamespace(c, &pod.Metadata) {
+		return
+	}
+	dryRun, err := isDryRun(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	// dryRun=All: run defaulting/validation only, no storage write or controller hooks
+	if dryRun {
+		dryRunCreate(c, "Pod", &pod)
 		return
 	}
 	// store; uses KubeObject impl from custom struct
-	if err := storage.DefaultStore.CreatePod(pod); err != nil {
+	if err := storage.DefaultStore.CreatePod(&pod); err != nil {
 		WriteError(c, http.StatusConflict, err.Error())
 		return
 	}
 
-	// Check if there's a pre-defined transition template for this pod
-	namespace := pod.GetNamespace()
-	if namespace == "" {
-		namespace = "default"
-	}
-
-	if controllers.DefaultTemplateRegistry != nil {
-		if template, exists := controllers.DefaultTemplateRegistry.GetTemplate(namespace, pod.GetName()); exists {
-			// Use the pre-defined transition sequence
-			if controllers.DefaultTransitionManager != nil {
-				req := controllers.TransitionRequest{
-					PodName:        pod.GetName(),
-					Namespace:      namespace,
-					CancelExisting: true,
-					Transitions:    template.Transitions,
-				}
-				controllers.DefaultTransitionManager.StartTransition(req)
-			}
-		} else {
-			// Use default controller behavior
-			if controllers.DefaultPodController != nil {
-				if err := controllers.DefaultPodController.OnPodCreated(pod); err != nil {
-					// Log error but don't fail the creation - the pod is already stored
-					_ = err
-				}
-			}
-		}
-	} else {
-		// Fallback to default controller behavior
-		if controllers.DefaultPodController != nil {
-			if err := controllers.DefaultPodController.OnPodCreated(pod); err != nil {
-				_ = err
-			}
-		}
-	}
+	// A pre-defined transition template (registered for the pod's name, or a scenario
+	// template matching it) drives the pod's status; else the pod controller does
+	controllers.StartPodLifecycle(storage.DefaultStore, pod)
 
 	// Return the pod with status from storage
 	storedPod, err := storage.DefaultStore.GetPod(pod.GetName())
@@ -417,6 +449,73 @@ func GetPod(c *gin.Context) {
 	c.JSON(http.StatusOK, storedPod)
 }
 
+// UpdatePod handles PUT /api/v1/pods/:name and /api/v1/namespaces/:namespace/pods/:name
+func UpdatePod(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	var pod resources.Pod
+	if err := json.Unmarshal(body, &pod); err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	replacePod(c, pod)
+}
+
+// PatchPod handles PATCH /api/v1/pods/:name and /api/v1/namespaces/:namespace/pods/:name
+func PatchPod(c *gin.Context) {
+	podName := c.Param("name")
+	existing, err := storage.DefaultStore.GetPod(podName)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("pods \"%s\" not found", podName))
+		return
+	}
+	var pod resources.Pod
+	if !readPatchedObject(c, existing, &pod) {
+		return
+	}
+	replacePod(c, pod)
+}
+
+// replacePod runs the update pipeline shared by PUT and PATCH.
+// Status is kept from storage: it belongs to the pod controller.
+func replacePod(c *gin.Context, pod resources.Pod) {
+	existing, err := storage.DefaultStore.GetPod(c.Param("name"))
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("pods \"%s\" not found", c.Param("name")))
+		return
+	}
+	if pod.Kind == "" {
+		WriteError(c, http.StatusBadRequest, "invalid pod")
+		return
+	}
+	if !checkUpdateName(c, pod.GetName()) {
+		return
+	}
+	resources.SetPodDefaults(&pod)
+	preserveMetadata(&pod.Metadata, existing)
+	pod.Status = existing["status"]
+
+	dryRun, err := isDryRun(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if dryRun {
+		dryRunUpdate(c, "Pod", &pod)
+		return
+	}
+
+	stored, err := storage.DefaultStore.UpdateObject("Pod", &pod)
+	if err != nil {
+		WriteError(c, http.StatusConflict, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, stored)
+}
+
 // DeletePod handles DELETE /api/v1/pods/:name and /api/v1/namespaces/:namespace/pods/:name
 // Deletes a pod by name
 func DeletePod(c *gin.Context) {
@@ -426,29 +525,63 @@ func DeletePod(c *gin.Context) {
 		namespace = "default"
 	}
 
+	opts, err := parseDeleteOptions(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	// Check if pod exists first
 	existingPod, err := storage.DefaultStore.GetPod(podName)
 	if err != nil {
 		WriteError(c, http.StatusNotFound, fmt.Sprintf("pods \"%s\" not found", podName))
 		return
 	}
+	if isDeleteDryRun(opts) {
+		c.JSON(http.StatusOK, existingPod)
+		return
+	}
 
-	// Cancel any active transitions for this pod
+	if err := setPropagationFinalizers("Pod", podName, opts); err != nil {
+		WriteError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Cancel any active transitions for this pod (the pod is shutting down)
 	if controllers.DefaultTransitionManager != nil {
 		controllers.DefaultTransitionManager.CancelTransition(namespace, podName)
 	}
 
-	// Remove any registered template for this pod
-	if controllers.DefaultTemplateRegistry != nil {
-		controllers.DefaultTemplateRegistry.RemoveTemplate(namespace, podName)
+	// Graceful deletion: gracePeriodSeconds from DeleteOptions, else the pod's
+	// terminationGracePeriodSeconds; 0 (kubectl --force --grace-period=0) deletes immediately
+	gracePeriod := controllers.PodGracePeriod(existingPod)
+	if opts.GracePeriodSeconds != nil {
+		gracePeriod = *opts.GracePeriodSeconds
+	}
+	if controllers.DefaultPodController == nil {
+		gracePeriod = 0
 	}
 
-	// Delete the pod
-	if err := storage.DefaultStore.DeletePod(podName); err != nil {
-		WriteError(c, http.StatusInternalServerError, err.Error())
+	if gracePeriod <= 0 {
+		if controllers.DefaultPodController != nil {
+			if _, err := controllers.DefaultPodController.DeletePod(podName, 0); err != nil {
+				WriteError(c, http.StatusInternalServerError, err.Error())
+				return
+			}
+		} else if err := storage.DefaultStore.DeletePod(podName); err != nil {
+			WriteError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+		// Return the deleted pod object (kubectl expects this)
+		c.JSON(http.StatusOK, deletedObject(storage.DefaultStore.GetPod, podName, existingPod))
 		return
 	}
 
-	// Return the deleted pod object (kubectl expects this)
-	c.JSON(http.StatusOK, existingPod)
+	// The pod stays visible as Terminating until the controller removes it
+	deletingPod, err := controllers.DefaultPodController.DeletePod(podName, gracePeriod)
+	if err != nil {
+		WriteError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, deletingPod)
 }
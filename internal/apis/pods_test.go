@@ -2,6 +2,7 @@ package apis
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -108,10 +109,10 @@ func TestWatchPods(t *testing.T) {
 	// Create request with watch=true
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
-	c.Request = httptest.NewRequest("GET", "/api/v1/pods?watch=true", nil)
-	c.Request = c.Request.WithContext(c.Request.Context())
+	ctx, cancel := context.WithCancel(context.Background())
+	c.Request = httptest.NewRequest("GET", "/api/v1/pods?watch=true", nil).WithContext(ctx)
 
-	// Run watch in goroutine with timeout
+	// Run watch in goroutine until the client goes away
 	done := make(chan bool)
 	go func() {
 		WatchPods(c)
@@ -120,6 +121,8 @@ func TestWatchPods(t *testing.T) {
 
 	// Wait a bit for initial events
 	time.Sleep(100 * time.Millisecond)
+	cancel()
+	<-done
 
 	// Check we got some output
 	if w.Body.Len() == 0 {
@@ -141,3 +144,223 @@ func TestWatchPods(t *testing.T) {
 		}
 	}
 }
+
+func TestWatchPodsStreamsChanges(t *testing.T) {
+	router := gin.New()
+	router.GET("/api/v1/pods", ListPods)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/v1/pods?watch=true&fieldSelector=metadata.name=watched-pod")
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	defer resp.Body.Close()
+	events := make(chan WatchEvent)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			var event WatchEvent
+			json.Unmarshal(scanner.Bytes(), &event)
+			events <- event
+		}
+		close(events)
+	}()
+
+	pod := &resources.Pod{
+		Kind:       "Pod",
+		APIVersion: "v1",
+		Metadata:   resources.ObjectMeta{Name: "watched-pod", Namespace: "default"},
+		Spec:       map[string]interface{}{"containers": []interface{}{map[string]interface{}{"name": "app", "image": "app:v1"}}},
+	}
+	// the server watches the store before it answers
+	storage.DefaultStore.CreatePod(pod)
+	storage.DefaultStore.MutatePod("watched-pod", func(stored map[string]interface{}) error {
+		stored["status"] = map[string]interface{}{"phase": "Running"}
+		return nil
+	})
+	storage.DefaultStore.DeletePod("watched-pod")
+
+	// every change arrives at once, well before the old 5s polling
+	for _, want := range []string{"ADDED", "MODIFIED", "DELETED"} {
+		select {
+		case event := <-events:
+			if event.Type != want {
+				t.Fatalf("Expected %s, got %s", want, event.Type)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Timed out waiting for %s", want)
+		}
+	}
+}
+
+func TestCreatePodDryRun(t *testing.T) {
+	body := `{"kind":"Pod","apiVersion":"v1","metadata":{"name":"dry-run-pod","namespace":"default"},
+		"spec":{"containers":[{"name":"nginx","image":"nginx"}]}}`
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/api/v1/namespaces/default/pods?dryRun=All", strings.NewReader(body))
+
+	CreatePod(c)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// the response carries defaults, but nothing is persisted
+	var response map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if spec := response["spec"].(map[string]interface{}); spec["restartPolicy"] != "Always" {
+		t.Errorf("Expected defaulted restartPolicy Always, got %v", spec["restartPolicy"])
+	}
+	if meta := response["metadata"].(map[string]interface{}); meta["uid"] == nil || meta["creationTimestamp"] == nil {
+		t.Errorf("Expected server-set uid and creationTimestamp, got %v", meta)
+	}
+	if _, err := storage.DefaultStore.GetPod("dry-run-pod"); err == nil {
+		t.Error("Expected dry-run pod not to be stored")
+	}
+
+	// a dry run hits the same name conflict as a real create
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/api/v1/namespaces/default/pods", strings.NewReader(body))
+	CreatePod(c)
+	defer storage.DefaultStore.DeletePod("dry-run-pod")
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/api/v1/namespaces/default/pods?dryRun=All", strings.NewReader(body))
+	CreatePod(c)
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 for an existing name, got %d", w.Code)
+	}
+
+	// unsupported dryRun values are rejected
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/api/v1/namespaces/default/pods?dryRun=Some", strings.NewReader(body))
+	CreatePod(c)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for dryRun=Some, got %d", w.Code)
+	}
+}
+
+func TestReplacePodRespondsWithStoredPod(t *testing.T) {
+	t.Cleanup(func() { storage.DefaultStore.DeletePod("replace-pod") })
+	pod := func(image string) string {
+		return `{"kind":"Pod","apiVersion":"v1","metadata":{"name":"replace-pod","namespace":"default"},
+			"spec":{"containers":[{"name":"app","image":"` + image + `"}]}}`
+	}
+	put := func(query, body string) (int, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("PUT", "/api/v1/namespaces/default/pods/replace-pod"+query, strings.NewReader(body))
+		c.Params = gin.Params{{Key: "namespace", Value: "default"}, {Key: "name", Value: "replace-pod"}}
+		UpdatePod(c)
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response
+	}
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("POST", "/api/v1/namespaces/default/pods", strings.NewReader(pod("nginx:1.25")))
+	CreatePod(c)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// a spec change bumps the generation, in a dry run as well
+	for _, query := range []string{"?dryRun=All", ""} {
+		code, response := put(query, pod("nginx:1.26"))
+		if code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %v", code, response)
+		}
+		if generation, _ := resources.NestedInt64(response, "metadata", "generation"); generation != 2 {
+			t.Errorf("Expected generation 2 in the %q response, got %d", query, generation)
+		}
+		if uid, _ := resources.NestedString(response, "metadata", "uid"); uid == "" {
+			t.Errorf("Expected the stored uid in the %q response", query)
+		}
+	}
+}
+
+func TestCreatePodGenerateName(t *testing.T) {
+	body := `{"kind":"Pod","apiVersion":"v1","metadata":{"generateName":"gen-pod-","namespace":"default"},
+		"spec":{"containers":[{"name":"nginx","image":"nginx"}]}}`
+
+	names := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("POST", "/api/v1/namespaces/default/pods", strings.NewReader(body))
+
+		CreatePod(c)
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		name := response["metadata"].(map[string]interface{})["name"].(string)
+		if !strings.HasPrefix(name, "gen-pod-") || len(name) != len("gen-pod-")+5 {
+			t.Errorf("Expected generated name gen-pod-xxxxx, got %q", name)
+		}
+		if _, err := storage.DefaultStore.GetPod(name); err != nil {
+			t.Errorf("Expected pod %s to be stored: %v", name, err)
+		}
+		names[name] = true
+	}
+	if len(names) != 3 {
+		t.Errorf("Expected 3 distinct generated names, got %v", names)
+	}
+}
+
+func TestBuildPodCellsInitContainers(t *testing.T) {
+	waiting := func(name, reason string, extra map[string]interface{}) interface{} {
+		status := map[string]interface{}{"name": name, "ready": false, "restartCount": float64(0),
+			"state": map[string]interface{}{"waiting": map[string]interface{}{"reason": reason}}}
+		for k, v := range extra {
+			status[k] = v
+		}
+		return status
+	}
+	completed := map[string]interface{}{"name": "migrate", "ready": true, "restartCount": float64(0),
+		"state": map[string]interface{}{"terminated": map[string]interface{}{"exitCode": float64(0), "reason": "Completed"}}}
+	sidecar := map[string]interface{}{"name": "proxy", "ready": true, "started": true, "restartCount": float64(0),
+		"state": map[string]interface{}{"running": map[string]interface{}{}}}
+	pod := func(initialized string, initStatuses ...interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"metadata": map[string]interface{}{"name": "web"},
+			"spec": map[string]interface{}{"initContainers": []interface{}{
+				map[string]interface{}{"name": "migrate"},
+				map[string]interface{}{"name": "proxy", "restartPolicy": "Always"},
+				map[string]interface{}{"name": "warmup"},
+			}},
+			"status": map[string]interface{}{
+				"phase":                 "Pending",
+				"conditions":            []interface{}{map[string]interface{}{"type": "Initialized", "status": initialized}},
+				"initContainerStatuses": initStatuses,
+				"containerStatuses":     []interface{}{waiting("app", "PodInitializing", nil)},
+			},
+		}
+	}
+
+	for _, tc := range []struct {
+		pod           map[string]interface{}
+		ready, status string
+	}{
+		{pod("False", waiting("migrate", "", map[string]interface{}{"state": map[string]interface{}{"running": map[string]interface{}{}}}),
+			waiting("proxy", "PodInitializing", nil), waiting("warmup", "PodInitializing", nil)), "0/2", "Init:0/3"},
+		{pod("False", completed, sidecar, waiting("warmup", "CrashLoopBackOff", nil)), "1/2", "Init:CrashLoopBackOff"},
+		{pod("False", completed, sidecar, map[string]interface{}{"name": "warmup", "state": map[string]interface{}{
+			"terminated": map[string]interface{}{"exitCode": float64(3)}}}), "1/2", "Init:ExitCode:3"},
+		{pod("True", completed, sidecar, completed), "1/2", "PodInitializing"},
+	} {
+		cells := buildPodCells(tc.pod)
+		if cells[1] != tc.ready || cells[2] != tc.status {
+			t.Errorf("Expected READY %s and STATUS %s, got %v and %v", tc.ready, tc.status, cells[1], cells[2])
+		}
+	}
+}
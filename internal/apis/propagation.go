@@ -0,0 +1,72 @@
+package apis
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+// DELETE propagation (kubectl --cascade=background|foreground|orphan). Like the upstream
+// registry, the requested policy is recorded as a finalizer on the object (orphan or
+// foregroundDeletion) before it is deleted; the garbage collector acts on it.
+
+// validatePropagation rejects unknown policies and conflicting orphanDependents.
+func validatePropagation(opts metav1.DeleteOptions) error {
+	if opts.OrphanDependents != nil && opts.PropagationPolicy != nil {
+		return fmt.Errorf("orphanDependents and propagationPolicy cannot be both set")
+	}
+	if opts.PropagationPolicy != nil {
+		switch *opts.PropagationPolicy {
+		case metav1.DeletePropagationBackground, metav1.DeletePropagationForeground, metav1.DeletePropagationOrphan:
+		default:
+			return fmt.Errorf("unsupported propagationPolicy %q", *opts.PropagationPolicy)
+		}
+	}
+	return nil
+}
+
+// setPropagationFinalizers records the requested policy on the stored object. Without an
+// explicit policy (or orphanDependents) existing GC finalizers are left as they are.
+func setPropagationFinalizers(kind, name string, opts metav1.DeleteOptions) error {
+	var policy metav1.DeletionPropagation
+	switch {
+	case opts.PropagationPolicy != nil:
+		policy = *opts.PropagationPolicy
+	case opts.OrphanDependents != nil && *opts.OrphanDependents:
+		policy = metav1.DeletePropagationOrphan
+	case opts.OrphanDependents != nil:
+		policy = metav1.DeletePropagationBackground
+	default:
+		return nil
+	}
+
+	_, err := storage.DefaultStore.MutateObject(kind, name, func(obj map[string]interface{}) error {
+		metadata, _ := obj["metadata"].(map[string]interface{})
+		if metadata == nil {
+			metadata = map[string]interface{}{}
+			obj["metadata"] = metadata
+		}
+		existing, _ := resources.NestedSlice(obj, "metadata", "finalizers")
+		finalizers := make([]interface{}, 0, len(existing)+1)
+		for _, f := range existing {
+			if f != metav1.FinalizerOrphanDependents && f != metav1.FinalizerDeleteDependents {
+				finalizers = append(finalizers, f)
+			}
+		}
+		switch policy {
+		case metav1.DeletePropagationOrphan:
+			finalizers = append(finalizers, metav1.FinalizerOrphanDependents)
+		case metav1.DeletePropagationForeground:
+			finalizers = append(finalizers, metav1.FinalizerDeleteDependents)
+		}
+		if len(finalizers) == 0 {
+			delete(metadata, "finalizers")
+		} else {
+			metadata["finalizers"] = finalizers
+		}
+		return nil
+	})
+	return err
+}
@@ -7,8 +7,6 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"k8s.io/apimachinery/pkg/util/validation"
-	"mockernetes/internal/controllers"
 	"mockernetes/internal/resources" // custom structs for mock control (no appsv1)
 	"mockernetes/internal/storage"
 )
@@ -25,9 +23,19 @@ func buildReplicaSetList(items []interface{}) string {
 	return string(b)
 }
 
+// ListReplicaSets also serves watch=true and filters by fieldSelector (kubectl rollout status).
 func ListReplicaSets(c *gin.Context) {
+	fields, err := parseFieldSelector(c.Query("fieldSelector"))
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if c.Query("watch") == "true" {
+		watchObjects(c, "ReplicaSet", storage.DefaultStore.ListReplicaSets, fields)
+		return
+	}
 	// ignore :namespace if present (mock simplification like pods)
-	items := storage.DefaultStore.ListReplicaSets()
+	items := filterByFields(storage.DefaultStore.ListReplicaSets(), fields)
 	c.Data(http.StatusOK, "application/json", []byte(buildReplicaSetList(items)))
 }
 
@@ -63,22 +71,31 @@ func CreateReplicaSet(c *gin.Context) {
 		WriteError(c, http.StatusBadRequest, "invalid replicaset")
 		return
 	}
-	// basic name validation (DNS label)
-	if errs := validation.IsDNS1123Label(rs.GetName()); len(errs) != 0 {
-		WriteError(c, http.StatusBadRequest, errs[0])
+	// apply upstream defaults (replicas, pod template)
+	resources.SetReplicaSetDefaults(&rs)
+	// validator for name (or generateName prefix)
+	if !validateCreateName(c, &rs.Metadata) {
+		return
+	}
+	if !admitNamespace(c, &rs.Metadata) {
+		return
+	}
+	dryRun, err := isDryRun(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	// dryRun=All: run defaulting/validation only, no storage write or controller hooks
+	if dryRun {
+		dryRunCreate(c, "ReplicaSet", &rs)
 		return
 	}
 	// store; uses KubeObject impl from custom struct
-	if err := storage.DefaultStore.CreateReplicaSet(rs); err != nil {
+	if err := storage.DefaultStore.CreateReplicaSet(&rs); err != nil {
 		WriteError(c, http.StatusConflict, err.Error())
 		return
 	}
 
-	// Notify the ReplicaSet controller to manage pods
-	if controllers.DefaultReplicaSetController != nil {
-		controllers.DefaultReplicaSetController.OnReplicaSetCreated(rs)
-	}
-
 	// Return the stored ReplicaSet with any status updates
 	storedRS, err := storage.DefaultStore.GetReplicaSet(rs.GetName())
 	if err != nil {
@@ -88,12 +105,81 @@ func CreateReplicaSet(c *gin.Context) {
 	c.JSON(http.StatusCreated, storedRS)
 }
 
+// UpdateReplicaSet handles PUT /apis/apps/v1/namespaces/:namespace/replicasets/:name
+func UpdateReplicaSet(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	var rs resources.ReplicaSet
+	if err := json.Unmarshal(body, &rs); err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	replaceReplicaSet(c, rs)
+}
+
+// PatchReplicaSet handles PATCH /apis/apps/v1/namespaces/:namespace/replicasets/:name
+func PatchReplicaSet(c *gin.Context) {
+	rsName := c.Param("name")
+	existing, err := storage.DefaultStore.GetReplicaSet(rsName)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("replicasets.apps \"%s\" not found", rsName))
+		return
+	}
+	var rs resources.ReplicaSet
+	if !readPatchedObject(c, existing, &rs) {
+		return
+	}
+	replaceReplicaSet(c, rs)
+}
+
+// replaceReplicaSet runs the update pipeline shared by PUT and PATCH.
+func replaceReplicaSet(c *gin.Context, rs resources.ReplicaSet) {
+	existing, err := storage.DefaultStore.GetReplicaSet(c.Param("name"))
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("replicasets.apps \"%s\" not found", c.Param("name")))
+		return
+	}
+	if rs.Kind == "" {
+		WriteError(c, http.StatusBadRequest, "invalid replicaset")
+		return
+	}
+	if !checkUpdateName(c, rs.GetName()) {
+		return
+	}
+	resources.SetReplicaSetDefaults(&rs)
+	preserveMetadata(&rs.Metadata, existing)
+	// status is owned by the controller (status subresource), not the main resource
+	rs.Status = existing["status"]
+
+	dryRun, err := isDryRun(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if dryRun {
+		dryRunUpdate(c, "ReplicaSet", &rs)
+		return
+	}
+
+	stored, err := storage.DefaultStore.UpdateObject("ReplicaSet", &rs)
+	if err != nil {
+		WriteError(c, http.StatusConflict, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, stored)
+}
+
 // DeleteReplicaSet handles DELETE /apis/apps/v1/namespaces/:namespace/replicasets/:name
 func DeleteReplicaSet(c *gin.Context) {
 	rsName := c.Param("name")
-	namespace := c.Param("namespace")
-	if namespace == "" {
-		namespace = "default"
+
+	opts, err := parseDeleteOptions(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
 	}
 
 	// Check if ReplicaSet exists
@@ -102,17 +188,20 @@ func DeleteReplicaSet(c *gin.Context) {
 		WriteError(c, http.StatusNotFound, fmt.Sprintf("replicasets.apps \"%s\" not found", rsName))
 		return
 	}
-
-	// Notify the controller to clean up pods
-	if controllers.DefaultReplicaSetController != nil {
-		controllers.DefaultReplicaSetController.OnReplicaSetDeleted(rsName, namespace)
+	if isDeleteDryRun(opts) {
+		c.JSON(http.StatusOK, rs)
+		return
 	}
 
 	// Delete the ReplicaSet
+	if err := setPropagationFinalizers("ReplicaSet", rsName, opts); err != nil {
+		WriteError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
 	if err := storage.DefaultStore.DeleteReplicaSet(rsName); err != nil {
 		WriteError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	c.JSON(http.StatusOK, rs)
+	c.JSON(http.StatusOK, deletedObject(storage.DefaultStore.GetReplicaSet, rsName, rs))
 }
@@ -0,0 +1,272 @@
+package apis
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"mockernetes/internal/controllers"
+	"mockernetes/internal/resources" // custom structs for mock control (no corev1)
+	"mockernetes/internal/storage"
+)
+
+// Services get their cluster IPs and node ports on create (see controllers.ServiceAllocator);
+// the endpointslice controller publishes the pods they select as EndpointSlices.
+
+// buildServiceList wraps store items into K8s list (like configmaps; uses custom resources.Service).
+func buildServiceList(items []interface{}) string {
+	list := map[string]interface{}{
+		"kind":       "ServiceList",
+		"apiVersion": "v1",
+		"metadata":   map[string]string{"resourceVersion": "1"},
+		"items":      items,
+	}
+	b, _ := json.Marshal(list)
+	return string(b)
+}
+
+// ListServices also serves watch=true and filters by fieldSelector and the URL namespace.
+func ListServices(c *gin.Context) {
+	fields, err := parseFieldSelector(c.Query("fieldSelector"))
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if c.Query("watch") == "true" {
+		watchObjects(c, "Service", storage.DefaultStore.ListServices, fields)
+		return
+	}
+	namespace := c.Param("namespace")
+	items := []interface{}{}
+	for _, item := range filterByFields(storage.DefaultStore.ListServices(), fields) {
+		if obj, ok := item.(map[string]interface{}); ok && inNamespace(obj, namespace) {
+			items = append(items, item)
+		}
+	}
+	c.Data(http.StatusOK, "application/json", []byte(buildServiceList(items)))
+}
+
+// GetService handles GET /api/v1/namespaces/:namespace/services/:name
+func GetService(c *gin.Context) {
+	svcName := c.Param("name")
+
+	svc, err := storage.DefaultStore.GetService(svcName)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("services \"%s\" not found", svcName))
+		return
+	}
+
+	c.JSON(http.StatusOK, svc)
+}
+
+// CreateService parses POST to custom resources.Service struct (for mock control, no corev1/scheme).
+// Validates, allocates cluster IPs and node ports, stores if not exists.
+func CreateService(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	var svc resources.Service
+	if err := json.Unmarshal(body, &svc); err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if svc.Kind == "" {
+		WriteError(c, http.StatusBadRequest, "invalid service")
+		return
+	}
+	// validator for name (or generateName prefix)
+	if !validateCreateName(c, &svc.Metadata) {
+		return
+	}
+	resources.SetServiceDefaults(&svc)
+	if !validateService(c, svc.Spec) {
+		return
+	}
+	if !admitNamespace(c, &svc.Metadata) {
+		return
+	}
+	if err := controllers.AllocateServiceAddresses(storage.DefaultStore, &svc, nil); err != nil {
+		WriteError(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	dryRun, err := isDryRun(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	// dryRun=All: run defaulting/validation/allocation only, no storage write
+	if dryRun {
+		dryRunCreate(c, "Service", &svc)
+		return
+	}
+	if err := storage.DefaultStore.CreateService(&svc); err != nil {
+		WriteError(c, http.StatusConflict, err.Error())
+		return
+	}
+
+	storedSvc, err := storage.DefaultStore.GetService(svc.GetName())
+	if err != nil {
+		c.JSON(http.StatusCreated, svc)
+		return
+	}
+	c.JSON(http.StatusCreated, storedSvc)
+}
+
+// UpdateService handles PUT /api/v1/namespaces/:namespace/services/:name
+func UpdateService(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	var svc resources.Service
+	if err := json.Unmarshal(body, &svc); err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	replaceService(c, svc)
+}
+
+// PatchService handles PATCH /api/v1/namespaces/:namespace/services/:name
+func PatchService(c *gin.Context) {
+	svcName := c.Param("name")
+	existing, err := storage.DefaultStore.GetService(svcName)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("services \"%s\" not found", svcName))
+		return
+	}
+	var svc resources.Service
+	if !readPatchedObject(c, existing, &svc) {
+		return
+	}
+	replaceService(c, svc)
+}
+
+// replaceService runs the update pipeline shared by PUT and PATCH. Cluster IPs are immutable;
+// node ports are kept for the ports that had one.
+func replaceService(c *gin.Context, svc resources.Service) {
+	existing, err := storage.DefaultStore.GetService(c.Param("name"))
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("services \"%s\" not found", c.Param("name")))
+		return
+	}
+	if svc.Kind == "" {
+		WriteError(c, http.StatusBadRequest, "invalid service")
+		return
+	}
+	if !checkUpdateName(c, svc.GetName()) {
+		return
+	}
+	preserveMetadata(&svc.Metadata, existing)
+	resources.SetServiceDefaults(&svc)
+	if !validateService(c, svc.Spec) {
+		return
+	}
+	if err := controllers.AllocateServiceAddresses(storage.DefaultStore, &svc, existing); err != nil {
+		WriteError(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	// status is owned by the controllers (status subresource), not the main resource
+	svc.Status = existing["status"]
+
+	dryRun, err := isDryRun(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if dryRun {
+		dryRunUpdate(c, "Service", &svc)
+		return
+	}
+
+	stored, err := storage.DefaultStore.UpdateObject("Service", &svc)
+	if err != nil {
+		WriteError(c, http.StatusConflict, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, stored)
+}
+
+// DeleteService handles DELETE /api/v1/namespaces/:namespace/services/:name
+// (its cluster IPs and node ports are free again once it is gone).
+func DeleteService(c *gin.Context) {
+	svcName := c.Param("name")
+
+	opts, err := parseDeleteOptions(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	svc, err := storage.DefaultStore.GetService(svcName)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("services \"%s\" not found", svcName))
+		return
+	}
+	if isDeleteDryRun(opts) {
+		c.JSON(http.StatusOK, svc)
+		return
+	}
+
+	if err := setPropagationFinalizers("Service", svcName, opts); err != nil {
+		WriteError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := storage.DefaultStore.DeleteService(svcName); err != nil {
+		WriteError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, deletedObject(storage.DefaultStore.GetService, svcName, svc))
+}
+
+// validateService checks the type, ports and externalName of a defaulted Service spec.
+func validateService(c *gin.Context, spec interface{}) bool {
+	specMap, _ := spec.(map[string]interface{})
+	if specMap == nil {
+		WriteError(c, http.StatusUnprocessableEntity, "spec: Required value")
+		return false
+	}
+	svcType, _ := specMap["type"].(string)
+	switch svcType {
+	case "ClusterIP", "NodePort", "LoadBalancer":
+	case "ExternalName":
+		if name, _ := specMap["externalName"].(string); name == "" {
+			WriteError(c, http.StatusUnprocessableEntity, "spec.externalName: Required value")
+			return false
+		}
+	default:
+		WriteError(c, http.StatusUnprocessableEntity, fmt.Sprintf(`spec.type: Unsupported value: %q: supported values: "ClusterIP", "ExternalName", "LoadBalancer", "NodePort"`, svcType))
+		return false
+	}
+
+	ports, _ := specMap["ports"].([]interface{})
+	headless := specMap["clusterIP"] == controllers.ClusterIPNone
+	if len(ports) == 0 && !headless && svcType != "ExternalName" {
+		WriteError(c, http.StatusUnprocessableEntity, "spec.ports: Required value")
+		return false
+	}
+	names := map[string]bool{}
+	for i, item := range ports {
+		port, _ := item.(map[string]interface{})
+		if n, _ := resources.ToInt64(port["port"]); n < 1 || n > 65535 {
+			WriteError(c, http.StatusUnprocessableEntity, fmt.Sprintf("spec.ports[%d].port: Invalid value: %v: must be between 1 and 65535, inclusive", i, port["port"]))
+			return false
+		}
+		name, _ := port["name"].(string)
+		if name == "" && len(ports) > 1 {
+			WriteError(c, http.StatusUnprocessableEntity, fmt.Sprintf("spec.ports[%d].name: Required value", i))
+			return false
+		}
+		if names[name] {
+			WriteError(c, http.StatusUnprocessableEntity, fmt.Sprintf("spec.ports[%d].name: Duplicate value: %q", i, name))
+			return false
+		}
+		names[name] = true
+	}
+	return true
+}
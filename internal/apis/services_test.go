@@ -0,0 +1,72 @@
+package apis
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// serveService runs a Service handler on a request body and decodes the response
+func serveService(t *testing.T, handler gin.HandlerFunc, method, name, contentType, body string) (int, map[string]interface{}) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, "/api/v1/namespaces/default/services/"+name, strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", contentType)
+	c.Params = gin.Params{{Key: "namespace", Value: "default"}, {Key: "name", Value: name}}
+	handler(c)
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	return w.Code, response
+}
+
+func TestCreateAndApplyService(t *testing.T) {
+	t.Cleanup(func() { storage.DefaultStore.DeleteService("apply-web") })
+	body := `{"kind":"Service","apiVersion":"v1","metadata":{"name":"apply-web"},"spec":{"type":"NodePort","selector":{"app":"web"},"ports":[{"port":80,"targetPort":8080}]}}`
+	code, created := serveService(t, CreateService, "POST", "", "application/json", body)
+	if code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %v", code, created)
+	}
+	clusterIP, _ := resources.NestedString(created, "spec", "clusterIP")
+	ports, _ := resources.NestedSlice(created, "spec", "ports")
+	nodePort := ports[0].(map[string]interface{})["nodePort"]
+	if clusterIP == "" || nodePort == nil || ports[0].(map[string]interface{})["protocol"] != "TCP" {
+		t.Fatalf("Expected an allocated clusterIP and nodePort, got %v", created["spec"])
+	}
+
+	// kubectl apply sends a strategic merge patch without the allocated values
+	patch := `{"spec":{"ports":[{"port":80,"targetPort":9090}]}}`
+	code, patched := serveService(t, PatchService, "PATCH", "apply-web", "application/strategic-merge-patch+json", patch)
+	if code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %v", code, patched)
+	}
+	ports, _ = resources.NestedSlice(patched, "spec", "ports")
+	if ip, _ := resources.NestedString(patched, "spec", "clusterIP"); ip != clusterIP || ports[0].(map[string]interface{})["nodePort"] != nodePort {
+		t.Errorf("Expected clusterIP %s and nodePort %v to be kept, got %v", clusterIP, nodePort, patched["spec"])
+	}
+	if ports[0].(map[string]interface{})["targetPort"] != float64(9090) {
+		t.Errorf("Expected targetPort 9090, got %v", ports[0])
+	}
+
+	// the node port is taken
+	body = `{"kind":"Service","apiVersion":"v1","metadata":{"name":"apply-other"},"spec":{"type":"NodePort","ports":[{"port":80,"nodePort":` + jsonNumber(nodePort) + `}]}}`
+	if code, response := serveService(t, CreateService, "POST", "", "application/json", body); code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422 for a taken nodePort, got %d: %v", code, response)
+	}
+	body = `{"kind":"Service","apiVersion":"v1","metadata":{"name":"apply-bad"},"spec":{"type":"Bogus","ports":[{"port":80}]}}`
+	if code, response := serveService(t, CreateService, "POST", "", "application/json", body); code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422 for an unknown type, got %d: %v", code, response)
+	}
+}
+
+func jsonNumber(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
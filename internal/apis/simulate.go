@@ -15,6 +15,7 @@ type SimulatePodRequest struct {
 	PodName     string                        `json:"podName"`
 	Namespace   string                        `json:"namespace,omitempty"`
 	Transitions []controllers.TransitionState `json:"transitions"`
+	Seed        *int64                        `json:"seed,omitempty"`
 }
 
 // SimulatePodResponse is the response for a simulate request
@@ -31,6 +32,7 @@ type TransitionTemplateResponse struct {
 	PodName     string                        `json:"podName"`
 	Namespace   string                        `json:"namespace"`
 	Transitions []controllers.TransitionState `json:"transitions"`
+	Seed        *int64                        `json:"seed,omitempty"`
 	CreatedAt   string                        `json:"createdAt"`
 }
 
@@ -94,10 +96,11 @@ func SimulatePod(c *gin.Context) {
 		PodName:     req.PodName,
 		Namespace:   namespace,
 		Transitions: req.Transitions,
+		Seed:        req.Seed,
 	}
 
 	if err := controllers.DefaultTemplateRegistry.RegisterTemplate(template); err != nil {
-		c.JSON(http.StatusInternalServerError, SimulatePodResponse{
+		c.JSON(http.StatusBadRequest, SimulatePodResponse{
 			Success: false,
 			Message: err.Error(),
 		})
@@ -178,6 +181,7 @@ func ListActiveTransitions(c *gin.Context) {
 			PodName:     t.PodName,
 			Namespace:   t.Namespace,
 			Transitions: t.Transitions,
+			Seed:        t.Seed,
 			CreatedAt:   t.CreatedAt.Format("2006-01-02T15:04:05Z"),
 		})
 	}
@@ -221,6 +225,7 @@ func GetPodTransition(c *gin.Context) {
 			PodName:     template.PodName,
 			Namespace:   template.Namespace,
 			Transitions: template.Transitions,
+			Seed:        template.Seed,
 			CreatedAt:   template.CreatedAt.Format("2006-01-02T15:04:05Z"),
 		},
 	})
@@ -0,0 +1,119 @@
+package apis
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"mockernetes/internal/controllers"
+	"mockernetes/internal/storage"
+)
+
+// SimulateNodeRequest sets the simulated health of a node's kubelet
+type SimulateNodeRequest struct {
+	// Condition is Ready, NotReady (the kubelet reports Ready=False) or Unreachable (the
+	// kubelet stops renewing its Lease; the node turns Unknown after the grace period)
+	Condition controllers.NodeHealth `json:"condition"`
+}
+
+// SimulateNodeResponse is the response for a node simulate request
+type SimulateNodeResponse struct {
+	Success   bool                   `json:"success"`
+	Message   string                 `json:"message"`
+	NodeName  string                 `json:"nodeName,omitempty"`
+	Condition controllers.NodeHealth `json:"condition,omitempty"`
+}
+
+// SimulateNode handles POST /simulate/controller/node/:name
+// Marks a node NotReady or Unreachable (or Ready again) to rehearse node failures
+func SimulateNode(c *gin.Context) {
+	nodeName := c.Param("name")
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, SimulateNodeResponse{
+			Success: false,
+			Message: "Failed to read request body: " + err.Error(),
+		})
+		return
+	}
+
+	var req SimulateNodeRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		c.JSON(http.StatusBadRequest, SimulateNodeResponse{
+			Success: false,
+			Message: "Invalid JSON: " + err.Error(),
+		})
+		return
+	}
+
+	if controllers.DefaultNodeLifecycleController == nil {
+		c.JSON(http.StatusServiceUnavailable, SimulateNodeResponse{
+			Success: false,
+			Message: "Node lifecycle controller not initialized",
+		})
+		return
+	}
+
+	if _, err := storage.DefaultStore.GetNode(nodeName); err != nil {
+		c.JSON(http.StatusNotFound, SimulateNodeResponse{
+			Success:  false,
+			Message:  "Node not found",
+			NodeName: nodeName,
+		})
+		return
+	}
+
+	if err := controllers.DefaultNodeLifecycleController.SetNodeHealth(nodeName, req.Condition); err != nil {
+		c.JSON(http.StatusBadRequest, SimulateNodeResponse{
+			Success:  false,
+			Message:  err.Error(),
+			NodeName: nodeName,
+		})
+		return
+	}
+
+	message := "Node kubelet is " + string(req.Condition)
+	if req.Condition == controllers.NodeHealthUnreachable {
+		message += "; the node turns Unknown once its Lease expires"
+	}
+	c.JSON(http.StatusOK, SimulateNodeResponse{
+		Success:   true,
+		Message:   message,
+		NodeName:  nodeName,
+		Condition: req.Condition,
+	})
+}
+
+// ListNodeSimulations handles GET /simulate/controller/node
+// Returns the simulated kubelet health of every node
+func ListNodeSimulations(c *gin.Context) {
+	if controllers.DefaultNodeLifecycleController == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"message": "Node lifecycle controller not initialized",
+		})
+		return
+	}
+
+	nodes := []SimulateNodeResponse{}
+	for _, item := range storage.DefaultStore.ListNodes() {
+		node, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		meta, _ := node["metadata"].(map[string]interface{})
+		name, _ := meta["name"].(string)
+		nodes = append(nodes, SimulateNodeResponse{
+			Success:   true,
+			NodeName:  name,
+			Condition: controllers.DefaultNodeLifecycleController.NodeHealth(name),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"count":   len(nodes),
+		"nodes":   nodes,
+	})
+}
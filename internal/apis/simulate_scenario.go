@@ -0,0 +1,116 @@
+package apis
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"mockernetes/internal/controllers"
+)
+
+// SimulateScenarioResponse is the response for a scenario request
+type SimulateScenarioResponse struct {
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	Name      string `json:"name,omitempty"`
+	Templates int    `json:"templates,omitempty"`
+	Replaced  bool   `json:"replaced,omitempty"`
+}
+
+// LoadScenario handles POST /simulate/scenarios
+// Loads a scenario (YAML or JSON) whose templates apply to the pods they match from now on
+func LoadScenario(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, SimulateScenarioResponse{
+			Success: false,
+			Message: "Failed to read request body: " + err.Error(),
+		})
+		return
+	}
+
+	scenario, err := controllers.ParseScenario(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, SimulateScenarioResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if controllers.DefaultTemplateRegistry == nil {
+		c.JSON(http.StatusServiceUnavailable, SimulateScenarioResponse{
+			Success: false,
+			Message: "Template registry not initialized",
+		})
+		return
+	}
+
+	replaced, err := controllers.DefaultTemplateRegistry.LoadScenario(*scenario)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, SimulateScenarioResponse{
+			Success: false,
+			Message: err.Error(),
+			Name:    scenario.Name,
+		})
+		return
+	}
+
+	message := "Scenario loaded"
+	if replaced {
+		message = "Scenario replaced"
+	}
+	c.JSON(http.StatusOK, SimulateScenarioResponse{
+		Success:   true,
+		Message:   message,
+		Name:      scenario.Name,
+		Templates: len(scenario.Templates),
+		Replaced:  replaced,
+	})
+}
+
+// ListScenarios handles GET /simulate/scenarios
+// Returns the loaded scenarios with the number of pods each template matched
+func ListScenarios(c *gin.Context) {
+	if controllers.DefaultTemplateRegistry == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"message": "Template registry not initialized",
+		})
+		return
+	}
+
+	scenarios := controllers.DefaultTemplateRegistry.ListScenarios()
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"count":     len(scenarios),
+		"scenarios": scenarios,
+	})
+}
+
+// DeleteScenario handles DELETE /simulate/scenarios/:name
+// Unloads a scenario; transitions it already started keep running
+func DeleteScenario(c *gin.Context) {
+	name := c.Param("name")
+	if controllers.DefaultTemplateRegistry == nil {
+		c.JSON(http.StatusServiceUnavailable, SimulateScenarioResponse{
+			Success: false,
+			Message: "Template registry not initialized",
+		})
+		return
+	}
+
+	if !controllers.DefaultTemplateRegistry.RemoveScenario(name) {
+		c.JSON(http.StatusNotFound, SimulateScenarioResponse{
+			Success: false,
+			Message: "Scenario not found",
+			Name:    name,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, SimulateScenarioResponse{
+		Success: true,
+		Message: "Scenario removed",
+		Name:    name,
+	})
+}
@@ -0,0 +1,206 @@
+package apis
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"mockernetes/internal/resources" // custom structs for mock control (no appsv1)
+	"mockernetes/internal/storage"
+)
+
+// buildStatefulSetList wraps store items into K8s list (like replicasets; uses custom resources.StatefulSet structs).
+func buildStatefulSetList(items []interface{}) string {
+	list := map[string]interface{}{
+		"kind":       "StatefulSetList",
+		"apiVersion": "apps/v1",
+		"metadata":   map[string]string{"resourceVersion": "1"},
+		"items":      items,
+	}
+	b, _ := json.Marshal(list)
+	return string(b)
+}
+
+// ListStatefulSets also serves watch=true and filters by fieldSelector (kubectl rollout status).
+func ListStatefulSets(c *gin.Context) {
+	fields, err := parseFieldSelector(c.Query("fieldSelector"))
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if c.Query("watch") == "true" {
+		watchObjects(c, "StatefulSet", storage.DefaultStore.ListStatefulSets, fields)
+		return
+	}
+	// ignore :namespace if present (mock simplification like pods)
+	items := filterByFields(storage.DefaultStore.ListStatefulSets(), fields)
+	c.Data(http.StatusOK, "application/json", []byte(buildStatefulSetList(items)))
+}
+
+// GetStatefulSet handles GET /apis/apps/v1/namespaces/:namespace/statefulsets/:name
+func GetStatefulSet(c *gin.Context) {
+	stsName := c.Param("name")
+
+	sts, err := storage.DefaultStore.GetStatefulSet(stsName)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("statefulsets.apps \"%s\" not found", stsName))
+		return
+	}
+
+	c.JSON(http.StatusOK, sts)
+}
+
+// CreateStatefulSet parses POST to custom resources.StatefulSet struct (for mock control, no appsv1/scheme).
+// Validates, stores if not exists, and triggers the controller to manage pods.
+func CreateStatefulSet(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	// Unmarshal to custom struct
+	var sts resources.StatefulSet
+	if err := json.Unmarshal(body, &sts); err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if sts.Kind == "" {
+		WriteError(c, http.StatusBadRequest, "invalid statefulset")
+		return
+	}
+	// apply upstream defaults (replicas, pod management, update strategy, templates)
+	resources.SetStatefulSetDefaults(&sts)
+	// validator for name (or generateName prefix)
+	if !validateCreateName(c, &sts.Metadata) {
+		return
+	}
+	if !admitNamespace(c, &sts.Metadata) {
+		return
+	}
+	dryRun, err := isDryRun(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	// dryRun=All: run defaulting/validation only, no storage write or controller hooks
+	if dryRun {
+		dryRunCreate(c, "StatefulSet", &sts)
+		return
+	}
+	// store; uses KubeObject impl from custom struct
+	if err := storage.DefaultStore.CreateStatefulSet(&sts); err != nil {
+		WriteError(c, http.StatusConflict, err.Error())
+		return
+	}
+
+	// Return the stored StatefulSet with any status updates
+	storedSTS, err := storage.DefaultStore.GetStatefulSet(sts.GetName())
+	if err != nil {
+		c.JSON(http.StatusCreated, sts)
+		return
+	}
+	c.JSON(http.StatusCreated, storedSTS)
+}
+
+// UpdateStatefulSet handles PUT /apis/apps/v1/namespaces/:namespace/statefulsets/:name
+func UpdateStatefulSet(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	var sts resources.StatefulSet
+	if err := json.Unmarshal(body, &sts); err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	replaceStatefulSet(c, sts)
+}
+
+// PatchStatefulSet handles PATCH /apis/apps/v1/namespaces/:namespace/statefulsets/:name
+func PatchStatefulSet(c *gin.Context) {
+	stsName := c.Param("name")
+	existing, err := storage.DefaultStore.GetStatefulSet(stsName)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("statefulsets.apps \"%s\" not found", stsName))
+		return
+	}
+	var sts resources.StatefulSet
+	if !readPatchedObject(c, existing, &sts) {
+		return
+	}
+	replaceStatefulSet(c, sts)
+}
+
+// replaceStatefulSet runs the update pipeline shared by PUT and PATCH.
+func replaceStatefulSet(c *gin.Context, sts resources.StatefulSet) {
+	existing, err := storage.DefaultStore.GetStatefulSet(c.Param("name"))
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("statefulsets.apps \"%s\" not found", c.Param("name")))
+		return
+	}
+	if sts.Kind == "" {
+		WriteError(c, http.StatusBadRequest, "invalid statefulset")
+		return
+	}
+	if !checkUpdateName(c, sts.GetName()) {
+		return
+	}
+	resources.SetStatefulSetDefaults(&sts)
+	preserveMetadata(&sts.Metadata, existing)
+	// status is owned by the controller (status subresource), not the main resource
+	sts.Status = existing["status"]
+
+	dryRun, err := isDryRun(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if dryRun {
+		dryRunUpdate(c, "StatefulSet", &sts)
+		return
+	}
+
+	stored, err := storage.DefaultStore.UpdateObject("StatefulSet", &sts)
+	if err != nil {
+		WriteError(c, http.StatusConflict, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, stored)
+}
+
+// DeleteStatefulSet handles DELETE /apis/apps/v1/namespaces/:namespace/statefulsets/:name
+func DeleteStatefulSet(c *gin.Context) {
+	stsName := c.Param("name")
+
+	opts, err := parseDeleteOptions(c)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Check if StatefulSet exists
+	sts, err := storage.DefaultStore.GetStatefulSet(stsName)
+	if err != nil {
+		WriteError(c, http.StatusNotFound, fmt.Sprintf("statefulsets.apps \"%s\" not found", stsName))
+		return
+	}
+	if isDeleteDryRun(opts) {
+		c.JSON(http.StatusOK, sts)
+		return
+	}
+
+	// Delete the StatefulSet
+	if err := setPropagationFinalizers("StatefulSet", stsName, opts); err != nil {
+		WriteError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := storage.DefaultStore.DeleteStatefulSet(stsName); err != nil {
+		WriteError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, deletedObject(storage.DefaultStore.GetStatefulSet, stsName, sts))
+}
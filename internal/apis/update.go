@@ -0,0 +1,64 @@
+package apis
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"mockernetes/internal/resources"
+)
+
+// Shared plumbing for PUT/PATCH handlers. Each kind has an UpdateX (PUT body) and
+// PatchX (patch applied to the stored object) that both end in a kind-specific replaceX,
+// so defaulting, validation and dryRun run the same way for both verbs.
+
+// readPatchedObject applies the PATCH body to existing and decodes the result into out.
+// Writes the error response and returns false on failure.
+func readPatchedObject(c *gin.Context, existing map[string]interface{}, out interface{}) bool {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		WriteError(c, http.StatusBadRequest, err.Error())
+		return false
+	}
+	patched, err := applyPatch(c.ContentType(), existing, body)
+	if err != nil {
+		if errors.Is(err, errUnsupportedPatch) {
+			WriteError(c, http.StatusUnsupportedMediaType, err.Error())
+		} else {
+			WriteError(c, http.StatusUnprocessableEntity, err.Error())
+		}
+		return false
+	}
+	if err := remarshal(patched, out); err != nil {
+		WriteError(c, http.StatusUnprocessableEntity, err.Error())
+		return false
+	}
+	return true
+}
+
+// checkUpdateName rejects bodies whose metadata.name differs from the URL.
+func checkUpdateName(c *gin.Context, name string) bool {
+	if urlName := c.Param("name"); name != urlName {
+		WriteError(c, http.StatusBadRequest, fmt.Sprintf("the name of the object (%s) does not match the name on the URL (%s)", name, urlName))
+		return false
+	}
+	return true
+}
+
+// preserveMetadata carries server-owned metadata over from the stored object.
+// Finalizers stay client-owned: removing the last one from an object being deleted removes it.
+func preserveMetadata(meta *resources.ObjectMeta, existing map[string]interface{}) {
+	if ct, ok := resources.NestedString(existing, "metadata", "creationTimestamp"); ok {
+		meta.CreationTimestamp = ct
+	}
+	meta.UID, _ = resources.NestedString(existing, "metadata", "uid")
+	// storage moves generation on when the spec changes
+	meta.Generation, _ = resources.NestedInt64(existing, "metadata", "generation")
+	meta.DeletionTimestamp, _ = resources.NestedString(existing, "metadata", "deletionTimestamp")
+	meta.DeletionGracePeriodSeconds = nil
+	if grace, ok := resources.NestedInt64(existing, "metadata", "deletionGracePeriodSeconds"); ok {
+		meta.DeletionGracePeriodSeconds = &grace
+	}
+}
@@ -0,0 +1,167 @@
+package apis
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+// List filtering and event-driven watches for the kinds served from store events
+// (kubectl rollout status lists and watches a single Deployment through a fieldSelector).
+
+// fieldRequirement is one term of a fieldSelector, e.g. metadata.name=web or metadata.namespace!=dev.
+type fieldRequirement struct {
+	field string
+	value string
+	equal bool
+	path  []string // where the field lives in the object
+}
+
+// supportedFields are the field labels every kind supports (upstream DefaultClusterScopedAttr/NamespaceScopedAttr).
+var supportedFields = map[string][]string{
+	"metadata.name":      {"metadata", "name"},
+	"metadata.namespace": {"metadata", "namespace"},
+}
+
+// parseFieldSelector parses a comma-separated fieldSelector; unknown fields are rejected like upstream.
+// kindFields adds the field labels of a kind (e.g. involvedObject.name for Events).
+func parseFieldSelector(selector string, kindFields ...map[string][]string) ([]fieldRequirement, error) {
+	var reqs []fieldRequirement
+	for _, term := range strings.Split(selector, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		req := fieldRequirement{equal: true}
+		if field, value, ok := strings.Cut(term, "!="); ok {
+			req.field, req.value, req.equal = field, value, false
+		} else if field, value, ok := strings.Cut(term, "=="); ok {
+			req.field, req.value = field, value
+		} else if field, value, ok := strings.Cut(term, "="); ok {
+			req.field, req.value = field, value
+		} else {
+			return nil, fmt.Errorf("invalid selector: '%s'; can't understand '%s'", selector, term)
+		}
+		req.path = supportedFields[req.field]
+		for _, fields := range kindFields {
+			if path, ok := fields[req.field]; ok {
+				req.path = path
+			}
+		}
+		if req.path == nil {
+			return nil, fmt.Errorf("field label not supported: %s", req.field)
+		}
+		reqs = append(reqs, req)
+	}
+	return reqs, nil
+}
+
+// matchesFields reports whether obj satisfies every requirement.
+func matchesFields(obj map[string]interface{}, reqs []fieldRequirement) bool {
+	for _, req := range reqs {
+		value, _ := resources.NestedString(obj, req.path...)
+		if (value == req.value) != req.equal {
+			return false
+		}
+	}
+	return true
+}
+
+// filterByFields returns the items matching reqs.
+func filterByFields(items []interface{}, reqs []fieldRequirement) []interface{} {
+	if len(reqs) == 0 {
+		return items
+	}
+	filtered := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		if obj, ok := item.(map[string]interface{}); ok && matchesFields(obj, reqs) {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
+
+// inNamespace reports whether obj lives in namespace ("" matches all).
+func inNamespace(obj map[string]interface{}, namespace string) bool {
+	if namespace == "" {
+		return true
+	}
+	ns, _ := resources.NestedString(obj, "metadata", "namespace")
+	if ns == "" {
+		ns = "default"
+	}
+	return ns == namespace
+}
+
+// watchObjects streams changes of kind as watch events: ADDED for the objects already stored,
+// then every store event, filtered by the URL namespace and fieldSelector. It returns when the
+// client goes away or timeoutSeconds passes (the client then re-lists and watches again).
+func watchObjects(c *gin.Context, kind string, list func() []interface{}, reqs []fieldRequirement) {
+	watchObjectsAs(c, kind, list, reqs, nil)
+}
+
+// watchObjectsAs is watchObjects sending render(obj) as the event objects (nil sends obj).
+func watchObjectsAs(c *gin.Context, kind string, list func() []interface{}, reqs []fieldRequirement, render func(obj map[string]interface{}) interface{}) {
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		WriteError(c, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+	// Subscribe before listing so nothing between the two is missed
+	watcher := storage.DefaultStore.Watch()
+	defer watcher.Stop()
+
+	var timeout <-chan time.Time
+	if seconds, err := strconv.Atoi(c.Query("timeoutSeconds")); err == nil && seconds > 0 {
+		timeout = time.After(time.Duration(seconds) * time.Second)
+	}
+
+	c.Header("Content-Type", "application/json")
+	c.Header("Transfer-Encoding", "chunked")
+	c.Header("Cache-Control", "no-cache")
+	c.Status(http.StatusOK)
+
+	namespace := c.Param("namespace")
+	send := func(eventType string, obj map[string]interface{}) {
+		if !inNamespace(obj, namespace) || !matchesFields(obj, reqs) {
+			return
+		}
+		event := WatchEvent{Type: eventType, Object: obj}
+		if render != nil {
+			event.Object = render(obj)
+		}
+		data, _ := json.Marshal(event)
+		fmt.Fprintf(c.Writer, "%s\n", data)
+		flusher.Flush()
+	}
+
+	for _, item := range list() {
+		if obj, ok := item.(map[string]interface{}); ok {
+			send(string(storage.Added), obj)
+		}
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-timeout:
+			return
+		case ev, ok := <-watcher.ResultChan():
+			if !ok {
+				return
+			}
+			if ev.Kind == kind {
+				send(string(ev.Type), ev.Object)
+			}
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package apis
+
+import "testing"
+
+func TestFieldSelector(t *testing.T) {
+	reqs, err := parseFieldSelector("metadata.name=web,metadata.namespace!=dev")
+	if err != nil {
+		t.Fatalf("parseFieldSelector failed: %v", err)
+	}
+	items := []interface{}{
+		decodeJSON(t, `{"metadata":{"name":"web","namespace":"default"}}`),
+		decodeJSON(t, `{"metadata":{"name":"web","namespace":"dev"}}`),
+		decodeJSON(t, `{"metadata":{"name":"api","namespace":"default"}}`),
+	}
+	filtered := filterByFields(items, reqs)
+	if len(filtered) != 1 {
+		t.Fatalf("Expected 1 match, got %d", len(filtered))
+	}
+	if _, err := parseFieldSelector("spec.replicas=3"); err == nil {
+		t.Error("Expected unsupported field label to be rejected")
+	}
+}
+
+func TestFieldSelectorKindFields(t *testing.T) {
+	reqs, err := parseFieldSelector("involvedObject.kind=Pod,involvedObject.name=web", eventFields)
+	if err != nil {
+		t.Fatalf("parseFieldSelector failed: %v", err)
+	}
+	items := []interface{}{
+		decodeJSON(t, `{"metadata":{"name":"web.1"},"involvedObject":{"kind":"Pod","name":"web"}}`),
+		decodeJSON(t, `{"metadata":{"name":"web.2"},"involvedObject":{"kind":"ReplicaSet","name":"web"}}`),
+		decodeJSON(t, `{"metadata":{"name":"api.1"},"involvedObject":{"kind":"Pod","name":"api"}}`),
+	}
+	if filtered := filterByFields(items, reqs); len(filtered) != 1 {
+		t.Fatalf("Expected 1 match, got %d", len(filtered))
+	}
+	if _, err := parseFieldSelector("involvedObject.name=web"); err == nil {
+		t.Error("Expected event field label to be rejected for other kinds")
+	}
+	if _, err := parseFieldSelector("spec.nodeName=node-1", podFields); err != nil {
+		t.Errorf("Expected spec.nodeName to be supported for pods: %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"time"
+
+	"mockernetes/internal/resources"
+)
+
+// Status conditions of the workload kinds. A condition only moves when its status or reason
+// changes, so unchanged syncs write nothing and lastTransitionTime marks the last flip.
+
+// setCondition returns the condition of condType in status if it already has condStatus and
+// reason, otherwise a new one (keeping lastTransitionTime when only the reason changed).
+func setCondition(status map[string]interface{}, condType, condStatus, reason, message string, now time.Time) map[string]interface{} {
+	current := deploymentCondition(status, condType)
+	if current != nil && current["status"] == condStatus && current["reason"] == reason {
+		return current
+	}
+	transition := now.UTC().Format(time.RFC3339)
+	if current != nil && current["status"] == condStatus {
+		if t, ok := current["lastTransitionTime"].(string); ok {
+			transition = t
+		}
+	}
+	return map[string]interface{}{
+		"type":               condType,
+		"status":             condStatus,
+		"lastTransitionTime": transition,
+		"reason":             reason,
+		"message":            message,
+	}
+}
+
+// withCondition returns the conditions of status with the one of condType replaced by cond,
+// or removed when cond is nil.
+func withCondition(status map[string]interface{}, condType string, cond map[string]interface{}) []interface{} {
+	if cond != nil {
+		return replaceCondition(status, cond)
+	}
+	var conditions []interface{}
+	existing, _ := resources.NestedSlice(status, "conditions")
+	for _, c := range existing {
+		if old, ok := c.(map[string]interface{}); ok && old["type"] == condType {
+			continue
+		}
+		conditions = append(conditions, c)
+	}
+	return conditions
+}
@@ -0,0 +1,352 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+// CronJobController creates Jobs from CronJobs on their schedule (pkg/controller/cronjob
+// upstream). Each sync starts the Job of the most recent schedule time that was missed,
+// subject to startingDeadlineSeconds and the concurrencyPolicy, prunes finished Jobs beyond
+// the history limits and requeues the CronJob for its next schedule time.
+type CronJobController struct {
+	store   *storage.InMemoryStore
+	queue   *RateLimitingQueue
+	workers int
+	// now is the controller's clock (a fake one in tests)
+	now func() time.Time
+}
+
+// CronJobScheduledTimestampAnnotation records on a Job the schedule time it was created for
+const CronJobScheduledTimestampAnnotation = "batch.kubernetes.io/cronjob-scheduled-timestamp"
+
+// maxMissedSchedules is how many missed schedule times a sync looks through before giving up
+// on finding the most recent one (the CronJob then waits for its next schedule time)
+const maxMissedSchedules = 100000
+
+// NewCronJobController creates a new CronJobController fed by the given informers
+func NewCronJobController(store *storage.InMemoryStore, informers *SharedInformerFactory) *CronJobController {
+	cc := &CronJobController{
+		store:   store,
+		queue:   NewRateLimitingQueue(),
+		workers: DefaultWorkers,
+		now:     time.Now,
+	}
+	informers.AddEventHandler("CronJob", ResourceEventHandlerFuncs{
+		AddFunc:    cc.enqueue,
+		UpdateFunc: func(_, cj map[string]interface{}) { cc.enqueue(cj) },
+		DeleteFunc: cc.enqueue,
+	})
+	informers.AddEventHandler("Job", ResourceEventHandlerFuncs{
+		AddFunc:    cc.enqueueForJob,
+		UpdateFunc: func(_, job map[string]interface{}) { cc.enqueueForJob(job) },
+		DeleteFunc: cc.enqueueForJob,
+	})
+	return cc
+}
+
+// Start starts the controller's workers
+func (cc *CronJobController) Start() {
+	runWorkers(cc.queue, cc.workers, "CronJob Controller", cc.syncCronJob)
+}
+
+// Stop stops the controller
+func (cc *CronJobController) Stop() {
+	cc.queue.ShutDownAndWait()
+}
+
+// enqueue queues a CronJob for sync
+func (cc *CronJobController) enqueue(cj map[string]interface{}) {
+	cc.queue.Add(objectKey(cj))
+}
+
+// enqueueForJob queues the CronJob controlling a Job
+func (cc *CronJobController) enqueueForJob(job map[string]interface{}) {
+	if key := ownerKey(job, "CronJob"); key != "" {
+		cc.queue.Add(key)
+	}
+}
+
+// syncCronJob reconciles the CronJob stored under key (gone CronJobs need no work; the
+// garbage collector deletes their Jobs)
+func (cc *CronJobController) syncCronJob(key string) error {
+	_, name := splitKey(key)
+	cj, err := cc.store.GetCronJob(name)
+	if err != nil {
+		return nil
+	}
+	wait, err := cc.reconcileCronJob(cj)
+	if err != nil {
+		return err
+	}
+	if wait > 0 {
+		cc.queue.AddAfter(key, wait)
+	}
+	return nil
+}
+
+// reconcileCronJob updates the active Jobs of a CronJob, starts the Job that is due and
+// cleans up finished Jobs. It returns how long until the next schedule time.
+func (cc *CronJobController) reconcileCronJob(cj map[string]interface{}) (time.Duration, error) {
+	name, _ := resources.NestedString(cj, "metadata", "name")
+	status := map[string]interface{}{}
+	if existing, ok := resources.NestedMap(cj, "status"); ok {
+		deepCopyJSON(existing, &status)
+	}
+
+	var active, succeeded, failed []map[string]interface{}
+	for _, item := range cc.store.ListJobs() {
+		job, ok := item.(map[string]interface{})
+		if !ok || namespaceOf(job) != namespaceOf(cj) || !isOwnedBy(job, "CronJob", name, objectUID(cj)) {
+			continue
+		}
+		jobStatus, _ := resources.NestedMap(job, "status")
+		switch {
+		case jobCondition(jobStatus, "Complete") == "True":
+			succeeded = append(succeeded, job)
+			completed, _ := resources.NestedString(jobStatus, "completionTime")
+			last, _ := resources.NestedString(status, "lastSuccessfulTime")
+			if completed != "" && completionAfter(completed, last) {
+				status["lastSuccessfulTime"] = completed
+			}
+		case jobCondition(jobStatus, "Failed") == "True":
+			failed = append(failed, job)
+		case !isBeingDeleted(job):
+			active = append(active, job)
+		}
+	}
+
+	wait, err := cc.startDueJob(cj, status, &active)
+	errs := []error{err}
+	errs = append(errs, cc.cleanupFinishedJobs(cj, "successfulJobsHistoryLimit", succeeded))
+	errs = append(errs, cc.cleanupFinishedJobs(cj, "failedJobsHistoryLimit", failed))
+
+	refs := make([]interface{}, 0, len(active))
+	for _, job := range active {
+		refs = append(refs, jobReference(job))
+	}
+	status["active"] = refs
+	if len(refs) == 0 {
+		delete(status, "active")
+	}
+	_, err = cc.store.MutateCronJob(name, func(stored map[string]interface{}) error {
+		stored["status"] = status
+		return nil
+	})
+	return wait, errors.Join(append(errs, err)...)
+}
+
+// startDueJob creates the Job of the most recent schedule time that was missed, if the
+// CronJob may start one now, and records it in status. It returns how long until the next
+// schedule time (0 if the CronJob doesn't need a timer).
+func (cc *CronJobController) startDueJob(cj, status map[string]interface{}, active *[]map[string]interface{}) (time.Duration, error) {
+	name, _ := resources.NestedString(cj, "metadata", "name")
+	spec, _ := resources.NestedMap(cj, "spec")
+	if suspend, _ := spec["suspend"].(bool); suspend || isBeingDeleted(cj) {
+		return 0, nil
+	}
+	expr, _ := resources.NestedString(spec, "schedule")
+	schedule, err := ParseSchedule(expr)
+	if err != nil {
+		// Not retried: the CronJob has to be fixed
+		fmt.Printf("[CronJob Controller] Unparseable schedule %q of CronJob %s: %v\n", expr, name, err)
+		return 0, nil
+	}
+	loc := time.Local
+	if tz, ok := resources.NestedString(spec, "timeZone"); ok {
+		if loc, err = time.LoadLocation(tz); err != nil {
+			fmt.Printf("[CronJob Controller] Unknown time zone %q of CronJob %s\n", tz, name)
+			return 0, nil
+		}
+	}
+
+	now := cc.now().In(loc)
+	earliest := creationTime(cj)
+	if last, ok := resources.NestedString(status, "lastScheduleTime"); ok {
+		if t, err := time.Parse(time.RFC3339, last); err == nil {
+			earliest = t
+		}
+	}
+	deadline, hasDeadline := resources.NestedInt64(spec, "startingDeadlineSeconds")
+	if hasDeadline {
+		if windowStart := now.Add(-time.Duration(deadline) * time.Second); windowStart.After(earliest) {
+			earliest = windowStart
+		}
+	}
+	var scheduled time.Time
+	for t, missed := schedule.Next(earliest.In(loc)), 0; !t.IsZero() && !t.After(now); t, missed = schedule.Next(t), missed+1 {
+		if missed == maxMissedSchedules {
+			fmt.Printf("[CronJob Controller] Too many missed start times of CronJob %s, check clock skew\n", name)
+			scheduled = time.Time{}
+			break
+		}
+		scheduled = t
+	}
+	var wait time.Duration
+	if next := schedule.Next(now); !next.IsZero() {
+		wait = next.Sub(now)
+	}
+	if scheduled.IsZero() {
+		return wait, nil
+	}
+	if hasDeadline && scheduled.Add(time.Duration(deadline)*time.Second).Before(now) {
+		fmt.Printf("[CronJob Controller] Missed starting window of CronJob %s for %s\n", name, scheduled.Format(time.RFC3339))
+		return wait, nil
+	}
+
+	jobName := fmt.Sprintf("%s-%d", name, scheduled.Unix()/60)
+	if _, err := cc.store.GetJob(jobName); err == nil {
+		// created by an earlier sync whose status update got lost
+		status["lastScheduleTime"] = scheduled.UTC().Format(time.RFC3339)
+		return wait, nil
+	}
+	switch policy, _ := spec["concurrencyPolicy"].(string); {
+	case policy == "Forbid" && len(*active) > 0:
+		// The run starts once the previous one finished, if still within the deadline
+		fmt.Printf("[CronJob Controller] Not starting Job for CronJob %s: previous run still active and concurrencyPolicy is Forbid\n", name)
+		return wait, nil
+	case policy == "Replace":
+		var errs []error
+		for _, job := range *active {
+			activeName, _ := resources.NestedString(job, "metadata", "name")
+			fmt.Printf("[CronJob Controller] Deleting active Job %s of CronJob %s: concurrencyPolicy is Replace\n", activeName, name)
+			if err := cc.store.DeleteJob(activeName); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if err := errors.Join(errs...); err != nil {
+			return wait, err
+		}
+		*active = nil
+	}
+
+	job := newJobFromCronJob(cj, jobName, scheduled)
+	if err := cc.store.CreateJob(&job); err != nil {
+		return wait, fmt.Errorf("failed to create Job for CronJob %s: %w", name, err)
+	}
+	fmt.Printf("[CronJob Controller] Created Job %s for CronJob %s scheduled at %s\n", jobName, name, scheduled.Format(time.RFC3339))
+	if created, err := cc.store.GetJob(jobName); err == nil {
+		*active = append(*active, created)
+	}
+	status["lastScheduleTime"] = scheduled.UTC().Format(time.RFC3339)
+	return wait, nil
+}
+
+// newJobFromCronJob builds the Job of a schedule time from the CronJob's jobTemplate,
+// controlled by the CronJob
+func newJobFromCronJob(cj map[string]interface{}, jobName string, scheduled time.Time) resources.Job {
+	name, _ := resources.NestedString(cj, "metadata", "name")
+	labels, _ := resources.NestedStringMap(cj, "spec", "jobTemplate", "metadata", "labels")
+	annotations, _ := resources.NestedStringMap(cj, "spec", "jobTemplate", "metadata", "annotations")
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[CronJobScheduledTimestampAnnotation] = scheduled.Format(time.RFC3339)
+	var spec map[string]interface{}
+	jobSpec, _ := resources.NestedMap(cj, "spec", "jobTemplate", "spec")
+	deepCopyJSON(jobSpec, &spec)
+
+	job := resources.Job{
+		Kind:       "Job",
+		APIVersion: "batch/v1",
+		Metadata: resources.ObjectMeta{
+			Name:              jobName,
+			Namespace:         namespaceOf(cj),
+			UID:               storage.NewUID(),
+			Labels:            labels,
+			Annotations:       annotations,
+			CreationTimestamp: time.Now().Format(time.RFC3339),
+			OwnerReferences: []resources.OwnerReference{{
+				APIVersion:         ownerAPIVersion("CronJob"),
+				Kind:               "CronJob",
+				Name:               name,
+				UID:                objectUID(cj),
+				Controller:         true,
+				BlockOwnerDeletion: true,
+			}},
+		},
+		Spec: spec,
+	}
+	// the Job's selector and pod labels are generated from its uid, as the API does
+	resources.SetJobDefaults(&job)
+	return job
+}
+
+// cleanupFinishedJobs deletes the oldest finished Jobs beyond the CronJob's history limit
+// in the given spec field
+func (cc *CronJobController) cleanupFinishedJobs(cj map[string]interface{}, limitField string, jobs []map[string]interface{}) error {
+	limit, ok := resources.NestedInt64(cj, "spec", limitField)
+	if !ok || int64(len(jobs)) <= limit {
+		return nil
+	}
+	// Job names end with the scheduled time in minutes, so they order Jobs started within
+	// the same second
+	sort.Slice(jobs, func(i, j int) bool {
+		si, sj := jobStartTime(jobs[i]), jobStartTime(jobs[j])
+		if !si.Equal(sj) {
+			return si.Before(sj)
+		}
+		ni, _ := resources.NestedString(jobs[i], "metadata", "name")
+		nj, _ := resources.NestedString(jobs[j], "metadata", "name")
+		return len(ni) < len(nj) || (len(ni) == len(nj) && ni < nj)
+	})
+	cjName, _ := resources.NestedString(cj, "metadata", "name")
+	var errs []error
+	for _, job := range jobs[:int64(len(jobs))-limit] {
+		if isBeingDeleted(job) {
+			continue
+		}
+		name, _ := resources.NestedString(job, "metadata", "name")
+		fmt.Printf("[CronJob Controller] Deleting Job %s of CronJob %s: beyond %s\n", name, cjName, limitField)
+		if err := cc.store.DeleteJob(name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// jobStartTime returns status.startTime of a Job, or its creation time
+func jobStartTime(job map[string]interface{}) time.Time {
+	if started, ok := resources.NestedString(job, "status", "startTime"); ok {
+		if t, err := time.Parse(time.RFC3339, started); err == nil {
+			return t
+		}
+	}
+	return creationTime(job)
+}
+
+// completionAfter reports whether RFC3339 time a is after b (or b is unset)
+func completionAfter(a, b string) bool {
+	ta, err := time.Parse(time.RFC3339, a)
+	if err != nil {
+		return false
+	}
+	tb, err := time.Parse(time.RFC3339, b)
+	return err != nil || ta.After(tb)
+}
+
+// jobReference returns the object reference status.active lists a Job by
+func jobReference(job map[string]interface{}) map[string]interface{} {
+	name, _ := resources.NestedString(job, "metadata", "name")
+	return map[string]interface{}{
+		"kind":       "Job",
+		"apiVersion": "batch/v1",
+		"namespace":  namespaceOf(job),
+		"name":       name,
+		"uid":        objectUID(job),
+	}
+}
+
+// DefaultCronJobController is the singleton instance
+var DefaultCronJobController *CronJobController
+
+// InitCronJobController initializes the default CronJob controller
+func InitCronJobController(store *storage.InMemoryStore) {
+	DefaultCronJobController = NewCronJobController(store, sharedInformers(store))
+	DefaultCronJobController.Start()
+}
@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+func TestScheduleNext(t *testing.T) {
+	at := func(s string) time.Time {
+		tm, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+	tests := []struct {
+		schedule string
+		from     string
+		want     string
+	}{
+		{"*/15 * * * *", "2026-03-01 10:07", "2026-03-01 10:15"},
+		{"*/15 * * * *", "2026-03-01 10:45", "2026-03-01 11:00"},
+		{"0 9-17/4 * * *", "2026-03-01 13:00", "2026-03-01 17:00"},
+		{"30 2 * * MON-FRI", "2026-03-06 03:00", "2026-03-09 02:30"}, // Friday night to Monday
+		{"0 0 1,15 * *", "2026-03-02 00:00", "2026-03-15 00:00"},
+		{"0 0 13 * 5", "2026-03-01 00:00", "2026-03-06 00:00"}, // day of month OR Friday
+		{"0 0 * * 7", "2026-03-02 00:00", "2026-03-08 00:00"},  // 7 is Sunday
+		{"0 0 29 feb *", "2026-03-01 00:00", "2028-02-29 00:00"},
+		{"@monthly", "2026-12-15 08:00", "2027-01-01 00:00"},
+		{"@hourly", "2026-03-01 10:00", "2026-03-01 11:00"},
+	}
+	for _, tt := range tests {
+		schedule, err := ParseSchedule(tt.schedule)
+		if err != nil {
+			t.Errorf("%s: %v", tt.schedule, err)
+			continue
+		}
+		if got := schedule.Next(at(tt.from)); !got.Equal(at(tt.want)) {
+			t.Errorf("%s after %s: expected %s, got %s", tt.schedule, tt.from, tt.want, got.Format("2006-01-02 15:04"))
+		}
+	}
+	for _, invalid := range []string{"* * * *", "60 * * * *", "*/0 * * * *", "5-1 * * * *", "@every 5m", "* * * foo *"} {
+		if _, err := ParseSchedule(invalid); err == nil {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+}
+
+// cronJobJobs returns the names of the CronJob's Jobs, sorted
+func cronJobJobs(store *storage.InMemoryStore) []string {
+	var names []string
+	for _, item := range store.ListJobs() {
+		name, _ := resources.NestedString(item.(map[string]interface{}), "metadata", "name")
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func finishJob(t *testing.T, store *storage.InMemoryStore, name, condType string) {
+	t.Helper()
+	_, err := store.MutateJob(name, func(job map[string]interface{}) error {
+		now := time.Now().UTC().Format(time.RFC3339)
+		job["status"] = map[string]interface{}{
+			"completionTime": now,
+			"conditions":     []interface{}{map[string]interface{}{"type": condType, "status": "True", "lastTransitionTime": now}},
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCronJobSchedulesJobs(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	created := time.Date(2026, 3, 1, 10, 2, 0, 0, time.UTC)
+	cj := resources.CronJob{
+		Kind:       "CronJob",
+		APIVersion: "batch/v1",
+		Metadata:   resources.ObjectMeta{Name: "report", Namespace: "default", CreationTimestamp: created.Format(time.RFC3339)},
+		Spec: map[string]interface{}{
+			"schedule":                   "*/5 * * * *",
+			"timeZone":                   "UTC",
+			"concurrencyPolicy":          "Forbid",
+			"successfulJobsHistoryLimit": float64(1),
+			"jobTemplate": map[string]interface{}{"spec": map[string]interface{}{"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"restartPolicy": "Never",
+					"containers":    []interface{}{map[string]interface{}{"name": "report", "image": "report:v1"}},
+				},
+			}}},
+		},
+	}
+	resources.SetCronJobDefaults(&cj)
+	store.CreateCronJob(&cj)
+
+	// A controller without workers, synced by hand on a fake clock
+	cc := NewCronJobController(store, NewSharedInformerFactory(store, 0))
+	now := created
+	cc.now = func() time.Time { return now }
+	sync := func(at time.Time) time.Duration {
+		t.Helper()
+		now = at
+		cronJob, _ := store.GetCronJob("report")
+		wait, err := cc.reconcileCronJob(cronJob)
+		if err != nil {
+			t.Fatalf("Sync failed: %v", err)
+		}
+		return wait
+	}
+
+	if wait := sync(created.Add(time.Minute)); wait != 2*time.Minute || len(cronJobJobs(store)) != 0 {
+		t.Fatalf("Expected no Job and a 2m wait before 10:05, got %v and %v", cronJobJobs(store), wait)
+	}
+	// The 10:05 run; a second sync doesn't start it again
+	sync(created.Add(3*time.Minute + 10*time.Second))
+	sync(created.Add(4 * time.Minute))
+	first := "report-29539325"
+	if jobs := cronJobJobs(store); len(jobs) != 1 || jobs[0] != first {
+		t.Fatalf("Expected Job %s, got %v", first, jobs)
+	}
+	job, _ := store.GetJob(first)
+	if !isOwnedBy(job, "CronJob", "report", "") {
+		t.Errorf("Expected the Job to be controlled by the CronJob, got %v", job["metadata"])
+	}
+	if selector, _ := resources.NestedString(job, "spec", "selector", "matchLabels", resources.JobControllerUIDLabel); selector != objectUID(job) {
+		t.Errorf("Expected a selector generated from the Job's uid, got %v", job["spec"])
+	}
+	status, _ := store.GetCronJob("report")
+	if active, _ := resources.NestedSlice(status, "status", "active"); len(active) != 1 {
+		t.Errorf("Expected 1 active Job, got %v", status["status"])
+	}
+
+	// Forbid: the 10:10 run waits for the 10:05 one, then starts late
+	sync(created.Add(8 * time.Minute))
+	if jobs := cronJobJobs(store); len(jobs) != 1 {
+		t.Fatalf("Expected the 10:10 run to wait, got %v", jobs)
+	}
+	finishJob(t, store, first, "Complete")
+	sync(created.Add(9 * time.Minute))
+	second := "report-29539330"
+	if jobs := cronJobJobs(store); len(jobs) != 2 || jobs[1] != second {
+		t.Fatalf("Expected Job %s to start once %s finished, got %v", second, first, jobs)
+	}
+
+	// Replace: the 10:15 run replaces the running 10:10 one; the finished 10:05 one is
+	// beyond the history limit once 10:15 completes too
+	store.MutateCronJob("report", func(cj map[string]interface{}) error {
+		cj["spec"].(map[string]interface{})["concurrencyPolicy"] = "Replace"
+		return nil
+	})
+	sync(created.Add(13 * time.Minute))
+	third := "report-29539335"
+	if jobs := cronJobJobs(store); len(jobs) != 2 || jobs[0] != first || jobs[1] != third {
+		t.Fatalf("Expected Jobs %s and %s, got %v", first, third, jobs)
+	}
+	finishJob(t, store, third, "Complete")
+	sync(created.Add(14 * time.Minute))
+	if jobs := cronJobJobs(store); len(jobs) != 1 || jobs[0] != third {
+		t.Fatalf("Expected only %s to be kept, got %v", third, jobs)
+	}
+	status, _ = store.GetCronJob("report")
+	if _, ok := resources.NestedString(status, "status", "lastSuccessfulTime"); !ok {
+		t.Errorf("Expected lastSuccessfulTime to be set, got %v", status["status"])
+	}
+
+	// Suspended CronJobs start nothing
+	store.MutateCronJob("report", func(cj map[string]interface{}) error {
+		cj["spec"].(map[string]interface{})["suspend"] = true
+		return nil
+	})
+	sync(created.Add(30 * time.Minute))
+	if jobs := cronJobJobs(store); len(jobs) != 1 {
+		t.Errorf("Expected a suspended CronJob to start no Jobs, got %v", jobs)
+	}
+}
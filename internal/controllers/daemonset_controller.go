@@ -0,0 +1,482 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+// DaemonSetController manages DaemonSets: one pod on every Node the pod template fits on
+// (pkg/controller/daemon upstream). It is driven by DaemonSet, Node and Pod change events
+// through a work queue, so pods follow nodes as they are added, relabeled, tainted or removed.
+type DaemonSetController struct {
+	store   *storage.InMemoryStore
+	queue   *RateLimitingQueue
+	workers int
+}
+
+// NewDaemonSetController creates a new DaemonSetController fed by the given informers
+func NewDaemonSetController(store *storage.InMemoryStore, informers *SharedInformerFactory) *DaemonSetController {
+	dsc := &DaemonSetController{
+		store:   store,
+		queue:   NewRateLimitingQueue(),
+		workers: DefaultWorkers,
+	}
+	informers.AddEventHandler("DaemonSet", ResourceEventHandlerFuncs{
+		AddFunc:    dsc.enqueue,
+		UpdateFunc: func(_, ds map[string]interface{}) { dsc.enqueue(ds) },
+		DeleteFunc: dsc.enqueue,
+	})
+	// Any node change may add or remove a daemon pod
+	informers.AddEventHandler("Node", ResourceEventHandlerFuncs{
+		AddFunc:    func(map[string]interface{}) { dsc.enqueueAll() },
+		UpdateFunc: func(_, _ map[string]interface{}) { dsc.enqueueAll() },
+		DeleteFunc: func(map[string]interface{}) { dsc.enqueueAll() },
+	})
+	informers.AddEventHandler("Pod", ResourceEventHandlerFuncs{
+		AddFunc: dsc.enqueueForPod,
+		UpdateFunc: func(old, pod map[string]interface{}) {
+			dsc.enqueueForPod(old)
+			dsc.enqueueForPod(pod)
+		},
+		DeleteFunc: dsc.enqueueForPod,
+	})
+	return dsc
+}
+
+// Start starts the controller's workers
+func (dsc *DaemonSetController) Start() {
+	runWorkers(dsc.queue, dsc.workers, "DaemonSet Controller", dsc.syncDaemonSet)
+}
+
+// Stop stops the controller
+func (dsc *DaemonSetController) Stop() {
+	dsc.queue.ShutDownAndWait()
+}
+
+// enqueue queues a DaemonSet for sync
+func (dsc *DaemonSetController) enqueue(ds map[string]interface{}) {
+	dsc.queue.Add(objectKey(ds))
+}
+
+// enqueueAll queues every DaemonSet
+func (dsc *DaemonSetController) enqueueAll() {
+	for _, item := range dsc.store.ListDaemonSets() {
+		if ds, ok := item.(map[string]interface{}); ok {
+			dsc.enqueue(ds)
+		}
+	}
+}
+
+// enqueueForPod queues the DaemonSet controlling a pod, or for an orphan every
+// DaemonSet whose selector matches it
+func (dsc *DaemonSetController) enqueueForPod(pod map[string]interface{}) {
+	if key := ownerKey(pod, "DaemonSet"); key != "" {
+		dsc.queue.Add(key)
+		return
+	}
+	if hasController(pod) || isBeingDeleted(pod) {
+		return
+	}
+	for _, item := range dsc.store.ListDaemonSets() {
+		ds, ok := item.(map[string]interface{})
+		if !ok || namespaceOf(ds) != namespaceOf(pod) {
+			continue
+		}
+		if selector, err := selectorOf(ds); err == nil && selector.Matches(podLabels(pod)) {
+			dsc.enqueue(ds)
+		}
+	}
+}
+
+// syncDaemonSet reconciles the DaemonSet stored under key (gone DaemonSets need no work)
+func (dsc *DaemonSetController) syncDaemonSet(key string) error {
+	_, name := splitKey(key)
+	ds, err := dsc.store.GetDaemonSet(name)
+	if err != nil {
+		return nil
+	}
+	return dsc.reconcileDaemonSet(ds)
+}
+
+// daemonNode is one node as a DaemonSet sees it
+type daemonNode struct {
+	name                  string
+	shouldRun             bool
+	shouldContinueRunning bool
+	pods                  []map[string]interface{} // daemon pods on the node, oldest first
+}
+
+// daemonSetPods is one sync's view of a DaemonSet's pods, by node
+type daemonSetPods struct {
+	ds              map[string]interface{}
+	name            string
+	hash            string // template hash of the update revision
+	minReadySeconds int64
+	nodes           []*daemonNode
+	orphaned        []map[string]interface{} // pods on nodes that don't exist
+	now             time.Time
+}
+
+// available reports whether a daemon pod is ready for minReadySeconds
+func (s *daemonSetPods) available(pod map[string]interface{}) bool {
+	ok, _ := podAvailableIn(pod, s.minReadySeconds, s.now)
+	return isPodReady(pod) && ok
+}
+
+// reconcileDaemonSet places and removes pods node by node, rolls them to the current template
+// and records the resulting status
+func (dsc *DaemonSetController) reconcileDaemonSet(ds map[string]interface{}) error {
+	// A DaemonSet waiting on finalizers keeps its pods but is no longer managed
+	if isBeingDeleted(ds) {
+		return nil
+	}
+	s, pods, err := dsc.getDaemonSetPods(ds)
+	if err != nil {
+		fmt.Printf("[DaemonSet Controller] Error syncing DaemonSet %s: %v\n", s.name, err)
+		return err
+	}
+	fmt.Printf("[DaemonSet Controller] Reconciling DaemonSet %s: nodes=%d, pods=%d\n", objectKey(ds), len(s.nodes), len(pods))
+
+	// Rolling updates only start from a placement that needed no changes; the
+	// events of created or deleted pods trigger the next sync
+	changed, manageErr := dsc.manageDaemonPods(s)
+	if manageErr == nil && !changed {
+		manageErr = dsc.rollingUpdate(s)
+	}
+	wait, err := dsc.updateDaemonSetStatus(s)
+	if err == nil {
+		live := []string{fmt.Sprintf("%s-%s", s.name, s.hash)}
+		for _, pod := range pods {
+			live = append(live, fmt.Sprintf("%s-%s", s.name, podRevision(pod)))
+		}
+		err = truncateHistory(dsc.store, ds, listRevisions(dsc.store, ds, "DaemonSet"), nil, live...)
+	}
+	if manageErr != nil || err != nil {
+		return errors.Join(manageErr, err)
+	}
+	if wait > 0 {
+		// Nothing fires when a ready pod outlives minReadySeconds
+		dsc.queue.AddAfter(objectKey(ds), wait)
+	}
+	return nil
+}
+
+// getDaemonSetPods makes sure the revision of the current template exists, claims the
+// DaemonSet's pods and sorts them by node. It also returns all claimed pods.
+func (dsc *DaemonSetController) getDaemonSetPods(ds map[string]interface{}) (*daemonSetPods, []map[string]interface{}, error) {
+	minReadySeconds, _ := resources.NestedInt64(ds, "spec", "minReadySeconds")
+	s := &daemonSetPods{ds: ds, minReadySeconds: minReadySeconds, now: time.Now()}
+	s.name, _ = resources.NestedString(ds, "metadata", "name")
+
+	revision, err := ensureRevision(dsc.store, ds, "DaemonSet", listRevisions(dsc.store, ds, "DaemonSet"))
+	if err != nil {
+		return s, nil, err
+	}
+	s.hash = daemonRevisionHash(s.name, revision)
+
+	selector, err := selectorOf(ds)
+	if err != nil {
+		return s, nil, err
+	}
+	pods, err := claimPods(dsc.store, ds, "DaemonSet", selector)
+	if err != nil {
+		return s, nil, err
+	}
+
+	podSpec := daemonPodSpec(ds)
+	byName := map[string]*daemonNode{}
+	for _, item := range dsc.store.ListNodes() {
+		node, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		dn := &daemonNode{}
+		dn.name, _ = resources.NestedString(node, "metadata", "name")
+		dn.shouldRun, dn.shouldContinueRunning = nodeShouldRunDaemonPod(podSpec, node)
+		byName[dn.name] = dn
+		s.nodes = append(s.nodes, dn)
+	}
+	for _, pod := range pods {
+		nodeName, _ := resources.NestedString(pod, "spec", "nodeName")
+		if dn := byName[nodeName]; dn != nil {
+			dn.pods = append(dn.pods, pod)
+		} else {
+			s.orphaned = append(s.orphaned, pod)
+		}
+	}
+	for _, dn := range s.nodes {
+		daemonPodsByAge(dn.pods)
+	}
+	return s, pods, nil
+}
+
+// manageDaemonPods creates the pods missing on nodes that should run one and deletes pods
+// that shouldn't be where they are: on nodes the DaemonSet no longer fits, on nodes that are
+// gone, finished pods (re-created on the next sync) and duplicates. It reports whether it
+// created or deleted anything.
+func (dsc *DaemonSetController) manageDaemonPods(s *daemonSetPods) (bool, error) {
+	surge, _ := dsc.fenceposts(s)
+	var condemned []map[string]interface{}
+	var create []string
+	for _, dn := range s.nodes {
+		if !dn.shouldContinueRunning {
+			condemned = append(condemned, dn.pods...)
+			continue
+		}
+		var running []map[string]interface{}
+		for _, pod := range dn.pods {
+			switch {
+			case isBeingDeleted(pod):
+			case isPodFinished(pod):
+				condemned = append(condemned, pod)
+			default:
+				running = append(running, pod)
+			}
+		}
+		if len(dn.pods) == 0 && dn.shouldRun {
+			create = append(create, dn.name)
+		}
+		condemned = append(condemned, duplicateDaemonPods(running, s.hash, surge > 0)...)
+	}
+	condemned = append(condemned, s.orphaned...)
+
+	var errs []error
+	changed := false
+	for _, nodeName := range create {
+		changed = true
+		if err := dsc.createDaemonPod(s, nodeName); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, pod := range condemned {
+		if isBeingDeleted(pod) {
+			continue
+		}
+		changed = true
+		podName, _ := resources.NestedString(pod, "metadata", "name")
+		nodeName, _ := resources.NestedString(pod, "spec", "nodeName")
+		fmt.Printf("[DaemonSet Controller] Deleting pod %s of DaemonSet %s from node %q\n", podName, s.name, nodeName)
+		if err := deletePodObject(dsc.store, podName); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return changed, errors.Join(errs...)
+}
+
+// duplicateDaemonPods returns the running pods on a node beyond the oldest one. During a
+// surge update a node may run one old and one updated pod side by side.
+func duplicateDaemonPods(running []map[string]interface{}, hash string, surge bool) []map[string]interface{} {
+	if !surge {
+		if len(running) > 1 {
+			return running[1:]
+		}
+		return nil
+	}
+	var extra []map[string]interface{}
+	var haveOld, haveNew bool
+	for _, pod := range running {
+		seen := &haveOld
+		if podRevision(pod) == hash {
+			seen = &haveNew
+		}
+		if *seen {
+			extra = append(extra, pod)
+		}
+		*seen = true
+	}
+	return extra
+}
+
+// fenceposts returns maxSurge and maxUnavailable scaled against the number of nodes that
+// should run the pod, both rounded up. Both resolving to 0 would block the update.
+func (dsc *DaemonSetController) fenceposts(s *daemonSetPods) (int32, int32) {
+	var desired int32
+	for _, dn := range s.nodes {
+		if dn.shouldRun {
+			desired++
+		}
+	}
+	rollingUpdate, _ := resources.NestedMap(s.ds, "spec", "updateStrategy", "rollingUpdate")
+	surgeValue, ok := rollingUpdate["maxSurge"]
+	if !ok {
+		surgeValue = float64(0)
+	}
+	unavailableValue, ok := rollingUpdate["maxUnavailable"]
+	if !ok {
+		unavailableValue = float64(1)
+	}
+	surge := scaledValue(surgeValue, desired, true)
+	unavailable := scaledValue(unavailableValue, desired, true)
+	if surge == 0 && unavailable == 0 {
+		unavailable = 1
+	}
+	return surge, unavailable
+}
+
+// rollingUpdate replaces pods of old revisions. Without surge an old pod is deleted (and
+// re-created by the next sync) while fewer than maxUnavailable nodes lack an available pod;
+// unavailable old pods go right away. With maxSurge the updated pod is started next to the
+// old one, which is deleted once the new pod is available. OnDelete leaves it to the user.
+func (dsc *DaemonSetController) rollingUpdate(s *daemonSetPods) error {
+	strategy, _ := resources.NestedString(s.ds, "spec", "updateStrategy", "type")
+	if strategy != "RollingUpdate" {
+		return nil
+	}
+	maxSurge, maxUnavailable := dsc.fenceposts(s)
+
+	var numUnavailable, numSurge int32
+	var deleteNow, deleteIfAllowed []map[string]interface{}
+	var createNow, createIfAllowed []string
+	for _, dn := range s.nodes {
+		if !dn.shouldRun {
+			continue
+		}
+		var oldPod, newPod map[string]interface{}
+		for _, pod := range dn.pods {
+			if !isPodActive(pod) {
+				continue
+			}
+			if podRevision(pod) == s.hash {
+				newPod = pod
+			} else if oldPod == nil {
+				oldPod = pod
+			}
+		}
+		switch {
+		case oldPod == nil && newPod == nil:
+			numUnavailable++
+		case oldPod == nil:
+			if !s.available(newPod) {
+				numUnavailable++
+			}
+		case maxSurge == 0:
+			if !s.available(oldPod) {
+				numUnavailable++
+				deleteNow = append(deleteNow, oldPod)
+			} else {
+				deleteIfAllowed = append(deleteIfAllowed, oldPod)
+			}
+		case newPod == nil:
+			if !s.available(oldPod) {
+				createNow = append(createNow, dn.name)
+			} else {
+				createIfAllowed = append(createIfAllowed, dn.name)
+			}
+		default:
+			// Surged: the old pod goes once its replacement is available
+			if s.available(newPod) {
+				deleteNow = append(deleteNow, oldPod)
+			} else {
+				numSurge++
+			}
+		}
+	}
+	if remaining := int(maxUnavailable - numUnavailable); remaining > 0 {
+		deleteNow = append(deleteNow, deleteIfAllowed[:min(remaining, len(deleteIfAllowed))]...)
+	}
+	if remaining := int(maxSurge - numSurge); remaining > 0 {
+		createNow = append(createNow, createIfAllowed[:min(remaining, len(createIfAllowed))]...)
+	}
+
+	var errs []error
+	for _, nodeName := range createNow {
+		if err := dsc.createDaemonPod(s, nodeName); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, pod := range deleteNow {
+		podName, _ := resources.NestedString(pod, "metadata", "name")
+		fmt.Printf("[DaemonSet Controller] Deleting pod %s of DaemonSet %s for update to %s\n", podName, s.name, s.hash)
+		if err := deletePodObject(dsc.store, podName); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// createDaemonPod creates the DaemonSet's pod on a node
+func (dsc *DaemonSetController) createDaemonPod(s *daemonSetPods, nodeName string) error {
+	pod := newDaemonPod(s.ds, s.hash, nodeName)
+	if err := createPod(dsc.store, &pod); err != nil {
+		return fmt.Errorf("failed to create pod for DaemonSet %s on node %s: %w", s.name, nodeName, err)
+	}
+	fmt.Printf("[DaemonSet Controller] Created pod %s for DaemonSet %s on node %s\n", pod.GetName(), s.name, nodeName)
+	return nil
+}
+
+// updateDaemonSetStatus records how many nodes run the pod, and how many of those pods are
+// updated, ready and available. It returns how long until the next ready pod becomes available.
+func (dsc *DaemonSetController) updateDaemonSetStatus(s *daemonSetPods) (time.Duration, error) {
+	generation, _ := resources.NestedInt64(s.ds, "metadata", "generation")
+
+	var desired, scheduled, misscheduled, ready, available, updated int32
+	var wait time.Duration
+	for _, dn := range s.nodes {
+		var active []map[string]interface{}
+		for _, pod := range dn.pods {
+			if isPodActive(pod) {
+				active = append(active, pod)
+			}
+		}
+		if dn.shouldRun {
+			desired++
+		}
+		if len(active) == 0 {
+			continue
+		}
+		if !dn.shouldRun {
+			// a pod on a node the DaemonSet would not place it on now
+			misscheduled++
+			continue
+		}
+		scheduled++
+		// The updated pod counts when a surge put one next to the old pod
+		pod := active[0]
+		for _, p := range active {
+			if podRevision(p) == s.hash {
+				pod = p
+			}
+		}
+		if podRevision(pod) == s.hash {
+			updated++
+		}
+		if isPodReady(pod) {
+			ready++
+			ok, after := podAvailableIn(pod, s.minReadySeconds, s.now)
+			if ok {
+				available++
+			} else if after > 0 && (wait == 0 || after < wait) {
+				wait = after
+			}
+		}
+	}
+
+	status := map[string]interface{}{
+		"observedGeneration":     generation,
+		"desiredNumberScheduled": desired,
+		"currentNumberScheduled": scheduled,
+		"numberMisscheduled":     misscheduled,
+		"numberReady":            ready,
+		"numberAvailable":        available,
+		"numberUnavailable":      max(desired-available, 0),
+		"updatedNumberScheduled": updated,
+	}
+	_, err := dsc.store.MutateDaemonSet(s.name, func(ds map[string]interface{}) error {
+		ds["status"] = status
+		return nil
+	})
+	return wait, err
+}
+
+// DefaultDaemonSetController is the singleton instance
+var DefaultDaemonSetController *DaemonSetController
+
+// InitDaemonSetController initializes the default DaemonSet controller
+func InitDaemonSetController(store *storage.InMemoryStore) {
+	DefaultDaemonSetController = NewDaemonSetController(store, sharedInformers(store))
+	DefaultDaemonSetController.Start()
+}
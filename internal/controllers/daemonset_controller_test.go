@@ -0,0 +1,212 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+func newTestNode(name string, labels map[string]string, taints ...map[string]interface{}) *resources.Node {
+	node := &resources.Node{
+		Kind:       "Node",
+		APIVersion: "v1",
+		Metadata:   resources.ObjectMeta{Name: name, Labels: labels},
+		Spec:       map[string]interface{}{},
+	}
+	if len(taints) > 0 {
+		items := make([]interface{}, len(taints))
+		for i, taint := range taints {
+			items[i] = taint
+		}
+		node.Spec.(map[string]interface{})["taints"] = items
+	}
+	resources.SetNodeDefaults(node)
+	return node
+}
+
+func newTestDaemonSet(name string, podSpec map[string]interface{}) resources.DaemonSet {
+	podSpec["containers"] = []interface{}{map[string]interface{}{"name": "agent", "image": "agent:v1"}}
+	ds := resources.DaemonSet{
+		Kind:       "DaemonSet",
+		APIVersion: "apps/v1",
+		Metadata:   resources.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: map[string]interface{}{
+			"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": name}},
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": name}},
+				"spec":     podSpec,
+			},
+		},
+	}
+	resources.SetDaemonSetDefaults(&ds)
+	return ds
+}
+
+func startDaemonSetController(t *testing.T, store *storage.InMemoryStore) {
+	controller := NewDaemonSetController(store, startInformers(t, store))
+	controller.Start()
+	t.Cleanup(controller.Stop)
+}
+
+// daemonPodsByNode returns the DaemonSet's pods that are not terminating, by node
+func daemonPodsByNode(store *storage.InMemoryStore) map[string][]map[string]interface{} {
+	byNode := map[string][]map[string]interface{}{}
+	for _, item := range store.ListPods() {
+		pod := item.(map[string]interface{})
+		if isBeingDeleted(pod) {
+			continue
+		}
+		node, _ := resources.NestedString(pod, "spec", "nodeName")
+		byNode[node] = append(byNode[node], pod)
+	}
+	return byNode
+}
+
+func TestDaemonSetRunsOnePodPerMatchingNode(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	usePodController(t, store)
+	startDaemonSetController(t, store)
+
+	agent := map[string]string{"role": "agent"}
+	store.CreateNode(newTestNode("node-a", agent))
+	store.CreateNode(newTestNode("node-b", agent, map[string]interface{}{"key": "dedicated", "value": "gpu", "effect": "NoSchedule"}))
+	store.CreateNode(newTestNode("node-c", nil))
+	// cordoned nodes still get their daemon pod
+	store.CreateNode(newTestNode("node-d", agent, map[string]interface{}{"key": TaintNodeUnschedulable, "effect": "NoSchedule"}))
+
+	if err := store.CreateDaemonSet(newTestDaemonSet("logs", map[string]interface{}{"nodeSelector": map[string]interface{}{"role": "agent"}})); err != nil {
+		t.Fatalf("Failed to create daemonset: %v", err)
+	}
+	waitFor(t, 3*time.Second, "pods on node-a and node-d", func() bool {
+		byNode := daemonPodsByNode(store)
+		return len(byNode) == 2 && len(byNode["node-a"]) == 1 && len(byNode["node-d"]) == 1
+	})
+	pod := daemonPodsByNode(store)["node-a"][0]
+	fields, _ := resources.NestedSlice(pod, "spec", "affinity", "nodeAffinity",
+		"requiredDuringSchedulingIgnoredDuringExecution", "nodeSelectorTerms")
+	if len(fields) != 1 || !nodeMatchesTerm(fields[0].(map[string]interface{}), map[string]interface{}{"metadata": map[string]interface{}{"name": "node-a"}}) {
+		t.Errorf("Expected the pod to be pinned to node-a by affinity, got %v", fields)
+	}
+
+	// New matching nodes get a pod, removed ones lose theirs
+	store.CreateNode(newTestNode("node-e", agent))
+	store.DeleteNode("node-a")
+	waitFor(t, 3*time.Second, "pods following the nodes", func() bool {
+		byNode := daemonPodsByNode(store)
+		return len(byNode) == 2 && len(byNode["node-d"]) == 1 && len(byNode["node-e"]) == 1
+	})
+
+	// A NoExecute taint the pods don't tolerate evicts them; NoSchedule would not
+	store.MutateNode("node-d", func(node map[string]interface{}) error {
+		node["spec"].(map[string]interface{})["taints"] = []interface{}{map[string]interface{}{"key": "maintenance", "effect": "NoExecute"}}
+		return nil
+	})
+	waitFor(t, 3*time.Second, "status with one pod", func() bool {
+		ds, _ := store.GetDaemonSet("logs")
+		desired, _ := resources.NestedInt64(ds, "status", "desiredNumberScheduled")
+		available, _ := resources.NestedInt64(ds, "status", "numberAvailable")
+		byNode := daemonPodsByNode(store)
+		return desired == 1 && available == 1 && len(byNode) == 1 && len(byNode["node-e"]) == 1
+	})
+}
+
+func TestDaemonSetRollingUpdateRespectsMaxUnavailable(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	usePodController(t, store)
+	startDaemonSetController(t, store)
+
+	nodes := []string{"node-a", "node-b", "node-c", "node-d"}
+	for _, name := range nodes {
+		store.CreateNode(newTestNode(name, nil))
+	}
+	store.CreateDaemonSet(newTestDaemonSet("metrics", map[string]interface{}{}))
+	waitFor(t, 3*time.Second, "4 available pods", func() bool {
+		ds, _ := store.GetDaemonSet("metrics")
+		available, _ := resources.NestedInt64(ds, "status", "numberAvailable")
+		return available == 4
+	})
+
+	store.MutateDaemonSet("metrics", func(ds map[string]interface{}) error {
+		containers, _ := resources.NestedSlice(ds, "spec", "template", "spec", "containers")
+		containers[0].(map[string]interface{})["image"] = "agent:v2"
+		return nil
+	})
+	// maxUnavailable=1: at most one node at a time is without a ready pod
+	waitFor(t, 5*time.Second, "all pods on agent:v2", func() bool {
+		byNode := daemonPodsByNode(store)
+		ready, updated := 0, 0
+		for _, name := range nodes {
+			for _, pod := range byNode[name] {
+				if isPodReady(pod) {
+					ready++
+				}
+				if podImage(pod) == "agent:v2" && isPodReady(pod) {
+					updated++
+				}
+			}
+		}
+		if ready < 3 {
+			t.Fatalf("Expected at most 1 unavailable node during the update, got %d ready pods", ready)
+		}
+		return updated == 4
+	})
+	waitFor(t, time.Second, "updatedNumberScheduled 4", func() bool {
+		ds, _ := store.GetDaemonSet("metrics")
+		updated, _ := resources.NestedInt64(ds, "status", "updatedNumberScheduled")
+		return updated == 4
+	})
+}
+
+func TestNodeShouldRunDaemonPod(t *testing.T) {
+	affinity := func(term map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{"nodeAffinity": map[string]interface{}{
+			"requiredDuringSchedulingIgnoredDuringExecution": map[string]interface{}{
+				"nodeSelectorTerms": []interface{}{term},
+			},
+		}}
+	}
+	requirement := func(key, operator string, values ...interface{}) []interface{} {
+		return []interface{}{map[string]interface{}{"key": key, "operator": operator, "values": values}}
+	}
+	toMap := func(node *resources.Node) map[string]interface{} {
+		var m map[string]interface{}
+		deepCopyJSON(node, &m)
+		return m
+	}
+	big := toMap(newTestNode("big", map[string]string{"cores": "64", "zone": "a"}))
+	small := toMap(newTestNode("small", map[string]string{"cores": "4", "zone": "b"}))
+	tainted := toMap(newTestNode("tainted", nil, map[string]interface{}{"key": "dedicated", "value": "db", "effect": "NoSchedule"}))
+	evicting := toMap(newTestNode("evicting", nil, map[string]interface{}{"key": "dedicated", "value": "db", "effect": "NoExecute"}))
+	preferred := toMap(newTestNode("preferred", nil, map[string]interface{}{"key": "dedicated", "effect": "PreferNoSchedule"}))
+	notReady := toMap(newTestNode("not-ready", nil, map[string]interface{}{"key": TaintNodeNotReady, "effect": "NoExecute"}))
+
+	tests := []struct {
+		name               string
+		spec               map[string]interface{}
+		node               map[string]interface{}
+		run, continueToRun bool
+	}{
+		{"no constraints", map[string]interface{}{}, big, true, true},
+		{"Gt matches", map[string]interface{}{"affinity": affinity(map[string]interface{}{"matchExpressions": requirement("cores", "Gt", "16")})}, big, true, true},
+		{"Gt does not match", map[string]interface{}{"affinity": affinity(map[string]interface{}{"matchExpressions": requirement("cores", "Gt", "16")})}, small, false, false},
+		{"NotIn", map[string]interface{}{"affinity": affinity(map[string]interface{}{"matchExpressions": requirement("zone", "NotIn", "a")})}, small, true, true},
+		{"matchFields", map[string]interface{}{"affinity": affinity(map[string]interface{}{"matchFields": requirement("metadata.name", "In", "small")})}, big, false, false},
+		{"empty term", map[string]interface{}{"affinity": affinity(map[string]interface{}{})}, big, false, false},
+		{"nodeSelector", map[string]interface{}{"nodeSelector": map[string]interface{}{"zone": "b"}}, big, false, false},
+		{"NoSchedule keeps running pods", map[string]interface{}{}, tainted, false, true},
+		{"NoExecute evicts", map[string]interface{}{}, evicting, false, false},
+		{"PreferNoSchedule ignored", map[string]interface{}{}, preferred, true, true},
+		{"tolerated", map[string]interface{}{"tolerations": []interface{}{map[string]interface{}{"key": "dedicated", "operator": "Equal", "value": "db"}}}, evicting, true, true},
+		{"wrong value", map[string]interface{}{"tolerations": []interface{}{map[string]interface{}{"key": "dedicated", "value": "web"}}}, tainted, false, true},
+		{"daemon tolerations", map[string]interface{}{}, notReady, true, true},
+	}
+	for _, tt := range tests {
+		ds := map[string]interface{}{"spec": map[string]interface{}{"template": map[string]interface{}{"spec": tt.spec}}}
+		run, continueToRun := nodeShouldRunDaemonPod(daemonPodSpec(ds), tt.node)
+		if run != tt.run || continueToRun != tt.continueToRun {
+			t.Errorf("%s: expected (%v, %v), got (%v, %v)", tt.name, tt.run, tt.continueToRun, run, continueToRun)
+		}
+	}
+}
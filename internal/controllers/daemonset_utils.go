@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"sort"
+	"strings"
+
+	"mockernetes/internal/resources"
+)
+
+// DaemonSet pods: one per node the pod template fits on. Their tolerations let them stay on
+// nodes that are unhealthy or cordoned, which is what node agents are for.
+
+// daemonPodTolerations are added to every DaemonSet pod (AddOrUpdateDaemonPodTolerations upstream)
+var daemonPodTolerations = []map[string]interface{}{
+	{"key": TaintNodeNotReady, "operator": "Exists", "effect": "NoExecute"},
+	{"key": TaintNodeUnreachable, "operator": "Exists", "effect": "NoExecute"},
+	{"key": TaintNodeDiskPressure, "operator": "Exists", "effect": "NoSchedule"},
+	{"key": TaintNodeMemoryPressure, "operator": "Exists", "effect": "NoSchedule"},
+	{"key": TaintNodePIDPressure, "operator": "Exists", "effect": "NoSchedule"},
+	{"key": TaintNodeUnschedulable, "operator": "Exists", "effect": "NoSchedule"},
+}
+
+// daemonPodSpec returns a copy of the DaemonSet's pod template spec with the daemon tolerations.
+// Host network pods also tolerate a node whose network is not set up yet.
+func daemonPodSpec(ds map[string]interface{}) map[string]interface{} {
+	var spec map[string]interface{}
+	template, _ := resources.NestedMap(ds, "spec", "template", "spec")
+	deepCopyJSON(template, &spec)
+	if spec == nil {
+		spec = map[string]interface{}{}
+	}
+	tolerations, _ := resources.NestedSlice(spec, "tolerations")
+	extra := daemonPodTolerations
+	if hostNetwork, _ := spec["hostNetwork"].(bool); hostNetwork {
+		extra = append(extra[:len(extra):len(extra)], map[string]interface{}{
+			"key": TaintNodeNetworkUnavailable, "operator": "Exists", "effect": "NoSchedule",
+		})
+	}
+	for _, toleration := range extra {
+		tolerations = addOrUpdateToleration(tolerations, toleration)
+	}
+	spec["tolerations"] = tolerations
+	return spec
+}
+
+// addOrUpdateToleration replaces the toleration with the same key, operator and effect
+// (e.g. one with tolerationSeconds) or appends it
+func addOrUpdateToleration(tolerations []interface{}, toleration map[string]interface{}) []interface{} {
+	for i, t := range tolerations {
+		existing, _ := t.(map[string]interface{})
+		operator, _ := existing["operator"].(string)
+		if operator == "" {
+			operator = "Equal"
+		}
+		if existing["key"] == toleration["key"] && existing["effect"] == toleration["effect"] && operator == toleration["operator"] {
+			tolerations[i] = copyMap(toleration)
+			return tolerations
+		}
+	}
+	return append(tolerations, copyMap(toleration))
+}
+
+// copyMap returns a shallow copy of m
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// nodeShouldRunDaemonPod reports whether a new daemon pod belongs on the node and whether
+// a daemon pod already there may keep running. Untolerated NoSchedule taints only keep new
+// pods off the node; NoExecute taints evict running ones too.
+func nodeShouldRunDaemonPod(podSpec, node map[string]interface{}) (shouldRun, shouldContinueRunning bool) {
+	if isBeingDeleted(node) || !nodeMatchesPod(podSpec, node) {
+		return false, false
+	}
+	if untoleratedTaint(podSpec, node, "NoExecute") != nil {
+		return false, false
+	}
+	return untoleratedTaint(podSpec, node, "NoSchedule") == nil, true
+}
+
+// newDaemonPod builds the DaemonSet's pod for a node. The pod is pinned to the node through
+// a matchFields affinity term (replacing any required node affinity) and bound right away.
+func newDaemonPod(ds map[string]interface{}, hash, nodeName string) resources.Pod {
+	template, _ := resources.NestedMap(ds, "spec", "template")
+	pod := newPodFromTemplate(ds, "DaemonSet", template, map[string]string{StatefulSetRevisionLabel: hash})
+	spec := daemonPodSpec(ds)
+
+	affinity, _ := spec["affinity"].(map[string]interface{})
+	if affinity == nil {
+		affinity = map[string]interface{}{}
+		spec["affinity"] = affinity
+	}
+	nodeAffinity, _ := affinity["nodeAffinity"].(map[string]interface{})
+	if nodeAffinity == nil {
+		nodeAffinity = map[string]interface{}{}
+		affinity["nodeAffinity"] = nodeAffinity
+	}
+	nodeAffinity["requiredDuringSchedulingIgnoredDuringExecution"] = map[string]interface{}{
+		"nodeSelectorTerms": []interface{}{map[string]interface{}{
+			"matchFields": []interface{}{map[string]interface{}{
+				"key":      "metadata.name",
+				"operator": "In",
+				"values":   []interface{}{nodeName},
+			}},
+		}},
+	}
+	spec["nodeName"] = nodeName
+	pod.Spec = spec
+	return pod
+}
+
+// daemonRevisionHash returns the template hash of a DaemonSet ControllerRevision
+// ("<ds>-<hash>"), which is what the pods are labeled with
+func daemonRevisionHash(dsName string, revision map[string]interface{}) string {
+	name, _ := resources.NestedString(revision, "metadata", "name")
+	return strings.TrimPrefix(name, dsName+"-")
+}
+
+// daemonPodsByAge sorts pods oldest first (by name on equal timestamps)
+func daemonPodsByAge(pods []map[string]interface{}) {
+	sort.SliceStable(pods, func(i, j int) bool {
+		ti, tj := creationTime(pods[i]), creationTime(pods[j])
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		ni, _ := resources.NestedString(pods[i], "metadata", "name")
+		nj, _ := resources.NestedString(pods[j], "metadata", "name")
+		return ni < nj
+	})
+}
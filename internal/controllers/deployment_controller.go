@@ -1,153 +1,194 @@
 package controllers
 
 import (
+	"encoding/json"
 	"fmt"
 	"hash/fnv"
-	"sync"
 	"time"
 
 	"mockernetes/internal/resources"
 	"mockernetes/internal/storage"
 )
 
-// DeploymentController manages the lifecycle of Deployments and their ReplicaSets
+// DeploymentController manages the lifecycle of Deployments and their ReplicaSets.
+// It is driven by Deployment and ReplicaSet change events through a work queue.
 type DeploymentController struct {
-	store       *storage.InMemoryStore
-	mu          sync.RWMutex
-	stopCh      chan struct{}
-	reconciling map[string]bool // track which deployments are being reconciled
-	reconcileMu sync.Mutex
+	store   *storage.InMemoryStore
+	queue   *RateLimitingQueue
+	workers int
 }
 
-// NewDeploymentController creates a new DeploymentController
-func NewDeploymentController(store *storage.InMemoryStore) *DeploymentController {
-	return &DeploymentController{
-		store:       store,
-		stopCh:      make(chan struct{}),
-		reconciling: make(map[string]bool),
+// NewDeploymentController creates a new DeploymentController fed by the given informers
+func NewDeploymentController(store *storage.InMemoryStore, informers *SharedInformerFactory) *DeploymentController {
+	dc := &DeploymentController{
+		store:   store,
+		queue:   NewRateLimitingQueue(),
+		workers: DefaultWorkers,
 	}
+	informers.AddEventHandler("Deployment", ResourceEventHandlerFuncs{
+		AddFunc:    dc.enqueue,
+		UpdateFunc: func(_, deploy map[string]interface{}) { dc.enqueue(deploy) },
+		DeleteFunc: dc.enqueue,
+	})
+	// ReplicaSet changes wake up the owning Deployment (e.g. a deleted RS gets re-created)
+	informers.AddEventHandler("ReplicaSet", ResourceEventHandlerFuncs{
+		AddFunc:    dc.enqueueOwner,
+		UpdateFunc: func(_, rs map[string]interface{}) { dc.enqueueOwner(rs) },
+		DeleteFunc: dc.enqueueOwner,
+	})
+	// Pod readiness drives the rollout, so pod changes wake up the Deployment owning their ReplicaSet
+	informers.AddEventHandler("Pod", ResourceEventHandlerFuncs{
+		AddFunc:    dc.enqueueForPod,
+		UpdateFunc: func(_, pod map[string]interface{}) { dc.enqueueForPod(pod) },
+		DeleteFunc: dc.enqueueForPod,
+	})
+	return dc
 }
 
-// Start starts the controller's reconciliation loop
+// Start starts the controller's workers
 func (dc *DeploymentController) Start() {
-	go dc.reconcileLoop()
+	runWorkers(dc.queue, dc.workers, "Deployment Controller", dc.syncDeployment)
 }
 
 // Stop stops the controller
 func (dc *DeploymentController) Stop() {
-	close(dc.stopCh)
+	dc.queue.ShutDownAndWait()
 }
 
-// reconcileLoop periodically reconciles Deployments
-func (dc *DeploymentController) reconcileLoop() {
-	ticker := time.NewTicker(10 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-dc.stopCh:
-			return
-		case <-ticker.C:
-			dc.reconcileAll()
-		}
+// enqueue queues a Deployment for sync
+func (dc *DeploymentController) enqueue(deploy map[string]interface{}) {
+	dc.queue.Add(objectKey(deploy))
+}
+
+// enqueueOwner queues the Deployment controlling a ReplicaSet, if any
+func (dc *DeploymentController) enqueueOwner(rs map[string]interface{}) {
+	if key := ownerKey(rs, "Deployment"); key != "" {
+		dc.queue.Add(key)
 	}
 }
 
-// reconcileAll reconciles all Deployments
-func (dc *DeploymentController) reconcileAll() {
-	deployments := dc.store.ListDeployments()
-	for _, deployItem := range deployments {
-		if deploy, ok := deployItem.(map[string]interface{}); ok {
-			dc.reconcileDeployment(deploy)
-		}
+// enqueueForPod queues the Deployment controlling the ReplicaSet of a pod, if any
+func (dc *DeploymentController) enqueueForPod(pod map[string]interface{}) {
+	ref := controllerOf(pod, "ReplicaSet")
+	if ref == nil {
+		return
+	}
+	rsName, _ := ref["name"].(string)
+	if rs, err := dc.store.GetReplicaSet(rsName); err == nil {
+		dc.enqueueOwner(rs)
 	}
 }
 
-// reconcileDeployment ensures the Deployment has the correct ReplicaSet
-func (dc *DeploymentController) reconcileDeployment(deploy map[string]interface{}) {
-	// Extract Deployment info
-	metadata, _ := deploy["metadata"].(map[string]interface{})
-	spec, _ := deploy["spec"].(map[string]interface{})
+// syncDeployment reconciles the Deployment stored under key (gone Deployments need no work)
+func (dc *DeploymentController) syncDeployment(key string) error {
+	_, name := splitKey(key)
+	deploy, err := dc.store.GetDeployment(name)
+	if err != nil {
+		return nil
+	}
+	return dc.reconcileDeployment(deploy)
+}
 
-	deployName, _ := metadata["name"].(string)
-	namespace, _ := metadata["namespace"].(string)
-	if namespace == "" {
-		namespace = "default"
+// reconcileDeployment rolls the Deployment's ReplicaSets towards the current pod template
+// using its strategy and records the resulting status
+func (dc *DeploymentController) reconcileDeployment(deploy map[string]interface{}) error {
+	// A Deployment waiting on finalizers is left alone until it is removed
+	if isBeingDeleted(deploy) {
+		return nil
 	}
 
-	// Prevent concurrent reconciliation of the same Deployment
-	deployKey := fmt.Sprintf("%s/%s", namespace, deployName)
-	dc.reconcileMu.Lock()
-	if dc.reconciling[deployKey] {
-		dc.reconcileMu.Unlock()
-		fmt.Printf("[Deployment Controller] Skipping reconciliation of %s - already in progress\n", deployKey)
-		return
+	spec, _ := deploy["spec"].(map[string]interface{})
+	r := dc.getRollout(deploy)
+
+	// A paused Deployment is only scaled; template changes wait for it to be resumed
+	var err error
+	if strategy, _ := resources.NestedString(spec, "strategy", "type"); r.paused {
+		err = dc.scalePaused(r)
+	} else if strategy == "Recreate" {
+		err = dc.rolloutRecreate(r)
+	} else {
+		err = dc.rolloutRolling(r)
 	}
-	dc.reconciling[deployKey] = true
-	dc.reconcileMu.Unlock()
-
-	defer func() {
-		dc.reconcileMu.Lock()
-		delete(dc.reconciling, deployKey)
-		dc.reconcileMu.Unlock()
-	}()
-
-	// Get desired replicas (default: 1)
-	desiredReplicas := int32(1)
-	if replicas, ok := spec["replicas"].(float64); ok {
-		desiredReplicas = int32(replicas)
-	}
-
-	// Get selector
-	selector := make(map[string]string)
-	if selectorMap, ok := spec["selector"].(map[string]interface{}); ok {
-		if matchLabels, ok := selectorMap["matchLabels"].(map[string]interface{}); ok {
-			for k, v := range matchLabels {
-				if sv, ok := v.(string); ok {
-					selector[k] = sv
-				}
-			}
-		}
+	if err == nil {
+		err = dc.syncRevision(r)
+	}
+	if err == nil {
+		err = dc.cleanupOldReplicaSets(r, spec)
+	}
+	if err != nil {
+		fmt.Printf("[Deployment Controller] Error rolling out Deployment %s: %v\n", r.name, err)
+		return err
 	}
 
-	// Get pod template
-	template, _ := spec["template"].(map[string]interface{})
-
-	// Generate pod-template-hash from template
-	templateHash := computeTemplateHash(template)
-
-	// Calculate expected ReplicaSet name
-	rsName := fmt.Sprintf("%s-%s", deployName, templateHash)
+	requeue, err := dc.updateDeploymentStatus(r)
+	if err != nil {
+		return err
+	}
+	// Nothing fires when a pod outlives minReadySeconds or the progress deadline passes
+	if r.requeueAfter > 0 && (requeue == 0 || r.requeueAfter < requeue) {
+		requeue = r.requeueAfter
+	}
+	if requeue > 0 {
+		dc.queue.AddAfter(objectKey(deploy), requeue)
+	}
+	return nil
+}
 
-	// Check if ReplicaSet exists
-	existingRS, err := dc.store.GetReplicaSet(rsName)
+// getRollout collects the ReplicaSets controlled by the Deployment (split into the new one,
+// whose template matches the Deployment's, and the old ones) and their pods
+func (dc *DeploymentController) getRollout(deploy map[string]interface{}) *rollout {
+	spec, _ := deploy["spec"].(map[string]interface{})
+	template, _ := spec["template"].(map[string]interface{})
+	replicas, _ := resources.NestedInt64(spec, "replicas")
+	minReadySeconds, _ := resources.NestedInt64(spec, "minReadySeconds")
+	paused, _ := spec["paused"].(bool)
+	r := &rollout{
+		deploy:          deploy,
+		hash:            computeTemplateHash(template),
+		replicas:        int32(replicas),
+		minReadySeconds: minReadySeconds,
+		paused:          paused,
+		pods:            make(map[string][]map[string]interface{}),
+		now:             time.Now(),
+	}
+	if strategy, _ := resources.NestedString(spec, "strategy", "type"); strategy != "Recreate" {
+		r.maxSurge, r.maxUnavailable = resolveFenceposts(spec, r.replicas)
+	}
+	r.name, _ = resources.NestedString(deploy, "metadata", "name")
+	r.namespace, _ = resources.NestedString(deploy, "metadata", "namespace")
+	if r.namespace == "" {
+		r.namespace = "default"
+	}
 
-	if err != nil {
-		// ReplicaSet doesn't exist, create it
-		fmt.Printf("[Deployment Controller] Creating ReplicaSet %s for Deployment %s\n", rsName, deployName)
-		if err := dc.createReplicaSetForDeployment(deployName, namespace, rsName, desiredReplicas, selector, template, templateHash); err != nil {
-			fmt.Printf("[Deployment Controller] Error creating ReplicaSet: %v\n", err)
-			return
+	deployUID := objectUID(deploy)
+	var owned []map[string]interface{}
+	for _, item := range dc.store.ListReplicaSets() {
+		if rs, ok := item.(map[string]interface{}); ok && isOwnedBy(rs, "Deployment", r.name, deployUID) {
+			owned = append(owned, rs)
 		}
-	} else {
-		// ReplicaSet exists, check if replicas need updating
-		rsSpec, _ := existingRS["spec"].(map[string]interface{})
-		currentReplicas := int32(1)
-		if replicas, ok := rsSpec["replicas"].(float64); ok {
-			currentReplicas = int32(replicas)
+	}
+	// The oldest ReplicaSet with the current template is the new one (a rollback reuses it)
+	sortByCreation(owned)
+	for _, rs := range owned {
+		rsTemplate, _ := resources.NestedMap(rs, "spec", "template")
+		if r.newRS == nil && equalIgnoreHash(rsTemplate, template) {
+			r.newRS = rs
+		} else {
+			r.oldRSs = append(r.oldRSs, rs)
 		}
+	}
 
-		if currentReplicas != desiredReplicas {
-			fmt.Printf("[Deployment Controller] Scaling ReplicaSet %s: %d -> %d\n", rsName, currentReplicas, desiredReplicas)
-			if err := dc.updateReplicaSetReplicas(rsName, desiredReplicas); err != nil {
-				fmt.Printf("[Deployment Controller] Error updating ReplicaSet: %v\n", err)
-				return
-			}
+	for _, item := range dc.store.ListPods() {
+		pod, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if ref := controllerOf(pod, "ReplicaSet"); ref != nil {
+			uid, _ := ref["uid"].(string)
+			r.pods[uid] = append(r.pods[uid], pod)
 		}
 	}
-
-	// Update Deployment status
-	dc.updateDeploymentStatus(deployName, namespace, desiredReplicas)
+	return r
 }
 
 // computeTemplateHash generates a simple hash from the pod template
@@ -155,192 +196,206 @@ func computeTemplateHash(template map[string]interface{}) string {
 	if template == nil {
 		return "00000000"
 	}
-	// Simple hash based on template content
-	data := fmt.Sprintf("%v", template)
+	// Simple hash based on template content (the same for templates equalIgnoreHash matches)
+	data := fmt.Sprintf("%v", normalizeTemplate(template))
 	h := fnv.New32a()
 	h.Write([]byte(data))
 	return fmt.Sprintf("%08x", h.Sum32())[:8]
 }
 
-// createReplicaSetForDeployment creates a new ReplicaSet for the Deployment
-func (dc *DeploymentController) createReplicaSetForDeployment(deployName, namespace, rsName string, replicas int32, selector map[string]string, template map[string]interface{}, templateHash string) error {
-	// Build owner reference
-	ownerRef := resources.OwnerReference{
-		APIVersion:         "apps/v1",
-		Kind:               "Deployment",
-		Name:               deployName,
-		UID:                fmt.Sprintf("deploy-uid-%s", deployName),
-		Controller:         true,
-		BlockOwnerDeletion: true,
+// deepCopyJSON copies in into out through JSON, like the store does on every read
+func deepCopyJSON(in interface{}, out interface{}) error {
+	b, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
+
+// createNewReplicaSet creates the ReplicaSet for the current pod template. The pod-template-hash
+// label goes on the ReplicaSet, its selector and its pod template, so its pods carry it too.
+func (dc *DeploymentController) createNewReplicaSet(r *rollout, replicas int32) error {
+	rsName := fmt.Sprintf("%s-%s", r.name, r.hash)
+	fmt.Printf("[Deployment Controller] Creating ReplicaSet %s for Deployment %s\n", rsName, r.name)
+
+	// Work on a copy so the Deployment's own template is not touched
+	var spec map[string]interface{}
+	if err := deepCopyJSON(r.deploy["spec"], &spec); err != nil {
+		return err
+	}
+	template, _ := spec["template"].(map[string]interface{})
+	if template == nil {
+		template = map[string]interface{}{}
+	}
+	templateMetadata, _ := template["metadata"].(map[string]interface{})
+	if templateMetadata == nil {
+		templateMetadata = map[string]interface{}{}
+		template["metadata"] = templateMetadata
 	}
+	podLabels, _ := templateMetadata["labels"].(map[string]interface{})
+	if podLabels == nil {
+		podLabels = map[string]interface{}{}
+		templateMetadata["labels"] = podLabels
+	}
+	podLabels["pod-template-hash"] = r.hash
 
-	// Build labels with pod-template-hash
 	labels := make(map[string]string)
-	if templateMetadata, ok := template["metadata"].(map[string]interface{}); ok {
-		if podLabels, ok := templateMetadata["labels"].(map[string]interface{}); ok {
-			for k, v := range podLabels {
-				if sv, ok := v.(string); ok {
-					labels[k] = sv
-				}
-			}
+	for k, v := range podLabels {
+		if sv, ok := v.(string); ok {
+			labels[k] = sv
+		}
+	}
+	matchLabels := map[string]interface{}{"pod-template-hash": r.hash}
+	if deploySelector, ok := resources.NestedMap(spec, "selector", "matchLabels"); ok {
+		for k, v := range deploySelector {
+			matchLabels[k] = v
 		}
 	}
-	// Add pod-template-hash label
-	labels["pod-template-hash"] = templateHash
 
-	// Build ReplicaSet spec
 	rsSpec := map[string]interface{}{
 		"replicas": replicas,
-		"selector": map[string]interface{}{
-			"matchLabels": labels,
-		},
+		"selector": map[string]interface{}{"matchLabels": matchLabels},
 		"template": template,
 	}
+	if r.minReadySeconds > 0 {
+		rsSpec["minReadySeconds"] = r.minReadySeconds
+	}
+
+	// Build owner reference (the garbage collector follows it by uid)
+	ownerRef := resources.OwnerReference{
+		APIVersion:         "apps/v1",
+		Kind:               "Deployment",
+		Name:               r.name,
+		UID:                objectUID(r.deploy),
+		Controller:         true,
+		BlockOwnerDeletion: true,
+	}
+	// Revision, copied Deployment annotations and desired/max-replicas
+	annotations := replicasAnnotations(r)
+	stamped := map[string]interface{}{}
+	setNewReplicaSetAnnotations(r.deploy, stamped, maxRevision(r.oldRSs)+1)
+	for k, v := range objectAnnotations(stamped) {
+		annotations[k], _ = v.(string)
+	}
 
-	// Create the ReplicaSet
 	rs := resources.ReplicaSet{
 		Kind:       "ReplicaSet",
 		APIVersion: "apps/v1",
 		Metadata: resources.ObjectMeta{
 			Name:              rsName,
-			Namespace:         namespace,
+			Namespace:         r.namespace,
 			Labels:            labels,
+			Annotations:       annotations,
 			CreationTimestamp: time.Now().Format(time.RFC3339),
 			OwnerReferences:   []resources.OwnerReference{ownerRef},
 		},
 		Spec: rsSpec,
 	}
-
 	if err := dc.store.CreateReplicaSet(rs); err != nil {
 		return fmt.Errorf("failed to create ReplicaSet: %w", err)
 	}
-
-	fmt.Printf("[Deployment Controller] Created ReplicaSet %s for Deployment %s\n", rsName, deployName)
-
-	// Trigger ReplicaSet controller for immediate reconciliation
-	if DefaultReplicaSetController != nil {
-		DefaultReplicaSetController.OnReplicaSetCreated(rs)
+	created, err := dc.store.GetReplicaSet(rsName)
+	if err != nil {
+		return fmt.Errorf("failed to get ReplicaSet: %w", err)
 	}
+	r.newRS = created
+	r.createdRS = true
 
+	fmt.Printf("[Deployment Controller] Created ReplicaSet %s for Deployment %s\n", rsName, r.name)
 	return nil
 }
 
-// updateReplicaSetReplicas updates the replicas count of an existing ReplicaSet
-func (dc *DeploymentController) updateReplicaSetReplicas(rsName string, replicas int32) error {
-	rs, err := dc.store.GetReplicaSet(rsName)
-	if err != nil {
-		return err
-	}
-
-	// Update spec.replicas
-	if spec, ok := rs["spec"].(map[string]interface{}); ok {
-		spec["replicas"] = replicas
-	}
-
-	// Convert back to ReplicaSet struct
-	updatedRS := resources.ReplicaSet{
-		Kind:       "ReplicaSet",
-		APIVersion: "apps/v1",
-		Metadata: resources.ObjectMeta{
-			Name:      rsName,
-			Namespace: "default",
-		},
-		Spec:   rs["spec"],
-		Status: rs["status"],
-	}
-
-	if err := dc.store.UpdateReplicaSet(updatedRS); err != nil {
-		return err
-	}
-
-	// Trigger reconciliation
-	if DefaultReplicaSetController != nil {
-		DefaultReplicaSetController.OnReplicaSetCreated(updatedRS)
-	}
-
-	return nil
+// updateReplicaSetReplicas updates the replicas count (and the given annotations) of an existing ReplicaSet
+func (dc *DeploymentController) updateReplicaSetReplicas(rsName string, replicas int32, annotations map[string]string) error {
+	// Update spec.replicas in place (keeps the RS metadata and ownerReferences);
+	// the ReplicaSet controller picks the change up from the store event
+	_, err := dc.store.MutateReplicaSet(rsName, func(rs map[string]interface{}) error {
+		if spec, ok := rs["spec"].(map[string]interface{}); ok {
+			spec["replicas"] = replicas
+		}
+		rsAnnotations := objectAnnotations(rs)
+		for k, v := range annotations {
+			rsAnnotations[k] = v
+		}
+		return nil
+	})
+	return err
 }
 
-// updateDeploymentStatus updates the Deployment status
-func (dc *DeploymentController) updateDeploymentStatus(deployName, namespace string, replicas int32) error {
-	deploy, err := dc.store.GetDeployment(deployName)
-	if err != nil {
-		return err
+// updateDeploymentStatus records replica counts of the observed pods and the Available,
+// Progressing and ReplicaFailure conditions. It returns when the Deployment must be checked
+// again for the progress deadline (0 if not needed).
+func (dc *DeploymentController) updateDeploymentStatus(r *rollout) (time.Duration, error) {
+	var replicas, ready, available int32
+	for _, rs := range r.allRSs() {
+		replicas += r.podCount(rs)
+		ready += r.readyCount(rs)
+		available += r.availableCount(rs)
 	}
-
+	updated := r.podCount(r.newRS)
+	// The generation this sync acted on, so clients can tell the status is current
+	generation, _ := resources.NestedInt64(r.deploy, "metadata", "generation")
 	status := map[string]interface{}{
-		"replicas":             replicas,
-		"availableReplicas":    replicas,
-		"readyReplicas":        replicas,
-		"updatedReplicas":      replicas,
-		"observedGeneration":   1,
+		"replicas":            replicas,
+		"updatedReplicas":     updated,
+		"readyReplicas":       ready,
+		"availableReplicas":   available,
+		"unavailableReplicas": max(r.replicas-available, 0),
+		"observedGeneration":  generation,
 	}
 
-	deploy["status"] = status
-
-	updatedDeploy := resources.Deployment{
-		Kind:       "Deployment",
-		APIVersion: "apps/v1",
-		Metadata: resources.ObjectMeta{
-			Name:      deployName,
-			Namespace: namespace,
-		},
-		Spec:   deploy["spec"],
-		Status: status,
-	}
-
-	return dc.store.UpdateDeployment(updatedDeploy)
+	var requeue time.Duration
+	_, err := dc.store.MutateDeployment(r.name, func(deploy map[string]interface{}) error {
+		previous, _ := deploy["status"].(map[string]interface{})
+		// Progressing compares against the previous counts, on top of the other conditions
+		base := map[string]interface{}{}
+		for k, v := range previous {
+			base[k] = v
+		}
+		base["conditions"] = availableConditions(r, previous, available)
+		conditions, after := progressingConditions(r, deploy, base, status)
+		requeue = after
+		if len(conditions) > 0 {
+			status["conditions"] = conditions
+		}
+		deploy["status"] = status
+		return nil
+	})
+	return requeue, err
 }
 
-// OnDeploymentCreated is called when a new Deployment is created
-func (dc *DeploymentController) OnDeploymentCreated(deploy resources.Deployment) error {
-	// Trigger immediate reconciliation
-	deployMap := map[string]interface{}{
-		"metadata": map[string]interface{}{
-			"name":      deploy.GetName(),
-			"namespace": deploy.GetNamespace(),
-		},
-		"spec": deploy.Spec,
+// availableConditions returns the conditions of previous with Available set from the number
+// of available pods (at least replicas-maxUnavailable) and the ReplicaFailure of any of the
+// Deployment's ReplicaSets mirrored, or cleared once none has one.
+func availableConditions(r *rollout, previous map[string]interface{}, available int32) []interface{} {
+	var cond map[string]interface{}
+	if available >= r.replicas-r.maxUnavailable {
+		cond = setCondition(previous, DeploymentAvailable, "True", MinimumReplicasAvailable, "Deployment has minimum availability.", r.now)
+	} else {
+		cond = setCondition(previous, DeploymentAvailable, "False", MinimumReplicasUnavailable, "Deployment does not have minimum availability.", r.now)
 	}
-	dc.reconcileDeployment(deployMap)
-	return nil
+	stampUpdateTime(cond, r.now)
+	conditions := withCondition(previous, DeploymentAvailable, cond)
+
+	var failure map[string]interface{}
+	for _, rs := range r.allRSs() {
+		rsStatus, _ := rs["status"].(map[string]interface{})
+		if rsFailure := deploymentCondition(rsStatus, ReplicaSetReplicaFailure); rsFailure != nil {
+			reason, _ := rsFailure["reason"].(string)
+			message, _ := rsFailure["message"].(string)
+			failure = setCondition(previous, ReplicaSetReplicaFailure, "True", reason, message, r.now)
+			stampUpdateTime(failure, r.now)
+			break
+		}
+	}
+	return withCondition(map[string]interface{}{"conditions": conditions}, ReplicaSetReplicaFailure, failure)
 }
 
-// OnDeploymentDeleted handles cleanup when a Deployment is deleted
-func (dc *DeploymentController) OnDeploymentDeleted(deployName, namespace string) error {
-	// Find and delete all ReplicaSets owned by this Deployment
-	allRS := dc.store.ListReplicaSets()
-	for _, rsItem := range allRS {
-		if rs, ok := rsItem.(map[string]interface{}); ok {
-			if metadata, ok := rs["metadata"].(map[string]interface{}); ok {
-				// Check owner references
-				if ownerRefsRaw, ok := metadata["ownerReferences"]; ok {
-					var ownerRefs []interface{}
-					switch v := ownerRefsRaw.(type) {
-					case []interface{}:
-						ownerRefs = v
-					case []map[string]interface{}:
-						for _, ref := range v {
-							ownerRefs = append(ownerRefs, ref)
-						}
-					}
-
-					for _, ownerRef := range ownerRefs {
-						if ref, ok := ownerRef.(map[string]interface{}); ok {
-							refName, _ := ref["name"].(string)
-							refKind, _ := ref["kind"].(string)
-							if refName == deployName && refKind == "Deployment" {
-								rsName, _ := metadata["name"].(string)
-								fmt.Printf("[Deployment Controller] Deleting ReplicaSet %s (owned by Deployment %s)\n", rsName, deployName)
-								dc.store.DeleteReplicaSet(rsName)
-							}
-						}
-					}
-				}
-			}
-		}
+// stampUpdateTime gives a new Deployment condition its lastUpdateTime (kept ones have it)
+func stampUpdateTime(cond map[string]interface{}, now time.Time) {
+	if _, ok := cond["lastUpdateTime"]; !ok {
+		cond["lastUpdateTime"] = now.UTC().Format(time.RFC3339)
 	}
-	return nil
 }
 
 // DefaultDeploymentController is the singleton instance
@@ -348,6 +403,6 @@ var DefaultDeploymentController *DeploymentController
 
 // InitDeploymentController initializes the default Deployment controller
 func InitDeploymentController(store *storage.InMemoryStore) {
-	DefaultDeploymentController = NewDeploymentController(store)
+	DefaultDeploymentController = NewDeploymentController(store, sharedInformers(store))
 	DefaultDeploymentController.Start()
 }
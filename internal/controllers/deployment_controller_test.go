@@ -0,0 +1,438 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+// usePodController installs a fast pod controller as DefaultPodController for the test.
+func usePodController(t *testing.T, store *storage.InMemoryStore) *PodController {
+	pc := NewPodController(store, 20*time.Millisecond)
+	pc.ShutdownDelay = 10 * time.Millisecond
+	pc.RestartBackOff = 10 * time.Millisecond
+	DefaultPodController = pc
+	t.Cleanup(func() {
+		pc.Stop()
+		DefaultPodController = nil
+	})
+	return pc
+}
+
+// startDeploymentControllers runs the ReplicaSet and Deployment controllers on store.
+func startDeploymentControllers(t *testing.T, store *storage.InMemoryStore) {
+	informers := startInformers(t, store)
+	rsController := NewReplicaSetController(store, informers)
+	rsController.Start()
+	t.Cleanup(rsController.Stop)
+	deployController := NewDeploymentController(store, informers)
+	deployController.Start()
+	t.Cleanup(deployController.Stop)
+}
+
+func newTestDeployment(name string, replicas int, image string, strategy map[string]interface{}) resources.Deployment {
+	deploy := resources.Deployment{
+		Kind:       "Deployment",
+		APIVersion: "apps/v1",
+		Metadata:   resources.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: map[string]interface{}{
+			"replicas": float64(replicas),
+			"strategy": strategy,
+			"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": name}},
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": name}},
+				"spec":     map[string]interface{}{"containers": []interface{}{map[string]interface{}{"name": name, "image": image}}},
+			},
+		},
+	}
+	resources.SetDeploymentDefaults(&deploy)
+	return deploy
+}
+
+// setImage changes the Deployment's pod template, starting a rollout.
+func setImage(store *storage.InMemoryStore, name, image string) {
+	store.MutateDeployment(name, func(deploy map[string]interface{}) error {
+		containers, _ := resources.NestedSlice(deploy, "spec", "template", "spec", "containers")
+		containers[0].(map[string]interface{})["image"] = image
+		return nil
+	})
+}
+
+// podsByHash counts stored pods per pod-template-hash label, all and ready ones.
+func podsByHash(store *storage.InMemoryStore) (ready, all map[string]int) {
+	ready, all = map[string]int{}, map[string]int{}
+	for _, item := range store.ListPods() {
+		pod := item.(map[string]interface{})
+		hash, _ := resources.NestedString(pod, "metadata", "labels", "pod-template-hash")
+		all[hash]++
+		if isPodReady(pod) {
+			ready[hash]++
+		}
+	}
+	return ready, all
+}
+
+func sum(counts map[string]int) int {
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	return total
+}
+
+func deploymentProgressing(store *storage.InMemoryStore, name string) map[string]interface{} {
+	deploy, err := store.GetDeployment(name)
+	if err != nil {
+		return nil
+	}
+	status, _ := deploy["status"].(map[string]interface{})
+	return deploymentCondition(status, DeploymentProgressing)
+}
+
+func TestDeploymentRollingUpdate(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	usePodController(t, store)
+	startDeploymentControllers(t, store)
+
+	strategy := map[string]interface{}{
+		"type":          "RollingUpdate",
+		"rollingUpdate": map[string]interface{}{"maxSurge": float64(1), "maxUnavailable": float64(0)},
+	}
+	if err := store.CreateDeployment(newTestDeployment("web", 4, "nginx:1.25", strategy)); err != nil {
+		t.Fatalf("Failed to create deployment: %v", err)
+	}
+	waitFor(t, 2*time.Second, "4 ready pods", func() bool {
+		ready, _ := podsByHash(store)
+		return sum(ready) == 4
+	})
+
+	_, initial := podsByHash(store)
+	setImage(store, "web", "nginx:1.26")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		ready, all := podsByHash(store)
+		var rsList []map[string]interface{}
+		for _, item := range store.ListReplicaSets() {
+			rsList = append(rsList, item.(map[string]interface{}))
+		}
+		if n := replicasTotal(rsList); n > 5 {
+			t.Fatalf("Expected at most replicas+maxSurge=5 replicas, got %d", n)
+		}
+		if n := sum(ready); n < 4 {
+			t.Fatalf("Expected at least replicas-maxUnavailable=4 ready pods, got %d (%v)", n, ready)
+		}
+		if _, old := ready[onlyKey(initial)]; len(all) == 1 && !old && sum(ready) == 4 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for the rollout, pods %v", all)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	for _, item := range store.ListReplicaSets() {
+		rs := item.(map[string]interface{})
+		image, _ := resources.NestedSlice(rs, "spec", "template", "spec", "containers")
+		want := int32(0)
+		if image[0].(map[string]interface{})["image"] == "nginx:1.26" {
+			want = 4
+		}
+		if got := rsReplicas(rs); got != want {
+			t.Errorf("Expected ReplicaSet with %v to have %d replicas, got %d", image[0], want, got)
+		}
+	}
+	waitFor(t, time.Second, "Progressing NewReplicaSetAvailable", func() bool {
+		cond := deploymentProgressing(store, "web")
+		return cond != nil && cond["reason"] == NewRSAvailableReason
+	})
+}
+
+// onlyKey returns the key of a single-entry count map.
+func onlyKey(counts map[string]int) string {
+	for hash := range counts {
+		return hash
+	}
+	return ""
+}
+
+func TestDeploymentRecreate(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	usePodController(t, store)
+	startDeploymentControllers(t, store)
+
+	if err := store.CreateDeployment(newTestDeployment("db", 2, "postgres:15", map[string]interface{}{"type": "Recreate"})); err != nil {
+		t.Fatalf("Failed to create deployment: %v", err)
+	}
+	waitFor(t, 2*time.Second, "2 ready pods", func() bool {
+		ready, _ := podsByHash(store)
+		return sum(ready) == 2
+	})
+
+	setImage(store, "db", "postgres:16")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		ready, all := podsByHash(store)
+		if len(all) > 1 {
+			t.Fatalf("Expected old pods to be gone before new ones are created, got %v", all)
+		}
+		if sum(ready) == 2 && len(store.ListReplicaSets()) == 2 {
+			deploy, _ := store.GetDeployment("db")
+			if updated, _ := resources.NestedInt64(deploy, "status", "updatedReplicas"); updated == 2 {
+				break
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for the recreate rollout, pods %v", all)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestDeploymentProgressDeadlineExceeded(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	// No pod controller: pods never become ready
+	startDeploymentControllers(t, store)
+
+	deploy := newTestDeployment("stuck", 1, "app:v1", nil)
+	deploy.Spec.(map[string]interface{})["progressDeadlineSeconds"] = float64(1)
+	if err := store.CreateDeployment(deploy); err != nil {
+		t.Fatalf("Failed to create deployment: %v", err)
+	}
+
+	waitFor(t, 5*time.Second, "Progressing=False", func() bool {
+		cond := deploymentProgressing(store, "stuck")
+		return cond != nil && cond["status"] == "False"
+	})
+	cond := deploymentProgressing(store, "stuck")
+	if cond["reason"] != TimedOutReason {
+		t.Errorf("Expected reason %s, got %v", TimedOutReason, cond["reason"])
+	}
+}
+
+func TestResolveFenceposts(t *testing.T) {
+	cases := []struct {
+		surge, unavailable  interface{}
+		replicas            int32
+		wantSurge, wantUnav int32
+	}{
+		{"25%", "25%", 10, 3, 2},
+		{float64(1), float64(0), 4, 1, 0},
+		{"0%", "0%", 3, 0, 1},
+		{"50%", float64(2), 3, 2, 2},
+	}
+	for _, c := range cases {
+		spec := map[string]interface{}{"strategy": map[string]interface{}{
+			"rollingUpdate": map[string]interface{}{"maxSurge": c.surge, "maxUnavailable": c.unavailable},
+		}}
+		surge, unavailable := resolveFenceposts(spec, c.replicas)
+		if surge != c.wantSurge || unavailable != c.wantUnav {
+			t.Errorf("resolveFenceposts(%v, %v, %d) = %d, %d, want %d, %d", c.surge, c.unavailable, c.replicas, surge, unavailable, c.wantSurge, c.wantUnav)
+		}
+	}
+}
+
+func TestPodAvailableAfterMinReadySeconds(t *testing.T) {
+	readyAt := time.Now().Add(-3 * time.Second)
+	pod := map[string]interface{}{
+		"status": map[string]interface{}{"conditions": []interface{}{
+			map[string]interface{}{"type": "Ready", "status": "True", "lastTransitionTime": readyAt.Format(time.RFC3339)},
+		}},
+	}
+	if ok, _ := podAvailableIn(pod, 0, time.Now()); !ok {
+		t.Errorf("Expected a ready pod to be available without minReadySeconds")
+	}
+	if ok, _ := podAvailableIn(pod, 2, time.Now()); !ok {
+		t.Errorf("Expected a pod ready for 3s to be available with minReadySeconds=2")
+	}
+	ok, wait := podAvailableIn(pod, 10, time.Now())
+	if ok || wait <= 0 || wait > 8*time.Second {
+		t.Errorf("Expected a pod ready for 3s to become available in ~7s, got %v/%v", ok, wait)
+	}
+}
+
+// replicaSetsByRevision returns the Deployment's ReplicaSets keyed by revision annotation.
+func replicaSetsByRevision(store *storage.InMemoryStore) map[int64]map[string]interface{} {
+	byRevision := map[int64]map[string]interface{}{}
+	for _, item := range store.ListReplicaSets() {
+		rs := item.(map[string]interface{})
+		byRevision[revisionOf(rs)] = rs
+	}
+	return byRevision
+}
+
+func TestDeploymentRolloutHistoryAndUndo(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	usePodController(t, store)
+	startDeploymentControllers(t, store)
+
+	deploy := newTestDeployment("api", 2, "api:v1", nil)
+	deploy.Metadata.Annotations = map[string]string{"kubernetes.io/change-cause": "initial"}
+	if err := store.CreateDeployment(deploy); err != nil {
+		t.Fatalf("Failed to create deployment: %v", err)
+	}
+	waitFor(t, 2*time.Second, "revision 1", func() bool {
+		d, _ := store.GetDeployment("api")
+		return revisionOf(d) == 1
+	})
+
+	setImage(store, "api", "api:v2")
+	waitFor(t, 3*time.Second, "revision 2 rolled out", func() bool {
+		rs := replicaSetsByRevision(store)
+		return len(rs) == 2 && rsReplicas(rs[1]) == 0 && rsReplicas(rs[2]) == 2
+	})
+	first := replicaSetsByRevision(store)[1]
+	if cause, _ := resources.NestedString(first, "metadata", "annotations", "kubernetes.io/change-cause"); cause != "initial" {
+		t.Errorf("Expected change-cause copied to the ReplicaSet, got %q", cause)
+	}
+
+	// kubectl rollout undo: patch in revision 1's template (typed, so with a null creationTimestamp)
+	template, _ := resources.NestedMap(first, "spec", "template")
+	metadata := template["metadata"].(map[string]interface{})
+	delete(metadata["labels"].(map[string]interface{}), "pod-template-hash")
+	metadata["creationTimestamp"] = nil
+	store.MutateDeployment("api", func(d map[string]interface{}) error {
+		d["spec"].(map[string]interface{})["template"] = template
+		return nil
+	})
+
+	waitFor(t, 3*time.Second, "rollback to revision 3", func() bool {
+		rs := replicaSetsByRevision(store)
+		return len(rs) == 2 && rs[3] != nil && rsReplicas(rs[3]) == 2 && rsReplicas(rs[2]) == 0
+	})
+	rolledBack := replicaSetsByRevision(store)[3]
+	if objectUID(rolledBack) != objectUID(first) {
+		t.Errorf("Expected the revision 1 ReplicaSet to be reused")
+	}
+	if history, _ := resources.NestedString(rolledBack, "metadata", "annotations", RevisionHistoryAnnotation); history != "1" {
+		t.Errorf("Expected revision-history 1, got %q", history)
+	}
+	d, _ := store.GetDeployment("api")
+	if revisionOf(d) != 3 {
+		t.Errorf("Expected Deployment revision 3, got %d", revisionOf(d))
+	}
+}
+
+func TestDeploymentRevisionHistoryLimit(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	usePodController(t, store)
+	startDeploymentControllers(t, store)
+
+	deploy := newTestDeployment("limited", 1, "app:v1", nil)
+	deploy.Spec.(map[string]interface{})["revisionHistoryLimit"] = float64(1)
+	if err := store.CreateDeployment(deploy); err != nil {
+		t.Fatalf("Failed to create deployment: %v", err)
+	}
+	for i, image := range []string{"app:v1", "app:v2", "app:v3", "app:v4"} {
+		if i > 0 {
+			setImage(store, "limited", image)
+		}
+		waitFor(t, 3*time.Second, image+" rolled out", func() bool {
+			pods := store.ListPods()
+			if len(pods) != 1 || !isPodReady(pods[0].(map[string]interface{})) {
+				return false
+			}
+			containers, _ := resources.NestedSlice(pods[0].(map[string]interface{}), "spec", "containers")
+			return containers[0].(map[string]interface{})["image"] == image
+		})
+	}
+
+	waitFor(t, time.Second, "old ReplicaSets cleaned up", func() bool {
+		return len(store.ListReplicaSets()) == 2
+	})
+	rs := replicaSetsByRevision(store)
+	if rs[3] == nil || rs[4] == nil {
+		t.Errorf("Expected revisions 3 and 4 to be kept, got %v", rs)
+	}
+}
+
+func TestDeploymentPauseAndResume(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	usePodController(t, store)
+	startDeploymentControllers(t, store)
+
+	if err := store.CreateDeployment(newTestDeployment("paused", 2, "app:v1", nil)); err != nil {
+		t.Fatalf("Failed to create deployment: %v", err)
+	}
+	waitFor(t, 2*time.Second, "2 ready pods", func() bool {
+		ready, _ := podsByHash(store)
+		return sum(ready) == 2
+	})
+
+	store.MutateDeployment("paused", func(d map[string]interface{}) error {
+		d["spec"].(map[string]interface{})["paused"] = true
+		return nil
+	})
+	waitFor(t, time.Second, "Progressing DeploymentPaused", func() bool {
+		cond := deploymentProgressing(store, "paused")
+		return cond != nil && cond["reason"] == PausedDeployReason && cond["status"] == "Unknown"
+	})
+
+	// Template changes wait, scaling still applies
+	setImage(store, "paused", "app:v2")
+	store.MutateDeployment("paused", func(d map[string]interface{}) error {
+		d["spec"].(map[string]interface{})["replicas"] = 3
+		return nil
+	})
+	waitFor(t, 2*time.Second, "scale to 3 while paused", func() bool {
+		_, all := podsByHash(store)
+		return sum(all) == 3
+	})
+	if n := len(store.ListReplicaSets()); n != 1 {
+		t.Fatalf("Expected no new ReplicaSet while paused, got %d ReplicaSets", n)
+	}
+
+	store.MutateDeployment("paused", func(d map[string]interface{}) error {
+		d["spec"].(map[string]interface{})["paused"] = false
+		return nil
+	})
+	waitFor(t, 3*time.Second, "rollout after resume", func() bool {
+		rs := replicaSetsByRevision(store)
+		return len(rs) == 2 && rsReplicas(rs[2]) == 3 && rsReplicas(rs[1]) == 0
+	})
+}
+
+func TestDeploymentStatusConditions(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	usePodController(t, store)
+	startDeploymentControllers(t, store)
+
+	if err := store.CreateDeployment(newTestDeployment("status", 2, "app:v1", map[string]interface{}{"type": "Recreate"})); err != nil {
+		t.Fatalf("Failed to create deployment: %v", err)
+	}
+	status := func() map[string]interface{} {
+		deploy, _ := store.GetDeployment("status")
+		status, _ := deploy["status"].(map[string]interface{})
+		return status
+	}
+	count := func(field string) int64 {
+		n, _ := resources.NestedInt64(status(), field)
+		return n
+	}
+
+	// Recreate tolerates no unavailable pods, so Available waits for both
+	available := deploymentCondition(status(), DeploymentAvailable)
+	if available != nil && available["status"] != "False" {
+		t.Errorf("Expected Available=False before pods are ready, got %v", available["status"])
+	}
+	waitFor(t, 2*time.Second, "Available=True", func() bool {
+		cond := deploymentCondition(status(), DeploymentAvailable)
+		return cond != nil && cond["status"] == "True" && cond["reason"] == MinimumReplicasAvailable
+	})
+	if count("readyReplicas") != 2 || count("unavailableReplicas") != 0 || count("observedGeneration") != 1 {
+		t.Errorf("Unexpected status %v", status())
+	}
+
+	// During the rollout nothing is available and the status catches up with the new generation
+	setImage(store, "status", "app:v2")
+	waitFor(t, 2*time.Second, "Available=False", func() bool {
+		cond := deploymentCondition(status(), DeploymentAvailable)
+		return cond["status"] == "False" && cond["reason"] == MinimumReplicasUnavailable
+	})
+	waitFor(t, 2*time.Second, "rollout of generation 2", func() bool {
+		cond := deploymentCondition(status(), DeploymentAvailable)
+		return count("observedGeneration") == 2 && count("updatedReplicas") == 2 &&
+			count("availableReplicas") == 2 && cond["status"] == "True"
+	})
+}
@@ -0,0 +1,234 @@
+package controllers
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"mockernetes/internal/resources"
+)
+
+// Rollout history of a Deployment (pkg/controller/deployment/sync.go upstream). Every pod
+// template the Deployment had is kept as a ReplicaSet stamped with a revision number; going
+// back to an older template (kubectl rollout undo patches it in) reuses that ReplicaSet under
+// a new revision. Scaled-down ReplicaSets beyond revisionHistoryLimit are deleted.
+
+// Annotations the Deployment controller maintains (k8s.io/kubectl/pkg/util/deployment).
+const (
+	RevisionAnnotation        = "deployment.kubernetes.io/revision"
+	RevisionHistoryAnnotation = "deployment.kubernetes.io/revision-history"
+	DesiredReplicasAnnotation = "deployment.kubernetes.io/desired-replicas"
+	MaxReplicasAnnotation     = "deployment.kubernetes.io/max-replicas"
+)
+
+// annotationsToSkip are Deployment annotations not copied to its ReplicaSets.
+var annotationsToSkip = map[string]bool{
+	"kubectl.kubernetes.io/last-applied-configuration": true,
+	RevisionAnnotation:                  true,
+	RevisionHistoryAnnotation:           true,
+	DesiredReplicasAnnotation:           true,
+	MaxReplicasAnnotation:               true,
+	"deprecated.deployment.rollback.to": true,
+}
+
+// normalizeTemplate returns a copy of a pod template for comparison and hashing: without the
+// pod-template-hash label and the null creationTimestamp typed clients send (kubectl rollout undo).
+func normalizeTemplate(template map[string]interface{}) map[string]interface{} {
+	var normalized map[string]interface{}
+	deepCopyJSON(template, &normalized)
+	if metadata, ok := normalized["metadata"].(map[string]interface{}); ok {
+		if labels, ok := metadata["labels"].(map[string]interface{}); ok {
+			delete(labels, "pod-template-hash")
+		}
+		if ts, ok := metadata["creationTimestamp"]; ok && ts == nil {
+			delete(metadata, "creationTimestamp")
+		}
+	}
+	return normalized
+}
+
+// equalIgnoreHash reports whether two pod templates are the same apart from pod-template-hash.
+func equalIgnoreHash(a, b map[string]interface{}) bool {
+	return reflect.DeepEqual(normalizeTemplate(a), normalizeTemplate(b))
+}
+
+// revisionOf returns the revision annotation of obj (0 if unset or invalid).
+func revisionOf(obj map[string]interface{}) int64 {
+	value, _ := resources.NestedString(obj, "metadata", "annotations", RevisionAnnotation)
+	revision, _ := strconv.ParseInt(value, 10, 64)
+	return revision
+}
+
+// maxRevision returns the highest revision among rsList.
+func maxRevision(rsList []map[string]interface{}) int64 {
+	var revision int64
+	for _, rs := range rsList {
+		revision = max(revision, revisionOf(rs))
+	}
+	return revision
+}
+
+// objectAnnotations returns metadata.annotations of obj, creating it if missing.
+func objectAnnotations(obj map[string]interface{}) map[string]interface{} {
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+		obj["metadata"] = metadata
+	}
+	annotations, _ := metadata["annotations"].(map[string]interface{})
+	if annotations == nil {
+		annotations = map[string]interface{}{}
+		metadata["annotations"] = annotations
+	}
+	return annotations
+}
+
+// setNewReplicaSetAnnotations copies the Deployment's annotations (e.g. kubernetes.io/change-cause)
+// to its new ReplicaSet and raises its revision to newRevision. A ReplicaSet that already had a
+// revision is being rolled back to, so the previous number is kept in the revision history.
+func setNewReplicaSetAnnotations(deploy, rs map[string]interface{}, newRevision int64) {
+	annotations := objectAnnotations(rs)
+	if deployAnnotations, ok := resources.NestedMap(deploy, "metadata", "annotations"); ok {
+		for k, v := range deployAnnotations {
+			if !annotationsToSkip[k] {
+				annotations[k] = v
+			}
+		}
+	}
+	oldRevision, hadRevision := annotations[RevisionAnnotation].(string)
+	if revisionOf(rs) >= newRevision {
+		return
+	}
+	annotations[RevisionAnnotation] = strconv.FormatInt(newRevision, 10)
+	if hadRevision {
+		if history, _ := annotations[RevisionHistoryAnnotation].(string); history != "" {
+			annotations[RevisionHistoryAnnotation] = history + "," + oldRevision
+		} else {
+			annotations[RevisionHistoryAnnotation] = oldRevision
+		}
+	}
+}
+
+// syncRevision stamps the new ReplicaSet with the next revision and mirrors it on the Deployment
+func (dc *DeploymentController) syncRevision(r *rollout) error {
+	if r.newRS == nil {
+		return nil
+	}
+	newRevision := maxRevision(r.oldRSs) + 1
+	rsName, _ := resources.NestedString(r.newRS, "metadata", "name")
+	rs, err := dc.store.MutateReplicaSet(rsName, func(rs map[string]interface{}) error {
+		setNewReplicaSetAnnotations(r.deploy, rs, newRevision)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	r.newRS = rs
+
+	revision := strconv.FormatInt(revisionOf(rs), 10)
+	_, err = dc.store.MutateDeployment(r.name, func(deploy map[string]interface{}) error {
+		objectAnnotations(deploy)[RevisionAnnotation] = revision
+		return nil
+	})
+	return err
+}
+
+// cleanupOldReplicaSets deletes the oldest scaled-down ReplicaSets beyond revisionHistoryLimit
+func (dc *DeploymentController) cleanupOldReplicaSets(r *rollout, spec map[string]interface{}) error {
+	limit, ok := resources.NestedInt64(spec, "revisionHistoryLimit")
+	if !ok {
+		return nil
+	}
+	var cleanable []map[string]interface{}
+	for _, rs := range r.oldRSs {
+		if !isBeingDeleted(rs) {
+			cleanable = append(cleanable, rs)
+		}
+	}
+	diff := len(cleanable) - int(limit)
+	if diff <= 0 {
+		return nil
+	}
+	sort.SliceStable(cleanable, func(i, j int) bool { return revisionOf(cleanable[i]) < revisionOf(cleanable[j]) })
+
+	var errs []string
+	for _, rs := range cleanable[:diff] {
+		// Only ReplicaSets that are fully scaled down are history
+		if rsReplicas(rs) != 0 || len(r.pods[objectUID(rs)]) > 0 {
+			continue
+		}
+		rsName, _ := resources.NestedString(rs, "metadata", "name")
+		fmt.Printf("[Deployment Controller] Deleting old ReplicaSet %s of Deployment %s (revisionHistoryLimit %d)\n", rsName, r.name, limit)
+		if err := dc.store.DeleteReplicaSet(rsName); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to clean up old ReplicaSets: %s", strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// scalePaused only resizes a paused Deployment, without making rollout progress: the active
+// ReplicaSet follows spec.replicas, or with several active ones (paused mid-rollout) the
+// difference to replicas+maxSurge is spread over them in proportion to their size.
+func (dc *DeploymentController) scalePaused(r *rollout) error {
+	var active []map[string]interface{}
+	for _, rs := range r.allRSs() {
+		if rsReplicas(rs) > 0 {
+			active = append(active, rs)
+		}
+	}
+
+	switch len(active) {
+	case 0:
+		// Nothing running: scale the newest ReplicaSet
+		target := r.newRS
+		if target == nil && len(r.oldRSs) > 0 {
+			target = r.oldRSs[len(r.oldRSs)-1]
+		}
+		if target == nil || r.replicas == 0 {
+			return nil
+		}
+		return dc.scaleReplicaSet(r, target, r.replicas)
+	case 1:
+		if rsReplicas(active[0]) == r.replicas {
+			return nil
+		}
+		return dc.scaleReplicaSet(r, active[0], r.replicas)
+	}
+
+	// Only a changed spec.replicas (ReplicaSets record the one they were sized for) resizes them
+	scaled := false
+	for _, rs := range active {
+		desired, _ := resources.NestedString(rs, "metadata", "annotations", DesiredReplicasAnnotation)
+		if n, err := strconv.ParseInt(desired, 10, 32); err == nil && int32(n) != r.replicas {
+			scaled = true
+		}
+	}
+	total := replicasTotal(active)
+	toAdd := r.replicas + r.maxSurge - total
+	if !scaled || toAdd == 0 {
+		return nil
+	}
+	// Newest first, so rounding leftovers land on the newest ReplicaSet
+	var added int32
+	sizes := make([]int32, len(active))
+	for i := len(active) - 1; i >= 0; i-- {
+		share := rsReplicas(active[i]) * toAdd / total
+		sizes[i] = max(rsReplicas(active[i])+share, 0)
+		added += sizes[i] - rsReplicas(active[i])
+	}
+	newest := len(active) - 1
+	sizes[newest] = max(sizes[newest]+toAdd-added, 0)
+	for i, rs := range active {
+		if sizes[i] != rsReplicas(rs) {
+			if err := dc.scaleReplicaSet(r, rs, sizes[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,388 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"reflect"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+// Labels of the EndpointSlices the controller manages
+const (
+	LabelServiceName             = "kubernetes.io/service-name"
+	LabelManagedBy               = "endpointslice.kubernetes.io/managed-by"
+	EndpointSliceControllerName  = "endpointslice-controller.k8s.io"
+	LabelTopologyZone            = "topology.kubernetes.io/zone"
+	endpointSliceAddressTypeIPv4 = "IPv4"
+)
+
+// EndpointSliceController keeps the EndpointSlices of Services with a selector in sync with
+// the pods they select (pkg/controller/endpointslice upstream). Endpoints follow the pods'
+// IPs and Ready conditions, so a pod turning unready (through the pod controller or a
+// simulated transition) flips its endpoint's readiness. There is one slice per address type
+// and port mapping, however many endpoints it has.
+type EndpointSliceController struct {
+	store   *storage.InMemoryStore
+	queue   *RateLimitingQueue
+	workers int
+}
+
+// NewEndpointSliceController creates a new EndpointSliceController fed by the given informers
+func NewEndpointSliceController(store *storage.InMemoryStore, informers *SharedInformerFactory) *EndpointSliceController {
+	esc := &EndpointSliceController{
+		store:   store,
+		queue:   NewRateLimitingQueue(),
+		workers: DefaultWorkers,
+	}
+	informers.AddEventHandler("Service", ResourceEventHandlerFuncs{
+		AddFunc:    esc.enqueue,
+		UpdateFunc: func(_, svc map[string]interface{}) { esc.enqueue(svc) },
+		DeleteFunc: esc.enqueue,
+	})
+	informers.AddEventHandler("Pod", ResourceEventHandlerFuncs{
+		AddFunc: esc.enqueueForPod,
+		UpdateFunc: func(old, pod map[string]interface{}) {
+			esc.enqueueForPod(old)
+			esc.enqueueForPod(pod)
+		},
+		DeleteFunc: esc.enqueueForPod,
+	})
+	// Managed slices changed or deleted by someone else are put back
+	informers.AddEventHandler("EndpointSlice", ResourceEventHandlerFuncs{
+		UpdateFunc: func(_, slice map[string]interface{}) { esc.enqueueForSlice(slice) },
+		DeleteFunc: esc.enqueueForSlice,
+	})
+	return esc
+}
+
+// Start starts the controller's workers
+func (esc *EndpointSliceController) Start() {
+	runWorkers(esc.queue, esc.workers, "EndpointSlice Controller", esc.syncService)
+}
+
+// Stop stops the controller
+func (esc *EndpointSliceController) Stop() {
+	esc.queue.ShutDownAndWait()
+}
+
+// enqueue queues a Service for sync
+func (esc *EndpointSliceController) enqueue(svc map[string]interface{}) {
+	esc.queue.Add(objectKey(svc))
+}
+
+// enqueueForPod queues every Service in the pod's namespace whose selector matches it
+func (esc *EndpointSliceController) enqueueForPod(pod map[string]interface{}) {
+	for _, item := range esc.store.ListServices() {
+		svc, ok := item.(map[string]interface{})
+		if !ok || namespaceOf(svc) != namespaceOf(pod) {
+			continue
+		}
+		if selector := serviceSelector(svc); selector != nil && selector.Matches(podLabels(pod)) {
+			esc.enqueue(svc)
+		}
+	}
+}
+
+// enqueueForSlice queues the Service of a managed EndpointSlice
+func (esc *EndpointSliceController) enqueueForSlice(slice map[string]interface{}) {
+	sliceLabels, _ := resources.NestedStringMap(slice, "metadata", "labels")
+	if sliceLabels[LabelManagedBy] == EndpointSliceControllerName && sliceLabels[LabelServiceName] != "" {
+		esc.queue.Add(namespaceOf(slice) + "/" + sliceLabels[LabelServiceName])
+	}
+}
+
+// serviceSelector returns the pod selector of a Service (nil when it has none: its
+// EndpointSlices are up to the user)
+func serviceSelector(svc map[string]interface{}) labels.Selector {
+	selector, _ := resources.NestedStringMap(svc, "spec", "selector")
+	if len(selector) == 0 {
+		return nil
+	}
+	if svcType, _ := resources.NestedString(svc, "spec", "type"); svcType == "ExternalName" {
+		return nil
+	}
+	return labels.SelectorFromSet(selector)
+}
+
+// syncService reconciles the EndpointSlices of the Service stored under key
+func (esc *EndpointSliceController) syncService(key string) error {
+	namespace, name := splitKey(key)
+	svc, err := esc.store.GetService(name)
+	if err != nil || namespaceOf(svc) != namespace {
+		// the garbage collector removes the slices of a deleted Service too
+		return esc.deleteSlices(esc.managedSlices(namespace, name))
+	}
+	if isBeingDeleted(svc) {
+		return nil
+	}
+	selector := serviceSelector(svc)
+	if selector == nil {
+		return esc.deleteSlices(esc.managedSlices(namespace, name))
+	}
+
+	desired := esc.desiredSlices(svc, selector)
+	existing := esc.managedSlices(namespace, name)
+	var stale []map[string]interface{}
+	for _, slice := range existing {
+		k := sliceKey(slice["addressType"], slice["ports"])
+		want, ok := desired[k]
+		if !ok {
+			stale = append(stale, slice)
+			continue
+		}
+		delete(desired, k)
+		var endpoints interface{}
+		deepCopyJSON(want.Endpoints, &endpoints)
+		if reflect.DeepEqual(slice["endpoints"], endpoints) {
+			continue
+		}
+		if _, err := esc.store.MutateEndpointSlice(objectName(slice), func(slice map[string]interface{}) error {
+			slice["endpoints"] = endpoints
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	keys := make([]string, 0, len(desired))
+	for k := range desired {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		slice := esc.newSlice(svc, desired[k])
+		if err := esc.store.CreateEndpointSlice(slice); err != nil {
+			return err
+		}
+		fmt.Printf("[EndpointSlice Controller] Created EndpointSlice %s for Service %s\n", slice.GetName(), key)
+	}
+	return esc.deleteSlices(stale)
+}
+
+// desiredSlices returns the addressType, ports and endpoints of the slices a Service should
+// have, by sliceKey. A Service without endpoints keeps one empty slice.
+func (esc *EndpointSliceController) desiredSlices(svc map[string]interface{}, selector labels.Selector) map[string]*resources.EndpointSlice {
+	families, _ := resources.NestedStringSlice(svc, "spec", "ipFamilies")
+	if len(families) == 0 {
+		families = []string{endpointSliceAddressTypeIPv4}
+	}
+	publishNotReady := false
+	if spec, ok := resources.NestedMap(svc, "spec"); ok {
+		publishNotReady, _ = spec["publishNotReadyAddresses"].(bool)
+	}
+
+	var pods []map[string]interface{}
+	for _, item := range esc.store.ListPods() {
+		pod, ok := item.(map[string]interface{})
+		if ok && namespaceOf(pod) == namespaceOf(svc) && !isPodFinished(pod) && selector.Matches(podLabels(pod)) {
+			pods = append(pods, pod)
+		}
+	}
+	sort.Slice(pods, func(i, j int) bool { return objectName(pods[i]) < objectName(pods[j]) })
+
+	desired := map[string]*resources.EndpointSlice{}
+	for _, pod := range pods {
+		ports := endpointPorts(svc, pod)
+		for _, family := range families {
+			ip := podIPOfFamily(pod, family)
+			if ip == "" {
+				continue
+			}
+			key := sliceKey(family, ports)
+			slice, ok := desired[key]
+			if !ok {
+				slice = &resources.EndpointSlice{AddressType: family, Endpoints: []interface{}{}, Ports: ports}
+				desired[key] = slice
+			}
+			slice.Endpoints = append(slice.Endpoints.([]interface{}), esc.endpoint(svc, pod, ip, publishNotReady))
+		}
+	}
+	if len(desired) == 0 {
+		placeholder := &resources.EndpointSlice{AddressType: families[0], Endpoints: []interface{}{}, Ports: []interface{}{}}
+		desired[sliceKey(placeholder.AddressType, placeholder.Ports)] = placeholder
+	}
+	return desired
+}
+
+// endpoint returns the endpoint of a pod address. An endpoint serves while its pod is Ready
+// (always with publishNotReadyAddresses) and is ready while it serves and isn't terminating.
+func (esc *EndpointSliceController) endpoint(svc, pod map[string]interface{}, ip string, publishNotReady bool) map[string]interface{} {
+	terminating := isBeingDeleted(pod)
+	serving := publishNotReady || isPodReady(pod)
+	endpoint := map[string]interface{}{
+		"addresses": []interface{}{ip},
+		"conditions": map[string]interface{}{
+			"ready":       serving && !terminating,
+			"serving":     serving,
+			"terminating": terminating,
+		},
+		"targetRef": map[string]interface{}{
+			"kind":      "Pod",
+			"namespace": namespaceOf(pod),
+			"name":      objectName(pod),
+			"uid":       objectUID(pod),
+		},
+	}
+	// pods of a StatefulSet are addressed by hostname through their headless Service
+	hostname, _ := resources.NestedString(pod, "spec", "hostname")
+	subdomain, _ := resources.NestedString(pod, "spec", "subdomain")
+	if hostname != "" && subdomain == objectName(svc) {
+		endpoint["hostname"] = hostname
+	}
+	if nodeName, _ := resources.NestedString(pod, "spec", "nodeName"); nodeName != "" {
+		endpoint["nodeName"] = nodeName
+		if node, err := esc.store.GetNode(nodeName); err == nil {
+			if zone := nodeLabels(node)[LabelTopologyZone]; zone != "" {
+				endpoint["zone"] = zone
+			}
+		}
+	}
+	return endpoint
+}
+
+// endpointPorts returns the ports of a Service as its endpoints on a pod serve them: a named
+// targetPort is looked up among the pod's container ports (ports the pod doesn't have are left
+// out)
+func endpointPorts(svc, pod map[string]interface{}) []interface{} {
+	servicePorts, _ := resources.NestedSlice(svc, "spec", "ports")
+	ports := []interface{}{}
+	for _, item := range servicePorts {
+		svcPort, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		target, ok := resources.ToInt64(svcPort["targetPort"])
+		if !ok {
+			name, _ := svcPort["targetPort"].(string)
+			if target, ok = containerPortNamed(pod, name); !ok {
+				continue
+			}
+		}
+		port := map[string]interface{}{"port": float64(target)}
+		for _, field := range []string{"name", "protocol", "appProtocol"} {
+			if value, ok := svcPort[field]; ok {
+				port[field] = value
+			}
+		}
+		ports = append(ports, port)
+	}
+	return ports
+}
+
+// containerPortNamed returns the containerPort of a pod's port with the given name
+func containerPortNamed(pod map[string]interface{}, name string) (int64, bool) {
+	containers, _ := resources.NestedSlice(pod, "spec", "containers")
+	for _, item := range containers {
+		container, _ := item.(map[string]interface{})
+		ports, _ := container["ports"].([]interface{})
+		for _, p := range ports {
+			if port, ok := p.(map[string]interface{}); ok && name != "" && port["name"] == name {
+				return resources.ToInt64(port["containerPort"])
+			}
+		}
+	}
+	return 0, false
+}
+
+// podIPOfFamily returns the pod IP of an IP family ("" if the pod has none yet)
+func podIPOfFamily(pod map[string]interface{}, family string) string {
+	var ips []string
+	entries, _ := resources.NestedSlice(pod, "status", "podIPs")
+	for _, entry := range entries {
+		if ip, ok := entry.(map[string]interface{})["ip"].(string); ok {
+			ips = append(ips, ip)
+		}
+	}
+	if len(ips) == 0 {
+		if ip, _ := resources.NestedString(pod, "status", "podIP"); ip != "" {
+			ips = []string{ip}
+		}
+	}
+	for _, ip := range ips {
+		if addr, err := netip.ParseAddr(ip); err == nil && ipFamily(addr) == family {
+			return ip
+		}
+	}
+	return ""
+}
+
+// sliceKey identifies the slice of an address type and port mapping
+func sliceKey(addressType, ports interface{}) string {
+	var normalized interface{}
+	deepCopyJSON(ports, &normalized)
+	if normalized == nil {
+		normalized = []interface{}{}
+	}
+	b, _ := json.Marshal(normalized)
+	return fmt.Sprintf("%v/%s", addressType, b)
+}
+
+// newSlice returns a new managed EndpointSlice of a Service
+func (esc *EndpointSliceController) newSlice(svc map[string]interface{}, desired *resources.EndpointSlice) *resources.EndpointSlice {
+	var endpoints interface{}
+	deepCopyJSON(desired.Endpoints, &endpoints)
+	return &resources.EndpointSlice{
+		Kind:       "EndpointSlice",
+		APIVersion: "discovery.k8s.io/v1",
+		Metadata: resources.ObjectMeta{
+			GenerateName: objectName(svc) + "-",
+			Namespace:    namespaceOf(svc),
+			Labels: map[string]string{
+				LabelServiceName: objectName(svc),
+				LabelManagedBy:   EndpointSliceControllerName,
+			},
+			OwnerReferences: []resources.OwnerReference{{
+				APIVersion:         ownerAPIVersion("Service"),
+				Kind:               "Service",
+				Name:               objectName(svc),
+				UID:                objectUID(svc),
+				Controller:         true,
+				BlockOwnerDeletion: true,
+			}},
+		},
+		AddressType: desired.AddressType,
+		Endpoints:   endpoints,
+		Ports:       desired.Ports,
+	}
+}
+
+// managedSlices returns the EndpointSlices the controller manages for a Service
+func (esc *EndpointSliceController) managedSlices(namespace, serviceName string) []map[string]interface{} {
+	var slices []map[string]interface{}
+	for _, item := range esc.store.ListEndpointSlices() {
+		slice, ok := item.(map[string]interface{})
+		if !ok || namespaceOf(slice) != namespace {
+			continue
+		}
+		sliceLabels, _ := resources.NestedStringMap(slice, "metadata", "labels")
+		if sliceLabels[LabelServiceName] == serviceName && sliceLabels[LabelManagedBy] == EndpointSliceControllerName {
+			slices = append(slices, slice)
+		}
+	}
+	return slices
+}
+
+// deleteSlices deletes EndpointSlices (ones already gone are fine)
+func (esc *EndpointSliceController) deleteSlices(slices []map[string]interface{}) error {
+	for _, slice := range slices {
+		if err := esc.store.DeleteEndpointSlice(objectName(slice)); err != nil {
+			if _, getErr := esc.store.GetEndpointSlice(objectName(slice)); getErr == nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// DefaultEndpointSliceController is the singleton instance
+var DefaultEndpointSliceController *EndpointSliceController
+
+// InitEndpointSliceController initializes the default EndpointSlice controller
+func InitEndpointSliceController(store *storage.InMemoryStore) {
+	DefaultEndpointSliceController = NewEndpointSliceController(store, sharedInformers(store))
+	DefaultEndpointSliceController.Start()
+}
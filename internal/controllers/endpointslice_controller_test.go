@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+// startEndpointSliceController runs the EndpointSlice controller on store.
+func startEndpointSliceController(t *testing.T, store *storage.InMemoryStore) {
+	esc := NewEndpointSliceController(store, startInformers(t, store))
+	esc.Start()
+	t.Cleanup(esc.Stop)
+}
+
+// serviceSlices returns the managed EndpointSlices of a Service by address type
+func serviceSlices(store *storage.InMemoryStore, service string) map[string]map[string]interface{} {
+	slices := map[string]map[string]interface{}{}
+	for _, slice := range (&EndpointSliceController{store: store}).managedSlices("default", service) {
+		addressType, _ := slice["addressType"].(string)
+		slices[addressType] = slice
+	}
+	return slices
+}
+
+// sliceEndpoints returns the endpoints of a slice by pod name
+func sliceEndpoints(slice map[string]interface{}) map[string]map[string]interface{} {
+	endpoints := map[string]map[string]interface{}{}
+	items, _ := slice["endpoints"].([]interface{})
+	for _, item := range items {
+		endpoint := item.(map[string]interface{})
+		pod, _ := resources.NestedString(endpoint, "targetRef", "name")
+		endpoints[pod] = endpoint
+	}
+	return endpoints
+}
+
+// endpointReady returns conditions.ready of an endpoint
+func endpointReady(endpoint map[string]interface{}) bool {
+	ready, _ := endpoint["conditions"].(map[string]interface{})["ready"].(bool)
+	return ready
+}
+
+func newWebPod(name string) *resources.Pod {
+	return newTestPod(name, map[string]string{"app": "web"}, map[string]interface{}{
+		"containers": []interface{}{map[string]interface{}{
+			"name": "app", "image": "app:v1",
+			"ports": []interface{}{map[string]interface{}{"name": "http", "containerPort": float64(8080)}},
+		}},
+	})
+}
+
+func TestEndpointSlicesFollowPodReadiness(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	pc := usePodController(t, store)
+	startEndpointSliceController(t, store)
+
+	svc := newTestService("web", map[string]interface{}{
+		"selector": map[string]interface{}{"app": "web"},
+		"ports":    []interface{}{map[string]interface{}{"name": "http", "port": float64(80), "targetPort": "http"}},
+	})
+	createService(t, store, newTestServiceAllocator(t), svc)
+	waitFor(t, 5*time.Second, "placeholder slice", func() bool {
+		slice := serviceSlices(store, "web")["IPv4"]
+		return slice != nil && len(sliceEndpoints(slice)) == 0
+	})
+
+	for _, name := range []string{"web-1", "web-2"} {
+		if err := createPod(store, newWebPod(name)); err != nil {
+			t.Fatalf("Failed to create pod: %v", err)
+		}
+	}
+	createPod(store, newTestPod("other", map[string]string{"app": "other"}, map[string]interface{}{}))
+	waitFor(t, 5*time.Second, "2 ready endpoints", func() bool {
+		endpoints := sliceEndpoints(serviceSlices(store, "web")["IPv4"])
+		return len(endpoints) == 2 && endpointReady(endpoints["web-1"]) && endpointReady(endpoints["web-2"])
+	})
+	slice := serviceSlices(store, "web")["IPv4"]
+	ports, _ := slice["ports"].([]interface{})
+	if len(ports) != 1 || ports[0].(map[string]interface{})["port"] != float64(8080) || ports[0].(map[string]interface{})["name"] != "http" {
+		t.Errorf("Expected the named targetPort resolved to 8080, got %v", ports)
+	}
+	pod, _ := store.GetPod("web-1")
+	podIP, _ := resources.NestedString(pod, "status", "podIP")
+	if addresses := sliceEndpoints(slice)["web-1"]["addresses"].([]interface{}); addresses[0] != podIP {
+		t.Errorf("Expected web-1's address %s, got %v", podIP, addresses)
+	}
+	if owner := controllerOf(slice, "Service"); owner == nil || owner["uid"] != objectUID(mustGetService(t, store, "web")) {
+		t.Errorf("Expected the slice to be owned by the service, got %v", slice["metadata"])
+	}
+
+	// a pod turning unready is still listed, not ready
+	store.MutatePod("web-1", func(pod map[string]interface{}) error {
+		markNotReady(pod["status"].(map[string]interface{}), "ReadinessProbeFailed", time.Now())
+		return nil
+	})
+	waitFor(t, 5*time.Second, "web-1 not ready", func() bool {
+		endpoints := sliceEndpoints(serviceSlices(store, "web")["IPv4"])
+		return len(endpoints) == 2 && !endpointReady(endpoints["web-1"]) && endpointReady(endpoints["web-2"])
+	})
+
+	// a deleted pod leaves the slice
+	if _, err := pc.DeletePod("web-2", 0); err != nil {
+		t.Fatalf("DeletePod failed: %v", err)
+	}
+	waitFor(t, 5*time.Second, "web-2 removed", func() bool {
+		endpoints := sliceEndpoints(serviceSlices(store, "web")["IPv4"])
+		return len(endpoints) == 1 && endpoints["web-1"] != nil
+	})
+
+	// and so do the slices of a deleted service
+	store.DeleteService("web")
+	waitFor(t, 5*time.Second, "slices deleted", func() bool {
+		return len(serviceSlices(store, "web")) == 0
+	})
+}
+
+func TestEndpointSlicesDualStackHeadless(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	pc := usePodController(t, store)
+	pc.IPAM, _ = NewIPAM("10.244.0.0/16,fd00:10:244::/56")
+	startEndpointSliceController(t, store)
+
+	allocator, _ := NewServiceAllocator("10.96.0.0/16,fd00:10:96::/112")
+	svc := newTestService("db", map[string]interface{}{
+		"clusterIP":      "None",
+		"ipFamilyPolicy": IPFamilyPolicyRequireDualStack,
+		"selector":       map[string]interface{}{"app": "web"},
+		"ports":          []interface{}{map[string]interface{}{"port": float64(5432)}},
+	})
+	createService(t, store, allocator, svc)
+
+	pod := newWebPod("db-0")
+	pod.Spec.(map[string]interface{})["hostname"] = "db-0"
+	pod.Spec.(map[string]interface{})["subdomain"] = "db"
+	if err := createPod(store, pod); err != nil {
+		t.Fatalf("Failed to create pod: %v", err)
+	}
+	waitFor(t, 5*time.Second, "IPv4 and IPv6 slices", func() bool {
+		slices := serviceSlices(store, "db")
+		return len(sliceEndpoints(slices["IPv4"])) == 1 && len(sliceEndpoints(slices["IPv6"])) == 1
+	})
+	for family, slice := range serviceSlices(store, "db") {
+		endpoint := sliceEndpoints(slice)["db-0"]
+		if endpoint["hostname"] != "db-0" {
+			t.Errorf("%s: expected hostname db-0, got %v", family, endpoint["hostname"])
+		}
+		if ip := podIPOfFamily(mustGetPod(t, store, "db-0"), family); endpoint["addresses"].([]interface{})[0] != ip {
+			t.Errorf("%s: expected address %s, got %v", family, ip, endpoint["addresses"])
+		}
+	}
+}
+
+// newTestServiceAllocator returns an allocator for the default service CIDR
+func newTestServiceAllocator(t *testing.T) *ServiceAllocator {
+	allocator, err := NewServiceAllocator(DefaultServiceCIDR)
+	if err != nil {
+		t.Fatalf("NewServiceAllocator failed: %v", err)
+	}
+	return allocator
+}
+
+func mustGetService(t *testing.T, store *storage.InMemoryStore, name string) map[string]interface{} {
+	svc, err := store.GetService(name)
+	if err != nil {
+		t.Fatalf("Failed to get service %s: %v", name, err)
+	}
+	return svc
+}
+
+func mustGetPod(t *testing.T, store *storage.InMemoryStore, name string) map[string]interface{} {
+	pod, err := store.GetPod(name)
+	if err != nil {
+		t.Fatalf("Failed to get pod %s: %v", name, err)
+	}
+	return pod
+}
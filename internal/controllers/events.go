@@ -0,0 +1,171 @@
+package controllers
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+// Events about objects (record.EventRecorder upstream). An event repeating the last one about
+// the same object (same reason, message, type and source) bumps its count and lastTimestamp
+// instead of adding another, like the upstream event correlator. Events expire an hour after
+// they were last seen, like the API server's --event-ttl.
+
+// Event types
+const (
+	EventTypeNormal  = "Normal"
+	EventTypeWarning = "Warning"
+)
+
+// DefaultEventTTL is how long an event is kept after it was last recorded
+const DefaultEventTTL = time.Hour
+
+// eventKey identifies the events that are counted together
+type eventKey struct {
+	uid, eventType, reason, message, component string
+}
+
+// recordedEvent is the stored event of a key
+type recordedEvent struct {
+	name     string
+	lastSeen time.Time
+}
+
+// eventRecorder records the events of a store. It indexes the events it stored so a repeat
+// is found without listing the store, and deletes the expired ones as it records.
+type eventRecorder struct {
+	mu        sync.Mutex
+	store     *storage.InMemoryStore
+	ttl       time.Duration
+	events    map[eventKey]*recordedEvent
+	lastPrune time.Time
+}
+
+// eventRecorderFor returns the event recorder of store
+func eventRecorderFor(store *storage.InMemoryStore) *eventRecorder {
+	return store.EventRecorder(func() interface{} {
+		return &eventRecorder{
+			store:     store,
+			ttl:       DefaultEventTTL,
+			events:    make(map[eventKey]*recordedEvent),
+			lastPrune: time.Now(),
+		}
+	}).(*eventRecorder)
+}
+
+// recordEvent reports an event about obj (a stored object) on behalf of component. Failures
+// are logged: events are informational and never fail a sync.
+func recordEvent(store *storage.InMemoryStore, obj map[string]interface{}, eventType, reason, message, component string) {
+	eventRecorderFor(store).record(obj, eventType, reason, message, component)
+}
+
+// record stores an event, or counts it on the stored one with the same key
+func (r *eventRecorder) record(obj map[string]interface{}, eventType, reason, message, component string) {
+	ref := objectReference(r.store, obj)
+	now := time.Now()
+	timestamp := now.UTC().Format(time.RFC3339)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prune(now)
+
+	key := eventKey{uid: ref.UID, eventType: eventType, reason: reason, message: message, component: component}
+	if ref.UID == "" {
+		key.uid = ref.Kind + "/" + ref.Namespace + "/" + ref.Name
+	}
+	if recorded, ok := r.events[key]; ok {
+		_, err := r.store.MutateEvent(recorded.name, func(event map[string]interface{}) error {
+			count, _ := resources.ToInt64(event["count"])
+			event["count"] = count + 1
+			event["lastTimestamp"] = timestamp
+			return nil
+		})
+		if err == nil {
+			recorded.lastSeen = now
+			return
+		}
+		// deleted since (by its namespace, or through the API): record it again
+		delete(r.events, key)
+	}
+
+	event := resources.Event{
+		Kind:       "Event",
+		APIVersion: "v1",
+		Metadata: resources.ObjectMeta{
+			// upstream names events "<object>.<unix nanoseconds in hex>"
+			Name: fmt.Sprintf("%s.%x", ref.Name, time.Now().UnixNano()),
+			// events about cluster-scoped objects (Nodes) go to the default namespace
+			Namespace: namespaceOf(obj),
+		},
+		InvolvedObject:     ref,
+		Reason:             reason,
+		Message:            message,
+		Source:             resources.EventSource{Component: component},
+		FirstTimestamp:     timestamp,
+		LastTimestamp:      timestamp,
+		Count:              1,
+		Type:               eventType,
+		ReportingComponent: component,
+	}
+	if err := r.store.CreateEvent(&event); err != nil {
+		fmt.Printf("[Events] Failed to record %s event for %s %s: %v\n", reason, ref.Kind, ref.Name, err)
+		return
+	}
+	r.events[key] = &recordedEvent{name: event.Metadata.Name, lastSeen: now}
+}
+
+// prune deletes the events last seen more than the TTL ago, at most every minute (every TTL
+// when shorter). Events created through the API expire too, by their lastTimestamp or
+// creationTimestamp.
+func (r *eventRecorder) prune(now time.Time) {
+	if now.Sub(r.lastPrune) < min(r.ttl, time.Minute) {
+		return
+	}
+	r.lastPrune = now
+	expired := make(map[string]bool)
+	for key, recorded := range r.events {
+		if now.Sub(recorded.lastSeen) > r.ttl {
+			expired[recorded.name] = true
+			delete(r.events, key)
+		}
+	}
+	for _, item := range r.store.ListEvents() {
+		event, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := resources.NestedString(event, "metadata", "name")
+		if !expired[name] && !eventExpired(event, now, r.ttl) {
+			continue
+		}
+		if err := r.store.DeleteEvent(name); err != nil {
+			fmt.Printf("[Events] Failed to delete expired event %s: %v\n", name, err)
+		}
+	}
+}
+
+// eventExpired reports whether a stored event was last seen more than ttl before now
+func eventExpired(event map[string]interface{}, now time.Time, ttl time.Duration) bool {
+	last, _ := event["lastTimestamp"].(string)
+	if last == "" {
+		last, _ = resources.NestedString(event, "metadata", "creationTimestamp")
+	}
+	seen, err := time.Parse(time.RFC3339, last)
+	return err == nil && now.Sub(seen) > ttl
+}
+
+// objectReference returns a reference to a stored object of store
+func objectReference(store *storage.InMemoryStore, obj map[string]interface{}) resources.ObjectReference {
+	kind, _ := obj["kind"].(string)
+	apiVersion, _ := obj["apiVersion"].(string)
+	name, _ := resources.NestedString(obj, "metadata", "name")
+	ref := resources.ObjectReference{Kind: kind, APIVersion: apiVersion, Name: name, UID: objectUID(obj)}
+	if slices.Contains(store.NamespacedKinds(), kind) {
+		ref.Namespace = namespaceOf(obj)
+	}
+	return ref
+}
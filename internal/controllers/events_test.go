@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+func TestRecordEventCountsAndExpires(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	if err := store.CreatePod(newTestPod("web", nil, map[string]interface{}{})); err != nil {
+		t.Fatalf("Failed to create pod: %v", err)
+	}
+	pod, _ := store.GetPod("web")
+
+	// a repeated event is counted on the stored one
+	recordEvent(store, pod, EventTypeWarning, "BackOff", "Back-off restarting failed container", "kubelet")
+	recordEvent(store, pod, EventTypeWarning, "BackOff", "Back-off restarting failed container", "kubelet")
+	recordEvent(store, pod, EventTypeNormal, "Pulled", "Container image already present", "kubelet")
+	events := store.ListEvents()
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+	for _, item := range events {
+		event := item.(map[string]interface{})
+		count, _ := resources.ToInt64(event["count"])
+		if event["reason"] == "BackOff" && count != 2 {
+			t.Errorf("Expected the BackOff event to be counted twice, got %d", count)
+		}
+	}
+
+	// an event deleted through the API is recorded again
+	for _, item := range events {
+		name, _ := resources.NestedString(item.(map[string]interface{}), "metadata", "name")
+		store.DeleteEvent(name)
+	}
+	recordEvent(store, pod, EventTypeWarning, "BackOff", "Back-off restarting failed container", "kubelet")
+	if events := store.ListEvents(); len(events) != 1 {
+		t.Fatalf("Expected the deleted event to be recorded again, got %d events", len(events))
+	}
+
+	// events not seen for the TTL are deleted
+	eventRecorderFor(store).ttl = 10 * time.Millisecond
+	time.Sleep(20 * time.Millisecond)
+	recordEvent(store, pod, EventTypeNormal, "Killing", "Stopping container app", "kubelet")
+	events = store.ListEvents()
+	if len(events) != 1 || events[0].(map[string]interface{})["reason"] != "Killing" {
+		t.Fatalf("Expected only the new event to remain, got %v", events)
+	}
+}
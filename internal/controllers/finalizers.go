@@ -0,0 +1,57 @@
+package controllers
+
+import "mockernetes/internal/resources"
+
+// Helpers for objects held in storage by finalizers (see storage/finalizers.go).
+
+// isBeingDeleted reports whether obj has been marked with metadata.deletionTimestamp.
+func isBeingDeleted(obj map[string]interface{}) bool {
+	ts, _ := resources.NestedString(obj, "metadata", "deletionTimestamp")
+	return ts != ""
+}
+
+// hasFinalizer reports whether metadata.finalizers contains finalizer.
+func hasFinalizer(obj map[string]interface{}, finalizer string) bool {
+	list, _ := resources.NestedSlice(obj, "metadata", "finalizers")
+	for _, f := range list {
+		if f == finalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// addFinalizer appends finalizer to metadata.finalizers unless already present.
+func addFinalizer(obj map[string]interface{}, finalizer string) {
+	if hasFinalizer(obj, finalizer) {
+		return
+	}
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+		obj["metadata"] = metadata
+	}
+	list, _ := resources.NestedSlice(obj, "metadata", "finalizers")
+	metadata["finalizers"] = append(list, finalizer)
+}
+
+// removeFinalizer drops finalizer from the string list at obj[fields...] and reports
+// whether it was present.
+func removeFinalizer(obj map[string]interface{}, finalizer string, fields ...string) bool {
+	list, ok := resources.NestedSlice(obj, fields...)
+	if !ok {
+		return false
+	}
+	kept := make([]interface{}, 0, len(list))
+	for _, f := range list {
+		if f != finalizer {
+			kept = append(kept, f)
+		}
+	}
+	if len(kept) == len(list) {
+		return false
+	}
+	parent, _ := resources.NestedMap(obj, fields[:len(fields)-1]...)
+	parent[fields[len(fields)-1]] = kept
+	return true
+}
@@ -0,0 +1,306 @@
+package controllers
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"mockernetes/internal/storage"
+)
+
+// GarbageCollector deletes dependents whose owners (metadata.ownerReferences, matched by uid)
+// are gone and implements the DELETE propagation policies:
+//   - Background: the owner is removed right away and its dependents are deleted afterwards;
+//   - Foreground: the owner keeps the foregroundDeletion finalizer until every dependent with
+//     blockOwnerDeletion=true has been removed;
+//   - Orphan: the owner keeps the orphan finalizer until its dependents' references to it are dropped.
+//
+// The API sets the finalizers on DELETE; this controller does the rest. Like upstream's
+// GraphBuilder, it keeps the owner graph up to date from informer events and queues (by uid)
+// only the objects an event concerns: the object itself, its owners and its dependents.
+type GarbageCollector struct {
+	store *storage.InMemoryStore
+	queue *RateLimitingQueue
+
+	mu         sync.RWMutex
+	nodes      map[string]*gcNode            // observed objects by uid
+	dependents map[string]map[string]*gcNode // owner uid -> dependents by uid
+}
+
+// gcNode is one object in the owner graph.
+type gcNode struct {
+	kind   string
+	name   string
+	uid    string
+	obj    map[string]interface{}
+	owners []string // uids of the ownerReferences
+}
+
+// NewGarbageCollector creates a new GarbageCollector fed by the given informers
+func NewGarbageCollector(store *storage.InMemoryStore, informers *SharedInformerFactory) *GarbageCollector {
+	gc := &GarbageCollector{
+		store:      store,
+		queue:      NewRateLimitingQueue(),
+		nodes:      make(map[string]*gcNode),
+		dependents: make(map[string]map[string]*gcNode),
+	}
+	for _, kind := range store.Kinds() {
+		informers.AddEventHandler(kind, ResourceEventHandlerFuncs{
+			AddFunc:    func(obj map[string]interface{}) { gc.observe(kind, nil, obj) },
+			UpdateFunc: func(old, obj map[string]interface{}) { gc.observe(kind, old, obj) },
+			DeleteFunc: gc.forget,
+		})
+	}
+	return gc
+}
+
+// Start starts the collector's worker
+func (gc *GarbageCollector) Start() {
+	runWorkers(gc.queue, 1, "GC", func(uid string) error {
+		gc.process(uid)
+		return nil
+	})
+}
+
+// Stop stops the collector
+func (gc *GarbageCollector) Stop() {
+	gc.queue.ShutDownAndWait()
+}
+
+// observe records an added or updated object in the graph and queues what it affects.
+// Deletion marks and ownerReferences changes are what can make work for the collector.
+func (gc *GarbageCollector) observe(kind string, old, obj map[string]interface{}) {
+	uid := objectUID(obj)
+	if uid == "" {
+		return
+	}
+	node := &gcNode{kind: kind, name: objectName(obj), uid: uid, obj: obj}
+	for _, ref := range ownerReferences(obj) {
+		if owner, _ := ref["uid"].(string); owner != "" {
+			node.owners = append(node.owners, owner)
+		}
+	}
+
+	gc.mu.Lock()
+	var oldOwners []string
+	if prev := gc.nodes[uid]; prev != nil {
+		oldOwners = prev.owners
+		gc.unlinkLocked(prev)
+	}
+	gc.nodes[uid] = node
+	for _, owner := range node.owners {
+		if gc.dependents[owner] == nil {
+			gc.dependents[owner] = make(map[string]*gcNode)
+		}
+		gc.dependents[owner][uid] = node
+	}
+	dependents := gc.dependentsLocked(uid)
+	gc.mu.Unlock()
+
+	if isBeingDeleted(obj) {
+		gc.queue.Add(uid)
+		// dependents react to an owner's foreground deletion
+		for _, dep := range dependents {
+			gc.queue.Add(dep.uid)
+		}
+	} else if len(node.owners) > 0 && old == nil {
+		gc.queue.Add(uid)
+	}
+	if old != nil && !reflect.DeepEqual(ownerReferences(old), ownerReferences(obj)) {
+		gc.queue.Add(uid)
+		// an owner waiting in foreground deletion may have lost a blocking dependent
+		for _, owner := range append(oldOwners, node.owners...) {
+			gc.queue.Add(owner)
+		}
+	}
+}
+
+// forget drops a deleted object from the graph and queues its owners and dependents
+func (gc *GarbageCollector) forget(obj map[string]interface{}) {
+	uid := objectUID(obj)
+	gc.mu.Lock()
+	node := gc.nodes[uid]
+	if node == nil {
+		gc.mu.Unlock()
+		return
+	}
+	gc.unlinkLocked(node)
+	delete(gc.nodes, uid)
+	dependents := gc.dependentsLocked(uid)
+	gc.mu.Unlock()
+
+	for _, owner := range node.owners {
+		gc.queue.Add(owner)
+	}
+	for _, dep := range dependents {
+		gc.queue.Add(dep.uid)
+	}
+}
+
+// unlinkLocked removes node from the dependents of its owners. The caller holds gc.mu.
+func (gc *GarbageCollector) unlinkLocked(node *gcNode) {
+	for _, owner := range node.owners {
+		delete(gc.dependents[owner], node.uid)
+		if len(gc.dependents[owner]) == 0 {
+			delete(gc.dependents, owner)
+		}
+	}
+}
+
+// dependentsLocked returns the observed dependents of the object with uid. The caller holds gc.mu.
+func (gc *GarbageCollector) dependentsLocked(uid string) []*gcNode {
+	dependents := make([]*gcNode, 0, len(gc.dependents[uid]))
+	for _, dep := range gc.dependents[uid] {
+		dependents = append(dependents, dep)
+	}
+	return dependents
+}
+
+// dependentsOf returns the observed dependents of the object with uid
+func (gc *GarbageCollector) dependentsOf(uid string) []*gcNode {
+	gc.mu.RLock()
+	defer gc.mu.RUnlock()
+	return gc.dependentsLocked(uid)
+}
+
+// ownerOf returns the owner an ownerReference points at, or nil when it is gone. An owner
+// missing from the graph is looked up in the store, as it may just not have been observed yet.
+func (gc *GarbageCollector) ownerOf(ref map[string]interface{}) map[string]interface{} {
+	uid, _ := ref["uid"].(string)
+	gc.mu.RLock()
+	node := gc.nodes[uid]
+	gc.mu.RUnlock()
+	if node != nil {
+		return node.obj
+	}
+	kind, _ := ref["kind"].(string)
+	name, _ := ref["name"].(string)
+	if obj, err := gc.store.GetObject(kind, name); err == nil && objectUID(obj) == uid {
+		return obj
+	}
+	return nil
+}
+
+// process handles one queued object: an owner being deleted with the orphan /
+// foregroundDeletion finalizers, or a dependent whose owners may be gone
+func (gc *GarbageCollector) process(uid string) {
+	gc.mu.RLock()
+	node := gc.nodes[uid]
+	gc.mu.RUnlock()
+	if node == nil {
+		return
+	}
+
+	if isBeingDeleted(node.obj) {
+		if hasFinalizer(node.obj, metav1.FinalizerOrphanDependents) {
+			gc.orphanDependents(node)
+		}
+		if hasFinalizer(node.obj, metav1.FinalizerDeleteDependents) && !gc.hasBlockingDependents(node) {
+			fmt.Printf("[GC] All blocking dependents of %s %s are gone, removing %s finalizer\n", node.kind, node.name, metav1.FinalizerDeleteDependents)
+			gc.removeObjectFinalizer(node, metav1.FinalizerDeleteDependents)
+		}
+		return
+	}
+
+	var solid int
+	stale := make(map[string]bool)
+	waiting := false
+	for _, ref := range ownerReferences(node.obj) {
+		uid, _ := ref["uid"].(string)
+		if uid == "" {
+			continue
+		}
+		owner := gc.ownerOf(ref)
+		switch {
+		case owner == nil:
+			stale[uid] = true
+		case isBeingDeleted(owner) && hasFinalizer(owner, metav1.FinalizerDeleteDependents):
+			stale[uid] = true
+			waiting = true
+		default:
+			solid++
+		}
+	}
+	if len(stale) == 0 {
+		return
+	}
+
+	if solid > 0 {
+		// Still owned by someone else: just drop the references to the departing owners
+		fmt.Printf("[GC] Removing dangling ownerReferences from %s %s\n", node.kind, node.name)
+		gc.store.MutateObject(node.kind, node.name, func(obj map[string]interface{}) error {
+			removeOwnerReferences(obj, stale)
+			return nil
+		})
+		return
+	}
+
+	policy := metav1.DeletePropagationBackground
+	if waiting && len(gc.dependentsOf(node.uid)) > 0 {
+		policy = metav1.DeletePropagationForeground
+	}
+	fmt.Printf("[GC] Deleting %s %s (owners gone, propagation %s)\n", node.kind, node.name, policy)
+	if err := gc.deleteObject(node.kind, node.name, policy); err != nil {
+		fmt.Printf("[GC] Error deleting %s %s: %v\n", node.kind, node.name, err)
+	}
+}
+
+// hasBlockingDependents reports whether any dependent still blocks its owner's foreground deletion
+func (gc *GarbageCollector) hasBlockingDependents(owner *gcNode) bool {
+	for _, dep := range gc.dependentsOf(owner.uid) {
+		for _, ref := range ownerReferences(dep.obj) {
+			if uid, _ := ref["uid"].(string); uid == owner.uid {
+				if block, _ := ref["blockOwnerDeletion"].(bool); block {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// orphanDependents removes the owner from its dependents' ownerReferences, then the orphan finalizer
+func (gc *GarbageCollector) orphanDependents(owner *gcNode) {
+	for _, dep := range gc.dependentsOf(owner.uid) {
+		fmt.Printf("[GC] Orphaning %s %s (owner %s %s)\n", dep.kind, dep.name, owner.kind, owner.name)
+		gc.store.MutateObject(dep.kind, dep.name, func(obj map[string]interface{}) error {
+			removeOwnerReferences(obj, map[string]bool{owner.uid: true})
+			return nil
+		})
+	}
+	gc.removeObjectFinalizer(owner, metav1.FinalizerOrphanDependents)
+}
+
+// removeObjectFinalizer drops a GC finalizer; storage removes the object if it was the last one
+func (gc *GarbageCollector) removeObjectFinalizer(node *gcNode, finalizer string) {
+	gc.store.MutateObject(node.kind, node.name, func(obj map[string]interface{}) error {
+		removeFinalizer(obj, finalizer, "metadata", "finalizers")
+		return nil
+	})
+}
+
+// deleteObject deletes a dependent like an API DELETE with the given propagation policy
+func (gc *GarbageCollector) deleteObject(kind, name string, policy metav1.DeletionPropagation) error {
+	if policy == metav1.DeletePropagationForeground {
+		if _, err := gc.store.MutateObject(kind, name, func(obj map[string]interface{}) error {
+			addFinalizer(obj, metav1.FinalizerDeleteDependents)
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	if kind == "Pod" {
+		return deletePodObject(gc.store, name)
+	}
+	return gc.store.DeleteObject(kind, name)
+}
+
+// DefaultGarbageCollector is the singleton instance
+var DefaultGarbageCollector *GarbageCollector
+
+// InitGarbageCollector initializes the default garbage collector
+func InitGarbageCollector(store *storage.InMemoryStore) {
+	DefaultGarbageCollector = NewGarbageCollector(store, sharedInformers(store))
+	DefaultGarbageCollector.Start()
+}
@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+// newOwnedChain stores Deployment "web" -> ReplicaSet "web-rs" -> Pod "web-pod", each
+// dependent pointing at its owner by uid with blockOwnerDeletion like the controllers set it.
+func newOwnedChain(t *testing.T, store *storage.InMemoryStore) {
+	t.Helper()
+	deploy := resources.Deployment{Kind: "Deployment", APIVersion: "apps/v1", Metadata: resources.ObjectMeta{Name: "web", Namespace: "default"}}
+	if err := store.CreateDeployment(deploy); err != nil {
+		t.Fatalf("Failed to create deployment: %v", err)
+	}
+	stored, _ := store.GetDeployment("web")
+	rs := resources.ReplicaSet{Kind: "ReplicaSet", APIVersion: "apps/v1", Metadata: resources.ObjectMeta{
+		Name:      "web-rs",
+		Namespace: "default",
+		OwnerReferences: []resources.OwnerReference{{
+			APIVersion: "apps/v1", Kind: "Deployment", Name: "web", UID: objectUID(stored), Controller: true, BlockOwnerDeletion: true,
+		}},
+	}}
+	if err := store.CreateReplicaSet(rs); err != nil {
+		t.Fatalf("Failed to create replicaset: %v", err)
+	}
+	storedRS, _ := store.GetReplicaSet("web-rs")
+	pod := resources.Pod{Kind: "Pod", APIVersion: "v1", Metadata: resources.ObjectMeta{
+		Name:      "web-pod",
+		Namespace: "default",
+		OwnerReferences: []resources.OwnerReference{{
+			APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-rs", UID: objectUID(storedRS), Controller: true, BlockOwnerDeletion: true,
+		}},
+	}}
+	if err := store.CreatePod(pod); err != nil {
+		t.Fatalf("Failed to create pod: %v", err)
+	}
+}
+
+// markForDeletion adds a GC finalizer (as the API does for the policy) and deletes the object.
+func markForDeletion(t *testing.T, store *storage.InMemoryStore, kind, name, finalizer string) {
+	t.Helper()
+	if finalizer != "" {
+		store.MutateObject(kind, name, func(obj map[string]interface{}) error {
+			addFinalizer(obj, finalizer)
+			return nil
+		})
+	}
+	if err := store.DeleteObject(kind, name); err != nil {
+		t.Fatalf("Failed to delete %s %s: %v", kind, name, err)
+	}
+}
+
+// startGarbageCollector runs a garbage collector on store until the test ends
+func startGarbageCollector(t *testing.T, store *storage.InMemoryStore) {
+	gc := NewGarbageCollector(store, startInformers(t, store))
+	gc.Start()
+	t.Cleanup(gc.Stop)
+}
+
+func TestGarbageCollectorBackground(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	startGarbageCollector(t, store)
+	newOwnedChain(t, store)
+
+	markForDeletion(t, store, "Deployment", "web", "")
+	waitFor(t, 5*time.Second, "ReplicaSet and Pod collected", func() bool {
+		_, rsErr := store.GetReplicaSet("web-rs")
+		_, podErr := store.GetPod("web-pod")
+		return rsErr != nil && podErr != nil
+	})
+}
+
+func TestGarbageCollectorOrphan(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	startGarbageCollector(t, store)
+	newOwnedChain(t, store)
+
+	markForDeletion(t, store, "Deployment", "web", metav1.FinalizerOrphanDependents)
+	waitFor(t, 5*time.Second, "Deployment removed once its dependents were orphaned", func() bool {
+		_, err := store.GetDeployment("web")
+		return err != nil
+	})
+	rs, err := store.GetReplicaSet("web-rs")
+	if err != nil {
+		t.Fatalf("Expected ReplicaSet to be orphaned, not deleted: %v", err)
+	}
+	if refs := ownerReferences(rs); len(refs) != 0 {
+		t.Errorf("Expected orphaned ReplicaSet to have no ownerReferences, got %v", refs)
+	}
+	if _, err := store.GetPod("web-pod"); err != nil {
+		t.Errorf("Expected Pod of the orphaned ReplicaSet to remain: %v", err)
+	}
+}
+
+func TestGarbageCollectorForeground(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	startGarbageCollector(t, store)
+	newOwnedChain(t, store)
+
+	// A finalizer on the pod keeps it (and so the blocked owners) around
+	store.MutatePod("web-pod", func(pod map[string]interface{}) error {
+		addFinalizer(pod, "example.com/hold")
+		return nil
+	})
+
+	markForDeletion(t, store, "Deployment", "web", metav1.FinalizerDeleteDependents)
+	waitFor(t, 5*time.Second, "the chain waiting for deletion", func() bool {
+		objs := store.ListObjects("Pod")
+		return len(objs) == 1 && isBeingDeleted(objs[0].(map[string]interface{}))
+	})
+	for _, kind := range []string{"Deployment", "ReplicaSet", "Pod"} {
+		objs := store.ListObjects(kind)
+		if len(objs) != 1 || !isBeingDeleted(objs[0].(map[string]interface{})) {
+			t.Fatalf("Expected %s to be waiting for deletion, got %v", kind, objs)
+		}
+	}
+
+	store.MutatePod("web-pod", func(pod map[string]interface{}) error {
+		removeFinalizer(pod, "example.com/hold", "metadata", "finalizers")
+		return nil
+	})
+	waitFor(t, 5*time.Second, "the chain removed after its dependents", func() bool {
+		for _, kind := range []string{"Deployment", "ReplicaSet", "Pod"} {
+			if len(store.ListObjects(kind)) != 0 {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func TestGarbageCollectorDependentOfMissingOwner(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	startGarbageCollector(t, store)
+	newOwnedChain(t, store)
+
+	// a dependent whose owner never existed is collected, the others are left alone
+	orphan := resources.Pod{Kind: "Pod", APIVersion: "v1", Metadata: resources.ObjectMeta{
+		Name:      "stray-pod",
+		Namespace: "default",
+		OwnerReferences: []resources.OwnerReference{{
+			APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "gone-rs", UID: "gone-uid", Controller: true,
+		}},
+	}}
+	if err := store.CreatePod(orphan); err != nil {
+		t.Fatalf("Failed to create pod: %v", err)
+	}
+	waitFor(t, 5*time.Second, "stray pod collected", func() bool {
+		_, err := store.GetPod("stray-pod")
+		return err != nil
+	})
+	if _, err := store.GetPod("web-pod"); err != nil {
+		t.Errorf("Expected the owned pod to remain: %v", err)
+	}
+}
@@ -0,0 +1,233 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// The fake image registry containers pull their images from. Without a config every image
+// exists and is present on every node. A config file (YAML or JSON) lists the images that exist, with
+// their digests and how long they take to pull; pulls of other images, and of images matching
+// a failure pattern, fail with ErrImagePull:
+//
+//	images:
+//	- image: nginx:1.25            # one tag; "nginx" alone matches every tag
+//	  digest: sha256:0d17...       # derived from the image when unset
+//	  pullDuration: 3s
+//	- image: registry.example.com/team/app
+//	  pullSecret: regcred          # pods must list it in spec.imagePullSecrets
+//	- image: busybox:1.36
+//	  preloaded: true              # present on every node without a pull
+//	failurePatterns: [":broken$"]  # regular expressions matched against the image
+//	allowUnknownImages: false      # true: images not listed exist too
+
+// ImageConfigEnv names the environment variable holding the path of the image registry config
+const ImageConfigEnv = "MOCKERNETES_IMAGE_CONFIG"
+
+// ImageConfig describes the images of the fake registry
+type ImageConfig struct {
+	Images             []KnownImage `json:"images,omitempty"`
+	FailurePatterns    []string     `json:"failurePatterns,omitempty"`
+	AllowUnknownImages bool         `json:"allowUnknownImages,omitempty"`
+}
+
+// KnownImage is an image (repository, or repository:tag) of the fake registry
+type KnownImage struct {
+	Image        string `json:"image"`
+	Digest       string `json:"digest,omitempty"`
+	PullDuration string `json:"pullDuration,omitempty"`
+	PullSecret   string `json:"pullSecret,omitempty"`
+	Preloaded    bool   `json:"preloaded,omitempty"`
+}
+
+// knownImage is a KnownImage with its reference split and duration parsed
+type knownImage struct {
+	repository   string
+	tag          string
+	digest       string
+	pullDuration time.Duration
+	pullSecret   string
+	preloaded    bool
+}
+
+// ImageRegistry resolves images and remembers which images each node has pulled
+type ImageRegistry struct {
+	images       []knownImage
+	failures     []*regexp.Regexp
+	allowUnknown bool
+	preloadAll   bool
+
+	mu sync.Mutex
+	// present holds the images pulled per node
+	present map[string]map[string]bool
+}
+
+// LoadImageConfig reads and checks an image registry config file
+func LoadImageConfig(path string) (*ImageConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var config ImageConfig
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return nil, fmt.Errorf("invalid image config %s: %w", path, err)
+	}
+	return &config, nil
+}
+
+// NewImageRegistry returns a registry for config; a nil config has every image
+func NewImageRegistry(config *ImageConfig) (*ImageRegistry, error) {
+	r := &ImageRegistry{present: map[string]map[string]bool{}}
+	if config == nil {
+		r.allowUnknown, r.preloadAll = true, true
+		return r, nil
+	}
+	r.allowUnknown = config.AllowUnknownImages
+	for i, image := range config.Images {
+		if image.Image == "" {
+			return nil, fmt.Errorf("images[%d]: image is required", i)
+		}
+		repository, tag, _ := splitImage(image.Image)
+		known := knownImage{repository: repository, tag: tag, digest: image.Digest,
+			pullSecret: image.PullSecret, preloaded: image.Preloaded}
+		if image.PullDuration != "" {
+			d, err := time.ParseDuration(image.PullDuration)
+			if err != nil {
+				return nil, fmt.Errorf("images[%d].pullDuration: %w", i, err)
+			}
+			known.pullDuration = d
+		}
+		if known.digest != "" && !strings.HasPrefix(known.digest, "sha256:") {
+			return nil, fmt.Errorf("images[%d].digest: %q is not a sha256 digest", i, image.Digest)
+		}
+		r.images = append(r.images, known)
+	}
+	for i, pattern := range config.FailurePatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("failurePatterns[%d]: %w", i, err)
+		}
+		r.failures = append(r.failures, re)
+	}
+	return r, nil
+}
+
+// resolve looks an image up for a pod with the given pull secrets. It returns the image ID
+// and how long a pull takes, or why the pull fails.
+func (r *ImageRegistry) resolve(image string, pullSecrets []string) (string, time.Duration, error) {
+	repository, tag, digest := splitImage(image)
+	if tag == "" {
+		tag = "latest"
+	}
+	ref := repository + ":" + tag
+	if digest != "" {
+		ref = repository + "@" + digest
+	}
+	for _, re := range r.failures {
+		if re.MatchString(image) {
+			return "", 0, fmt.Errorf("failed to pull and unpack image %q: failed to resolve reference %q: unexpected status from HEAD request: 500 Internal Server Error", ref, ref)
+		}
+	}
+	known := r.lookup(repository, tag)
+	if known == nil && !r.allowUnknown {
+		return "", 0, fmt.Errorf("failed to pull and unpack image %q: failed to resolve reference %q: %s: not found", ref, ref, ref)
+	}
+	var duration time.Duration
+	if known != nil {
+		if known.pullSecret != "" && !slices.Contains(pullSecrets, known.pullSecret) {
+			return "", 0, fmt.Errorf("failed to pull and unpack image %q: failed to resolve reference %q: pull access denied, repository does not exist or may require authorization: authorization failed: no basic auth credentials", ref, ref)
+		}
+		if digest == "" {
+			digest = known.digest
+		}
+		duration = known.pullDuration
+	}
+	if digest == "" {
+		digest = fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(repository+":"+tag)))
+	}
+	return "docker-pullable://" + repository + "@" + digest, duration, nil
+}
+
+// lookup returns the known image of a repository and tag, if any
+func (r *ImageRegistry) lookup(repository, tag string) *knownImage {
+	for i := range r.images {
+		if known := &r.images[i]; known.repository == repository && (known.tag == "" || known.tag == tag) {
+			return known
+		}
+	}
+	return nil
+}
+
+// isPresent reports whether node has image, preloaded or pulled
+func (r *ImageRegistry) isPresent(node, image string) bool {
+	if r.preloadAll {
+		return true
+	}
+	repository, tag, _ := splitImage(image)
+	if tag == "" {
+		tag = "latest"
+	}
+	if known := r.lookup(repository, tag); known != nil && known.preloaded {
+		return true
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.present[node][image]
+}
+
+// markPresent records that node has pulled image
+func (r *ImageRegistry) markPresent(node, image string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.present[node] == nil {
+		r.present[node] = map[string]bool{}
+	}
+	r.present[node][image] = true
+}
+
+// splitImage splits an image reference into repository, tag and digest.
+// Docker Hub names are shortened like the docker CLI does (docker.io/library/nginx is nginx).
+func splitImage(image string) (repository, tag, digest string) {
+	repository = image
+	if i := strings.Index(repository, "@"); i >= 0 {
+		repository, digest = repository[:i], repository[i+1:]
+	}
+	if i := strings.LastIndex(repository, ":"); i > strings.LastIndex(repository, "/") {
+		repository, tag = repository[:i], repository[i+1:]
+	}
+	repository = strings.TrimPrefix(repository, "docker.io/")
+	repository = strings.TrimPrefix(repository, "library/")
+	return repository, tag, digest
+}
+
+// DefaultImageRegistry is the singleton instance
+var DefaultImageRegistry *ImageRegistry
+
+// InitImageRegistry initializes the default image registry from the config file at path, or
+// one that has every image when path is empty
+func InitImageRegistry(path string) error {
+	var config *ImageConfig
+	if path != "" {
+		var err error
+		if config, err = LoadImageConfig(path); err != nil {
+			return err
+		}
+	}
+	registry, err := NewImageRegistry(config)
+	if err != nil {
+		return fmt.Errorf("invalid image config %s: %w", path, err)
+	}
+	DefaultImageRegistry = registry
+	if config != nil {
+		fmt.Printf("[Images] Registry with %d images and %d failure patterns\n", len(registry.images), len(registry.failures))
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package controllers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+// useImageRegistry makes the pod controller pull images from a registry for config
+func useImageRegistry(t *testing.T, pc *PodController, config *ImageConfig) {
+	registry, err := NewImageRegistry(config)
+	if err != nil {
+		t.Fatalf("NewImageRegistry failed: %v", err)
+	}
+	pc.Images = registry
+}
+
+// testImageConfig has a slow public image, a private one and a preloaded one
+func testImageConfig() *ImageConfig {
+	return &ImageConfig{
+		Images: []KnownImage{
+			{Image: "nginx:1.25", PullDuration: "50ms"},
+			{Image: "registry.example.com/team/app", Digest: "sha256:abc", PullSecret: "regcred"},
+			{Image: "busybox", Preloaded: true},
+		},
+		FailurePatterns: []string{":broken$"},
+	}
+}
+
+func TestImageRegistryResolve(t *testing.T) {
+	registry, err := NewImageRegistry(testImageConfig())
+	if err != nil {
+		t.Fatalf("NewImageRegistry failed: %v", err)
+	}
+	imageID, duration, err := registry.resolve("docker.io/library/nginx:1.25", nil)
+	if err != nil || duration != 50*time.Millisecond || !strings.HasPrefix(imageID, "docker-pullable://nginx@sha256:") {
+		t.Errorf("Expected nginx:1.25 to pull in 50ms, got %q %v %v", imageID, duration, err)
+	}
+	if _, _, err := registry.resolve("nginx:1.26", nil); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected an unknown tag not to be found, got %v", err)
+	}
+	if _, _, err := registry.resolve("registry.example.com/team/app:v2", nil); err == nil || !strings.Contains(err.Error(), "pull access denied") {
+		t.Errorf("Expected a private image to need its pull secret, got %v", err)
+	}
+	imageID, _, err = registry.resolve("registry.example.com/team/app:v2", []string{"regcred"})
+	if err != nil || imageID != "docker-pullable://registry.example.com/team/app@sha256:abc" {
+		t.Errorf("Expected the configured digest, got %q %v", imageID, err)
+	}
+	if _, _, err := registry.resolve("busybox:broken", nil); err == nil || !strings.Contains(err.Error(), "500 Internal Server Error") {
+		t.Errorf("Expected the failure pattern to fail the pull, got %v", err)
+	}
+	if !registry.isPresent("node-a", "busybox:1.36") || registry.isPresent("node-a", "nginx:1.25") {
+		t.Error("Expected only the preloaded image to be present before any pull")
+	}
+
+	for _, bad := range []*ImageConfig{
+		{Images: []KnownImage{{Image: "nginx", PullDuration: "soon"}}},
+		{Images: []KnownImage{{Image: "nginx", Digest: "md5:1"}}},
+		{FailurePatterns: []string{"("}},
+	} {
+		if _, err := NewImageRegistry(bad); err == nil {
+			t.Errorf("Expected %+v to be refused", bad)
+		}
+	}
+}
+
+func TestSplitImage(t *testing.T) {
+	for image, want := range map[string][3]string{
+		"nginx":                                 {"nginx", "", ""},
+		"docker.io/library/nginx:1.25":          {"nginx", "1.25", ""},
+		"localhost:5000/app":                    {"localhost:5000/app", "", ""},
+		"quay.io/org/app:v1@sha256:0123":        {"quay.io/org/app", "v1", "sha256:0123"},
+		"registry.example.com:443/app@sha256:1": {"registry.example.com:443/app", "", "sha256:1"},
+	} {
+		repository, tag, digest := splitImage(image)
+		if got := [3]string{repository, tag, digest}; got != want {
+			t.Errorf("splitImage(%q) = %v, want %v", image, got, want)
+		}
+	}
+}
+
+func TestImagePullBackOff(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	pc := usePodController(t, store)
+	useImageRegistry(t, pc, testImageConfig())
+
+	pod := newTestPod("pulling", nil, map[string]interface{}{
+		"containers": []interface{}{
+			map[string]interface{}{"name": "app", "image": "nginx:1.25"},
+			map[string]interface{}{"name": "sidecar", "image": "missing:v1"},
+		},
+	})
+	if err := createPod(store, pod); err != nil {
+		t.Fatalf("Failed to create pod: %v", err)
+	}
+	waitFor(t, 5*time.Second, "pull started", func() bool {
+		return appStatus(store, "pulling") != nil
+	})
+	stored, _ := store.GetPod("pulling")
+	if reason, _ := resources.NestedString(appStatus(store, "pulling"), "state", "waiting", "reason"); reason != ContainerReasonContainerCreating {
+		t.Errorf("Expected app to wait for its image, got %v", stored["status"])
+	}
+	if phase, _ := resources.NestedString(stored, "status", "phase"); phase != string(PodPending) {
+		t.Errorf("Expected the pod to stay Pending while pulling, got %s", phase)
+	}
+
+	waitFor(t, 5*time.Second, "app running", func() bool {
+		_, running := resources.NestedMap(appStatus(store, "pulling"), "state", "running")
+		return running
+	})
+	if imageID, _ := appStatus(store, "pulling")["imageID"].(string); !strings.HasPrefix(imageID, "docker-pullable://nginx@sha256:") {
+		t.Errorf("Expected the imageID of nginx, got %q", imageID)
+	}
+	waitFor(t, 5*time.Second, "sidecar in ImagePullBackOff", func() bool {
+		stored, _ := store.GetPod("pulling")
+		reason, _ := resources.NestedString(podContainerStatus(stored, "sidecar"), "state", "waiting", "reason")
+		return reason == ContainerReasonImagePullBackOff
+	})
+	stored, _ = store.GetPod("pulling")
+	if phase, _ := resources.NestedString(stored, "status", "phase"); phase != string(PodPending) || isPodReady(stored) {
+		t.Errorf("Expected the pod Pending and unready without its sidecar, got %v", stored["status"])
+	}
+	if !hasEvent(store, EventReasonPulled, `Successfully pulled image "nginx:1.25" in 50ms`) {
+		t.Error("Expected a Pulled event for nginx")
+	}
+	if !hasEvent(store, EventReasonFailed, `Failed to pull image "missing:v1"`) || !hasEvent(store, EventReasonFailed, "Error: ImagePullBackOff") {
+		t.Error("Expected Failed events for the missing image")
+	}
+	// the pull is retried after the back-off
+	waitFor(t, 5*time.Second, "pull retried", func() bool {
+		for _, item := range store.ListEvents() {
+			event := item.(map[string]interface{})
+			if count, _ := resources.ToInt64(event["count"]); event["message"] == "Error: "+ContainerReasonErrImagePull && count > 1 {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func TestImagePullPolicies(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	pc := usePodController(t, store)
+	useImageRegistry(t, pc, testImageConfig())
+
+	pod := newTestPod("policies", nil, map[string]interface{}{
+		"nodeName": "node-a",
+		"containers": []interface{}{
+			map[string]interface{}{"name": "app", "image": "nginx:1.25", "imagePullPolicy": "IfNotPresent"},
+			map[string]interface{}{"name": "tools", "image": "busybox:1.36", "imagePullPolicy": "Never"},
+			map[string]interface{}{"name": "sidecar", "image": "nginx:1.25", "imagePullPolicy": "Never"},
+		},
+	})
+	if err := createPod(store, pod); err != nil {
+		t.Fatalf("Failed to create pod: %v", err)
+	}
+	waitFor(t, 5*time.Second, "app running", func() bool {
+		_, running := resources.NestedMap(appStatus(store, "policies"), "state", "running")
+		return running
+	})
+	stored, _ := store.GetPod("policies")
+	if _, running := resources.NestedMap(podContainerStatus(stored, "tools"), "state", "running"); !running {
+		t.Errorf("Expected the preloaded image to run under pull policy Never, got %v", podContainerStatus(stored, "tools"))
+	}
+	waiting, _ := resources.NestedMap(podContainerStatus(stored, "sidecar"), "state", "waiting")
+	if waiting["reason"] != ContainerReasonErrImageNeverPull || waiting["message"] != `Container image "nginx:1.25" is not present with pull policy of Never` {
+		t.Errorf("Expected ErrImageNeverPull for an image not on the node, got %v", waiting)
+	}
+	if phase, _ := resources.NestedString(stored, "status", "phase"); phase != string(PodPending) {
+		t.Errorf("Expected the pod to stay Pending, got %s", phase)
+	}
+
+	// the node has nginx now: a second pod starts right away
+	pod = newTestPod("cached", nil, map[string]interface{}{
+		"nodeName": "node-a",
+		"containers": []interface{}{
+			map[string]interface{}{"name": "app", "image": "nginx:1.25", "imagePullPolicy": "Never"},
+		},
+	})
+	if err := createPod(store, pod); err != nil {
+		t.Fatalf("Failed to create pod: %v", err)
+	}
+	waitFor(t, 5*time.Second, "cached pod running", func() bool {
+		stored, err := store.GetPod("cached")
+		phase, _ := resources.NestedString(stored, "status", "phase")
+		return err == nil && phase == string(PodRunning)
+	})
+	if !hasEvent(store, EventReasonPulled, `Container image "nginx:1.25" already present on machine`) {
+		t.Error("Expected an already present event")
+	}
+}
@@ -0,0 +1,216 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+// Informers turn store change events into handler calls. The store itself is the cache,
+// so handlers typically just enqueue a key and the controller re-reads the object in sync.
+
+// DefaultResyncPeriod re-delivers every object as an update, as a safety net for missed work.
+const DefaultResyncPeriod = 30 * time.Second
+
+// ResourceEventHandlerFuncs receives the events of one kind. Any func may be nil.
+// Objects are copies owned by the handler: each handler, and each argument, gets its own.
+type ResourceEventHandlerFuncs struct {
+	AddFunc    func(obj map[string]interface{})
+	UpdateFunc func(oldObj, newObj map[string]interface{})
+	DeleteFunc func(obj map[string]interface{})
+}
+
+// SharedInformerFactory watches the store once and fans events out to per-kind handlers.
+type SharedInformerFactory struct {
+	store    *storage.InMemoryStore
+	resync   time.Duration
+	mu       sync.RWMutex
+	handlers map[string][]ResourceEventHandlerFuncs
+	started  bool
+	watcher  *storage.Watcher
+	stopCh   chan struct{}
+}
+
+// NewSharedInformerFactory creates a factory; resync <= 0 disables periodic resync.
+func NewSharedInformerFactory(store *storage.InMemoryStore, resync time.Duration) *SharedInformerFactory {
+	return &SharedInformerFactory{
+		store:    store,
+		resync:   resync,
+		handlers: make(map[string][]ResourceEventHandlerFuncs),
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// AddEventHandler registers h for kind and replays the existing objects to it as adds.
+func (f *SharedInformerFactory) AddEventHandler(kind string, h ResourceEventHandlerFuncs) {
+	f.mu.Lock()
+	f.handlers[kind] = append(f.handlers[kind], h)
+	f.mu.Unlock()
+
+	if h.AddFunc != nil {
+		for _, item := range f.store.ListObjects(kind) {
+			if obj, ok := item.(map[string]interface{}); ok {
+				h.AddFunc(obj)
+			}
+		}
+	}
+}
+
+// Start begins watching the store (idempotent).
+func (f *SharedInformerFactory) Start() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.started {
+		return
+	}
+	f.started = true
+	f.watcher = f.store.Watch()
+	go f.run()
+}
+
+// Stop stops watching the store.
+func (f *SharedInformerFactory) Stop() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.started {
+		return
+	}
+	f.started = false
+	f.watcher.Stop()
+	close(f.stopCh)
+}
+
+// run dispatches store events and periodic resyncs
+func (f *SharedInformerFactory) run() {
+	var resyncC <-chan time.Time
+	if f.resync > 0 {
+		ticker := time.NewTicker(f.resync)
+		defer ticker.Stop()
+		resyncC = ticker.C
+	}
+	events := f.watcher.ResultChan()
+	for {
+		select {
+		case <-f.stopCh:
+			return
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			f.dispatch(ev)
+		case <-resyncC:
+			f.resyncAll()
+		}
+	}
+}
+
+// dispatch calls the handlers registered for the event's kind
+func (f *SharedInformerFactory) dispatch(ev storage.Event) {
+	f.mu.RLock()
+	handlers := f.handlers[ev.Kind]
+	f.mu.RUnlock()
+	for i, h := range handlers {
+		// the last handler gets the decoded objects, the others copies of them
+		obj, oldObj := ev.Object, ev.OldObject
+		if i < len(handlers)-1 {
+			obj, oldObj = nil, nil
+			deepCopyJSON(ev.Object, &obj)
+			deepCopyJSON(ev.OldObject, &oldObj)
+		}
+		switch ev.Type {
+		case storage.Added:
+			if h.AddFunc != nil {
+				h.AddFunc(obj)
+			}
+		case storage.Modified:
+			if h.UpdateFunc != nil {
+				h.UpdateFunc(oldObj, obj)
+			}
+		case storage.Deleted:
+			if h.DeleteFunc != nil {
+				h.DeleteFunc(obj)
+			}
+		}
+	}
+}
+
+// resyncAll re-delivers every object of the watched kinds as an unchanged update
+func (f *SharedInformerFactory) resyncAll() {
+	f.mu.RLock()
+	kinds := make([]string, 0, len(f.handlers))
+	for kind := range f.handlers {
+		kinds = append(kinds, kind)
+	}
+	f.mu.RUnlock()
+	for _, kind := range kinds {
+		for _, item := range f.store.ListObjects(kind) {
+			if obj, ok := item.(map[string]interface{}); ok {
+				var oldObj map[string]interface{}
+				deepCopyJSON(obj, &oldObj)
+				f.dispatch(storage.Event{Type: storage.Modified, Kind: kind, Object: obj, OldObject: oldObj})
+			}
+		}
+	}
+}
+
+// objectKey returns the queue key "namespace/name" of an object (just "name" if cluster-scoped).
+func objectKey(obj map[string]interface{}) string {
+	name, _ := resources.NestedString(obj, "metadata", "name")
+	if namespace, _ := resources.NestedString(obj, "metadata", "namespace"); namespace != "" {
+		return namespace + "/" + name
+	}
+	return name
+}
+
+// splitKey splits a queue key into namespace ("default" if unset) and name.
+func splitKey(key string) (namespace, name string) {
+	if namespace, name, ok := strings.Cut(key, "/"); ok {
+		return namespace, name
+	}
+	return "default", key
+}
+
+// controllerOf returns the owner reference with controller=true of the given kind, if any.
+func controllerOf(obj map[string]interface{}, kind string) map[string]interface{} {
+	for _, ref := range ownerReferences(obj) {
+		if isController, _ := ref["controller"].(bool); isController && ref["kind"] == kind {
+			return ref
+		}
+	}
+	return nil
+}
+
+// ownerKey returns the queue key of obj's controller of the given kind ("" if none).
+func ownerKey(obj map[string]interface{}, kind string) string {
+	ref := controllerOf(obj, kind)
+	if ref == nil {
+		return ""
+	}
+	name, _ := ref["name"].(string)
+	namespace, _ := resources.NestedString(obj, "metadata", "namespace")
+	if namespace == "" {
+		namespace = "default"
+	}
+	return fmt.Sprintf("%s/%s", namespace, name)
+}
+
+// DefaultInformerFactory is shared by the default controllers
+var DefaultInformerFactory *SharedInformerFactory
+
+// sharedInformers returns DefaultInformerFactory, creating and starting it on first use
+func sharedInformers(store *storage.InMemoryStore) *SharedInformerFactory {
+	if DefaultInformerFactory == nil {
+		DefaultInformerFactory = NewSharedInformerFactory(store, DefaultResyncPeriod)
+		DefaultInformerFactory.Start()
+	}
+	return DefaultInformerFactory
+}
+
+// InitInformers initializes and starts DefaultInformerFactory
+func InitInformers(store *storage.InMemoryStore) {
+	sharedInformers(store)
+}
@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+func TestInformerHandlersOwnTheirObjects(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	factory := NewSharedInformerFactory(store, 10*time.Millisecond)
+	t.Cleanup(factory.Stop)
+
+	var mu sync.Mutex
+	var seen []string
+	resynced := false
+	// the first handler scribbles over everything it is given
+	factory.AddEventHandler("Pod", ResourceEventHandlerFuncs{
+		AddFunc: func(obj map[string]interface{}) {
+			obj["metadata"].(map[string]interface{})["name"] = "scribbled"
+		},
+		UpdateFunc: func(oldObj, newObj map[string]interface{}) {
+			newObj["metadata"].(map[string]interface{})["name"] = "scribbled"
+		},
+	})
+	factory.AddEventHandler("Pod", ResourceEventHandlerFuncs{
+		AddFunc: func(obj map[string]interface{}) {
+			name, _ := resources.NestedString(obj, "metadata", "name")
+			mu.Lock()
+			seen = append(seen, name)
+			mu.Unlock()
+		},
+		UpdateFunc: func(oldObj, newObj map[string]interface{}) {
+			oldName, _ := resources.NestedString(oldObj, "metadata", "name")
+			newName, _ := resources.NestedString(newObj, "metadata", "name")
+			mu.Lock()
+			resynced = oldName == "web" && newName == "web"
+			mu.Unlock()
+		},
+	})
+	factory.Start()
+
+	if err := store.CreatePod(newTestPod("web", nil, map[string]interface{}{})); err != nil {
+		t.Fatalf("Failed to create pod: %v", err)
+	}
+	waitFor(t, 5*time.Second, "resync", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return resynced
+	})
+	mu.Lock()
+	defer mu.Unlock()
+	if len(seen) != 1 || seen[0] != "web" {
+		t.Errorf("Expected the second handler to see the pod unchanged, got %v", seen)
+	}
+}
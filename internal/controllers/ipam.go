@@ -0,0 +1,368 @@
+package controllers
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+// Pod IP address management (the node IPAM controller and the CNI's host-local IPAM
+// upstream). Every node gets a podCIDR per cluster CIDR (a /24 of an IPv4 one, a /64 of an
+// IPv6 one) and pods get the next free address of each of their node's podCIDRs, one per IP
+// family with a dual-stack cluster CIDR. Addresses are handed out round robin, so a released
+// address isn't reused right away, and are released when their pod is deleted.
+
+// ClusterCIDREnv names the environment variable holding the cluster CIDR: one prefix, or an
+// IPv4 and an IPv6 one separated by a comma for dual-stack, e.g. "10.244.0.0/16,fd00:10:244::/56"
+const ClusterCIDREnv = "MOCKERNETES_CLUSTER_CIDR"
+
+// DefaultClusterCIDR is the cluster CIDR when none is configured (like a kind cluster's)
+const DefaultClusterCIDR = "10.244.0.0/16"
+
+// Node podCIDR sizes (kube-controller-manager --node-cidr-mask-size-ipv4/ipv6)
+const (
+	nodeCIDRMaskSizeIPv4 = 24
+	nodeCIDRMaskSizeIPv6 = 64
+)
+
+// EventReasonFailedCreatePodSandBox is the reason of the event about a pod that got no IP
+const EventReasonFailedCreatePodSandBox = "FailedCreatePodSandBox"
+
+// podSandboxRetryDelay is how long a pod that got no IP waits before its start is retried
+const podSandboxRetryDelay = time.Second
+
+// maxAllocationAttempts bounds the search for a free address or subnet in huge IPv6 ranges
+const maxAllocationAttempts = 1 << 16
+
+// IPAM hands out pod IPs and node podCIDRs from the cluster CIDRs
+type IPAM struct {
+	clusterCIDRs []netip.Prefix
+
+	mu        sync.Mutex
+	owners    map[netip.Addr]string       // allocated address -> pod uid
+	allocated map[string][]netip.Addr     // pod uid -> its addresses
+	last      map[netip.Prefix]netip.Addr // last address handed out per range
+}
+
+// NewIPAM creates an IPAM for a cluster CIDR (see ClusterCIDREnv; "" is DefaultClusterCIDR)
+func NewIPAM(clusterCIDR string) (*IPAM, error) {
+	if clusterCIDR == "" {
+		clusterCIDR = DefaultClusterCIDR
+	}
+	ipam := &IPAM{
+		owners:    map[netip.Addr]string{},
+		allocated: map[string][]netip.Addr{},
+		last:      map[netip.Prefix]netip.Addr{},
+	}
+	cidrs, err := parseCIDRs(clusterCIDR)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cluster CIDR %q: %w", clusterCIDR, err)
+	}
+	ipam.clusterCIDRs = cidrs
+	return ipam, nil
+}
+
+// parseCIDRs parses one prefix, or an IPv4 and an IPv6 one separated by a comma
+func parseCIDRs(s string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	families := map[bool]bool{}
+	for _, cidr := range strings.Split(s, ",") {
+		prefix, err := netip.ParsePrefix(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, err
+		}
+		if families[prefix.Addr().Is4()] {
+			return nil, fmt.Errorf("at most one IPv4 and one IPv6 prefix")
+		}
+		families[prefix.Addr().Is4()] = true
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// ipFamily returns the IP family of an address ("IPv4" or "IPv6")
+func ipFamily(addr netip.Addr) string {
+	if addr.Is4() {
+		return "IPv4"
+	}
+	return "IPv6"
+}
+
+// ClusterCIDRs returns the cluster CIDRs, the primary IP family first
+func (ipam *IPAM) ClusterCIDRs() []netip.Prefix {
+	return ipam.clusterCIDRs
+}
+
+// nodeMaskSize returns the size of node podCIDRs carved out of a cluster CIDR
+func nodeMaskSize(cluster netip.Prefix) int {
+	size := nodeCIDRMaskSizeIPv4
+	if cluster.Addr().Is6() {
+		size = nodeCIDRMaskSizeIPv6
+	}
+	return max(size, cluster.Bits())
+}
+
+// AssignPodCIDRs gives a node without spec.podCIDRs the first subnet of every cluster CIDR no
+// other node of store has
+func (ipam *IPAM) AssignPodCIDRs(store *storage.InMemoryStore, node *resources.Node) {
+	spec, _ := node.Spec.(map[string]interface{})
+	if spec == nil {
+		spec = map[string]interface{}{}
+		node.Spec = spec
+	}
+	if cidrs, ok := spec["podCIDRs"].([]interface{}); ok && len(cidrs) > 0 {
+		return
+	}
+	used := map[netip.Prefix]bool{}
+	for _, item := range store.ListNodes() {
+		if other, ok := item.(map[string]interface{}); ok {
+			for _, cidr := range nodePodCIDRs(other) {
+				used[cidr] = true
+			}
+		}
+	}
+
+	var cidrs []interface{}
+	for _, cluster := range ipam.clusterCIDRs {
+		size := nodeMaskSize(cluster)
+		for n := uint64(0); n < maxAllocationAttempts; n++ {
+			subnet, ok := nthSubnet(cluster, size, n)
+			if !ok {
+				fmt.Printf("[IPAM] No podCIDR of %s left for node %s\n", cluster, node.Metadata.Name)
+				break
+			}
+			if !used[subnet] {
+				cidrs = append(cidrs, subnet.String())
+				break
+			}
+		}
+	}
+	if len(cidrs) > 0 {
+		spec["podCIDR"] = cidrs[0]
+		spec["podCIDRs"] = cidrs
+	}
+}
+
+// nodePodCIDRs returns spec.podCIDRs of a node
+func nodePodCIDRs(node map[string]interface{}) []netip.Prefix {
+	var prefixes []netip.Prefix
+	cidrs, _ := resources.NestedStringSlice(node, "spec", "podCIDRs")
+	for _, cidr := range cidrs {
+		if prefix, err := netip.ParsePrefix(cidr); err == nil {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes
+}
+
+// nthSubnet returns the n-th subnet of the given size in cluster (false past the last one)
+func nthSubnet(cluster netip.Prefix, size int, n uint64) (netip.Prefix, bool) {
+	if free := size - cluster.Bits(); free < 64 && n >= 1<<free {
+		return netip.Prefix{}, false
+	}
+	ip := addToAddr(cluster.Masked().Addr(), n, cluster.Addr().BitLen()-size)
+	return netip.PrefixFrom(ip, size), cluster.Contains(ip)
+}
+
+// addToAddr returns addr + n<<shift
+func addToAddr(addr netip.Addr, n uint64, shift int) netip.Addr {
+	b := addr.As16()
+	hi, lo := binary.BigEndian.Uint64(b[:8]), binary.BigEndian.Uint64(b[8:])
+	var addHi, addLo uint64
+	switch {
+	case shift >= 128:
+	case shift >= 64:
+		addHi = n << (shift - 64)
+	case shift == 0:
+		addLo = n
+	default:
+		addHi, addLo = n>>(64-shift), n<<shift
+	}
+	lo, carry := bits.Add64(lo, addLo, 0)
+	hi, _ = bits.Add64(hi, addHi, carry)
+	binary.BigEndian.PutUint64(b[:8], hi)
+	binary.BigEndian.PutUint64(b[8:], lo)
+	ip := netip.AddrFrom16(b)
+	if addr.Is4() {
+		ip = ip.Unmap()
+	}
+	return ip
+}
+
+// Allocate returns the addresses of a pod: the ones it already has, or the next free address
+// of each range (the pod's node's podCIDRs, or the cluster CIDRs). Addresses of pods gone from
+// store or finished are reclaimed when a range runs out.
+func (ipam *IPAM) Allocate(store *storage.InMemoryStore, podUID string, ranges []netip.Prefix) ([]netip.Addr, error) {
+	ipam.mu.Lock()
+	defer ipam.mu.Unlock()
+	if addrs, ok := ipam.allocated[podUID]; ok {
+		return addrs, nil
+	}
+	var addrs []netip.Addr
+	for _, r := range ranges {
+		addr, ok := ipam.next(r)
+		if !ok {
+			ipam.reclaim(store)
+			if addr, ok = ipam.next(r); !ok {
+				for _, a := range addrs {
+					delete(ipam.owners, a)
+				}
+				return nil, fmt.Errorf("no IP addresses available in range set: %s", r)
+			}
+		}
+		ipam.owners[addr] = podUID
+		addrs = append(addrs, addr)
+	}
+	ipam.allocated[podUID] = addrs
+	return addrs, nil
+}
+
+// next returns the first free address of a range after the last one handed out
+func (ipam *IPAM) next(r netip.Prefix) (netip.Addr, bool) {
+	first, last := rangeBounds(r)
+	if !first.IsValid() {
+		return netip.Addr{}, false
+	}
+	addr, ok := ipam.last[r]
+	if !ok {
+		addr = last
+	}
+	for i := 0; i < maxAllocationAttempts; i++ {
+		if addr = addr.Next(); !addr.IsValid() || last.Less(addr) {
+			addr = first
+		}
+		if _, taken := ipam.owners[addr]; !taken {
+			ipam.last[r] = addr
+			return addr, true
+		}
+		if addr == ipam.last[r] {
+			break
+		}
+	}
+	return netip.Addr{}, false
+}
+
+// rangeBounds returns the first and last pod address of a range: the network address and the
+// gateway (.1) aren't handed out, nor is the IPv4 broadcast address
+func rangeBounds(r netip.Prefix) (first, last netip.Addr) {
+	r = r.Masked()
+	first = r.Addr().Next().Next()
+	last = lastAddr(r)
+	if last.Is4() {
+		last = last.Prev()
+	}
+	if !first.IsValid() || !r.Contains(first) || last.Less(first) {
+		return netip.Addr{}, netip.Addr{}
+	}
+	return first, last
+}
+
+// lastAddr returns the last address of a prefix
+func lastAddr(r netip.Prefix) netip.Addr {
+	b := r.Masked().Addr().As16()
+	hostBits := r.Addr().BitLen() - r.Bits()
+	for i := 15; i >= 0 && hostBits > 0; i-- {
+		n := min(hostBits, 8)
+		b[i] |= byte(1<<n - 1)
+		hostBits -= n
+	}
+	ip := netip.AddrFrom16(b)
+	if r.Addr().Is4() {
+		ip = ip.Unmap()
+	}
+	return ip
+}
+
+// Release frees the addresses of a pod
+func (ipam *IPAM) Release(podUID string) {
+	ipam.mu.Lock()
+	defer ipam.mu.Unlock()
+	ipam.release(podUID)
+}
+
+func (ipam *IPAM) release(podUID string) {
+	for _, addr := range ipam.allocated[podUID] {
+		if ipam.owners[addr] == podUID {
+			delete(ipam.owners, addr)
+		}
+	}
+	delete(ipam.allocated, podUID)
+}
+
+// reclaim releases the addresses of pods that are gone from store or finished
+func (ipam *IPAM) reclaim(store *storage.InMemoryStore) {
+	live := map[string]bool{}
+	for _, item := range store.ListPods() {
+		if pod, ok := item.(map[string]interface{}); ok && !isPodFinished(pod) {
+			live[objectUID(pod)] = true
+		}
+	}
+	for uid := range ipam.allocated {
+		if !live[uid] {
+			ipam.release(uid)
+		}
+	}
+}
+
+// podNetwork returns the addresses a starting pod gets: its node's InternalIP for hostNetwork
+// pods, else addresses from its node's podCIDRs (the cluster CIDRs when the pod isn't bound
+// or its node has none)
+func (ipam *IPAM) podNetwork(store *storage.InMemoryStore, pod map[string]interface{}, hostIP string) ([]string, error) {
+	if hostNetwork, _ := resources.NestedMap(pod, "spec"); hostNetwork["hostNetwork"] == true {
+		return []string{hostIP}, nil
+	}
+	ranges := ipam.clusterCIDRs
+	if nodeName, _ := resources.NestedString(pod, "spec", "nodeName"); nodeName != "" {
+		if node, err := store.GetNode(nodeName); err == nil {
+			if cidrs := nodePodCIDRs(node); len(cidrs) > 0 {
+				ranges = cidrs
+			}
+		}
+	}
+	addrs, err := ipam.Allocate(store, objectUID(pod), ranges)
+	if err != nil {
+		return nil, err
+	}
+	ips := make([]string, len(addrs))
+	for i, addr := range addrs {
+		ips[i] = addr.String()
+	}
+	return ips, nil
+}
+
+// DefaultIPAM is the singleton instance
+var DefaultIPAM *IPAM
+
+// InitIPAM initializes the default IPAM for a cluster CIDR ("" is DefaultClusterCIDR)
+func InitIPAM(clusterCIDR string) error {
+	ipam, err := NewIPAM(clusterCIDR)
+	if err != nil {
+		return err
+	}
+	DefaultIPAM = ipam
+	fmt.Printf("[IPAM] Allocating pod IPs from %s\n", strings.Join(prefixStrings(ipam.clusterCIDRs), ","))
+	return nil
+}
+
+// AssignPodCIDRs gives a new node its podCIDRs from the default IPAM, if there is one
+func AssignPodCIDRs(store *storage.InMemoryStore, node *resources.Node) {
+	if DefaultIPAM != nil {
+		DefaultIPAM.AssignPodCIDRs(store, node)
+	}
+}
+
+// prefixStrings formats prefixes
+func prefixStrings(prefixes []netip.Prefix) []string {
+	s := make([]string, len(prefixes))
+	for i, p := range prefixes {
+		s[i] = p.String()
+	}
+	return s
+}
@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"fmt"
+	"net/netip"
+	"testing"
+	"time"
+
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+// podIPs returns status.podIPs of a pod
+func podIPs(store *storage.InMemoryStore, name string) []string {
+	pod, _ := store.GetPod(name)
+	entries, _ := resources.NestedSlice(pod, "status", "podIPs")
+	var ips []string
+	for _, entry := range entries {
+		if ip, ok := entry.(map[string]interface{})["ip"].(string); ok {
+			ips = append(ips, ip)
+		}
+	}
+	return ips
+}
+
+func TestIPAMAllocatesUniqueIPs(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	ipam, err := NewIPAM("10.244.0.0/16")
+	if err != nil {
+		t.Fatalf("NewIPAM failed: %v", err)
+	}
+	node := netip.MustParsePrefix("10.244.3.0/24")
+	seen := map[netip.Addr]bool{}
+	for i := 0; i < 253; i++ {
+		pod := newTestPod(fmt.Sprintf("pod-%d", i), nil, map[string]interface{}{})
+		store.CreatePod(pod)
+		stored, _ := store.GetPod(pod.GetName())
+		addrs, err := ipam.Allocate(store, objectUID(stored), []netip.Prefix{node})
+		if err != nil {
+			t.Fatalf("Allocate %d failed: %v", i, err)
+		}
+		addr := addrs[0]
+		if seen[addr] || !node.Contains(addr) {
+			t.Fatalf("Expected a new address of %s, got %s", node, addr)
+		}
+		if last := addr.As4()[3]; last == 0 || last == 1 || last == 255 {
+			t.Errorf("Network, gateway and broadcast addresses are reserved, got %s", addr)
+		}
+		seen[addr] = true
+
+		// allocating again returns the same address
+		if again, _ := ipam.Allocate(store, objectUID(stored), []netip.Prefix{node}); again[0] != addr {
+			t.Errorf("Expected %s again, got %s", addr, again[0])
+		}
+	}
+
+	if _, err := ipam.Allocate(store, "another-pod", []netip.Prefix{node}); err == nil {
+		t.Fatal("Expected the range to be exhausted")
+	}
+	// a pod gone from the store gives its address back
+	store.DeletePod("pod-0")
+	addrs, err := ipam.Allocate(store, "another-pod", []netip.Prefix{node})
+	if err != nil || addrs[0] != netip.MustParseAddr("10.244.3.2") {
+		t.Fatalf("Expected the address of the deleted pod, got %v (%v)", addrs, err)
+	}
+}
+
+func TestAssignPodCIDRs(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	ipam, err := NewIPAM("10.244.0.0/16,fd00:10:244::/56")
+	if err != nil {
+		t.Fatalf("NewIPAM failed: %v", err)
+	}
+	expected := [][]string{
+		{"10.244.0.0/24", "fd00:10:244::/64"},
+		{"10.244.1.0/24", "fd00:10:244:1::/64"},
+		{"10.244.2.0/24", "fd00:10:244:2::/64"},
+	}
+	for i, cidrs := range expected {
+		node := newTestNode("node-"+string(rune('a'+i)), nil)
+		ipam.AssignPodCIDRs(store, node)
+		store.CreateNode(node)
+		stored, _ := store.GetNode(node.Metadata.Name)
+		got, _ := resources.NestedStringSlice(stored, "spec", "podCIDRs")
+		podCIDR, _ := resources.NestedString(stored, "spec", "podCIDR")
+		if len(got) != 2 || got[0] != cidrs[0] || got[1] != cidrs[1] || podCIDR != cidrs[0] {
+			t.Errorf("Expected podCIDRs %v, got %v (podCIDR %s)", cidrs, got, podCIDR)
+		}
+	}
+
+	if _, err := NewIPAM("10.244.0.0/16,10.245.0.0/16"); err == nil {
+		t.Error("Expected an error for two IPv4 cluster CIDRs")
+	}
+	if _, err := NewIPAM("10.244.0.0"); err == nil {
+		t.Error("Expected an error for an invalid cluster CIDR")
+	}
+}
+
+func TestPodGetsDualStackIPsFromNodePodCIDRs(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	pc := usePodController(t, store)
+	ipam, _ := NewIPAM("10.244.0.0/16,fd00:10:244::/56")
+	pc.IPAM = ipam
+
+	for _, name := range []string{"node-a", "node-b"} {
+		node := newTestNode(name, nil)
+		AssignNodeAddress(store, node)
+		ipam.AssignPodCIDRs(store, node)
+		store.CreateNode(node)
+	}
+	for _, name := range []string{"web-1", "web-2"} {
+		if err := createPod(store, newTestPod(name, nil, map[string]interface{}{"nodeName": "node-b"})); err != nil {
+			t.Fatalf("Failed to create pod: %v", err)
+		}
+	}
+	waitFor(t, 5*time.Second, "pods running", func() bool {
+		return len(podIPs(store, "web-1")) == 2 && len(podIPs(store, "web-2")) == 2
+	})
+
+	node, _ := store.GetNode("node-b")
+	cidrs := nodePodCIDRs(node)
+	first, second := podIPs(store, "web-1"), podIPs(store, "web-2")
+	for i, ips := range [][]string{first, second} {
+		for family, ip := range ips {
+			if !cidrs[family].Contains(netip.MustParseAddr(ip)) {
+				t.Errorf("Expected pod %d's IP %s in %s", i, ip, cidrs[family])
+			}
+		}
+	}
+	if first[0] == second[0] || first[1] == second[1] {
+		t.Errorf("Expected unique pod IPs, got %v and %v", first, second)
+	}
+	pod, _ := store.GetPod("web-1")
+	if podIP, _ := resources.NestedString(pod, "status", "podIP"); podIP != first[0] {
+		t.Errorf("Expected podIP %s, got %s", first[0], podIP)
+	}
+
+	// deleting a pod releases its addresses
+	if _, err := pc.DeletePod("web-1", 0); err != nil {
+		t.Fatalf("DeletePod failed: %v", err)
+	}
+	waitFor(t, 5*time.Second, "web-1 deleted", func() bool {
+		_, err := store.GetPod("web-1")
+		return err != nil
+	})
+	ipam.mu.Lock()
+	_, taken := ipam.owners[netip.MustParseAddr(first[0])]
+	ipam.mu.Unlock()
+	if taken {
+		t.Errorf("Expected %s to be released", first[0])
+	}
+}
+
+func TestPodStaysPendingWhenPodCIDRIsExhausted(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	pc := usePodController(t, store)
+	pc.IPAM, _ = NewIPAM(DefaultClusterCIDR)
+
+	node := newTestNode("node-a", nil)
+	// a /30 has a single pod address (.2)
+	node.Spec.(map[string]interface{})["podCIDRs"] = []interface{}{"10.244.7.0/30"}
+	store.CreateNode(node)
+
+	createPod(store, newTestPod("first", nil, map[string]interface{}{"nodeName": "node-a"}))
+	waitFor(t, 5*time.Second, "first running", func() bool {
+		ips := podIPs(store, "first")
+		return len(ips) == 1 && ips[0] == "10.244.7.2"
+	})
+	createPod(store, newTestPod("second", nil, map[string]interface{}{"nodeName": "node-a"}))
+	waitFor(t, 5*time.Second, "FailedCreatePodSandBox event", func() bool {
+		for _, item := range store.ListEvents() {
+			if item.(map[string]interface{})["reason"] == EventReasonFailedCreatePodSandBox {
+				return true
+			}
+		}
+		return false
+	})
+	pod, _ := store.GetPod("second")
+	if phase, _ := resources.NestedString(pod, "status", "phase"); phase != string(PodPending) {
+		t.Errorf("Expected second to stay Pending, got %s", phase)
+	}
+
+	// once first is gone, the retry gives second the freed address
+	if _, err := pc.DeletePod("first", 0); err != nil {
+		t.Fatalf("DeletePod failed: %v", err)
+	}
+	waitFor(t, 5*time.Second, "second running", func() bool {
+		ips := podIPs(store, "second")
+		return len(ips) == 1 && ips[0] == "10.244.7.2"
+	})
+}
@@ -0,0 +1,559 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+// JobController manages Jobs: pods that run to completion (pkg/controller/job upstream). It
+// keeps up to spec.parallelism pods running until spec.completions of them have succeeded,
+// replaces failed pods with exponential backoff and fails the Job on its backoffLimit,
+// activeDeadlineSeconds or a FailJob podFailurePolicy rule. Finished Jobs are deleted once
+// their ttlSecondsAfterFinished has passed.
+type JobController struct {
+	store   *storage.InMemoryStore
+	queue   *RateLimitingQueue
+	workers int
+	// BackoffBase is how long a Job waits before replacing a failed pod; the delay doubles
+	// with every further failure up to MaxBackoff.
+	BackoffBase time.Duration
+	MaxBackoff  time.Duration
+
+	mu          sync.Mutex
+	lastFailure map[string]time.Time // by Job key: when the Job last counted a failed pod
+}
+
+// Pod replacement backoff of Jobs (DefaultJobPodFailureBackOff and MaxJobPodFailureBackOff upstream)
+const (
+	DefaultJobBackoff    = 10 * time.Second
+	DefaultJobMaxBackoff = 6 * time.Minute
+)
+
+// Job condition reasons
+const (
+	JobReasonCompletionsReached   = "CompletionsReached"
+	JobReasonBackoffLimitExceeded = "BackoffLimitExceeded"
+	JobReasonDeadlineExceeded     = "DeadlineExceeded"
+	JobReasonPodFailurePolicy     = "PodFailurePolicy"
+	JobReasonSuspended            = "JobSuspended"
+	JobReasonResumed              = "JobResumed"
+)
+
+// NewJobController creates a new JobController fed by the given informers
+func NewJobController(store *storage.InMemoryStore, informers *SharedInformerFactory) *JobController {
+	jc := &JobController{
+		store:       store,
+		queue:       NewRateLimitingQueue(),
+		workers:     DefaultWorkers,
+		BackoffBase: DefaultJobBackoff,
+		MaxBackoff:  DefaultJobMaxBackoff,
+		lastFailure: make(map[string]time.Time),
+	}
+	informers.AddEventHandler("Job", ResourceEventHandlerFuncs{
+		AddFunc:    jc.enqueue,
+		UpdateFunc: func(_, job map[string]interface{}) { jc.enqueue(job) },
+		DeleteFunc: jc.enqueue,
+	})
+	informers.AddEventHandler("Pod", ResourceEventHandlerFuncs{
+		AddFunc: jc.enqueueForPod,
+		UpdateFunc: func(old, pod map[string]interface{}) {
+			jc.enqueueForPod(old)
+			jc.enqueueForPod(pod)
+		},
+		DeleteFunc: jc.enqueueForPod,
+	})
+	return jc
+}
+
+// Start starts the controller's workers
+func (jc *JobController) Start() {
+	runWorkers(jc.queue, jc.workers, "Job Controller", jc.syncJob)
+}
+
+// Stop stops the controller
+func (jc *JobController) Stop() {
+	jc.queue.ShutDownAndWait()
+}
+
+// enqueue queues a Job for sync
+func (jc *JobController) enqueue(job map[string]interface{}) {
+	jc.queue.Add(objectKey(job))
+}
+
+// enqueueForPod queues the Job controlling a pod, or for an orphan every Job whose
+// selector matches it
+func (jc *JobController) enqueueForPod(pod map[string]interface{}) {
+	if key := ownerKey(pod, "Job"); key != "" {
+		jc.queue.Add(key)
+		return
+	}
+	if hasController(pod) || isBeingDeleted(pod) {
+		return
+	}
+	for _, item := range jc.store.ListJobs() {
+		job, ok := item.(map[string]interface{})
+		if !ok || namespaceOf(job) != namespaceOf(pod) {
+			continue
+		}
+		if selector, err := selectorOf(job); err == nil && selector.Matches(podLabels(pod)) {
+			jc.enqueue(job)
+		}
+	}
+}
+
+// syncJob reconciles the Job stored under key. The pods of a Job that is gone or being
+// deleted are let go of: nothing will count them anymore.
+func (jc *JobController) syncJob(key string) error {
+	_, name := splitKey(key)
+	job, err := jc.store.GetJob(name)
+	if err != nil || isBeingDeleted(job) {
+		jc.mu.Lock()
+		delete(jc.lastFailure, key)
+		jc.mu.Unlock()
+		return jc.releaseJobPods(key, "")
+	}
+	if err := jc.releaseJobPods(key, objectUID(job)); err != nil {
+		return err
+	}
+	return jc.reconcileJob(key, job)
+}
+
+// releaseJobPods removes the tracking finalizer from pods controlled by a Job under key
+// other than the one with keepUID (a deleted Job re-created with the same name)
+func (jc *JobController) releaseJobPods(key, keepUID string) error {
+	var errs []error
+	for _, item := range jc.store.ListPods() {
+		pod, ok := item.(map[string]interface{})
+		if !ok || ownerKey(pod, "Job") != key || !hasFinalizer(pod, JobTrackingFinalizer) {
+			continue
+		}
+		if ref := controllerOf(pod, "Job"); keepUID != "" && ref["uid"] == keepUID {
+			continue
+		}
+		podName, _ := resources.NestedString(pod, "metadata", "name")
+		errs = append(errs, jc.removeTrackingFinalizer(podName))
+	}
+	return errors.Join(errs...)
+}
+
+// removeTrackingFinalizer lets go of a pod; one already being deleted is removed with it
+func (jc *JobController) removeTrackingFinalizer(podName string) error {
+	_, err := jc.store.MutatePod(podName, func(pod map[string]interface{}) error {
+		removeFinalizer(pod, JobTrackingFinalizer, "metadata", "finalizers")
+		return nil
+	})
+	if err != nil && jc.podGone(podName) {
+		return nil
+	}
+	return err
+}
+
+// podGone reports whether a pod no longer exists
+func (jc *JobController) podGone(podName string) bool {
+	_, err := jc.store.GetPod(podName)
+	return err != nil
+}
+
+// jobSync is one sync's view of a Job and its pods
+type jobSync struct {
+	job         map[string]interface{}
+	key, name   string
+	status      map[string]interface{} // the status being built
+	indexed     bool
+	completions int64 // -1 when unset (any pod succeeding completes a NonIndexed Job)
+	parallelism int64
+	completed   map[int]bool // completed indexes of an Indexed Job
+	active      []map[string]interface{}
+	terminating int64
+	failJob     string // message of a matched FailJob pod failure policy rule
+	now         time.Time
+}
+
+// count returns a status counter
+func (s *jobSync) count(field string) int64 {
+	n, _ := resources.NestedInt64(s.status, field)
+	return n
+}
+
+// setCondition sets a status condition of the Job
+func (s *jobSync) setCondition(condType, condStatus, reason, message string) {
+	s.status["conditions"] = withCondition(s.status, condType, setCondition(s.status, condType, condStatus, reason, message, s.now))
+}
+
+// reconcileJob counts the Job's finished pods, decides whether it is complete or failed and
+// otherwise creates or deletes pods to match the parallelism. It writes the resulting status
+// and requeues the Job for whatever comes next on a timer (backoff, deadline, TTL).
+func (jc *JobController) reconcileJob(key string, job map[string]interface{}) error {
+	s := &jobSync{job: job, key: key, status: map[string]interface{}{}, completions: -1, now: time.Now()}
+	s.name, _ = resources.NestedString(job, "metadata", "name")
+	if status, ok := resources.NestedMap(job, "status"); ok {
+		deepCopyJSON(status, &s.status)
+	}
+	mode, _ := resources.NestedString(job, "spec", "completionMode")
+	s.indexed = mode == "Indexed"
+	if completions, ok := resources.NestedInt64(job, "spec", "completions"); ok {
+		s.completions = completions
+	}
+	s.parallelism, _ = resources.NestedInt64(job, "spec", "parallelism")
+	completedIndexes, _ := resources.NestedString(s.status, "completedIndexes")
+	s.completed = parseIndexes(completedIndexes)
+
+	selector, err := selectorOf(job)
+	if err != nil {
+		return err
+	}
+	pods, err := claimPods(jc.store, job, "Job", selector)
+	if err != nil {
+		fmt.Printf("[Job Controller] Error claiming pods of Job %s: %v\n", key, err)
+		return err
+	}
+
+	finished := jobCondition(s.status, "Complete") == "True" || jobCondition(s.status, "Failed") == "True"
+	errs := []error{jc.trackFinishedPods(s, pods, finished)}
+	var wait time.Duration
+	if !finished {
+		fmt.Printf("[Job Controller] Reconciling Job %s: active=%d, succeeded=%d, failed=%d\n",
+			key, len(s.active), s.count("succeeded"), s.count("failed"))
+		var err error
+		wait, err = jc.syncActivePods(s)
+		errs = append(errs, err)
+	}
+	s.status["active"] = len(s.active)
+	ready := 0
+	for _, pod := range s.active {
+		if isPodReady(pod) {
+			ready++
+		}
+	}
+	s.status["ready"] = ready
+	s.status["terminating"] = s.terminating
+	if s.indexed {
+		s.status["completedIndexes"] = formatIndexes(s.completed)
+	}
+	if _, err := jc.store.MutateJob(s.name, func(stored map[string]interface{}) error {
+		stored["status"] = s.status
+		return nil
+	}); err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+
+	// Finished Jobs clean up after themselves once their TTL has passed
+	if ttl, ok := resources.NestedInt64(job, "spec", "ttlSecondsAfterFinished"); ok {
+		if finishedAt := jobFinishTime(s.status); !finishedAt.IsZero() {
+			expiresIn := finishedAt.Add(time.Duration(ttl) * time.Second).Sub(s.now)
+			if expiresIn <= 0 {
+				fmt.Printf("[Job Controller] Deleting finished Job %s: ttlSecondsAfterFinished passed\n", key)
+				errs = append(errs, jc.store.DeleteJob(s.name))
+			} else if wait == 0 || expiresIn < wait {
+				wait = expiresIn
+			}
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	if wait > 0 {
+		jc.queue.AddAfter(key, wait)
+	}
+	return nil
+}
+
+// trackFinishedPods counts pods that succeeded or failed since the last sync into the status
+// and removes their tracking finalizer. Failed pods go through the pod failure policy. It
+// also collects the active pods. A finished Job lets go of all its pods.
+func (jc *JobController) trackFinishedPods(s *jobSync, pods []map[string]interface{}, finished bool) error {
+	replaceOnlyFailed, _ := resources.NestedString(s.job, "spec", "podReplacementPolicy")
+	succeeded, failed := s.count("succeeded"), s.count("failed")
+	newFailures := false
+	var errs []error
+	for _, pod := range pods {
+		podName, _ := resources.NestedString(pod, "metadata", "name")
+		phase, _ := resources.NestedString(pod, "status", "phase")
+		deleting := isBeingDeleted(pod)
+		if isPodActive(pod) {
+			s.active = append(s.active, pod)
+		} else if deleting && !isPodFinished(pod) {
+			s.terminating++
+		}
+		if !hasFinalizer(pod, JobTrackingFinalizer) {
+			continue
+		}
+
+		switch {
+		case phase == string(PodSucceeded):
+			if !s.indexed {
+				succeeded++
+			} else if index := podCompletionIndex(pod); index >= 0 && (s.completions < 0 || int64(index) < s.completions) && !s.completed[index] {
+				s.completed[index] = true
+				succeeded++
+			}
+		case phase == string(PodFailed):
+			action, message, matched := matchPodFailurePolicy(s.job, pod)
+			if matched && action == PodFailurePolicyActionIgnore {
+				fmt.Printf("[Job Controller] Ignoring failure of pod %s of Job %s: %s\n", podName, s.name, message)
+				break
+			}
+			if matched && action == PodFailurePolicyActionFailJob && s.failJob == "" {
+				s.failJob = message
+			}
+			failed++
+			newFailures = true
+		case deleting && replaceOnlyFailed != "Failed":
+			// A pod deleted before it finished counts as failed: it may never get to report
+			failed++
+			newFailures = true
+		default:
+			if !finished {
+				continue
+			}
+		}
+		errs = append(errs, jc.removeTrackingFinalizer(podName))
+	}
+	s.status["succeeded"] = succeeded
+	s.status["failed"] = failed
+	if newFailures {
+		jc.mu.Lock()
+		jc.lastFailure[s.key] = s.now
+		jc.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+// syncActivePods finishes the Job once it completed or failed, and otherwise creates and
+// deletes pods to run the ones still needed. It returns when the Job has to be synced again.
+func (jc *JobController) syncActivePods(s *jobSync) (time.Duration, error) {
+	spec, _ := resources.NestedMap(s.job, "spec")
+	suspended, _ := spec["suspend"].(bool)
+	if suspended {
+		if jobCondition(s.status, "Suspended") != "True" {
+			fmt.Printf("[Job Controller] Suspending Job %s\n", s.key)
+		}
+		s.setCondition("Suspended", "True", JobReasonSuspended, "Job suspended")
+	} else if jobCondition(s.status, "Suspended") == "True" {
+		// The active deadline starts over on resume
+		fmt.Printf("[Job Controller] Resuming Job %s\n", s.key)
+		s.setCondition("Suspended", "False", JobReasonResumed, "Job resumed")
+		delete(s.status, "startTime")
+	}
+	if _, ok := s.status["startTime"]; !ok && !suspended {
+		s.status["startTime"] = s.now.UTC().Format(time.RFC3339)
+	}
+
+	reason, message, deadlineIn := jc.failureReason(s, suspended)
+	switch {
+	case reason != "":
+		fmt.Printf("[Job Controller] Job %s failed: %s\n", s.key, message)
+		s.setCondition("Failed", "True", reason, message)
+		return 0, jc.deleteJobPods(s, s.active)
+	case jc.complete(s):
+		fmt.Printf("[Job Controller] Job %s completed\n", s.key)
+		s.setCondition("Complete", "True", JobReasonCompletionsReached, "Reached expected number of succeeded pods")
+		s.status["completionTime"] = s.now.UTC().Format(time.RFC3339)
+		return 0, jc.deleteJobPods(s, s.active)
+	case suspended:
+		return 0, jc.deleteJobPods(s, s.active)
+	}
+
+	wait, err := jc.manageJobPods(s)
+	if deadlineIn > 0 && (wait == 0 || deadlineIn < wait) {
+		wait = deadlineIn
+	}
+	return wait, err
+}
+
+// failureReason returns why the Job has failed ("" if it hasn't), and otherwise how long
+// until its active deadline
+func (jc *JobController) failureReason(s *jobSync, suspended bool) (string, string, time.Duration) {
+	if s.failJob != "" {
+		return JobReasonPodFailurePolicy, s.failJob, 0
+	}
+	// With restartPolicy OnFailure failures show up as container restarts
+	failures := s.count("failed")
+	if policy, _ := resources.NestedString(s.job, "spec", "template", "spec", "restartPolicy"); policy == "OnFailure" {
+		for _, pod := range s.active {
+			failures += podRestarts(pod)
+		}
+	}
+	backoffLimit, ok := resources.NestedInt64(s.job, "spec", "backoffLimit")
+	if !ok {
+		backoffLimit = resources.DefaultBackoffLimit
+	}
+	if failures > backoffLimit {
+		return JobReasonBackoffLimitExceeded, "Job has reached the specified backoff limit", 0
+	}
+
+	deadline, ok := resources.NestedInt64(s.job, "spec", "activeDeadlineSeconds")
+	if !ok || suspended {
+		return "", "", 0
+	}
+	startTime, _ := resources.NestedString(s.status, "startTime")
+	started, err := time.Parse(time.RFC3339, startTime)
+	if err != nil {
+		return "", "", 0
+	}
+	remaining := started.Add(time.Duration(deadline) * time.Second).Sub(s.now)
+	if remaining <= 0 {
+		return JobReasonDeadlineExceeded, "Job was active longer than specified deadline", 0
+	}
+	return "", "", remaining
+}
+
+// complete reports whether the Job has as many successful pods as it needs. Without
+// completions a NonIndexed Job is done once a pod succeeded and the others have finished.
+func (jc *JobController) complete(s *jobSync) bool {
+	if s.indexed {
+		return int64(len(s.completed)) >= s.completions
+	}
+	if s.completions < 0 {
+		return s.count("succeeded") > 0 && len(s.active) == 0
+	}
+	return s.count("succeeded") >= s.completions
+}
+
+// manageJobPods creates the pods the Job is missing, waiting out the backoff after failures,
+// and deletes active pods beyond what it needs
+func (jc *JobController) manageJobPods(s *jobSync) (time.Duration, error) {
+	var create []int // completion indexes; -1 for NonIndexed pods
+	var excess []map[string]interface{}
+	// With podReplacementPolicy Failed a terminating pod is only replaced once it has failed
+	busy := int64(len(s.active))
+	if policy, _ := resources.NestedString(s.job, "spec", "podReplacementPolicy"); policy == "Failed" {
+		busy += s.terminating
+	}
+
+	if s.indexed {
+		create, excess = s.indexesToRun(busy)
+	} else {
+		want := s.parallelism
+		if s.completions >= 0 {
+			want = min(want, s.completions-s.count("succeeded"))
+		} else if s.count("succeeded") > 0 {
+			want = 0
+		}
+		if busy > want && len(s.active) > 0 {
+			excess = podsToDelete(s.active, nil, int(min(busy-want, int64(len(s.active)))))
+		}
+		for i := busy; i < want; i++ {
+			create = append(create, -1)
+		}
+	}
+
+	err := jc.deleteJobPods(s, excess)
+	if len(create) == 0 || err != nil {
+		return 0, err
+	}
+	jc.mu.Lock()
+	lastFailure, ok := jc.lastFailure[s.key]
+	jc.mu.Unlock()
+	if ok {
+		if wait := lastFailure.Add(jobBackoff(jc.BackoffBase, jc.MaxBackoff, s.count("failed"))).Sub(s.now); wait > 0 {
+			return wait, nil
+		}
+	}
+
+	var errs []error
+	attempt := int(s.count("failed"))
+	for _, index := range create {
+		pod := newJobPod(s.job, index, attempt)
+		if err := createPod(jc.store, &pod); err != nil {
+			errs = append(errs, fmt.Errorf("failed to create pod for Job %s: %w", s.name, err))
+			continue
+		}
+		fmt.Printf("[Job Controller] Created pod %s for Job %s\n", pod.GetName(), s.name)
+		if created, err := jc.store.GetPod(pod.GetName()); err == nil {
+			s.active = append(s.active, created)
+		}
+	}
+	return 0, errors.Join(errs...)
+}
+
+// indexesToRun returns the pending indexes of an Indexed Job to start pods for (lowest first,
+// up to the parallelism) and the active pods to delete: duplicates of an index, pods of
+// completed or out of range indexes and, lowest indexes kept, pods beyond the parallelism.
+func (s *jobSync) indexesToRun(busy int64) ([]int, []map[string]interface{}) {
+	capacity := s.parallelism - (busy - int64(len(s.active)))
+	var excess []map[string]interface{}
+	running := map[int]bool{}
+	for _, pod := range sortedByIndex(s.active) {
+		index := podCompletionIndex(pod)
+		if index < 0 || int64(index) >= s.completions || s.completed[index] || running[index] || int64(len(running)) >= capacity {
+			excess = append(excess, pod)
+			continue
+		}
+		running[index] = true
+	}
+	var create []int
+	for index := 0; int64(index) < s.completions && int64(len(running)+len(create)) < capacity; index++ {
+		if !s.completed[index] && !running[index] {
+			create = append(create, index)
+		}
+	}
+	return create, excess
+}
+
+// sortedByIndex returns pods ordered by completion index, oldest first within an index
+func sortedByIndex(pods []map[string]interface{}) []map[string]interface{} {
+	sorted := append([]map[string]interface{}(nil), pods...)
+	daemonPodsByAge(sorted)
+	byIndex := map[int][]map[string]interface{}{}
+	var indexes []int
+	for _, pod := range sorted {
+		index := podCompletionIndex(pod)
+		if _, ok := byIndex[index]; !ok {
+			indexes = append(indexes, index)
+		}
+		byIndex[index] = append(byIndex[index], pod)
+	}
+	sort.Ints(indexes)
+	sorted = sorted[:0]
+	for _, index := range indexes {
+		sorted = append(sorted, byIndex[index]...)
+	}
+	return sorted
+}
+
+// deleteJobPods deletes active pods the Job doesn't need. They are let go of first: a pod
+// the Job deleted itself doesn't count as failed.
+func (jc *JobController) deleteJobPods(s *jobSync, pods []map[string]interface{}) error {
+	var errs []error
+	deleted := map[string]bool{}
+	for _, pod := range pods {
+		podName, _ := resources.NestedString(pod, "metadata", "name")
+		fmt.Printf("[Job Controller] Deleting pod %s of Job %s\n", podName, s.name)
+		if err := jc.removeTrackingFinalizer(podName); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := deletePodObject(jc.store, podName); err != nil && !jc.podGone(podName) {
+			errs = append(errs, err)
+			continue
+		}
+		deleted[podName] = true
+	}
+	var active []map[string]interface{}
+	for _, pod := range s.active {
+		podName, _ := resources.NestedString(pod, "metadata", "name")
+		if deleted[podName] {
+			s.terminating++
+		} else {
+			active = append(active, pod)
+		}
+	}
+	s.active = active
+	return errors.Join(errs...)
+}
+
+// DefaultJobController is the singleton instance
+var DefaultJobController *JobController
+
+// InitJobController initializes the default Job controller
+func InitJobController(store *storage.InMemoryStore) {
+	DefaultJobController = NewJobController(store, sharedInformers(store))
+	DefaultJobController.Start()
+}
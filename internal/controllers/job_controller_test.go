@@ -0,0 +1,240 @@
+package controllers
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+// newTestJob returns a defaulted Job whose pods run for 30ms and exit with exitCodes
+// (comma separated, per attempt); spec fields are merged into the Job spec
+func newTestJob(name, exitCodes string, spec map[string]interface{}) *resources.Job {
+	jobSpec := map[string]interface{}{
+		"template": map[string]interface{}{
+			"metadata": map[string]interface{}{"annotations": map[string]interface{}{
+				RunDurationAnnotation: "30ms",
+				ExitCodeAnnotation:    exitCodes,
+			}},
+			"spec": map[string]interface{}{
+				"restartPolicy": "Never",
+				"containers":    []interface{}{map[string]interface{}{"name": "task", "image": "task:v1"}},
+			},
+		},
+	}
+	for k, v := range spec {
+		jobSpec[k] = v
+	}
+	job := &resources.Job{
+		Kind:       "Job",
+		APIVersion: "batch/v1",
+		Metadata:   resources.ObjectMeta{Name: name, Namespace: "default", UID: storage.NewUID()},
+		Spec:       jobSpec,
+	}
+	resources.SetJobDefaults(job)
+	return job
+}
+
+func startJobController(t *testing.T, store *storage.InMemoryStore) *JobController {
+	controller := NewJobController(store, startInformers(t, store))
+	controller.BackoffBase = 10 * time.Millisecond
+	controller.Start()
+	t.Cleanup(controller.Stop)
+	return controller
+}
+
+func jobStatus(store *storage.InMemoryStore, name string) map[string]interface{} {
+	job, _ := store.GetJob(name)
+	status, _ := job["status"].(map[string]interface{})
+	return status
+}
+
+// waitForJobCondition waits for a True condition of the Job and returns its reason
+func waitForJobCondition(t *testing.T, store *storage.InMemoryStore, name, condType string) string {
+	t.Helper()
+	waitFor(t, 5*time.Second, name+" "+condType, func() bool {
+		return jobCondition(jobStatus(store, name), condType) == "True"
+	})
+	reason, _ := deploymentCondition(jobStatus(store, name), condType)["reason"].(string)
+	return reason
+}
+
+func TestJobRunsCompletionsWithParallelism(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	usePodController(t, store)
+	startJobController(t, store)
+
+	store.CreateJob(newTestJob("batch", "0", map[string]interface{}{"completions": float64(5), "parallelism": float64(2)}))
+	waitFor(t, 5*time.Second, "job complete", func() bool {
+		active := 0
+		for _, item := range store.ListPods() {
+			if isPodActive(item.(map[string]interface{})) {
+				active++
+			}
+		}
+		if active > 2 {
+			t.Fatalf("Expected at most 2 running pods, got %d", active)
+		}
+		return jobCondition(jobStatus(store, "batch"), "Complete") == "True"
+	})
+
+	status := jobStatus(store, "batch")
+	if succeeded, _ := resources.NestedInt64(status, "succeeded"); succeeded != 5 {
+		t.Errorf("Expected 5 succeeded pods, got %d", succeeded)
+	}
+	if _, ok := status["completionTime"]; !ok {
+		t.Error("Expected completionTime to be set")
+	}
+	for _, item := range store.ListPods() {
+		pod := item.(map[string]interface{})
+		if hasFinalizer(pod, JobTrackingFinalizer) {
+			t.Errorf("Expected the tracking finalizer to be removed from counted pods, got %v", pod["metadata"])
+		}
+		statuses, _ := resources.NestedSlice(pod, "status", "containerStatuses")
+		if _, ok := resources.NestedInt64(statuses[0].(map[string]interface{}), "state", "terminated", "exitCode"); !ok {
+			t.Errorf("Expected the container to have terminated, got %v", statuses[0])
+		}
+	}
+}
+
+func TestJobBackoffLimit(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	usePodController(t, store)
+	startJobController(t, store)
+
+	// Fails on every attempt: 1 pod + 2 retries
+	store.CreateJob(newTestJob("flaky", "1", map[string]interface{}{"backoffLimit": float64(2)}))
+	if reason := waitForJobCondition(t, store, "flaky", "Failed"); reason != JobReasonBackoffLimitExceeded {
+		t.Errorf("Expected reason %s, got %s", JobReasonBackoffLimitExceeded, reason)
+	}
+	if failed, _ := resources.NestedInt64(jobStatus(store, "flaky"), "failed"); failed != 3 {
+		t.Errorf("Expected 3 failed pods, got %d", failed)
+	}
+
+	// Succeeds on its third pod
+	store.CreateJob(newTestJob("retry", "1,1,0", nil))
+	waitForJobCondition(t, store, "retry", "Complete")
+	status := jobStatus(store, "retry")
+	failed, _ := resources.NestedInt64(status, "failed")
+	succeeded, _ := resources.NestedInt64(status, "succeeded")
+	if failed != 2 || succeeded != 1 {
+		t.Errorf("Expected 2 failed and 1 succeeded pods, got %d and %d", failed, succeeded)
+	}
+
+	// With restartPolicy OnFailure the container restarts count towards the limit
+	onFailure := newTestJob("restarts", "1", map[string]interface{}{"backoffLimit": float64(1)})
+	template, _ := resources.NestedMap(onFailure.Spec.(map[string]interface{}), "template", "spec")
+	template["restartPolicy"] = "OnFailure"
+	store.CreateJob(onFailure)
+	if reason := waitForJobCondition(t, store, "restarts", "Failed"); reason != JobReasonBackoffLimitExceeded {
+		t.Errorf("Expected reason %s, got %s", JobReasonBackoffLimitExceeded, reason)
+	}
+}
+
+func TestIndexedJob(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	usePodController(t, store)
+	startJobController(t, store)
+
+	store.CreateJob(newTestJob("shards", "0", map[string]interface{}{
+		"completions": float64(4), "parallelism": float64(2), "completionMode": "Indexed",
+	}))
+	waitForJobCondition(t, store, "shards", "Complete")
+	if indexes, _ := resources.NestedString(jobStatus(store, "shards"), "completedIndexes"); indexes != "0-3" {
+		t.Errorf("Expected completedIndexes 0-3, got %q", indexes)
+	}
+	seen := map[int]bool{}
+	for _, item := range store.ListPods() {
+		pod := item.(map[string]interface{})
+		index := podCompletionIndex(pod)
+		seen[index] = true
+		hostname, _ := resources.NestedString(pod, "spec", "hostname")
+		if want := "shards-" + strconv.Itoa(index); hostname != want {
+			t.Errorf("Expected hostname %s, got %s", want, hostname)
+		}
+	}
+	if len(seen) != 4 {
+		t.Errorf("Expected one pod per index, got %v", seen)
+	}
+}
+
+func TestJobPodFailurePolicy(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	usePodController(t, store)
+	startJobController(t, store)
+
+	rules := map[string]interface{}{"rules": []interface{}{
+		map[string]interface{}{
+			"action":      PodFailurePolicyActionFailJob,
+			"onExitCodes": map[string]interface{}{"operator": "In", "values": []interface{}{float64(42)}},
+		},
+		map[string]interface{}{
+			"action":          PodFailurePolicyActionIgnore,
+			"onPodConditions": []interface{}{map[string]interface{}{"type": "DisruptionTarget"}},
+		},
+	}}
+
+	// A FailJob rule fails the Job on the first matching failure, whatever the backoffLimit
+	store.CreateJob(newTestJob("fatal", "42", map[string]interface{}{"podFailurePolicy": rules}))
+	if reason := waitForJobCondition(t, store, "fatal", "Failed"); reason != JobReasonPodFailurePolicy {
+		t.Errorf("Expected reason %s, got %s", JobReasonPodFailurePolicy, reason)
+	}
+
+	// An Ignore rule replaces the pod without counting the failure
+	job := newTestJob("evicted", "0", map[string]interface{}{"podFailurePolicy": rules})
+	annotations, _ := resources.NestedMap(job.Spec.(map[string]interface{}), "template", "metadata", "annotations")
+	annotations[RunDurationAnnotation] = "1m"
+	store.CreateJob(job)
+	var first string
+	waitFor(t, 3*time.Second, "a running pod", func() bool {
+		for _, item := range store.ListPods() {
+			if phase, _ := resources.NestedString(item.(map[string]interface{}), "status", "phase"); phase == string(PodRunning) {
+				first, _ = resources.NestedString(item.(map[string]interface{}), "metadata", "name")
+				return true
+			}
+		}
+		return false
+	})
+	store.MutatePod(first, func(pod map[string]interface{}) error {
+		status := pod["status"].(map[string]interface{})
+		status["phase"] = string(PodFailed)
+		status["conditions"] = append(status["conditions"].([]interface{}), map[string]interface{}{
+			"type": "DisruptionTarget", "status": "True", "reason": "EvictionByEvictionAPI",
+		})
+		return nil
+	})
+	waitFor(t, 3*time.Second, "a replacement pod", func() bool {
+		active, _ := resources.NestedInt64(jobStatus(store, "evicted"), "active")
+		return active == 1 && len(store.ListPods()) == 3
+	})
+	if failed, _ := resources.NestedInt64(jobStatus(store, "evicted"), "failed"); failed != 0 {
+		t.Errorf("Expected the ignored failure not to be counted, got failed=%d", failed)
+	}
+}
+
+func TestJobSuspendAndTTL(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	usePodController(t, store)
+	startJobController(t, store)
+
+	store.CreateJob(newTestJob("later", "0", map[string]interface{}{"suspend": true, "ttlSecondsAfterFinished": float64(0)}))
+	if reason := waitForJobCondition(t, store, "later", "Suspended"); reason != JobReasonSuspended {
+		t.Errorf("Expected reason %s, got %s", JobReasonSuspended, reason)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n := len(store.ListPods()); n != 0 {
+		t.Fatalf("Expected a suspended Job to run no pods, got %d", n)
+	}
+
+	// Resumed, it runs to completion and is then deleted right away
+	store.MutateJob("later", func(job map[string]interface{}) error {
+		job["spec"].(map[string]interface{})["suspend"] = false
+		return nil
+	})
+	waitFor(t, 5*time.Second, "job deleted after finishing", func() bool {
+		_, err := store.GetJob("later")
+		return err != nil
+	})
+}
@@ -0,0 +1,261 @@
+package controllers
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"mockernetes/internal/resources"
+)
+
+// Job pods: stamped from the Job's template and held by the tracking finalizer until the Job
+// has counted them, so a finished pod that gets deleted is never lost from status.succeeded
+// or status.failed. Indexed Jobs give every pod a completion index.
+
+// Finalizer, annotation and env var the Job controller puts on its pods.
+const (
+	JobTrackingFinalizer         = "batch.kubernetes.io/job-tracking"
+	JobCompletionIndexAnnotation = "batch.kubernetes.io/job-completion-index"
+	JobCompletionIndexEnv        = "JOB_COMPLETION_INDEX"
+)
+
+// Pod failure policy actions (spec.podFailurePolicy.rules[].action)
+const (
+	PodFailurePolicyActionFailJob   = "FailJob"
+	PodFailurePolicyActionFailIndex = "FailIndex"
+	PodFailurePolicyActionIgnore    = "Ignore"
+	PodFailurePolicyActionCount     = "Count"
+)
+
+// newJobPod builds a pod of the Job. index is the completion index (-1 for NonIndexed Jobs)
+// and attempt the number of failed pods so far, which the simulated exit codes are picked by.
+func newJobPod(job map[string]interface{}, index, attempt int) resources.Pod {
+	name, _ := resources.NestedString(job, "metadata", "name")
+	template, _ := resources.NestedMap(job, "spec", "template")
+	var extraLabels map[string]string
+	if index >= 0 {
+		extraLabels = map[string]string{JobCompletionIndexAnnotation: strconv.Itoa(index)}
+	}
+	pod := newPodFromTemplate(job, "Job", template, extraLabels)
+	pod.Metadata.Finalizers = []string{JobTrackingFinalizer}
+	if pod.Metadata.Annotations == nil {
+		pod.Metadata.Annotations = map[string]string{}
+	}
+	pod.Metadata.Annotations[AttemptAnnotation] = strconv.Itoa(attempt)
+	if index < 0 {
+		return pod
+	}
+
+	pod.Metadata.Annotations[JobCompletionIndexAnnotation] = strconv.Itoa(index)
+	pod.Metadata.GenerateName = fmt.Sprintf("%s-%d-", name, index)
+	spec, _ := pod.Spec.(map[string]interface{})
+	if spec == nil {
+		return pod
+	}
+	if _, ok := spec["hostname"]; !ok {
+		spec["hostname"] = fmt.Sprintf("%s-%d", name, index)
+	}
+	containers, _ := resources.NestedSlice(spec, "containers")
+	for _, c := range containers {
+		container, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		env, _ := resources.NestedSlice(container, "env")
+		container["env"] = append(env, map[string]interface{}{
+			"name": JobCompletionIndexEnv,
+			"valueFrom": map[string]interface{}{"fieldRef": map[string]interface{}{
+				"fieldPath": fmt.Sprintf("metadata.annotations['%s']", JobCompletionIndexAnnotation),
+			}},
+		})
+	}
+	return pod
+}
+
+// podCompletionIndex returns the completion index of an Indexed Job's pod (-1 if it has none)
+func podCompletionIndex(pod map[string]interface{}) int {
+	value, ok := resources.NestedString(pod, "metadata", "annotations", JobCompletionIndexAnnotation)
+	if !ok {
+		return -1
+	}
+	index, err := strconv.Atoi(value)
+	if err != nil || index < 0 {
+		return -1
+	}
+	return index
+}
+
+// parseIndexes parses status.completedIndexes ("1,3-5,7") into a set
+func parseIndexes(s string) map[int]bool {
+	set := map[int]bool{}
+	for _, part := range strings.Split(s, ",") {
+		first, last, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		from, err := strconv.Atoi(first)
+		if err != nil {
+			continue
+		}
+		to := from
+		if isRange {
+			if to, err = strconv.Atoi(last); err != nil {
+				continue
+			}
+		}
+		for i := from; i <= to; i++ {
+			set[i] = true
+		}
+	}
+	return set
+}
+
+// formatIndexes formats a set of indexes the way status.completedIndexes shows them,
+// with consecutive indexes as ranges
+func formatIndexes(set map[int]bool) string {
+	indexes := make([]int, 0, len(set))
+	for i := range set {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	var parts []string
+	for i := 0; i < len(indexes); {
+		j := i
+		for j+1 < len(indexes) && indexes[j+1] == indexes[j]+1 {
+			j++
+		}
+		if j == i {
+			parts = append(parts, strconv.Itoa(indexes[i]))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", indexes[i], indexes[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
+
+// matchPodFailurePolicy returns the action of the first spec.podFailurePolicy rule a failed pod
+// matches, with a message saying why (matched=false if none does). Rules match on non-zero
+// container exit codes (onExitCodes) or on pod conditions (onPodConditions).
+func matchPodFailurePolicy(job, pod map[string]interface{}) (action, message string, matched bool) {
+	rules, _ := resources.NestedSlice(job, "spec", "podFailurePolicy", "rules")
+	podName, _ := resources.NestedString(pod, "metadata", "name")
+	for i, r := range rules {
+		rule, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		action, _ = rule["action"].(string)
+		if onExitCodes, ok := rule["onExitCodes"].(map[string]interface{}); ok {
+			if container, code, ok := matchExitCodes(onExitCodes, pod); ok {
+				return action, fmt.Sprintf("Container %s for pod %s/%s failed with exit code %d matching %s rule at index %d",
+					container, namespaceOf(pod), podName, code, action, i), true
+			}
+			continue
+		}
+		patterns, _ := resources.NestedSlice(rule, "onPodConditions")
+		for _, p := range patterns {
+			pattern, ok := p.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			condType, _ := pattern["type"].(string)
+			condStatus, _ := pattern["status"].(string)
+			if condStatus == "" {
+				condStatus = "True"
+			}
+			if cond := podCondition(pod, condType); cond != nil && cond["status"] == condStatus {
+				return action, fmt.Sprintf("Pod %s/%s has condition %s matching %s rule at index %d",
+					namespaceOf(pod), podName, condType, action, i), true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// matchExitCodes returns the first terminated container (of onExitCodes.containerName, if
+// set) whose non-zero exit code is In or NotIn the requirement's values
+func matchExitCodes(requirement, pod map[string]interface{}) (string, int64, bool) {
+	containerName, _ := requirement["containerName"].(string)
+	operator, _ := requirement["operator"].(string)
+	rawValues, _ := requirement["values"].([]interface{})
+	var values []int64
+	for _, v := range rawValues {
+		if n, ok := resources.ToInt64(v); ok {
+			values = append(values, n)
+		}
+	}
+	for _, field := range []string{"initContainerStatuses", "containerStatuses"} {
+		statuses, _ := resources.NestedSlice(pod, "status", field)
+		for _, cs := range statuses {
+			containerStatus, ok := cs.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _ := containerStatus["name"].(string)
+			if containerName != "" && name != containerName {
+				continue
+			}
+			terminated, ok := resources.NestedMap(containerStatus, "state", "terminated")
+			if !ok {
+				continue
+			}
+			code, _ := resources.ToInt64(terminated["exitCode"])
+			if code == 0 {
+				continue
+			}
+			if in := slices.Contains(values, code); (operator == "In") == in {
+				return name, code, true
+			}
+		}
+	}
+	return "", 0, false
+}
+
+// podRestarts returns the total restart count of a pod's containers
+func podRestarts(pod map[string]interface{}) int64 {
+	var total int64
+	statuses, _ := resources.NestedSlice(pod, "status", "containerStatuses")
+	for _, cs := range statuses {
+		if containerStatus, ok := cs.(map[string]interface{}); ok {
+			restarts, _ := resources.ToInt64(containerStatus["restartCount"])
+			total += restarts
+		}
+	}
+	return total
+}
+
+// jobCondition returns the status of a Job condition ("" if the Job doesn't have it)
+func jobCondition(status map[string]interface{}, condType string) string {
+	cond := deploymentCondition(status, condType)
+	if cond == nil {
+		return ""
+	}
+	condStatus, _ := cond["status"].(string)
+	return condStatus
+}
+
+// jobFinishTime returns when a finished Job completed or failed (zero if it hasn't)
+func jobFinishTime(status map[string]interface{}) time.Time {
+	var finished string
+	if jobCondition(status, "Complete") == "True" {
+		finished, _ = resources.NestedString(status, "completionTime")
+	} else if jobCondition(status, "Failed") == "True" {
+		finished, _ = deploymentCondition(status, "Failed")["lastTransitionTime"].(string)
+	}
+	t, _ := time.Parse(time.RFC3339, finished)
+	return t
+}
+
+// jobBackoff returns how long after its last pod failure a Job waits before creating pods
+// again: base doubled for every failure after the first, at most max
+func jobBackoff(base, max time.Duration, failures int64) time.Duration {
+	if failures <= 0 || base <= 0 {
+		return 0
+	}
+	delay := base
+	for i := int64(1); i < failures && delay < max; i++ {
+		delay *= 2
+	}
+	return min(delay, max)
+}
@@ -0,0 +1,272 @@
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+// NamespaceFinalizer is the spec.finalizers entry owned by the namespace controller.
+const NamespaceFinalizer = "kubernetes"
+
+// namespaceResyncDelay is how soon a Terminating namespace is checked again while content remains
+// (content removal also requeues it through events; this covers finalizers cleared elsewhere).
+const namespaceResyncDelay = time.Second
+
+// Namespace deletion condition types (k8s.io/api/core/v1).
+const (
+	NamespaceDeletionDiscoveryFailure = "NamespaceDeletionDiscoveryFailure"
+	NamespaceDeletionContentFailure   = "NamespaceDeletionContentFailure"
+	NamespaceDeletionGVParsingFailure = "NamespaceDeletionGroupVersionParsingFailure"
+	NamespaceContentRemaining         = "NamespaceContentRemaining"
+	NamespaceFinalizersRemaining      = "NamespaceFinalizersRemaining"
+)
+
+// NamespaceController finalizes namespaces marked for deletion: the namespace is moved to
+// Terminating, every namespaced object in it is deleted, progress is reported through the
+// upstream status conditions, and the "kubernetes" finalizer is removed once it is empty
+// (after which storage drops the namespace).
+type NamespaceController struct {
+	store *storage.InMemoryStore
+	queue *RateLimitingQueue
+}
+
+// NewNamespaceController creates a new NamespaceController fed by the given informers
+func NewNamespaceController(store *storage.InMemoryStore, informers *SharedInformerFactory) *NamespaceController {
+	nc := &NamespaceController{
+		store: store,
+		queue: NewRateLimitingQueue(),
+	}
+	informers.AddEventHandler("Namespace", ResourceEventHandlerFuncs{
+		AddFunc:    nc.enqueueIfDeleting,
+		UpdateFunc: func(_, ns map[string]interface{}) { nc.enqueueIfDeleting(ns) },
+	})
+	// Content going away moves a Terminating namespace along
+	for _, kind := range store.NamespacedKinds() {
+		informers.AddEventHandler(kind, ResourceEventHandlerFuncs{
+			UpdateFunc: func(_, obj map[string]interface{}) {
+				if isBeingDeleted(obj) {
+					nc.enqueueNamespaceOf(obj)
+				}
+			},
+			DeleteFunc: nc.enqueueNamespaceOf,
+		})
+	}
+	return nc
+}
+
+// Start starts the controller's workers
+func (nc *NamespaceController) Start() {
+	runWorkers(nc.queue, DefaultWorkers, "Namespace Controller", nc.syncNamespace)
+}
+
+// Stop stops the controller
+func (nc *NamespaceController) Stop() {
+	nc.queue.ShutDownAndWait()
+}
+
+// enqueueIfDeleting queues a namespace that has been marked for deletion
+func (nc *NamespaceController) enqueueIfDeleting(ns map[string]interface{}) {
+	if isBeingDeleted(ns) {
+		nc.queue.Add(objectKey(ns))
+	}
+}
+
+// enqueueNamespaceOf queues the namespace of a namespaced object if it is Terminating
+func (nc *NamespaceController) enqueueNamespaceOf(obj map[string]interface{}) {
+	namespace, _ := splitKey(objectKey(obj))
+	if ns, err := nc.store.GetNamespace(namespace); err == nil && isBeingDeleted(ns) {
+		nc.queue.Add(namespace)
+	}
+}
+
+// syncNamespace finalizes a Terminating namespace, checking back while content remains
+func (nc *NamespaceController) syncNamespace(key string) error {
+	finalized, err := nc.finalizeNamespace(key)
+	if err != nil {
+		return err
+	}
+	if !finalized {
+		if ns, err := nc.store.GetNamespace(key); err == nil && isBeingDeleted(ns) {
+			nc.queue.AddAfter(key, namespaceResyncDelay)
+		}
+	}
+	return nil
+}
+
+// finalizeNamespace deletes the content of a Terminating namespace and removes the
+// "kubernetes" finalizer once nothing is left. Reports whether the namespace was finalized.
+func (nc *NamespaceController) finalizeNamespace(nsName string) (bool, error) {
+	ns, err := nc.store.GetNamespace(nsName)
+	if err != nil || !isBeingDeleted(ns) {
+		return false, err
+	}
+
+	deleteErrs := nc.deleteContent(nsName)
+	remaining, finalizers := nc.remainingContent(nsName)
+	empty := len(remaining) == 0
+
+	_, err = nc.store.MutateNamespace(nsName, func(ns map[string]interface{}) error {
+		status, _ := ns["status"].(map[string]interface{})
+		if status == nil {
+			status = map[string]interface{}{}
+			ns["status"] = status
+		}
+		status["phase"] = "Terminating"
+		status["conditions"] = namespaceDeletionConditions(status, deleteErrs, remaining, finalizers)
+		if empty && removeFinalizer(ns, NamespaceFinalizer, "spec", "finalizers") {
+			fmt.Printf("[Namespace Controller] Namespace %s is empty, removed %q finalizer\n", nsName, NamespaceFinalizer)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return empty, nil
+}
+
+// deleteContent deletes every namespaced object in the namespace that isn't already being deleted
+func (nc *NamespaceController) deleteContent(nsName string) []string {
+	var errs []string
+	for _, kind := range nc.store.NamespacedKinds() {
+		for _, obj := range nc.objectsInNamespace(kind, nsName) {
+			if isBeingDeleted(obj) {
+				continue
+			}
+			name, _ := resources.NestedString(obj, "metadata", "name")
+			fmt.Printf("[Namespace Controller] Deleting %s %s/%s\n", kind, nsName, name)
+			var err error
+			if kind == "Pod" {
+				err = deletePodObject(nc.store, name)
+			} else {
+				err = nc.store.DeleteObject(kind, name)
+			}
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("failed to delete %s %s: %v", storage.ResourceName(kind), name, err))
+			}
+		}
+	}
+	return errs
+}
+
+// remainingContent counts the objects still in the namespace per resource, and the
+// finalizers holding them
+func (nc *NamespaceController) remainingContent(nsName string) (map[string]int, map[string]int) {
+	remaining := make(map[string]int)
+	finalizers := make(map[string]int)
+	for _, kind := range nc.store.NamespacedKinds() {
+		for _, obj := range nc.objectsInNamespace(kind, nsName) {
+			remaining[storage.ResourceName(kind)]++
+			list, _ := resources.NestedSlice(obj, "metadata", "finalizers")
+			for _, f := range list {
+				if s, ok := f.(string); ok {
+					finalizers[s]++
+				}
+			}
+		}
+	}
+	return remaining, finalizers
+}
+
+// objectsInNamespace lists the objects of kind in the namespace (unset namespace means "default")
+func (nc *NamespaceController) objectsInNamespace(kind, nsName string) []map[string]interface{} {
+	var objs []map[string]interface{}
+	for _, item := range nc.store.ListObjects(kind) {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		objNS, _ := resources.NestedString(obj, "metadata", "namespace")
+		if objNS == "" {
+			objNS = "default"
+		}
+		if objNS == nsName {
+			objs = append(objs, obj)
+		}
+	}
+	return objs
+}
+
+// namespaceDeletionConditions builds the status conditions reported while a namespace terminates,
+// keeping lastTransitionTime for conditions whose status didn't change
+func namespaceDeletionConditions(status map[string]interface{}, deleteErrs []string, remaining, finalizers map[string]int) []interface{} {
+	previous := make(map[string]map[string]interface{})
+	if existing, ok := resources.NestedSlice(status, "conditions"); ok {
+		for _, c := range existing {
+			if cond, ok := c.(map[string]interface{}); ok {
+				condType, _ := cond["type"].(string)
+				previous[condType] = cond
+			}
+		}
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	condition := func(condType, condStatus, reason, message string) interface{} {
+		transition := now
+		if prev, ok := previous[condType]; ok && prev["status"] == condStatus {
+			if t, ok := prev["lastTransitionTime"].(string); ok {
+				transition = t
+			}
+		}
+		return map[string]interface{}{
+			"type":               condType,
+			"status":             condStatus,
+			"lastTransitionTime": transition,
+			"reason":             reason,
+			"message":            message,
+		}
+	}
+
+	conditions := []interface{}{
+		condition(NamespaceDeletionDiscoveryFailure, "False", "ResourcesDiscovered", "All resources successfully discovered"),
+		condition(NamespaceDeletionGVParsingFailure, "False", "ParsedGroupVersions", "All legacy kube types successfully parsed"),
+	}
+	if len(deleteErrs) > 0 {
+		conditions = append(conditions, condition(NamespaceDeletionContentFailure, "True", "ContentDeletionFailed",
+			"Failed to delete all resource types, "+strings.Join(deleteErrs, ", ")))
+	} else {
+		conditions = append(conditions, condition(NamespaceDeletionContentFailure, "False", "ContentDeleted",
+			"All content successfully deleted, may be waiting on finalization"))
+	}
+	if len(remaining) > 0 {
+		conditions = append(conditions, condition(NamespaceContentRemaining, "True", "SomeResourcesRemain",
+			"Some resources are remaining: "+countsMessage(remaining, "%s has %d resource instances")))
+	} else {
+		conditions = append(conditions, condition(NamespaceContentRemaining, "False", "ContentRemoved",
+			"All content successfully removed"))
+	}
+	if len(finalizers) > 0 {
+		conditions = append(conditions, condition(NamespaceFinalizersRemaining, "True", "SomeFinalizersRemain",
+			"Some content in the namespace has finalizers remaining: "+countsMessage(finalizers, "%s in %d resource instances")))
+	} else {
+		conditions = append(conditions, condition(NamespaceFinalizersRemaining, "False", "ContentHasNoFinalizers",
+			"All content-preserving finalizers finished"))
+	}
+	return conditions
+}
+
+// countsMessage formats name->count pairs sorted by name, e.g. "pods has 2 resource instances"
+func countsMessage(counts map[string]int, format string) string {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf(format, name, counts[name]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// DefaultNamespaceController is the singleton instance
+var DefaultNamespaceController *NamespaceController
+
+// InitNamespaceController initializes the default Namespace controller
+func InitNamespaceController(store *storage.InMemoryStore) {
+	DefaultNamespaceController = NewNamespaceController(store, sharedInformers(store))
+	DefaultNamespaceController.Start()
+}
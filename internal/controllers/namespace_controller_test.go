@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+func TestFinalizerBlocksDeletion(t *testing.T) {
+	store := storage.NewInMemoryStore()
+
+	cm := resources.ConfigMap{
+		Kind:       "ConfigMap",
+		APIVersion: "v1",
+		Metadata: resources.ObjectMeta{
+			Name:       "guarded",
+			Namespace:  "default",
+			Finalizers: []string{"example.com/cleanup"},
+		},
+	}
+	if err := store.CreateConfigMap(cm); err != nil {
+		t.Fatalf("Failed to create configmap: %v", err)
+	}
+
+	// Delete only marks the object while the finalizer is present
+	if err := store.DeleteConfigMap("guarded"); err != nil {
+		t.Fatalf("Failed to delete configmap: %v", err)
+	}
+	stored, err := store.GetConfigMap("guarded")
+	if err != nil {
+		t.Fatalf("Expected configmap to remain until finalized: %v", err)
+	}
+	if !isBeingDeleted(stored) {
+		t.Fatalf("Expected deletionTimestamp to be set, got %v", stored["metadata"])
+	}
+
+	// An update can't clear deletionTimestamp, but dropping the last finalizer removes the object
+	cm.Metadata.Finalizers = nil
+	if err := store.UpdateConfigMap(cm); err != nil {
+		t.Fatalf("Failed to update configmap: %v", err)
+	}
+	if _, err := store.GetConfigMap("guarded"); err == nil {
+		t.Errorf("Expected configmap to be removed after its last finalizer was cleared")
+	}
+}
+
+func TestNamespaceControllerFinalize(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	controller := NewNamespaceController(store, NewSharedInformerFactory(store, 0))
+
+	ns := resources.Namespace{
+		Kind:       "Namespace",
+		APIVersion: "v1",
+		Metadata:   resources.ObjectMeta{Name: "team-a"},
+	}
+	resources.SetNamespaceDefaults(&ns)
+	if err := store.CreateNamespace(ns); err != nil {
+		t.Fatalf("Failed to create namespace: %v", err)
+	}
+
+	// Not being deleted: the finalizer is left alone
+	controller.syncNamespace("team-a")
+	if _, err := store.GetNamespace("team-a"); err != nil {
+		t.Fatalf("Expected namespace to exist: %v", err)
+	}
+
+	// The "kubernetes" spec finalizer holds the namespace until the controller removes it
+	if err := store.DeleteNamespace("team-a"); err != nil {
+		t.Fatalf("Failed to delete namespace: %v", err)
+	}
+	if _, err := store.GetNamespace("team-a"); err != nil {
+		t.Fatalf("Expected namespace to remain until finalized: %v", err)
+	}
+
+	controller.syncNamespace("team-a")
+	if _, err := store.GetNamespace("team-a"); err == nil {
+		t.Errorf("Expected namespace to be removed after finalization")
+	}
+}
+
+func TestNamespaceControllerDeletesContent(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	controller := NewNamespaceController(store, NewSharedInformerFactory(store, 0))
+
+	ns := resources.Namespace{Kind: "Namespace", APIVersion: "v1", Metadata: resources.ObjectMeta{Name: "team-b"}}
+	resources.SetNamespaceDefaults(&ns)
+	store.CreateNamespace(ns)
+	store.CreatePod(resources.Pod{Kind: "Pod", APIVersion: "v1", Metadata: resources.ObjectMeta{Name: "worker", Namespace: "team-b"}})
+	store.CreateConfigMap(resources.ConfigMap{Kind: "ConfigMap", APIVersion: "v1", Metadata: resources.ObjectMeta{
+		Name: "held", Namespace: "team-b", Finalizers: []string{"example.com/hold"},
+	}})
+	store.CreateConfigMap(resources.ConfigMap{Kind: "ConfigMap", APIVersion: "v1", Metadata: resources.ObjectMeta{Name: "other", Namespace: "default"}})
+
+	store.DeleteNamespace("team-b")
+	if finalized, err := controller.finalizeNamespace("team-b"); err != nil || finalized {
+		t.Fatalf("Expected namespace to wait for finalizers, finalized=%v err=%v", finalized, err)
+	}
+
+	if _, err := store.GetPod("worker"); err == nil {
+		t.Errorf("Expected pod in the namespace to be deleted")
+	}
+	if _, err := store.GetConfigMap("other"); err != nil {
+		t.Errorf("Expected configmap in another namespace to be kept: %v", err)
+	}
+	stored, err := store.GetNamespace("team-b")
+	if err != nil {
+		t.Fatalf("Expected namespace to remain: %v", err)
+	}
+	if phase, _ := resources.NestedString(stored, "status", "phase"); phase != "Terminating" {
+		t.Errorf("Expected phase Terminating, got %q", phase)
+	}
+	conditions, _ := resources.NestedSlice(stored, "status", "conditions")
+	found := false
+	for _, c := range conditions {
+		cond := c.(map[string]interface{})
+		if cond["type"] == NamespaceFinalizersRemaining {
+			found = true
+			if cond["status"] != "True" || !strings.Contains(cond["message"].(string), "example.com/hold in 1 resource instances") {
+				t.Errorf("Unexpected %s condition: %v", NamespaceFinalizersRemaining, cond)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("Expected a %s condition, got %v", NamespaceFinalizersRemaining, conditions)
+	}
+
+	store.MutateConfigMap("held", func(cm map[string]interface{}) error {
+		removeFinalizer(cm, "example.com/hold", "metadata", "finalizers")
+		return nil
+	})
+	if finalized, err := controller.finalizeNamespace("team-b"); err != nil || !finalized {
+		t.Fatalf("Expected namespace to be finalized, finalized=%v err=%v", finalized, err)
+	}
+	if _, err := store.GetNamespace("team-b"); err == nil {
+		t.Errorf("Expected namespace to be removed")
+	}
+}
@@ -0,0 +1,205 @@
+package controllers
+
+import (
+	"fmt"
+	"net/netip"
+	"os"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+	"sigs.k8s.io/yaml"
+)
+
+// The fake Nodes pods are scheduled to. They are registered at startup from a config file
+// (YAML or JSON) describing them, or created through the API; without a config the cluster
+// has a single default node, so pods always have somewhere to run:
+//
+//	nodes:
+//	- name: control-plane
+//	  labels: {node-role.kubernetes.io/control-plane: ""}
+//	  taints: [{key: node-role.kubernetes.io/control-plane, effect: NoSchedule}]
+//	- namePrefix: worker-        # worker-1 ... worker-3
+//	  count: 3
+//	  capacity: {cpu: "8", memory: 32Gi, pods: "110"}
+//	  labels: {topology.kubernetes.io/zone: zone-a}
+
+// NodeConfigEnv names the environment variable holding the path of the node config
+const NodeConfigEnv = "MOCKERNETES_NODE_CONFIG"
+
+// DefaultNodeName is the node registered when there is no node config
+const DefaultNodeName = "mockernetes-node"
+
+// Node address types
+const (
+	NodeInternalIP = "InternalIP"
+	NodeHostName   = "Hostname"
+)
+
+// nodeAddressPrefix is the network node InternalIPs are picked from (like a kind cluster's)
+var nodeAddressPrefix = netip.MustParsePrefix("172.18.0.0/16")
+
+// NodeConfig describes the fake nodes of the cluster
+type NodeConfig struct {
+	Nodes []NodeGroup `json:"nodes"`
+}
+
+// NodeGroup describes one node (name) or count nodes named namePrefix1, namePrefix2, ...
+// Capacity defaults to 4 cpu, 16Gi memory and 110 pods; allocatable equals capacity.
+type NodeGroup struct {
+	Name       string                   `json:"name,omitempty"`
+	NamePrefix string                   `json:"namePrefix,omitempty"`
+	Count      int                      `json:"count,omitempty"`
+	Capacity   map[string]string        `json:"capacity,omitempty"`
+	Labels     map[string]string        `json:"labels,omitempty"`
+	Taints     []map[string]interface{} `json:"taints,omitempty"`
+}
+
+// LoadNodeConfig reads and checks a node config file
+func LoadNodeConfig(path string) (*NodeConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var config NodeConfig
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return nil, fmt.Errorf("invalid node config %s: %w", path, err)
+	}
+	for i, group := range config.Nodes {
+		if (group.Name == "") == (group.NamePrefix == "") {
+			return nil, fmt.Errorf("invalid node config %s: nodes[%d]: exactly one of name and namePrefix is required", path, i)
+		}
+		if group.Name != "" && group.Count > 1 {
+			return nil, fmt.Errorf("invalid node config %s: nodes[%d]: count requires namePrefix", path, i)
+		}
+		for name, value := range group.Capacity {
+			if _, err := resource.ParseQuantity(value); err != nil {
+				return nil, fmt.Errorf("invalid node config %s: nodes[%d].capacity.%s: %w", path, i, name, err)
+			}
+		}
+		for j, taint := range group.Taints {
+			switch taint["effect"] {
+			case "NoSchedule", "PreferNoSchedule", "NoExecute":
+			default:
+				return nil, fmt.Errorf("invalid node config %s: nodes[%d].taints[%d]: unsupported effect %v", path, i, j, taint["effect"])
+			}
+		}
+	}
+	return &config, nil
+}
+
+// newConfigNodes returns the Nodes a config describes
+func (config *NodeConfig) newConfigNodes() []*resources.Node {
+	var nodes []*resources.Node
+	for _, group := range config.Nodes {
+		names := []string{group.Name}
+		if group.NamePrefix != "" {
+			names = nil
+			for i := 1; i <= max(group.Count, 1); i++ {
+				names = append(names, fmt.Sprintf("%s%d", group.NamePrefix, i))
+			}
+		}
+		for _, name := range names {
+			nodes = append(nodes, newConfigNode(name, group))
+		}
+	}
+	return nodes
+}
+
+// newConfigNode returns a Ready node of a group
+func newConfigNode(name string, group NodeGroup) *resources.Node {
+	labels := map[string]string{}
+	for k, v := range group.Labels {
+		labels[k] = v
+	}
+	spec := map[string]interface{}{}
+	if len(group.Taints) > 0 {
+		var taints []interface{}
+		deepCopyJSON(group.Taints, &taints)
+		spec["taints"] = taints
+	}
+	node := &resources.Node{
+		Kind:       "Node",
+		APIVersion: "v1",
+		Metadata:   resources.ObjectMeta{Name: name, Labels: labels},
+		Spec:       spec,
+	}
+	resources.SetNodeDefaults(node)
+	if len(group.Capacity) > 0 {
+		status := node.Status.(map[string]interface{})
+		capacity := map[string]interface{}{}
+		for k, v := range status["capacity"].(map[string]interface{}) {
+			capacity[k] = v
+		}
+		for k, v := range group.Capacity {
+			capacity[k] = v
+		}
+		status["capacity"] = capacity
+		status["allocatable"] = capacity
+	}
+	return node
+}
+
+// AssignNodeAddress gives a node without status.addresses the lowest InternalIP no other
+// node of store has, and its name as Hostname
+func AssignNodeAddress(store *storage.InMemoryStore, node *resources.Node) {
+	status, ok := node.Status.(map[string]interface{})
+	if !ok {
+		return
+	}
+	if addresses, ok := status["addresses"].([]interface{}); ok && len(addresses) > 0 {
+		return
+	}
+	used := map[string]bool{}
+	for _, item := range store.ListNodes() {
+		if other, ok := item.(map[string]interface{}); ok {
+			used[nodeAddress(other, NodeInternalIP)] = true
+		}
+	}
+	// .0.1 is the gateway
+	address := nodeAddressPrefix.Addr().Next().Next()
+	for used[address.String()] && nodeAddressPrefix.Contains(address.Next()) {
+		address = address.Next()
+	}
+	status["addresses"] = []interface{}{
+		map[string]interface{}{"type": NodeInternalIP, "address": address.String()},
+		map[string]interface{}{"type": NodeHostName, "address": node.Metadata.Name},
+	}
+}
+
+// nodeAddress returns the node's address of the given type ("" if it has none)
+func nodeAddress(node map[string]interface{}, addressType string) string {
+	addresses, _ := resources.NestedSlice(node, "status", "addresses")
+	for _, a := range addresses {
+		if address, ok := a.(map[string]interface{}); ok && address["type"] == addressType {
+			value, _ := address["address"].(string)
+			return value
+		}
+	}
+	return ""
+}
+
+// InitNodes registers the nodes of the config file at path, or the default node when path is
+// empty. Nodes that already exist are left alone.
+func InitNodes(store *storage.InMemoryStore, path string) error {
+	config := &NodeConfig{Nodes: []NodeGroup{{Name: DefaultNodeName}}}
+	if path != "" {
+		var err error
+		if config, err = LoadNodeConfig(path); err != nil {
+			return err
+		}
+	}
+	for _, node := range config.newConfigNodes() {
+		if _, err := store.GetNode(node.GetName()); err == nil {
+			continue
+		}
+		AssignNodeAddress(store, node)
+		AssignPodCIDRs(store, node)
+		if err := store.CreateNode(node); err != nil {
+			return err
+		}
+		stored, _ := store.GetNode(node.GetName())
+		fmt.Printf("[Nodes] Registered node %s (%s)\n", node.GetName(), nodeAddress(stored, NodeInternalIP))
+	}
+	return nil
+}
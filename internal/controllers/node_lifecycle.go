@@ -0,0 +1,566 @@
+package controllers
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+// NodeLifecycleController plays the kubelets of the fake nodes and the node lifecycle
+// controller that watches them (pkg/controller/nodelifecycle and the taint eviction controller
+// upstream). Every node's simulated kubelet renews the node's Lease in kube-node-lease; the
+// controller reflects its health in the Ready condition and the not-ready/unreachable taints,
+// marks the node's pods not ready, and evicts pods from NoExecute taints they don't tolerate
+// (at once, or after their tolerationSeconds). Evicted pods are deleted, so their owners
+// replace them on nodes that are still Ready.
+type NodeLifecycleController struct {
+	store     *storage.InMemoryStore
+	nodeQueue *RateLimitingQueue
+	podQueue  *RateLimitingQueue
+
+	// HeartbeatInterval is how often a kubelet renews its Lease (10s upstream)
+	HeartbeatInterval time.Duration
+	// MonitorPeriod is how often node health is checked (5s upstream)
+	MonitorPeriod time.Duration
+	// GracePeriod is how long a Lease may go unrenewed before the node is Unknown (40s upstream)
+	GracePeriod time.Duration
+
+	mu     sync.Mutex
+	health map[string]NodeHealth // simulated kubelet health by node name (missing: Ready)
+	stop   chan struct{}
+	wg     sync.WaitGroup
+	// now is the controller's clock (a fake one in tests)
+	now func() time.Time
+}
+
+// NodeHealth is the simulated health of a node's kubelet
+type NodeHealth string
+
+const (
+	// NodeHealthReady kubelets renew their Lease and report Ready
+	NodeHealthReady NodeHealth = "Ready"
+	// NodeHealthNotReady kubelets renew their Lease but report Ready=False
+	NodeHealthNotReady NodeHealth = "NotReady"
+	// NodeHealthUnreachable kubelets stop renewing their Lease; the node turns Unknown once
+	// GracePeriod has passed
+	NodeHealthUnreachable NodeHealth = "Unreachable"
+)
+
+// NodeLeaseNamespace holds the node heartbeat Leases
+const NodeLeaseNamespace = "kube-node-lease"
+
+// Components the node lifecycle reports events as
+const (
+	NodeControllerName          = "node-controller"
+	TaintEvictionControllerName = "taint-eviction-controller"
+)
+
+// Reasons of the node lifecycle's events and of the pod Ready condition it sets
+const (
+	EventReasonNodeReady            = "NodeReady"
+	EventReasonNodeNotReady         = "NodeNotReady"
+	EventReasonTaintManagerEviction = "TaintManagerEviction"
+	PodReasonNodeNotReady           = "NodeNotReady"
+)
+
+// The Ready conditions of the fake nodes
+const (
+	nodeReadyReason          = "KubeletReady"
+	nodeReadyMessage         = "kubelet is posting ready status"
+	nodeNotReadyReason       = "KubeletNotReady"
+	nodeNotReadyMessage      = "container runtime is down (simulated)"
+	nodeStatusUnknownReason  = "NodeStatusUnknown"
+	nodeStatusUnknownMessage = "Kubelet stopped posting node status."
+)
+
+// leaseTimeFormat is the MicroTime format of Lease renewTime
+const leaseTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+// nodeLeaseDurationSeconds is the leaseDurationSeconds of node Leases (40s upstream)
+const nodeLeaseDurationSeconds = 40
+
+// NewNodeLifecycleController creates a new NodeLifecycleController fed by the given informers
+func NewNodeLifecycleController(store *storage.InMemoryStore, informers *SharedInformerFactory) *NodeLifecycleController {
+	nc := &NodeLifecycleController{
+		store:             store,
+		nodeQueue:         NewRateLimitingQueue(),
+		podQueue:          NewRateLimitingQueue(),
+		HeartbeatInterval: 10 * time.Second,
+		MonitorPeriod:     5 * time.Second,
+		GracePeriod:       nodeLeaseDurationSeconds * time.Second,
+		health:            map[string]NodeHealth{},
+		stop:              make(chan struct{}),
+		now:               time.Now,
+	}
+	informers.AddEventHandler("Node", ResourceEventHandlerFuncs{
+		AddFunc: func(node map[string]interface{}) { nc.nodeQueue.Add(nodeName(node)) },
+		UpdateFunc: func(old, node map[string]interface{}) {
+			oldTaints, _ := resources.NestedSlice(old, "spec", "taints")
+			taints, _ := resources.NestedSlice(node, "spec", "taints")
+			if !reflect.DeepEqual(oldTaints, taints) {
+				nc.enqueuePodsOn(nodeName(node))
+			}
+		},
+		DeleteFunc: func(node map[string]interface{}) {
+			nc.mu.Lock()
+			delete(nc.health, nodeName(node))
+			nc.mu.Unlock()
+		},
+	})
+	informers.AddEventHandler("Pod", ResourceEventHandlerFuncs{
+		AddFunc: nc.enqueuePod,
+		UpdateFunc: func(old, pod map[string]interface{}) {
+			oldNode, _ := resources.NestedString(old, "spec", "nodeName")
+			oldTolerations, _ := resources.NestedSlice(old, "spec", "tolerations")
+			tolerations, _ := resources.NestedSlice(pod, "spec", "tolerations")
+			if newNode, _ := resources.NestedString(pod, "spec", "nodeName"); oldNode != newNode || !reflect.DeepEqual(oldTolerations, tolerations) {
+				nc.enqueuePod(pod)
+			}
+		},
+	})
+	return nc
+}
+
+// Start starts the controller's workers and the health monitor
+func (nc *NodeLifecycleController) Start() {
+	runWorkers(nc.nodeQueue, 1, "Node Lifecycle Controller", nc.syncNode)
+	runWorkers(nc.podQueue, DefaultWorkers, "Taint Eviction Controller", nc.syncPod)
+	nc.wg.Add(1)
+	go func() {
+		defer nc.wg.Done()
+		ticker := time.NewTicker(nc.MonitorPeriod)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-nc.stop:
+				return
+			case <-ticker.C:
+				for _, item := range nc.store.ListNodes() {
+					if node, ok := item.(map[string]interface{}); ok {
+						nc.nodeQueue.Add(nodeName(node))
+					}
+				}
+			}
+		}
+	}()
+}
+
+// Stop stops the controller
+func (nc *NodeLifecycleController) Stop() {
+	close(nc.stop)
+	nc.wg.Wait()
+	nc.nodeQueue.ShutDownAndWait()
+	nc.podQueue.ShutDownAndWait()
+}
+
+// SetNodeHealth sets the simulated health of a node's kubelet. NotReady and Ready show at once
+// (the kubelet posts them); Unreachable once the node's Lease is older than GracePeriod.
+func (nc *NodeLifecycleController) SetNodeHealth(name string, health NodeHealth) error {
+	switch health {
+	case NodeHealthReady, NodeHealthNotReady, NodeHealthUnreachable:
+	default:
+		return fmt.Errorf("unsupported node condition %q: must be one of Ready, NotReady, Unreachable", health)
+	}
+	if _, err := nc.store.GetNode(name); err != nil {
+		return fmt.Errorf("nodes %q not found", name)
+	}
+	nc.mu.Lock()
+	nc.health[name] = health
+	nc.mu.Unlock()
+	fmt.Printf("[Node Lifecycle Controller] Simulating %s kubelet on node %s\n", health, name)
+	nc.nodeQueue.Add(name)
+	return nil
+}
+
+// NodeHealth returns the simulated health of a node's kubelet
+func (nc *NodeLifecycleController) NodeHealth(name string) NodeHealth {
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+	if health, ok := nc.health[name]; ok {
+		return health
+	}
+	return NodeHealthReady
+}
+
+// enqueuePod queues a pod bound to a node for the taint eviction check
+func (nc *NodeLifecycleController) enqueuePod(pod map[string]interface{}) {
+	if nodeName, _ := resources.NestedString(pod, "spec", "nodeName"); nodeName != "" {
+		nc.podQueue.Add(objectKey(pod))
+	}
+}
+
+// enqueuePodsOn queues every pod bound to the node for the taint eviction check
+func (nc *NodeLifecycleController) enqueuePodsOn(node string) {
+	for _, pod := range nc.podsOn(node) {
+		nc.podQueue.Add(objectKey(pod))
+	}
+}
+
+// podsOn returns the pods bound to the node
+func (nc *NodeLifecycleController) podsOn(node string) []map[string]interface{} {
+	var pods []map[string]interface{}
+	for _, item := range nc.store.ListPods() {
+		pod, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if name, _ := resources.NestedString(pod, "spec", "nodeName"); name == node {
+			pods = append(pods, pod)
+		}
+	}
+	return pods
+}
+
+// syncNode runs the node's kubelet heartbeat, then updates its Ready condition, taints and
+// the readiness of its pods from what the heartbeats show
+func (nc *NodeLifecycleController) syncNode(name string) error {
+	node, err := nc.store.GetNode(name)
+	if err != nil || isBeingDeleted(node) {
+		return nil
+	}
+	now := nc.now()
+	health := nc.NodeHealth(name)
+
+	lastHeartbeat := nc.leaseRenewTime(name)
+	if health != NodeHealthUnreachable && now.Sub(lastHeartbeat) >= nc.HeartbeatInterval {
+		if err := nc.renewLease(node, now); err != nil {
+			return err
+		}
+		lastHeartbeat = now
+	}
+	if lastHeartbeat.IsZero() {
+		// no heartbeat yet: the grace period runs from the node's creation
+		created, _ := resources.NestedString(node, "metadata", "creationTimestamp")
+		lastHeartbeat, _ = time.Parse(time.RFC3339, created)
+	}
+
+	var condStatus, reason, message string
+	switch {
+	case now.Sub(lastHeartbeat) > nc.GracePeriod:
+		condStatus, reason, message = "Unknown", nodeStatusUnknownReason, nodeStatusUnknownMessage
+	case health == NodeHealthNotReady:
+		condStatus, reason, message = "False", nodeNotReadyReason, nodeNotReadyMessage
+	case health == NodeHealthReady:
+		condStatus, reason, message = "True", nodeReadyReason, nodeReadyMessage
+	default:
+		// unreachable within the grace period: the last posted status stands
+		return nil
+	}
+	return nc.setNodeReady(node, condStatus, reason, message, now)
+}
+
+// setNodeReady writes the node's Ready condition and the taints that go with it, and moves
+// the readiness of the node's pods along
+func (nc *NodeLifecycleController) setNodeReady(node map[string]interface{}, condStatus, reason, message string, now time.Time) error {
+	name := nodeName(node)
+	previous := "True"
+	if status, ok := resources.NestedMap(node, "status"); ok {
+		if cond := deploymentCondition(status, "Ready"); cond != nil {
+			previous, _ = cond["status"].(string)
+		}
+	}
+	stored, err := nc.store.MutateNode(name, func(node map[string]interface{}) error {
+		status, _ := node["status"].(map[string]interface{})
+		if status == nil {
+			status = map[string]interface{}{}
+			node["status"] = status
+		}
+		cond := setCondition(status, "Ready", condStatus, reason, message, now)
+		if _, ok := cond["lastHeartbeatTime"]; !ok {
+			cond["lastHeartbeatTime"] = now.UTC().Format(time.RFC3339)
+		}
+		status["conditions"] = replaceCondition(status, cond)
+
+		spec, _ := node["spec"].(map[string]interface{})
+		if spec == nil {
+			spec = map[string]interface{}{}
+			node["spec"] = spec
+		}
+		spec["taints"] = conditionTaints(nodeTaints(node), condStatus, now)
+		if len(spec["taints"].([]interface{})) == 0 {
+			delete(spec, "taints")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil
+	}
+
+	if previous != condStatus {
+		fmt.Printf("[Node Lifecycle Controller] Node %s Ready condition is now %s (%s)\n", name, condStatus, reason)
+		eventReason, component := EventReasonNodeNotReady, NodeControllerName
+		if condStatus == "True" {
+			eventReason, component = EventReasonNodeReady, "kubelet"
+		}
+		recordEvent(nc.store, stored, EventTypeNormal, eventReason,
+			fmt.Sprintf("Node %s status is now: %s", name, eventReason), component)
+	}
+	nc.setPodsReadiness(name, condStatus == "True", now)
+	return nil
+}
+
+// conditionTaints returns the node's taints with the not-ready (Ready=False) or unreachable
+// (Ready=Unknown) NoSchedule and NoExecute taints in place of the other ones. NoExecute taints
+// record when they were added; evictions count tolerationSeconds from then.
+func conditionTaints(taints []map[string]interface{}, condStatus string, now time.Time) []interface{} {
+	want := ""
+	switch condStatus {
+	case "False":
+		want = TaintNodeNotReady
+	case "Unknown":
+		want = TaintNodeUnreachable
+	}
+	result := []interface{}{}
+	have := map[string]bool{}
+	for _, taint := range taints {
+		key, _ := taint["key"].(string)
+		if key == TaintNodeNotReady || key == TaintNodeUnreachable {
+			if key != want {
+				continue
+			}
+			effect, _ := taint["effect"].(string)
+			have[effect] = true
+		}
+		result = append(result, taint)
+	}
+	if want == "" {
+		return result
+	}
+	for _, effect := range []string{"NoSchedule", "NoExecute"} {
+		if have[effect] {
+			continue
+		}
+		taint := map[string]interface{}{"key": want, "effect": effect}
+		if effect == "NoExecute" {
+			taint["timeAdded"] = now.UTC().Format(time.RFC3339)
+		}
+		result = append(result, taint)
+	}
+	return result
+}
+
+// setPodsReadiness marks the running pods of a node not ready while the node isn't Ready, and
+// ready again (the ones it marked) once it is
+func (nc *NodeLifecycleController) setPodsReadiness(node string, nodeReady bool, now time.Time) {
+	for _, pod := range nc.podsOn(node) {
+		if isBeingDeleted(pod) || isPodFinished(pod) {
+			continue
+		}
+		cond := podCondition(pod, "Ready")
+		if cond == nil {
+			continue
+		}
+		if nodeReady && (cond["status"] == "True" || cond["reason"] != PodReasonNodeNotReady) {
+			continue
+		}
+		if !nodeReady && cond["status"] != "True" {
+			continue
+		}
+		podName, _ := resources.NestedString(pod, "metadata", "name")
+		nc.store.MutatePod(podName, func(pod map[string]interface{}) error {
+			status, _ := pod["status"].(map[string]interface{})
+			if status == nil {
+				return nil
+			}
+			if nodeReady {
+				markReady(status, now)
+			} else {
+				markNotReady(status, PodReasonNodeNotReady, now)
+			}
+			return nil
+		})
+		if !nodeReady {
+			stored, err := nc.store.GetPod(podName)
+			if err == nil {
+				recordEvent(nc.store, stored, EventTypeWarning, EventReasonNodeNotReady, "Node is not ready", NodeControllerName)
+			}
+		}
+	}
+}
+
+// markReady sets the Ready and ContainersReady conditions of a pod status back to True, and
+// every running container to ready
+func markReady(status map[string]interface{}, now time.Time) {
+	conditions, _ := resources.NestedSlice(status, "conditions")
+	for _, c := range conditions {
+		cond, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if t := cond["type"]; (t == "Ready" || t == "ContainersReady") && cond["status"] != "True" {
+			cond["status"] = "True"
+			delete(cond, "reason")
+			cond["lastTransitionTime"] = now.UTC().Format(time.RFC3339)
+		}
+	}
+	containerStatuses, _ := resources.NestedSlice(status, "containerStatuses")
+	for _, cs := range containerStatuses {
+		if containerStatus, ok := cs.(map[string]interface{}); ok {
+			if _, running := resources.NestedMap(containerStatus, "state", "running"); running {
+				containerStatus["ready"] = true
+			}
+		}
+	}
+}
+
+// leaseRenewTime returns when the node's Lease was last renewed (zero if it has none)
+func (nc *NodeLifecycleController) leaseRenewTime(node string) time.Time {
+	lease, err := nc.store.GetLease(node)
+	if err != nil {
+		return time.Time{}
+	}
+	renewed, _ := resources.NestedString(lease, "spec", "renewTime")
+	t, err := time.Parse(leaseTimeFormat, renewed)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// renewLease is the kubelet heartbeat: it renews (or creates) the node's Lease
+func (nc *NodeLifecycleController) renewLease(node map[string]interface{}, now time.Time) error {
+	name := nodeName(node)
+	renewTime := now.UTC().Format(leaseTimeFormat)
+	owner := []interface{}{map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Node",
+		"name":       name,
+		"uid":        objectUID(node),
+	}}
+	_, err := nc.store.MutateLease(name, func(lease map[string]interface{}) error {
+		spec, _ := lease["spec"].(map[string]interface{})
+		if spec == nil {
+			spec = map[string]interface{}{}
+			lease["spec"] = spec
+		}
+		spec["renewTime"] = renewTime
+		if meta, ok := lease["metadata"].(map[string]interface{}); ok {
+			meta["ownerReferences"] = owner
+		}
+		return nil
+	})
+	if err == nil {
+		return nil
+	}
+	lease := resources.Lease{
+		Kind:       "Lease",
+		APIVersion: "coordination.k8s.io/v1",
+		Metadata: resources.ObjectMeta{
+			Name:      name,
+			Namespace: NodeLeaseNamespace,
+			OwnerReferences: []resources.OwnerReference{{
+				APIVersion: "v1",
+				Kind:       "Node",
+				Name:       name,
+				UID:        objectUID(node),
+			}},
+		},
+		Spec: map[string]interface{}{
+			"holderIdentity":       name,
+			"leaseDurationSeconds": nodeLeaseDurationSeconds,
+			"renewTime":            renewTime,
+		},
+	}
+	return nc.store.CreateLease(&lease)
+}
+
+// syncPod evicts the pod stored under key once the NoExecute taints of its node call for it
+func (nc *NodeLifecycleController) syncPod(key string) error {
+	_, name := splitKey(key)
+	pod, err := nc.store.GetPod(name)
+	if err != nil || isBeingDeleted(pod) || isPodFinished(pod) {
+		return nil
+	}
+	nodeName, _ := resources.NestedString(pod, "spec", "nodeName")
+	node, err := nc.store.GetNode(nodeName)
+	if err != nil {
+		return nil
+	}
+	spec, _ := pod["spec"].(map[string]interface{})
+	now := nc.now()
+	evictAt, evict := evictionTime(spec, node, now)
+	if !evict {
+		return nil
+	}
+	if wait := evictAt.Sub(now); wait > 0 {
+		// checked again then: the taint may be gone by that time
+		nc.podQueue.AddAfter(key, wait)
+		return nil
+	}
+
+	fmt.Printf("[Taint Eviction Controller] Evicting pod %s/%s from node %s\n", namespaceOf(pod), name, nodeName)
+	recordEvent(nc.store, pod, EventTypeNormal, EventReasonTaintManagerEviction,
+		fmt.Sprintf("Marking for deletion Pod %s/%s", namespaceOf(pod), name), TaintEvictionControllerName)
+	return deletePodObject(nc.store, name)
+}
+
+// evictionTime returns when a pod has to leave a node for its NoExecute taints: the zero time
+// for a taint it doesn't tolerate, else the time the first taint was added plus the shortest
+// tolerationSeconds of the tolerations that match (never, if none of them has one). Taints
+// without timeAdded count from now.
+func evictionTime(podSpec, node map[string]interface{}, now time.Time) (time.Time, bool) {
+	var taints []map[string]interface{}
+	for _, taint := range nodeTaints(node) {
+		if taint["effect"] == "NoExecute" {
+			taints = append(taints, taint)
+		}
+	}
+	if len(taints) == 0 {
+		return time.Time{}, false
+	}
+	if untoleratedTaint(podSpec, node, "NoExecute") != nil {
+		return time.Time{}, true
+	}
+
+	tolerations, _ := resources.NestedSlice(podSpec, "tolerations")
+	shortest := int64(-1)
+	for _, t := range tolerations {
+		toleration, ok := t.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, taint := range taints {
+			if !toleratesTaint(toleration, taint) {
+				continue
+			}
+			if s, ok := resources.ToInt64(toleration["tolerationSeconds"]); ok && (shortest < 0 || s < shortest) {
+				shortest = max(s, 0)
+			}
+			break
+		}
+	}
+	if shortest < 0 {
+		return time.Time{}, false
+	}
+
+	var added time.Time
+	for _, taint := range taints {
+		timeAdded, _ := taint["timeAdded"].(string)
+		if t, err := time.Parse(time.RFC3339, timeAdded); err == nil && (added.IsZero() || t.Before(added)) {
+			added = t
+		}
+	}
+	if added.IsZero() {
+		added = now
+	}
+	return added.Add(time.Duration(shortest) * time.Second), true
+}
+
+// DefaultNodeLifecycleController is the singleton instance
+var DefaultNodeLifecycleController *NodeLifecycleController
+
+// InitNodeLifecycleController initializes the default node lifecycle controller and the
+// kube-node-lease namespace its Leases live in
+func InitNodeLifecycleController(store *storage.InMemoryStore) {
+	if _, err := store.GetNamespace(NodeLeaseNamespace); err != nil {
+		ns := resources.Namespace{Kind: "Namespace", APIVersion: "v1", Metadata: resources.ObjectMeta{Name: NodeLeaseNamespace}}
+		resources.SetNamespaceDefaults(&ns)
+		store.CreateNamespace(&ns)
+	}
+	DefaultNodeLifecycleController = NewNodeLifecycleController(store, sharedInformers(store))
+	DefaultNodeLifecycleController.Start()
+}
@@ -0,0 +1,201 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+// startNodeLifecycleController runs the node lifecycle controller on store with short
+// heartbeat and grace periods.
+func startNodeLifecycleController(t *testing.T, store *storage.InMemoryStore) *NodeLifecycleController {
+	nc := NewNodeLifecycleController(store, startInformers(t, store))
+	nc.HeartbeatInterval = 20 * time.Millisecond
+	nc.MonitorPeriod = 10 * time.Millisecond
+	nc.GracePeriod = 100 * time.Millisecond
+	nc.Start()
+	t.Cleanup(nc.Stop)
+	return nc
+}
+
+// nodeReadyStatus returns the status of a node's Ready condition
+func nodeReadyStatus(store *storage.InMemoryStore, name string) string {
+	node, _ := store.GetNode(name)
+	status, _ := resources.NestedMap(node, "status")
+	cond := deploymentCondition(status, "Ready")
+	s, _ := cond["status"].(string)
+	return s
+}
+
+// hasTaint reports whether a node has the taint key with effect
+func hasTaint(store *storage.InMemoryStore, name, key, effect string) bool {
+	node, _ := store.GetNode(name)
+	for _, taint := range nodeTaints(node) {
+		if taint["key"] == key && taint["effect"] == effect {
+			return true
+		}
+	}
+	return false
+}
+
+func TestNodeNotReadyEvictsPodsToReadyNodes(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	startScheduler(t, store)
+	nc := startNodeLifecycleController(t, store)
+	rsController := NewReplicaSetController(store, startInformers(t, store))
+	rsController.Start()
+	t.Cleanup(rsController.Stop)
+
+	store.CreateNode(newTestNode("node-a", nil))
+	store.CreateNode(newTestNode("node-b", nil))
+
+	rs := newTestReplicaSet("web", 2, map[string]interface{}{"matchLabels": map[string]interface{}{"app": "web"}})
+	template, _ := resources.NestedMap(rs.Spec.(map[string]interface{}), "template", "spec")
+	template["tolerations"] = []interface{}{map[string]interface{}{
+		"key": TaintNodeNotReady, "operator": "Exists", "effect": "NoExecute", "tolerationSeconds": float64(1),
+	}}
+	if err := store.CreateReplicaSet(rs); err != nil {
+		t.Fatalf("Failed to create replicaset: %v", err)
+	}
+	waitFor(t, 5*time.Second, "2 ready pods", func() bool {
+		ready := 0
+		for _, name := range ownedPods(store, "web") {
+			if pod, err := store.GetPod(name); err == nil && isPodReady(pod) {
+				ready++
+			}
+		}
+		return ready == 2
+	})
+	failing := boundNode(store, ownedPods(store, "web")[0])
+	healthy := map[string]string{"node-a": "node-b", "node-b": "node-a"}[failing]
+
+	if err := nc.SetNodeHealth(failing, NodeHealthNotReady); err != nil {
+		t.Fatalf("SetNodeHealth failed: %v", err)
+	}
+	waitFor(t, 5*time.Second, failing+" NotReady and tainted", func() bool {
+		return nodeReadyStatus(store, failing) == "False" &&
+			hasTaint(store, failing, TaintNodeNotReady, "NoSchedule") && hasTaint(store, failing, TaintNodeNotReady, "NoExecute")
+	})
+	waitFor(t, 5*time.Second, "pods on "+failing+" marked not ready", func() bool {
+		for _, name := range ownedPods(store, "web") {
+			pod, _ := store.GetPod(name)
+			if boundNode(store, name) == failing && isPodReady(pod) {
+				return false
+			}
+		}
+		return true
+	})
+
+	// after tolerationSeconds the pods leave the node and are replaced on the healthy one
+	waitFor(t, 5*time.Second, "pods moved to "+healthy, func() bool {
+		pods := ownedPods(store, "web")
+		if len(pods) != 2 {
+			return false
+		}
+		for _, name := range pods {
+			if boundNode(store, name) != healthy {
+				return false
+			}
+		}
+		return true
+	})
+	evicted := false
+	for _, item := range store.ListEvents() {
+		if event := item.(map[string]interface{}); event["reason"] == EventReasonTaintManagerEviction {
+			evicted = true
+		}
+	}
+	if !evicted {
+		t.Error("Expected a TaintManagerEviction event")
+	}
+
+	if err := nc.SetNodeHealth(failing, NodeHealthReady); err != nil {
+		t.Fatalf("SetNodeHealth failed: %v", err)
+	}
+	waitFor(t, 5*time.Second, failing+" Ready and untainted", func() bool {
+		node, _ := store.GetNode(failing)
+		return nodeReadyStatus(store, failing) == "True" && len(nodeTaints(node)) == 0
+	})
+}
+
+func TestNodeUnreachableAfterLeaseExpires(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	usePodController(t, store)
+	nc := startNodeLifecycleController(t, store)
+
+	node := newTestNode("node-a", nil)
+	store.CreateNode(node)
+	waitFor(t, 5*time.Second, "node lease", func() bool {
+		lease, err := store.GetLease("node-a")
+		holder, _ := resources.NestedString(lease, "spec", "holderIdentity")
+		return err == nil && holder == "node-a" && namespaceOf(lease) == NodeLeaseNamespace
+	})
+
+	// created pods tolerate unreachable nodes for 300s unless told otherwise
+	pod := newTestPod("app", nil, map[string]interface{}{"nodeName": "node-a", "tolerations": []interface{}{
+		map[string]interface{}{"key": TaintNodeUnreachable, "operator": "Exists", "effect": "NoExecute", "tolerationSeconds": 0},
+	}})
+	if err := createPod(store, pod); err != nil {
+		t.Fatalf("Failed to create pod: %v", err)
+	}
+
+	if err := nc.SetNodeHealth("node-a", NodeHealthUnreachable); err != nil {
+		t.Fatalf("SetNodeHealth failed: %v", err)
+	}
+	// still Ready within the grace period
+	if status := nodeReadyStatus(store, "node-a"); status != "True" {
+		t.Errorf("Expected node-a to stay Ready within the grace period, got %s", status)
+	}
+	waitFor(t, 5*time.Second, "node-a Unknown and tainted", func() bool {
+		return nodeReadyStatus(store, "node-a") == "Unknown" && hasTaint(store, "node-a", TaintNodeUnreachable, "NoExecute")
+	})
+	// a pod tolerating the taint for 0s is evicted at once
+	waitFor(t, 5*time.Second, "pod evicted", func() bool {
+		stored, err := store.GetPod("app")
+		return err != nil || isBeingDeleted(stored)
+	})
+}
+
+func TestEvictionTime(t *testing.T) {
+	added := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	var node map[string]interface{}
+	deepCopyJSON(newTestNode("node-a", nil, map[string]interface{}{
+		"key": TaintNodeNotReady, "effect": "NoExecute", "timeAdded": added.Format(time.RFC3339),
+	}), &node)
+	toleration := func(seconds interface{}) map[string]interface{} {
+		t := map[string]interface{}{"key": TaintNodeNotReady, "operator": "Exists", "effect": "NoExecute"}
+		if seconds != nil {
+			t["tolerationSeconds"] = seconds
+		}
+		return t
+	}
+
+	tests := []struct {
+		name        string
+		tolerations []interface{}
+		evict       bool
+		at          time.Time
+	}{
+		{"untolerated", nil, true, time.Time{}},
+		{"tolerated forever", []interface{}{toleration(nil)}, false, time.Time{}},
+		{"shortest tolerationSeconds", []interface{}{toleration(float64(300)), toleration(float64(30))}, true, added.Add(30 * time.Second)},
+		{"unrelated toleration", []interface{}{map[string]interface{}{"key": "other", "operator": "Exists", "tolerationSeconds": float64(5)}}, true, time.Time{}},
+	}
+	now := added.Add(time.Hour)
+	for _, tt := range tests {
+		at, evict := evictionTime(map[string]interface{}{"tolerations": tt.tolerations}, node, now)
+		if evict != tt.evict || !at.Equal(tt.at) {
+			t.Errorf("%s: expected (%v, %v), got (%v, %v)", tt.name, tt.at, tt.evict, at, evict)
+		}
+	}
+
+	// a taint without timeAdded counts from the controller's clock
+	var untimed map[string]interface{}
+	deepCopyJSON(newTestNode("node-b", nil, map[string]interface{}{"key": TaintNodeNotReady, "effect": "NoExecute"}), &untimed)
+	at, _ := evictionTime(map[string]interface{}{"tolerations": []interface{}{toleration(float64(30))}}, untimed, now)
+	if !at.Equal(now.Add(30 * time.Second)) {
+		t.Errorf("Expected eviction 30s after %v, got %v", now, at)
+	}
+}
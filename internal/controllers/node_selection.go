@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"slices"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"mockernetes/internal/resources"
+)
+
+// Whether a pod may run on a node (component-helpers/scheduling upstream): the node has to
+// match the pod's nodeSelector and required node affinity, and the pod has to tolerate the
+// node's NoSchedule and NoExecute taints.
+
+// Taint keys the node lifecycle puts on nodes and DaemonSet pods tolerate.
+const (
+	TaintNodeNotReady           = "node.kubernetes.io/not-ready"
+	TaintNodeUnreachable        = "node.kubernetes.io/unreachable"
+	TaintNodeUnschedulable      = "node.kubernetes.io/unschedulable"
+	TaintNodeDiskPressure       = "node.kubernetes.io/disk-pressure"
+	TaintNodeMemoryPressure     = "node.kubernetes.io/memory-pressure"
+	TaintNodePIDPressure        = "node.kubernetes.io/pid-pressure"
+	TaintNodeNetworkUnavailable = "node.kubernetes.io/network-unavailable"
+)
+
+// selectionOperators maps node selector operators to label selection operators
+var selectionOperators = map[string]selection.Operator{
+	"In":           selection.In,
+	"NotIn":        selection.NotIn,
+	"Exists":       selection.Exists,
+	"DoesNotExist": selection.DoesNotExist,
+	"Gt":           selection.GreaterThan,
+	"Lt":           selection.LessThan,
+}
+
+// nodeLabels returns metadata.labels of a node as a label set
+func nodeLabels(node map[string]interface{}) labels.Set {
+	set, _ := resources.NestedStringMap(node, "metadata", "labels")
+	return labels.Set(set)
+}
+
+// nodeMatchesPod reports whether the node matches the nodeSelector and the required node
+// affinity of a pod spec
+func nodeMatchesPod(podSpec, node map[string]interface{}) bool {
+	nodeSelector, _ := resources.NestedStringMap(podSpec, "nodeSelector")
+	if !labels.SelectorFromSet(nodeSelector).Matches(nodeLabels(node)) {
+		return false
+	}
+	terms, ok := resources.NestedSlice(podSpec, "affinity", "nodeAffinity",
+		"requiredDuringSchedulingIgnoredDuringExecution", "nodeSelectorTerms")
+	if !ok {
+		return true
+	}
+	// Terms are ORed; an empty term matches nothing
+	for _, t := range terms {
+		if term, ok := t.(map[string]interface{}); ok && nodeMatchesTerm(term, node) {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeMatchesTerm reports whether the node matches all matchExpressions (on labels) and
+// matchFields (on metadata.name) of a node selector term
+func nodeMatchesTerm(term, node map[string]interface{}) bool {
+	expressions, _ := resources.NestedSlice(term, "matchExpressions")
+	fields, _ := resources.NestedSlice(term, "matchFields")
+	if len(expressions) == 0 && len(fields) == 0 {
+		return false
+	}
+	nodeName, _ := resources.NestedString(node, "metadata", "name")
+	return requirementsMatch(expressions, nodeLabels(node)) &&
+		requirementsMatch(fields, labels.Set{"metadata.name": nodeName})
+}
+
+// requirementsMatch reports whether set satisfies every node selector requirement
+func requirementsMatch(requirements []interface{}, set labels.Set) bool {
+	for _, r := range requirements {
+		requirement, _ := r.(map[string]interface{})
+		key, _ := resources.NestedString(requirement, "key")
+		operator, _ := resources.NestedString(requirement, "operator")
+		values, _ := resources.NestedStringSlice(requirement, "values")
+		op, ok := selectionOperators[operator]
+		if !ok {
+			return false
+		}
+		req, err := labels.NewRequirement(key, op, values)
+		if err != nil || !req.Matches(set) {
+			return false
+		}
+	}
+	return true
+}
+
+// nodeTaints returns spec.taints of a node
+func nodeTaints(node map[string]interface{}) []map[string]interface{} {
+	var taints []map[string]interface{}
+	items, _ := resources.NestedSlice(node, "spec", "taints")
+	for _, item := range items {
+		if taint, ok := item.(map[string]interface{}); ok {
+			taints = append(taints, taint)
+		}
+	}
+	return taints
+}
+
+// untoleratedTaint returns the first taint of the node with one of the given effects
+// (NoSchedule, NoExecute) the pod spec does not tolerate, or nil.
+func untoleratedTaint(podSpec, node map[string]interface{}, effects ...string) map[string]interface{} {
+	tolerations, _ := resources.NestedSlice(podSpec, "tolerations")
+	for _, taint := range nodeTaints(node) {
+		if effect, _ := taint["effect"].(string); !slices.Contains(effects, effect) {
+			continue
+		}
+		tolerated := false
+		for _, t := range tolerations {
+			if toleration, ok := t.(map[string]interface{}); ok && toleratesTaint(toleration, taint) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			return taint
+		}
+	}
+	return nil
+}
+
+// toleratesTaint reports whether a toleration matches a taint: same effect (or any), and the
+// same key and value (operator Equal) or key (operator Exists; an empty key matches all)
+func toleratesTaint(toleration, taint map[string]interface{}) bool {
+	if effect, _ := toleration["effect"].(string); effect != "" && effect != taint["effect"] {
+		return false
+	}
+	key, _ := toleration["key"].(string)
+	if key != "" && key != taint["key"] {
+		return false
+	}
+	operator, _ := toleration["operator"].(string)
+	switch operator {
+	case "Exists":
+		return true
+	case "", "Equal":
+		value, _ := toleration["value"].(string)
+		taintValue, _ := taint["value"].(string)
+		return value == taintValue
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package controllers
+
+import "mockernetes/internal/resources"
+
+// Helpers for metadata.ownerReferences on stored objects. References built in Go
+// ([]resources.OwnerReference) come back from storage as []interface{} of maps.
+
+// objectUID returns metadata.uid.
+func objectUID(obj map[string]interface{}) string {
+	uid, _ := resources.NestedString(obj, "metadata", "uid")
+	return uid
+}
+
+// objectName returns metadata.name of obj.
+func objectName(obj map[string]interface{}) string {
+	name, _ := resources.NestedString(obj, "metadata", "name")
+	return name
+}
+
+// ownerReferences returns the owner references of obj as maps.
+func ownerReferences(obj map[string]interface{}) []map[string]interface{} {
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	var refs []map[string]interface{}
+	switch v := metadata["ownerReferences"].(type) {
+	case []interface{}:
+		for _, r := range v {
+			if ref, ok := r.(map[string]interface{}); ok {
+				refs = append(refs, ref)
+			}
+		}
+	case []map[string]interface{}:
+		refs = v
+	}
+	return refs
+}
+
+// isOwnedBy reports whether obj references the given owner. The uid is compared when both
+// sides have one, so a re-created owner with the same name doesn't inherit old dependents.
+func isOwnedBy(obj map[string]interface{}, kind, name, uid string) bool {
+	for _, ref := range ownerReferences(obj) {
+		refKind, _ := ref["kind"].(string)
+		refName, _ := ref["name"].(string)
+		refUID, _ := ref["uid"].(string)
+		if refKind != kind || refName != name {
+			continue
+		}
+		if uid == "" || refUID == "" || refUID == uid {
+			return true
+		}
+	}
+	return false
+}
+
+// removeOwnerReferences drops the references whose uid is in uids and reports whether any were removed.
+func removeOwnerReferences(obj map[string]interface{}, uids map[string]bool) bool {
+	refs := ownerReferences(obj)
+	kept := make([]interface{}, 0, len(refs))
+	for _, ref := range refs {
+		if uid, _ := ref["uid"].(string); !uids[uid] {
+			kept = append(kept, ref)
+		}
+	}
+	if len(kept) == len(refs) {
+		return false
+	}
+	metadata := obj["metadata"].(map[string]interface{})
+	if len(kept) == 0 {
+		delete(metadata, "ownerReferences")
+	} else {
+		metadata["ownerReferences"] = kept
+	}
+	return true
+}
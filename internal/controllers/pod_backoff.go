@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"mockernetes/internal/resources"
+)
+
+// Container restart back-off (the kubelet's CrashLoopBackOff). A container that exited or was
+// killed by a failed probe is restarted right away the first time; after that it waits
+// RestartBackOff, doubling with every restart up to MaxRestartBackOff, in a waiting state with
+// reason CrashLoopBackOff. The back-off starts over once a container ran for longer than twice
+// MaxRestartBackOff (10 minutes by default) before exiting again.
+const (
+	DefaultRestartBackOff    = 10 * time.Second
+	DefaultMaxRestartBackOff = 300 * time.Second
+
+	ContainerReasonCrashLoopBackOff = "CrashLoopBackOff"
+	EventReasonBackOff              = "BackOff"
+)
+
+// restartBackOff is the back-off of one container
+type restartBackOff struct {
+	delay      time.Duration
+	lastUpdate time.Time
+}
+
+// containerRestart is a container of a pod waiting to be restarted after delay
+type containerRestart struct {
+	container string
+	// restarts is the container's restartCount before the restart
+	restarts int64
+	delay    time.Duration
+}
+
+// nextRestartBackOff returns how long the container (keyed "<pod uid>/<name>") that exited at
+// now waits before it is restarted, and doubles its back-off.
+func (pc *PodController) nextRestartBackOff(key string, now time.Time) time.Duration {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	entry, ok := pc.backOff[key]
+	if !ok || now.Sub(entry.lastUpdate) > 2*pc.MaxRestartBackOff {
+		pc.backOff[key] = &restartBackOff{delay: pc.RestartBackOff, lastUpdate: now}
+		return 0
+	}
+	delay := entry.delay
+	entry.delay = min(2*entry.delay, pc.MaxRestartBackOff)
+	entry.lastUpdate = now
+	return delay
+}
+
+// forgetBackOff drops the restart and image pull back-offs of the containers of a removed pod
+func (pc *PodController) forgetBackOff(pod map[string]interface{}) {
+	prefix := objectUID(pod) + "/"
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	for key := range pc.backOff {
+		if strings.HasPrefix(key, prefix) {
+			delete(pc.backOff, key)
+		}
+	}
+}
+
+// crashLoopBackOffState is the state of a container of pod waiting delay to be restarted
+func crashLoopBackOffState(pod map[string]interface{}, container string, delay time.Duration) map[string]interface{} {
+	podName, _ := resources.NestedString(pod, "metadata", "name")
+	return map[string]interface{}{
+		"waiting": map[string]interface{}{
+			"reason": ContainerReasonCrashLoopBackOff,
+			"message": fmt.Sprintf("back-off %s restarting failed container=%s pod=%s_%s(%s)",
+				delay, container, podName, namespaceOf(pod), objectUID(pod)),
+		},
+	}
+}
+
+// restartAfterBackOff restarts an exited container once its back-off has passed
+func (pc *PodController) restartAfterBackOff(podName, uid string, restart *containerRestart) {
+	if restart.delay <= 0 {
+		pc.restartContainer(podName, uid, restart.container, restart.restarts)
+		return
+	}
+	if pod, err := pc.store.GetPod(podName); err == nil {
+		recordEvent(pc.store, pod, EventTypeWarning, EventReasonBackOff,
+			fmt.Sprintf("Back-off restarting failed container %s in pod %s_%s(%s)", restart.container, podName, namespaceOf(pod), uid), "kubelet")
+	}
+	go func() {
+		select {
+		case <-time.After(restart.delay):
+			pc.restartContainer(podName, uid, restart.container, restart.restarts)
+		case <-pc.stopCh:
+		}
+	}()
+}
+
+// restartContainer runs an exited container again: its restartCount goes up, its last
+// termination moves to lastState, and it is ready once its probes pass.
+func (pc *PodController) restartContainer(podName, uid, container string, restarts int64) {
+	now := time.Now()
+	restarted := false
+	stored, err := pc.store.MutatePod(podName, func(pod map[string]interface{}) error {
+		restarted = false
+		containerStatus := podContainerStatus(pod, container)
+		phase, _ := resources.NestedString(pod, "status", "phase")
+		if objectUID(pod) != uid || isBeingDeleted(pod) || phase != string(PodRunning) || containerStatus == nil {
+			return nil
+		}
+		if current, _ := resources.ToInt64(containerStatus["restartCount"]); current != restarts {
+			return nil
+		}
+		if terminated, ok := resources.NestedMap(containerStatus, "state", "terminated"); ok {
+			containerStatus["lastState"] = map[string]interface{}{"terminated": terminated}
+		}
+		started, ready := containerStartState(podContainerSpec(pod, container))
+		containerStatus["restartCount"] = restarts + 1
+		containerStatus["state"] = map[string]interface{}{
+			"running": map[string]interface{}{"startedAt": now.Format(time.RFC3339)},
+		}
+		containerStatus["started"] = started
+		containerStatus["ready"] = ready
+		setReadyConditions(pod["status"].(map[string]interface{}), now)
+		restarted = true
+		return nil
+	})
+	if err != nil || !restarted {
+		return
+	}
+	fmt.Printf("[Pod Controller] Restarting container %s of pod %s (restart %d)\n", container, podName, restarts+1)
+	pc.scheduleContainerExit(stored, container)
+}
+
+// podContainerSpec returns the spec of the named container of a pod, if any
+func podContainerSpec(pod map[string]interface{}, name string) map[string]interface{} {
+	containers, _ := resources.NestedSlice(pod, "spec", "containers")
+	for _, item := range containers {
+		if container, ok := item.(map[string]interface{}); ok && container["name"] == name {
+			return container
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+// Pod ownership by label selector (ControllerRefManager upstream). A controller owns the pods
+// its ownerReference marks as controller; orphans matching its selector are adopted and owned
+// pods whose labels stop matching are released, so relabeling a pod takes it out of service.
+
+// selectorOf returns the spec.selector of a workload. A missing or empty selector matches nothing.
+func selectorOf(obj map[string]interface{}) (labels.Selector, error) {
+	raw, _ := resources.NestedMap(obj, "spec", "selector")
+	if len(raw) == 0 {
+		return labels.Nothing(), nil
+	}
+	var selector metav1.LabelSelector
+	if err := deepCopyJSON(raw, &selector); err != nil {
+		return nil, err
+	}
+	if len(selector.MatchLabels) == 0 && len(selector.MatchExpressions) == 0 {
+		return labels.Nothing(), nil
+	}
+	return metav1.LabelSelectorAsSelector(&selector)
+}
+
+// podLabels returns metadata.labels of a pod as a label set
+func podLabels(pod map[string]interface{}) labels.Set {
+	set, _ := resources.NestedStringMap(pod, "metadata", "labels")
+	return labels.Set(set)
+}
+
+// namespaceOf returns metadata.namespace ("default" if unset)
+func namespaceOf(obj map[string]interface{}) string {
+	namespace, _ := resources.NestedString(obj, "metadata", "namespace")
+	if namespace == "" {
+		return "default"
+	}
+	return namespace
+}
+
+// claimPods returns the pods in the owner's namespace it controls once matching orphans are
+// adopted and non-matching pods released. An owner being deleted adopts and releases nothing.
+func claimPods(store *storage.InMemoryStore, owner map[string]interface{}, kind string, selector labels.Selector) ([]map[string]interface{}, error) {
+	ownerName, _ := resources.NestedString(owner, "metadata", "name")
+	ownerUID := objectUID(owner)
+	namespace := namespaceOf(owner)
+	deleting := isBeingDeleted(owner)
+
+	var claimed []map[string]interface{}
+	var errs []error
+	for _, item := range store.ListPods() {
+		pod, ok := item.(map[string]interface{})
+		if !ok || namespaceOf(pod) != namespace {
+			continue
+		}
+		podName, _ := resources.NestedString(pod, "metadata", "name")
+		matches := selector.Matches(podLabels(pod))
+
+		if ref := controllerOf(pod, kind); ref != nil {
+			if ref["uid"] != ownerUID || ref["name"] != ownerName {
+				continue
+			}
+			if matches || deleting {
+				claimed = append(claimed, pod)
+				continue
+			}
+			fmt.Printf("[%s Controller] Releasing pod %s from %s %s: labels no longer match\n", kind, podName, kind, ownerName)
+			if err := releasePod(store, podName, ownerUID); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if hasController(pod) || !matches || deleting || isBeingDeleted(pod) {
+			continue
+		}
+		fmt.Printf("[%s Controller] Adopting pod %s into %s %s\n", kind, podName, kind, ownerName)
+		adopted, err := adoptPod(store, podName, kind, ownerName, ownerUID, selector)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if adopted != nil {
+			claimed = append(claimed, adopted)
+		}
+	}
+	return claimed, errors.Join(errs...)
+}
+
+// hasController reports whether any ownerReference of obj is marked as controller
+func hasController(obj map[string]interface{}) bool {
+	for _, ref := range ownerReferences(obj) {
+		if controller, _ := ref["controller"].(bool); controller {
+			return true
+		}
+	}
+	return false
+}
+
+// adoptPod makes the owner the controller of an orphaned pod. It returns nil (and no error)
+// when the pod was claimed by someone else or relabeled in the meantime.
+func adoptPod(store *storage.InMemoryStore, podName, kind, ownerName, ownerUID string, selector labels.Selector) (map[string]interface{}, error) {
+	adopted := false
+	pod, err := store.MutatePod(podName, func(pod map[string]interface{}) error {
+		if hasController(pod) || isBeingDeleted(pod) || !selector.Matches(podLabels(pod)) {
+			return nil
+		}
+		metadata := pod["metadata"].(map[string]interface{})
+		refs := make([]interface{}, 0, len(ownerReferences(pod))+1)
+		for _, ref := range ownerReferences(pod) {
+			refs = append(refs, ref)
+		}
+		metadata["ownerReferences"] = append(refs, map[string]interface{}{
+			"apiVersion":         ownerAPIVersion(kind),
+			"kind":               kind,
+			"name":               ownerName,
+			"uid":                ownerUID,
+			"controller":         true,
+			"blockOwnerDeletion": true,
+		})
+		adopted = true
+		return nil
+	})
+	if err != nil || !adopted {
+		return nil, err
+	}
+	return pod, nil
+}
+
+// releasePod removes the owner's reference from a pod, leaving it an orphan
+func releasePod(store *storage.InMemoryStore, podName, ownerUID string) error {
+	_, err := store.MutatePod(podName, func(pod map[string]interface{}) error {
+		removeOwnerReferences(pod, map[string]bool{ownerUID: true})
+		return nil
+	})
+	return err
+}
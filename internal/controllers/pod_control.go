@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+// Pod creation shared by the workload controllers (PodControl upstream): a pod is stamped
+// from the owner's pod template and handed to the pod controller for its lifecycle.
+
+// newPodFromTemplate builds a pod from a workload's spec.template, controlled by owner.
+// extraLabels are added to the template labels; the spec is a copy the caller may change.
+func newPodFromTemplate(owner map[string]interface{}, kind string, template map[string]interface{}, extraLabels map[string]string) resources.Pod {
+	ownerName, _ := resources.NestedString(owner, "metadata", "name")
+	labels, _ := resources.NestedStringMap(template, "metadata", "labels")
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	for k, v := range extraLabels {
+		labels[k] = v
+	}
+	annotations, _ := resources.NestedStringMap(template, "metadata", "annotations")
+	var spec map[string]interface{}
+	deepCopyJSON(template["spec"], &spec)
+
+	return resources.Pod{
+		Kind:       "Pod",
+		APIVersion: "v1",
+		Metadata: resources.ObjectMeta{
+			// storage picks "<owner>-xxxxx" like the upstream controllers
+			GenerateName:      ownerName + "-",
+			Namespace:         namespaceOf(owner),
+			Labels:            labels,
+			Annotations:       annotations,
+			CreationTimestamp: time.Now().Format(time.RFC3339),
+			// the garbage collector follows the owner reference by uid
+			OwnerReferences: []resources.OwnerReference{{
+				APIVersion:         ownerAPIVersion(kind),
+				Kind:               kind,
+				Name:               ownerName,
+				UID:                objectUID(owner),
+				Controller:         true,
+				BlockOwnerDeletion: true,
+			}},
+		},
+		Spec: spec,
+	}
+}
+
+// createPod stores pod (its generated name is written back) and starts its lifecycle. The
+// pod is admitted like one created through the API (service account, default tolerations).
+func createPod(store *storage.InMemoryStore, pod *resources.Pod) error {
+	resources.SetPodDefaults(pod)
+	if err := store.CreatePod(pod); err != nil {
+		return fmt.Errorf("failed to create pod: %w", err)
+	}
+	StartPodLifecycle(store, *pod)
+	return nil
+}
+
+// ownerAPIVersion returns the group version of a workload kind for its owner references
+func ownerAPIVersion(kind string) string {
+	switch kind {
+	case "Job", "CronJob":
+		return "batch/v1"
+	case "Service":
+		return "v1"
+	}
+	return "apps/v1"
+}
@@ -3,6 +3,7 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"math/rand"
 	"sync"
 	"time"
 
@@ -27,9 +28,19 @@ type PodStatus struct {
 	Phase             PodPhase          `json:"phase"`
 	Conditions        []PodCondition    `json:"conditions,omitempty"`
 	PodIP             string            `json:"podIP"`
+	PodIPs            []PodIPEntry      `json:"podIPs,omitempty"`
 	HostIP            string            `json:"hostIP"`
 	StartTime         *time.Time        `json:"startTime,omitempty"`
 	ContainerStatuses []ContainerStatus `json:"containerStatuses,omitempty"`
+	// InitContainerStatuses and EphemeralContainerStatuses are those of spec.initContainers
+	// and spec.ephemeralContainers
+	InitContainerStatuses      []ContainerStatus `json:"initContainerStatuses,omitempty"`
+	EphemeralContainerStatuses []ContainerStatus `json:"ephemeralContainerStatuses,omitempty"`
+}
+
+// PodIPEntry is an entry of status.podIPs (one per IP family)
+type PodIPEntry struct {
+	IP string `json:"ip"`
 }
 
 // PodCondition represents a condition of a Pod
@@ -48,6 +59,7 @@ type ContainerStatus struct {
 	ImageID      string                 `json:"imageID"`
 	ContainerID  string                 `json:"containerID"`
 	Ready        bool                   `json:"ready"`
+	Started      *bool                  `json:"started,omitempty"`
 	RestartCount int32                  `json:"restartCount"`
 	State        map[string]interface{} `json:"state,omitempty"`
 	LastState    map[string]interface{} `json:"lastState,omitempty"`
@@ -60,15 +72,65 @@ type PodController struct {
 	stopCh chan struct{}
 	// StartupDelay is the duration a pod stays in Pending before transitioning to Running.
 	StartupDelay time.Duration
+	// ShutdownDelay is how long containers take to exit after SIGTERM on graceful deletion.
+	// Containers still running when the grace period ends are killed (exit code 137).
+	ShutdownDelay time.Duration
+	// RunDuration is how long containers of pods with restartPolicy Never or OnFailure run
+	// before exiting when the pod has no run-duration annotation (0: until deleted).
+	RunDuration time.Duration
+	// InitContainerDuration is how long init containers run before completing when the pod
+	// has no run-duration annotation for them.
+	InitContainerDuration time.Duration
+	// WaitForBinding holds pods without spec.nodeName in Pending until the scheduler binds
+	// them (OnPodBound); without a scheduler every pod starts right away.
+	WaitForBinding bool
+	// IPAM allocates pod IPs; they are released when the pod is removed.
+	IPAM *IPAM
+	// Images is the registry container images are pulled from.
+	Images *ImageRegistry
+	// RestartBackOff is the first back-off before an exited container is restarted again;
+	// it doubles with every restart up to MaxRestartBackOff.
+	RestartBackOff    time.Duration
+	MaxRestartBackOff time.Duration
+	// ProbeTimeUnit is the length of a second of probe timings (initialDelaySeconds,
+	// periodSeconds); tests shorten it.
+	ProbeTimeUnit time.Duration
+	// terminating holds a cancel channel per pod with a pending graceful deletion
+	terminating map[string]chan struct{}
+	// starting holds the uids of pods whose start is scheduled
+	starting map[string]bool
+	// backOff holds the restart back-off per "<pod uid>/<container>", and the image pull
+	// back-off per "<pod uid>/<container>/image"
+	backOff map[string]*restartBackOff
 }
 
 // NewPodController creates a new PodController with the given startup delay.
 func NewPodController(store *storage.InMemoryStore, startupDelay time.Duration) *PodController {
 	return &PodController{
-		store:        store,
-		stopCh:       make(chan struct{}),
-		StartupDelay: startupDelay,
+		store:                 store,
+		stopCh:                make(chan struct{}),
+		StartupDelay:          startupDelay,
+		ShutdownDelay:         DefaultShutdownDelay,
+		RunDuration:           DefaultRunDuration,
+		InitContainerDuration: DefaultInitContainerDuration,
+		RestartBackOff:        DefaultRestartBackOff,
+		MaxRestartBackOff:     DefaultMaxRestartBackOff,
+		terminating:           make(map[string]chan struct{}),
+		starting:              make(map[string]bool),
+		backOff:               make(map[string]*restartBackOff),
+		IPAM:                  podIPAM(),
+		Images:                podImageRegistry(),
+		ProbeTimeUnit:         time.Second,
+	}
+}
+
+// podIPAM returns DefaultIPAM, or an IPAM for DefaultClusterCIDR when there is none
+func podIPAM() *IPAM {
+	if DefaultIPAM != nil {
+		return DefaultIPAM
 	}
+	ipam, _ := NewIPAM(DefaultClusterCIDR)
+	return ipam
 }
 
 // Start starts the controller (for now just initializes)
@@ -110,48 +172,148 @@ func (pc *PodController) OnPodCreated(pod resources.Pod) error {
 		return err
 	}
 
-	// Schedule async transition to Running after StartupDelay
+	// Pods waiting for the scheduler start once they are bound to a node
+	stored, err := pc.store.GetPod(pod.GetName())
+	if err != nil {
+		return nil
+	}
+	if nodeName, _ := resources.NestedString(stored, "spec", "nodeName"); nodeName == "" && pc.WaitForBinding {
+		return nil
+	}
+	pc.startAfterDelay(stored)
+	return nil
+}
+
+// OnPodBound is called by the scheduler once it has set spec.nodeName of a pod; the pod's
+// containers start after StartupDelay.
+func (pc *PodController) OnPodBound(podName string) error {
+	stored, err := pc.store.GetPod(podName)
+	if err != nil {
+		return err
+	}
+	pc.startAfterDelay(stored)
+	return nil
+}
+
+// startAfterDelay schedules the async transition to Running after StartupDelay, once per pod
+// (creation and binding may both ask for it)
+func (pc *PodController) startAfterDelay(stored map[string]interface{}) {
+	// pods given to the transition manager keep the status their transitions set
+	tm := DefaultTransitionManager
+	if tm != nil && tm.Drives(stored) {
+		return
+	}
+	uid := objectUID(stored)
+	pc.mu.Lock()
+	if pc.starting[uid] {
+		pc.mu.Unlock()
+		return
+	}
+	pc.starting[uid] = true
+	pc.mu.Unlock()
+
+	var pod resources.Pod
+	deepCopyJSON(stored, &pod)
 	go func() {
 		select {
 		case <-time.After(pc.StartupDelay):
+			// the scheduler may bind a pod before it is given to the transition manager
+			if tm != nil && tm.Drives(stored) {
+				return
+			}
 			pc.OnPodStarted(pod)
 		case <-pc.stopCh:
 			return
 		}
 	}()
-
-	return nil
 }
 
 // OnPodStarted is called when a pod's containers are started
 // It transitions the pod from Pending to Running
 func (pc *PodController) OnPodStarted(pod resources.Pod) error {
+	// A pod deleted while still Pending never starts its containers
+	stored, err := pc.store.GetPod(pod.GetName())
+	if err != nil {
+		return err
+	}
+	if isBeingDeleted(stored) {
+		return nil
+	}
+
 	now := time.Now()
 
-	// Build container statuses from pod spec
-	containerStatuses := pc.buildContainerStatuses(pod.Spec, true)
+	// The pod sandbox gets its IPs before the containers start
+	hostIP := pc.hostIP(pod.GetName())
+	podIPs, err := pc.allocatePodIPs(pod.GetName(), hostIP)
+	if err != nil {
+		return err
+	}
+
+	// Init containers run first, one at a time; the containers wait for them
+	initContainers, _ := resources.NestedSlice(stored, "spec", "initContainers")
+	initStatuses := waitingContainerStatuses(initContainers, "init-container", ContainerReasonPodInitializing)
+	initialized, initReason, initMessage := "True", "", ""
+	var incomplete []string
+	for _, cs := range initStatuses {
+		incomplete = append(incomplete, cs.Name)
+	}
+	if len(incomplete) > 0 {
+		initialized, initReason, initMessage = "False", "ContainersNotInitialized", incompleteMessage(incomplete)
+	}
+
+	// Container images are pulled before the containers start; the pod stays Pending while
+	// containers wait for them. Containers with a readiness or startup probe become ready once
+	// their probes pass.
+	var containerStatuses []ContainerStatus
+	var pulls []imagePull
+	phase := PodPending
+	if len(initStatuses) > 0 {
+		containers, _ := resources.NestedSlice(stored, "spec", "containers")
+		containerStatuses = waitingContainerStatuses(containers, "container", ContainerReasonPodInitializing)
+	} else {
+		containerStatuses, pulls, phase = pc.startingContainers(stored)
+	}
+	ready, readyReason, readyMessage := "True", "", ""
+	var unready []string
+	for _, cs := range containerStatuses {
+		if !cs.Ready {
+			unready = append(unready, cs.Name)
+		}
+	}
+	if len(unready) > 0 {
+		ready, readyReason, readyMessage = "False", "ContainersNotReady", unreadyMessage(unready)
+	}
 
 	status := PodStatus{
-		Phase:             PodRunning,
-		PodIP:             "10.244.0.1", // Mock pod IP
-		HostIP:            "127.0.0.1",  // Mock host IP
-		StartTime:         &now,
-		ContainerStatuses: containerStatuses,
+		Phase:                      phase,
+		PodIP:                      podIPs[0].IP,
+		PodIPs:                     podIPs,
+		HostIP:                     hostIP,
+		StartTime:                  &now,
+		ContainerStatuses:          containerStatuses,
+		InitContainerStatuses:      initStatuses,
+		EphemeralContainerStatuses: pc.ephemeralContainerStatuses(stored),
 		Conditions: []PodCondition{
 			{
 				Type:               "Initialized",
-				Status:             "True",
+				Status:             initialized,
 				LastTransitionTime: &now,
+				Reason:             initReason,
+				Message:            initMessage,
 			},
 			{
 				Type:               "Ready",
-				Status:             "True",
+				Status:             ready,
 				LastTransitionTime: &now,
+				Reason:             readyReason,
+				Message:            readyMessage,
 			},
 			{
 				Type:               "ContainersReady",
-				Status:             "True",
+				Status:             ready,
 				LastTransitionTime: &now,
+				Reason:             readyReason,
+				Message:            readyMessage,
 			},
 			{
 				Type:               "PodScheduled",
@@ -161,11 +323,29 @@ func (pc *PodController) OnPodStarted(pod resources.Pod) error {
 		},
 	}
 
-	return pc.updatePodStatus(pod, status)
+	if err := pc.updatePodStatus(pod, status); err != nil {
+		return err
+	}
+	if len(initStatuses) > 0 {
+		pc.runInitContainer(pod.GetName(), objectUID(stored), 0, 0)
+		return nil
+	}
+	for _, pull := range pulls {
+		pc.followImagePull(stored, pull)
+	}
+	if phase != PodRunning {
+		return nil
+	}
+	// Containers that run to completion exit after their (simulated) run time
+	pc.scheduleExit(pod.GetName())
+	pc.startProbes(pod.GetName())
+	return nil
 }
 
-// buildContainerStatuses extracts container info from pod spec and creates container statuses
-func (pc *PodController) buildContainerStatuses(spec interface{}, ready bool) []ContainerStatus {
+// buildContainerStatuses extracts container info from pod spec and creates container statuses.
+// Containers with a readiness or startup probe start unready; the prober makes them ready.
+// Containers whose image pull is pending or failed wait.
+func (pc *PodController) buildContainerStatuses(spec interface{}, pulls []imagePull) []ContainerStatus {
 	var statuses []ContainerStatus
 
 	// Try to extract containers from spec
@@ -183,12 +363,13 @@ func (pc *PodController) buildContainerStatuses(spec interface{}, ready bool) []
 					}
 
 					now := time.Now()
+					started, ready := containerStartState(container)
 					status := ContainerStatus{
 						Name:         name,
 						Image:        image,
-						ImageID:      "docker-pullable://nginx@sha256:mock",
 						ContainerID:  fmt.Sprintf("docker://container-%d", i),
 						Ready:        ready,
+						Started:      &started,
 						RestartCount: 0,
 						State: map[string]interface{}{
 							"running": map[string]interface{}{
@@ -197,6 +378,13 @@ func (pc *PodController) buildContainerStatuses(spec interface{}, ready bool) []
 						},
 						LastState: map[string]interface{}{},
 					}
+					if pull := findImagePull(pulls, name); pull != nil {
+						status.ImageID = pull.imageID
+						if waiting := pull.waitingState(); waiting != nil {
+							status.ImageID, status.Ready, started = "", false, false
+							status.State = map[string]interface{}{"waiting": waiting}
+						}
+					}
 					statuses = append(statuses, status)
 				}
 			}
@@ -225,6 +413,7 @@ func (pc *PodController) OnPodCompleted(pod resources.Pod) error {
 				Reason:             "PodCompleted",
 			},
 		},
+		ContainerStatuses: pc.exitedContainerStatuses(pod.GetName(), 0, "Completed"),
 	}
 
 	return pc.updatePodStatus(pod, status)
@@ -249,125 +438,111 @@ func (pc *PodController) OnPodFailed(pod resources.Pod, reason string) error {
 				Reason:             reason,
 			},
 		},
+		ContainerStatuses: pc.exitedContainerStatuses(pod.GetName(), 1, "Error"),
 	}
 
 	return pc.updatePodStatus(pod, status)
 }
 
-// updatePodStatus updates the pod status in the store
-// Preserves existing metadata including creationTimestamp and ownerReferences
+// updatePodStatus replaces the pod status in the store.
+// Only status is written: metadata (creationTimestamp, ownerReferences, deletionTimestamp)
+// and spec are kept exactly as stored.
 func (pc *PodController) updatePodStatus(pod resources.Pod, status PodStatus) error {
-	// Get existing pod from storage to preserve metadata
-	existingPod, err := pc.store.GetPod(pod.GetName())
-	if err != nil {
-		// Pod not found, use original pod metadata
-		existingPod = nil
-	}
+	_, err := pc.store.MutatePod(pod.GetName(), func(stored map[string]interface{}) error {
+		stored["status"] = status
+		return nil
+	})
+	return err
+}
 
-	metadata := pod.Metadata
-	if existingPod != nil {
-		if meta, ok := existingPod["metadata"].(map[string]interface{}); ok {
-			// Preserve creationTimestamp from existing pod
-			if ct, ok := meta["creationTimestamp"].(string); ok && ct != "" {
-				metadata.CreationTimestamp = ct
-			}
-			// Preserve ownerReferences from existing pod if not set in incoming pod
-			if len(metadata.OwnerReferences) == 0 {
-				if ownerRefsRaw, ok := meta["ownerReferences"].([]interface{}); ok {
-					metadata.OwnerReferences = convertToOwnerReferences(ownerRefsRaw)
-				}
-			}
+// updatePodStatusWithMetadata updates the pod status and sets creation timestamp if missing.
+// A PodScheduled condition the scheduler already reported is kept.
+func (pc *PodController) updatePodStatusWithMetadata(pod resources.Pod, status PodStatus, creationTime time.Time) error {
+	_, err := pc.store.MutatePod(pod.GetName(), func(stored map[string]interface{}) error {
+		meta, _ := stored["metadata"].(map[string]interface{})
+		if meta == nil {
+			meta = map[string]interface{}{}
+			stored["metadata"] = meta
 		}
-	}
-
-	// Create a new pod with updated status
-	updatedPod := resources.Pod{
-		Kind:       pod.Kind,
-		APIVersion: pod.APIVersion,
-		Metadata:   metadata,
-		Spec:       pod.Spec,
-		Status:     status,
-	}
-
-	// Update in storage
-	return pc.store.UpdatePod(updatedPod)
+		if ct, _ := meta["creationTimestamp"].(string); ct == "" {
+			meta["creationTimestamp"] = creationTime.Format(time.RFC3339)
+		}
+		if scheduled := podCondition(stored, "PodScheduled"); scheduled != nil {
+			var cond PodCondition
+			deepCopyJSON(scheduled, &cond)
+			status.Conditions = append(status.Conditions, cond)
+		}
+		stored["status"] = status
+		return nil
+	})
+	return err
 }
 
-// convertToOwnerReferences converts []interface{} to []resources.OwnerReference
-func convertToOwnerReferences(ownerRefsRaw []interface{}) []resources.OwnerReference {
-	var ownerRefs []resources.OwnerReference
-	for _, refRaw := range ownerRefsRaw {
-		if refMap, ok := refRaw.(map[string]interface{}); ok {
-			ownerRef := resources.OwnerReference{}
-			if apiVersion, ok := refMap["apiVersion"].(string); ok {
-				ownerRef.APIVersion = apiVersion
-			}
-			if kind, ok := refMap["kind"].(string); ok {
-				ownerRef.Kind = kind
-			}
-			if name, ok := refMap["name"].(string); ok {
-				ownerRef.Name = name
-			}
-			if uid, ok := refMap["uid"].(string); ok {
-				ownerRef.UID = uid
-			}
-			if controller, ok := refMap["controller"].(bool); ok {
-				ownerRef.Controller = controller
-			}
-			if blockOwnerDeletion, ok := refMap["blockOwnerDeletion"].(bool); ok {
-				ownerRef.BlockOwnerDeletion = blockOwnerDeletion
+// allocatePodIPs allocates the IPs of a starting pod. When its node's podCIDR is exhausted the
+// pod stays Pending with a FailedCreatePodSandBox event and the start is retried later.
+func (pc *PodController) allocatePodIPs(podName, hostIP string) ([]PodIPEntry, error) {
+	stored, err := pc.store.GetPod(podName)
+	if err != nil {
+		return nil, err
+	}
+	ips, err := pc.IPAM.podNetwork(pc.store, stored, hostIP)
+	if err != nil {
+		recordEvent(pc.store, stored, EventTypeWarning, EventReasonFailedCreatePodSandBox,
+			"Failed to create pod sandbox: failed to allocate pod IP: "+err.Error(), "kubelet")
+		pc.mu.Lock()
+		delete(pc.starting, objectUID(stored))
+		pc.mu.Unlock()
+		go func() {
+			select {
+			case <-time.After(podSandboxRetryDelay):
+				if retry, err := pc.store.GetPod(podName); err == nil && objectUID(retry) == objectUID(stored) {
+					pc.startAfterDelay(retry)
+				}
+			case <-pc.stopCh:
 			}
-			ownerRefs = append(ownerRefs, ownerRef)
-		}
+		}()
+		return nil, err
 	}
-	return ownerRefs
+	podIPs := make([]PodIPEntry, len(ips))
+	for i, ip := range ips {
+		podIPs[i] = PodIPEntry{IP: ip}
+	}
+	return podIPs, nil
 }
 
-// updatePodStatusWithMetadata updates the pod status and sets creation timestamp
-// Preserves existing metadata including ownerReferences
-func (pc *PodController) updatePodStatusWithMetadata(pod resources.Pod, status PodStatus, creationTime time.Time) error {
-	// Get existing pod from storage to preserve metadata
-	existingPod, err := pc.store.GetPod(pod.GetName())
+// hostIP returns the InternalIP of the node the pod is bound to (127.0.0.1 when the pod
+// isn't bound or the node has no address)
+func (pc *PodController) hostIP(podName string) string {
+	pod, err := pc.store.GetPod(podName)
 	if err != nil {
-		existingPod = nil
+		return "127.0.0.1"
 	}
-
-	// Create a new pod with updated status and creation timestamp
-	metadata := pod.Metadata
-	if metadata.CreationTimestamp == "" {
-		metadata.CreationTimestamp = creationTime.Format(time.RFC3339)
-	}
-
-	// Preserve ownerReferences from existing pod if not set in incoming pod
-	if existingPod != nil && len(metadata.OwnerReferences) == 0 {
-		if meta, ok := existingPod["metadata"].(map[string]interface{}); ok {
-			if ownerRefsRaw, ok := meta["ownerReferences"].([]interface{}); ok {
-				metadata.OwnerReferences = convertToOwnerReferences(ownerRefsRaw)
-			}
-		}
+	nodeName, _ := resources.NestedString(pod, "spec", "nodeName")
+	node, err := pc.store.GetNode(nodeName)
+	if err != nil {
+		return "127.0.0.1"
 	}
-
-	updatedPod := resources.Pod{
-		Kind:       pod.Kind,
-		APIVersion: pod.APIVersion,
-		Metadata:   metadata,
-		Spec:       pod.Spec,
-		Status:     status,
+	if address := nodeAddress(node, NodeInternalIP); address != "" {
+		return address
 	}
-
-	// Update in storage
-	return pc.store.UpdatePod(updatedPod)
+	return "127.0.0.1"
 }
 
 // TransitionState defines a single state in a pod's lifecycle transition
-// with the duration to wait before applying it.
+// with the duration to wait before applying it. A step setting loop, branch
+// or waitFor is a control step applying no state (see transition_program.go).
 type TransitionState struct {
 	Phase           string                 `json:"phase"`
 	Delay           string                 `json:"delay"`
+	Jitter          string                 `json:"jitter,omitempty"`
 	PodIP           string                 `json:"podIP,omitempty"`
 	HostIP          string                 `json:"hostIP,omitempty"`
 	Conditions      []PodCondition         `json:"conditions,omitempty"`
 	ContainerStates map[string]interface{} `json:"containerStates,omitempty"`
+	Stop            bool                   `json:"stop,omitempty"`
+	Loop            *TransitionLoop        `json:"loop,omitempty"`
+	Branch          []TransitionBranch     `json:"branch,omitempty"`
+	WaitFor         *TransitionWait        `json:"waitFor,omitempty"`
 }
 
 // TransitionRequest defines a complete state transition sequence for a pod
@@ -376,6 +551,8 @@ type TransitionRequest struct {
 	Namespace      string            `json:"namespace,omitempty"`
 	CancelExisting bool              `json:"cancelExisting,omitempty"`
 	Transitions    []TransitionState `json:"transitions"`
+	// Seed makes the random choices (branches, jitter) reproducible
+	Seed *int64 `json:"seed,omitempty"`
 }
 
 // ActiveTransition tracks a running transition sequence for a pod
@@ -384,6 +561,8 @@ type ActiveTransition struct {
 	Namespace   string
 	CancelFunc  context.CancelFunc
 	Transitions []TransitionState
+	Seed        int64
+	rand        *rand.Rand
 }
 
 // TransitionManager manages active state transitions across pods
@@ -391,6 +570,8 @@ type TransitionManager struct {
 	mu        sync.RWMutex
 	active    map[string]*ActiveTransition // key: "namespace/podName"
 	store     *storage.InMemoryStore
+	// driven maps the pods whose status transitions set, finished or not, to their uid
+	driven map[string]string
 }
 
 // NewTransitionManager creates a new transition manager
@@ -398,9 +579,19 @@ func NewTransitionManager(store *storage.InMemoryStore) *TransitionManager {
 	return &TransitionManager{
 		active: make(map[string]*ActiveTransition),
 		store:  store,
+		driven: make(map[string]string),
 	}
 }
 
+// Drives reports whether the pod's status is set by a transition rather than the pod controller
+func (tm *TransitionManager) Drives(pod map[string]interface{}) bool {
+	name, _ := resources.NestedString(pod, "metadata", "name")
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	uid, ok := tm.driven[tm.key(namespaceOf(pod), name)]
+	return ok && uid == objectUID(pod)
+}
+
 // key generates a unique key for a pod
 func (tm *TransitionManager) key(namespace, podName string) string {
 	if namespace == "" {
@@ -415,6 +606,7 @@ func (tm *TransitionManager) CancelTransition(namespace, podName string) bool {
 	defer tm.mu.Unlock()
 
 	key := tm.key(namespace, podName)
+	delete(tm.driven, key)
 	if active, exists := tm.active[key]; exists {
 		active.CancelFunc()
 		delete(tm.active, key)
@@ -431,6 +623,9 @@ func (tm *TransitionManager) StartTransition(req TransitionRequest) (*ActiveTran
 	if len(req.Transitions) == 0 {
 		return nil, fmt.Errorf("at least one transition is required")
 	}
+	if err := validateTransitions(req.Transitions, "transitions"); err != nil {
+		return nil, err
+	}
 
 	namespace := req.Namespace
 	if namespace == "" {
@@ -443,23 +638,27 @@ func (tm *TransitionManager) StartTransition(req TransitionRequest) (*ActiveTran
 	}
 
 	// Verify pod exists
-	_, err := tm.store.GetPod(req.PodName)
+	pod, err := tm.store.GetPod(req.PodName)
 	if err != nil {
 		return nil, fmt.Errorf("pod %s not found: %w", req.PodName, err)
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
+	rng, seed := newTransitionRand(req.Seed)
 
 	active := &ActiveTransition{
 		PodName:     req.PodName,
 		Namespace:   namespace,
 		CancelFunc:  cancel,
 		Transitions: req.Transitions,
+		Seed:        seed,
+		rand:        rng,
 	}
 
 	// Store the active transition
 	tm.mu.Lock()
 	tm.active[tm.key(namespace, req.PodName)] = active
+	tm.driven[tm.key(namespace, req.PodName)] = objectUID(pod)
 	tm.mu.Unlock()
 
 	// Start the transition sequence in background
@@ -471,47 +670,20 @@ func (tm *TransitionManager) StartTransition(req TransitionRequest) (*ActiveTran
 // runTransitionSequence executes the state transition sequence
 func (tm *TransitionManager) runTransitionSequence(ctx context.Context, active *ActiveTransition) {
 	defer func() {
-		// Clean up on completion
+		// Clean up on completion, unless a newer transition replaced this one
 		tm.mu.Lock()
-		delete(tm.active, tm.key(active.Namespace, active.PodName))
+		key := tm.key(active.Namespace, active.PodName)
+		if tm.active[key] == active {
+			delete(tm.active, key)
+		}
 		tm.mu.Unlock()
 	}()
 
-	for i, state := range active.Transitions {
-		select {
-		case <-ctx.Done():
-			return // Transition was cancelled
-		default:
-		}
-
-		// Parse delay duration
-		delay, err := time.ParseDuration(state.Delay)
-		if err != nil {
-			delay = 0
-		}
-
-		// Wait for the delay (skip for first state if delay is 0)
-		if i > 0 || delay > 0 {
-			select {
-			case <-time.After(delay):
-			case <-ctx.Done():
-				return
-			}
-		}
-
-		// Apply the state
-		tm.applyState(active.Namespace, active.PodName, state)
-	}
+	tm.runSteps(ctx, active, active.Transitions)
 }
 
 // applyState applies a single transition state to a pod
 func (tm *TransitionManager) applyState(namespace, podName string, state TransitionState) error {
-	// Get current pod
-	existingPod, err := tm.store.GetPod(podName)
-	if err != nil {
-		return err
-	}
-
 	// Build conditions with timestamps
 	now := time.Now()
 	conditions := make([]PodCondition, 0, len(state.Conditions))
@@ -533,44 +705,12 @@ func (tm *TransitionManager) applyState(namespace, podName string, state Transit
 		status.ContainerStatuses = tm.buildContainerStatusesFromStates(state.ContainerStates)
 	}
 
-	// Extract existing metadata
-	metadata := resources.ObjectMeta{
-		Name:      podName,
-		Namespace: namespace,
-	}
-	if meta, ok := existingPod["metadata"].(map[string]interface{}); ok {
-		if ns, ok := meta["namespace"].(string); ok {
-			metadata.Namespace = ns
-		}
-		if ct, ok := meta["creationTimestamp"].(string); ok {
-			metadata.CreationTimestamp = ct
-		}
-		if labels, ok := meta["labels"].(map[string]interface{}); ok {
-			metadata.Labels = make(map[string]string)
-			for k, v := range labels {
-				if sv, ok := v.(string); ok {
-					metadata.Labels[k] = sv
-				}
-			}
-		}
-	}
-
-	// Extract spec
-	var spec interface{}
-	if s, ok := existingPod["spec"]; ok {
-		spec = s
-	}
-
-	// Create updated pod
-	updatedPod := resources.Pod{
-		Kind:       "Pod",
-		APIVersion: "v1",
-		Metadata:   metadata,
-		Spec:       spec,
-		Status:     status,
-	}
-
-	return tm.store.UpdatePod(updatedPod)
+	// Only the status changes; metadata (ownerReferences, deletionTimestamp) stays as stored
+	_, err := tm.store.MutatePod(podName, func(stored map[string]interface{}) error {
+		stored["status"] = status
+		return nil
+	})
+	return err
 }
 
 // buildContainerStatusesFromStates creates ContainerStatus from the state definition
@@ -633,6 +773,9 @@ var DefaultTransitionManager *TransitionManager
 // DefaultStartupDelay is the default time a pod stays in Pending before transitioning to Running.
 const DefaultStartupDelay = 20 * time.Second
 
+// DefaultShutdownDelay is the default time containers take to exit after SIGTERM.
+const DefaultShutdownDelay = 2 * time.Second
+
 // InitPodController initializes the default pod controller with the default startup delay.
 func InitPodController(store *storage.InMemoryStore) {
 	DefaultPodController = NewPodController(store, DefaultStartupDelay)
@@ -645,6 +788,7 @@ type TransitionTemplate struct {
 	PodName     string            `json:"podName"`
 	Namespace   string            `json:"namespace,omitempty"`
 	Transitions []TransitionState `json:"transitions"`
+	Seed        *int64            `json:"seed,omitempty"`
 	CreatedAt   time.Time         `json:"createdAt"`
 }
 
@@ -652,6 +796,8 @@ type TransitionTemplate struct {
 type TemplateRegistry struct {
 	mu        sync.RWMutex
 	templates map[string]*TransitionTemplate // key: "namespace/podName"
+	// scenarios hold the templates matching pods by name pattern, labels or owner
+	scenarios []*loadedScenario
 }
 
 // NewTemplateRegistry creates a new template registry
@@ -677,6 +823,9 @@ func (tr *TemplateRegistry) RegisterTemplate(template TransitionTemplate) error
 	if len(template.Transitions) == 0 {
 		return fmt.Errorf("at least one transition is required")
 	}
+	if err := validateTransitions(template.Transitions, "transitions"); err != nil {
+		return err
+	}
 
 	namespace := template.Namespace
 	if namespace == "" {
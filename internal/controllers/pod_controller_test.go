@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 	"time"
 
@@ -174,3 +175,175 @@ func TestPodStatusJSON(t *testing.T) {
 		t.Errorf("Expected podIP 10.244.0.1, got %v", unmarshaled["podIP"])
 	}
 }
+
+func TestPodControllerGracefulDeletion(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	controller := NewPodController(store, 10*time.Millisecond)
+	controller.ShutdownDelay = 50 * time.Millisecond
+	controller.Start()
+	defer controller.Stop()
+
+	pod := resources.Pod{
+		Kind: "Pod", APIVersion: "v1",
+		Metadata: resources.ObjectMeta{Name: "graceful-pod", Namespace: "default"},
+		Spec: map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"name": "nginx", "image": "nginx"},
+			},
+		},
+	}
+	store.CreatePod(pod)
+	controller.OnPodCreated(pod)
+	time.Sleep(30 * time.Millisecond)
+
+	deleting, err := controller.DeletePod("graceful-pod", 30)
+	if err != nil {
+		t.Fatalf("DeletePod failed: %v", err)
+	}
+
+	// Marked, but still stored while the containers shut down
+	meta := deleting["metadata"].(map[string]interface{})
+	if grace, _ := resources.ToInt64(meta["deletionGracePeriodSeconds"]); meta["deletionTimestamp"] == nil || grace != 30 {
+		t.Errorf("Expected deletionTimestamp and deletionGracePeriodSeconds 30, got %v", meta)
+	}
+	stored, err := store.GetPod("graceful-pod")
+	if err != nil {
+		t.Fatalf("Expected pod to still exist while terminating: %v", err)
+	}
+	cs := stored["status"].(map[string]interface{})["containerStatuses"].([]interface{})[0].(map[string]interface{})
+	if cs["ready"] != false {
+		t.Errorf("Expected container to be unready while terminating, got %v", cs["ready"])
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if _, err := store.GetPod("graceful-pod"); err == nil {
+		t.Error("Expected pod to be removed once its containers exited")
+	}
+}
+
+func TestPodControllerForceDeletion(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	controller := NewPodController(store, time.Hour)
+	defer controller.Stop()
+
+	pod := resources.Pod{
+		Kind: "Pod", APIVersion: "v1",
+		Metadata: resources.ObjectMeta{Name: "forced-pod", Namespace: "default"},
+	}
+	store.CreatePod(pod)
+
+	if _, err := controller.DeletePod("forced-pod", 0); err != nil {
+		t.Fatalf("DeletePod failed: %v", err)
+	}
+	if _, err := store.GetPod("forced-pod"); err == nil {
+		t.Error("Expected grace period 0 to remove the pod immediately")
+	}
+}
+
+func TestRestartBackOffDoublesAndResets(t *testing.T) {
+	pc := NewPodController(storage.NewInMemoryStore(), time.Hour)
+	defer pc.Stop()
+	now := time.Now()
+	want := []time.Duration{0, 10 * time.Second, 20 * time.Second, 40 * time.Second, 80 * time.Second, 160 * time.Second, 300 * time.Second, 300 * time.Second}
+	for i, delay := range want {
+		if got := pc.nextRestartBackOff("uid/app", now); got != delay {
+			t.Errorf("exit %d: expected back-off %s, got %s", i+1, delay, got)
+		}
+	}
+	// a container that ran for more than 10 minutes starts over
+	if got := pc.nextRestartBackOff("uid/app", now.Add(11*time.Minute)); got != 0 {
+		t.Errorf("Expected the back-off to reset, got %s", got)
+	}
+}
+
+func TestCrashLoopBackOff(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	pc := usePodController(t, store)
+	pc.RestartBackOff = 100 * time.Millisecond
+
+	pod := newTestPod("crasher", nil, map[string]interface{}{"restartPolicy": "Always"})
+	pod.Metadata.Annotations = map[string]string{RunDurationAnnotation: "20ms", ExitCodeAnnotation: "1"}
+	if err := createPod(store, pod); err != nil {
+		t.Fatalf("Failed to create pod: %v", err)
+	}
+	container := func() map[string]interface{} {
+		stored, _ := store.GetPod("crasher")
+		return podContainerStatus(stored, "app")
+	}
+
+	// the first exit restarts right away, the second waits the back-off
+	waitFor(t, 5*time.Second, "CrashLoopBackOff", func() bool {
+		reason, _ := resources.NestedString(container(), "state", "waiting", "reason")
+		return reason == ContainerReasonCrashLoopBackOff
+	})
+	app := container()
+	message, _ := resources.NestedString(app, "state", "waiting", "message")
+	if app["restartCount"] != float64(1) || !strings.HasPrefix(message, "back-off 100ms restarting failed container=app pod=crasher_default(") {
+		t.Errorf("Expected restartCount 1 and a 100ms back-off, got %v", app)
+	}
+	if code, _ := resources.NestedInt64(app, "lastState", "terminated", "exitCode"); code != 1 {
+		t.Errorf("Expected lastState.terminated with exit code 1, got %v", app["lastState"])
+	}
+	stored, _ := store.GetPod("crasher")
+	if phase, _ := resources.NestedString(stored, "status", "phase"); phase != string(PodRunning) || isPodReady(stored) {
+		t.Errorf("Expected a running, unready pod in back-off, got %v", stored["status"])
+	}
+	if !hasEvent(store, EventReasonBackOff, "Back-off restarting failed container app in pod crasher_default") {
+		t.Error("Expected a BackOff event")
+	}
+
+	// the next back-off is twice as long
+	waitFor(t, 5*time.Second, "second back-off", func() bool {
+		message, _ := resources.NestedString(container(), "state", "waiting", "message")
+		return strings.HasPrefix(message, "back-off 200ms")
+	})
+	if restarts := container()["restartCount"]; restarts != float64(2) {
+		t.Errorf("Expected restartCount 2, got %v", restarts)
+	}
+}
+
+func TestRestartPolicies(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	usePodController(t, store)
+	twoContainers := func() map[string]interface{} {
+		return map[string]interface{}{"containers": []interface{}{
+			map[string]interface{}{"name": "main", "image": "main:v1"},
+			map[string]interface{}{"name": "helper", "image": "helper:v1"},
+		}}
+	}
+	phase := func(name string) string {
+		stored, _ := store.GetPod(name)
+		phase, _ := resources.NestedString(stored, "status", "phase")
+		return phase
+	}
+
+	// Never: each container exits on its own; the pod fails once both are done
+	never := newTestPod("never", nil, twoContainers())
+	never.Spec.(map[string]interface{})["restartPolicy"] = "Never"
+	never.Metadata.Annotations = map[string]string{
+		RunDurationAnnotation:             "20ms",
+		ExitCodeAnnotation + ".main":      "3",
+		RunDurationAnnotation + ".helper": "200ms",
+	}
+	createPod(store, never)
+	waitFor(t, 5*time.Second, "main exited", func() bool {
+		stored, _ := store.GetPod("never")
+		code, ok := resources.NestedInt64(podContainerStatus(stored, "main"), "state", "terminated", "exitCode")
+		return ok && code == 3
+	})
+	if p := phase("never"); p != string(PodRunning) {
+		t.Errorf("Expected the pod to run while helper does, got %s", p)
+	}
+	waitFor(t, 5*time.Second, "never failed", func() bool { return phase("never") == string(PodFailed) })
+
+	// OnFailure: a failed container restarts, then the pod succeeds
+	onFailure := newTestPod("on-failure", nil, twoContainers())
+	onFailure.Spec.(map[string]interface{})["restartPolicy"] = "OnFailure"
+	onFailure.Metadata.Annotations = map[string]string{RunDurationAnnotation: "20ms", ExitCodeAnnotation + ".main": "1,0"}
+	createPod(store, onFailure)
+	waitFor(t, 5*time.Second, "on-failure succeeded", func() bool { return phase("on-failure") == string(PodSucceeded) })
+	stored, _ := store.GetPod("on-failure")
+	if main, helper := podContainerStatus(stored, "main"), podContainerStatus(stored, "helper"); main["restartCount"] != float64(1) || helper["restartCount"] != float64(0) {
+		t.Errorf("Expected only main to restart once, got %v and %v", main, helper)
+	}
+}
@@ -0,0 +1,306 @@
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	"mockernetes/internal/resources"
+)
+
+// Image pulls (kubelet side). When a pod starts, each container's image is pulled from the
+// fake registry on the pod's node according to its imagePullPolicy: Always pulls, IfNotPresent
+// only pulls images the node doesn't have yet, Never only runs images the node has. Containers
+// wait in ContainerCreating while their image pulls, and the pod stays Pending until they all
+// started. A failed pull shows ErrImagePull and then ImagePullBackOff until it is retried,
+// after a back-off doubling from RestartBackOff up to MaxRestartBackOff.
+
+// Waiting reasons of containers that haven't started
+const (
+	ContainerReasonContainerCreating = "ContainerCreating"
+	ContainerReasonErrImagePull      = "ErrImagePull"
+	ContainerReasonImagePullBackOff  = "ImagePullBackOff"
+	ContainerReasonErrImageNeverPull = "ErrImageNeverPull"
+)
+
+// Event reasons of image pulls
+const (
+	EventReasonPulling = "Pulling"
+	EventReasonPulled  = "Pulled"
+	EventReasonFailed  = "Failed"
+)
+
+// imagePull is the outcome of pulling the image of a container
+type imagePull struct {
+	container string
+	image     string
+	imageID   string
+	// duration is how long the pull takes; pulled is false when the node had the image
+	duration time.Duration
+	pulled   bool
+	// reason and message say why the image can't be pulled
+	reason  string
+	message string
+}
+
+// podImageRegistry returns DefaultImageRegistry, or a registry that has every image
+func podImageRegistry() *ImageRegistry {
+	if DefaultImageRegistry != nil {
+		return DefaultImageRegistry
+	}
+	registry, _ := NewImageRegistry(nil)
+	return registry
+}
+
+// pullImages pulls the images of the containers of a starting pod
+func (pc *PodController) pullImages(pod map[string]interface{}) []imagePull {
+	var pulls []imagePull
+	containers, _ := resources.NestedSlice(pod, "spec", "containers")
+	for _, item := range containers {
+		if container, ok := item.(map[string]interface{}); ok {
+			pulls = append(pulls, pc.pullImage(pod, container))
+		}
+	}
+	return pulls
+}
+
+// startingContainers pulls the images of a pod's containers and returns their statuses, and
+// the pod's phase: Pending while a container waits for its image
+func (pc *PodController) startingContainers(pod map[string]interface{}) ([]ContainerStatus, []imagePull, PodPhase) {
+	pulls := pc.pullImages(pod)
+	statuses := pc.buildContainerStatuses(pod["spec"], pulls)
+	phase := PodRunning
+	for _, cs := range statuses {
+		if _, waiting := cs.State["waiting"]; waiting {
+			phase = PodPending
+		}
+	}
+	return statuses, pulls, phase
+}
+
+// findImagePull returns the pull of the named container, if any
+func findImagePull(pulls []imagePull, container string) *imagePull {
+	for i := range pulls {
+		if pulls[i].container == container {
+			return &pulls[i]
+		}
+	}
+	return nil
+}
+
+// waitingState returns the waiting state of a container until its image is pulled, or nil if
+// it can start right away
+func (p *imagePull) waitingState() map[string]interface{} {
+	switch {
+	case p.reason != "":
+		return map[string]interface{}{"reason": p.reason, "message": p.message}
+	case p.pulled && p.duration > 0:
+		return map[string]interface{}{"reason": ContainerReasonContainerCreating}
+	}
+	return nil
+}
+
+// pullImage pulls the image of a container of pod on the pod's node
+func (pc *PodController) pullImage(pod, container map[string]interface{}) imagePull {
+	name, _ := container["name"].(string)
+	image, _ := container["image"].(string)
+	policy, _ := container["imagePullPolicy"].(string)
+	if policy == "" {
+		policy = resources.DefaultImagePullPolicy(image)
+	}
+	node, _ := resources.NestedString(pod, "spec", "nodeName")
+	present := pc.Images.isPresent(node, image)
+	if policy == "Never" {
+		if !present {
+			return imagePull{container: name, image: image, reason: ContainerReasonErrImageNeverPull,
+				message: fmt.Sprintf("Container image %q is not present with pull policy of Never", image)}
+		}
+	} else if policy == "Always" || !present {
+		imageID, duration, err := pc.Images.resolve(image, podPullSecrets(pod))
+		if err != nil {
+			return imagePull{container: name, image: image, reason: ContainerReasonErrImagePull, message: err.Error()}
+		}
+		if present {
+			// only the manifest is fetched again
+			duration = 0
+		}
+		return imagePull{container: name, image: image, imageID: imageID, duration: duration, pulled: true}
+	}
+	imageID, _, _ := pc.Images.resolve(image, nil)
+	return imagePull{container: name, image: image, imageID: imageID}
+}
+
+// followImagePull reports a container's image pull in events and carries it on: the container
+// starts once its image is pulled, and a failed pull is retried after the back-off.
+func (pc *PodController) followImagePull(pod map[string]interface{}, pull imagePull) {
+	podName, _ := resources.NestedString(pod, "metadata", "name")
+	uid, container := objectUID(pod), pull.container
+	switch {
+	case pull.reason == ContainerReasonErrImageNeverPull:
+		recordEvent(pc.store, pod, EventTypeWarning, ContainerReasonErrImageNeverPull, pull.message, "kubelet")
+	case pull.reason != "":
+		recordEvent(pc.store, pod, EventTypeNormal, EventReasonPulling, fmt.Sprintf("Pulling image %q", pull.image), "kubelet")
+		recordEvent(pc.store, pod, EventTypeWarning, EventReasonFailed, fmt.Sprintf("Failed to pull image %q: %s", pull.image, pull.message), "kubelet")
+		recordEvent(pc.store, pod, EventTypeWarning, EventReasonFailed, "Error: "+ContainerReasonErrImagePull, "kubelet")
+		fmt.Printf("[Pod Controller] Failed to pull image %s for container %s of pod %s\n", pull.image, container, podName)
+		pc.retryImagePull(podName, uid, container, pull)
+	case !pull.pulled:
+		recordEvent(pc.store, pod, EventTypeNormal, EventReasonPulled, fmt.Sprintf("Container image %q already present on machine", pull.image), "kubelet")
+		pc.startWaitingContainer(podName, uid, container, pull.imageID)
+	default:
+		recordEvent(pc.store, pod, EventTypeNormal, EventReasonPulling, fmt.Sprintf("Pulling image %q", pull.image), "kubelet")
+		pulled := func() {
+			node, _ := resources.NestedString(pod, "spec", "nodeName")
+			pc.Images.markPresent(node, pull.image)
+			recordEvent(pc.store, pod, EventTypeNormal, EventReasonPulled,
+				fmt.Sprintf("Successfully pulled image %q in %s (%s including waiting)", pull.image, pull.duration, pull.duration), "kubelet")
+			pc.startWaitingContainer(podName, uid, container, pull.imageID)
+		}
+		if pull.duration <= 0 {
+			pulled()
+			return
+		}
+		go func() {
+			if pc.after(pull.duration) {
+				pulled()
+			}
+		}()
+	}
+}
+
+// retryImagePull backs off a failed image pull: the container shows ErrImagePull until the
+// next pod sync (RestartBackOff later), then ImagePullBackOff until the pull is retried.
+func (pc *PodController) retryImagePull(podName, uid, container string, pull imagePull) {
+	delay := pc.nextImagePullBackOff(uid+"/"+container+"/image", time.Now())
+	go func() {
+		if !pc.after(pc.RestartBackOff) {
+			return
+		}
+		message := fmt.Sprintf("Back-off pulling image %q", pull.image)
+		pod, ok := pc.setContainerWaiting(podName, uid, container, ContainerReasonImagePullBackOff, message)
+		if !ok {
+			return
+		}
+		recordEvent(pc.store, pod, EventTypeNormal, EventReasonBackOff, message, "kubelet")
+		recordEvent(pc.store, pod, EventTypeWarning, EventReasonFailed, "Error: "+ContainerReasonImagePullBackOff, "kubelet")
+		if !pc.after(delay) {
+			return
+		}
+		pod, err := pc.store.GetPod(podName)
+		if err != nil || objectUID(pod) != uid || isBeingDeleted(pod) {
+			return
+		}
+		retry := pc.pullImage(pod, podContainerSpec(pod, container))
+		if retry.reason != "" {
+			if pod, ok = pc.setContainerWaiting(podName, uid, container, retry.reason, retry.message); !ok {
+				return
+			}
+		}
+		pc.followImagePull(pod, retry)
+	}()
+}
+
+// nextImagePullBackOff returns how long a failed image pull waits before it is retried; unlike
+// restarts, the first retry waits too.
+func (pc *PodController) nextImagePullBackOff(key string, now time.Time) time.Duration {
+	if delay := pc.nextRestartBackOff(key, now); delay > 0 {
+		return delay
+	}
+	return pc.nextRestartBackOff(key, now)
+}
+
+// setContainerWaiting changes the waiting reason of a container that hasn't started. It returns
+// the pod as stored, and false if the container doesn't wait (anymore).
+func (pc *PodController) setContainerWaiting(podName, uid, container, reason, message string) (map[string]interface{}, bool) {
+	waiting := false
+	pod, err := pc.store.MutatePod(podName, func(pod map[string]interface{}) error {
+		waiting = false
+		containerStatus := podContainerStatus(pod, container)
+		if objectUID(pod) != uid || isBeingDeleted(pod) || containerStatus == nil {
+			return nil
+		}
+		if _, ok := resources.NestedMap(containerStatus, "state", "waiting"); !ok {
+			return nil
+		}
+		containerStatus["state"] = map[string]interface{}{
+			"waiting": map[string]interface{}{"reason": reason, "message": message},
+		}
+		waiting = true
+		return nil
+	})
+	return pod, err == nil && waiting
+}
+
+// startWaitingContainer starts a container whose image has been pulled. The pod runs once none
+// of its containers waits anymore.
+func (pc *PodController) startWaitingContainer(podName, uid, container, imageID string) {
+	now := time.Now()
+	running := false
+	_, err := pc.store.MutatePod(podName, func(pod map[string]interface{}) error {
+		running = false
+		containerStatus := podContainerStatus(pod, container)
+		if objectUID(pod) != uid || isBeingDeleted(pod) || containerStatus == nil {
+			return nil
+		}
+		if _, ok := resources.NestedMap(containerStatus, "state", "waiting"); !ok {
+			return nil
+		}
+		started, ready := containerStartState(podContainerSpec(pod, container))
+		containerStatus["imageID"] = imageID
+		containerStatus["state"] = map[string]interface{}{
+			"running": map[string]interface{}{"startedAt": now.Format(time.RFC3339)},
+		}
+		containerStatus["started"] = started
+		containerStatus["ready"] = ready
+
+		status := pod["status"].(map[string]interface{})
+		containerStatuses, _ := resources.NestedSlice(status, "containerStatuses")
+		for _, cs := range containerStatuses {
+			if _, waiting := resources.NestedMap(cs.(map[string]interface{}), "state", "waiting"); waiting {
+				setReadyConditions(status, now)
+				return nil
+			}
+		}
+		running = status["phase"] == string(PodPending)
+		status["phase"] = string(PodRunning)
+		setReadyConditions(status, now)
+		return nil
+	})
+	if err != nil || !running {
+		return
+	}
+	fmt.Printf("[Pod Controller] Pod %s is running\n", podName)
+	pc.scheduleExit(podName)
+	pc.startProbes(podName)
+}
+
+// after waits for d, and reports false when the controller stopped meanwhile
+func (pc *PodController) after(d time.Duration) bool {
+	select {
+	case <-time.After(d):
+		return true
+	case <-pc.stopCh:
+		return false
+	}
+}
+
+// containerStartState returns whether a container that just started counts as started and
+// ready: not before its startup and readiness probes pass.
+func containerStartState(container map[string]interface{}) (started, ready bool) {
+	_, hasStartupProbe := container["startupProbe"]
+	_, hasReadinessProbe := container["readinessProbe"]
+	return !hasStartupProbe, !hasStartupProbe && !hasReadinessProbe
+}
+
+// podPullSecrets returns the names of the pod's imagePullSecrets
+func podPullSecrets(pod map[string]interface{}) []string {
+	var names []string
+	secrets, _ := resources.NestedSlice(pod, "spec", "imagePullSecrets")
+	for _, item := range secrets {
+		if secret, ok := item.(map[string]interface{}); ok {
+			if name, _ := secret["name"].(string); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
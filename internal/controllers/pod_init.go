@@ -0,0 +1,364 @@
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"mockernetes/internal/resources"
+)
+
+// Init containers (kubelet side). A pod with spec.initContainers runs them one at a time, in
+// order, before its containers start; meanwhile the pod is Pending with Initialized False.
+// An init container runs for InitContainerDuration and exits with 0, unless annotated with
+// run-duration.<name> and exit-code.<name> (the pod-wide annotations are left to the
+// containers). A failed init container is restarted after the restart back-off, or fails the
+// pod under restartPolicy Never. Init containers with restartPolicy Always are native sidecars:
+// the next one starts as soon as the sidecar started, and it keeps running with the containers.
+// Ephemeral containers (kubectl debug) run from the start.
+
+// DefaultInitContainerDuration is how long init containers run unless annotated.
+const DefaultInitContainerDuration = time.Second
+
+// ContainerReasonPodInitializing is the waiting reason of containers until the pod is initialized
+const ContainerReasonPodInitializing = "PodInitializing"
+
+// podInitContainers returns the init containers of a pod's spec
+func podInitContainers(pod map[string]interface{}) []map[string]interface{} {
+	var containers []map[string]interface{}
+	items, _ := resources.NestedSlice(pod, "spec", "initContainers")
+	for _, item := range items {
+		if container, ok := item.(map[string]interface{}); ok {
+			containers = append(containers, container)
+		}
+	}
+	return containers
+}
+
+// isSidecar reports whether an init container is a native sidecar (restartPolicy Always)
+func isSidecar(container map[string]interface{}) bool {
+	return container["restartPolicy"] == "Always"
+}
+
+// initContainerStatus returns the status of the named init container of a pod, if any
+func initContainerStatus(pod map[string]interface{}, name string) map[string]interface{} {
+	statuses, _ := resources.NestedSlice(pod, "status", "initContainerStatuses")
+	for _, cs := range statuses {
+		if containerStatus, ok := cs.(map[string]interface{}); ok && containerStatus["name"] == name {
+			return containerStatus
+		}
+	}
+	return nil
+}
+
+// initContainerDone reports whether an init container let the next one start: it completed,
+// or it is a sidecar that started
+func initContainerDone(container, containerStatus map[string]interface{}) bool {
+	if code, ok := resources.NestedInt64(containerStatus, "state", "terminated", "exitCode"); ok && code == 0 {
+		return true
+	}
+	_, running := resources.NestedMap(containerStatus, "state", "running")
+	return isSidecar(container) && running && containerStatus["started"] == true
+}
+
+// incompleteInitContainers returns the names of the init containers of a pod that aren't done
+func incompleteInitContainers(pod map[string]interface{}) []string {
+	var names []string
+	for _, container := range podInitContainers(pod) {
+		name, _ := container["name"].(string)
+		if !initContainerDone(container, initContainerStatus(pod, name)) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// incompleteMessage is the message of Initialized conditions with incomplete init containers
+func incompleteMessage(names []string) string {
+	return fmt.Sprintf("containers with incomplete status: [%s]", strings.Join(names, " "))
+}
+
+// setInitializedCondition sets the Initialized condition of a pod from its init containers
+func setInitializedCondition(pod map[string]interface{}, now time.Time) {
+	cond := podCondition(pod, "Initialized")
+	if cond == nil {
+		return
+	}
+	status := "True"
+	incomplete := incompleteInitContainers(pod)
+	if len(incomplete) > 0 {
+		status = "False"
+	}
+	if cond["status"] != status {
+		cond["status"] = status
+		cond["lastTransitionTime"] = now.UTC().Format(time.RFC3339)
+	}
+	if len(incomplete) == 0 {
+		delete(cond, "reason")
+		delete(cond, "message")
+		return
+	}
+	cond["reason"] = "ContainersNotInitialized"
+	cond["message"] = incompleteMessage(incomplete)
+}
+
+// waitingContainerStatuses returns the statuses of containers that haven't started yet
+func waitingContainerStatuses(containers []interface{}, idPrefix, reason string) []ContainerStatus {
+	var statuses []ContainerStatus
+	for i, item := range containers {
+		container, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := container["name"].(string)
+		image, _ := container["image"].(string)
+		started := false
+		statuses = append(statuses, ContainerStatus{
+			Name:        name,
+			Image:       image,
+			ContainerID: fmt.Sprintf("docker://%s-%d", idPrefix, i),
+			Started:     &started,
+			State: map[string]interface{}{
+				"waiting": map[string]interface{}{"reason": reason},
+			},
+			LastState: map[string]interface{}{},
+		})
+	}
+	return statuses
+}
+
+// ephemeralContainerStatuses returns the statuses of a starting pod's ephemeral containers,
+// which run right away and are never ready
+func (pc *PodController) ephemeralContainerStatuses(pod map[string]interface{}) []ContainerStatus {
+	containers, _ := resources.NestedSlice(pod, "spec", "ephemeralContainers")
+	statuses := waitingContainerStatuses(containers, "ephemeral-container", "")
+	now := time.Now().Format(time.RFC3339)
+	for i := range statuses {
+		imageID, _, _ := pc.Images.resolve(statuses[i].Image, nil)
+		started := true
+		statuses[i].ImageID = imageID
+		statuses[i].Started = &started
+		statuses[i].State = map[string]interface{}{
+			"running": map[string]interface{}{"startedAt": now},
+		}
+	}
+	return statuses
+}
+
+// initContainerRunDuration returns how long an init container runs before it exits
+func (pc *PodController) initContainerRunDuration(pod map[string]interface{}, container string) time.Duration {
+	value, ok := resources.NestedString(pod, "metadata", "annotations", RunDurationAnnotation+"."+container)
+	if !ok {
+		return pc.InitContainerDuration
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		podName, _ := resources.NestedString(pod, "metadata", "name")
+		fmt.Printf("[Pod Controller] Ignoring invalid %s.%s %q on pod %s: %v\n", RunDurationAnnotation, container, value, podName, err)
+		return pc.InitContainerDuration
+	}
+	return duration
+}
+
+// initContainerExitCode returns the exit code of an init container after restarts restarts
+func initContainerExitCode(pod map[string]interface{}, container string, restarts int64) int {
+	value, _ := resources.NestedString(pod, "metadata", "annotations", ExitCodeAnnotation+"."+container)
+	attemptValue, _ := resources.NestedString(pod, "metadata", "annotations", AttemptAnnotation)
+	attempt, _ := strconv.Atoi(attemptValue)
+	return attemptExitCode(value, attempt+int(restarts))
+}
+
+// runInitContainer starts (or, after the back-off, restarts) the index-th init container of a
+// pod that is initializing. restarts is the container's restartCount it expects.
+func (pc *PodController) runInitContainer(podName, uid string, index int, restarts int64) {
+	now := time.Now()
+	var container map[string]interface{}
+	stored, err := pc.store.MutatePod(podName, func(pod map[string]interface{}) error {
+		container = nil
+		containers := podInitContainers(pod)
+		phase, _ := resources.NestedString(pod, "status", "phase")
+		if objectUID(pod) != uid || isBeingDeleted(pod) || phase != string(PodPending) || index >= len(containers) {
+			return nil
+		}
+		name, _ := containers[index]["name"].(string)
+		containerStatus := initContainerStatus(pod, name)
+		if containerStatus == nil {
+			return nil
+		}
+		if current, _ := resources.ToInt64(containerStatus["restartCount"]); current != restarts {
+			return nil
+		}
+		if reason, _ := resources.NestedString(containerStatus, "state", "waiting", "reason"); reason != ContainerReasonPodInitializing {
+			containerStatus["restartCount"] = restarts + 1
+		}
+		image, _ := containers[index]["image"].(string)
+		imageID, _, _ := pc.Images.resolve(image, podPullSecrets(pod))
+		containerStatus["imageID"] = imageID
+		containerStatus["state"] = map[string]interface{}{
+			"running": map[string]interface{}{"startedAt": now.Format(time.RFC3339)},
+		}
+		containerStatus["started"] = true
+		containerStatus["ready"] = isSidecar(containers[index])
+		setInitializedCondition(pod, now)
+		container = containers[index]
+		return nil
+	})
+	if err != nil || container == nil {
+		return
+	}
+	name, _ := container["name"].(string)
+	fmt.Printf("[Pod Controller] Running init container %s of pod %s\n", name, podName)
+	if isSidecar(container) {
+		pc.nextInitContainer(podName, uid, index+1)
+		return
+	}
+	duration := pc.initContainerRunDuration(stored, name)
+	current, _ := resources.ToInt64(initContainerStatus(stored, name)["restartCount"])
+	go func() {
+		if pc.after(duration) {
+			pc.onInitContainerExited(podName, uid, index, current)
+		}
+	}()
+}
+
+// nextInitContainer runs the index-th init container of a pod, or its containers once every
+// init container is done
+func (pc *PodController) nextInitContainer(podName, uid string, index int) {
+	pod, err := pc.store.GetPod(podName)
+	if err != nil || objectUID(pod) != uid {
+		return
+	}
+	if index < len(podInitContainers(pod)) {
+		pc.runInitContainer(podName, uid, index, 0)
+		return
+	}
+	pc.startContainers(podName, uid)
+}
+
+// onInitContainerExited is called when a running init container exits at the end of its run
+// time: the next one starts when it succeeded, else it is restarted after the back-off or,
+// under restartPolicy Never, the pod fails.
+func (pc *PodController) onInitContainerExited(podName, uid string, index int, restarts int64) {
+	now := time.Now()
+	exited, exitCode := false, 0
+	var restart *containerRestart
+	stored, err := pc.store.MutatePod(podName, func(pod map[string]interface{}) error {
+		exited, restart = false, nil
+		containers := podInitContainers(pod)
+		phase, _ := resources.NestedString(pod, "status", "phase")
+		if objectUID(pod) != uid || isBeingDeleted(pod) || phase != string(PodPending) || index >= len(containers) {
+			return nil
+		}
+		name, _ := containers[index]["name"].(string)
+		containerStatus := initContainerStatus(pod, name)
+		if containerStatus == nil {
+			return nil
+		}
+		if current, _ := resources.ToInt64(containerStatus["restartCount"]); current != restarts {
+			return nil
+		}
+		if _, running := resources.NestedMap(containerStatus, "state", "running"); !running {
+			return nil
+		}
+		exitCode = initContainerExitCode(pod, name, restarts)
+		reason := "Completed"
+		if exitCode != 0 {
+			reason = "Error"
+		}
+		terminated := exitedState(containerStatus, exitCode, reason, now)
+		containerStatus["state"] = terminated
+		containerStatus["ready"] = exitCode == 0
+		exited = true
+		if exitCode != 0 {
+			if policy, _ := resources.NestedString(pod, "spec", "restartPolicy"); policy == "Never" {
+				status := pod["status"].(map[string]interface{})
+				status["phase"] = string(PodFailed)
+				stopRunningContainers(status, "initContainerStatuses", 0, "Completed", now)
+				stopRunningContainers(status, "ephemeralContainerStatuses", 0, "Completed", now)
+			} else {
+				delay := pc.nextRestartBackOff(uid+"/"+name, now)
+				containerStatus["lastState"] = terminated
+				if delay > 0 {
+					containerStatus["state"] = crashLoopBackOffState(pod, name, delay)
+				}
+				restart = &containerRestart{container: name, restarts: restarts, delay: delay}
+			}
+		}
+		setInitializedCondition(pod, now)
+		return nil
+	})
+	if err != nil || !exited {
+		return
+	}
+	switch {
+	case exitCode == 0:
+		pc.nextInitContainer(podName, uid, index+1)
+	case restart != nil:
+		if restart.delay > 0 {
+			recordEvent(pc.store, stored, EventTypeWarning, EventReasonBackOff,
+				fmt.Sprintf("Back-off restarting failed container %s in pod %s_%s(%s)", restart.container, podName, namespaceOf(stored), uid), "kubelet")
+		}
+		go func() {
+			if pc.after(restart.delay) {
+				pc.runInitContainer(podName, uid, index, restarts)
+			}
+		}()
+	default:
+		fmt.Printf("[Pod Controller] Pod %s failed: init container exited with code %d\n", podName, exitCode)
+	}
+}
+
+// stopRunningContainers records the running containers of a status field (initContainerStatuses,
+// ...) as terminated with exitCode
+func stopRunningContainers(status map[string]interface{}, field string, exitCode int, reason string, now time.Time) {
+	statuses, _ := resources.NestedSlice(status, field)
+	for _, cs := range statuses {
+		containerStatus, ok := cs.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if _, running := resources.NestedMap(containerStatus, "state", "running"); running {
+			containerStatus["state"] = exitedState(containerStatus, exitCode, reason, now)
+			containerStatus["ready"] = false
+		}
+	}
+}
+
+// startContainers starts the containers of a pod whose init containers are done
+func (pc *PodController) startContainers(podName, uid string) {
+	stored, err := pc.store.GetPod(podName)
+	if err != nil || objectUID(stored) != uid {
+		return
+	}
+	statuses, pulls, phase := pc.startingContainers(stored)
+	var containerStatuses []interface{}
+	deepCopyJSON(statuses, &containerStatuses)
+	now := time.Now()
+	started := false
+	_, err = pc.store.MutatePod(podName, func(pod map[string]interface{}) error {
+		started = false
+		current, _ := resources.NestedString(pod, "status", "phase")
+		if objectUID(pod) != uid || isBeingDeleted(pod) || current != string(PodPending) {
+			return nil
+		}
+		status := pod["status"].(map[string]interface{})
+		status["containerStatuses"] = containerStatuses
+		status["phase"] = string(phase)
+		setInitializedCondition(pod, now)
+		setReadyConditions(status, now)
+		started = true
+		return nil
+	})
+	if err != nil || !started {
+		return
+	}
+	fmt.Printf("[Pod Controller] Pod %s initialized\n", podName)
+	for _, pull := range pulls {
+		pc.followImagePull(stored, pull)
+	}
+	if phase == PodRunning {
+		pc.scheduleExit(podName)
+		pc.startProbes(podName)
+	}
+}
@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+// newInitPod returns a pod with init containers "migrate" and "warmup" around a native
+// sidecar "proxy", a container "app" and an ephemeral container "debugger"
+func newInitPod(name string, annotations map[string]string) *resources.Pod {
+	pod := newTestPod(name, nil, map[string]interface{}{
+		"initContainers": []interface{}{
+			map[string]interface{}{"name": "migrate", "image": "migrate:v1"},
+			map[string]interface{}{"name": "proxy", "image": "proxy:v1", "restartPolicy": "Always"},
+			map[string]interface{}{"name": "warmup", "image": "warmup:v1"},
+		},
+		"ephemeralContainers": []interface{}{
+			map[string]interface{}{"name": "debugger", "image": "busybox"},
+		},
+	})
+	pod.Metadata.Annotations = annotations
+	return pod
+}
+
+// podPhase returns the phase of a stored pod
+func podPhase(store *storage.InMemoryStore, name string) string {
+	pod, _ := store.GetPod(name)
+	phase, _ := resources.NestedString(pod, "status", "phase")
+	return phase
+}
+
+func TestInitContainersRunInOrder(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	pc := usePodController(t, store)
+	pc.InitContainerDuration = 20 * time.Millisecond
+
+	pod := newInitPod("initializing", map[string]string{RunDurationAnnotation + ".migrate": "100ms"})
+	if err := createPod(store, pod); err != nil {
+		t.Fatalf("Failed to create pod: %v", err)
+	}
+	waitFor(t, 5*time.Second, "migrate running", func() bool {
+		stored, _ := store.GetPod("initializing")
+		_, running := resources.NestedMap(initContainerStatus(stored, "migrate"), "state", "running")
+		return running
+	})
+	stored, _ := store.GetPod("initializing")
+	if reason, _ := resources.NestedString(initContainerStatus(stored, "proxy"), "state", "waiting", "reason"); reason != ContainerReasonPodInitializing {
+		t.Errorf("Expected proxy to wait for migrate, got %v", initContainerStatus(stored, "proxy"))
+	}
+	if reason, _ := resources.NestedString(podContainerStatus(stored, "app"), "state", "waiting", "reason"); reason != ContainerReasonPodInitializing {
+		t.Errorf("Expected app to wait for the init containers, got %v", podContainerStatus(stored, "app"))
+	}
+	initialized := podCondition(stored, "Initialized")
+	if initialized["status"] != "False" || initialized["message"] != "containers with incomplete status: [migrate proxy warmup]" {
+		t.Errorf("Expected the pod not initialized, got %v", initialized)
+	}
+	if phase, _ := resources.NestedString(stored, "status", "phase"); phase != string(PodPending) {
+		t.Errorf("Expected the pod Pending while initializing, got %s", phase)
+	}
+	debugger, _ := resources.NestedSlice(stored, "status", "ephemeralContainerStatuses")
+	if len(debugger) != 1 {
+		t.Errorf("Expected a status for the ephemeral container, got %v", debugger)
+	}
+
+	waitFor(t, 5*time.Second, "pod running", func() bool {
+		return podPhase(store, "initializing") == string(PodRunning)
+	})
+	stored, _ = store.GetPod("initializing")
+	for _, name := range []string{"migrate", "warmup"} {
+		if reason, _ := resources.NestedString(initContainerStatus(stored, name), "state", "terminated", "reason"); reason != "Completed" {
+			t.Errorf("Expected init container %s completed, got %v", name, initContainerStatus(stored, name))
+		}
+	}
+	if _, running := resources.NestedMap(initContainerStatus(stored, "proxy"), "state", "running"); !running {
+		t.Errorf("Expected the sidecar to keep running, got %v", initContainerStatus(stored, "proxy"))
+	}
+	if podCondition(stored, "Initialized")["status"] != "True" || !isPodReady(stored) {
+		t.Errorf("Expected the pod initialized and ready, got %v", stored["status"])
+	}
+
+	// deleting the pod stops the sidecar with the containers
+	if _, err := pc.DeletePod("initializing", 1); err != nil {
+		t.Fatalf("Failed to delete pod: %v", err)
+	}
+	waitFor(t, 5*time.Second, "pod removed", func() bool {
+		_, err := store.GetPod("initializing")
+		return err != nil
+	})
+}
+
+func TestInitContainerFailure(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	pc := usePodController(t, store)
+	pc.InitContainerDuration = 10 * time.Millisecond
+
+	// restarted after the back-off until it succeeds
+	pod := newInitPod("retrying", map[string]string{ExitCodeAnnotation + ".migrate": "1,1,0"})
+	if err := createPod(store, pod); err != nil {
+		t.Fatalf("Failed to create pod: %v", err)
+	}
+	waitFor(t, 5*time.Second, "pod running", func() bool {
+		return podPhase(store, "retrying") == string(PodRunning)
+	})
+	stored, _ := store.GetPod("retrying")
+	migrate := initContainerStatus(stored, "migrate")
+	if restarts, _ := resources.ToInt64(migrate["restartCount"]); restarts != 2 {
+		t.Errorf("Expected migrate to be restarted twice, got %v", migrate)
+	}
+	if code, _ := resources.NestedInt64(migrate, "lastState", "terminated", "exitCode"); code != 1 {
+		t.Errorf("Expected the last failure in lastState, got %v", migrate["lastState"])
+	}
+
+	// under restartPolicy Never the pod fails
+	pod = newInitPod("failing", map[string]string{ExitCodeAnnotation + ".warmup": "3"})
+	pod.Spec.(map[string]interface{})["restartPolicy"] = "Never"
+	if err := createPod(store, pod); err != nil {
+		t.Fatalf("Failed to create pod: %v", err)
+	}
+	waitFor(t, 5*time.Second, "pod failed", func() bool {
+		return podPhase(store, "failing") == string(PodFailed)
+	})
+	stored, _ = store.GetPod("failing")
+	if code, _ := resources.NestedInt64(initContainerStatus(stored, "warmup"), "state", "terminated", "exitCode"); code != 3 {
+		t.Errorf("Expected warmup to have exited with 3, got %v", initContainerStatus(stored, "warmup"))
+	}
+	if _, running := resources.NestedMap(initContainerStatus(stored, "proxy"), "state", "running"); running {
+		t.Error("Expected the sidecar to stop with the failed pod")
+	}
+	if reason, _ := resources.NestedString(podContainerStatus(stored, "app"), "state", "waiting", "reason"); reason != ContainerReasonPodInitializing {
+		t.Errorf("Expected app never to start, got %v", podContainerStatus(stored, "app"))
+	}
+}
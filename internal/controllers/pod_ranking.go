@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"mockernetes/internal/resources"
+)
+
+// Choosing which pods a scale-down removes (ActivePodsWithRanks upstream): the pods that
+// matter least go first, so a scale-down disrupts as little running work as possible.
+
+// PodDeletionCostAnnotation lets users rank pods for scale-down; lower costs are deleted first.
+const PodDeletionCostAnnotation = "controller.kubernetes.io/pod-deletion-cost"
+
+// podPhaseRank orders phases for deletion: Pending < Unknown < Running
+var podPhaseRank = map[string]int{string(PodPending): 0, string(PodUnknown): 1, string(PodRunning): 2}
+
+// rankedPod is a pod with the number of related pods on its node
+type rankedPod struct {
+	pod  map[string]interface{}
+	rank int
+}
+
+// podsToDelete returns the count pods to remove first. related are the active pods of the
+// same workload (all ReplicaSets of a Deployment); pods doubled up on a node go first.
+func podsToDelete(pods, related []map[string]interface{}, count int) []map[string]interface{} {
+	if count >= len(pods) {
+		return pods
+	}
+	onNode := map[string]int{}
+	for _, pod := range related {
+		if node, _ := resources.NestedString(pod, "spec", "nodeName"); node != "" {
+			onNode[node]++
+		}
+	}
+	ranked := make([]rankedPod, len(pods))
+	for i, pod := range pods {
+		node, _ := resources.NestedString(pod, "spec", "nodeName")
+		ranked[i] = rankedPod{pod: pod}
+		if node != "" {
+			ranked[i].rank = onNode[node]
+		}
+	}
+	now := time.Now()
+	sort.SliceStable(ranked, func(i, j int) bool { return deleteBefore(ranked[i], ranked[j], now) })
+
+	victims := make([]map[string]interface{}, count)
+	for i := range victims {
+		victims[i] = ranked[i].pod
+	}
+	return victims
+}
+
+// deleteBefore reports whether a should be deleted before b
+func deleteBefore(a, b rankedPod, now time.Time) bool {
+	// 1. Unscheduled < scheduled
+	nodeA, _ := resources.NestedString(a.pod, "spec", "nodeName")
+	nodeB, _ := resources.NestedString(b.pod, "spec", "nodeName")
+	if (nodeA == "") != (nodeB == "") {
+		return nodeA == ""
+	}
+	// 2. Pending < Unknown < Running
+	phaseA, _ := resources.NestedString(a.pod, "status", "phase")
+	phaseB, _ := resources.NestedString(b.pod, "status", "phase")
+	if podPhaseRank[phaseA] != podPhaseRank[phaseB] {
+		return podPhaseRank[phaseA] < podPhaseRank[phaseB]
+	}
+	// 3. Not ready < ready
+	readyA, readyB := isPodReady(a.pod), isPodReady(b.pod)
+	if readyA != readyB {
+		return !readyA
+	}
+	// 4. Lower pod-deletion-cost < higher
+	if costA, costB := deletionCost(a.pod), deletionCost(b.pod); costA != costB {
+		return costA < costB
+	}
+	// 5. Doubled up on a node < alone
+	if a.rank != b.rank {
+		return a.rank > b.rank
+	}
+	// 6. Ready for less time < more time, compared in powers of two
+	if readyA && readyB {
+		if diff, ok := logRankDiff(readyTime(a.pod), readyTime(b.pod), now); ok {
+			return diff < 0
+		}
+	}
+	// 7. More container restarts < fewer
+	if restartsA, restartsB := maxRestarts(a.pod), maxRestarts(b.pod); restartsA != restartsB {
+		return restartsA > restartsB
+	}
+	// 8. Newer < older
+	if diff, ok := logRankDiff(creationTime(a.pod), creationTime(b.pod), now); ok {
+		return diff < 0
+	}
+	return false
+}
+
+// deletionCost returns the pod-deletion-cost annotation (0 if unset or invalid)
+func deletionCost(pod map[string]interface{}) int32 {
+	value, _ := resources.NestedString(pod, "metadata", "annotations", PodDeletionCostAnnotation)
+	cost, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return 0
+	}
+	return int32(cost)
+}
+
+// readyTime returns when the pod last became ready (zero if unknown)
+func readyTime(pod map[string]interface{}) time.Time {
+	cond := podCondition(pod, "Ready")
+	since, _ := cond["lastTransitionTime"].(string)
+	t, _ := time.Parse(time.RFC3339, since)
+	return t
+}
+
+// creationTime returns metadata.creationTimestamp (zero if unset)
+func creationTime(obj map[string]interface{}) time.Time {
+	ts, _ := resources.NestedString(obj, "metadata", "creationTimestamp")
+	t, _ := time.Parse(time.RFC3339, ts)
+	return t
+}
+
+// maxRestarts returns the highest restartCount among the pod's containers
+func maxRestarts(pod map[string]interface{}) int64 {
+	var restarts int64
+	statuses, _ := resources.NestedSlice(pod, "status", "containerStatuses")
+	for _, s := range statuses {
+		if status, ok := s.(map[string]interface{}); ok {
+			n, _ := resources.NestedInt64(status, "restartCount")
+			restarts = max(restarts, n)
+		}
+	}
+	return restarts
+}
+
+// logRankDiff compares the ages of t1 and t2 at now on a log2 scale, so pods of about the same
+// age rank equal (LogarithmicScaleDown). A zero time counts as the newest. ok is false on a tie.
+func logRankDiff(t1, t2, now time.Time) (int, bool) {
+	if t1.Equal(t2) {
+		return 0, false
+	}
+	if t1.IsZero() {
+		return -1, true
+	}
+	if t2.IsZero() {
+		return 1, true
+	}
+	rank := func(t time.Time) int {
+		age := now.Sub(t)
+		if age <= 0 {
+			return -1
+		}
+		return int(math.Log2(float64(age)))
+	}
+	diff := rank(t1) - rank(t2)
+	return diff, diff != 0
+}
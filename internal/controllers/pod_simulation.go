@@ -0,0 +1,253 @@
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"mockernetes/internal/resources"
+)
+
+// Simulated container run time and exit codes. Containers of pods that don't restart
+// (restartPolicy Never or OnFailure) exit after RunDuration with code 0; annotations on the
+// pod (or the pod template of its workload) change how long they run and how they exit:
+//
+//	simulation.mockernetes.io/run-duration:     "30s"
+//	simulation.mockernetes.io/exit-code:        "1,1,0"   (per attempt, the last one repeats)
+//	simulation.mockernetes.io/exit-code.worker: "137"     (container worker only)
+//
+// Pods with restartPolicy Always only exit when annotated. Each container exits on its own;
+// restarted containers (restartPolicy Always, or OnFailure after an error) run again after the
+// restart back-off. The attempt is the container's restartCount plus the attempt annotation,
+// which the Job controller sets to the number of pods the Job has seen fail, so "1,1,0" makes
+// a Job succeed on its third pod.
+const (
+	RunDurationAnnotation = "simulation.mockernetes.io/run-duration"
+	ExitCodeAnnotation    = "simulation.mockernetes.io/exit-code"
+	AttemptAnnotation     = "simulation.mockernetes.io/attempt"
+)
+
+// DefaultRunDuration is how long containers of run-to-completion pods run unless annotated.
+const DefaultRunDuration = 5 * time.Second
+
+// containerAnnotation returns the container's own simulation annotation, else the pod-wide one
+func containerAnnotation(pod map[string]interface{}, annotation, container string) (string, bool) {
+	if value, ok := resources.NestedString(pod, "metadata", "annotations", annotation+"."+container); ok {
+		return value, true
+	}
+	return resources.NestedString(pod, "metadata", "annotations", annotation)
+}
+
+// simulatedRunDuration returns how long a container of the pod runs before it exits, and
+// false if it keeps running until the pod is deleted.
+func (pc *PodController) simulatedRunDuration(pod map[string]interface{}, container string) (time.Duration, bool) {
+	if value, ok := containerAnnotation(pod, RunDurationAnnotation, container); ok {
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			podName, _ := resources.NestedString(pod, "metadata", "name")
+			fmt.Printf("[Pod Controller] Ignoring invalid %s %q on pod %s: %v\n", RunDurationAnnotation, value, podName, err)
+			return 0, false
+		}
+		return duration, true
+	}
+	if policy, _ := resources.NestedString(pod, "spec", "restartPolicy"); policy == "Never" || policy == "OnFailure" {
+		return pc.RunDuration, pc.RunDuration > 0
+	}
+	return 0, false
+}
+
+// simulatedExitCode returns the exit code of a container's given attempt from the exit-code
+// annotation (0 when unset or not a number)
+func simulatedExitCode(pod map[string]interface{}, container string, attempt int) int {
+	value, _ := containerAnnotation(pod, ExitCodeAnnotation, container)
+	return attemptExitCode(value, attempt)
+}
+
+// attemptExitCode returns the exit code of an attempt from an exit-code annotation value
+func attemptExitCode(value string, attempt int) int {
+	if value == "" {
+		return 0
+	}
+	codes := strings.Split(value, ",")
+	code, err := strconv.Atoi(strings.TrimSpace(codes[min(attempt, len(codes)-1)]))
+	if err != nil {
+		return 0
+	}
+	return code
+}
+
+// containerAttempt returns the attempt a container of the pod is on: the attempt annotation
+// plus the container's restarts
+func containerAttempt(pod map[string]interface{}, container string) int {
+	value, _ := resources.NestedString(pod, "metadata", "annotations", AttemptAnnotation)
+	attempt, _ := strconv.Atoi(value)
+	restarts, _ := resources.ToInt64(podContainerStatus(pod, container)["restartCount"])
+	return attempt + int(restarts)
+}
+
+// scheduleExit makes the containers of a running pod exit once their run time is up
+func (pc *PodController) scheduleExit(podName string) {
+	pod, err := pc.store.GetPod(podName)
+	if err != nil {
+		return
+	}
+	containers, _ := resources.NestedSlice(pod, "spec", "containers")
+	for _, item := range containers {
+		if name, _ := item.(map[string]interface{})["name"].(string); name != "" {
+			pc.scheduleContainerExit(pod, name)
+		}
+	}
+}
+
+// scheduleContainerExit makes a running container exit once its run time is up, unless it was
+// restarted (or the pod replaced) meanwhile
+func (pc *PodController) scheduleContainerExit(pod map[string]interface{}, container string) {
+	duration, ok := pc.simulatedRunDuration(pod, container)
+	if !ok {
+		return
+	}
+	podName, _ := resources.NestedString(pod, "metadata", "name")
+	uid := objectUID(pod)
+	restarts, _ := resources.ToInt64(podContainerStatus(pod, container)["restartCount"])
+	go func() {
+		select {
+		case <-time.After(duration):
+			pc.onContainerExited(podName, uid, container, restarts)
+		case <-pc.stopCh:
+		}
+	}()
+}
+
+// onContainerExited is called when a running container exits at the end of its run time. Its
+// exit code decides with the restartPolicy whether it is restarted (after the back-off) or
+// stays terminated; the pod Succeeds or Fails once no container runs or restarts.
+func (pc *PodController) onContainerExited(podName, uid, container string, restarts int64) error {
+	now := time.Now()
+	var restart *containerRestart
+	_, err := pc.store.MutatePod(podName, func(pod map[string]interface{}) error {
+		restart = nil
+		containerStatus := podContainerStatus(pod, container)
+		phase, _ := resources.NestedString(pod, "status", "phase")
+		if objectUID(pod) != uid || isBeingDeleted(pod) || phase != string(PodRunning) || containerStatus == nil {
+			return nil
+		}
+		if current, _ := resources.ToInt64(containerStatus["restartCount"]); current != restarts {
+			return nil
+		}
+		if _, running := resources.NestedMap(containerStatus, "state", "running"); !running {
+			return nil
+		}
+		exitCode := simulatedExitCode(pod, container, containerAttempt(pod, container))
+		reason := "Completed"
+		if exitCode != 0 {
+			reason = "Error"
+		}
+		restart = pc.exitContainer(pod, container, exitCode, reason, now)
+		updatePhaseFromContainers(pod, now)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if restart != nil {
+		pc.restartAfterBackOff(podName, uid, restart)
+	}
+	return nil
+}
+
+// exitContainer records that a running container of pod exited with exitCode. When the
+// restartPolicy restarts it, the container either waits in CrashLoopBackOff or, without a
+// back-off, is terminated until restartAfterBackOff runs it again.
+func (pc *PodController) exitContainer(pod map[string]interface{}, container string, exitCode int, reason string, now time.Time) *containerRestart {
+	containerStatus := podContainerStatus(pod, container)
+	policy, _ := resources.NestedString(pod, "spec", "restartPolicy")
+	terminated := exitedState(containerStatus, exitCode, reason, now)
+	containerStatus["ready"] = false
+	containerStatus["started"] = false
+	containerStatus["state"] = terminated
+	if policy == "Never" || (policy == "OnFailure" && exitCode == 0) {
+		return nil
+	}
+
+	restarts, _ := resources.ToInt64(containerStatus["restartCount"])
+	delay := pc.nextRestartBackOff(objectUID(pod)+"/"+container, now)
+	if delay > 0 {
+		containerStatus["lastState"] = terminated
+		containerStatus["state"] = crashLoopBackOffState(pod, container, delay)
+	}
+	return &containerRestart{container: container, restarts: restarts, delay: delay}
+}
+
+// updatePhaseFromContainers ends a running pod once none of its containers runs or will be
+// restarted: Succeeded when they all exited with 0, else Failed. While it runs, its Ready
+// conditions follow its containers.
+func updatePhaseFromContainers(pod map[string]interface{}, now time.Time) {
+	status, _ := pod["status"].(map[string]interface{})
+	containerStatuses, _ := resources.NestedSlice(status, "containerStatuses")
+	if len(containerStatuses) == 0 {
+		return
+	}
+	policy, _ := resources.NestedString(pod, "spec", "restartPolicy")
+	failed := false
+	for _, cs := range containerStatuses {
+		containerStatus, _ := cs.(map[string]interface{})
+		terminated, ok := resources.NestedMap(containerStatus, "state", "terminated")
+		code, _ := resources.ToInt64(terminated["exitCode"])
+		if !ok || policy == "Always" || (policy == "OnFailure" && code != 0) {
+			setReadyConditions(status, now)
+			return
+		}
+		failed = failed || code != 0
+	}
+	condReason := "PodCompleted"
+	status["phase"] = string(PodSucceeded)
+	if failed {
+		condReason = "PodFailed"
+		status["phase"] = string(PodFailed)
+	}
+	markNotReady(status, condReason, now)
+}
+
+// exitedContainerStatuses returns the stored container statuses of a pod with every running
+// container exited with exitCode
+func (pc *PodController) exitedContainerStatuses(podName string, exitCode int, reason string) []ContainerStatus {
+	pod, err := pc.store.GetPod(podName)
+	if err != nil {
+		return nil
+	}
+	now := time.Now()
+	containerStatuses, _ := resources.NestedSlice(pod, "status", "containerStatuses")
+	for _, cs := range containerStatuses {
+		containerStatus, ok := cs.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if _, running := resources.NestedMap(containerStatus, "state", "running"); running {
+			containerStatus["state"] = exitedState(containerStatus, exitCode, reason, now)
+		}
+		containerStatus["ready"] = false
+	}
+	var statuses []ContainerStatus
+	deepCopyJSON(containerStatuses, &statuses)
+	return statuses
+}
+
+// exitedState returns the terminated state of a running container that exited with exitCode
+func exitedState(containerStatus map[string]interface{}, exitCode int, reason string, now time.Time) map[string]interface{} {
+	startedAt := now.Format(time.RFC3339)
+	if running, ok := resources.NestedMap(containerStatus, "state", "running"); ok {
+		if s, ok := running["startedAt"].(string); ok {
+			startedAt = s
+		}
+	}
+	return map[string]interface{}{
+		"terminated": map[string]interface{}{
+			"exitCode":    exitCode,
+			"reason":      reason,
+			"startedAt":   startedAt,
+			"finishedAt":  now.Format(time.RFC3339),
+			"containerID": containerStatus["containerID"],
+		},
+	}
+}
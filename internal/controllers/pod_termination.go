@@ -0,0 +1,244 @@
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+// Graceful pod deletion (kubelet side). The API marks the pod with deletionTimestamp and
+// deletionGracePeriodSeconds; the pod shows as Terminating until its containers have
+// exited (after ShutdownDelay, or killed when the grace period runs out) and is then removed.
+
+// PodGracePeriod returns spec.terminationGracePeriodSeconds (30 if unset).
+// Pods that already finished are deleted immediately, like upstream.
+func PodGracePeriod(pod map[string]interface{}) int64 {
+	if phase, _ := resources.NestedString(pod, "status", "phase"); phase == string(PodSucceeded) || phase == string(PodFailed) {
+		return 0
+	}
+	if grace, ok := resources.NestedInt64(pod, "spec", "terminationGracePeriodSeconds"); ok {
+		return grace
+	}
+	return resources.DefaultTerminationGracePeriodSeconds
+}
+
+// DeletePod starts graceful deletion of a pod with the given grace period and returns the
+// pod as stored afterwards. A grace period of 0 (kubectl --force --grace-period=0) removes
+// the pod immediately. A repeated delete may only shorten an existing grace period.
+func (pc *PodController) DeletePod(podName string, gracePeriod int64) (map[string]interface{}, error) {
+	if gracePeriod <= 0 {
+		pod, err := pc.store.GetPod(podName)
+		if err != nil {
+			return nil, err
+		}
+		pc.cancelTermination(podName)
+		return pod, pc.removePod(podName)
+	}
+
+	now := time.Now()
+	shortened := false
+	pod, err := pc.store.MutatePod(podName, func(stored map[string]interface{}) error {
+		meta, _ := stored["metadata"].(map[string]interface{})
+		if meta == nil {
+			meta = map[string]interface{}{}
+			stored["metadata"] = meta
+		}
+		if current, ok := resources.ToInt64(meta["deletionGracePeriodSeconds"]); ok && isBeingDeleted(stored) && current <= gracePeriod {
+			return nil
+		}
+		meta["deletionTimestamp"] = now.Add(time.Duration(gracePeriod) * time.Second).UTC().Format(time.RFC3339)
+		meta["deletionGracePeriodSeconds"] = gracePeriod
+		shortened = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if shortened {
+		fmt.Printf("[Pod Controller] Pod %s terminating (grace period %ds)\n", podName, gracePeriod)
+		pc.startTermination(podName, time.Duration(gracePeriod)*time.Second)
+	}
+	return pod, nil
+}
+
+// startTermination (re)schedules the container shutdown for a terminating pod.
+func (pc *PodController) startTermination(podName string, grace time.Duration) {
+	pc.mu.Lock()
+	if cancel, ok := pc.terminating[podName]; ok {
+		close(cancel)
+	}
+	cancel := make(chan struct{})
+	pc.terminating[podName] = cancel
+	pc.mu.Unlock()
+
+	// Containers stop receiving traffic right away
+	pc.setPodNotReady(podName, "PodTerminating")
+
+	killed := pc.ShutdownDelay <= 0 || pc.ShutdownDelay > grace
+	wait := pc.ShutdownDelay
+	if killed {
+		wait = grace
+	}
+
+	go func() {
+		select {
+		case <-time.After(wait):
+		case <-cancel:
+			return
+		case <-pc.stopCh:
+			return
+		}
+
+		pc.mu.Lock()
+		if pc.terminating[podName] != cancel {
+			pc.mu.Unlock()
+			return
+		}
+		delete(pc.terminating, podName)
+		pc.mu.Unlock()
+
+		pc.terminateContainers(podName, killed)
+		if err := pc.removePod(podName); err != nil {
+			fmt.Printf("[Pod Controller] Error removing terminated pod %s: %v\n", podName, err)
+		}
+	}()
+}
+
+// cancelTermination drops a pending termination (pod force-deleted meanwhile).
+func (pc *PodController) cancelTermination(podName string) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if cancel, ok := pc.terminating[podName]; ok {
+		close(cancel)
+		delete(pc.terminating, podName)
+	}
+}
+
+// setPodNotReady flips the Ready and ContainersReady conditions to False.
+func (pc *PodController) setPodNotReady(podName, reason string) {
+	now := time.Now()
+	pc.store.MutatePod(podName, func(pod map[string]interface{}) error {
+		status, _ := pod["status"].(map[string]interface{})
+		markNotReady(status, reason, now)
+		return nil
+	})
+}
+
+// markNotReady sets the Ready and ContainersReady conditions of a pod status to False with
+// reason, and every container to not ready.
+func markNotReady(status map[string]interface{}, reason string, now time.Time) {
+	conditions, _ := resources.NestedSlice(status, "conditions")
+	for _, c := range conditions {
+		cond, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if t := cond["type"]; (t == "Ready" || t == "ContainersReady") && cond["status"] != "False" {
+			cond["status"] = "False"
+			cond["reason"] = reason
+			cond["lastTransitionTime"] = now.Format(time.RFC3339)
+		}
+	}
+	if containerStatuses, ok := resources.NestedSlice(status, "containerStatuses"); ok {
+		for _, cs := range containerStatuses {
+			if containerStatus, ok := cs.(map[string]interface{}); ok {
+				containerStatus["ready"] = false
+			}
+		}
+	}
+}
+
+// terminateContainers records every running container as terminated: exit code 0 when it
+// shut down within the grace period, 137 (SIGKILL) when the grace period ran out.
+func (pc *PodController) terminateContainers(podName string, killed bool) {
+	exitCode, reason, phase := 0, "Completed", PodSucceeded
+	if killed {
+		exitCode, reason, phase = 137, "Error", PodFailed
+	}
+	stoppedAt := time.Now()
+	now := stoppedAt.Format(time.RFC3339)
+
+	pc.store.MutatePod(podName, func(pod map[string]interface{}) error {
+		status, _ := pod["status"].(map[string]interface{})
+		if status == nil {
+			return nil
+		}
+		containerStatuses, _ := resources.NestedSlice(status, "containerStatuses")
+		for _, cs := range containerStatuses {
+			containerStatus, ok := cs.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			startedAt := now
+			if running, ok := resources.NestedMap(containerStatus, "state", "running"); ok {
+				if s, ok := running["startedAt"].(string); ok {
+					startedAt = s
+				}
+			}
+			containerStatus["ready"] = false
+			containerStatus["state"] = map[string]interface{}{
+				"terminated": map[string]interface{}{
+					"exitCode":    exitCode,
+					"reason":      reason,
+					"startedAt":   startedAt,
+					"finishedAt":  now,
+					"containerID": containerStatus["containerID"],
+				},
+			}
+		}
+		// sidecars and ephemeral containers shut down with them; finished init containers stay
+		stopRunningContainers(status, "initContainerStatuses", exitCode, reason, stoppedAt)
+		stopRunningContainers(status, "ephemeralContainerStatuses", exitCode, reason, stoppedAt)
+		if len(containerStatuses) > 0 {
+			status["phase"] = phase
+		}
+		return nil
+	})
+}
+
+// removePod deletes the pod from storage and drops its simulation state.
+func (pc *PodController) removePod(podName string) error {
+	namespace := "default"
+	if pod, err := pc.store.GetPod(podName); err == nil {
+		if ns, _ := resources.NestedString(pod, "metadata", "namespace"); ns != "" {
+			namespace = ns
+		}
+		pc.mu.Lock()
+		delete(pc.starting, objectUID(pod))
+		pc.mu.Unlock()
+		pc.IPAM.Release(objectUID(pod))
+		pc.forgetBackOff(pod)
+	}
+	if DefaultTransitionManager != nil {
+		DefaultTransitionManager.CancelTransition(namespace, podName)
+	}
+	if DefaultTemplateRegistry != nil {
+		DefaultTemplateRegistry.RemoveTemplate(namespace, podName)
+	}
+	return pc.store.DeletePod(podName)
+}
+
+// deletePodObject deletes a pod on behalf of a controller (scale-down, garbage collection):
+// gracefully when the pod controller is running, else straight from storage.
+func deletePodObject(store *storage.InMemoryStore, podName string) error {
+	if DefaultPodController != nil {
+		pod, err := store.GetPod(podName)
+		if err != nil {
+			return err
+		}
+		_, err = DefaultPodController.DeletePod(podName, PodGracePeriod(pod))
+		return err
+	}
+	// Cancel any active transitions
+	if DefaultTransitionManager != nil {
+		DefaultTransitionManager.CancelTransition("default", podName)
+	}
+	// Remove any templates
+	if DefaultTemplateRegistry != nil {
+		DefaultTemplateRegistry.RemoveTemplate("default", podName)
+	}
+	return store.DeletePod(podName)
+}
@@ -0,0 +1,434 @@
+package controllers
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"mockernetes/internal/resources"
+)
+
+// Container probes (the kubelet prober). Once a pod runs, the startupProbe, livenessProbe and
+// readinessProbe of its containers run after initialDelaySeconds and then every periodSeconds;
+// failureThreshold failures in a row fail a probe, successThreshold successes pass it.
+// Readiness sets the container's ready and with it the pod's Ready and ContainersReady
+// conditions. A failed liveness or startup probe kills the container, which is restarted
+// unless the pod's restartPolicy is Never. Liveness and readiness only run once the startup
+// probe passed.
+//
+// Probes succeed unless the pod (or the pod template of its workload) carries a script of
+// outcomes, one per probe run with the last one repeating; "outcome*n" repeats it n times:
+//
+//	simulation.mockernetes.io/readiness-probe:     "success*3,failure*3,success"  (every container)
+//	simulation.mockernetes.io/readiness-probe.web: "failure,success"              (container web)
+//
+// and liveness-probe and startup-probe likewise. Runs are counted across container restarts.
+const (
+	ReadinessProbeAnnotation = "simulation.mockernetes.io/readiness-probe"
+	LivenessProbeAnnotation  = "simulation.mockernetes.io/liveness-probe"
+	StartupProbeAnnotation   = "simulation.mockernetes.io/startup-probe"
+)
+
+// Event reasons of the prober
+const (
+	EventReasonUnhealthy = "Unhealthy"
+	EventReasonKilling   = "Killing"
+)
+
+// Probe kinds, in the order they run
+const (
+	probeStartup   = "Startup"
+	probeLiveness  = "Liveness"
+	probeReadiness = "Readiness"
+)
+
+// probeKinds maps each probe kind to its container field and script annotation
+var probeKinds = []struct{ kind, field, annotation string }{
+	{probeStartup, "startupProbe", StartupProbeAnnotation},
+	{probeLiveness, "livenessProbe", LivenessProbeAnnotation},
+	{probeReadiness, "readinessProbe", ReadinessProbeAnnotation},
+}
+
+// probeStep is an outcome of a probe script and how many runs it lasts
+type probeStep struct {
+	success bool
+	count   int
+}
+
+// containerProbe is a probe of a container with its timings in ProbeTimeUnits
+type containerProbe struct {
+	spec             map[string]interface{}
+	initialDelay     int
+	period           int
+	successThreshold int
+	failureThreshold int
+	script           []probeStep
+	// runs counts the probes run so far; successes and failures the latest in a row
+	runs, successes, failures int
+}
+
+// containerProber is the probe state of one container since it last (re)started
+type containerProber struct {
+	name      string
+	probes    map[string]*containerProbe
+	startTick int
+	restarts  int64
+	started   bool
+	ready     bool
+}
+
+// probeResult is what a probe run changed for a container
+type probeResult struct {
+	container string
+	started   bool
+	ready     bool
+	// killedBy is the kind of the probe that killed the container, if any
+	killedBy string
+	failures []string
+}
+
+// startProbes probes the containers of a pod that just started until it stops running.
+// Pods without probes aren't probed.
+func (pc *PodController) startProbes(podName string) {
+	pod, err := pc.store.GetPod(podName)
+	if err != nil {
+		return
+	}
+	containers := containerProbers(pod)
+	if len(containers) == 0 {
+		return
+	}
+	uid := objectUID(pod)
+	ticker := time.NewTicker(pc.ProbeTimeUnit)
+	go func() {
+		defer ticker.Stop()
+		for tick := 1; ; tick++ {
+			select {
+			case <-ticker.C:
+			case <-pc.stopCh:
+				return
+			}
+			if !pc.runProbes(podName, uid, containers, tick) {
+				return
+			}
+		}
+	}()
+}
+
+// runProbes runs the probes due at tick and applies their results. It returns false once the
+// pod is no longer running.
+func (pc *PodController) runProbes(podName, uid string, containers []*containerProber, tick int) bool {
+	pod, err := pc.store.GetPod(podName)
+	if err != nil || objectUID(pod) != uid || isBeingDeleted(pod) {
+		return false
+	}
+	if phase, _ := resources.NestedString(pod, "status", "phase"); phase != string(PodRunning) {
+		return false
+	}
+
+	var results []probeResult
+	for _, c := range containers {
+		containerStatus := podContainerStatus(pod, c.name)
+		// containers restarted by someone else (exits, transitions) start probing afresh
+		if restarts, _ := resources.ToInt64(containerStatus["restartCount"]); restarts != c.restarts {
+			c.reset(tick, restarts)
+		}
+		if _, running := resources.NestedMap(containerStatus, "state", "running"); !running {
+			continue
+		}
+		if result, changed := c.probe(tick); changed {
+			results = append(results, result)
+		}
+	}
+	if len(results) == 0 {
+		return true
+	}
+	policy, _ := resources.NestedString(pod, "spec", "restartPolicy")
+	var restarts []*containerRestart
+	stored, err := pc.store.MutatePod(podName, func(pod map[string]interface{}) error {
+		restarts = nil
+		if objectUID(pod) != uid {
+			return nil
+		}
+		restarts = pc.applyProbeResults(pod, results, time.Now())
+		return nil
+	})
+	if err != nil || objectUID(stored) != uid {
+		return false
+	}
+	for _, restart := range restarts {
+		pc.restartAfterBackOff(podName, uid, restart)
+	}
+	podIP, _ := resources.NestedString(stored, "status", "podIP")
+	for _, result := range results {
+		for _, kind := range result.failures {
+			recordEvent(pc.store, stored, EventTypeWarning, EventReasonUnhealthy,
+				fmt.Sprintf("%s probe failed: %s", kind, probeFailureMessage(findProber(containers, result.container).probes[kind].spec, podIP)), "kubelet")
+		}
+		if result.killedBy != "" {
+			fmt.Printf("[Pod Controller] Container %s of pod %s failed its %s probe\n", result.container, podName, strings.ToLower(result.killedBy))
+			message := fmt.Sprintf("Container %s failed %s probe, will be restarted", result.container, strings.ToLower(result.killedBy))
+			if policy == "Never" {
+				message = fmt.Sprintf("Stopping container %s", result.container)
+			}
+			recordEvent(pc.store, stored, EventTypeNormal, EventReasonKilling, message, "kubelet")
+		}
+	}
+	return true
+}
+
+// probe runs the container's probes that are due at tick and reports what changed
+func (c *containerProber) probe(tick int) (probeResult, bool) {
+	age := tick - c.startTick
+	result := probeResult{container: c.name, started: c.started, ready: c.ready}
+	changed := false
+	for _, kind := range []string{probeStartup, probeLiveness, probeReadiness} {
+		p := c.probes[kind]
+		if p == nil || !p.due(age) {
+			continue
+		}
+		// the startup probe runs until it passes, the others only after that
+		if started := kind != probeStartup; started != c.started {
+			continue
+		}
+		success := p.run()
+		if !success {
+			result.failures = append(result.failures, kind)
+			changed = true
+		}
+		switch {
+		case kind == probeStartup && success:
+			c.started, result.started, changed = true, true, true
+		case kind == probeReadiness && success && !c.ready && p.successes >= p.successThreshold:
+			c.ready, result.ready, changed = true, true, true
+		case kind == probeReadiness && !success && c.ready && p.failures >= p.failureThreshold:
+			c.ready, result.ready, changed = false, false, true
+		case kind != probeReadiness && !success && p.failures >= p.failureThreshold:
+			result.killedBy = kind
+			return result, true
+		}
+	}
+	return result, changed
+}
+
+// reset starts the probes of a container over after it (re)started before tick
+func (c *containerProber) reset(tick int, restarts int64) {
+	c.startTick, c.restarts = tick, restarts
+	c.started = c.probes[probeStartup] == nil
+	c.ready = c.probes[probeReadiness] == nil
+	for _, p := range c.probes {
+		p.successes, p.failures = 0, 0
+	}
+}
+
+// due reports whether the probe runs when its container is age ProbeTimeUnits old
+func (p *containerProbe) due(age int) bool {
+	first := max(p.initialDelay, 1)
+	return age >= first && (age-first)%p.period == 0
+}
+
+// run runs the probe once and returns its scripted outcome
+func (p *containerProbe) run() bool {
+	success := scriptedOutcome(p.script, p.runs)
+	p.runs++
+	if success {
+		p.successes, p.failures = p.successes+1, 0
+	} else {
+		p.successes, p.failures = 0, p.failures+1
+	}
+	return success
+}
+
+// applyProbeResults writes the probe results to the container statuses of a pod. Containers
+// killed by a failed probe exit with code 137; it returns those to restart.
+func (pc *PodController) applyProbeResults(pod map[string]interface{}, results []probeResult, now time.Time) []*containerRestart {
+	var restarts []*containerRestart
+	for _, result := range results {
+		containerStatus := podContainerStatus(pod, result.container)
+		if containerStatus == nil {
+			continue
+		}
+		if result.killedBy != "" {
+			if restart := pc.exitContainer(pod, result.container, 137, "Error", now); restart != nil {
+				restarts = append(restarts, restart)
+			}
+			continue
+		}
+		containerStatus["started"] = result.started
+		containerStatus["ready"] = result.ready
+	}
+	updatePhaseFromContainers(pod, now)
+	return restarts
+}
+
+// setReadyConditions sets the Ready and ContainersReady conditions of a pod status from the
+// ready of its containers
+func setReadyConditions(status map[string]interface{}, now time.Time) {
+	var unready []string
+	containerStatuses, _ := resources.NestedSlice(status, "containerStatuses")
+	for _, cs := range containerStatuses {
+		if containerStatus, ok := cs.(map[string]interface{}); ok && containerStatus["ready"] != true {
+			name, _ := containerStatus["name"].(string)
+			unready = append(unready, name)
+		}
+	}
+	conditions, _ := resources.NestedSlice(status, "conditions")
+	for _, c := range conditions {
+		cond, ok := c.(map[string]interface{})
+		if !ok || (cond["type"] != "Ready" && cond["type"] != "ContainersReady") {
+			continue
+		}
+		switch {
+		case len(unready) == 0 && cond["status"] != "True":
+			cond["status"] = "True"
+			delete(cond, "reason")
+			delete(cond, "message")
+			cond["lastTransitionTime"] = now.UTC().Format(time.RFC3339)
+		case len(unready) > 0:
+			if cond["status"] != "False" {
+				cond["status"] = "False"
+				cond["lastTransitionTime"] = now.UTC().Format(time.RFC3339)
+			}
+			cond["reason"] = "ContainersNotReady"
+			cond["message"] = unreadyMessage(unready)
+		}
+	}
+}
+
+// unreadyMessage is the message of Ready conditions with unready containers
+func unreadyMessage(names []string) string {
+	return fmt.Sprintf("containers with unready status: [%s]", strings.Join(names, " "))
+}
+
+// containerProbers returns the probe state of the pod's containers that have probes
+func containerProbers(pod map[string]interface{}) []*containerProber {
+	var probers []*containerProber
+	containers, _ := resources.NestedSlice(pod, "spec", "containers")
+	for _, item := range containers {
+		container, _ := item.(map[string]interface{})
+		name, _ := container["name"].(string)
+		c := &containerProber{name: name, probes: map[string]*containerProbe{}}
+		for _, k := range probeKinds {
+			spec, ok := container[k.field].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			c.probes[k.kind] = &containerProbe{
+				spec:             spec,
+				initialDelay:     probeField(spec, "initialDelaySeconds", 0),
+				period:           max(probeField(spec, "periodSeconds", 10), 1),
+				successThreshold: max(probeField(spec, "successThreshold", 1), 1),
+				failureThreshold: max(probeField(spec, "failureThreshold", 3), 1),
+				script:           probeScript(pod, k.annotation, name),
+			}
+		}
+		if len(c.probes) > 0 {
+			c.reset(0, 0)
+			probers = append(probers, c)
+		}
+	}
+	return probers
+}
+
+// probeField returns an int field of a probe spec (def when unset)
+func probeField(spec map[string]interface{}, field string, def int) int {
+	if v, ok := resources.ToInt64(spec[field]); ok {
+		return int(v)
+	}
+	return def
+}
+
+// probeScript returns the scripted outcomes of a container's probe: the container's own
+// annotation, else the pod-wide one (nil when there is none or it's invalid)
+func probeScript(pod map[string]interface{}, annotation, container string) []probeStep {
+	value, ok := resources.NestedString(pod, "metadata", "annotations", annotation+"."+container)
+	if !ok {
+		value, ok = resources.NestedString(pod, "metadata", "annotations", annotation)
+	}
+	if !ok {
+		return nil
+	}
+	script, err := parseProbeScript(value)
+	if err != nil {
+		podName, _ := resources.NestedString(pod, "metadata", "name")
+		fmt.Printf("[Pod Controller] Ignoring invalid %s %q on pod %s: %v\n", annotation, value, podName, err)
+		return nil
+	}
+	return script
+}
+
+// parseProbeScript parses a comma-separated list of "success" and "failure", each optionally
+// repeated with "*n"
+func parseProbeScript(value string) ([]probeStep, error) {
+	var script []probeStep
+	for _, item := range strings.Split(value, ",") {
+		outcome, count := strings.TrimSpace(item), 1
+		if o, n, ok := strings.Cut(outcome, "*"); ok {
+			var err error
+			if count, err = strconv.Atoi(strings.TrimSpace(n)); err != nil || count < 1 {
+				return nil, fmt.Errorf("invalid repeat count %q", n)
+			}
+			outcome = strings.TrimSpace(o)
+		}
+		switch outcome {
+		case "success":
+			script = append(script, probeStep{success: true, count: count})
+		case "failure":
+			script = append(script, probeStep{success: false, count: count})
+		default:
+			return nil, fmt.Errorf("unknown outcome %q (want success or failure)", outcome)
+		}
+	}
+	return script, nil
+}
+
+// scriptedOutcome returns the outcome of the given probe run (success without a script)
+func scriptedOutcome(script []probeStep, run int) bool {
+	if len(script) == 0 {
+		return true
+	}
+	for _, step := range script {
+		if run < step.count {
+			return step.success
+		}
+		run -= step.count
+	}
+	return script[len(script)-1].success
+}
+
+// probeFailureMessage describes a failed probe like the kubelet's probe handlers do
+func probeFailureMessage(spec map[string]interface{}, podIP string) string {
+	switch {
+	case spec["httpGet"] != nil:
+		return "HTTP probe failed with statuscode: 500"
+	case spec["tcpSocket"] != nil:
+		tcpSocket, _ := resources.NestedMap(spec, "tcpSocket")
+		return fmt.Sprintf("dial tcp %s: connect: connection refused", net.JoinHostPort(podIP, fmt.Sprint(tcpSocket["port"])))
+	case spec["grpc"] != nil:
+		return `service unhealthy (responded with "NOT_SERVING")`
+	}
+	return "command exited with code 1"
+}
+
+// podContainerStatus returns the status of the named container of a pod, if any
+func podContainerStatus(pod map[string]interface{}, name string) map[string]interface{} {
+	containerStatuses, _ := resources.NestedSlice(pod, "status", "containerStatuses")
+	for _, cs := range containerStatuses {
+		if containerStatus, ok := cs.(map[string]interface{}); ok && containerStatus["name"] == name {
+			return containerStatus
+		}
+	}
+	return nil
+}
+
+// findProber returns the prober of the named container
+func findProber(containers []*containerProber, name string) *containerProber {
+	for _, c := range containers {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
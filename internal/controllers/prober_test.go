@@ -0,0 +1,194 @@
+package controllers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+// useProbingPodController returns a pod controller whose probe seconds last 10ms
+func useProbingPodController(t *testing.T, store *storage.InMemoryStore) *PodController {
+	pc := usePodController(t, store)
+	pc.ProbeTimeUnit = 10 * time.Millisecond
+	return pc
+}
+
+// newProbedPod returns a pod with a probed container "app" and an unprobed "sidecar"
+func newProbedPod(name string, probes map[string]interface{}, annotations map[string]string) *resources.Pod {
+	app := map[string]interface{}{"name": "app", "image": "app:v1"}
+	for field, probe := range probes {
+		app[field] = probe
+	}
+	pod := newTestPod(name, map[string]string{"app": "probed"}, map[string]interface{}{
+		"containers": []interface{}{app, map[string]interface{}{"name": "sidecar", "image": "sidecar:v1"}},
+	})
+	pod.Metadata.Annotations = annotations
+	return pod
+}
+
+// probeSpec returns an httpGet probe run every period ProbeTimeUnits
+func probeSpec(period, failureThreshold int) map[string]interface{} {
+	return map[string]interface{}{
+		"httpGet":          map[string]interface{}{"path": "/healthz", "port": float64(8080)},
+		"periodSeconds":    float64(period),
+		"failureThreshold": float64(failureThreshold),
+	}
+}
+
+// appStatus returns the status of the "app" container of a pod
+func appStatus(store *storage.InMemoryStore, name string) map[string]interface{} {
+	pod, err := store.GetPod(name)
+	if err != nil {
+		return nil
+	}
+	return podContainerStatus(pod, "app")
+}
+
+// hasEvent reports whether an event with reason and a message containing substr was recorded
+func hasEvent(store *storage.InMemoryStore, reason, substr string) bool {
+	for _, item := range store.ListEvents() {
+		event := item.(map[string]interface{})
+		message, _ := event["message"].(string)
+		if event["reason"] == reason && strings.Contains(message, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestReadinessProbeFlipsReady(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	useProbingPodController(t, store)
+
+	pod := newProbedPod("flappy", map[string]interface{}{"readinessProbe": probeSpec(1, 2)},
+		map[string]string{ReadinessProbeAnnotation: "failure*3,success*15,failure"})
+	if err := createPod(store, pod); err != nil {
+		t.Fatalf("Failed to create pod: %v", err)
+	}
+	waitFor(t, 5*time.Second, "pod running", func() bool {
+		stored, err := store.GetPod("flappy")
+		phase, _ := resources.NestedString(stored, "status", "phase")
+		return err == nil && phase == string(PodRunning)
+	})
+	stored, _ := store.GetPod("flappy")
+	if isPodReady(stored) || podCondition(stored, "ContainersReady")["message"] != "containers with unready status: [app]" {
+		t.Errorf("Expected the pod to start unready on app, got %v", stored["status"])
+	}
+	if sidecar := podContainerStatus(stored, "sidecar"); sidecar["ready"] != true {
+		t.Errorf("Expected the unprobed sidecar to be ready, got %v", sidecar)
+	}
+
+	waitFor(t, 5*time.Second, "pod ready", func() bool {
+		stored, _ := store.GetPod("flappy")
+		return isPodReady(stored) && podCondition(stored, "ContainersReady")["status"] == "True"
+	})
+	waitFor(t, 5*time.Second, "pod unready again", func() bool {
+		stored, _ := store.GetPod("flappy")
+		return !isPodReady(stored) && appStatus(store, "flappy")["ready"] == false
+	})
+	if restarts := appStatus(store, "flappy")["restartCount"]; restarts != float64(0) {
+		t.Errorf("Expected readiness failures not to restart the container, got restartCount %v", restarts)
+	}
+	if !hasEvent(store, EventReasonUnhealthy, "Readiness probe failed: HTTP probe failed with statuscode: 500") {
+		t.Error("Expected an Unhealthy event for the failed readiness probe")
+	}
+}
+
+func TestLivenessProbeRestartsContainer(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	useProbingPodController(t, store)
+
+	pod := newProbedPod("sick", map[string]interface{}{"livenessProbe": probeSpec(1, 3)},
+		map[string]string{LivenessProbeAnnotation + ".app": "success*2,failure*3,success"})
+	if err := createPod(store, pod); err != nil {
+		t.Fatalf("Failed to create pod: %v", err)
+	}
+	waitFor(t, 5*time.Second, "app restarted", func() bool {
+		return appStatus(store, "sick")["restartCount"] == float64(1)
+	})
+	app := appStatus(store, "sick")
+	if code, _ := resources.NestedInt64(app, "lastState", "terminated", "exitCode"); code != 137 {
+		t.Errorf("Expected lastState.terminated with exit code 137, got %v", app["lastState"])
+	}
+	if _, running := resources.NestedMap(app, "state", "running"); !running || app["ready"] != true {
+		t.Errorf("Expected app running and ready again, got %v", app)
+	}
+	if !hasEvent(store, EventReasonKilling, "Container app failed liveness probe, will be restarted") {
+		t.Error("Expected a Killing event")
+	}
+
+	// the script passes from then on: no more restarts, and the sidecar never restarted
+	time.Sleep(100 * time.Millisecond)
+	stored, _ := store.GetPod("sick")
+	if restarts := appStatus(store, "sick")["restartCount"]; restarts != float64(1) {
+		t.Errorf("Expected a single restart, got %v", restarts)
+	}
+	if restarts := podContainerStatus(stored, "sidecar")["restartCount"]; restarts != float64(0) {
+		t.Errorf("Expected the sidecar not to restart, got %v", restarts)
+	}
+	if !isPodReady(stored) {
+		t.Errorf("Expected the pod to be ready, got %v", stored["status"])
+	}
+}
+
+func TestStartupProbeGatesReadinessAndLiveness(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	useProbingPodController(t, store)
+
+	// liveness would kill the container right away, but only runs once startup passed
+	probes := map[string]interface{}{
+		"startupProbe":  probeSpec(2, 10),
+		"livenessProbe": probeSpec(1, 1),
+	}
+	pod := newProbedPod("slow", probes, map[string]string{
+		StartupProbeAnnotation:  "failure*5,success",
+		LivenessProbeAnnotation: "failure",
+	})
+	pod.Spec.(map[string]interface{})["restartPolicy"] = "Never"
+	if err := createPod(store, pod); err != nil {
+		t.Fatalf("Failed to create pod: %v", err)
+	}
+	waitFor(t, 5*time.Second, "pod running", func() bool {
+		return appStatus(store, "slow") != nil
+	})
+	if app := appStatus(store, "slow"); app["started"] != false || app["ready"] != false {
+		t.Errorf("Expected app not started and not ready before its startup probe passed, got %v", app)
+	}
+
+	// under restartPolicy Never the liveness failure stops the container for good
+	waitFor(t, 5*time.Second, "app killed", func() bool {
+		_, terminated := resources.NestedMap(appStatus(store, "slow"), "state", "terminated")
+		return terminated
+	})
+	stored, _ := store.GetPod("slow")
+	if restarts := appStatus(store, "slow")["restartCount"]; restarts != float64(0) || isPodReady(stored) {
+		t.Errorf("Expected app stopped without restarts and the pod unready, got %v", stored["status"])
+	}
+	if phase, _ := resources.NestedString(stored, "status", "phase"); phase != string(PodRunning) {
+		t.Errorf("Expected the pod to keep running on its sidecar, got phase %s", phase)
+	}
+}
+
+func TestParseProbeScript(t *testing.T) {
+	script, err := parseProbeScript("success*2, failure ,success")
+	if err != nil {
+		t.Fatalf("parseProbeScript failed: %v", err)
+	}
+	want := []bool{true, true, false, true, true}
+	for run, outcome := range want {
+		if got := scriptedOutcome(script, run); got != outcome {
+			t.Errorf("run %d: expected %v, got %v", run, outcome, got)
+		}
+	}
+	if !scriptedOutcome(nil, 3) {
+		t.Error("Expected probes without a script to succeed")
+	}
+	for _, bad := range []string{"ok", "failure*0", "success*x"} {
+		if _, err := parseProbeScript(bad); err == nil {
+			t.Errorf("Expected %q to be refused", bad)
+		}
+	}
+}
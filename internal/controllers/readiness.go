@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"time"
+
+	"mockernetes/internal/resources"
+)
+
+// Pod readiness as the workload controllers see it: a pod is ready while its Ready
+// condition is True and available once it has been ready for minReadySeconds.
+
+// podCondition returns the status condition of the given type, if any.
+func podCondition(pod map[string]interface{}, condType string) map[string]interface{} {
+	conditions, _ := resources.NestedSlice(pod, "status", "conditions")
+	for _, c := range conditions {
+		if cond, ok := c.(map[string]interface{}); ok && cond["type"] == condType {
+			return cond
+		}
+	}
+	return nil
+}
+
+// isPodActive reports whether the pod is neither terminating nor finished (Succeeded/Failed),
+// i.e. counts towards a workload's replicas.
+func isPodActive(pod map[string]interface{}) bool {
+	phase, _ := resources.NestedString(pod, "status", "phase")
+	return !isBeingDeleted(pod) && phase != string(PodSucceeded) && phase != string(PodFailed)
+}
+
+// isPodReady reports whether the pod is not terminating and its Ready condition is True.
+func isPodReady(pod map[string]interface{}) bool {
+	if isBeingDeleted(pod) {
+		return false
+	}
+	cond := podCondition(pod, "Ready")
+	return cond != nil && cond["status"] == "True"
+}
+
+// podAvailableIn reports whether a ready pod is available at now. For a ready pod that is
+// still inside minReadySeconds it also returns how long until it becomes available.
+func podAvailableIn(pod map[string]interface{}, minReadySeconds int64, now time.Time) (bool, time.Duration) {
+	if !isPodReady(pod) {
+		return false, 0
+	}
+	if minReadySeconds <= 0 {
+		return true, 0
+	}
+	since, _ := podCondition(pod, "Ready")["lastTransitionTime"].(string)
+	readyAt, err := time.Parse(time.RFC3339, since)
+	if err != nil {
+		return true, 0
+	}
+	availableAt := readyAt.Add(time.Duration(minReadySeconds) * time.Second)
+	if !now.Before(availableAt) {
+		return true, 0
+	}
+	return false, availableAt.Sub(now)
+}
@@ -1,70 +1,94 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
-	"sync"
 	"time"
 
 	"mockernetes/internal/resources"
 	"mockernetes/internal/storage"
 )
 
-// ReplicaSetController manages the lifecycle of ReplicaSets and their pods
+// ReplicaSetController manages the lifecycle of ReplicaSets and their pods.
+// It is driven by ReplicaSet and Pod change events through a work queue keyed by "namespace/name".
 type ReplicaSetController struct {
-	store        *storage.InMemoryStore
-	mu           sync.RWMutex
-	stopCh       chan struct{}
-	reconciling  map[string]bool // track which RS are being reconciled
-	reconcileMu  sync.Mutex
+	store   *storage.InMemoryStore
+	queue   *RateLimitingQueue
+	workers int
 }
 
-// NewReplicaSetController creates a new ReplicaSetController
-func NewReplicaSetController(store *storage.InMemoryStore) *ReplicaSetController {
-	return &ReplicaSetController{
-		store:       store,
-		stopCh:      make(chan struct{}),
-		reconciling: make(map[string]bool),
+// NewReplicaSetController creates a new ReplicaSetController fed by the given informers
+func NewReplicaSetController(store *storage.InMemoryStore, informers *SharedInformerFactory) *ReplicaSetController {
+	rsc := &ReplicaSetController{
+		store:   store,
+		queue:   NewRateLimitingQueue(),
+		workers: DefaultWorkers,
 	}
+	informers.AddEventHandler("ReplicaSet", ResourceEventHandlerFuncs{
+		AddFunc:    rsc.enqueue,
+		UpdateFunc: func(_, rs map[string]interface{}) { rsc.enqueue(rs) },
+		DeleteFunc: rsc.enqueue,
+	})
+	// Pod changes wake up the owning ReplicaSet (e.g. a deleted pod gets replaced)
+	informers.AddEventHandler("Pod", ResourceEventHandlerFuncs{
+		AddFunc: rsc.enqueueOwner,
+		UpdateFunc: func(old, pod map[string]interface{}) {
+			rsc.enqueueOwner(old)
+			rsc.enqueueOwner(pod)
+		},
+		DeleteFunc: rsc.enqueueOwner,
+	})
+	return rsc
 }
 
-// Start starts the controller's reconciliation loop
+// Start starts the controller's workers
 func (rsc *ReplicaSetController) Start() {
-	// Start a background reconciliation loop
-	go rsc.reconcileLoop()
+	runWorkers(rsc.queue, rsc.workers, "RS Controller", rsc.syncReplicaSet)
 }
 
 // Stop stops the controller
 func (rsc *ReplicaSetController) Stop() {
-	close(rsc.stopCh)
+	rsc.queue.ShutDownAndWait()
+}
+
+// enqueue queues a ReplicaSet for sync
+func (rsc *ReplicaSetController) enqueue(rs map[string]interface{}) {
+	rsc.queue.Add(objectKey(rs))
 }
 
-// reconcileLoop periodically reconciles ReplicaSets
-func (rsc *ReplicaSetController) reconcileLoop() {
-	ticker := time.NewTicker(10 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-rsc.stopCh:
-			return
-		case <-ticker.C:
-			rsc.reconcileAll()
+// enqueueOwner queues the ReplicaSet controlling a pod, or for an orphan every ReplicaSet
+// whose selector matches it (one of them adopts it)
+func (rsc *ReplicaSetController) enqueueOwner(pod map[string]interface{}) {
+	if key := ownerKey(pod, "ReplicaSet"); key != "" {
+		rsc.queue.Add(key)
+		return
+	}
+	if hasController(pod) || isBeingDeleted(pod) {
+		return
+	}
+	for _, item := range rsc.store.ListReplicaSets() {
+		rs, ok := item.(map[string]interface{})
+		if !ok || namespaceOf(rs) != namespaceOf(pod) {
+			continue
+		}
+		if selector, err := selectorOf(rs); err == nil && selector.Matches(podLabels(pod)) {
+			rsc.queue.Add(objectKey(rs))
 		}
 	}
 }
 
-// reconcileAll reconciles all ReplicaSets
-func (rsc *ReplicaSetController) reconcileAll() {
-	rss := rsc.store.ListReplicaSets()
-	for _, rsItem := range rss {
-		if rs, ok := rsItem.(map[string]interface{}); ok {
-			rsc.reconcileReplicaSet(rs)
-		}
+// syncReplicaSet reconciles the ReplicaSet stored under key (gone ReplicaSets need no work)
+func (rsc *ReplicaSetController) syncReplicaSet(key string) error {
+	_, name := splitKey(key)
+	rs, err := rsc.store.GetReplicaSet(name)
+	if err != nil {
+		return nil
 	}
+	return rsc.reconcileReplicaSet(rs)
 }
 
 // reconcileReplicaSet ensures the ReplicaSet has the correct number of pods
-func (rsc *ReplicaSetController) reconcileReplicaSet(rs map[string]interface{}) {
+func (rsc *ReplicaSetController) reconcileReplicaSet(rs map[string]interface{}) error {
 	// Extract ReplicaSet info
 	metadata, _ := rs["metadata"].(map[string]interface{})
 	spec, _ := rs["spec"].(map[string]interface{})
@@ -74,29 +98,11 @@ func (rsc *ReplicaSetController) reconcileReplicaSet(rs map[string]interface{})
 	if namespace == "" {
 		namespace = "default"
 	}
-
-	// Prevent concurrent reconciliation of the same ReplicaSet
 	rsKey := fmt.Sprintf("%s/%s", namespace, rsName)
-	rsc.reconcileMu.Lock()
-	if rsc.reconciling[rsKey] {
-		rsc.reconcileMu.Unlock()
-		fmt.Printf("[RS Controller] Skipping reconciliation of %s - already in progress\n", rsKey)
-		return
-	}
-	rsc.reconciling[rsKey] = true
-	rsc.reconcileMu.Unlock()
-
-	defer func() {
-		rsc.reconcileMu.Lock()
-		delete(rsc.reconciling, rsKey)
-		rsc.reconcileMu.Unlock()
-	}()
-
-	// Get desired replicas
-	desiredReplicas := int32(1) // default
-	if replicas, ok := spec["replicas"].(float64); ok {
-		desiredReplicas = int32(replicas)
-	}
+
+	// Get desired replicas (defaulted to 1 by the API on create)
+	replicas, _ := resources.NestedInt64(spec, "replicas")
+	desiredReplicas := int32(replicas)
 
 	// Get selector
 	selector := make(map[string]string)
@@ -110,16 +116,41 @@ func (rsc *ReplicaSetController) reconcileReplicaSet(rs map[string]interface{})
 		}
 	}
 
-	// Count existing pods matching this ReplicaSet
-	existingPods := rsc.getPodsForReplicaSet(rsName, namespace)
+	// Claim the pods matching the selector and count the active ones
+	existingPods, err := rsc.getPodsForReplicaSet(rs)
+	if err != nil {
+		fmt.Printf("[RS Controller] Error claiming pods for ReplicaSet %s: %v\n", rsKey, err)
+		return err
+	}
 	currentReplicas := int32(len(existingPods))
 
 	fmt.Printf("[RS Controller] Reconciling ReplicaSet %s: desired=%d, current=%d\n", rsKey, desiredReplicas, currentReplicas)
 
-	// Update ReplicaSet status
-	rsc.updateReplicaSetStatus(rsName, namespace, currentReplicas, desiredReplicas)
+	// A ReplicaSet waiting on finalizers keeps its pods but is no longer scaled
+	var manageErr error
+	if !isBeingDeleted(rs) {
+		manageErr = rsc.manageReplicas(rs, spec, selector, existingPods, desiredReplicas)
+	}
 
-	// Scale up or down as needed
+	// Status reflects the pods observed by this sync; the pod events of the changes above
+	// trigger the next one. Failures are retried with backoff.
+	wait, err := rsc.updateReplicaSetStatus(rs, existingPods, manageErr)
+	if err != nil {
+		return err
+	}
+	if wait > 0 {
+		// Nothing fires when a ready pod outlives minReadySeconds
+		rsc.queue.AddAfter(rsKey, wait)
+	}
+	return manageErr
+}
+
+// manageReplicas creates or deletes pods until the ReplicaSet has the desired number
+func (rsc *ReplicaSetController) manageReplicas(rs, spec map[string]interface{}, selector map[string]string, existingPods []map[string]interface{}, desiredReplicas int32) error {
+	rsName, _ := resources.NestedString(rs, "metadata", "name")
+	namespace := namespaceOf(rs)
+	currentReplicas := int32(len(existingPods))
+	var errs []error
 	if currentReplicas < desiredReplicas {
 		// Need to create pods
 		diff := desiredReplicas - currentReplicas
@@ -127,209 +158,178 @@ func (rsc *ReplicaSetController) reconcileReplicaSet(rs map[string]interface{})
 		for i := int32(0); i < diff; i++ {
 			if err := rsc.createPodForReplicaSet(rsName, namespace, spec, selector, currentReplicas+i); err != nil {
 				fmt.Printf("[RS Controller] Error creating pod: %v\n", err)
+				errs = append(errs, err)
 			}
 		}
 	} else if currentReplicas > desiredReplicas {
-		// Need to delete pods
+		// Need to delete pods, the least valuable ones first
 		diff := currentReplicas - desiredReplicas
 		fmt.Printf("[RS Controller] Deleting %d pods for ReplicaSet %s\n", diff, rsName)
-		for i := int32(0); i < diff && i < int32(len(existingPods)); i++ {
-			if podName, ok := existingPods[i]["podName"].(string); ok {
-				if err := rsc.deletePod(podName); err != nil {
-					fmt.Printf("[RS Controller] Error deleting pod %s: %v\n", podName, err)
-				}
+		for _, pod := range podsToDelete(existingPods, rsc.relatedPods(rs), int(diff)) {
+			podName, _ := resources.NestedString(pod, "metadata", "name")
+			if err := rsc.deletePod(podName); err != nil {
+				fmt.Printf("[RS Controller] Error deleting pod %s: %v\n", podName, err)
+				errs = append(errs, err)
 			}
 		}
 	}
+	if len(errs) == 0 {
+		return nil
+	}
+	reason := "FailedDelete"
+	if currentReplicas < desiredReplicas {
+		reason = "FailedCreate"
+	}
+	return &replicaFailure{reason: reason, err: errors.Join(errs...)}
+}
+
+// replicaFailure is a manageReplicas error, reported as the ReplicaFailure condition
+type replicaFailure struct {
+	reason string
+	err    error
 }
 
-// getPodsForReplicaSet returns pods owned by this ReplicaSet
-func (rsc *ReplicaSetController) getPodsForReplicaSet(rsName, namespace string) []map[string]interface{} {
+func (e *replicaFailure) Error() string { return e.err.Error() }
+func (e *replicaFailure) Unwrap() error { return e.err }
+
+// getPodsForReplicaSet claims the pods matching the ReplicaSet's selector (adopting orphans,
+// releasing pods relabeled out of it) and returns the active ones
+func (rsc *ReplicaSetController) getPodsForReplicaSet(rs map[string]interface{}) ([]map[string]interface{}, error) {
+	selector, err := selectorOf(rs)
+	if err != nil {
+		return nil, err
+	}
+	claimed, err := claimPods(rsc.store, rs, "ReplicaSet", selector)
 	var pods []map[string]interface{}
-	allPods := rsc.store.ListPods()
-
-	fmt.Printf("[RS Controller] getPodsForReplicaSet: checking %d total pods for RS %s/%s\n", len(allPods), namespace, rsName)
-
-	for _, podItem := range allPods {
-		if pod, ok := podItem.(map[string]interface{}); ok {
-			// Check if this pod is owned by our ReplicaSet
-			if metadata, ok := pod["metadata"].(map[string]interface{}); ok {
-				podName, _ := metadata["name"].(string)
-				podNS, _ := metadata["namespace"].(string)
-
-				// Check owner references - handle both []interface{} and []map[string]interface{}
-				if ownerRefsRaw, ok := metadata["ownerReferences"]; ok {
-					fmt.Printf("[RS Controller] getPodsForReplicaSet: pod %s/%s has ownerReferences\n", podNS, podName)
-					var ownerRefs []interface{}
-					switch v := ownerRefsRaw.(type) {
-					case []interface{}:
-						ownerRefs = v
-					case []map[string]interface{}:
-						for _, ref := range v {
-							ownerRefs = append(ownerRefs, ref)
-						}
-					}
-
-					for _, ownerRef := range ownerRefs {
-						if ref, ok := ownerRef.(map[string]interface{}); ok {
-							refName, _ := ref["name"].(string)
-							refKind, _ := ref["kind"].(string)
-							fmt.Printf("[RS Controller] getPodsForReplicaSet: checking ownerRef name=%s kind=%s against rsName=%s\n", refName, refKind, rsName)
-							if refName == rsName && refKind == "ReplicaSet" {
-								fmt.Printf("[RS Controller] getPodsForReplicaSet: found matching pod %s\n", podName)
-								pods = append(pods, map[string]interface{}{
-									"podName":   podName,
-									"namespace": podNS,
-								})
-							}
-						}
-					}
-				} else {
-					fmt.Printf("[RS Controller] getPodsForReplicaSet: pod %s/%s has NO ownerReferences\n", podNS, podName)
-				}
-			}
+	for _, pod := range claimed {
+		// Terminating and finished pods no longer count towards the replicas
+		if isPodActive(pod) {
+			pods = append(pods, pod)
 		}
 	}
 
-	fmt.Printf("[RS Controller] getPodsForReplicaSet: found %d pods for RS %s/%s\n", len(pods), namespace, rsName)
-	return pods
+	rsName, _ := resources.NestedString(rs, "metadata", "name")
+	fmt.Printf("[RS Controller] getPodsForReplicaSet: found %d pods for RS %s/%s\n", len(pods), namespaceOf(rs), rsName)
+	return pods, err
 }
 
-// createPodForReplicaSet creates a new pod for the ReplicaSet
-func (rsc *ReplicaSetController) createPodForReplicaSet(rsName, namespace string, rsSpec map[string]interface{}, selector map[string]string, index int32) error {
-	fmt.Printf("[RS Controller] createPodForReplicaSet called for %s/%s, index %d\n", namespace, rsName, index)
-
-	// Extract pod template from ReplicaSet spec
-	template, _ := rsSpec["template"].(map[string]interface{})
-	templateMetadata, _ := template["metadata"].(map[string]interface{})
-	templateSpec, _ := template["spec"].(map[string]interface{})
-
-	// Build pod labels (merge template labels with selector labels)
-	labels := make(map[string]string)
-	if podLabels, ok := templateMetadata["labels"].(map[string]interface{}); ok {
-		for k, v := range podLabels {
-			if sv, ok := v.(string); ok {
-				labels[k] = sv
+// relatedPods returns the active pods of the ReplicaSets sharing this one's controller
+// (the ReplicaSets of one Deployment), or of this ReplicaSet alone
+func (rsc *ReplicaSetController) relatedPods(rs map[string]interface{}) []map[string]interface{} {
+	uids := map[string]bool{objectUID(rs): true}
+	if owner := controllerOf(rs, "Deployment"); owner != nil {
+		for _, item := range rsc.store.ListReplicaSets() {
+			if other, ok := item.(map[string]interface{}); ok {
+				if ref := controllerOf(other, "Deployment"); ref != nil && ref["uid"] == owner["uid"] {
+					uids[objectUID(other)] = true
+				}
 			}
 		}
 	}
-	// Add selector labels
-	for k, v := range selector {
-		labels[k] = v
+	var related []map[string]interface{}
+	for _, item := range rsc.store.ListPods() {
+		pod, ok := item.(map[string]interface{})
+		if !ok || !isPodActive(pod) {
+			continue
+		}
+		if ref := controllerOf(pod, "ReplicaSet"); ref != nil {
+			if uid, _ := ref["uid"].(string); uids[uid] {
+				related = append(related, pod)
+			}
+		}
 	}
+	return related
+}
 
-	// Generate unique pod name
-	podName := fmt.Sprintf("%s-%d", rsName, time.Now().UnixNano())
-
-	// Build owner reference
-	ownerRef := resources.OwnerReference{
-		APIVersion:         "apps/v1",
-		Kind:               "ReplicaSet",
-		Name:               rsName,
-		UID:                fmt.Sprintf("rs-uid-%s", rsName),
-		Controller:         true,
-		BlockOwnerDeletion: true,
-	}
+// createPodForReplicaSet creates a new pod for the ReplicaSet
+func (rsc *ReplicaSetController) createPodForReplicaSet(rsName, namespace string, rsSpec map[string]interface{}, selector map[string]string, index int32) error {
+	fmt.Printf("[RS Controller] createPodForReplicaSet called for %s/%s, index %d\n", namespace, rsName, index)
 
-	// Create the pod struct with ownerReferences
-	pod := resources.Pod{
-		Kind:       "Pod",
-		APIVersion: "v1",
-		Metadata: resources.ObjectMeta{
-			Name:              podName,
-			Namespace:         namespace,
-			Labels:            labels,
-			CreationTimestamp: time.Now().Format(time.RFC3339),
-			OwnerReferences:   []resources.OwnerReference{ownerRef},
-		},
-		Spec: templateSpec,
+	// Build owner reference (the garbage collector follows it by uid)
+	rs, err := rsc.store.GetReplicaSet(rsName)
+	if err != nil {
+		return fmt.Errorf("failed to get ReplicaSet: %w", err)
 	}
 
-	// Store the pod
-	if err := rsc.store.CreatePod(pod); err != nil {
+	// Pod labels are the template labels merged with the selector labels
+	template, _ := rsSpec["template"].(map[string]interface{})
+	pod := newPodFromTemplate(rs, "ReplicaSet", template, selector)
+	if err := createPod(rsc.store, &pod); err != nil {
 		return fmt.Errorf("failed to create pod for ReplicaSet: %w", err)
 	}
 
-	fmt.Printf("[RS Controller] Created pod %s with ownerReferences for ReplicaSet %s\n", podName, rsName)
-
-	// Trigger pod controller for lifecycle management
-	if DefaultPodController != nil {
-		DefaultPodController.OnPodCreated(pod)
-	}
-
+	fmt.Printf("[RS Controller] Created pod %s with ownerReferences for ReplicaSet %s\n", pod.GetName(), rsName)
 	return nil
 }
 
-// deletePod deletes a pod by name
+// deletePod deletes a pod by name, gracefully when the pod controller is running
 func (rsc *ReplicaSetController) deletePod(podName string) error {
-	// Cancel any active transitions
-	if DefaultTransitionManager != nil {
-		DefaultTransitionManager.CancelTransition("default", podName)
-	}
-	// Remove any templates
-	if DefaultTemplateRegistry != nil {
-		DefaultTemplateRegistry.RemoveTemplate("default", podName)
-	}
-	return rsc.store.DeletePod(podName)
+	return deletePodObject(rsc.store, podName)
 }
 
-// updateReplicaSetStatus updates the ReplicaSet status with current replica counts
-func (rsc *ReplicaSetController) updateReplicaSetStatus(rsName, namespace string, current, desired int32) error {
-	rs, err := rsc.store.GetReplicaSet(rsName)
-	if err != nil {
-		return err
+// ReplicaSetReplicaFailure is the ReplicaSet (and Deployment) condition set while pods
+// can't be created or deleted.
+const ReplicaSetReplicaFailure = "ReplicaFailure"
+
+// updateReplicaSetStatus records the replica counts of the observed pods (calculateStatus upstream)
+// and the ReplicaFailure condition. It returns how long until the next ready pod becomes available.
+func (rsc *ReplicaSetController) updateReplicaSetStatus(rs map[string]interface{}, pods []map[string]interface{}, manageErr error) (time.Duration, error) {
+	rsName, _ := resources.NestedString(rs, "metadata", "name")
+	minReadySeconds, _ := resources.NestedInt64(rs, "spec", "minReadySeconds")
+	templateLabels, _ := resources.NestedMap(rs, "spec", "template", "metadata", "labels")
+	generation, _ := resources.NestedInt64(rs, "metadata", "generation")
+
+	var labeled, ready, available int32
+	var wait time.Duration
+	now := time.Now()
+	for _, pod := range pods {
+		if hasLabels(pod, templateLabels) {
+			labeled++
+		}
+		if isPodReady(pod) {
+			ready++
+		}
+		ok, after := podAvailableIn(pod, minReadySeconds, now)
+		if ok {
+			available++
+		} else if after > 0 && (wait == 0 || after < wait) {
+			wait = after
+		}
 	}
-
-	// Update status
 	status := map[string]interface{}{
-		"replicas":             current,
-		"availableReplicas":    current,
-		"readyReplicas":        current,
-		"fullyLabeledReplicas": current,
-		"observedGeneration":   1,
-	}
-
-	rs["status"] = status
-
-	// Convert back to ReplicaSet struct and update
-	updatedRS := resources.ReplicaSet{
-		Kind:       "ReplicaSet",
-		APIVersion: "apps/v1",
-		Metadata: resources.ObjectMeta{
-			Name:      rsName,
-			Namespace: namespace,
-		},
-		Spec:   rs["spec"],
-		Status: status,
+		"replicas":             int32(len(pods)),
+		"fullyLabeledReplicas": labeled,
+		"readyReplicas":        ready,
+		"availableReplicas":    available,
+		"observedGeneration":   generation,
 	}
 
-	return rsc.store.UpdateReplicaSet(updatedRS)
-}
-
-// OnReplicaSetCreated is called when a new ReplicaSet is created
-func (rsc *ReplicaSetController) OnReplicaSetCreated(rs resources.ReplicaSet) error {
-	// Trigger immediate reconciliation
-	rsMap := map[string]interface{}{
-		"metadata": map[string]interface{}{
-			"name":      rs.GetName(),
-			"namespace": rs.GetNamespace(),
-		},
-		"spec": rs.Spec,
-	}
-	rsc.reconcileReplicaSet(rsMap)
-	return nil
+	// Write only the status so user-owned metadata (labels, finalizers, ...) is kept
+	_, err := rsc.store.MutateReplicaSet(rsName, func(rs map[string]interface{}) error {
+		previous, _ := rs["status"].(map[string]interface{})
+		var failure map[string]interface{}
+		var rf *replicaFailure
+		if errors.As(manageErr, &rf) {
+			failure = setCondition(previous, ReplicaSetReplicaFailure, "True", rf.reason, rf.Error(), now)
+		}
+		if conditions := withCondition(previous, ReplicaSetReplicaFailure, failure); len(conditions) > 0 {
+			status["conditions"] = conditions
+		}
+		rs["status"] = status
+		return nil
+	})
+	return wait, err
 }
 
-// OnReplicaSetDeleted handles cleanup when a ReplicaSet is deleted
-func (rsc *ReplicaSetController) OnReplicaSetDeleted(rsName, namespace string) error {
-	// Find and delete all pods owned by this ReplicaSet
-	pods := rsc.getPodsForReplicaSet(rsName, namespace)
-	for _, pod := range pods {
-		if podName, ok := pod["podName"].(string); ok {
-			rsc.deletePod(podName)
+// hasLabels reports whether obj carries every label in labels
+func hasLabels(obj map[string]interface{}, labels map[string]interface{}) bool {
+	objLabels, _ := resources.NestedMap(obj, "metadata", "labels")
+	for k, v := range labels {
+		if objLabels[k] != v {
+			return false
 		}
 	}
-	return nil
+	return true
 }
 
 // DefaultReplicaSetController is the singleton instance
@@ -337,6 +337,6 @@ var DefaultReplicaSetController *ReplicaSetController
 
 // InitReplicaSetController initializes the default ReplicaSet controller
 func InitReplicaSetController(store *storage.InMemoryStore) {
-	DefaultReplicaSetController = NewReplicaSetController(store)
+	DefaultReplicaSetController = NewReplicaSetController(store, sharedInformers(store))
 	DefaultReplicaSetController.Start()
 }
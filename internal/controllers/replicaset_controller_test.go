@@ -0,0 +1,341 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+// waitFor polls cond until it holds or the timeout passes.
+func waitFor(t *testing.T, timeout time.Duration, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// startInformers returns a started informer factory stopped at the end of the test.
+func startInformers(t *testing.T, store *storage.InMemoryStore) *SharedInformerFactory {
+	informers := NewSharedInformerFactory(store, 0)
+	informers.Start()
+	t.Cleanup(informers.Stop)
+	return informers
+}
+
+func podCount(store *storage.InMemoryStore) func() int {
+	return func() int { return len(store.ListPods()) }
+}
+
+func TestReplicaSetControllerConvergesOnEvents(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	controller := NewReplicaSetController(store, startInformers(t, store))
+	controller.Start()
+	defer controller.Stop()
+
+	rs := resources.ReplicaSet{
+		Kind:       "ReplicaSet",
+		APIVersion: "apps/v1",
+		Metadata:   resources.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: map[string]interface{}{
+			"replicas": float64(3),
+			"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "web"}},
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "web"}},
+				"spec":     map[string]interface{}{"containers": []interface{}{map[string]interface{}{"name": "nginx", "image": "nginx"}}},
+			},
+		},
+	}
+	if err := store.CreateReplicaSet(rs); err != nil {
+		t.Fatalf("Failed to create replicaset: %v", err)
+	}
+	count := podCount(store)
+	waitFor(t, time.Second, "3 pods", func() bool { return count() == 3 })
+
+	// Scale down through a spec change
+	store.MutateReplicaSet("web", func(rs map[string]interface{}) error {
+		rs["spec"].(map[string]interface{})["replicas"] = 1
+		return nil
+	})
+	waitFor(t, time.Second, "scale down to 1 pod", func() bool { return count() == 1 })
+
+	// A deleted pod is replaced
+	pod := store.ListPods()[0].(map[string]interface{})
+	name, _ := resources.NestedString(pod, "metadata", "name")
+	store.DeletePod(name)
+	waitFor(t, time.Second, "replacement pod", func() bool {
+		pods := store.ListPods()
+		if len(pods) != 1 {
+			return false
+		}
+		newName, _ := resources.NestedString(pods[0].(map[string]interface{}), "metadata", "name")
+		return newName != name
+	})
+
+	stored, _ := store.GetReplicaSet("web")
+	waitFor(t, time.Second, "status.replicas 1", func() bool {
+		stored, _ = store.GetReplicaSet("web")
+		replicas, _ := resources.NestedInt64(stored, "status", "replicas")
+		return replicas == 1
+	})
+}
+
+func TestDeploymentControllerCreatesReplicaSet(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	informers := startInformers(t, store)
+	rsController := NewReplicaSetController(store, informers)
+	rsController.Start()
+	defer rsController.Stop()
+	deployController := NewDeploymentController(store, informers)
+	deployController.Start()
+	defer deployController.Stop()
+
+	deploy := resources.Deployment{
+		Kind:       "Deployment",
+		APIVersion: "apps/v1",
+		Metadata:   resources.ObjectMeta{Name: "api", Namespace: "default"},
+		Spec: map[string]interface{}{
+			"replicas": float64(2),
+			"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "api"}},
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "api"}},
+				"spec":     map[string]interface{}{"containers": []interface{}{map[string]interface{}{"name": "api", "image": "api:v1"}}},
+			},
+		},
+	}
+	if err := store.CreateDeployment(deploy); err != nil {
+		t.Fatalf("Failed to create deployment: %v", err)
+	}
+
+	waitFor(t, time.Second, "ReplicaSet and 2 pods", func() bool {
+		return len(store.ListReplicaSets()) == 1 && len(store.ListPods()) == 2
+	})
+
+	store.MutateDeployment("api", func(d map[string]interface{}) error {
+		d["spec"].(map[string]interface{})["replicas"] = 4
+		return nil
+	})
+	waitFor(t, time.Second, "scale up to 4 pods", func() bool { return len(store.ListPods()) == 4 })
+}
+
+func TestReplicaSetStatusFollowsPodReadiness(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	usePodController(t, store)
+	controller := NewReplicaSetController(store, startInformers(t, store))
+	controller.Start()
+	defer controller.Stop()
+
+	rs := resources.ReplicaSet{
+		Kind:       "ReplicaSet",
+		APIVersion: "apps/v1",
+		Metadata:   resources.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: map[string]interface{}{
+			"replicas": float64(2),
+			"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "web"}},
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "web"}},
+				"spec":     map[string]interface{}{"containers": []interface{}{map[string]interface{}{"name": "nginx", "image": "nginx"}}},
+			},
+		},
+	}
+	if err := store.CreateReplicaSet(rs); err != nil {
+		t.Fatalf("Failed to create replicaset: %v", err)
+	}
+	status := func(field string) int64 {
+		stored, _ := store.GetReplicaSet("web")
+		n, _ := resources.NestedInt64(stored, "status", field)
+		return n
+	}
+
+	waitFor(t, time.Second, "2 ready and available replicas", func() bool {
+		return status("readyReplicas") == 2 && status("availableReplicas") == 2 && status("fullyLabeledReplicas") == 2
+	})
+	if status("observedGeneration") != 1 {
+		t.Errorf("Expected observedGeneration 1, got %d", status("observedGeneration"))
+	}
+
+	// A pod turning unready is no longer counted
+	pod := store.ListPods()[0].(map[string]interface{})
+	name, _ := resources.NestedString(pod, "metadata", "name")
+	store.MutatePod(name, func(pod map[string]interface{}) error {
+		podCondition(pod, "Ready")["status"] = "False"
+		return nil
+	})
+	waitFor(t, time.Second, "1 ready replica", func() bool {
+		return status("readyReplicas") == 1 && status("availableReplicas") == 1 && status("replicas") == 2
+	})
+
+	// A spec change moves the generation, and the status follows it
+	store.MutateReplicaSet("web", func(rs map[string]interface{}) error {
+		rs["spec"].(map[string]interface{})["replicas"] = 3
+		return nil
+	})
+	stored, _ := store.GetReplicaSet("web")
+	if generation, _ := resources.NestedInt64(stored, "metadata", "generation"); generation != 2 {
+		t.Fatalf("Expected generation 2 after a spec change, got %d", generation)
+	}
+	waitFor(t, time.Second, "observedGeneration 2 with 3 replicas", func() bool {
+		return status("observedGeneration") == 2 && status("replicas") == 3
+	})
+}
+
+func newTestReplicaSet(name string, replicas int, selector map[string]interface{}) resources.ReplicaSet {
+	return resources.ReplicaSet{
+		Kind:       "ReplicaSet",
+		APIVersion: "apps/v1",
+		Metadata:   resources.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: map[string]interface{}{
+			"replicas": float64(replicas),
+			"selector": selector,
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": name}},
+				"spec":     map[string]interface{}{"containers": []interface{}{map[string]interface{}{"name": "nginx", "image": "nginx"}}},
+			},
+		},
+	}
+}
+
+// ownedPods returns the names of the pods controlled by the named ReplicaSet.
+func ownedPods(store *storage.InMemoryStore, rsName string) []string {
+	var names []string
+	for _, item := range store.ListPods() {
+		pod := item.(map[string]interface{})
+		if ref := controllerOf(pod, "ReplicaSet"); ref != nil && ref["name"] == rsName && !isBeingDeleted(pod) {
+			name, _ := resources.NestedString(pod, "metadata", "name")
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func TestReplicaSetAdoptsAndReleasesPods(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	controller := NewReplicaSetController(store, startInformers(t, store))
+	controller.Start()
+	defer controller.Stop()
+
+	orphan := resources.Pod{
+		Kind:       "Pod",
+		APIVersion: "v1",
+		Metadata:   resources.ObjectMeta{Name: "orphan", Namespace: "default", Labels: map[string]string{"app": "web", "tier": "front"}},
+		Spec:       map[string]interface{}{"containers": []interface{}{map[string]interface{}{"name": "nginx", "image": "nginx"}}},
+	}
+	if err := store.CreatePod(orphan); err != nil {
+		t.Fatalf("Failed to create pod: %v", err)
+	}
+	selector := map[string]interface{}{
+		"matchExpressions": []interface{}{
+			map[string]interface{}{"key": "app", "operator": "In", "values": []interface{}{"web"}},
+			map[string]interface{}{"key": "canary", "operator": "DoesNotExist"},
+		},
+	}
+	if err := store.CreateReplicaSet(newTestReplicaSet("web", 2, selector)); err != nil {
+		t.Fatalf("Failed to create replicaset: %v", err)
+	}
+
+	// The orphan is adopted and counts as one of the two replicas
+	waitFor(t, time.Second, "orphan adopted with 2 pods in total", func() bool {
+		return len(ownedPods(store, "web")) == 2 && len(store.ListPods()) == 2
+	})
+	if pod, _ := store.GetPod("orphan"); controllerOf(pod, "ReplicaSet") == nil {
+		t.Fatal("Expected the orphan pod to be adopted")
+	}
+
+	// Relabeled out of the selector it is released and replaced
+	store.MutatePod("orphan", func(pod map[string]interface{}) error {
+		pod["metadata"].(map[string]interface{})["labels"].(map[string]interface{})["canary"] = "true"
+		return nil
+	})
+	waitFor(t, time.Second, "orphan released and replaced", func() bool {
+		pod, _ := store.GetPod("orphan")
+		return len(ownerReferences(pod)) == 0 && len(ownedPods(store, "web")) == 2 && len(store.ListPods()) == 3
+	})
+}
+
+func TestReplicaSetScaleDownHonorsDeletionCost(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	usePodController(t, store)
+	controller := NewReplicaSetController(store, startInformers(t, store))
+	controller.Start()
+	defer controller.Stop()
+
+	selector := map[string]interface{}{"matchLabels": map[string]interface{}{"app": "web"}}
+	if err := store.CreateReplicaSet(newTestReplicaSet("web", 3, selector)); err != nil {
+		t.Fatalf("Failed to create replicaset: %v", err)
+	}
+	waitFor(t, time.Second, "3 ready pods", func() bool {
+		stored, _ := store.GetReplicaSet("web")
+		ready, _ := resources.NestedInt64(stored, "status", "readyReplicas")
+		return ready == 3
+	})
+
+	pods := ownedPods(store, "web")
+	cheap := pods[1]
+	store.MutatePod(cheap, func(pod map[string]interface{}) error {
+		objectAnnotations(pod)[PodDeletionCostAnnotation] = "-100"
+		return nil
+	})
+	for _, name := range []string{pods[0], pods[2]} {
+		store.MutatePod(name, func(pod map[string]interface{}) error {
+			objectAnnotations(pod)[PodDeletionCostAnnotation] = "100"
+			return nil
+		})
+	}
+	store.MutateReplicaSet("web", func(rs map[string]interface{}) error {
+		rs["spec"].(map[string]interface{})["replicas"] = 2
+		return nil
+	})
+	waitFor(t, time.Second, "scale down to 2 pods", func() bool { return len(ownedPods(store, "web")) == 2 })
+	for _, name := range ownedPods(store, "web") {
+		if name == cheap {
+			t.Errorf("Expected pod %s with the lowest deletion cost to be deleted first", cheap)
+		}
+	}
+}
+
+func TestPodsToDeleteRanking(t *testing.T) {
+	now := time.Now()
+	pod := func(name, phase, node string, ready bool, readySince time.Duration, annotations map[string]interface{}) map[string]interface{} {
+		readyStatus := "False"
+		if ready {
+			readyStatus = "True"
+		}
+		return map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name":              name,
+				"annotations":       annotations,
+				"creationTimestamp": now.Add(-time.Hour).Format(time.RFC3339),
+			},
+			"spec": map[string]interface{}{"nodeName": node},
+			"status": map[string]interface{}{
+				"phase": phase,
+				"conditions": []interface{}{map[string]interface{}{
+					"type": "Ready", "status": readyStatus,
+					"lastTransitionTime": now.Add(-readySince).Format(time.RFC3339),
+				}},
+			},
+		}
+	}
+	pods := []map[string]interface{}{
+		pod("old", "Running", "node-e", true, time.Hour, nil),
+		pod("doubled", "Running", "node-b", true, time.Hour, nil),
+		pod("cheap", "Running", "node-c", true, time.Hour, map[string]interface{}{PodDeletionCostAnnotation: "-5"}),
+		pod("fresh", "Running", "node-d", true, time.Second, nil),
+		pod("unready", "Running", "node-a", false, time.Hour, nil),
+		pod("pending", "Pending", "node-a", false, 0, nil),
+		pod("unscheduled", "Pending", "", false, 0, nil),
+	}
+	related := append([]map[string]interface{}{pod("sibling", "Running", "node-b", true, time.Hour, nil)}, pods...)
+
+	want := []string{"unscheduled", "pending", "unready", "cheap", "doubled", "fresh", "old"}
+	for i, victim := range podsToDelete(pods, related, len(pods)-1) {
+		if name, _ := resources.NestedString(victim, "metadata", "name"); name != want[i] {
+			t.Errorf("Victim %d: expected %s, got %s", i, want[i], name)
+		}
+	}
+}
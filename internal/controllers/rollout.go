@@ -0,0 +1,468 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"mockernetes/internal/resources"
+)
+
+// Deployment rollout strategies, following pkg/controller/deployment/{rolling,recreate}.go upstream.
+// A rollout is one sync's view of a Deployment: the new ReplicaSet (matching the current
+// pod template), the old ones, and the pods of each. Scaling one step at a time and waiting
+// for the next pod event gives the usual surge/drain sequence.
+
+// rollout holds the ReplicaSets and pods of a Deployment for one sync
+type rollout struct {
+	deploy          map[string]interface{}
+	name            string
+	namespace       string
+	hash            string
+	replicas        int32
+	maxSurge        int32
+	maxUnavailable  int32 // 0 for Recreate
+	minReadySeconds int64
+	paused          bool
+	newRS           map[string]interface{}
+	oldRSs          []map[string]interface{}            // oldest first
+	pods            map[string][]map[string]interface{} // by ReplicaSet uid, terminating pods included
+	now             time.Time
+	// createdRS is set when this sync created the new ReplicaSet
+	createdRS bool
+	// requeueAfter is when the next ready pod becomes available (0 if none is waiting)
+	requeueAfter time.Duration
+}
+
+// allRSs returns the old ReplicaSets followed by the new one (if it exists)
+func (r *rollout) allRSs() []map[string]interface{} {
+	if r.newRS == nil {
+		return r.oldRSs
+	}
+	return append(append([]map[string]interface{}{}, r.oldRSs...), r.newRS)
+}
+
+// activePods returns the pods of rs that are neither terminating nor finished
+func (r *rollout) activePods(rs map[string]interface{}) []map[string]interface{} {
+	if rs == nil {
+		return nil
+	}
+	var active []map[string]interface{}
+	for _, pod := range r.pods[objectUID(rs)] {
+		if isPodActive(pod) {
+			active = append(active, pod)
+		}
+	}
+	return active
+}
+
+// podCount returns the number of non-terminating pods of rs
+func (r *rollout) podCount(rs map[string]interface{}) int32 {
+	return int32(len(r.activePods(rs)))
+}
+
+// readyCount returns the number of ready pods of rs
+func (r *rollout) readyCount(rs map[string]interface{}) int32 {
+	var ready int32
+	for _, pod := range r.activePods(rs) {
+		if isPodReady(pod) {
+			ready++
+		}
+	}
+	return ready
+}
+
+// availableCount returns the number of available pods of rs, noting when the next one
+// still inside minReadySeconds becomes available
+func (r *rollout) availableCount(rs map[string]interface{}) int32 {
+	var available int32
+	for _, pod := range r.activePods(rs) {
+		ok, wait := podAvailableIn(pod, r.minReadySeconds, r.now)
+		if ok {
+			available++
+		} else if wait > 0 && (r.requeueAfter == 0 || wait < r.requeueAfter) {
+			r.requeueAfter = wait
+		}
+	}
+	return available
+}
+
+// availableTotal sums availableCount over rsList
+func (r *rollout) availableTotal(rsList []map[string]interface{}) int32 {
+	var total int32
+	for _, rs := range rsList {
+		total += r.availableCount(rs)
+	}
+	return total
+}
+
+// oldPodsExist reports whether any old ReplicaSet still has pods, terminating ones included
+func (r *rollout) oldPodsExist() bool {
+	for _, rs := range r.oldRSs {
+		if len(r.pods[objectUID(rs)]) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// rsReplicas returns spec.replicas of a ReplicaSet (0 for nil)
+func rsReplicas(rs map[string]interface{}) int32 {
+	replicas, _ := resources.NestedInt64(rs, "spec", "replicas")
+	return int32(replicas)
+}
+
+// replicasTotal sums spec.replicas over rsList
+func replicasTotal(rsList []map[string]interface{}) int32 {
+	var total int32
+	for _, rs := range rsList {
+		total += rsReplicas(rs)
+	}
+	return total
+}
+
+// sortByCreation orders ReplicaSets oldest first (name breaks ties of the second-granular timestamps)
+func sortByCreation(rsList []map[string]interface{}) {
+	sort.SliceStable(rsList, func(i, j int) bool {
+		ti, _ := resources.NestedString(rsList[i], "metadata", "creationTimestamp")
+		tj, _ := resources.NestedString(rsList[j], "metadata", "creationTimestamp")
+		if ti != tj {
+			return ti < tj
+		}
+		ni, _ := resources.NestedString(rsList[i], "metadata", "name")
+		nj, _ := resources.NestedString(rsList[j], "metadata", "name")
+		return ni < nj
+	})
+}
+
+// scaledValue resolves an int-or-percent value (2 or "25%") against total
+func scaledValue(value interface{}, total int32, roundUp bool) int32 {
+	s, ok := value.(string)
+	if !ok {
+		n, _ := resources.ToInt64(value)
+		return int32(n)
+	}
+	percent, err := strconv.Atoi(strings.TrimSuffix(s, "%"))
+	if err != nil {
+		return 0
+	}
+	if !strings.HasSuffix(s, "%") {
+		return int32(percent)
+	}
+	scaled := float64(percent) * float64(total) / 100
+	if roundUp {
+		return int32(math.Ceil(scaled))
+	}
+	return int32(math.Floor(scaled))
+}
+
+// replicasAnnotations returns the desired/max-replicas annotations for the rollout's ReplicaSets
+func replicasAnnotations(r *rollout) map[string]string {
+	return map[string]string{
+		DesiredReplicasAnnotation: strconv.Itoa(int(r.replicas)),
+		MaxReplicasAnnotation:     strconv.Itoa(int(r.replicas + r.maxSurge)),
+	}
+}
+
+// resolveFenceposts returns the absolute maxSurge (rounded up) and maxUnavailable (rounded down).
+// Both resolving to 0 would block the rollout, so maxUnavailable becomes 1 like upstream.
+func resolveFenceposts(spec map[string]interface{}, replicas int32) (int32, int32) {
+	rollingUpdate, _ := resources.NestedMap(spec, "strategy", "rollingUpdate")
+	surgeValue, ok := rollingUpdate["maxSurge"]
+	if !ok {
+		surgeValue = resources.DefaultMaxSurge
+	}
+	unavailableValue, ok := rollingUpdate["maxUnavailable"]
+	if !ok {
+		unavailableValue = resources.DefaultMaxUnavailable
+	}
+	surge := scaledValue(surgeValue, replicas, true)
+	unavailable := scaledValue(unavailableValue, replicas, false)
+	if surge == 0 && unavailable == 0 {
+		unavailable = 1
+	}
+	return surge, unavailable
+}
+
+// rolloutRolling creates or scales the new ReplicaSet up within maxSurge and scales the
+// old ones down within maxUnavailable
+func (dc *DeploymentController) rolloutRolling(r *rollout) error {
+	if r.newRS == nil {
+		if err := dc.createNewReplicaSet(r, newRSReplicas(r, r.maxSurge)); err != nil {
+			return err
+		}
+	} else if err := dc.reconcileNewReplicaSet(r, r.maxSurge); err != nil {
+		return err
+	}
+	return dc.reconcileOldReplicaSets(r, r.maxUnavailable)
+}
+
+// newRSReplicas returns how far the new ReplicaSet may scale towards replicas without
+// exceeding replicas+maxSurge pods in total
+func newRSReplicas(r *rollout, maxSurge int32) int32 {
+	current := rsReplicas(r.newRS)
+	maxTotal := r.replicas + maxSurge
+	total := replicasTotal(r.allRSs())
+	if total >= maxTotal {
+		return current
+	}
+	return current + min(maxTotal-total, r.replicas-current)
+}
+
+// reconcileNewReplicaSet scales the new ReplicaSet up within maxSurge, or down if replicas shrank
+func (dc *DeploymentController) reconcileNewReplicaSet(r *rollout, maxSurge int32) error {
+	current := rsReplicas(r.newRS)
+	switch {
+	case current == r.replicas:
+		return nil
+	case current > r.replicas:
+		return dc.scaleReplicaSet(r, r.newRS, r.replicas)
+	}
+	if replicas := newRSReplicas(r, maxSurge); replicas != current {
+		return dc.scaleReplicaSet(r, r.newRS, replicas)
+	}
+	return nil
+}
+
+// reconcileOldReplicaSets scales old ReplicaSets down while at least replicas-maxUnavailable
+// pods stay available. Unhealthy old pods go first since they don't count as available anyway.
+func (dc *DeploymentController) reconcileOldReplicaSets(r *rollout, maxUnavailable int32) error {
+	if replicasTotal(r.oldRSs) == 0 {
+		return nil
+	}
+	minAvailable := r.replicas - maxUnavailable
+	newRSUnavailable := rsReplicas(r.newRS) - r.availableCount(r.newRS)
+	maxScaledDown := replicasTotal(r.allRSs()) - minAvailable - newRSUnavailable
+	if maxScaledDown <= 0 {
+		return nil
+	}
+
+	cleaned, err := dc.cleanupUnhealthyReplicas(r, maxScaledDown)
+	if err != nil {
+		return err
+	}
+	return dc.scaleDownOldReplicaSets(r, minAvailable, maxScaledDown-cleaned)
+}
+
+// cleanupUnhealthyReplicas removes up to maxCleanup unavailable replicas from old ReplicaSets
+func (dc *DeploymentController) cleanupUnhealthyReplicas(r *rollout, maxCleanup int32) (int32, error) {
+	var cleaned int32
+	for _, rs := range r.oldRSs {
+		if cleaned >= maxCleanup {
+			break
+		}
+		replicas := rsReplicas(rs)
+		unhealthy := replicas - r.availableCount(rs)
+		if replicas == 0 || unhealthy <= 0 {
+			continue
+		}
+		scaleDown := min(maxCleanup-cleaned, unhealthy)
+		if err := dc.scaleReplicaSet(r, rs, replicas-scaleDown); err != nil {
+			return cleaned, err
+		}
+		cleaned += scaleDown
+	}
+	return cleaned, nil
+}
+
+// scaleDownOldReplicaSets scales old ReplicaSets down, oldest first, by as many available
+// replicas as exceed minAvailable (capped at maxScaleDown)
+func (dc *DeploymentController) scaleDownOldReplicaSets(r *rollout, minAvailable, maxScaleDown int32) error {
+	available := r.availableTotal(r.allRSs())
+	scaleDownTotal := min(available-minAvailable, maxScaleDown)
+	for _, rs := range r.oldRSs {
+		if scaleDownTotal <= 0 {
+			break
+		}
+		replicas := rsReplicas(rs)
+		if replicas == 0 {
+			continue
+		}
+		scaleDown := min(replicas, scaleDownTotal)
+		if err := dc.scaleReplicaSet(r, rs, replicas-scaleDown); err != nil {
+			return err
+		}
+		scaleDownTotal -= scaleDown
+	}
+	return nil
+}
+
+// rolloutRecreate scales all old ReplicaSets to zero and scales the new one up only once
+// every old pod is gone
+func (dc *DeploymentController) rolloutRecreate(r *rollout) error {
+	for _, rs := range r.oldRSs {
+		if rsReplicas(rs) > 0 {
+			if err := dc.scaleReplicaSet(r, rs, 0); err != nil {
+				return err
+			}
+		}
+	}
+	if r.oldPodsExist() {
+		return nil
+	}
+	if r.newRS == nil {
+		return dc.createNewReplicaSet(r, r.replicas)
+	}
+	if rsReplicas(r.newRS) != r.replicas {
+		return dc.scaleReplicaSet(r, r.newRS, r.replicas)
+	}
+	return nil
+}
+
+// scaleReplicaSet sets spec.replicas of rs in the store and in the rollout's copy, recording
+// the Deployment size it was scaled for in the desired/max-replicas annotations
+func (dc *DeploymentController) scaleReplicaSet(r *rollout, rs map[string]interface{}, replicas int32) error {
+	name, _ := resources.NestedString(rs, "metadata", "name")
+	current := rsReplicas(rs)
+	direction := "up"
+	if replicas < current {
+		direction = "down"
+	}
+	fmt.Printf("[Deployment Controller] Scaling %s ReplicaSet %s: %d -> %d\n", direction, name, current, replicas)
+	if err := dc.updateReplicaSetReplicas(name, replicas, replicasAnnotations(r)); err != nil {
+		return err
+	}
+	if spec, ok := rs["spec"].(map[string]interface{}); ok {
+		spec["replicas"] = float64(replicas)
+	}
+	return nil
+}
+
+// Deployment condition types and reasons (k8s.io/api/apps/v1, pkg/controller/deployment/util).
+const (
+	DeploymentAvailable   = "Available"
+	DeploymentProgressing = "Progressing"
+
+	MinimumReplicasAvailable   = "MinimumReplicasAvailable"
+	MinimumReplicasUnavailable = "MinimumReplicasUnavailable"
+
+	NewReplicaSetReason     = "NewReplicaSetCreated"
+	FoundNewRSReason        = "FoundNewReplicaSet"
+	ReplicaSetUpdatedReason = "ReplicaSetUpdated"
+	NewRSAvailableReason    = "NewReplicaSetAvailable"
+	TimedOutReason          = "ProgressDeadlineExceeded"
+	PausedDeployReason      = "DeploymentPaused"
+	ResumedDeployReason     = "DeploymentResumed"
+)
+
+// deploymentCondition returns the condition of the given type from a Deployment status
+func deploymentCondition(status map[string]interface{}, condType string) map[string]interface{} {
+	conditions, _ := resources.NestedSlice(status, "conditions")
+	for _, c := range conditions {
+		if cond, ok := c.(map[string]interface{}); ok && cond["type"] == condType {
+			return cond
+		}
+	}
+	return nil
+}
+
+// progressingConditions returns the status conditions with Progressing updated for the new
+// status (syncRolloutStatus upstream), plus how long until the progress deadline has to be
+// checked again (0 when the rollout is complete or has no deadline).
+func progressingConditions(r *rollout, deploy, previous, status map[string]interface{}) ([]interface{}, time.Duration) {
+	now := r.now.UTC().Format(time.RFC3339)
+	rsName, _ := resources.NestedString(r.newRS, "metadata", "name")
+	current := deploymentCondition(previous, DeploymentProgressing)
+
+	// set replaces the Progressing condition. Unless refresh is given, a condition with the
+	// same status and reason is kept as is; lastTransitionTime only moves when status flips.
+	set := func(condStatus, reason, message string, refresh bool) {
+		if current != nil && current["status"] == condStatus && current["reason"] == reason && !refresh {
+			return
+		}
+		transition := now
+		if current != nil && current["status"] == condStatus {
+			if t, ok := current["lastTransitionTime"].(string); ok {
+				transition = t
+			}
+		}
+		current = map[string]interface{}{
+			"type":               DeploymentProgressing,
+			"status":             condStatus,
+			"lastUpdateTime":     now,
+			"lastTransitionTime": transition,
+			"reason":             reason,
+			"message":            message,
+		}
+	}
+
+	// While paused there is no progress to track; resuming restarts the deadline
+	reason, _ := current["reason"].(string)
+	if r.paused {
+		if reason != PausedDeployReason {
+			set("Unknown", PausedDeployReason, "Deployment is paused", false)
+		}
+		return replaceCondition(previous, current), 0
+	}
+	if reason == PausedDeployReason {
+		set("Unknown", ResumedDeployReason, "Deployment is resumed", false)
+	}
+
+	if r.createdRS {
+		set("True", NewReplicaSetReason, fmt.Sprintf("Created new replica set %q", rsName), false)
+	} else if current == nil && r.newRS != nil {
+		set("True", FoundNewRSReason, fmt.Sprintf("Found new replica set %q", rsName), false)
+	}
+
+	count := func(s map[string]interface{}, field string) int64 {
+		n, _ := resources.NestedInt64(s, field)
+		return n
+	}
+	replicas, updated := count(status, "replicas"), count(status, "updatedReplicas")
+	available := count(status, "availableReplicas")
+	complete := updated == int64(r.replicas) && replicas == int64(r.replicas) && available == int64(r.replicas)
+	progressing := updated > count(previous, "updatedReplicas") ||
+		replicas-updated < count(previous, "replicas")-count(previous, "updatedReplicas") ||
+		count(status, "readyReplicas") > count(previous, "readyReplicas") ||
+		available > count(previous, "availableReplicas")
+	deadline, hasDeadline := resources.NestedInt64(deploy, "spec", "progressDeadlineSeconds")
+
+	var lastUpdate time.Time
+	if current != nil {
+		updateTime, _ := current["lastUpdateTime"].(string)
+		lastUpdate, _ = time.Parse(time.RFC3339, updateTime)
+	}
+	reason, _ = current["reason"].(string)
+	switch {
+	case complete && r.newRS != nil:
+		set("True", NewRSAvailableReason, fmt.Sprintf("ReplicaSet %q has successfully progressed.", rsName), false)
+	case progressing && r.newRS != nil:
+		set("True", ReplicaSetUpdatedReason, fmt.Sprintf("ReplicaSet %q is progressing.", rsName), true)
+	case hasDeadline && current != nil && reason != NewRSAvailableReason &&
+		(reason == TimedOutReason || r.now.After(lastUpdate.Add(time.Duration(deadline)*time.Second))):
+		set("False", TimedOutReason, fmt.Sprintf("ReplicaSet %q has timed out progressing.", rsName), false)
+	}
+
+	conditions := replaceCondition(previous, current)
+
+	// A stuck rollout produces no events, so the deadline has to be checked on a timer
+	if !hasDeadline || current == nil || complete || current["reason"] == NewRSAvailableReason || current["reason"] == TimedOutReason {
+		return conditions, 0
+	}
+	updateTime, _ := current["lastUpdateTime"].(string)
+	lastUpdate, _ = time.Parse(time.RFC3339, updateTime)
+	return conditions, max(lastUpdate.Add(time.Duration(deadline)*time.Second).Sub(r.now), 0) + time.Second
+}
+
+// replaceCondition returns the conditions of status with cond put in place of the one of the
+// same type (appended if there was none); the other conditions are kept as they are.
+func replaceCondition(status, cond map[string]interface{}) []interface{} {
+	var conditions []interface{}
+	replaced := false
+	existing, _ := resources.NestedSlice(status, "conditions")
+	for _, c := range existing {
+		if old, ok := c.(map[string]interface{}); ok && cond != nil && old["type"] == cond["type"] {
+			conditions = append(conditions, cond)
+			replaced = true
+			continue
+		}
+		conditions = append(conditions, c)
+	}
+	if !replaced && cond != nil {
+		conditions = append(conditions, cond)
+	}
+	return conditions
+}
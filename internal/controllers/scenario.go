@@ -0,0 +1,342 @@
+package controllers
+
+import (
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+	"sigs.k8s.io/yaml"
+)
+
+// Simulation scenarios. A scenario bundles transition templates that apply to every pod they
+// match, unlike the templates of POST /simulate/controller/pod that target one pod name: pods
+// are matched by namespace, name (exact, prefix or regular expression), label selector and
+// owner (a Deployment owns the pods of its ReplicaSets), and nth narrows a template to the nth
+// pod it matches. The first template (in load order) applying to a created pod drives its
+// status; every template counts the pods it matches. Scenario files (YAML or JSON) are loaded
+// at startup from MOCKERNETES_SCENARIOS (comma separated paths) or POSTed to /simulate/scenarios:
+//
+//	name: chaos
+//	templates:
+//	- name: third-web-pod-fails
+//	  match:
+//	    owner: {kind: Deployment, name: web}
+//	    nth: 3
+//	  transitions:
+//	  - {phase: Running, delay: 1s}
+//	  - {phase: Failed, delay: 10s}
+
+// ScenariosEnv names the environment variable holding the scenario files loaded at startup
+const ScenariosEnv = "MOCKERNETES_SCENARIOS"
+
+// maxOwnerDepth bounds how far owner matching follows owner references (pod, ReplicaSet, Deployment)
+const maxOwnerDepth = 3
+
+// Scenario is a named set of transition templates
+type Scenario struct {
+	Name      string             `json:"name"`
+	Templates []ScenarioTemplate `json:"templates"`
+}
+
+// ScenarioTemplate is a transition sequence for the pods matching it
+type ScenarioTemplate struct {
+	Name        string            `json:"name,omitempty"`
+	Match       PodMatch          `json:"match"`
+	Transitions []TransitionState `json:"transitions"`
+	// Seed makes the random choices reproducible; each pod draws from the seed mixed with its name
+	Seed *int64 `json:"seed,omitempty"`
+}
+
+// PodMatch selects pods; unset fields match every pod
+type PodMatch struct {
+	Namespace  string                `json:"namespace,omitempty"`
+	Name       string                `json:"name,omitempty"`
+	NamePrefix string                `json:"namePrefix,omitempty"`
+	NameRegex  string                `json:"nameRegex,omitempty"`
+	Selector   *metav1.LabelSelector `json:"selector,omitempty"`
+	Owner      *OwnerMatch           `json:"owner,omitempty"`
+	// Nth applies the template to the nth pod matching the other fields only (1 is the first)
+	Nth int `json:"nth,omitempty"`
+}
+
+// OwnerMatch matches the pods owned, directly or through their owners, by a kind of object
+type OwnerMatch struct {
+	Kind string `json:"kind"`
+	// Name is the owner's name; empty matches every owner of the kind
+	Name string `json:"name,omitempty"`
+}
+
+// ScenarioInfo reports a loaded scenario with how many pods each template matched
+type ScenarioInfo struct {
+	Name      string                 `json:"name"`
+	Templates []ScenarioTemplateInfo `json:"templates"`
+}
+
+// ScenarioTemplateInfo reports a template of a loaded scenario
+type ScenarioTemplateInfo struct {
+	Name    string   `json:"name,omitempty"`
+	Match   PodMatch `json:"match"`
+	Matched int      `json:"matched"`
+}
+
+// scenarioTemplate is a loaded ScenarioTemplate with its matcher compiled
+type scenarioTemplate struct {
+	ScenarioTemplate
+	nameRegex *regexp.Regexp
+	selector  labels.Selector
+	// matched counts the created pods the template matched
+	matched int
+}
+
+// loadedScenario is a scenario in the template registry
+type loadedScenario struct {
+	name      string
+	templates []*scenarioTemplate
+}
+
+// ParseScenario reads a scenario from YAML or JSON
+func ParseScenario(data []byte) (*Scenario, error) {
+	var scenario Scenario
+	if err := yaml.UnmarshalStrict(data, &scenario); err != nil {
+		return nil, fmt.Errorf("invalid scenario: %w", err)
+	}
+	return &scenario, nil
+}
+
+// LoadScenarioFile reads a scenario file; a scenario without a name is named after the file
+func LoadScenarioFile(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	scenario, err := ParseScenario(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if scenario.Name == "" {
+		scenario.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return scenario, nil
+}
+
+// compileScenario checks a scenario and compiles its matchers
+func compileScenario(scenario Scenario) (*loadedScenario, error) {
+	if scenario.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if len(scenario.Templates) == 0 {
+		return nil, fmt.Errorf("at least one template is required")
+	}
+	loaded := &loadedScenario{name: scenario.Name}
+	for i, template := range scenario.Templates {
+		if len(template.Transitions) == 0 {
+			return nil, fmt.Errorf("templates[%d]: at least one transition is required", i)
+		}
+		if err := validateTransitions(template.Transitions, fmt.Sprintf("templates[%d].transitions", i)); err != nil {
+			return nil, err
+		}
+		compiled := &scenarioTemplate{ScenarioTemplate: template, selector: labels.Everything()}
+		match := template.Match
+		if match.NameRegex != "" {
+			re, err := regexp.Compile(match.NameRegex)
+			if err != nil {
+				return nil, fmt.Errorf("templates[%d].match.nameRegex: %w", i, err)
+			}
+			compiled.nameRegex = re
+		}
+		if match.Selector != nil {
+			selector, err := metav1.LabelSelectorAsSelector(match.Selector)
+			if err != nil {
+				return nil, fmt.Errorf("templates[%d].match.selector: %w", i, err)
+			}
+			compiled.selector = selector
+		}
+		if match.Owner != nil && match.Owner.Kind == "" {
+			return nil, fmt.Errorf("templates[%d].match.owner: kind is required", i)
+		}
+		if match.Nth < 0 {
+			return nil, fmt.Errorf("templates[%d].match.nth: must be positive", i)
+		}
+		loaded.templates = append(loaded.templates, compiled)
+	}
+	return loaded, nil
+}
+
+// LoadScenario adds a scenario to the registry, replacing the one with the same name (and its
+// match counts). It reports whether one was replaced.
+func (tr *TemplateRegistry) LoadScenario(scenario Scenario) (bool, error) {
+	loaded, err := compileScenario(scenario)
+	if err != nil {
+		return false, fmt.Errorf("scenario %q: %w", scenario.Name, err)
+	}
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	for i, existing := range tr.scenarios {
+		if existing.name == loaded.name {
+			tr.scenarios[i] = loaded
+			return true, nil
+		}
+	}
+	tr.scenarios = append(tr.scenarios, loaded)
+	return false, nil
+}
+
+// LoadScenarioFiles loads the scenario files of a comma separated list of paths
+func (tr *TemplateRegistry) LoadScenarioFiles(paths string) error {
+	for _, path := range strings.Split(paths, ",") {
+		if path = strings.TrimSpace(path); path == "" {
+			continue
+		}
+		scenario, err := LoadScenarioFile(path)
+		if err != nil {
+			return err
+		}
+		if _, err := tr.LoadScenario(*scenario); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		fmt.Printf("[Scenarios] Loaded scenario %s with %d templates from %s\n", scenario.Name, len(scenario.Templates), path)
+	}
+	return nil
+}
+
+// RemoveScenario removes a scenario by name
+func (tr *TemplateRegistry) RemoveScenario(name string) bool {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	for i, existing := range tr.scenarios {
+		if existing.name == name {
+			tr.scenarios = append(tr.scenarios[:i], tr.scenarios[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// ListScenarios returns the loaded scenarios in load order
+func (tr *TemplateRegistry) ListScenarios() []ScenarioInfo {
+	tr.mu.RLock()
+	defer tr.mu.RUnlock()
+	infos := make([]ScenarioInfo, 0, len(tr.scenarios))
+	for _, scenario := range tr.scenarios {
+		info := ScenarioInfo{Name: scenario.name, Templates: []ScenarioTemplateInfo{}}
+		for _, template := range scenario.templates {
+			info.Templates = append(info.Templates, ScenarioTemplateInfo{Name: template.Name, Match: template.Match, Matched: template.matched})
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// MatchPod returns the transition template for a created pod: the one registered for its name,
+// else the first scenario template applying to it. Scenario templates count the pod when they
+// match it, so it must be called once per created pod.
+func (tr *TemplateRegistry) MatchPod(store *storage.InMemoryStore, pod map[string]interface{}) (*TransitionTemplate, bool) {
+	name, namespace := objectName(pod), namespaceOf(pod)
+	if template, ok := tr.GetTemplate(namespace, name); ok {
+		return template, true
+	}
+
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	var found *TransitionTemplate
+	for _, scenario := range tr.scenarios {
+		for _, template := range scenario.templates {
+			if !template.matches(store, pod) {
+				continue
+			}
+			template.matched++
+			if found == nil && (template.Match.Nth == 0 || template.Match.Nth == template.matched) {
+				fmt.Printf("[Scenarios] Pod %s matches template %q of scenario %s\n", name, template.Name, scenario.name)
+				found = &TransitionTemplate{PodName: name, Namespace: namespace, Transitions: template.Transitions, Seed: podSeed(template.Seed, namespace, name)}
+			}
+		}
+	}
+	return found, found != nil
+}
+
+// podSeed mixes a pod's name into a template seed, so the pods a template matches make
+// different but reproducible random choices
+func podSeed(seed *int64, namespace, name string) *int64 {
+	if seed == nil {
+		return nil
+	}
+	h := fnv.New64a()
+	h.Write([]byte(namespace + "/" + name))
+	mixed := *seed ^ int64(h.Sum64())
+	return &mixed
+}
+
+// matches reports whether a pod matches the template (nth aside)
+func (t *scenarioTemplate) matches(store *storage.InMemoryStore, pod map[string]interface{}) bool {
+	name := objectName(pod)
+	match := t.Match
+	switch {
+	case match.Namespace != "" && match.Namespace != namespaceOf(pod):
+		return false
+	case match.Name != "" && match.Name != name:
+		return false
+	case !strings.HasPrefix(name, match.NamePrefix):
+		return false
+	case t.nameRegex != nil && !t.nameRegex.MatchString(name):
+		return false
+	case !t.selector.Matches(podLabels(pod)):
+		return false
+	case match.Owner != nil && !ownedThrough(store, pod, match.Owner, maxOwnerDepth):
+		return false
+	}
+	return true
+}
+
+// ownedThrough reports whether obj is owned by the owner, directly or through its owners' owners
+func ownedThrough(store *storage.InMemoryStore, obj map[string]interface{}, owner *OwnerMatch, depth int) bool {
+	if depth <= 0 {
+		return false
+	}
+	for _, ref := range ownerReferences(obj) {
+		kind, _ := ref["kind"].(string)
+		name, _ := ref["name"].(string)
+		if kind == owner.Kind && (owner.Name == "" || owner.Name == name) {
+			return true
+		}
+		parent, err := store.GetObject(kind, name)
+		if err != nil {
+			continue
+		}
+		if uid, _ := ref["uid"].(string); uid != "" && uid != objectUID(parent) {
+			continue
+		}
+		if ownedThrough(store, parent, owner, depth-1) {
+			return true
+		}
+	}
+	return false
+}
+
+// StartPodLifecycle hands a created pod to the simulation: a transition template registered
+// for it, or a scenario template matching it, drives its status; else the pod controller runs it.
+func StartPodLifecycle(store *storage.InMemoryStore, pod resources.Pod) {
+	if DefaultTemplateRegistry != nil && DefaultTransitionManager != nil {
+		if stored, err := store.GetPod(pod.GetName()); err == nil {
+			if template, ok := DefaultTemplateRegistry.MatchPod(store, stored); ok {
+				DefaultTransitionManager.StartTransition(TransitionRequest{
+					PodName:        pod.GetName(),
+					Namespace:      template.Namespace,
+					CancelExisting: true,
+					Transitions:    template.Transitions,
+					Seed:           template.Seed,
+				})
+				return
+			}
+		}
+	}
+	if DefaultPodController != nil {
+		DefaultPodController.OnPodCreated(pod)
+	}
+}
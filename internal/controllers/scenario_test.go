@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+// useTemplateRegistry installs a template registry and transition manager on store
+func useTemplateRegistry(t *testing.T, store *storage.InMemoryStore) *TemplateRegistry {
+	DefaultTemplateRegistry = NewTemplateRegistry()
+	DefaultTransitionManager = NewTransitionManager(store)
+	t.Cleanup(func() {
+		DefaultTemplateRegistry = nil
+		DefaultTransitionManager = nil
+	})
+	return DefaultTemplateRegistry
+}
+
+const testScenario = `
+name: chaos
+templates:
+- name: batch
+  match:
+    namespace: jobs
+    namePrefix: batch-
+    selector: {matchExpressions: [{key: tier, operator: In, values: [worker]}]}
+  transitions: [{phase: Failed, delay: 0s}]
+- name: every-second-cache
+  match: {nameRegex: "^cache-[0-9]+$", nth: 2}
+  transitions: [{phase: Unknown, delay: 0s}]
+`
+
+func TestScenarioMatchesPods(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	registry := NewTemplateRegistry()
+	scenario, err := ParseScenario([]byte(testScenario))
+	if err != nil {
+		t.Fatalf("ParseScenario failed: %v", err)
+	}
+	if _, err := registry.LoadScenario(*scenario); err != nil {
+		t.Fatalf("LoadScenario failed: %v", err)
+	}
+
+	pod := func(name, namespace string, labels map[string]string) map[string]interface{} {
+		p := newTestPod(name, labels, map[string]interface{}{})
+		p.Metadata.Namespace = namespace
+		var obj map[string]interface{}
+		deepCopyJSON(p, &obj)
+		return obj
+	}
+	for _, tc := range []struct {
+		pod   map[string]interface{}
+		phase string
+	}{
+		{pod("batch-1", "jobs", map[string]string{"tier": "worker"}), "Failed"},
+		{pod("batch-2", "default", map[string]string{"tier": "worker"}), ""},
+		{pod("batch-3", "jobs", map[string]string{"tier": "web"}), ""},
+		{pod("cache-1", "default", nil), ""},
+		{pod("cache-x", "default", nil), ""},
+		{pod("cache-2", "default", nil), "Unknown"},
+		{pod("cache-3", "default", nil), ""},
+	} {
+		template, ok := registry.MatchPod(store, tc.pod)
+		phase := ""
+		if ok {
+			phase = template.Transitions[0].Phase
+		}
+		if phase != tc.phase {
+			t.Errorf("pod %s: expected transition to %q, got %q", objectName(tc.pod), tc.phase, phase)
+		}
+	}
+	if matched := registry.ListScenarios()[0].Templates[1].Matched; matched != 3 {
+		t.Errorf("Expected the cache template to have matched 3 pods, got %d", matched)
+	}
+
+	// templates registered for a pod's name come first
+	registry.RegisterTemplate(TransitionTemplate{PodName: "cache-4", Transitions: []TransitionState{{Phase: "Succeeded"}}})
+	if template, _ := registry.MatchPod(store, pod("cache-4", "default", nil)); template.Transitions[0].Phase != "Succeeded" {
+		t.Errorf("Expected the exact-name template, got %v", template)
+	}
+
+	for _, bad := range []string{
+		"templates: [{transitions: [{phase: Failed}]}]",
+		"name: x\ntemplates: [{match: {nameRegex: '('}, transitions: [{phase: Failed}]}]",
+		"name: x\ntemplates: [{match: {owner: {name: web}}, transitions: [{phase: Failed}]}]",
+		"name: x\ntemplates: [{match: {namePrefix: a}}]",
+	} {
+		scenario, err := ParseScenario([]byte(bad))
+		if err == nil {
+			_, err = registry.LoadScenario(*scenario)
+		}
+		if err == nil {
+			t.Errorf("Expected %q to be refused", bad)
+		}
+	}
+	if _, err := ParseScenario([]byte("name: x\ntemplate: []")); err == nil {
+		t.Error("Expected unknown fields to be refused")
+	}
+}
+
+func TestScenarioTargetsDeploymentPods(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	usePodController(t, store)
+	registry := useTemplateRegistry(t, store)
+	startDeploymentControllers(t, store)
+
+	path := filepath.Join(t.TempDir(), "third-web-pod.yaml")
+	os.WriteFile(path, []byte(`
+templates:
+- match:
+    owner: {kind: Deployment, name: web}
+    nth: 3
+  transitions: [{phase: Unknown, delay: 0s}]
+`), 0o644)
+	if err := registry.LoadScenarioFiles(path); err != nil {
+		t.Fatalf("LoadScenarioFiles failed: %v", err)
+	}
+	if scenarios := registry.ListScenarios(); len(scenarios) != 1 || scenarios[0].Name != "third-web-pod" {
+		t.Fatalf("Expected the scenario to be named after its file, got %v", scenarios)
+	}
+
+	if err := store.CreateDeployment(newTestDeployment("web", 4, "nginx:1.25", nil)); err != nil {
+		t.Fatalf("Failed to create deployment: %v", err)
+	}
+	phases := func() map[string]int {
+		counts := map[string]int{}
+		for _, item := range store.ListPods() {
+			phase, _ := resources.NestedString(item.(map[string]interface{}), "status", "phase")
+			counts[phase]++
+		}
+		return counts
+	}
+	waitFor(t, 5*time.Second, "3 running pods and 1 unknown", func() bool {
+		counts := phases()
+		return counts[string(PodRunning)] == 3 && counts[string(PodUnknown)] == 1
+	})
+	if matched := registry.ListScenarios()[0].Templates[0].Matched; matched != 4 {
+		t.Errorf("Expected the template to have matched the 4 pods of the deployment, got %d", matched)
+	}
+}
@@ -0,0 +1,243 @@
+package controllers
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+// Scheduler binds pending pods to Nodes (kube-scheduler upstream). A pod without
+// spec.nodeName is filtered against every node (resources, nodeSelector and node affinity,
+// taints, host ports, topology spread and inter-pod affinity), the nodes it fits on are
+// scored and the pod is bound to the best one, ties going to the first node by name. A pod
+// that fits nowhere stays Pending with PodScheduled=False and a FailedScheduling event, and
+// is tried again when nodes or pods change.
+type Scheduler struct {
+	store *storage.InMemoryStore
+	queue *RateLimitingQueue
+}
+
+// SchedulerName is the component name the scheduler reports events as
+const SchedulerName = resources.DefaultSchedulerName
+
+// PodReasonUnschedulable is the PodScheduled=False reason of pods that fit no node
+const PodReasonUnschedulable = "Unschedulable"
+
+// Reasons of the scheduler's events
+const (
+	EventReasonScheduled        = "Scheduled"
+	EventReasonFailedScheduling = "FailedScheduling"
+)
+
+// NewScheduler creates a new Scheduler fed by the given informers
+func NewScheduler(store *storage.InMemoryStore, informers *SharedInformerFactory) *Scheduler {
+	s := &Scheduler{
+		store: store,
+		queue: NewRateLimitingQueue(),
+	}
+	informers.AddEventHandler("Pod", ResourceEventHandlerFuncs{
+		AddFunc: s.enqueueIfPending,
+		UpdateFunc: func(old, pod map[string]interface{}) {
+			// a status write by the pod controller or the scheduler itself changes nothing
+			if !reflect.DeepEqual(old["spec"], pod["spec"]) || !reflect.DeepEqual(podLabels(old), podLabels(pod)) {
+				s.enqueueIfPending(pod)
+			}
+			if placementChanged(old, pod) {
+				s.enqueueUnscheduled()
+			}
+		},
+		DeleteFunc: func(pod map[string]interface{}) {
+			if nodeName, _ := resources.NestedString(pod, "spec", "nodeName"); nodeName != "" {
+				s.enqueueUnscheduled()
+			}
+		},
+	})
+	// New, relabeled, untainted or resized nodes may fit pods that fit nowhere before
+	informers.AddEventHandler("Node", ResourceEventHandlerFuncs{
+		AddFunc: func(map[string]interface{}) { s.enqueueUnscheduled() },
+		UpdateFunc: func(old, node map[string]interface{}) {
+			oldAllocatable, _ := resources.NestedMap(old, "status", "allocatable")
+			allocatable, _ := resources.NestedMap(node, "status", "allocatable")
+			if !reflect.DeepEqual(old["spec"], node["spec"]) || !reflect.DeepEqual(nodeLabels(old), nodeLabels(node)) ||
+				!reflect.DeepEqual(oldAllocatable, allocatable) {
+				s.enqueueUnscheduled()
+			}
+		},
+		DeleteFunc: func(map[string]interface{}) { s.enqueueUnscheduled() },
+	})
+	return s
+}
+
+// Start starts the scheduler's worker. There is only one: every decision has to see the
+// bindings made before it.
+func (s *Scheduler) Start() {
+	runWorkers(s.queue, 1, "Scheduler", s.syncPod)
+}
+
+// Stop stops the scheduler
+func (s *Scheduler) Stop() {
+	s.queue.ShutDownAndWait()
+}
+
+// needsScheduling reports whether a pod waits for this scheduler: it has no node, isn't
+// being deleted or finished, and names the default scheduler
+func needsScheduling(pod map[string]interface{}) bool {
+	nodeName, _ := resources.NestedString(pod, "spec", "nodeName")
+	schedulerName, _ := resources.NestedString(pod, "spec", "schedulerName")
+	return nodeName == "" && !isBeingDeleted(pod) && !isPodFinished(pod) &&
+		(schedulerName == "" || schedulerName == resources.DefaultSchedulerName)
+}
+
+// placementChanged reports whether a pod update may let waiting pods fit: the pod was bound,
+// stopped holding its node's resources, or changed labels other pods' affinity looks at
+func placementChanged(old, pod map[string]interface{}) bool {
+	oldNode, _ := resources.NestedString(old, "spec", "nodeName")
+	nodeName, _ := resources.NestedString(pod, "spec", "nodeName")
+	if nodeName == "" {
+		return false
+	}
+	return oldNode != nodeName || isPodFinished(pod) != isPodFinished(old) ||
+		isBeingDeleted(pod) != isBeingDeleted(old) || !reflect.DeepEqual(podLabels(old), podLabels(pod))
+}
+
+// enqueueIfPending queues a pod that waits to be scheduled
+func (s *Scheduler) enqueueIfPending(pod map[string]interface{}) {
+	if needsScheduling(pod) {
+		s.queue.Add(objectKey(pod))
+	}
+}
+
+// enqueueUnscheduled queues every pod waiting to be scheduled
+func (s *Scheduler) enqueueUnscheduled() {
+	for _, item := range s.store.ListPods() {
+		if pod, ok := item.(map[string]interface{}); ok {
+			s.enqueueIfPending(pod)
+		}
+	}
+}
+
+// syncPod schedules the pod stored under key (gone or already bound pods need no work)
+func (s *Scheduler) syncPod(key string) error {
+	_, name := splitKey(key)
+	pod, err := s.store.GetPod(name)
+	if err != nil || !needsScheduling(pod) {
+		return nil
+	}
+	return s.schedulePod(pod)
+}
+
+// schedulePod picks a node for the pod and binds it there, or records why it fits nowhere
+func (s *Scheduler) schedulePod(pod map[string]interface{}) error {
+	cycle := newSchedulingCycle(s.store, pod)
+	if len(cycle.nodes) == 0 {
+		return s.recordFailure(pod, "no nodes available to schedule pods")
+	}
+
+	var feasible []map[string]interface{}
+	reasons := map[string]int{}
+	for _, node := range cycle.nodes {
+		failures := cycle.filter(node)
+		if len(failures) == 0 {
+			feasible = append(feasible, node)
+			continue
+		}
+		for _, reason := range failures {
+			reasons[reason]++
+		}
+	}
+	if len(feasible) == 0 {
+		return s.recordFailure(pod, fitError(len(cycle.nodes), reasons))
+	}
+
+	nodeName, _ := resources.NestedString(cycle.selectHost(feasible), "metadata", "name")
+	return s.bind(pod, nodeName)
+}
+
+// fitError returns the message of a pod that fits no node, e.g.
+// "0/3 nodes are available: 1 Insufficient cpu, 2 node(s) had untolerated taint {gpu: true}."
+func fitError(numNodes int, reasons map[string]int) string {
+	histogram := make([]string, 0, len(reasons))
+	for reason, count := range reasons {
+		histogram = append(histogram, fmt.Sprintf("%d %s", count, reason))
+	}
+	sort.Strings(histogram)
+	return fmt.Sprintf("0/%d nodes are available: %s.", numNodes, strings.Join(histogram, ", "))
+}
+
+// bind sets spec.nodeName of the pod and hands it to the pod controller to start
+func (s *Scheduler) bind(pod map[string]interface{}, nodeName string) error {
+	podName, _ := resources.NestedString(pod, "metadata", "name")
+	bound := false
+	stored, err := s.store.MutatePod(podName, func(stored map[string]interface{}) error {
+		if !needsScheduling(stored) {
+			return nil
+		}
+		spec, _ := stored["spec"].(map[string]interface{})
+		if spec == nil {
+			spec = map[string]interface{}{}
+			stored["spec"] = spec
+		}
+		spec["nodeName"] = nodeName
+		status, _ := stored["status"].(map[string]interface{})
+		if status == nil {
+			status = map[string]interface{}{"phase": string(PodPending)}
+			stored["status"] = status
+		}
+		status["conditions"] = replaceCondition(status,
+			setCondition(status, "PodScheduled", "True", "", "", time.Now()))
+		bound = true
+		return nil
+	})
+	if err != nil || !bound {
+		// deleted or bound meanwhile
+		return nil
+	}
+	fmt.Printf("[Scheduler] Bound pod %s/%s to node %s\n", namespaceOf(stored), podName, nodeName)
+	recordEvent(s.store, stored, EventTypeNormal, EventReasonScheduled,
+		fmt.Sprintf("Successfully assigned %s/%s to %s", namespaceOf(stored), podName, nodeName), SchedulerName)
+	if DefaultPodController != nil {
+		return DefaultPodController.OnPodBound(podName)
+	}
+	return nil
+}
+
+// recordFailure leaves the pod Pending with PodScheduled=False and reports why
+func (s *Scheduler) recordFailure(pod map[string]interface{}, message string) error {
+	podName, _ := resources.NestedString(pod, "metadata", "name")
+	stored, err := s.store.MutatePod(podName, func(stored map[string]interface{}) error {
+		status, _ := stored["status"].(map[string]interface{})
+		if status == nil {
+			status = map[string]interface{}{"phase": string(PodPending)}
+			stored["status"] = status
+		}
+		// the message follows the cluster; the transition time stays
+		cond := setCondition(status, "PodScheduled", "False", PodReasonUnschedulable, message, time.Now())
+		cond["message"] = message
+		status["conditions"] = replaceCondition(status, cond)
+		return nil
+	})
+	if err != nil {
+		return nil
+	}
+	fmt.Printf("[Scheduler] Unable to schedule pod %s/%s: %s\n", namespaceOf(stored), podName, message)
+	recordEvent(s.store, stored, EventTypeWarning, EventReasonFailedScheduling, message, SchedulerName)
+	return nil
+}
+
+// DefaultScheduler is the singleton instance
+var DefaultScheduler *Scheduler
+
+// InitScheduler initializes the default scheduler; from then on the pod controller holds
+// pods without a node until they are bound.
+func InitScheduler(store *storage.InMemoryStore) {
+	if DefaultPodController != nil {
+		DefaultPodController.WaitForBinding = true
+	}
+	DefaultScheduler = NewScheduler(store, sharedInformers(store))
+	DefaultScheduler.Start()
+}
@@ -0,0 +1,354 @@
+package controllers
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"mockernetes/internal/resources"
+)
+
+// Placement relative to other pods: inter-pod affinity and anti-affinity (InterPodAffinity
+// upstream) and topology spread constraints (PodTopologySpread). Both group nodes into
+// topology domains by the value of a node label, e.g. topology.kubernetes.io/zone.
+
+// affinityTerm is a pod (anti-)affinity term: pods matching selector in namespaces, counted
+// per domain of topologyKey
+type affinityTerm struct {
+	selector    labels.Selector
+	namespaces  map[string]bool // nil: every namespace
+	topologyKey string
+	weight      int64
+}
+
+// matches reports whether a pod is one the term selects
+func (t affinityTerm) matches(pod map[string]interface{}) bool {
+	if t.namespaces != nil && !t.namespaces[namespaceOf(pod)] {
+		return false
+	}
+	return t.selector.Matches(podLabels(pod))
+}
+
+// podAffinityTerms returns the terms of a pod's spec.affinity.<kind>.<when>, e.g.
+// podAntiAffinity.requiredDuringSchedulingIgnoredDuringExecution. Preferred terms carry
+// their weight.
+func (c *schedulingCycle) podAffinityTerms(pod map[string]interface{}, kind, when string) []affinityTerm {
+	items, _ := resources.NestedSlice(pod, "spec", "affinity", kind, when)
+	terms := make([]affinityTerm, 0, len(items))
+	for _, item := range items {
+		raw, _ := item.(map[string]interface{})
+		weight := int64(1)
+		if term, ok := raw["podAffinityTerm"].(map[string]interface{}); ok {
+			weight, _ = resources.ToInt64(raw["weight"])
+			raw = term
+		}
+		terms = append(terms, c.newAffinityTerm(pod, raw, weight))
+	}
+	return terms
+}
+
+// newAffinityTerm parses a term of pod. Without namespaces and namespaceSelector it selects
+// pods in the pod's own namespace; an empty namespaceSelector selects every namespace.
+func (c *schedulingCycle) newAffinityTerm(pod, raw map[string]interface{}, weight int64) affinityTerm {
+	term := affinityTerm{selector: termSelector(raw["labelSelector"]), weight: weight}
+	term.topologyKey, _ = raw["topologyKey"].(string)
+	namespaces, _ := resources.NestedStringSlice(raw, "namespaces")
+	var nsSelector labels.Selector
+	if raw["namespaceSelector"] != nil {
+		if nsSelector = termSelector(raw["namespaceSelector"]); nsSelector.Empty() {
+			return term
+		}
+	}
+	term.namespaces = map[string]bool{}
+	for _, ns := range namespaces {
+		term.namespaces[ns] = true
+	}
+	if nsSelector == nil && len(namespaces) == 0 {
+		term.namespaces[namespaceOf(pod)] = true
+	}
+	if nsSelector != nil {
+		for ns, set := range c.namespaceLabels {
+			if nsSelector.Matches(set) {
+				term.namespaces[ns] = true
+			}
+		}
+	}
+	return term
+}
+
+// termSelector converts a metav1.LabelSelector map; a missing selector matches nothing and
+// an empty one everything
+func termSelector(raw interface{}) labels.Selector {
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return labels.Nothing()
+	}
+	var selector metav1.LabelSelector
+	if err := deepCopyJSON(m, &selector); err != nil {
+		return labels.Nothing()
+	}
+	s, err := metav1.LabelSelectorAsSelector(&selector)
+	if err != nil {
+		return labels.Nothing()
+	}
+	return s
+}
+
+// sameDomain reports whether two nodes have the same value of topologyKey
+func sameDomain(a, b map[string]interface{}, topologyKey string) bool {
+	va, ok := nodeLabels(a)[topologyKey]
+	if !ok {
+		return false
+	}
+	vb, ok := nodeLabels(b)[topologyKey]
+	return ok && va == vb
+}
+
+// forEachBoundPod calls fn with every pod bound to an existing node, and the node
+func (c *schedulingCycle) forEachBoundPod(fn func(pod, node map[string]interface{})) {
+	for _, node := range c.nodes {
+		for _, pod := range c.podsOnNode[nodeName(node)] {
+			fn(pod, node)
+		}
+	}
+}
+
+// filterPodAffinity checks, like upstream and in its order, the required anti-affinity of the
+// pods already placed, then the pod's own required anti-affinity and affinity
+func (c *schedulingCycle) filterPodAffinity(node map[string]interface{}) []string {
+	const required = "requiredDuringSchedulingIgnoredDuringExecution"
+	var existingConflict, antiConflict bool
+	c.forEachBoundPod(func(pod, other map[string]interface{}) {
+		for _, term := range c.podAffinityTerms(pod, "podAntiAffinity", required) {
+			if term.matches(c.pod) && sameDomain(node, other, term.topologyKey) {
+				existingConflict = true
+			}
+		}
+	})
+	if existingConflict {
+		return []string{"node(s) didn't satisfy existing pods anti-affinity rules"}
+	}
+
+	antiAffinity := c.podAffinityTerms(c.pod, "podAntiAffinity", required)
+	c.forEachBoundPod(func(pod, other map[string]interface{}) {
+		for _, term := range antiAffinity {
+			if term.matches(pod) && sameDomain(node, other, term.topologyKey) {
+				antiConflict = true
+			}
+		}
+	})
+	if antiConflict {
+		return []string{"node(s) didn't match pod anti-affinity rules"}
+	}
+
+	affinity := c.podAffinityTerms(c.pod, "podAffinity", required)
+	if len(affinity) == 0 {
+		return nil
+	}
+	satisfied := make([]bool, len(affinity))
+	anyMatch := false
+	c.forEachBoundPod(func(pod, other map[string]interface{}) {
+		for i, term := range affinity {
+			if term.matches(pod) {
+				anyMatch = true
+				satisfied[i] = satisfied[i] || sameDomain(node, other, term.topologyKey)
+			}
+		}
+	})
+	allSatisfied, matchesSelf := true, true
+	for i, term := range affinity {
+		allSatisfied = allSatisfied && satisfied[i]
+		matchesSelf = matchesSelf && term.matches(c.pod)
+	}
+	// The first pod of a group that selects itself may go anywhere
+	if allSatisfied || (!anyMatch && matchesSelf) {
+		return nil
+	}
+	return []string{"node(s) didn't match pod affinity rules"}
+}
+
+// scorePodAffinity prefers nodes whose domains hold the pods the pod's preferred affinity
+// terms select (and avoids those of its preferred anti-affinity terms), and symmetrically
+// the domains of pods whose preferred terms select this pod
+func (c *schedulingCycle) scorePodAffinity(nodes []map[string]interface{}) []int64 {
+	const preferred = "preferredDuringSchedulingIgnoredDuringExecution"
+	affinity := c.podAffinityTerms(c.pod, "podAffinity", preferred)
+	antiAffinity := c.podAffinityTerms(c.pod, "podAntiAffinity", preferred)
+
+	scores := make([]int64, len(nodes))
+	c.forEachBoundPod(func(pod, other map[string]interface{}) {
+		existingAffinity := c.podAffinityTerms(pod, "podAffinity", preferred)
+		existingAntiAffinity := c.podAffinityTerms(pod, "podAntiAffinity", preferred)
+		for i, node := range nodes {
+			for _, term := range affinity {
+				if term.matches(pod) && sameDomain(node, other, term.topologyKey) {
+					scores[i] += term.weight
+				}
+			}
+			for _, term := range antiAffinity {
+				if term.matches(pod) && sameDomain(node, other, term.topologyKey) {
+					scores[i] -= term.weight
+				}
+			}
+			for _, term := range existingAffinity {
+				if term.matches(c.pod) && sameDomain(node, other, term.topologyKey) {
+					scores[i] += term.weight
+				}
+			}
+			for _, term := range existingAntiAffinity {
+				if term.matches(c.pod) && sameDomain(node, other, term.topologyKey) {
+					scores[i] -= term.weight
+				}
+			}
+		}
+	})
+	return normalizeRange(scores)
+}
+
+// normalizeRange scales scores to 0-100 between the lowest and the highest one (all 0 when
+// they are equal)
+func normalizeRange(scores []int64) []int64 {
+	if len(scores) == 0 {
+		return scores
+	}
+	lowest, highest := scores[0], scores[0]
+	for _, s := range scores {
+		lowest, highest = min(lowest, s), max(highest, s)
+	}
+	for i, s := range scores {
+		if highest > lowest {
+			scores[i] = (s - lowest) * 100 / (highest - lowest)
+		} else {
+			scores[i] = 0
+		}
+	}
+	return scores
+}
+
+// spreadConstraint is a topology spread constraint with the matching pods counted per domain
+type spreadConstraint struct {
+	maxSkew     int64
+	minDomains  int64
+	topologyKey string
+	selector    labels.Selector
+	counts      map[string]int64 // matching pods per domain of the eligible nodes
+}
+
+// spreadConstraints returns the pod's topologySpreadConstraints with the given
+// whenUnsatisfiable (DoNotSchedule or ScheduleAnyway)
+func (c *schedulingCycle) spreadConstraints(whenUnsatisfiable string) []*spreadConstraint {
+	if constraints, ok := c.spread[whenUnsatisfiable]; ok {
+		return constraints
+	}
+	var constraints []*spreadConstraint
+	items, _ := resources.NestedSlice(c.spec, "topologySpreadConstraints")
+	for _, item := range items {
+		raw, _ := item.(map[string]interface{})
+		if when, _ := raw["whenUnsatisfiable"].(string); when != whenUnsatisfiable {
+			continue
+		}
+		sc := &spreadConstraint{maxSkew: 1, minDomains: 1, selector: termSelector(raw["labelSelector"]), counts: map[string]int64{}}
+		sc.topologyKey, _ = raw["topologyKey"].(string)
+		if v, ok := resources.ToInt64(raw["maxSkew"]); ok {
+			sc.maxSkew = v
+		}
+		if v, ok := resources.ToInt64(raw["minDomains"]); ok {
+			sc.minDomains = v
+		}
+		// matchLabelKeys narrows the selector to pods with the pod's values of those labels
+		keys, _ := resources.NestedStringSlice(raw, "matchLabelKeys")
+		for _, key := range keys {
+			if value, ok := podLabels(c.pod)[key]; ok {
+				if req, err := labels.NewRequirement(key, selection.Equals, []string{value}); err == nil {
+					sc.selector = sc.selector.Add(*req)
+				}
+			}
+		}
+		honorAffinity := raw["nodeAffinityPolicy"] != "Ignore"
+		honorTaints := raw["nodeTaintsPolicy"] == "Honor"
+		for _, node := range c.nodes {
+			domain, ok := nodeLabels(node)[sc.topologyKey]
+			if !ok || (honorAffinity && !nodeMatchesPod(c.spec, node)) ||
+				(honorTaints && untoleratedTaint(c.spec, node, "NoSchedule", "NoExecute") != nil) {
+				continue
+			}
+			sc.counts[domain] += 0
+			for _, pod := range c.podsOnNode[nodeName(node)] {
+				if namespaceOf(pod) == c.namespace && !isBeingDeleted(pod) && sc.selector.Matches(podLabels(pod)) {
+					sc.counts[domain]++
+				}
+			}
+		}
+		constraints = append(constraints, sc)
+	}
+	c.spread[whenUnsatisfiable] = constraints
+	return constraints
+}
+
+// filterTopologySpread keeps a pod off nodes where it would make a DoNotSchedule constraint's
+// skew (the pods in the node's domain, itself included, minus the fewest in any domain)
+// exceed maxSkew. With fewer domains than minDomains the fewest count as 0.
+func (c *schedulingCycle) filterTopologySpread(node map[string]interface{}) []string {
+	for _, sc := range c.spreadConstraints("DoNotSchedule") {
+		domain, ok := nodeLabels(node)[sc.topologyKey]
+		if !ok {
+			return []string{"node(s) didn't match pod topology spread constraints (missing required label)"}
+		}
+		var fewest int64 = -1
+		for _, count := range sc.counts {
+			if fewest < 0 || count < fewest {
+				fewest = count
+			}
+		}
+		if fewest < 0 || int64(len(sc.counts)) < sc.minDomains {
+			fewest = 0
+		}
+		self := int64(0)
+		if sc.selector.Matches(podLabels(c.pod)) {
+			self = 1
+		}
+		if sc.counts[domain]+self-fewest > sc.maxSkew {
+			return []string{"node(s) didn't match pod topology spread constraints"}
+		}
+	}
+	return nil
+}
+
+// scoreTopologySpread prefers nodes whose domains hold the fewest pods matching the
+// ScheduleAnyway constraints; nodes missing a constraint's label score lowest
+func (c *schedulingCycle) scoreTopologySpread(nodes []map[string]interface{}) []int64 {
+	scores := make([]int64, len(nodes))
+	constraints := c.spreadConstraints("ScheduleAnyway")
+	if len(constraints) == 0 {
+		return scores
+	}
+	missing := make([]bool, len(nodes))
+	var lowest, highest int64 = -1, 0
+	for i, node := range nodes {
+		for _, sc := range constraints {
+			domain, ok := nodeLabels(node)[sc.topologyKey]
+			if !ok {
+				missing[i] = true
+				break
+			}
+			scores[i] += sc.counts[domain]
+		}
+		if missing[i] {
+			continue
+		}
+		if lowest < 0 || scores[i] < lowest {
+			lowest = scores[i]
+		}
+		highest = max(highest, scores[i])
+	}
+	for i := range scores {
+		switch {
+		case missing[i]:
+			scores[i] = 0
+		case highest > lowest:
+			scores[i] = (highest - scores[i]) * 100 / (highest - lowest)
+		default:
+			scores[i] = 100
+		}
+	}
+	return scores
+}
@@ -0,0 +1,399 @@
+package controllers
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+// Filter and score plugins of the scheduler (the default plugins upstream, without volumes,
+// images and preemption). Filters run in upstream order and the first one a node fails is
+// what the FailedScheduling message counts for it. Scores are normalized to 0-100 and
+// weighted like upstream: NodeResourcesFit 1, NodeAffinity 2, TaintToleration 3,
+// InterPodAffinity 2 and PodTopologySpread 2.
+
+// Requests counted for containers that request no cpu or memory when spreading load
+// (upstream DefaultMilliCPURequest and DefaultMemoryRequest)
+const (
+	defaultMilliCPURequest = 100               // 0.1 core
+	defaultMemoryRequest   = 200 * 1024 * 1024 // 200Mi
+)
+
+// schedulingCycle is one attempt to place a pod, on the cluster as it was at its start
+type schedulingCycle struct {
+	pod         map[string]interface{}
+	spec        map[string]interface{}
+	namespace   string
+	nodes       []map[string]interface{} // sorted by name
+	nodesByName map[string]map[string]interface{}
+	// podsOnNode holds the pods bound to each node that haven't finished
+	podsOnNode map[string][]map[string]interface{}
+	// namespaceLabels holds the labels of every namespace (for namespaceSelectors)
+	namespaceLabels map[string]labels.Set
+	// spread caches the topology spread constraints by whenUnsatisfiable
+	spread map[string][]*spreadConstraint
+}
+
+// newSchedulingCycle snapshots the nodes and pods of store to place pod
+func newSchedulingCycle(store *storage.InMemoryStore, pod map[string]interface{}) *schedulingCycle {
+	c := &schedulingCycle{
+		pod:             pod,
+		namespace:       namespaceOf(pod),
+		nodesByName:     map[string]map[string]interface{}{},
+		podsOnNode:      map[string][]map[string]interface{}{},
+		namespaceLabels: map[string]labels.Set{},
+		spread:          map[string][]*spreadConstraint{},
+	}
+	c.spec, _ = pod["spec"].(map[string]interface{})
+	for _, item := range store.ListNodes() {
+		if node, ok := item.(map[string]interface{}); ok && !isBeingDeleted(node) {
+			c.nodes = append(c.nodes, node)
+			c.nodesByName[nodeName(node)] = node
+		}
+	}
+	sort.Slice(c.nodes, func(i, j int) bool { return nodeName(c.nodes[i]) < nodeName(c.nodes[j]) })
+	for _, item := range store.ListPods() {
+		other, ok := item.(map[string]interface{})
+		if !ok || isPodFinished(other) {
+			continue
+		}
+		if name, _ := resources.NestedString(other, "spec", "nodeName"); name != "" {
+			c.podsOnNode[name] = append(c.podsOnNode[name], other)
+		}
+	}
+	for _, item := range store.ListNamespaces() {
+		if ns, ok := item.(map[string]interface{}); ok {
+			name, _ := resources.NestedString(ns, "metadata", "name")
+			c.namespaceLabels[name] = podLabels(ns)
+		}
+	}
+	return c
+}
+
+// nodeName returns metadata.name of a node
+func nodeName(node map[string]interface{}) string {
+	name, _ := resources.NestedString(node, "metadata", "name")
+	return name
+}
+
+// filter returns why the pod can't run on node (nothing if it can)
+func (c *schedulingCycle) filter(node map[string]interface{}) []string {
+	for _, f := range []func(map[string]interface{}) []string{
+		c.filterUnschedulable,
+		c.filterTaints,
+		c.filterNodeAffinity,
+		c.filterHostPorts,
+		c.filterResources,
+		c.filterTopologySpread,
+		c.filterPodAffinity,
+	} {
+		if reasons := f(node); len(reasons) > 0 {
+			return reasons
+		}
+	}
+	return nil
+}
+
+// selectHost returns the feasible node with the highest weighted score (the first by name
+// on a tie, where upstream picks one at random)
+func (c *schedulingCycle) selectHost(nodes []map[string]interface{}) map[string]interface{} {
+	totals := make([]int64, len(nodes))
+	for _, plugin := range []struct {
+		weight int64
+		score  func([]map[string]interface{}) []int64
+	}{
+		{1, c.scoreLeastAllocated},
+		{2, c.scoreNodeAffinity},
+		{3, c.scoreTaintToleration},
+		{2, c.scorePodAffinity},
+		{2, c.scoreTopologySpread},
+	} {
+		for i, score := range plugin.score(nodes) {
+			totals[i] += plugin.weight * score
+		}
+	}
+	best := 0
+	for i := range totals {
+		if totals[i] > totals[best] {
+			best = i
+		}
+	}
+	return nodes[best]
+}
+
+// filterUnschedulable keeps pods off cordoned nodes unless they tolerate the unschedulable taint
+func (c *schedulingCycle) filterUnschedulable(node map[string]interface{}) []string {
+	spec, _ := node["spec"].(map[string]interface{})
+	if unschedulable, _ := spec["unschedulable"].(bool); !unschedulable {
+		return nil
+	}
+	taint := map[string]interface{}{"key": TaintNodeUnschedulable, "effect": "NoSchedule"}
+	tolerations, _ := resources.NestedSlice(c.spec, "tolerations")
+	for _, t := range tolerations {
+		if toleration, ok := t.(map[string]interface{}); ok && toleratesTaint(toleration, taint) {
+			return nil
+		}
+	}
+	return []string{"node(s) were unschedulable"}
+}
+
+// filterTaints keeps pods off nodes with NoSchedule or NoExecute taints they don't tolerate
+func (c *schedulingCycle) filterTaints(node map[string]interface{}) []string {
+	taint := untoleratedTaint(c.spec, node, "NoSchedule", "NoExecute")
+	if taint == nil {
+		return nil
+	}
+	key, _ := taint["key"].(string)
+	value, _ := taint["value"].(string)
+	return []string{fmt.Sprintf("node(s) had untolerated taint {%s: %s}", key, value)}
+}
+
+// filterNodeAffinity keeps pods on nodes matching their nodeSelector and required node affinity
+func (c *schedulingCycle) filterNodeAffinity(node map[string]interface{}) []string {
+	if nodeMatchesPod(c.spec, node) {
+		return nil
+	}
+	return []string{"node(s) didn't match Pod's node affinity/selector"}
+}
+
+// filterHostPorts keeps pods off nodes where a pod already uses one of their host ports
+func (c *schedulingCycle) filterHostPorts(node map[string]interface{}) []string {
+	wanted := hostPorts(c.spec)
+	if len(wanted) == 0 {
+		return nil
+	}
+	for _, other := range c.podsOnNode[nodeName(node)] {
+		spec, _ := other["spec"].(map[string]interface{})
+		for port := range hostPorts(spec) {
+			if wanted[port] {
+				return []string{"node(s) didn't have free ports for the requested pod ports"}
+			}
+		}
+	}
+	return nil
+}
+
+// hostPorts returns the host ports of a pod spec's containers as "protocol/port"
+func hostPorts(spec map[string]interface{}) map[string]bool {
+	ports := map[string]bool{}
+	containers, _ := resources.NestedSlice(spec, "containers")
+	for _, c := range containers {
+		container, _ := c.(map[string]interface{})
+		items, _ := resources.NestedSlice(container, "ports")
+		for _, p := range items {
+			port, _ := p.(map[string]interface{})
+			hostPort, _ := resources.ToInt64(port["hostPort"])
+			if hostPort <= 0 {
+				continue
+			}
+			protocol, _ := port["protocol"].(string)
+			if protocol == "" {
+				protocol = "TCP"
+			}
+			ports[fmt.Sprintf("%s/%d", protocol, hostPort)] = true
+		}
+	}
+	return ports
+}
+
+// filterResources keeps pods on nodes with room for their requests and one more pod
+func (c *schedulingCycle) filterResources(node map[string]interface{}) []string {
+	name := nodeName(node)
+	allocatable := nodeAllocatable(node)
+	var reasons []string
+	if pods, ok := allocatable["pods"]; ok && int64(len(c.podsOnNode[name])+1)*1000 > pods {
+		reasons = append(reasons, "Too many pods")
+	}
+	requests := podRequests(c.spec, false)
+	requested := c.requestedOn(name, false)
+	for _, r := range resourceNames(requests) {
+		if r != "pods" && requests[r] > 0 && requested[r]+requests[r] > allocatable[r] {
+			reasons = append(reasons, "Insufficient "+r)
+		}
+	}
+	return reasons
+}
+
+// scoreLeastAllocated prefers nodes with the largest share of cpu and memory left
+func (c *schedulingCycle) scoreLeastAllocated(nodes []map[string]interface{}) []int64 {
+	scores := make([]int64, len(nodes))
+	requests := podRequests(c.spec, true)
+	for i, node := range nodes {
+		allocatable := nodeAllocatable(node)
+		requested := c.requestedOn(nodeName(node), true)
+		for _, r := range []string{"cpu", "memory"} {
+			used := requested[r] + requests[r]
+			if allocatable[r] > 0 && used <= allocatable[r] {
+				scores[i] += (allocatable[r] - used) * 100 / allocatable[r] / 2
+			}
+		}
+	}
+	return scores
+}
+
+// scoreNodeAffinity prefers nodes matching the heaviest preferred node affinity terms
+func (c *schedulingCycle) scoreNodeAffinity(nodes []map[string]interface{}) []int64 {
+	scores := make([]int64, len(nodes))
+	terms, _ := resources.NestedSlice(c.spec, "affinity", "nodeAffinity", "preferredDuringSchedulingIgnoredDuringExecution")
+	for i, node := range nodes {
+		for _, t := range terms {
+			term, _ := t.(map[string]interface{})
+			preference, _ := term["preference"].(map[string]interface{})
+			if weight, _ := resources.ToInt64(term["weight"]); nodeMatchesTerm(preference, node) {
+				scores[i] += weight
+			}
+		}
+	}
+	return normalizeScores(scores, false)
+}
+
+// scoreTaintToleration prefers nodes with fewer PreferNoSchedule taints the pod doesn't tolerate
+func (c *schedulingCycle) scoreTaintToleration(nodes []map[string]interface{}) []int64 {
+	scores := make([]int64, len(nodes))
+	tolerations, _ := resources.NestedSlice(c.spec, "tolerations")
+	for i, node := range nodes {
+		for _, taint := range nodeTaints(node) {
+			if taint["effect"] != "PreferNoSchedule" {
+				continue
+			}
+			tolerated := false
+			for _, t := range tolerations {
+				if toleration, ok := t.(map[string]interface{}); ok && toleratesTaint(toleration, taint) {
+					tolerated = true
+					break
+				}
+			}
+			if !tolerated {
+				scores[i]++
+			}
+		}
+	}
+	return normalizeScores(scores, true)
+}
+
+// normalizeScores scales scores to 0-100 of the highest one; with reverse, lower is better
+func normalizeScores(scores []int64, reverse bool) []int64 {
+	var highest int64
+	for _, s := range scores {
+		highest = max(highest, s)
+	}
+	for i, s := range scores {
+		if highest > 0 {
+			s = s * 100 / highest
+		}
+		if reverse {
+			s = 100 - s
+		}
+		scores[i] = s
+	}
+	return scores
+}
+
+// nodeAllocatable returns status.allocatable of a node in milli-units
+func nodeAllocatable(node map[string]interface{}) map[string]int64 {
+	allocatable, _ := resources.NestedMap(node, "status", "allocatable")
+	return resourceList(allocatable)
+}
+
+// requestedOn returns the summed requests of the pods on a node, in milli-units
+func (c *schedulingCycle) requestedOn(node string, nonZero bool) map[string]int64 {
+	total := map[string]int64{}
+	for _, pod := range c.podsOnNode[node] {
+		spec, _ := pod["spec"].(map[string]interface{})
+		for r, v := range podRequests(spec, nonZero) {
+			total[r] += v
+		}
+	}
+	return total
+}
+
+// podRequests returns what a pod spec requests, in milli-units: the sum over its containers
+// (limits stand in for missing requests), but at least the largest init container's request,
+// plus the pod overhead. With nonZero, containers without a cpu or memory request count the
+// default requests.
+func podRequests(spec map[string]interface{}, nonZero bool) map[string]int64 {
+	total := map[string]int64{}
+	containers, _ := resources.NestedSlice(spec, "containers")
+	for _, c := range containers {
+		for r, v := range containerRequests(c, nonZero) {
+			total[r] += v
+		}
+	}
+	initContainers, _ := resources.NestedSlice(spec, "initContainers")
+	for _, c := range initContainers {
+		for r, v := range containerRequests(c, nonZero) {
+			total[r] = max(total[r], v)
+		}
+	}
+	overhead, _ := resources.NestedMap(spec, "overhead")
+	for r, v := range resourceList(overhead) {
+		total[r] += v
+	}
+	return total
+}
+
+// containerRequests returns the requests of a container, taking limits for missing ones
+func containerRequests(c interface{}, nonZero bool) map[string]int64 {
+	container, _ := c.(map[string]interface{})
+	limits, _ := resources.NestedMap(container, "resources", "limits")
+	requests := resourceList(limits)
+	explicit, _ := resources.NestedMap(container, "resources", "requests")
+	for r, v := range resourceList(explicit) {
+		requests[r] = v
+	}
+	if nonZero {
+		if _, ok := requests["cpu"]; !ok {
+			requests["cpu"] = defaultMilliCPURequest
+		}
+		if _, ok := requests["memory"]; !ok {
+			requests["memory"] = defaultMemoryRequest * 1000
+		}
+	}
+	return requests
+}
+
+// resourceList parses a resource list ({"cpu": "500m", "memory": "1Gi"}) into milli-units;
+// unparsable quantities are left out
+func resourceList(list map[string]interface{}) map[string]int64 {
+	parsed := map[string]int64{}
+	for r, value := range list {
+		switch v := value.(type) {
+		case string:
+			if q, err := resource.ParseQuantity(v); err == nil {
+				parsed[r] = q.MilliValue()
+			}
+		case float64:
+			parsed[r] = int64(v * 1000)
+		}
+	}
+	return parsed
+}
+
+// resourceNames returns the resources of a list in the order upstream reports them: cpu,
+// memory, ephemeral-storage, then the others by name
+func resourceNames(list map[string]int64) []string {
+	rank := map[string]int{"cpu": 0, "memory": 1, "ephemeral-storage": 2}
+	names := make([]string, 0, len(list))
+	for r := range list {
+		names = append(names, r)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		ri, ok := rank[names[i]]
+		if !ok {
+			ri = len(rank)
+		}
+		rj, ok := rank[names[j]]
+		if !ok {
+			rj = len(rank)
+		}
+		if ri != rj {
+			return ri < rj
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
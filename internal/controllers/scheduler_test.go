@@ -0,0 +1,289 @@
+package controllers
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+// startScheduler runs the scheduler on store with a pod controller that waits for bindings.
+func startScheduler(t *testing.T, store *storage.InMemoryStore) {
+	pc := usePodController(t, store)
+	pc.WaitForBinding = true
+	scheduler := NewScheduler(store, startInformers(t, store))
+	scheduler.Start()
+	t.Cleanup(scheduler.Stop)
+}
+
+func newTestPod(name string, labels map[string]string, spec map[string]interface{}) *resources.Pod {
+	if spec["containers"] == nil {
+		spec["containers"] = []interface{}{map[string]interface{}{"name": "app", "image": "app:v1"}}
+	}
+	return &resources.Pod{
+		Kind:       "Pod",
+		APIVersion: "v1",
+		Metadata:   resources.ObjectMeta{Name: name, Namespace: "default", Labels: labels},
+		Spec:       spec,
+	}
+}
+
+// boundNode returns the node a pod is bound to, "" if none
+func boundNode(store *storage.InMemoryStore, name string) string {
+	pod, err := store.GetPod(name)
+	if err != nil {
+		return ""
+	}
+	node, _ := resources.NestedString(pod, "spec", "nodeName")
+	return node
+}
+
+func TestSchedulerBindsPodToNodeWithRoom(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	startScheduler(t, store)
+
+	for _, name := range []string{"small", "large"} {
+		node := newTestNode(name, nil)
+		if name == "small" {
+			status := node.Status.(map[string]interface{})
+			status["allocatable"] = map[string]interface{}{"cpu": "1", "memory": "2Gi", "pods": "110"}
+		}
+		AssignNodeAddress(store, node)
+		store.CreateNode(node)
+	}
+
+	pod := newTestPod("web", nil, map[string]interface{}{
+		"containers": []interface{}{map[string]interface{}{
+			"name": "app", "image": "app:v1",
+			"resources": map[string]interface{}{"requests": map[string]interface{}{"cpu": "2"}},
+		}},
+	})
+	if err := createPod(store, pod); err != nil {
+		t.Fatalf("Failed to create pod: %v", err)
+	}
+
+	waitFor(t, 5*time.Second, "web running", func() bool {
+		stored, _ := store.GetPod("web")
+		phase, _ := resources.NestedString(stored, "status", "phase")
+		return phase == string(PodRunning)
+	})
+	if node := boundNode(store, "web"); node != "large" {
+		t.Fatalf("Expected web on large, got %q", node)
+	}
+	stored, _ := store.GetPod("web")
+	large, _ := store.GetNode("large")
+	if hostIP, _ := resources.NestedString(stored, "status", "hostIP"); hostIP != nodeAddress(large, NodeInternalIP) {
+		t.Errorf("Expected hostIP %s, got %s", nodeAddress(large, NodeInternalIP), hostIP)
+	}
+	status, _ := stored["status"].(map[string]interface{})
+	if cond := podCondition(stored, "PodScheduled"); cond["status"] != "True" {
+		t.Errorf("Expected PodScheduled=True, got %v (%v)", cond, status["conditions"])
+	}
+
+	scheduled := false
+	for _, item := range store.ListEvents() {
+		event := item.(map[string]interface{})
+		if event["reason"] == EventReasonScheduled && event["message"] == "Successfully assigned default/web to large" {
+			scheduled = true
+		}
+	}
+	if !scheduled {
+		t.Errorf("Expected a Scheduled event, got %v", store.ListEvents())
+	}
+}
+
+func TestSchedulerReportsUnschedulablePodsUntilANodeFits(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	startScheduler(t, store)
+
+	store.CreateNode(newTestNode("node-a", nil))
+	store.CreateNode(newTestNode("node-b", nil))
+	store.CreateNode(newTestNode("node-gpu", nil, map[string]interface{}{"key": "dedicated", "value": "gpu", "effect": "NoSchedule"}))
+
+	// one pod per hostname
+	antiAffinity := func() map[string]interface{} {
+		return map[string]interface{}{"affinity": map[string]interface{}{
+			"podAntiAffinity": map[string]interface{}{
+				"requiredDuringSchedulingIgnoredDuringExecution": []interface{}{map[string]interface{}{
+					"labelSelector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "db"}},
+					"topologyKey":   resources.LabelHostname,
+				}},
+			},
+		}}
+	}
+	for i := 1; i <= 3; i++ {
+		if err := createPod(store, newTestPod(fmt.Sprintf("db-%d", i), map[string]string{"app": "db"}, antiAffinity())); err != nil {
+			t.Fatalf("Failed to create pod: %v", err)
+		}
+	}
+
+	want := "0/3 nodes are available: 1 node(s) had untolerated taint {dedicated: gpu}, " +
+		"2 node(s) didn't satisfy existing pods anti-affinity rules."
+	var pending string
+	waitFor(t, 5*time.Second, "two pods bound and one unschedulable", func() bool {
+		bound := map[string]bool{}
+		pending = ""
+		for i := 1; i <= 3; i++ {
+			name := fmt.Sprintf("db-%d", i)
+			if node := boundNode(store, name); node != "" {
+				bound[node] = true
+			} else {
+				stored, _ := store.GetPod(name)
+				if cond := podCondition(stored, "PodScheduled"); cond["reason"] == PodReasonUnschedulable && cond["message"] == want {
+					pending = name
+				}
+			}
+		}
+		return len(bound) == 2 && !bound["node-gpu"] && pending != ""
+	})
+
+	stored, _ := store.GetPod(pending)
+	if phase, _ := resources.NestedString(stored, "status", "phase"); phase != string(PodPending) {
+		t.Errorf("Expected unschedulable pod to stay Pending, got %s", phase)
+	}
+	failed := false
+	for _, item := range store.ListEvents() {
+		event := item.(map[string]interface{})
+		name, _ := resources.NestedString(event, "involvedObject", "name")
+		if name == pending && event["reason"] == EventReasonFailedScheduling && event["type"] == EventTypeWarning {
+			failed = true
+		}
+	}
+	if !failed {
+		t.Errorf("Expected a FailedScheduling event for %s", pending)
+	}
+
+	// a new node makes room
+	store.CreateNode(newTestNode("node-c", nil))
+	waitFor(t, 5*time.Second, pending+" bound to node-c", func() bool {
+		return boundNode(store, pending) == "node-c"
+	})
+}
+
+func TestSchedulerSpreadsPodsAcrossZones(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	startScheduler(t, store)
+
+	zones := map[string]string{"node-1": "zone-a", "node-2": "zone-a", "node-3": "zone-b", "node-4": "zone-c"}
+	for name, zone := range zones {
+		store.CreateNode(newTestNode(name, map[string]string{"topology.kubernetes.io/zone": zone}))
+	}
+
+	for i := 1; i <= 6; i++ {
+		spec := map[string]interface{}{"topologySpreadConstraints": []interface{}{map[string]interface{}{
+			"maxSkew":           1,
+			"topologyKey":       "topology.kubernetes.io/zone",
+			"whenUnsatisfiable": "DoNotSchedule",
+			"labelSelector":     map[string]interface{}{"matchLabels": map[string]interface{}{"app": "web"}},
+		}}}
+		if err := createPod(store, newTestPod(fmt.Sprintf("web-%d", i), map[string]string{"app": "web"}, spec)); err != nil {
+			t.Fatalf("Failed to create pod: %v", err)
+		}
+	}
+
+	perZone := map[string]int{}
+	waitFor(t, 5*time.Second, "six pods bound", func() bool {
+		perZone = map[string]int{}
+		for i := 1; i <= 6; i++ {
+			node := boundNode(store, fmt.Sprintf("web-%d", i))
+			if node == "" {
+				return false
+			}
+			perZone[zones[node]]++
+		}
+		return true
+	})
+	for _, zone := range []string{"zone-a", "zone-b", "zone-c"} {
+		if perZone[zone] != 2 {
+			t.Errorf("Expected 2 pods in each zone, got %v", perZone)
+			break
+		}
+	}
+}
+
+func TestLoadNodeConfig(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := dir + "/" + name
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	path := write("nodes.yaml", `nodes:
+- name: control-plane
+  taints: [{key: node-role.kubernetes.io/control-plane, effect: NoSchedule}]
+- namePrefix: worker-
+  count: 2
+  capacity: {cpu: "8"}
+  labels: {topology.kubernetes.io/zone: zone-a}
+`)
+	store := storage.NewInMemoryStore()
+	if err := InitNodes(store, path); err != nil {
+		t.Fatalf("InitNodes failed: %v", err)
+	}
+	addresses := map[string]string{}
+	for _, name := range []string{"control-plane", "worker-1", "worker-2"} {
+		node, err := store.GetNode(name)
+		if err != nil {
+			t.Fatalf("Expected node %s: %v", name, err)
+		}
+		addresses[name] = nodeAddress(node, NodeInternalIP)
+	}
+	if addresses["control-plane"] != "172.18.0.2" || addresses["worker-2"] != "172.18.0.4" {
+		t.Errorf("Unexpected node addresses %v", addresses)
+	}
+	worker, _ := store.GetNode("worker-1")
+	if cpu, _ := resources.NestedString(worker, "status", "allocatable", "cpu"); cpu != "8" {
+		t.Errorf("Expected 8 allocatable cpu, got %q", cpu)
+	}
+	if zone := nodeLabels(worker)["topology.kubernetes.io/zone"]; zone != "zone-a" {
+		t.Errorf("Expected zone-a, got %q", zone)
+	}
+
+	for content, want := range map[string]string{
+		"nodes: [{name: a, namePrefix: b}]":                     "exactly one of name and namePrefix",
+		"nodes: [{name: a, capacity: {cpu: lots}}]":             "capacity.cpu",
+		"nodes: [{name: a, taints: [{key: k, effect: Evict}]}]": "unsupported effect",
+		"nodes: [{name: a, zone: b}]":                           "unknown field",
+	} {
+		if _, err := LoadNodeConfig(write("bad.yaml", content)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error containing %q for %q, got %v", want, content, err)
+		}
+	}
+}
+
+func TestSchedulerKeepsTemplateStatus(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	registry := useTemplateRegistry(t, store)
+	startScheduler(t, store)
+	node := newTestNode("worker", nil)
+	AssignNodeAddress(store, node)
+	store.CreateNode(node)
+
+	registry.RegisterTemplate(TransitionTemplate{PodName: "scripted", Transitions: []TransitionState{{
+		Phase:      "Running",
+		PodIP:      "1.2.3.4",
+		Conditions: []PodCondition{{Type: "Ready", Status: "False"}},
+	}}})
+	if err := createPod(store, newTestPod("scripted", nil, map[string]interface{}{})); err != nil {
+		t.Fatalf("Failed to create pod: %v", err)
+	}
+	waitFor(t, 5*time.Second, "scripted bound", func() bool {
+		return boundNode(store, "scripted") == "worker"
+	})
+	// well past the pod controller's startup delay
+	time.Sleep(100 * time.Millisecond)
+	stored, _ := store.GetPod("scripted")
+	if podIP, _ := resources.NestedString(stored, "status", "podIP"); podIP != "1.2.3.4" {
+		t.Errorf("Expected the template's podIP to stay, got %s", podIP)
+	}
+	if isPodReady(stored) {
+		t.Error("Expected the pod to stay unready as the template set it")
+	}
+}
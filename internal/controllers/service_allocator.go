@@ -0,0 +1,332 @@
+package controllers
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+// Cluster IPs and node ports of Services (the service registry's allocators upstream). Both are
+// derived from the stored Services, so deleting a Service frees them. Requested values are
+// validated; missing ones get the lowest free value (.1 of the service CIDR is left to the
+// "kubernetes" Service, as upstream).
+
+// ServiceCIDREnv names the environment variable holding the service CIDR: one prefix, or an
+// IPv4 and an IPv6 one separated by a comma for dual-stack Services
+const ServiceCIDREnv = "MOCKERNETES_SERVICE_CIDR"
+
+// DefaultServiceCIDR is the service CIDR when none is configured (kube-apiserver's default)
+const DefaultServiceCIDR = "10.96.0.0/12"
+
+// Node port range (kube-apiserver --service-node-port-range)
+const (
+	NodePortRangeMin = 30000
+	NodePortRangeMax = 32767
+)
+
+// IP family policies of a Service
+const (
+	IPFamilyPolicySingleStack      = "SingleStack"
+	IPFamilyPolicyPreferDualStack  = "PreferDualStack"
+	IPFamilyPolicyRequireDualStack = "RequireDualStack"
+)
+
+// ClusterIPNone is the clusterIP of a headless Service
+const ClusterIPNone = "None"
+
+// ServiceAllocator hands out cluster IPs from the service CIDRs and node ports
+type ServiceAllocator struct {
+	serviceCIDRs []netip.Prefix
+}
+
+// NewServiceAllocator creates a ServiceAllocator for a service CIDR ("" is DefaultServiceCIDR)
+func NewServiceAllocator(serviceCIDR string) (*ServiceAllocator, error) {
+	if serviceCIDR == "" {
+		serviceCIDR = DefaultServiceCIDR
+	}
+	cidrs, err := parseCIDRs(serviceCIDR)
+	if err != nil {
+		return nil, fmt.Errorf("invalid service CIDR %q: %w", serviceCIDR, err)
+	}
+	return &ServiceAllocator{serviceCIDRs: cidrs}, nil
+}
+
+// cidrFor returns the service CIDR of an IP family
+func (a *ServiceAllocator) cidrFor(family string) (netip.Prefix, bool) {
+	for _, cidr := range a.serviceCIDRs {
+		if ipFamily(cidr.Addr()) == family {
+			return cidr, true
+		}
+	}
+	return netip.Prefix{}, false
+}
+
+// Allocate fills in spec.clusterIP(s), ipFamilies, ipFamilyPolicy and the node ports of a
+// Service. existing is the stored Service on update (nil on create): its cluster IPs are kept
+// and its node ports reused for the same ports.
+func (a *ServiceAllocator) Allocate(store *storage.InMemoryStore, svc *resources.Service, existing map[string]interface{}) error {
+	spec, ok := svc.Spec.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	old, _ := resources.NestedMap(existing, "spec")
+	if old["type"] == "ExternalName" {
+		old = nil
+	}
+	usedIPs, usedPorts := map[netip.Addr]bool{}, map[string]bool{}
+	for _, item := range store.ListServices() {
+		other, ok := item.(map[string]interface{})
+		if !ok || other["metadata"].(map[string]interface{})["name"] == svc.Metadata.Name {
+			continue
+		}
+		for _, ip := range serviceClusterIPs(other["spec"]) {
+			if addr, err := netip.ParseAddr(ip); err == nil {
+				usedIPs[addr] = true
+			}
+		}
+		for key, nodePort := range serviceNodePorts(other["spec"]) {
+			protocol, _, _ := strings.Cut(key, "/")
+			usedPorts[fmt.Sprintf("%s/%d", protocol, nodePort)] = true
+		}
+	}
+
+	if spec["type"] == "ExternalName" {
+		for _, field := range []string{"clusterIP", "clusterIPs", "ipFamilies", "ipFamilyPolicy"} {
+			delete(spec, field)
+		}
+		return allocateNodePorts(spec, nil, usedPorts)
+	}
+	if err := a.allocateClusterIPs(spec, old, usedIPs); err != nil {
+		return err
+	}
+	return allocateNodePorts(spec, old, usedPorts)
+}
+
+// serviceClusterIPs returns spec.clusterIPs of a Service spec (spec.clusterIP when unset)
+func serviceClusterIPs(spec interface{}) []string {
+	specMap, _ := spec.(map[string]interface{})
+	if ips, _ := resources.NestedStringSlice(specMap, "clusterIPs"); len(ips) > 0 {
+		return ips
+	}
+	if ip, _ := specMap["clusterIP"].(string); ip != "" {
+		return []string{ip}
+	}
+	return nil
+}
+
+// serviceNodePorts returns the node ports of a Service spec by service port ("<protocol>/<port>")
+func serviceNodePorts(spec interface{}) map[string]int64 {
+	specMap, _ := spec.(map[string]interface{})
+	ports, _ := specMap["ports"].([]interface{})
+	nodePorts := map[string]int64{}
+	for _, item := range ports {
+		port, _ := item.(map[string]interface{})
+		if nodePort, ok := resources.ToInt64(port["nodePort"]); ok && nodePort != 0 {
+			nodePorts[portKey(port, port["port"])] = nodePort
+		}
+	}
+	return nodePorts
+}
+
+// portKey returns "<protocol>/<number>" for a service port
+func portKey(port map[string]interface{}, number interface{}) string {
+	protocol, _ := port["protocol"].(string)
+	if protocol == "" {
+		protocol = "TCP"
+	}
+	if n, ok := resources.ToInt64(number); ok {
+		return fmt.Sprintf("%s/%d", protocol, n)
+	}
+	return protocol + "/"
+}
+
+// allocateClusterIPs settles the IP families of a Service and gives it a cluster IP of each
+func (a *ServiceAllocator) allocateClusterIPs(spec, old map[string]interface{}, used map[netip.Addr]bool) error {
+	requested := serviceClusterIPs(spec)
+	if oldIPs := serviceClusterIPs(old); len(oldIPs) > 0 {
+		for _, field := range []string{"ipFamilies", "ipFamilyPolicy"} {
+			if _, ok := spec[field]; !ok && old[field] != nil {
+				spec[field] = old[field]
+			}
+		}
+		if len(requested) == 0 {
+			requested = oldIPs
+		} else if requested[0] != oldIPs[0] {
+			return fmt.Errorf("spec.clusterIPs[0]: Invalid value: %q: may not change once set", requested[0])
+		} else if len(requested) < len(oldIPs) && spec["ipFamilyPolicy"] != IPFamilyPolicySingleStack {
+			// an update sending only spec.clusterIP keeps the secondary IP
+			requested = oldIPs
+		}
+	}
+	headless := len(requested) > 0 && requested[0] == ClusterIPNone
+
+	families, _ := resources.NestedStringSlice(spec, "ipFamilies")
+	for i, family := range families {
+		if _, ok := a.cidrFor(family); !ok {
+			return fmt.Errorf("spec.ipFamilies[%d]: Invalid value: %q: not configured on this cluster", i, family)
+		}
+	}
+	if len(families) == 0 && !headless {
+		for _, ip := range requested {
+			if addr, err := netip.ParseAddr(ip); err == nil {
+				families = append(families, ipFamily(addr))
+			}
+		}
+	}
+	if len(families) == 0 {
+		families = []string{ipFamily(a.serviceCIDRs[0].Addr())}
+	}
+	policy, _ := spec["ipFamilyPolicy"].(string)
+	switch policy {
+	case "", IPFamilyPolicySingleStack:
+		policy = IPFamilyPolicySingleStack
+		if len(families) > 1 || len(requested) > 1 {
+			return fmt.Errorf("spec.ipFamilyPolicy: Invalid value: %q: must be dual-stack for more than one IP family", policy)
+		}
+	case IPFamilyPolicyPreferDualStack, IPFamilyPolicyRequireDualStack:
+		if len(families) == 1 && len(a.serviceCIDRs) == 2 {
+			for _, cidr := range a.serviceCIDRs {
+				if family := ipFamily(cidr.Addr()); family != families[0] {
+					families = append(families, family)
+				}
+			}
+		}
+		if policy == IPFamilyPolicyRequireDualStack && len(families) < 2 {
+			return fmt.Errorf("spec.ipFamilyPolicy: Invalid value: %q: this cluster is not configured for dual-stack services", policy)
+		}
+	default:
+		return fmt.Errorf(`spec.ipFamilyPolicy: Unsupported value: %q: supported values: "PreferDualStack", "RequireDualStack", "SingleStack"`, policy)
+	}
+	spec["ipFamilyPolicy"] = policy
+	spec["ipFamilies"] = stringsToInterfaces(families)
+
+	if headless {
+		spec["clusterIP"] = ClusterIPNone
+		spec["clusterIPs"] = []interface{}{ClusterIPNone}
+		return nil
+	}
+	ips := make([]string, len(families))
+	for i, family := range families {
+		cidr, _ := a.cidrFor(family)
+		if i >= len(requested) {
+			addr, ok := lowestFreeAddr(cidr, used)
+			if !ok {
+				return fmt.Errorf("spec.clusterIPs[%d]: Internal error: no %s cluster IPs left in %s", i, family, cidr)
+			}
+			ips[i] = addr.String()
+			continue
+		}
+		addr, err := netip.ParseAddr(requested[i])
+		if err != nil || ipFamily(addr) != family {
+			return fmt.Errorf("spec.clusterIPs[%d]: Invalid value: %q: must be a valid %s address", i, requested[i], family)
+		}
+		// .1 may be requested (the "kubernetes" Service has it), the network address can't
+		if first, last := rangeBounds(cidr); !cidr.Contains(addr) || addr.Less(first.Prev()) || last.Less(addr) {
+			return fmt.Errorf("spec.clusterIPs[%d]: Invalid value: %q: provided IP is not in the valid range. The range of valid IPs is %s", i, requested[i], cidr)
+		}
+		if used[addr] {
+			return fmt.Errorf("spec.clusterIPs[%d]: Invalid value: %q: provided IP is already allocated", i, requested[i])
+		}
+		ips[i] = addr.String()
+	}
+	spec["clusterIP"] = ips[0]
+	spec["clusterIPs"] = stringsToInterfaces(ips)
+	return nil
+}
+
+// lowestFreeAddr returns the lowest address of a range no Service has
+func lowestFreeAddr(cidr netip.Prefix, used map[netip.Addr]bool) (netip.Addr, bool) {
+	first, last := rangeBounds(cidr)
+	if !first.IsValid() {
+		return netip.Addr{}, false
+	}
+	addr := first
+	for i := 0; i < maxAllocationAttempts && !last.Less(addr); i++ {
+		if !used[addr] {
+			return addr, true
+		}
+		addr = addr.Next()
+	}
+	return netip.Addr{}, false
+}
+
+// allocateNodePorts gives every port of a NodePort or LoadBalancer Service a node port (the one
+// the port had before when old is set); other types may not have node ports
+func allocateNodePorts(spec, old map[string]interface{}, used map[string]bool) error {
+	oldNodePorts := serviceNodePorts(old)
+	wantNodePorts := spec["type"] == "NodePort" || spec["type"] == "LoadBalancer"
+	ports, _ := spec["ports"].([]interface{})
+	for i, item := range ports {
+		port, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		nodePort, _ := resources.ToInt64(port["nodePort"])
+		previous := oldNodePorts[portKey(port, port["port"])]
+		if !wantNodePorts {
+			if nodePort != 0 && nodePort != previous {
+				return fmt.Errorf("spec.ports[%d].nodePort: Forbidden: may not be used when `type` is '%s'", i, spec["type"])
+			}
+			delete(port, "nodePort")
+			continue
+		}
+		if nodePort == 0 && previous != 0 && !used[portKey(port, previous)] {
+			nodePort = previous
+		}
+		if nodePort == 0 {
+			for n := int64(NodePortRangeMin); n <= NodePortRangeMax; n++ {
+				if !used[portKey(port, n)] {
+					nodePort = n
+					break
+				}
+			}
+			if nodePort == 0 {
+				return fmt.Errorf("spec.ports[%d].nodePort: Internal error: no node ports left", i)
+			}
+		} else if nodePort < NodePortRangeMin || nodePort > NodePortRangeMax {
+			return fmt.Errorf("spec.ports[%d].nodePort: Invalid value: %d: provided port is not in the valid range. The range of valid ports is %d-%d", i, nodePort, NodePortRangeMin, NodePortRangeMax)
+		} else if used[portKey(port, nodePort)] {
+			return fmt.Errorf("spec.ports[%d].nodePort: Invalid value: %d: provided port is already allocated", i, nodePort)
+		}
+		used[portKey(port, nodePort)] = true
+		port["nodePort"] = float64(nodePort)
+	}
+	return nil
+}
+
+// stringsToInterfaces converts a string slice for a json-decoded map
+func stringsToInterfaces(s []string) []interface{} {
+	out := make([]interface{}, len(s))
+	for i, v := range s {
+		out[i] = v
+	}
+	return out
+}
+
+// DefaultServiceAllocator is the singleton instance
+var DefaultServiceAllocator *ServiceAllocator
+
+// InitServiceAllocator initializes the default ServiceAllocator for a service CIDR ("" is
+// DefaultServiceCIDR)
+func InitServiceAllocator(serviceCIDR string) error {
+	allocator, err := NewServiceAllocator(serviceCIDR)
+	if err != nil {
+		return err
+	}
+	DefaultServiceAllocator = allocator
+	fmt.Printf("[Service Allocator] Allocating cluster IPs from %s\n", strings.Join(prefixStrings(allocator.serviceCIDRs), ","))
+	return nil
+}
+
+// AllocateServiceAddresses allocates the cluster IPs and node ports of a Service with the
+// default ServiceAllocator (one for DefaultServiceCIDR when there is none)
+func AllocateServiceAddresses(store *storage.InMemoryStore, svc *resources.Service, existing map[string]interface{}) error {
+	allocator := DefaultServiceAllocator
+	if allocator == nil {
+		allocator, _ = NewServiceAllocator(DefaultServiceCIDR)
+	}
+	return allocator.Allocate(store, svc, existing)
+}
@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+func newTestService(name string, spec map[string]interface{}) *resources.Service {
+	svc := &resources.Service{
+		Kind:       "Service",
+		APIVersion: "v1",
+		Metadata:   resources.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       spec,
+	}
+	resources.SetServiceDefaults(svc)
+	return svc
+}
+
+// createService allocates the addresses of a Service and stores it
+func createService(t *testing.T, store *storage.InMemoryStore, allocator *ServiceAllocator, svc *resources.Service) {
+	t.Helper()
+	if err := allocator.Allocate(store, svc, nil); err != nil {
+		t.Fatalf("Allocate %s failed: %v", svc.Metadata.Name, err)
+	}
+	if err := store.CreateService(svc); err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+}
+
+func tcpPort(port, nodePort int) map[string]interface{} {
+	p := map[string]interface{}{"port": float64(port)}
+	if nodePort != 0 {
+		p["nodePort"] = float64(nodePort)
+	}
+	return p
+}
+
+func TestServiceAllocatorClusterIPs(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	allocator, _ := NewServiceAllocator("10.96.0.0/12")
+
+	web := newTestService("web", map[string]interface{}{"ports": []interface{}{tcpPort(80, 0)}})
+	createService(t, store, allocator, web)
+	spec := web.Spec.(map[string]interface{})
+	if spec["clusterIP"] != "10.96.0.2" || spec["ipFamilyPolicy"] != IPFamilyPolicySingleStack {
+		t.Errorf("Expected clusterIP 10.96.0.2 (SingleStack), got %v (%v)", spec["clusterIP"], spec["ipFamilyPolicy"])
+	}
+	api := newTestService("api", map[string]interface{}{"ports": []interface{}{tcpPort(80, 0)}})
+	createService(t, store, allocator, api)
+	if ip := api.Spec.(map[string]interface{})["clusterIP"]; ip != "10.96.0.3" {
+		t.Errorf("Expected clusterIP 10.96.0.3, got %v", ip)
+	}
+
+	headless := newTestService("db", map[string]interface{}{"clusterIP": "None"})
+	createService(t, store, allocator, headless)
+	if ips := headless.Spec.(map[string]interface{})["clusterIPs"]; len(ips.([]interface{})) != 1 || ips.([]interface{})[0] != "None" {
+		t.Errorf("Expected a headless service, got clusterIPs %v", ips)
+	}
+	external := newTestService("ext", map[string]interface{}{"type": "ExternalName", "externalName": "example.com"})
+	createService(t, store, allocator, external)
+	if _, ok := external.Spec.(map[string]interface{})["clusterIP"]; ok {
+		t.Error("Expected no clusterIP for an ExternalName service")
+	}
+
+	tests := []struct {
+		name string
+		spec map[string]interface{}
+		err  string
+	}{
+		{"taken", map[string]interface{}{"clusterIP": "10.96.0.2"}, "provided IP is already allocated"},
+		{"out of range", map[string]interface{}{"clusterIP": "10.200.0.1"}, "not in the valid range"},
+		{"no IPv6", map[string]interface{}{"ipFamilies": []interface{}{"IPv6"}}, "not configured"},
+		{"no dual-stack", map[string]interface{}{"ipFamilyPolicy": IPFamilyPolicyRequireDualStack}, "not configured for dual-stack"},
+	}
+	for _, tt := range tests {
+		tt.spec["ports"] = []interface{}{tcpPort(80, 0)}
+		err := allocator.Allocate(store, newTestService("bad", tt.spec), nil)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.name, tt.err, err)
+		}
+	}
+
+	// deleting a Service frees its IP
+	store.DeleteService("web")
+	again := newTestService("again", map[string]interface{}{"ports": []interface{}{tcpPort(80, 0)}})
+	createService(t, store, allocator, again)
+	if ip := again.Spec.(map[string]interface{})["clusterIP"]; ip != "10.96.0.2" {
+		t.Errorf("Expected the freed clusterIP 10.96.0.2, got %v", ip)
+	}
+}
+
+func TestServiceAllocatorDualStack(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	allocator, _ := NewServiceAllocator("10.96.0.0/16,fd00:10:96::/112")
+
+	svc := newTestService("web", map[string]interface{}{
+		"ipFamilyPolicy": IPFamilyPolicyPreferDualStack,
+		"ports":          []interface{}{tcpPort(80, 0)},
+	})
+	createService(t, store, allocator, svc)
+	ips, _ := resources.NestedStringSlice(svc.Spec.(map[string]interface{}), "clusterIPs")
+	families, _ := resources.NestedStringSlice(svc.Spec.(map[string]interface{}), "ipFamilies")
+	if len(ips) != 2 || ips[0] != "10.96.0.2" || ips[1] != "fd00:10:96::2" || families[1] != "IPv6" {
+		t.Errorf("Expected dual-stack clusterIPs, got %v (%v)", ips, families)
+	}
+}
+
+func TestServiceAllocatorNodePorts(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	allocator, _ := NewServiceAllocator(DefaultServiceCIDR)
+
+	web := newTestService("web", map[string]interface{}{
+		"type":  "NodePort",
+		"ports": []interface{}{tcpPort(80, 0), tcpPort(443, 30443)},
+	})
+	web.Spec.(map[string]interface{})["ports"].([]interface{})[1].(map[string]interface{})["name"] = "https"
+	createService(t, store, allocator, web)
+	ports := web.Spec.(map[string]interface{})["ports"].([]interface{})
+	if ports[0].(map[string]interface{})["nodePort"] != float64(NodePortRangeMin) || ports[1].(map[string]interface{})["nodePort"] != float64(30443) {
+		t.Errorf("Expected nodePorts 30000 and 30443, got %v", ports)
+	}
+
+	for _, nodePort := range []int{30443, 8080} {
+		other := newTestService("other", map[string]interface{}{"type": "NodePort", "ports": []interface{}{tcpPort(80, nodePort)}})
+		if err := allocator.Allocate(store, other, nil); err == nil {
+			t.Errorf("Expected nodePort %d to be refused", nodePort)
+		}
+	}
+	clusterIP := newTestService("internal", map[string]interface{}{"ports": []interface{}{tcpPort(80, 30100)}})
+	if err := allocator.Allocate(store, clusterIP, nil); err == nil {
+		t.Error("Expected a nodePort to be refused for a ClusterIP service")
+	}
+
+	// an update without the allocated values (kubectl replace) keeps them
+	stored, _ := store.GetService("web")
+	update := newTestService("web", map[string]interface{}{
+		"type":  "NodePort",
+		"ports": []interface{}{tcpPort(80, 0), tcpPort(443, 0)},
+	})
+	update.Spec.(map[string]interface{})["ports"].([]interface{})[1].(map[string]interface{})["name"] = "https"
+	if err := allocator.Allocate(store, update, stored); err != nil {
+		t.Fatalf("Allocate on update failed: %v", err)
+	}
+	spec := update.Spec.(map[string]interface{})
+	ports = spec["ports"].([]interface{})
+	if spec["clusterIP"] != stored["spec"].(map[string]interface{})["clusterIP"] ||
+		ports[0].(map[string]interface{})["nodePort"] != float64(NodePortRangeMin) || ports[1].(map[string]interface{})["nodePort"] != float64(30443) {
+		t.Errorf("Expected the clusterIP and nodePorts to be kept, got %v", spec)
+	}
+	update.Spec.(map[string]interface{})["clusterIP"] = "10.96.0.99"
+	update.Spec.(map[string]interface{})["clusterIPs"] = []interface{}{"10.96.0.99"}
+	if err := allocator.Allocate(store, update, stored); err == nil || !strings.Contains(err.Error(), "may not change") {
+		t.Errorf("Expected the clusterIP change to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,385 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+// StatefulSetController manages StatefulSets: pods with stable names (web-0, web-1, ...),
+// their PersistentVolumeClaims and ordered rollouts (pkg/controller/statefulset upstream).
+// It is driven by StatefulSet and Pod change events through a work queue.
+type StatefulSetController struct {
+	store   *storage.InMemoryStore
+	queue   *RateLimitingQueue
+	workers int
+}
+
+// NewStatefulSetController creates a new StatefulSetController fed by the given informers
+func NewStatefulSetController(store *storage.InMemoryStore, informers *SharedInformerFactory) *StatefulSetController {
+	ssc := &StatefulSetController{
+		store:   store,
+		queue:   NewRateLimitingQueue(),
+		workers: DefaultWorkers,
+	}
+	informers.AddEventHandler("StatefulSet", ResourceEventHandlerFuncs{
+		AddFunc:    ssc.enqueue,
+		UpdateFunc: func(_, set map[string]interface{}) { ssc.enqueue(set) },
+		DeleteFunc: ssc.enqueue,
+	})
+	// Pods becoming ready (or going away) let an ordered rollout take its next step
+	informers.AddEventHandler("Pod", ResourceEventHandlerFuncs{
+		AddFunc: ssc.enqueueForPod,
+		UpdateFunc: func(old, pod map[string]interface{}) {
+			ssc.enqueueForPod(old)
+			ssc.enqueueForPod(pod)
+		},
+		DeleteFunc: ssc.enqueueForPod,
+	})
+	return ssc
+}
+
+// Start starts the controller's workers
+func (ssc *StatefulSetController) Start() {
+	runWorkers(ssc.queue, ssc.workers, "StatefulSet Controller", ssc.syncStatefulSet)
+}
+
+// Stop stops the controller
+func (ssc *StatefulSetController) Stop() {
+	ssc.queue.ShutDownAndWait()
+}
+
+// enqueue queues a StatefulSet for sync
+func (ssc *StatefulSetController) enqueue(set map[string]interface{}) {
+	ssc.queue.Add(objectKey(set))
+}
+
+// enqueueForPod queues the StatefulSet controlling a pod, or for an orphan every
+// StatefulSet whose selector matches it
+func (ssc *StatefulSetController) enqueueForPod(pod map[string]interface{}) {
+	if key := ownerKey(pod, "StatefulSet"); key != "" {
+		ssc.queue.Add(key)
+		return
+	}
+	if hasController(pod) || isBeingDeleted(pod) {
+		return
+	}
+	for _, item := range ssc.store.ListStatefulSets() {
+		set, ok := item.(map[string]interface{})
+		if !ok || namespaceOf(set) != namespaceOf(pod) {
+			continue
+		}
+		if selector, err := selectorOf(set); err == nil && selector.Matches(podLabels(pod)) {
+			ssc.queue.Add(objectKey(set))
+		}
+	}
+}
+
+// syncStatefulSet reconciles the StatefulSet stored under key (gone StatefulSets need no work)
+func (ssc *StatefulSetController) syncStatefulSet(key string) error {
+	_, name := splitKey(key)
+	set, err := ssc.store.GetStatefulSet(name)
+	if err != nil {
+		return nil
+	}
+	return ssc.reconcileStatefulSet(set)
+}
+
+// statefulSetPods is one sync's view of a StatefulSet's pods
+type statefulSetPods struct {
+	set             map[string]interface{}
+	name            string
+	start           int // spec.ordinals.start
+	monotonic       bool
+	minReadySeconds int64
+	currentRevision map[string]interface{}
+	updateRevision  map[string]interface{}
+	replicas        []map[string]interface{} // by ordinal-start, nil where the pod is missing
+	condemned       []map[string]interface{} // pods beyond the replicas, highest ordinal first
+	now             time.Time
+}
+
+// healthy reports whether a pod lets an ordered rollout move on: Running, Ready and
+// available for minReadySeconds
+func (s *statefulSetPods) healthy(pod map[string]interface{}) bool {
+	if !isRunningAndReady(pod) {
+		return false
+	}
+	available, _ := podAvailableIn(pod, s.minReadySeconds, s.now)
+	return available
+}
+
+// revisionFor returns the revision a new pod gets: below the partition pods stay on the
+// current revision
+func (s *statefulSetPods) revisionFor(ordinal int) map[string]interface{} {
+	strategy, _ := resources.NestedString(s.set, "spec", "updateStrategy", "type")
+	partition, _ := resources.NestedInt64(s.set, "spec", "updateStrategy", "rollingUpdate", "partition")
+	if strategy == "RollingUpdate" && ordinal < int(partition) {
+		return s.currentRevision
+	}
+	return s.updateRevision
+}
+
+// reconcileStatefulSet creates, deletes and updates pods one ordinal at a time (all at once
+// with podManagementPolicy Parallel) and records the resulting status
+func (ssc *StatefulSetController) reconcileStatefulSet(set map[string]interface{}) error {
+	// A StatefulSet waiting on finalizers keeps its pods but is no longer managed
+	if isBeingDeleted(set) {
+		return nil
+	}
+	s, pods, err := ssc.getStatefulSetPods(set)
+	if err != nil {
+		fmt.Printf("[StatefulSet Controller] Error syncing StatefulSet %s: %v\n", s.name, err)
+		return err
+	}
+	fmt.Printf("[StatefulSet Controller] Reconciling StatefulSet %s: desired=%d, pods=%d\n", objectKey(set), len(s.replicas), len(pods))
+
+	// Status reflects the pods observed by this sync; their events trigger the next one
+	manageErr := ssc.manageStatefulSetPods(s)
+	wait, err := ssc.updateStatefulSetStatus(s, pods)
+	if err == nil {
+		currentName, _ := resources.NestedString(s.currentRevision, "metadata", "name")
+		updateName, _ := resources.NestedString(s.updateRevision, "metadata", "name")
+		err = truncateHistory(ssc.store, set, listRevisions(ssc.store, set, "StatefulSet"), pods, currentName, updateName)
+	}
+	if manageErr != nil || err != nil {
+		return errors.Join(manageErr, err)
+	}
+	if wait > 0 {
+		// Nothing fires when a ready pod outlives minReadySeconds
+		ssc.queue.AddAfter(objectKey(set), wait)
+	}
+	return nil
+}
+
+// getStatefulSetPods claims the set's pods, makes sure the revision of its current template
+// exists and slots the pods by ordinal. It also returns all claimed pods.
+func (ssc *StatefulSetController) getStatefulSetPods(set map[string]interface{}) (*statefulSetPods, []map[string]interface{}, error) {
+	replicas, _ := resources.NestedInt64(set, "spec", "replicas")
+	start, _ := resources.NestedInt64(set, "spec", "ordinals", "start")
+	policy, _ := resources.NestedString(set, "spec", "podManagementPolicy")
+	minReadySeconds, _ := resources.NestedInt64(set, "spec", "minReadySeconds")
+	s := &statefulSetPods{
+		set:             set,
+		start:           int(start),
+		monotonic:       policy != "Parallel",
+		minReadySeconds: minReadySeconds,
+		replicas:        make([]map[string]interface{}, replicas),
+		now:             time.Now(),
+	}
+	s.name, _ = resources.NestedString(set, "metadata", "name")
+
+	revisions := listRevisions(ssc.store, set, "StatefulSet")
+	updateRevision, err := ensureRevision(ssc.store, set, "StatefulSet", revisions)
+	if err != nil {
+		return s, nil, err
+	}
+	s.updateRevision = updateRevision
+	s.currentRevision = updateRevision
+	if current, _ := resources.NestedString(set, "status", "currentRevision"); current != "" {
+		for _, revision := range revisions {
+			if name, _ := resources.NestedString(revision, "metadata", "name"); name == current {
+				s.currentRevision = revision
+			}
+		}
+	}
+
+	selector, err := selectorOf(set)
+	if err != nil {
+		return s, nil, err
+	}
+	pods, err := claimPods(ssc.store, set, "StatefulSet", selector)
+	if err != nil {
+		return s, nil, err
+	}
+	for _, pod := range pods {
+		ordinal := ordinalOf(pod, s.name)
+		switch {
+		case ordinal < 0:
+			// not one of ours by name; left alone
+		case ordinal >= s.start && ordinal < s.start+len(s.replicas):
+			s.replicas[ordinal-s.start] = pod
+		default:
+			s.condemned = append(s.condemned, pod)
+		}
+	}
+	sort.SliceStable(s.condemned, func(i, j int) bool {
+		return ordinalOf(s.condemned[i], s.name) > ordinalOf(s.condemned[j], s.name)
+	})
+	return s, pods, nil
+}
+
+// manageStatefulSetPods takes the next steps towards the desired pods: replace finished pods,
+// create missing ones (with their claims) in ordinal order, remove pods beyond the replicas
+// from the highest ordinal down, then roll pods to the update revision (highest ordinal
+// first, down to the partition). With OrderedReady every step waits for the previous pod
+// to be Running and Ready; Parallel only waits during rolling updates.
+func (ssc *StatefulSetController) manageStatefulSetPods(s *statefulSetPods) error {
+	for i, pod := range s.replicas {
+		ordinal := s.start + i
+		if pod != nil && isPodFinished(pod) && !isBeingDeleted(pod) {
+			fmt.Printf("[StatefulSet Controller] Pod %s-%d has finished, recreating it\n", s.name, ordinal)
+			if err := deletePodObject(ssc.store, fmt.Sprintf("%s-%d", s.name, ordinal)); err != nil {
+				return err
+			}
+			if s.monotonic {
+				return nil
+			}
+			continue
+		}
+		if pod == nil {
+			if err := ssc.createStatefulSetPod(s, ordinal); err != nil {
+				return err
+			}
+			if s.monotonic {
+				return nil
+			}
+			continue
+		}
+		// A terminating pod is re-created once it is gone
+		if isBeingDeleted(pod) {
+			if s.monotonic {
+				return nil
+			}
+			continue
+		}
+		// Claims deleted behind the pod's back are re-created for the next incarnation
+		if err := ensureClaims(ssc.store, s.set, ordinal); err != nil {
+			return err
+		}
+		if s.monotonic && !s.healthy(pod) {
+			return nil
+		}
+	}
+
+	whenScaled, _ := resources.NestedString(s.set, "spec", "persistentVolumeClaimRetentionPolicy", "whenScaled")
+	for _, pod := range s.condemned {
+		podName, _ := resources.NestedString(pod, "metadata", "name")
+		if isBeingDeleted(pod) {
+			if s.monotonic {
+				return nil
+			}
+			continue
+		}
+		if whenScaled == "Delete" {
+			if err := releaseClaimsWithPod(ssc.store, s.set, pod, ordinalOf(pod, s.name)); err != nil {
+				return err
+			}
+		}
+		fmt.Printf("[StatefulSet Controller] Deleting pod %s for scale down of StatefulSet %s\n", podName, s.name)
+		if err := deletePodObject(ssc.store, podName); err != nil {
+			return err
+		}
+		if s.monotonic {
+			return nil
+		}
+	}
+
+	// OnDelete leaves updates to the user deleting pods
+	strategy, _ := resources.NestedString(s.set, "spec", "updateStrategy", "type")
+	if strategy != "RollingUpdate" {
+		return nil
+	}
+	partition, _ := resources.NestedInt64(s.set, "spec", "updateStrategy", "rollingUpdate", "partition")
+	updateName, _ := resources.NestedString(s.updateRevision, "metadata", "name")
+	// the partition is an ordinal, target a slot (ordinal - start)
+	for target := len(s.replicas) - 1; target >= int(partition)-s.start && target >= 0; target-- {
+		pod := s.replicas[target]
+		if pod == nil {
+			return nil
+		}
+		if podRevision(pod) != updateName && !isBeingDeleted(pod) {
+			podName, _ := resources.NestedString(pod, "metadata", "name")
+			fmt.Printf("[StatefulSet Controller] Deleting pod %s for update to revision %s\n", podName, updateName)
+			return deletePodObject(ssc.store, podName)
+		}
+		// One pod at a time: wait for the updated pod to come back healthy
+		if !s.healthy(pod) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// createStatefulSetPod creates the claims and the pod for ordinal
+func (ssc *StatefulSetController) createStatefulSetPod(s *statefulSetPods, ordinal int) error {
+	if err := ensureClaims(ssc.store, s.set, ordinal); err != nil {
+		return err
+	}
+	pod := newStatefulSetPod(s.set, s.revisionFor(ordinal), ordinal)
+	if err := createPod(ssc.store, &pod); err != nil {
+		return fmt.Errorf("failed to create pod %s: %w", pod.GetName(), err)
+	}
+	fmt.Printf("[StatefulSet Controller] Created pod %s for StatefulSet %s\n", pod.GetName(), s.name)
+	return nil
+}
+
+// updateStatefulSetStatus records the replica counts and revisions of the observed pods.
+// A rolling update is complete once every pod is updated and ready; the update revision
+// then becomes the current one. It returns how long until the next ready pod becomes available.
+func (ssc *StatefulSetController) updateStatefulSetStatus(s *statefulSetPods, pods []map[string]interface{}) (time.Duration, error) {
+	currentName, _ := resources.NestedString(s.currentRevision, "metadata", "name")
+	updateName, _ := resources.NestedString(s.updateRevision, "metadata", "name")
+	generation, _ := resources.NestedInt64(s.set, "metadata", "generation")
+
+	var replicas, ready, available, current, updated int32
+	var wait time.Duration
+	for _, pod := range pods {
+		if isBeingDeleted(pod) {
+			continue
+		}
+		replicas++
+		if isRunningAndReady(pod) {
+			ready++
+			ok, after := podAvailableIn(pod, s.minReadySeconds, s.now)
+			if ok {
+				available++
+			} else if after > 0 && (wait == 0 || after < wait) {
+				wait = after
+			}
+		}
+		switch podRevision(pod) {
+		case currentName:
+			current++
+			if currentName == updateName {
+				updated++
+			}
+		case updateName:
+			updated++
+		}
+	}
+	strategy, _ := resources.NestedString(s.set, "spec", "updateStrategy", "type")
+	if strategy == "RollingUpdate" && updated == replicas && ready == replicas {
+		currentName, current = updateName, updated
+	}
+
+	status := map[string]interface{}{
+		"observedGeneration": generation,
+		"replicas":           replicas,
+		"readyReplicas":      ready,
+		"availableReplicas":  available,
+		"currentReplicas":    current,
+		"updatedReplicas":    updated,
+		"currentRevision":    currentName,
+		"updateRevision":     updateName,
+		"collisionCount":     0,
+	}
+	_, err := ssc.store.MutateStatefulSet(s.name, func(set map[string]interface{}) error {
+		set["status"] = status
+		return nil
+	})
+	return wait, err
+}
+
+// DefaultStatefulSetController is the singleton instance
+var DefaultStatefulSetController *StatefulSetController
+
+// InitStatefulSetController initializes the default StatefulSet controller
+func InitStatefulSetController(store *storage.InMemoryStore) {
+	DefaultStatefulSetController = NewStatefulSetController(store, sharedInformers(store))
+	DefaultStatefulSetController.Start()
+}
@@ -0,0 +1,271 @@
+package controllers
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+func newTestStatefulSet(name string, replicas int, strategy map[string]interface{}) resources.StatefulSet {
+	set := resources.StatefulSet{
+		Kind:       "StatefulSet",
+		APIVersion: "apps/v1",
+		Metadata:   resources.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: map[string]interface{}{
+			"replicas":       float64(replicas),
+			"serviceName":    name,
+			"updateStrategy": strategy,
+			"selector":       map[string]interface{}{"matchLabels": map[string]interface{}{"app": name}},
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": name}},
+				"spec":     map[string]interface{}{"containers": []interface{}{map[string]interface{}{"name": "db", "image": "db:v1"}}},
+			},
+			"volumeClaimTemplates": []interface{}{map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "data"},
+				"spec": map[string]interface{}{
+					"accessModes": []interface{}{"ReadWriteOnce"},
+					"resources":   map[string]interface{}{"requests": map[string]interface{}{"storage": "1Gi"}},
+				},
+			}},
+		},
+	}
+	resources.SetStatefulSetDefaults(&set)
+	return set
+}
+
+func startStatefulSetController(t *testing.T, store *storage.InMemoryStore) {
+	controller := NewStatefulSetController(store, startInformers(t, store))
+	controller.Start()
+	t.Cleanup(controller.Stop)
+}
+
+// statefulSetPod returns pod ordinal of the set if it exists and is not terminating.
+func statefulSetPod(store *storage.InMemoryStore, set string, ordinal int) map[string]interface{} {
+	pod, err := store.GetPod(fmt.Sprintf("%s-%d", set, ordinal))
+	if err != nil || isBeingDeleted(pod) {
+		return nil
+	}
+	return pod
+}
+
+func statefulSetStatus(store *storage.InMemoryStore, name string) map[string]interface{} {
+	set, _ := store.GetStatefulSet(name)
+	status, _ := set["status"].(map[string]interface{})
+	return status
+}
+
+func podImage(pod map[string]interface{}) string {
+	containers, _ := resources.NestedSlice(pod, "spec", "containers")
+	image, _ := containers[0].(map[string]interface{})["image"].(string)
+	return image
+}
+
+func TestStatefulSetOrderedReadyScaling(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	usePodController(t, store)
+	startStatefulSetController(t, store)
+
+	if err := store.CreateStatefulSet(newTestStatefulSet("db", 3, nil)); err != nil {
+		t.Fatalf("Failed to create statefulset: %v", err)
+	}
+	// Pod i+1 is only created once pod i is running and ready
+	waitFor(t, 3*time.Second, "3 ready pods", func() bool {
+		for i := 1; i < 3; i++ {
+			if statefulSetPod(store, "db", i) != nil && !isRunningAndReady(statefulSetPod(store, "db", i-1)) {
+				t.Fatalf("Pod db-%d exists before db-%d is ready", i, i-1)
+			}
+		}
+		ready, _ := resources.NestedInt64(statefulSetStatus(store, "db"), "readyReplicas")
+		return ready == 3
+	})
+
+	pod := statefulSetPod(store, "db", 1)
+	if hostname, _ := resources.NestedString(pod, "spec", "hostname"); hostname != "db-1" {
+		t.Errorf("Expected hostname db-1, got %q", hostname)
+	}
+	if index, _ := resources.NestedString(pod, "metadata", "labels", PodIndexLabel); index != "1" {
+		t.Errorf("Expected pod-index label 1, got %q", index)
+	}
+	volumes, _ := resources.NestedSlice(pod, "spec", "volumes")
+	if claim, _ := resources.NestedString(volumes[0].(map[string]interface{}), "persistentVolumeClaim", "claimName"); claim != "data-db-1" {
+		t.Errorf("Expected volume to use claim data-db-1, got %q", claim)
+	}
+	for i := 0; i < 3; i++ {
+		claim, err := store.GetPersistentVolumeClaim(fmt.Sprintf("data-db-%d", i))
+		if err != nil {
+			t.Fatalf("Expected claim data-db-%d: %v", i, err)
+		}
+		if phase, _ := resources.NestedString(claim, "status", "phase"); phase != "Bound" {
+			t.Errorf("Expected claim data-db-%d to be Bound, got %q", i, phase)
+		}
+	}
+
+	// Scale down removes the highest ordinal first, and keeps the claims (whenScaled=Retain)
+	store.MutateStatefulSet("db", func(set map[string]interface{}) error {
+		set["spec"].(map[string]interface{})["replicas"] = 1
+		return nil
+	})
+	waitFor(t, 3*time.Second, "scale down to db-0", func() bool {
+		if statefulSetPod(store, "db", 1) == nil && statefulSetPod(store, "db", 2) != nil {
+			t.Fatal("db-1 was deleted before db-2")
+		}
+		return len(store.ListPods()) == 1 && statefulSetPod(store, "db", 0) != nil
+	})
+	if n := len(store.ListPersistentVolumeClaims()); n != 3 {
+		t.Errorf("Expected the 3 claims to be retained, got %d", n)
+	}
+}
+
+func TestStatefulSetParallelCreatesAllPods(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	pc := NewPodController(store, 300*time.Millisecond)
+	DefaultPodController = pc
+	t.Cleanup(func() {
+		pc.Stop()
+		DefaultPodController = nil
+	})
+	startStatefulSetController(t, store)
+
+	set := newTestStatefulSet("cache", 3, nil)
+	set.Spec.(map[string]interface{})["podManagementPolicy"] = "Parallel"
+	if err := store.CreateStatefulSet(set); err != nil {
+		t.Fatalf("Failed to create statefulset: %v", err)
+	}
+	waitFor(t, 200*time.Millisecond, "3 pods before any is ready", func() bool { return len(store.ListPods()) == 3 })
+}
+
+func TestStatefulSetRollingUpdateWithPartition(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	usePodController(t, store)
+	startStatefulSetController(t, store)
+
+	if err := store.CreateStatefulSet(newTestStatefulSet("db", 3, nil)); err != nil {
+		t.Fatalf("Failed to create statefulset: %v", err)
+	}
+	waitFor(t, 3*time.Second, "3 ready pods", func() bool {
+		ready, _ := resources.NestedInt64(statefulSetStatus(store, "db"), "readyReplicas")
+		return ready == 3
+	})
+	initial, _ := resources.NestedString(statefulSetStatus(store, "db"), "currentRevision")
+
+	// Only ordinals >= partition move to the new template, highest first
+	store.MutateStatefulSet("db", func(set map[string]interface{}) error {
+		spec := set["spec"].(map[string]interface{})
+		spec["updateStrategy"].(map[string]interface{})["rollingUpdate"] = map[string]interface{}{"partition": 1}
+		containers, _ := resources.NestedSlice(spec, "template", "spec", "containers")
+		containers[0].(map[string]interface{})["image"] = "db:v2"
+		return nil
+	})
+	waitFor(t, 3*time.Second, "db-1 and db-2 updated", func() bool {
+		status := statefulSetStatus(store, "db")
+		updated, _ := resources.NestedInt64(status, "updatedReplicas")
+		ready, _ := resources.NestedInt64(status, "readyReplicas")
+		return updated == 2 && ready == 3
+	})
+	if image := podImage(statefulSetPod(store, "db", 0)); image != "db:v1" {
+		t.Errorf("Expected db-0 below the partition to keep db:v1, got %s", image)
+	}
+	if image := podImage(statefulSetPod(store, "db", 2)); image != "db:v2" {
+		t.Errorf("Expected db-2 to run db:v2, got %s", image)
+	}
+	if current, _ := resources.NestedString(statefulSetStatus(store, "db"), "currentRevision"); current != initial {
+		t.Errorf("Expected currentRevision to stay %s during a partitioned update, got %s", initial, current)
+	}
+
+	// Lowering the partition finishes the rollout
+	store.MutateStatefulSet("db", func(set map[string]interface{}) error {
+		set["spec"].(map[string]interface{})["updateStrategy"].(map[string]interface{})["rollingUpdate"] = map[string]interface{}{"partition": 0}
+		return nil
+	})
+	waitFor(t, 3*time.Second, "rollout complete", func() bool {
+		status := statefulSetStatus(store, "db")
+		current, _ := resources.NestedString(status, "currentRevision")
+		update, _ := resources.NestedString(status, "updateRevision")
+		ready, _ := resources.NestedInt64(status, "readyReplicas")
+		return current == update && current != initial && ready == 3
+	})
+	if image := podImage(statefulSetPod(store, "db", 0)); image != "db:v2" {
+		t.Errorf("Expected db-0 to run db:v2, got %s", image)
+	}
+	if n := len(store.ListControllerRevisions()); n != 2 {
+		t.Errorf("Expected 2 ControllerRevisions, got %d", n)
+	}
+}
+
+func TestStatefulSetPartitionWithOrdinalsStart(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	usePodController(t, store)
+	startStatefulSetController(t, store)
+
+	// ordinals 5, 6 and 7; the partition is an ordinal, not an offset from start
+	set := newTestStatefulSet("db", 3, nil)
+	set.Spec.(map[string]interface{})["ordinals"] = map[string]interface{}{"start": float64(5)}
+	if err := store.CreateStatefulSet(set); err != nil {
+		t.Fatalf("Failed to create statefulset: %v", err)
+	}
+	waitFor(t, 3*time.Second, "3 ready pods", func() bool {
+		ready, _ := resources.NestedInt64(statefulSetStatus(store, "db"), "readyReplicas")
+		return ready == 3
+	})
+
+	store.MutateStatefulSet("db", func(set map[string]interface{}) error {
+		spec := set["spec"].(map[string]interface{})
+		spec["updateStrategy"].(map[string]interface{})["rollingUpdate"] = map[string]interface{}{"partition": 6}
+		containers, _ := resources.NestedSlice(spec, "template", "spec", "containers")
+		containers[0].(map[string]interface{})["image"] = "db:v2"
+		return nil
+	})
+	waitFor(t, 3*time.Second, "db-6 and db-7 updated", func() bool {
+		status := statefulSetStatus(store, "db")
+		updated, _ := resources.NestedInt64(status, "updatedReplicas")
+		ready, _ := resources.NestedInt64(status, "readyReplicas")
+		return updated == 2 && ready == 3
+	})
+	for ordinal, want := range map[int]string{5: "db:v1", 6: "db:v2", 7: "db:v2"} {
+		if image := podImage(statefulSetPod(store, "db", ordinal)); image != want {
+			t.Errorf("Expected db-%d to run %s, got %s", ordinal, want, image)
+		}
+	}
+}
+
+func TestStatefulSetOnDelete(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	usePodController(t, store)
+	startStatefulSetController(t, store)
+
+	if err := store.CreateStatefulSet(newTestStatefulSet("db", 2, map[string]interface{}{"type": "OnDelete"})); err != nil {
+		t.Fatalf("Failed to create statefulset: %v", err)
+	}
+	waitFor(t, 3*time.Second, "2 ready pods", func() bool {
+		ready, _ := resources.NestedInt64(statefulSetStatus(store, "db"), "readyReplicas")
+		return ready == 2
+	})
+	store.MutateStatefulSet("db", func(set map[string]interface{}) error {
+		containers, _ := resources.NestedSlice(set, "spec", "template", "spec", "containers")
+		containers[0].(map[string]interface{})["image"] = "db:v2"
+		return nil
+	})
+	waitFor(t, time.Second, "new update revision", func() bool {
+		status := statefulSetStatus(store, "db")
+		current, _ := resources.NestedString(status, "currentRevision")
+		update, _ := resources.NestedString(status, "updateRevision")
+		return current != update
+	})
+	time.Sleep(100 * time.Millisecond)
+	if image := podImage(statefulSetPod(store, "db", 1)); image != "db:v1" {
+		t.Fatalf("Expected OnDelete to leave db-1 on db:v1, got %s", image)
+	}
+
+	// A deleted pod comes back from the update revision
+	deletePodObject(store, "db-1")
+	waitFor(t, 3*time.Second, "db-1 re-created on db:v2", func() bool {
+		pod := statefulSetPod(store, "db", 1)
+		return pod != nil && podImage(pod) == "db:v2" && isRunningAndReady(pod)
+	})
+	if image := podImage(statefulSetPod(store, "db", 0)); image != "db:v1" {
+		t.Errorf("Expected db-0 to stay on db:v1, got %s", image)
+	}
+}
@@ -0,0 +1,320 @@
+package controllers
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+// Pod identity, volume claims and revisions of StatefulSets (stateful_set_utils.go upstream).
+// Pod i of StatefulSet web is web-i; its claim for volumeClaimTemplate data is data-web-i,
+// so a re-created pod gets its old volumes back. Every pod template the set had is kept
+// as a ControllerRevision, and pods are labeled with the revision they were created from.
+
+// Labels the StatefulSet controller puts on its pods.
+const (
+	StatefulSetRevisionLabel = "controller-revision-hash"
+	StatefulSetPodNameLabel  = "statefulset.kubernetes.io/pod-name"
+	PodIndexLabel            = "apps.kubernetes.io/pod-index"
+)
+
+// ordinalOf returns the ordinal of a pod of the named set (-1 if the name doesn't fit)
+func ordinalOf(pod map[string]interface{}, setName string) int {
+	podName, _ := resources.NestedString(pod, "metadata", "name")
+	suffix, ok := strings.CutPrefix(podName, setName+"-")
+	if !ok {
+		return -1
+	}
+	ordinal, err := strconv.Atoi(suffix)
+	if err != nil || ordinal < 0 || strconv.Itoa(ordinal) != suffix {
+		return -1
+	}
+	return ordinal
+}
+
+// podRevision returns the controller-revision-hash label of a pod
+func podRevision(pod map[string]interface{}) string {
+	revision, _ := resources.NestedString(pod, "metadata", "labels", StatefulSetRevisionLabel)
+	return revision
+}
+
+// isRunningAndReady reports whether the pod is Running, Ready and not terminating
+func isRunningAndReady(pod map[string]interface{}) bool {
+	phase, _ := resources.NestedString(pod, "status", "phase")
+	return phase == string(PodRunning) && isPodReady(pod)
+}
+
+// isPodFinished reports whether the pod's containers are done (Succeeded or Failed)
+func isPodFinished(pod map[string]interface{}) bool {
+	phase, _ := resources.NestedString(pod, "status", "phase")
+	return phase == string(PodSucceeded) || phase == string(PodFailed)
+}
+
+// claimName returns the name of the claim of pod ordinal for a volumeClaimTemplate
+func claimName(template map[string]interface{}, setName string, ordinal int) string {
+	templateName, _ := resources.NestedString(template, "metadata", "name")
+	return fmt.Sprintf("%s-%s-%d", templateName, setName, ordinal)
+}
+
+// claimTemplates returns spec.volumeClaimTemplates
+func claimTemplates(set map[string]interface{}) []map[string]interface{} {
+	var templates []map[string]interface{}
+	items, _ := resources.NestedSlice(set, "spec", "volumeClaimTemplates")
+	for _, item := range items {
+		if template, ok := item.(map[string]interface{}); ok {
+			templates = append(templates, template)
+		}
+	}
+	return templates
+}
+
+// newStatefulSetPod builds pod ordinal of the set from the pod template stored in revision
+func newStatefulSetPod(set, revision map[string]interface{}, ordinal int) resources.Pod {
+	setName, _ := resources.NestedString(set, "metadata", "name")
+	revisionName, _ := resources.NestedString(revision, "metadata", "name")
+	podName := fmt.Sprintf("%s-%d", setName, ordinal)
+
+	template := revisionTemplate(revision)
+	pod := newPodFromTemplate(set, "StatefulSet", template, map[string]string{
+		StatefulSetPodNameLabel:  podName,
+		PodIndexLabel:            strconv.Itoa(ordinal),
+		StatefulSetRevisionLabel: revisionName,
+	})
+	// Stable pod names instead of generated ones
+	pod.Metadata.Name, pod.Metadata.GenerateName = podName, ""
+
+	spec, _ := pod.Spec.(map[string]interface{})
+	if spec == nil {
+		spec = map[string]interface{}{}
+	}
+	// Stable network identity: <pod>.<serviceName>
+	spec["hostname"] = podName
+	if serviceName, _ := resources.NestedString(set, "spec", "serviceName"); serviceName != "" {
+		spec["subdomain"] = serviceName
+	}
+	// The pod's claims replace template volumes of the same name
+	var volumes []interface{}
+	claimed := map[string]string{}
+	for _, claim := range claimTemplates(set) {
+		name, _ := resources.NestedString(claim, "metadata", "name")
+		claimed[name] = claimName(claim, setName, ordinal)
+	}
+	existing, _ := resources.NestedSlice(spec, "volumes")
+	for _, v := range existing {
+		if volume, ok := v.(map[string]interface{}); ok && claimed[fmt.Sprint(volume["name"])] == "" {
+			volumes = append(volumes, volume)
+		}
+	}
+	for _, claim := range claimTemplates(set) {
+		name, _ := resources.NestedString(claim, "metadata", "name")
+		volumes = append(volumes, map[string]interface{}{
+			"name":                  name,
+			"persistentVolumeClaim": map[string]interface{}{"claimName": claimed[name]},
+		})
+	}
+	if len(volumes) > 0 {
+		spec["volumes"] = volumes
+	}
+	pod.Spec = spec
+	return pod
+}
+
+// ensureClaims creates the missing claims of pod ordinal. A claim still being deleted can't
+// be reused yet, so the pod has to wait for it.
+func ensureClaims(store *storage.InMemoryStore, set map[string]interface{}, ordinal int) error {
+	setName, _ := resources.NestedString(set, "metadata", "name")
+	selectorLabels, _ := resources.NestedStringMap(set, "spec", "selector", "matchLabels")
+	whenDeleted, _ := resources.NestedString(set, "spec", "persistentVolumeClaimRetentionPolicy", "whenDeleted")
+
+	for _, template := range claimTemplates(set) {
+		name := claimName(template, setName, ordinal)
+		if existing, err := store.GetPersistentVolumeClaim(name); err == nil {
+			if isBeingDeleted(existing) {
+				return fmt.Errorf("PersistentVolumeClaim %s is being deleted", name)
+			}
+			continue
+		}
+		labels, _ := resources.NestedStringMap(template, "metadata", "labels")
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		for k, v := range selectorLabels {
+			labels[k] = v
+		}
+		var spec map[string]interface{}
+		deepCopyJSON(template["spec"], &spec)
+		claim := resources.PersistentVolumeClaim{
+			Kind:       "PersistentVolumeClaim",
+			APIVersion: "v1",
+			Metadata: resources.ObjectMeta{
+				Name:              name,
+				Namespace:         namespaceOf(set),
+				Labels:            labels,
+				CreationTimestamp: time.Now().Format(time.RFC3339),
+			},
+			Spec: spec,
+		}
+		// whenDeleted=Delete: the claim goes with the StatefulSet (garbage collector)
+		if whenDeleted == "Delete" {
+			claim.Metadata.OwnerReferences = []resources.OwnerReference{{
+				APIVersion: "apps/v1",
+				Kind:       "StatefulSet",
+				Name:       setName,
+				UID:        objectUID(set),
+			}}
+		}
+		resources.SetPersistentVolumeClaimDefaults(&claim)
+		if err := store.CreatePersistentVolumeClaim(claim); err != nil {
+			return fmt.Errorf("failed to create PersistentVolumeClaim %s: %w", name, err)
+		}
+		fmt.Printf("[StatefulSet Controller] Created PersistentVolumeClaim %s for %s-%d\n", name, setName, ordinal)
+	}
+	return nil
+}
+
+// releaseClaimsWithPod makes the claims of a condemned pod dependents of the pod, so they are
+// deleted with it (persistentVolumeClaimRetentionPolicy.whenScaled=Delete)
+func releaseClaimsWithPod(store *storage.InMemoryStore, set, pod map[string]interface{}, ordinal int) error {
+	setName, _ := resources.NestedString(set, "metadata", "name")
+	podName, _ := resources.NestedString(pod, "metadata", "name")
+	podUID := objectUID(pod)
+	for _, template := range claimTemplates(set) {
+		_, err := store.MutatePersistentVolumeClaim(claimName(template, setName, ordinal), func(claim map[string]interface{}) error {
+			if isOwnedBy(claim, "Pod", podName, podUID) {
+				return nil
+			}
+			metadata := claim["metadata"].(map[string]interface{})
+			refs := make([]interface{}, 0, len(ownerReferences(claim))+1)
+			for _, ref := range ownerReferences(claim) {
+				refs = append(refs, ref)
+			}
+			metadata["ownerReferences"] = append(refs, map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Pod",
+				"name":       podName,
+				"uid":        podUID,
+			})
+			return nil
+		})
+		if err != nil && !strings.Contains(err.Error(), "not found") {
+			return err
+		}
+	}
+	return nil
+}
+
+// revisionTemplate returns the pod template stored in a ControllerRevision
+func revisionTemplate(revision map[string]interface{}) map[string]interface{} {
+	template, _ := resources.NestedMap(revision, "data", "spec", "template")
+	return template
+}
+
+// revisionNumber returns the revision field of a ControllerRevision
+func revisionNumber(revision map[string]interface{}) int64 {
+	n, _ := resources.NestedInt64(revision, "revision")
+	return n
+}
+
+// listRevisions returns the ControllerRevisions owned by the set, oldest revision first
+func listRevisions(store *storage.InMemoryStore, set map[string]interface{}, kind string) []map[string]interface{} {
+	setName, _ := resources.NestedString(set, "metadata", "name")
+	var revisions []map[string]interface{}
+	for _, item := range store.ListControllerRevisions() {
+		if revision, ok := item.(map[string]interface{}); ok && isOwnedBy(revision, kind, setName, objectUID(set)) {
+			revisions = append(revisions, revision)
+		}
+	}
+	sort.SliceStable(revisions, func(i, j int) bool { return revisionNumber(revisions[i]) < revisionNumber(revisions[j]) })
+	return revisions
+}
+
+// ensureRevision returns the ControllerRevision holding the set's current pod template,
+// creating it with the next revision number. Going back to an older template moves its
+// revision to the front instead (a rollback is a new revision).
+func ensureRevision(store *storage.InMemoryStore, set map[string]interface{}, kind string, revisions []map[string]interface{}) (map[string]interface{}, error) {
+	setName, _ := resources.NestedString(set, "metadata", "name")
+	template, _ := resources.NestedMap(set, "spec", "template")
+	var next int64 = 1
+	if len(revisions) > 0 {
+		next = revisionNumber(revisions[len(revisions)-1]) + 1
+	}
+
+	for i, revision := range revisions {
+		if !reflect.DeepEqual(normalizeTemplate(revisionTemplate(revision)), normalizeTemplate(template)) {
+			continue
+		}
+		if i == len(revisions)-1 {
+			return revision, nil
+		}
+		name, _ := resources.NestedString(revision, "metadata", "name")
+		return store.MutateControllerRevision(name, func(revision map[string]interface{}) error {
+			revision["revision"] = next
+			return nil
+		})
+	}
+
+	var data map[string]interface{}
+	if err := deepCopyJSON(map[string]interface{}{"spec": map[string]interface{}{"template": template}}, &data); err != nil {
+		return nil, err
+	}
+	labels, _ := resources.NestedStringMap(set, "spec", "selector", "matchLabels")
+	name := fmt.Sprintf("%s-%s", setName, computeTemplateHash(template))
+	revision := resources.ControllerRevision{
+		Kind:       "ControllerRevision",
+		APIVersion: "apps/v1",
+		Metadata: resources.ObjectMeta{
+			Name:              name,
+			Namespace:         namespaceOf(set),
+			Labels:            labels,
+			CreationTimestamp: time.Now().Format(time.RFC3339),
+			OwnerReferences: []resources.OwnerReference{{
+				APIVersion:         "apps/v1",
+				Kind:               kind,
+				Name:               setName,
+				UID:                objectUID(set),
+				Controller:         true,
+				BlockOwnerDeletion: true,
+			}},
+		},
+		Data:     data,
+		Revision: next,
+	}
+	if err := store.CreateControllerRevision(revision); err != nil {
+		return nil, fmt.Errorf("failed to create ControllerRevision %s: %w", name, err)
+	}
+	return store.GetControllerRevision(name)
+}
+
+// truncateHistory deletes the oldest revisions no pod uses beyond revisionHistoryLimit
+func truncateHistory(store *storage.InMemoryStore, set map[string]interface{}, revisions, pods []map[string]interface{}, live ...string) error {
+	limit, ok := resources.NestedInt64(set, "spec", "revisionHistoryLimit")
+	if !ok {
+		return nil
+	}
+	inUse := map[string]bool{}
+	for _, name := range live {
+		inUse[name] = true
+	}
+	for _, pod := range pods {
+		inUse[podRevision(pod)] = true
+	}
+	var history []map[string]interface{}
+	for _, revision := range revisions {
+		if name, _ := resources.NestedString(revision, "metadata", "name"); !inUse[name] {
+			history = append(history, revision)
+		}
+	}
+	for i := 0; i < len(history)-int(limit); i++ {
+		name, _ := resources.NestedString(history[i], "metadata", "name")
+		if err := store.DeleteControllerRevision(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
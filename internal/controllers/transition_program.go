@@ -0,0 +1,288 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+)
+
+// Transition programs. Besides the states it applies, a transition sequence can hold control
+// steps: a loop repeats its steps count times (until cancelled without a count), a branch runs
+// one of its step lists picked at random in proportion to their weights, and waitFor blocks
+// until a pod has a phase or condition. Every step first waits its delay plus a random part of
+// its jitter, and a state with stop ends the sequence. Random choices are drawn from the seed
+// of the request (a random one when unset), so a seeded program replays the same way:
+//
+//	transitions:
+//	- {phase: Running, delay: 2s, conditions: [{type: Ready, status: "True"}]}
+//	- waitFor: {pod: database, condition: Ready, timeout: 1m}
+//	- loop:
+//	    count: 10
+//	    steps:
+//	    - {phase: Running, delay: 30s, jitter: 5s, conditions: [{type: Ready, status: "False"}]}
+//	    - {phase: Running, delay: 10s, conditions: [{type: Ready, status: "True"}]}
+//	    - branch:
+//	      - {weight: 9}
+//	      - {weight: 1, steps: [{phase: Failed, delay: 0s, stop: true}]}
+
+// TransitionLoop repeats a list of steps
+type TransitionLoop struct {
+	// Count is the number of iterations; 0 repeats the steps until the transition is cancelled
+	Count int               `json:"count,omitempty"`
+	Steps []TransitionState `json:"steps"`
+}
+
+// TransitionBranch is one of the step lists a branch picks from
+type TransitionBranch struct {
+	Weight int               `json:"weight"`
+	Steps  []TransitionState `json:"steps,omitempty"`
+}
+
+// TransitionWait blocks a sequence until a pod (the transitioned one by default) has the phase
+// and condition; without either it waits for the pod to be Ready
+type TransitionWait struct {
+	Pod       string `json:"pod,omitempty"`
+	Phase     string `json:"phase,omitempty"`
+	Condition string `json:"condition,omitempty"`
+	// Status is the awaited status of the condition, "True" by default
+	Status string `json:"status,omitempty"`
+	// Timeout bounds the wait, after which the sequence goes on; 0 waits until cancelled
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// validateTransitions checks a transition program; path prefixes its errors
+func validateTransitions(steps []TransitionState, path string) error {
+	for i, step := range steps {
+		stepPath := fmt.Sprintf("%s[%d]", path, i)
+		for field, value := range map[string]string{"delay": step.Delay, "jitter": step.Jitter} {
+			if value == "" {
+				continue
+			}
+			if d, err := time.ParseDuration(value); err != nil || d < 0 {
+				return fmt.Errorf("%s.%s: invalid duration %q", stepPath, field, value)
+			}
+		}
+
+		controls := 0
+		if step.Loop != nil {
+			controls++
+		}
+		if len(step.Branch) > 0 {
+			controls++
+		}
+		if step.WaitFor != nil {
+			controls++
+		}
+		if controls > 1 {
+			return fmt.Errorf("%s: only one of loop, branch and waitFor may be set", stepPath)
+		}
+		if controls == 1 && (step.Phase != "" || step.Stop || len(step.Conditions) > 0 || len(step.ContainerStates) > 0) {
+			return fmt.Errorf("%s: a loop, branch or waitFor step cannot set a state", stepPath)
+		}
+
+		switch {
+		case step.Loop != nil:
+			if step.Loop.Count < 0 {
+				return fmt.Errorf("%s.loop.count: must not be negative", stepPath)
+			}
+			if len(step.Loop.Steps) == 0 {
+				return fmt.Errorf("%s.loop: at least one step is required", stepPath)
+			}
+			if err := validateTransitions(step.Loop.Steps, stepPath+".loop.steps"); err != nil {
+				return err
+			}
+			if step.Loop.Count == 0 && minTransitionDuration(step.Loop.Steps) == 0 {
+				return fmt.Errorf("%s.loop: an endless loop needs a delay", stepPath)
+			}
+		case len(step.Branch) > 0:
+			for j, branch := range step.Branch {
+				if branch.Weight <= 0 {
+					return fmt.Errorf("%s.branch[%d].weight: must be positive", stepPath, j)
+				}
+				if err := validateTransitions(branch.Steps, fmt.Sprintf("%s.branch[%d].steps", stepPath, j)); err != nil {
+					return err
+				}
+			}
+		case step.WaitFor != nil:
+			if step.WaitFor.Timeout != "" {
+				if d, err := time.ParseDuration(step.WaitFor.Timeout); err != nil || d < 0 {
+					return fmt.Errorf("%s.waitFor.timeout: invalid duration %q", stepPath, step.WaitFor.Timeout)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// minTransitionDuration returns the shortest time a step list can take
+func minTransitionDuration(steps []TransitionState) time.Duration {
+	var total time.Duration
+	for _, step := range steps {
+		delay, _ := time.ParseDuration(step.Delay)
+		total += delay
+		switch {
+		case step.Loop != nil:
+			iterations := step.Loop.Count
+			if iterations == 0 {
+				iterations = 1
+			}
+			total += time.Duration(iterations) * minTransitionDuration(step.Loop.Steps)
+		case len(step.Branch) > 0:
+			shortest := time.Duration(-1)
+			for _, branch := range step.Branch {
+				if d := minTransitionDuration(branch.Steps); shortest < 0 || d < shortest {
+					shortest = d
+				}
+			}
+			total += shortest
+		}
+	}
+	return total
+}
+
+// runSteps runs a step list; it returns false once the transition is cancelled or stopped, or
+// its pod is gone
+func (tm *TransitionManager) runSteps(ctx context.Context, active *ActiveTransition, steps []TransitionState) bool {
+	for _, step := range steps {
+		if !sleepContext(ctx, active.stepDelay(step)) {
+			return false
+		}
+		switch {
+		case step.Loop != nil:
+			for i := 0; step.Loop.Count == 0 || i < step.Loop.Count; i++ {
+				if !tm.runSteps(ctx, active, step.Loop.Steps) {
+					return false
+				}
+			}
+		case len(step.Branch) > 0:
+			if !tm.runSteps(ctx, active, active.pickBranch(step.Branch).Steps) {
+				return false
+			}
+		case step.WaitFor != nil:
+			if !tm.waitFor(ctx, active, step.WaitFor) {
+				return false
+			}
+		default:
+			if err := tm.applyState(active.Namespace, active.PodName, step); err != nil {
+				return false
+			}
+			if step.Stop {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// sleepContext waits for d; it returns false if ctx is done first
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// stepDelay returns the delay of a step with a random part of its jitter added
+func (active *ActiveTransition) stepDelay(step TransitionState) time.Duration {
+	delay, _ := time.ParseDuration(step.Delay)
+	if jitter, _ := time.ParseDuration(step.Jitter); jitter > 0 {
+		delay += time.Duration(active.rand.Int63n(int64(jitter)))
+	}
+	return delay
+}
+
+// pickBranch picks a branch at random in proportion to the weights
+func (active *ActiveTransition) pickBranch(branches []TransitionBranch) TransitionBranch {
+	total := 0
+	for _, branch := range branches {
+		total += branch.Weight
+	}
+	n := active.rand.Intn(total)
+	for _, branch := range branches {
+		if n < branch.Weight {
+			return branch
+		}
+		n -= branch.Weight
+	}
+	return branches[len(branches)-1]
+}
+
+// waitFor blocks until the awaited pod matches, the timeout passes or the transition is
+// cancelled; it returns false when cancelled
+func (tm *TransitionManager) waitFor(ctx context.Context, active *ActiveTransition, wait *TransitionWait) bool {
+	podName := wait.Pod
+	if podName == "" {
+		podName = active.PodName
+	}
+	// watch before reading the pod so no change in between is missed
+	watcher := tm.store.Watch()
+	defer watcher.Stop()
+	if pod, err := tm.store.GetPod(podName); err == nil && wait.matches(pod) {
+		return true
+	}
+
+	var timeout <-chan time.Time
+	if d, _ := time.ParseDuration(wait.Timeout); d > 0 {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	for {
+		select {
+		case ev, ok := <-watcher.ResultChan():
+			if !ok {
+				return false
+			}
+			if ev.Kind == "Pod" && ev.Name == podName && ev.Type != storage.Deleted && wait.matches(ev.Object) {
+				return true
+			}
+		case <-timeout:
+			fmt.Printf("[Transition Manager] Pod %s stopped waiting for pod %s after %s\n", active.PodName, podName, wait.Timeout)
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// matches reports whether a pod has the awaited phase and condition
+func (wait *TransitionWait) matches(pod map[string]interface{}) bool {
+	if wait.Phase != "" {
+		if phase, _ := resources.NestedString(pod, "status", "phase"); phase != wait.Phase {
+			return false
+		}
+	}
+	condition := wait.Condition
+	if condition == "" && wait.Phase == "" {
+		condition = "Ready"
+	}
+	if condition == "" {
+		return true
+	}
+	status := wait.Status
+	if status == "" {
+		status = "True"
+	}
+	cond := podCondition(pod, condition)
+	return cond != nil && cond["status"] == status
+}
+
+// newTransitionRand returns the random source of a transition and its seed, random when unset
+func newTransitionRand(seed *int64) (*rand.Rand, int64) {
+	s := time.Now().UnixNano()
+	if seed != nil {
+		s = *seed
+	}
+	return rand.New(rand.NewSource(s)), s
+}
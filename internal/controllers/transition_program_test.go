@@ -0,0 +1,185 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"mockernetes/internal/resources"
+	"mockernetes/internal/storage"
+	"sigs.k8s.io/yaml"
+)
+
+// parseTransitions reads a transition program from YAML
+func parseTransitions(t *testing.T, program string) []TransitionState {
+	var steps []TransitionState
+	if err := yaml.UnmarshalStrict([]byte(program), &steps); err != nil {
+		t.Fatalf("Invalid program %q: %v", program, err)
+	}
+	return steps
+}
+
+// recordPhases records the phases a pod goes through until the test ends
+func recordPhases(t *testing.T, store *storage.InMemoryStore, name string) func() []string {
+	watcher := store.Watch()
+	done := make(chan struct{})
+	var phases []string
+	go func() {
+		defer close(done)
+		for ev := range watcher.ResultChan() {
+			if ev.Kind != "Pod" || ev.Name != name {
+				continue
+			}
+			phase, _ := resources.NestedString(ev.Object, "status", "phase")
+			if len(phases) == 0 || phases[len(phases)-1] != phase {
+				phases = append(phases, phase)
+			}
+		}
+	}()
+	return func() []string {
+		watcher.Stop()
+		<-done
+		return phases
+	}
+}
+
+func TestValidateTransitions(t *testing.T) {
+	for _, program := range []string{
+		"[{phase: Running, delay: soon}]",
+		"[{phase: Running, jitter: -1s}]",
+		"[{loop: {steps: [{phase: Running}]}}]",
+		"[{loop: {count: 2, steps: []}}]",
+		"[{loop: {count: 2, steps: [{phase: Running}]}, waitFor: {pod: db}}]",
+		"[{phase: Running, branch: [{weight: 1}]}]",
+		"[{branch: [{weight: 0}]}]",
+		"[{waitFor: {timeout: forever}}]",
+		"[{loop: {count: 1, steps: [{branch: [{weight: 1, steps: [{phase: Running, delay: x}]}]}]}}]",
+	} {
+		if err := validateTransitions(parseTransitions(t, program), "transitions"); err == nil {
+			t.Errorf("Expected %s to be refused", program)
+		}
+	}
+	for _, program := range []string{
+		"[{phase: Running, delay: 1s, jitter: 500ms}]",
+		"[{loop: {steps: [{phase: Running, delay: 0s}, {waitFor: {}}, {phase: Unknown, delay: 1s}]}}]",
+		"[{loop: {steps: [{branch: [{weight: 1, steps: [{phase: Running, delay: 1s}]}, {weight: 2, steps: [{phase: Unknown, delay: 2s}]}]}]}}]",
+	} {
+		if err := validateTransitions(parseTransitions(t, program), "transitions"); err != nil {
+			t.Errorf("Expected %s to be accepted, got %v", program, err)
+		}
+	}
+}
+
+func TestTransitionProgramIsReproducible(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	tm := NewTransitionManager(store)
+	program := parseTransitions(t, `
+- loop:
+    count: 30
+    steps:
+    - branch:
+      - {weight: 1, steps: [{phase: Running, delay: 1ms, jitter: 1ms}]}
+      - {weight: 1, steps: [{phase: Unknown, delay: 1ms}]}
+`)
+
+	run := func(name string, seed int64) []string {
+		if err := store.CreatePod(newTestPod(name, nil, map[string]interface{}{})); err != nil {
+			t.Fatalf("Failed to create pod: %v", err)
+		}
+		phases := recordPhases(t, store, name)
+		active, err := tm.StartTransition(TransitionRequest{PodName: name, Transitions: program, Seed: &seed})
+		if err != nil {
+			t.Fatalf("StartTransition failed: %v", err)
+		}
+		if active.Seed != seed {
+			t.Errorf("Expected seed %d, got %d", seed, active.Seed)
+		}
+		waitFor(t, 5*time.Second, "program done", func() bool {
+			_, running := tm.GetActiveTransition("default", name)
+			return !running
+		})
+		return phases()
+	}
+	first, second := run("first", 42), run("second", 42)
+	if len(first) < 3 || len(first) != len(second) {
+		t.Fatalf("Expected the same flapping for the same seed, got %v and %v", first, second)
+	}
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("Expected the same flapping for the same seed, got %v and %v", first, second)
+		}
+	}
+}
+
+func TestTransitionProgramStopsAndWaits(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	tm := NewTransitionManager(store)
+	for _, name := range []string{"db", "app", "flapping"} {
+		if err := store.CreatePod(newTestPod(name, nil, map[string]interface{}{})); err != nil {
+			t.Fatalf("Failed to create pod: %v", err)
+		}
+	}
+
+	// an endless loop ends with a stop state
+	seed := int64(7)
+	if _, err := tm.StartTransition(TransitionRequest{PodName: "flapping", Seed: &seed, Transitions: parseTransitions(t, `
+- loop:
+    steps:
+    - {phase: Running, delay: 2ms}
+    - branch:
+      - {weight: 3}
+      - {weight: 1, steps: [{phase: Failed, delay: 0s, stop: true}]}
+`)}); err != nil {
+		t.Fatalf("StartTransition failed: %v", err)
+	}
+	waitFor(t, 5*time.Second, "flapping pod failed", func() bool {
+		_, running := tm.GetActiveTransition("default", "flapping")
+		return !running && podPhase(store, "flapping") == "Failed"
+	})
+
+	// app waits for db to be Ready, and for a missing pod until the timeout
+	if _, err := tm.StartTransition(TransitionRequest{PodName: "app", Transitions: parseTransitions(t, `
+- {phase: Pending, delay: 0s}
+- waitFor: {pod: db}
+- {phase: Running, delay: 0s}
+- waitFor: {pod: cache, phase: Running, timeout: 20ms}
+- {phase: Succeeded, delay: 0s}
+`)}); err != nil {
+		t.Fatalf("StartTransition failed: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if phase := podPhase(store, "app"); phase != "Pending" {
+		t.Fatalf("Expected app to wait for db, got %s", phase)
+	}
+	if _, err := tm.StartTransition(TransitionRequest{PodName: "db", Transitions: parseTransitions(t, `
+- {phase: Running, delay: 0s, conditions: [{type: Ready, status: "True"}]}
+`)}); err != nil {
+		t.Fatalf("StartTransition failed: %v", err)
+	}
+	waitFor(t, 5*time.Second, "app succeeded", func() bool {
+		return podPhase(store, "app") == "Succeeded"
+	})
+}
+
+func TestStartTransitionReplacesRunningProgram(t *testing.T) {
+	store := storage.NewInMemoryStore()
+	tm := NewTransitionManager(store)
+	if err := store.CreatePod(newTestPod("web", nil, map[string]interface{}{})); err != nil {
+		t.Fatalf("Failed to create pod: %v", err)
+	}
+	program := parseTransitions(t, "[{loop: {steps: [{phase: Running, delay: 5ms}]}}]")
+	if _, err := tm.StartTransition(TransitionRequest{PodName: "web", Transitions: program}); err != nil {
+		t.Fatalf("StartTransition failed: %v", err)
+	}
+	replacement, err := tm.StartTransition(TransitionRequest{PodName: "web", CancelExisting: true, Transitions: program})
+	if err != nil {
+		t.Fatalf("StartTransition failed: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	// the cancelled program must not untrack its replacement
+	if active, ok := tm.GetActiveTransition("default", "web"); !ok || active != replacement {
+		t.Fatal("Expected the replacement to stay active")
+	}
+	if !tm.CancelTransition("default", "web") {
+		t.Fatal("Expected the replacement to be cancelled")
+	}
+}
@@ -0,0 +1,193 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// Work queue shared by the event-driven controllers (modelled on client-go's workqueue):
+//   - a key is queued at most once however often it is added (dirty set);
+//   - a key being processed is never handed to a second worker (per-key serialization);
+//     adds during processing are replayed once Done is called;
+//   - failed keys are requeued with per-key exponential backoff (AddRateLimited).
+
+// Backoff bounds for AddRateLimited, and the worker count of each controller.
+const (
+	DefaultBaseBackoff = 5 * time.Millisecond
+	DefaultMaxBackoff  = 60 * time.Second
+	DefaultWorkers     = 2
+)
+
+// RateLimitingQueue is a deduplicating, per-key serialized work queue with backoff requeues.
+type RateLimitingQueue struct {
+	mu         sync.Mutex
+	cond       *sync.Cond
+	queue      []string
+	dirty      map[string]bool
+	processing map[string]bool
+	failures   map[string]int
+	shutdown   bool
+
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
+}
+
+// NewRateLimitingQueue creates a queue with the default backoff bounds.
+func NewRateLimitingQueue() *RateLimitingQueue {
+	return NewRateLimitingQueueWithBackoff(DefaultBaseBackoff, DefaultMaxBackoff)
+}
+
+// NewRateLimitingQueueWithBackoff creates a queue whose requeue delay doubles from base up to max.
+func NewRateLimitingQueueWithBackoff(base, max time.Duration) *RateLimitingQueue {
+	q := &RateLimitingQueue{
+		dirty:       make(map[string]bool),
+		processing:  make(map[string]bool),
+		failures:    make(map[string]int),
+		baseBackoff: base,
+		maxBackoff:  max,
+	}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// Add queues key unless it is already waiting.
+func (q *RateLimitingQueue) Add(key string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.shutdown || q.dirty[key] {
+		return
+	}
+	q.dirty[key] = true
+	if q.processing[key] {
+		// picked up again by Done
+		return
+	}
+	q.queue = append(q.queue, key)
+	q.cond.Signal()
+}
+
+// AddAfter queues key once delay has passed.
+func (q *RateLimitingQueue) AddAfter(key string, delay time.Duration) {
+	if delay <= 0 {
+		q.Add(key)
+		return
+	}
+	time.AfterFunc(delay, func() { q.Add(key) })
+}
+
+// AddRateLimited requeues key after its current backoff and bumps the backoff.
+func (q *RateLimitingQueue) AddRateLimited(key string) {
+	q.mu.Lock()
+	exp := q.failures[key]
+	q.failures[key] = exp + 1
+	q.mu.Unlock()
+	q.AddAfter(key, q.backoff(exp))
+}
+
+// backoff returns base*2^exp capped at the maximum.
+func (q *RateLimitingQueue) backoff(exp int) time.Duration {
+	delay := float64(q.baseBackoff) * math.Pow(2, float64(exp))
+	if delay > float64(q.maxBackoff) {
+		return q.maxBackoff
+	}
+	return time.Duration(delay)
+}
+
+// Forget resets the backoff of key (call after a successful sync).
+func (q *RateLimitingQueue) Forget(key string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.failures, key)
+}
+
+// NumRequeues returns how many times key has been rate-limited since the last Forget.
+func (q *RateLimitingQueue) NumRequeues(key string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.failures[key]
+}
+
+// Get blocks until a key is available; shutdown is true once the queue is shut down.
+func (q *RateLimitingQueue) Get() (key string, shutdown bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.queue) == 0 && !q.shutdown {
+		q.cond.Wait()
+	}
+	if len(q.queue) == 0 {
+		return "", true
+	}
+	key = q.queue[0]
+	q.queue = q.queue[1:]
+	delete(q.dirty, key)
+	q.processing[key] = true
+	return key, false
+}
+
+// Done marks key as processed, queueing it again if it was added meanwhile.
+func (q *RateLimitingQueue) Done(key string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.processing, key)
+	if q.shutdown {
+		q.cond.Broadcast()
+		return
+	}
+	if q.dirty[key] {
+		q.queue = append(q.queue, key)
+		q.cond.Signal()
+	}
+}
+
+// Len returns the number of keys waiting.
+func (q *RateLimitingQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.queue)
+}
+
+// ShutDown stops handing out keys; blocked Get calls return shutdown=true.
+func (q *RateLimitingQueue) ShutDown() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.shutdown = true
+	q.cond.Broadcast()
+}
+
+// ShutDownAndWait shuts the queue down, drops the keys still waiting and blocks until the
+// keys being processed are Done, so no sync runs once it returns.
+func (q *RateLimitingQueue) ShutDownAndWait() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.shutdown = true
+	q.queue = nil
+	q.dirty = make(map[string]bool)
+	q.cond.Broadcast()
+	for len(q.processing) > 0 {
+		q.cond.Wait()
+	}
+}
+
+// runWorkers processes keys from q with sync on n goroutines until the queue shuts down.
+// A failed sync is requeued with backoff; success resets it.
+func runWorkers(q *RateLimitingQueue, n int, name string, sync func(key string) error) {
+	for i := 0; i < n; i++ {
+		go func() {
+			for {
+				key, shutdown := q.Get()
+				if shutdown {
+					return
+				}
+				if err := sync(key); err != nil {
+					fmt.Printf("[%s] Error syncing %s (retry %d): %v\n", name, key, q.NumRequeues(key)+1, err)
+					q.AddRateLimited(key)
+				} else {
+					q.Forget(key)
+				}
+				q.Done(key)
+			}
+		}()
+	}
+}
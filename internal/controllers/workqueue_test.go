@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWorkQueueDeduplicatesAndSerializes(t *testing.T) {
+	q := NewRateLimitingQueue()
+	q.Add("default/web")
+	q.Add("default/web")
+	if q.Len() != 1 {
+		t.Fatalf("Expected 1 queued key, got %d", q.Len())
+	}
+
+	key, _ := q.Get()
+	// Re-added while processing: held back until Done
+	q.Add(key)
+	if q.Len() != 0 {
+		t.Errorf("Expected key being processed not to be handed out again, queue len %d", q.Len())
+	}
+	q.Done(key)
+	if q.Len() != 1 {
+		t.Errorf("Expected key to be requeued after Done, queue len %d", q.Len())
+	}
+	q.ShutDown()
+}
+
+func TestWorkQueueBackoff(t *testing.T) {
+	q := NewRateLimitingQueueWithBackoff(10*time.Millisecond, 40*time.Millisecond)
+	for i, want := range []time.Duration{10, 20, 40, 40} {
+		if got := q.backoff(i); got != want*time.Millisecond {
+			t.Errorf("backoff(%d) = %v, want %v", i, got, want*time.Millisecond)
+		}
+	}
+
+	q.AddRateLimited("default/web")
+	q.AddRateLimited("default/web")
+	if n := q.NumRequeues("default/web"); n != 2 {
+		t.Errorf("Expected 2 requeues, got %d", n)
+	}
+	q.Forget("default/web")
+	if n := q.NumRequeues("default/web"); n != 0 {
+		t.Errorf("Expected requeues to reset after Forget, got %d", n)
+	}
+	q.ShutDown()
+}
+
+func TestRunWorkersRetriesFailures(t *testing.T) {
+	q := NewRateLimitingQueueWithBackoff(time.Millisecond, 10*time.Millisecond)
+	defer q.ShutDown()
+
+	var attempts int32
+	var wg sync.WaitGroup
+	wg.Add(1)
+	runWorkers(q, 2, "Test", func(key string) error {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			return errTestSync
+		}
+		wg.Done()
+		return nil
+	})
+	q.Add("default/web")
+
+	done := make(chan struct{})
+	go func() { wg.Wait(); close(done) }()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected sync to succeed after retries, attempts=%d", atomic.LoadInt32(&attempts))
+	}
+	// Forget runs right after the successful sync returns
+	deadline := time.Now().Add(time.Second)
+	for q.NumRequeues("default/web") != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := q.NumRequeues("default/web"); n != 0 {
+		t.Errorf("Expected backoff to be forgotten after success, got %d", n)
+	}
+}
+
+var errTestSync = errors.New("sync failed")
@@ -0,0 +1,468 @@
+package resources
+
+import (
+	"strings"
+	"time"
+)
+
+// Defaulting applied by the API layer on create/update, mirroring the upstream
+// SetDefaults_* funcs (k8s.io/kubernetes/pkg/apis/{core,apps}/v1/defaults.go) so that
+// GET output matches what a real apiserver stores.
+// Numbers are float64 to match json-decoded maps the controllers read back.
+
+// Upstream default values.
+const (
+	DefaultTerminationGracePeriodSeconds = 30
+	DefaultRevisionHistoryLimit          = 10
+	DefaultProgressDeadlineSeconds       = 600
+	DefaultTolerationSeconds             = 300
+	DefaultSchedulerName                 = "default-scheduler"
+	DefaultMaxSurge                      = "25%"
+	DefaultMaxUnavailable                = "25%"
+	DefaultBackoffLimit                  = 6
+	DefaultSuccessfulJobsHistoryLimit    = 3
+	DefaultFailedJobsHistoryLimit        = 1
+)
+
+// Labels the Job registry puts on a Job's pods (and selects them by) unless manualSelector is set.
+const (
+	JobControllerUIDLabel       = "batch.kubernetes.io/controller-uid"
+	JobNameLabel                = "batch.kubernetes.io/job-name"
+	LegacyJobControllerUIDLabel = "controller-uid"
+	LegacyJobNameLabel          = "job-name"
+)
+
+// SetNamespaceDefaults adds the "kubernetes" finalizer and Active phase (namespace strategy).
+func SetNamespaceDefaults(ns *Namespace) {
+	spec, _ := ns.Spec.(map[string]interface{})
+	if spec == nil {
+		spec = map[string]interface{}{}
+		ns.Spec = spec
+	}
+	if _, ok := spec["finalizers"]; !ok {
+		spec["finalizers"] = []interface{}{"kubernetes"}
+	}
+	status, _ := ns.Status.(map[string]interface{})
+	if status == nil {
+		status = map[string]interface{}{}
+		ns.Status = status
+	}
+	setDefault(status, "phase", "Active")
+}
+
+// SetPodDefaults defaults a Pod's spec, as defaulting and admission do on creation.
+func SetPodDefaults(pod *Pod) {
+	if spec, ok := pod.Spec.(map[string]interface{}); ok {
+		SetPodSpecDefaults(spec)
+		// ServiceAccount and DefaultTolerationSeconds admission plugins, which only admit
+		// pods: workload templates don't get them
+		setDefault(spec, "serviceAccountName", "default")
+		setDefault(spec, "serviceAccount", spec["serviceAccountName"])
+		spec["tolerations"] = defaultTolerations(spec["tolerations"])
+	}
+}
+
+// SetReplicaSetDefaults defaults replicas and the pod template.
+func SetReplicaSetDefaults(rs *ReplicaSet) {
+	spec, ok := rs.Spec.(map[string]interface{})
+	if !ok {
+		return
+	}
+	setDefault(spec, "replicas", float64(1))
+	setPodTemplateDefaults(spec)
+}
+
+// SetDeploymentDefaults defaults replicas, strategy, history/progress limits and the pod template.
+func SetDeploymentDefaults(deploy *Deployment) {
+	spec, ok := deploy.Spec.(map[string]interface{})
+	if !ok {
+		return
+	}
+	setDefault(spec, "replicas", float64(1))
+	setDefault(spec, "revisionHistoryLimit", float64(DefaultRevisionHistoryLimit))
+	setDefault(spec, "progressDeadlineSeconds", float64(DefaultProgressDeadlineSeconds))
+
+	strategy, _ := spec["strategy"].(map[string]interface{})
+	if strategy == nil {
+		strategy = map[string]interface{}{}
+		spec["strategy"] = strategy
+	}
+	setDefault(strategy, "type", "RollingUpdate")
+	if strategy["type"] == "RollingUpdate" {
+		rollingUpdate, _ := strategy["rollingUpdate"].(map[string]interface{})
+		if rollingUpdate == nil {
+			rollingUpdate = map[string]interface{}{}
+			strategy["rollingUpdate"] = rollingUpdate
+		}
+		setDefault(rollingUpdate, "maxUnavailable", DefaultMaxUnavailable)
+		setDefault(rollingUpdate, "maxSurge", DefaultMaxSurge)
+	}
+	setPodTemplateDefaults(spec)
+}
+
+// SetStatefulSetDefaults defaults replicas, pod management, update strategy, history,
+// PVC retention and the pod and volume claim templates.
+func SetStatefulSetDefaults(sts *StatefulSet) {
+	spec, ok := sts.Spec.(map[string]interface{})
+	if !ok {
+		return
+	}
+	setDefault(spec, "replicas", float64(1))
+	setDefault(spec, "podManagementPolicy", "OrderedReady")
+	setDefault(spec, "revisionHistoryLimit", float64(DefaultRevisionHistoryLimit))
+
+	strategy, _ := spec["updateStrategy"].(map[string]interface{})
+	if strategy == nil {
+		strategy = map[string]interface{}{}
+		spec["updateStrategy"] = strategy
+	}
+	setDefault(strategy, "type", "RollingUpdate")
+	if strategy["type"] == "RollingUpdate" {
+		rollingUpdate, _ := strategy["rollingUpdate"].(map[string]interface{})
+		if rollingUpdate == nil {
+			rollingUpdate = map[string]interface{}{}
+			strategy["rollingUpdate"] = rollingUpdate
+		}
+		setDefault(rollingUpdate, "partition", float64(0))
+	}
+
+	retention, _ := spec["persistentVolumeClaimRetentionPolicy"].(map[string]interface{})
+	if retention == nil {
+		retention = map[string]interface{}{}
+		spec["persistentVolumeClaimRetentionPolicy"] = retention
+	}
+	setDefault(retention, "whenDeleted", "Retain")
+	setDefault(retention, "whenScaled", "Retain")
+
+	if templates, ok := NestedSlice(spec, "volumeClaimTemplates"); ok {
+		for _, t := range templates {
+			if template, ok := t.(map[string]interface{}); ok {
+				setDefault(template, "apiVersion", "v1")
+				setDefault(template, "kind", "PersistentVolumeClaim")
+				if claimSpec, ok := template["spec"].(map[string]interface{}); ok {
+					setDefault(claimSpec, "volumeMode", "Filesystem")
+				}
+			}
+		}
+	}
+	setPodTemplateDefaults(spec)
+}
+
+// SetDaemonSetDefaults defaults the update strategy, history and the pod template.
+func SetDaemonSetDefaults(ds *DaemonSet) {
+	spec, ok := ds.Spec.(map[string]interface{})
+	if !ok {
+		return
+	}
+	setDefault(spec, "revisionHistoryLimit", float64(DefaultRevisionHistoryLimit))
+
+	strategy, _ := spec["updateStrategy"].(map[string]interface{})
+	if strategy == nil {
+		strategy = map[string]interface{}{}
+		spec["updateStrategy"] = strategy
+	}
+	setDefault(strategy, "type", "RollingUpdate")
+	if strategy["type"] == "RollingUpdate" {
+		rollingUpdate, _ := strategy["rollingUpdate"].(map[string]interface{})
+		if rollingUpdate == nil {
+			rollingUpdate = map[string]interface{}{}
+			strategy["rollingUpdate"] = rollingUpdate
+		}
+		setDefault(rollingUpdate, "maxUnavailable", float64(1))
+		setDefault(rollingUpdate, "maxSurge", float64(0))
+	}
+	setPodTemplateDefaults(spec)
+}
+
+// SetJobDefaults defaults completions/parallelism, the backoff limit, completion mode and
+// pod replacement, and generates the selector from metadata.uid (which the caller assigns)
+// unless spec.manualSelector is set. The API validates restartPolicy (Never or OnFailure) first.
+func SetJobDefaults(job *Job) {
+	spec, ok := job.Spec.(map[string]interface{})
+	if !ok {
+		return
+	}
+	_, hasCompletions := spec["completions"]
+	_, hasParallelism := spec["parallelism"]
+	if !hasCompletions && !hasParallelism {
+		spec["completions"] = float64(1)
+	}
+	setDefault(spec, "parallelism", float64(1))
+	setDefault(spec, "backoffLimit", float64(DefaultBackoffLimit))
+	setDefault(spec, "completionMode", "NonIndexed")
+	setDefault(spec, "suspend", false)
+	if _, ok := spec["podFailurePolicy"]; ok {
+		setDefault(spec, "podReplacementPolicy", "Failed")
+	} else {
+		setDefault(spec, "podReplacementPolicy", "TerminatingOrFailed")
+	}
+
+	template, _ := spec["template"].(map[string]interface{})
+	if manual, _ := spec["manualSelector"].(bool); !manual && template != nil && job.Metadata.UID != "" {
+		metadata, _ := template["metadata"].(map[string]interface{})
+		if metadata == nil {
+			metadata = map[string]interface{}{}
+			template["metadata"] = metadata
+		}
+		labels, _ := metadata["labels"].(map[string]interface{})
+		if labels == nil {
+			labels = map[string]interface{}{}
+			metadata["labels"] = labels
+		}
+		labels[JobControllerUIDLabel] = job.Metadata.UID
+		labels[JobNameLabel] = job.Metadata.Name
+		labels[LegacyJobControllerUIDLabel] = job.Metadata.UID
+		labels[LegacyJobNameLabel] = job.Metadata.Name
+		spec["selector"] = map[string]interface{}{
+			"matchLabels": map[string]interface{}{JobControllerUIDLabel: job.Metadata.UID},
+		}
+	}
+	setPodTemplateDefaults(spec)
+}
+
+// SetCronJobDefaults defaults the concurrency policy, history limits and the job's pod template.
+func SetCronJobDefaults(cj *CronJob) {
+	spec, ok := cj.Spec.(map[string]interface{})
+	if !ok {
+		return
+	}
+	setDefault(spec, "concurrencyPolicy", "Allow")
+	setDefault(spec, "suspend", false)
+	setDefault(spec, "successfulJobsHistoryLimit", float64(DefaultSuccessfulJobsHistoryLimit))
+	setDefault(spec, "failedJobsHistoryLimit", float64(DefaultFailedJobsHistoryLimit))
+	if jobSpec, ok := NestedMap(spec, "jobTemplate", "spec"); ok {
+		setPodTemplateDefaults(jobSpec)
+	}
+}
+
+// SetPersistentVolumeClaimDefaults defaults the volume mode. Claims are bound as soon as they
+// are created (there is no storage behind them), so status reports the requested capacity.
+func SetPersistentVolumeClaimDefaults(pvc *PersistentVolumeClaim) {
+	spec, ok := pvc.Spec.(map[string]interface{})
+	if !ok {
+		return
+	}
+	setDefault(spec, "volumeMode", "Filesystem")
+	if pvc.Status == nil {
+		status := map[string]interface{}{"phase": "Bound"}
+		if modes, ok := spec["accessModes"]; ok {
+			status["accessModes"] = modes
+		}
+		if requests, ok := NestedMap(spec, "resources", "requests"); ok {
+			status["capacity"] = requests
+		}
+		pvc.Status = status
+	}
+}
+
+// SetServiceDefaults defaults the type, session affinity, traffic policies, port protocols and
+// targetPorts (a targetPort defaults to the port). Cluster IPs, IP families and node ports are
+// allocated separately.
+func SetServiceDefaults(svc *Service) {
+	spec, ok := svc.Spec.(map[string]interface{})
+	if !ok {
+		return
+	}
+	setDefault(spec, "type", "ClusterIP")
+	setDefault(spec, "sessionAffinity", "None")
+	switch spec["type"] {
+	case "ExternalName":
+	case "NodePort", "LoadBalancer":
+		setDefault(spec, "externalTrafficPolicy", "Cluster")
+		fallthrough
+	default:
+		setDefault(spec, "internalTrafficPolicy", "Cluster")
+	}
+	ports, _ := spec["ports"].([]interface{})
+	for _, item := range ports {
+		if port, ok := item.(map[string]interface{}); ok {
+			setDefault(port, "protocol", "TCP")
+			if port["port"] != nil {
+				setDefault(port, "targetPort", port["port"])
+			}
+		}
+	}
+	if svc.Status == nil {
+		svc.Status = map[string]interface{}{"loadBalancer": map[string]interface{}{}}
+	}
+}
+
+// Well-known labels the kubelet puts on its Node.
+const (
+	LabelHostname = "kubernetes.io/hostname"
+	LabelOS       = "kubernetes.io/os"
+	LabelArch     = "kubernetes.io/arch"
+)
+
+// SetNodeDefaults labels a node like a kubelet registering it would, and reports a node
+// without status as a Ready node with the usual capacity. Allocatable defaults to capacity.
+func SetNodeDefaults(node *Node) {
+	if node.Metadata.Labels == nil {
+		node.Metadata.Labels = map[string]string{}
+	}
+	labels := node.Metadata.Labels
+	if _, ok := labels[LabelHostname]; !ok && node.Metadata.Name != "" {
+		labels[LabelHostname] = node.Metadata.Name
+	}
+	if _, ok := labels[LabelOS]; !ok {
+		labels[LabelOS] = "linux"
+	}
+	if _, ok := labels[LabelArch]; !ok {
+		labels[LabelArch] = "amd64"
+	}
+
+	if status, ok := node.Status.(map[string]interface{}); ok {
+		if capacity, ok := status["capacity"]; ok {
+			setDefault(status, "allocatable", capacity)
+		}
+		return
+	}
+	if node.Status != nil {
+		return
+	}
+	capacity := map[string]interface{}{"cpu": "4", "memory": "16Gi", "pods": "110"}
+	node.Status = map[string]interface{}{
+		"capacity":    capacity,
+		"allocatable": capacity,
+		"conditions": []interface{}{map[string]interface{}{
+			"type":               "Ready",
+			"status":             "True",
+			"reason":             "KubeletReady",
+			"message":            "kubelet is posting ready status",
+			"lastTransitionTime": time.Now().UTC().Format(time.RFC3339),
+		}},
+	}
+}
+
+// setPodTemplateDefaults defaults spec.template.spec of a workload.
+func setPodTemplateDefaults(workloadSpec map[string]interface{}) {
+	if podSpec, ok := NestedMap(workloadSpec, "template", "spec"); ok {
+		SetPodSpecDefaults(podSpec)
+	}
+}
+
+// SetPodSpecDefaults defaults a pod spec map in place (also used for templates).
+func SetPodSpecDefaults(spec map[string]interface{}) {
+	setDefault(spec, "restartPolicy", "Always")
+	setDefault(spec, "terminationGracePeriodSeconds", float64(DefaultTerminationGracePeriodSeconds))
+	setDefault(spec, "dnsPolicy", "ClusterFirst")
+	setDefault(spec, "schedulerName", DefaultSchedulerName)
+	setDefault(spec, "securityContext", map[string]interface{}{})
+	setDefault(spec, "enableServiceLinks", true)
+
+	for _, field := range []string{"initContainers", "containers", "ephemeralContainers"} {
+		if containers, ok := NestedSlice(spec, field); ok {
+			for _, c := range containers {
+				if container, ok := c.(map[string]interface{}); ok {
+					setContainerDefaults(container)
+				}
+			}
+		}
+	}
+	if volumes, ok := NestedSlice(spec, "volumes"); ok {
+		for _, v := range volumes {
+			if volume, ok := v.(map[string]interface{}); ok {
+				setVolumeDefaults(volume)
+			}
+		}
+	}
+}
+
+// setContainerDefaults defaults pull policy, termination message, ports, env and probes.
+func setContainerDefaults(container map[string]interface{}) {
+	image, _ := container["image"].(string)
+	setDefault(container, "imagePullPolicy", DefaultImagePullPolicy(image))
+	setDefault(container, "terminationMessagePath", "/dev/termination-log")
+	setDefault(container, "terminationMessagePolicy", "File")
+	setDefault(container, "resources", map[string]interface{}{})
+
+	if ports, ok := NestedSlice(container, "ports"); ok {
+		for _, p := range ports {
+			if port, ok := p.(map[string]interface{}); ok {
+				setDefault(port, "protocol", "TCP")
+			}
+		}
+	}
+	if env, ok := NestedSlice(container, "env"); ok {
+		for _, e := range env {
+			envVar, _ := e.(map[string]interface{})
+			if fieldRef, ok := NestedMap(envVar, "valueFrom", "fieldRef"); ok {
+				setDefault(fieldRef, "apiVersion", "v1")
+			}
+		}
+	}
+	for _, name := range []string{"livenessProbe", "readinessProbe", "startupProbe"} {
+		if probe, ok := container[name].(map[string]interface{}); ok {
+			setProbeDefaults(probe)
+		}
+	}
+}
+
+// setProbeDefaults defaults probe timings and the httpGet scheme.
+func setProbeDefaults(probe map[string]interface{}) {
+	setDefault(probe, "timeoutSeconds", float64(1))
+	setDefault(probe, "periodSeconds", float64(10))
+	setDefault(probe, "successThreshold", float64(1))
+	setDefault(probe, "failureThreshold", float64(3))
+	if httpGet, ok := probe["httpGet"].(map[string]interface{}); ok {
+		setDefault(httpGet, "path", "/")
+		setDefault(httpGet, "scheme", "HTTP")
+	}
+}
+
+// setVolumeDefaults defaults file modes of projected sources (0644 like upstream).
+func setVolumeDefaults(volume map[string]interface{}) {
+	for _, source := range []string{"configMap", "secret", "projected", "downwardAPI"} {
+		if src, ok := volume[source].(map[string]interface{}); ok {
+			setDefault(src, "defaultMode", float64(420))
+		}
+	}
+	if emptyDir, ok := volume["emptyDir"]; ok && emptyDir == nil {
+		volume["emptyDir"] = map[string]interface{}{}
+	}
+}
+
+// defaultTolerations appends the not-ready/unreachable NoExecute tolerations
+// added by the DefaultTolerationSeconds admission plugin unless already present.
+func defaultTolerations(raw interface{}) []interface{} {
+	tolerations, _ := raw.([]interface{})
+	for _, key := range []string{"node.kubernetes.io/not-ready", "node.kubernetes.io/unreachable"} {
+		found := false
+		for _, t := range tolerations {
+			if tm, ok := t.(map[string]interface{}); ok && tm["key"] == key {
+				found = true
+				break
+			}
+		}
+		if !found {
+			tolerations = append(tolerations, map[string]interface{}{
+				"key":               key,
+				"operator":          "Exists",
+				"effect":            "NoExecute",
+				"tolerationSeconds": float64(DefaultTolerationSeconds),
+			})
+		}
+	}
+	return tolerations
+}
+
+// DefaultImagePullPolicy returns Always for ":latest" or untagged images, IfNotPresent otherwise.
+func DefaultImagePullPolicy(image string) string {
+	if strings.Contains(image, "@") {
+		return "IfNotPresent"
+	}
+	// tag separator must come after the last path component (registry may have a port)
+	lastSlash := strings.LastIndex(image, "/")
+	tagIdx := strings.LastIndex(image, ":")
+	if tagIdx <= lastSlash || image[tagIdx+1:] == "latest" {
+		return "Always"
+	}
+	return "IfNotPresent"
+}
+
+// setDefault sets m[key] only when it's absent.
+func setDefault(m map[string]interface{}, key string, value interface{}) {
+	if _, ok := m[key]; !ok {
+		m[key] = value
+	}
+}
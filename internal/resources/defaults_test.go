@@ -0,0 +1,98 @@
+package resources
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSetDeploymentDefaults(t *testing.T) {
+	var deploy Deployment
+	body := `{"kind":"Deployment","apiVersion":"apps/v1","metadata":{"name":"web"},
+		"spec":{"selector":{"matchLabels":{"app":"web"}},"template":{"metadata":{"labels":{"app":"web"}},
+		"spec":{"containers":[{"name":"nginx","image":"nginx:1.25","ports":[{"containerPort":80}]}]}}}}`
+	if err := json.Unmarshal([]byte(body), &deploy); err != nil {
+		t.Fatalf("Failed to unmarshal deployment: %v", err)
+	}
+
+	SetDeploymentDefaults(&deploy)
+
+	spec := deploy.Spec.(map[string]interface{})
+	if replicas, _ := NestedInt64(spec, "replicas"); replicas != 1 {
+		t.Errorf("Expected replicas 1, got %d", replicas)
+	}
+	if limit, _ := NestedInt64(spec, "revisionHistoryLimit"); limit != 10 {
+		t.Errorf("Expected revisionHistoryLimit 10, got %d", limit)
+	}
+	if deadline, _ := NestedInt64(spec, "progressDeadlineSeconds"); deadline != 600 {
+		t.Errorf("Expected progressDeadlineSeconds 600, got %d", deadline)
+	}
+	if maxSurge, _ := NestedString(spec, "strategy", "rollingUpdate", "maxSurge"); maxSurge != "25%" {
+		t.Errorf("Expected maxSurge 25%%, got %q", maxSurge)
+	}
+
+	podSpec, _ := NestedMap(spec, "template", "spec")
+	if podSpec["restartPolicy"] != "Always" || podSpec["dnsPolicy"] != "ClusterFirst" {
+		t.Errorf("Expected restartPolicy Always and dnsPolicy ClusterFirst, got %v/%v", podSpec["restartPolicy"], podSpec["dnsPolicy"])
+	}
+	if grace, _ := NestedInt64(podSpec, "terminationGracePeriodSeconds"); grace != 30 {
+		t.Errorf("Expected terminationGracePeriodSeconds 30, got %d", grace)
+	}
+
+	containers, _ := NestedSlice(podSpec, "containers")
+	container := containers[0].(map[string]interface{})
+	if container["imagePullPolicy"] != "IfNotPresent" {
+		t.Errorf("Expected imagePullPolicy IfNotPresent, got %v", container["imagePullPolicy"])
+	}
+	ports, _ := NestedSlice(container, "ports")
+	if ports[0].(map[string]interface{})["protocol"] != "TCP" {
+		t.Errorf("Expected port protocol TCP, got %v", ports[0])
+	}
+}
+
+func TestSetDefaultsKeepsUserValues(t *testing.T) {
+	spec := map[string]interface{}{
+		"restartPolicy": "Never",
+		"containers": []interface{}{
+			map[string]interface{}{"name": "app", "image": "busybox", "imagePullPolicy": "Never"},
+		},
+	}
+
+	SetPodSpecDefaults(spec)
+
+	if spec["restartPolicy"] != "Never" {
+		t.Errorf("Expected restartPolicy Never to be kept, got %v", spec["restartPolicy"])
+	}
+	container := spec["containers"].([]interface{})[0].(map[string]interface{})
+	if container["imagePullPolicy"] != "Never" {
+		t.Errorf("Expected imagePullPolicy Never to be kept, got %v", container["imagePullPolicy"])
+	}
+	// admission defaults are for pods only, not for workload templates
+	if spec["tolerations"] != nil || spec["serviceAccountName"] != nil {
+		t.Errorf("Expected no admission defaults on a pod spec, got %v", spec)
+	}
+
+	pod := &Pod{Spec: spec}
+	SetPodDefaults(pod)
+	if tolerations := spec["tolerations"].([]interface{}); len(tolerations) != 2 {
+		t.Errorf("Expected 2 default tolerations, got %d", len(tolerations))
+	}
+	if spec["serviceAccountName"] != "default" || spec["serviceAccount"] != "default" {
+		t.Errorf("Expected the default service account, got %v/%v", spec["serviceAccountName"], spec["serviceAccount"])
+	}
+}
+
+func TestDefaultImagePullPolicy(t *testing.T) {
+	cases := map[string]string{
+		"nginx":                         "Always",
+		"nginx:latest":                  "Always",
+		"nginx:1.25":                    "IfNotPresent",
+		"registry:5000/team/app":        "Always",
+		"registry:5000/team/app:v2":     "IfNotPresent",
+		"nginx@sha256:0123456789abcdef": "IfNotPresent",
+	}
+	for image, want := range cases {
+		if got := DefaultImagePullPolicy(image); got != want {
+			t.Errorf("DefaultImagePullPolicy(%q) = %s, want %s", image, got, want)
+		}
+	}
+}
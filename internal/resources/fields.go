@@ -0,0 +1,121 @@
+package resources
+
+// Accessors for the map[string]interface{} specs carried by the custom structs.
+// Values may come straight from json.Unmarshal (float64 numbers, []interface{} lists)
+// or be built in Go by the controllers (int32/int64, typed maps), so both are accepted.
+
+// NestedMap walks fields and returns the map found there (nil, false if missing).
+func NestedMap(obj map[string]interface{}, fields ...string) (map[string]interface{}, bool) {
+	cur := obj
+	for _, f := range fields {
+		next, ok := cur[f].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		cur = next
+	}
+	return cur, cur != nil
+}
+
+// NestedString returns the string at the given path ("" if missing).
+func NestedString(obj map[string]interface{}, fields ...string) (string, bool) {
+	if len(fields) == 0 {
+		return "", false
+	}
+	parent, ok := NestedMap(obj, fields[:len(fields)-1]...)
+	if !ok {
+		return "", false
+	}
+	s, ok := parent[fields[len(fields)-1]].(string)
+	return s, ok
+}
+
+// NestedInt64 returns the number at the given path, whatever its Go numeric type.
+func NestedInt64(obj map[string]interface{}, fields ...string) (int64, bool) {
+	if len(fields) == 0 {
+		return 0, false
+	}
+	parent, ok := NestedMap(obj, fields[:len(fields)-1]...)
+	if !ok {
+		return 0, false
+	}
+	return ToInt64(parent[fields[len(fields)-1]])
+}
+
+// NestedSlice returns the list at the given path.
+func NestedSlice(obj map[string]interface{}, fields ...string) ([]interface{}, bool) {
+	if len(fields) == 0 {
+		return nil, false
+	}
+	parent, ok := NestedMap(obj, fields[:len(fields)-1]...)
+	if !ok {
+		return nil, false
+	}
+	switch v := parent[fields[len(fields)-1]].(type) {
+	case []interface{}:
+		return v, true
+	case []map[string]interface{}:
+		out := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			out = append(out, item)
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+// NestedStringMap returns a map[string]string (labels, selectors) at the given path.
+func NestedStringMap(obj map[string]interface{}, fields ...string) (map[string]string, bool) {
+	if len(fields) == 0 {
+		return nil, false
+	}
+	parent, ok := NestedMap(obj, fields[:len(fields)-1]...)
+	if !ok {
+		return nil, false
+	}
+	switch v := parent[fields[len(fields)-1]].(type) {
+	case map[string]string:
+		return v, true
+	case map[string]interface{}:
+		out := make(map[string]string, len(v))
+		for k, val := range v {
+			if s, ok := val.(string); ok {
+				out[k] = s
+			}
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+// ToInt64 converts the numeric types found in spec maps to int64.
+func ToInt64(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return int64(n), true
+	case float32:
+		return int64(n), true
+	case int:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	}
+	return 0, false
+}
+
+// NestedStringSlice returns a []string (selector values, args) at the given path.
+func NestedStringSlice(obj map[string]interface{}, fields ...string) ([]string, bool) {
+	items, ok := NestedSlice(obj, fields...)
+	if !ok {
+		return nil, false
+	}
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out, true
+}
@@ -12,6 +12,12 @@ type KubeObject interface {
 	GetKind() string // e.g., "Pod" (GetKind to avoid field conflict)
 }
 
+// NameSetter is implemented by pointers to the custom structs so storage can
+// write back a name generated from metadata.generateName.
+type NameSetter interface {
+	SetName(name string)
+}
+
 // OwnerReference represents a reference to an owning object
 type OwnerReference struct {
 	APIVersion         string `json:"apiVersion"`
@@ -25,12 +31,19 @@ type OwnerReference struct {
 // ObjectMeta shared for custom resource structs.
 type ObjectMeta struct {
 	Name              string            `json:"name"`
+	UID               string            `json:"uid,omitempty"`
+	GenerateName      string            `json:"generateName,omitempty"`
 	Namespace         string            `json:"namespace,omitempty"`
 	Labels            map[string]string `json:"labels,omitempty"`
 	Annotations       map[string]string `json:"annotations,omitempty"`
 	CreationTimestamp string            `json:"creationTimestamp,omitempty"`
+	Generation        int64             `json:"generation,omitempty"`
 	OwnerReferences   []OwnerReference  `json:"ownerReferences,omitempty"`
-	// TODO: uid, resourceVersion for full mock
+	Finalizers        []string          `json:"finalizers,omitempty"`
+	// Set by graceful deletion: when the object will be removed and the grace it was given.
+	DeletionTimestamp          string `json:"deletionTimestamp,omitempty"`
+	DeletionGracePeriodSeconds *int64 `json:"deletionGracePeriodSeconds,omitempty"`
+	// TODO: resourceVersion for full mock
 }
 
 // Namespace custom struct (impls KubeObject).
@@ -46,6 +59,7 @@ func (n Namespace) GetName() string         { return n.Metadata.Name }
 func (n Namespace) GetNamespace() string    { return n.Metadata.Namespace }
 func (n Namespace) ToJSON() ([]byte, error) { return json.Marshal(n) }
 func (n Namespace) GetKind() string         { return n.Kind }
+func (n *Namespace) SetName(name string)    { n.Metadata.Name = name }
 
 // Pod custom struct.
 type Pod struct {
@@ -60,6 +74,7 @@ func (p Pod) GetName() string         { return p.Metadata.Name }
 func (p Pod) GetNamespace() string    { return p.Metadata.Namespace }
 func (p Pod) ToJSON() ([]byte, error) { return json.Marshal(p) }
 func (p Pod) GetKind() string         { return p.Kind }
+func (p *Pod) SetName(name string)    { p.Metadata.Name = name }
 
 // ConfigMap custom struct.
 type ConfigMap struct {
@@ -73,6 +88,7 @@ func (c ConfigMap) GetName() string         { return c.Metadata.Name }
 func (c ConfigMap) GetNamespace() string    { return c.Metadata.Namespace }
 func (c ConfigMap) ToJSON() ([]byte, error) { return json.Marshal(c) }
 func (c ConfigMap) GetKind() string         { return c.Kind }
+func (c *ConfigMap) SetName(name string)    { c.Metadata.Name = name }
 
 // Deployment custom struct.
 type Deployment struct {
@@ -87,6 +103,7 @@ func (d Deployment) GetName() string         { return d.Metadata.Name }
 func (d Deployment) GetNamespace() string    { return d.Metadata.Namespace }
 func (d Deployment) ToJSON() ([]byte, error) { return json.Marshal(d) }
 func (d Deployment) GetKind() string         { return d.Kind }
+func (d *Deployment) SetName(name string)    { d.Metadata.Name = name }
 
 // ReplicaSet custom struct.
 type ReplicaSet struct {
@@ -101,6 +118,197 @@ func (r ReplicaSet) GetName() string         { return r.Metadata.Name }
 func (r ReplicaSet) GetNamespace() string    { return r.Metadata.Namespace }
 func (r ReplicaSet) ToJSON() ([]byte, error) { return json.Marshal(r) }
 func (r ReplicaSet) GetKind() string         { return r.Kind }
+func (r *ReplicaSet) SetName(name string)    { r.Metadata.Name = name }
+
+// StatefulSet custom struct.
+type StatefulSet struct {
+	Kind       string      `json:"kind"`
+	APIVersion string      `json:"apiVersion"`
+	Metadata   ObjectMeta  `json:"metadata"`
+	Spec       interface{} `json:"spec,omitempty"`
+	Status     interface{} `json:"status,omitempty"`
+}
+
+func (s StatefulSet) GetName() string         { return s.Metadata.Name }
+func (s StatefulSet) GetNamespace() string    { return s.Metadata.Namespace }
+func (s StatefulSet) ToJSON() ([]byte, error) { return json.Marshal(s) }
+func (s StatefulSet) GetKind() string         { return s.Kind }
+func (s *StatefulSet) SetName(name string)    { s.Metadata.Name = name }
+
+// DaemonSet custom struct.
+type DaemonSet struct {
+	Kind       string      `json:"kind"`
+	APIVersion string      `json:"apiVersion"`
+	Metadata   ObjectMeta  `json:"metadata"`
+	Spec       interface{} `json:"spec,omitempty"`
+	Status     interface{} `json:"status,omitempty"`
+}
+
+func (d DaemonSet) GetName() string         { return d.Metadata.Name }
+func (d DaemonSet) GetNamespace() string    { return d.Metadata.Namespace }
+func (d DaemonSet) ToJSON() ([]byte, error) { return json.Marshal(d) }
+func (d DaemonSet) GetKind() string         { return d.Kind }
+func (d *DaemonSet) SetName(name string)    { d.Metadata.Name = name }
+
+// ControllerRevision custom struct (immutable snapshot of a workload's pod template).
+type ControllerRevision struct {
+	Kind       string      `json:"kind"`
+	APIVersion string      `json:"apiVersion"`
+	Metadata   ObjectMeta  `json:"metadata"`
+	Data       interface{} `json:"data,omitempty"`
+	Revision   int64       `json:"revision"`
+}
+
+func (r ControllerRevision) GetName() string         { return r.Metadata.Name }
+func (r ControllerRevision) GetNamespace() string    { return r.Metadata.Namespace }
+func (r ControllerRevision) ToJSON() ([]byte, error) { return json.Marshal(r) }
+func (r ControllerRevision) GetKind() string         { return r.Kind }
+func (r *ControllerRevision) SetName(name string)    { r.Metadata.Name = name }
+
+// PersistentVolumeClaim custom struct.
+type PersistentVolumeClaim struct {
+	Kind       string      `json:"kind"`
+	APIVersion string      `json:"apiVersion"`
+	Metadata   ObjectMeta  `json:"metadata"`
+	Spec       interface{} `json:"spec,omitempty"`
+	Status     interface{} `json:"status,omitempty"`
+}
+
+func (p PersistentVolumeClaim) GetName() string         { return p.Metadata.Name }
+func (p PersistentVolumeClaim) GetNamespace() string    { return p.Metadata.Namespace }
+func (p PersistentVolumeClaim) ToJSON() ([]byte, error) { return json.Marshal(p) }
+func (p PersistentVolumeClaim) GetKind() string         { return p.Kind }
+func (p *PersistentVolumeClaim) SetName(name string)    { p.Metadata.Name = name }
+
+// Job custom struct (batch/v1).
+type Job struct {
+	Kind       string      `json:"kind"`
+	APIVersion string      `json:"apiVersion"`
+	Metadata   ObjectMeta  `json:"metadata"`
+	Spec       interface{} `json:"spec,omitempty"`
+	Status     interface{} `json:"status,omitempty"`
+}
+
+func (j Job) GetName() string         { return j.Metadata.Name }
+func (j Job) GetNamespace() string    { return j.Metadata.Namespace }
+func (j Job) ToJSON() ([]byte, error) { return json.Marshal(j) }
+func (j Job) GetKind() string         { return j.Kind }
+func (j *Job) SetName(name string)    { j.Metadata.Name = name }
+
+// CronJob custom struct (batch/v1).
+type CronJob struct {
+	Kind       string      `json:"kind"`
+	APIVersion string      `json:"apiVersion"`
+	Metadata   ObjectMeta  `json:"metadata"`
+	Spec       interface{} `json:"spec,omitempty"`
+	Status     interface{} `json:"status,omitempty"`
+}
+
+func (c CronJob) GetName() string         { return c.Metadata.Name }
+func (c CronJob) GetNamespace() string    { return c.Metadata.Namespace }
+func (c CronJob) ToJSON() ([]byte, error) { return json.Marshal(c) }
+func (c CronJob) GetKind() string         { return c.Kind }
+func (c *CronJob) SetName(name string)    { c.Metadata.Name = name }
+
+// Node custom struct (cluster-scoped; nodes are fake, no kubelet behind them).
+type Node struct {
+	Kind       string      `json:"kind"`
+	APIVersion string      `json:"apiVersion"`
+	Metadata   ObjectMeta  `json:"metadata"`
+	Spec       interface{} `json:"spec,omitempty"`
+	Status     interface{} `json:"status,omitempty"`
+}
+
+func (n Node) GetName() string         { return n.Metadata.Name }
+func (n Node) GetNamespace() string    { return n.Metadata.Namespace }
+func (n Node) ToJSON() ([]byte, error) { return json.Marshal(n) }
+func (n Node) GetKind() string         { return n.Kind }
+func (n *Node) SetName(name string)    { n.Metadata.Name = name }
+
+// Lease custom struct (coordination.k8s.io/v1; node heartbeats in kube-node-lease).
+type Lease struct {
+	Kind       string      `json:"kind"`
+	APIVersion string      `json:"apiVersion"`
+	Metadata   ObjectMeta  `json:"metadata"`
+	Spec       interface{} `json:"spec,omitempty"`
+}
+
+func (l Lease) GetName() string         { return l.Metadata.Name }
+func (l Lease) GetNamespace() string    { return l.Metadata.Namespace }
+func (l Lease) ToJSON() ([]byte, error) { return json.Marshal(l) }
+func (l Lease) GetKind() string         { return l.Kind }
+func (l *Lease) SetName(name string)    { l.Metadata.Name = name }
+
+// Service custom struct (core/v1; cluster IPs and node ports are allocated on create).
+type Service struct {
+	Kind       string      `json:"kind"`
+	APIVersion string      `json:"apiVersion"`
+	Metadata   ObjectMeta  `json:"metadata"`
+	Spec       interface{} `json:"spec,omitempty"`
+	Status     interface{} `json:"status,omitempty"`
+}
+
+func (s Service) GetName() string         { return s.Metadata.Name }
+func (s Service) GetNamespace() string    { return s.Metadata.Namespace }
+func (s Service) ToJSON() ([]byte, error) { return json.Marshal(s) }
+func (s Service) GetKind() string         { return s.Kind }
+func (s *Service) SetName(name string)    { s.Metadata.Name = name }
+
+// EndpointSlice custom struct (discovery.k8s.io/v1; no spec, the endpoints are top-level).
+type EndpointSlice struct {
+	Kind        string      `json:"kind"`
+	APIVersion  string      `json:"apiVersion"`
+	Metadata    ObjectMeta  `json:"metadata"`
+	AddressType string      `json:"addressType"`
+	Endpoints   interface{} `json:"endpoints"`
+	Ports       interface{} `json:"ports,omitempty"`
+}
+
+func (e EndpointSlice) GetName() string         { return e.Metadata.Name }
+func (e EndpointSlice) GetNamespace() string    { return e.Metadata.Namespace }
+func (e EndpointSlice) ToJSON() ([]byte, error) { return json.Marshal(e) }
+func (e EndpointSlice) GetKind() string         { return e.Kind }
+func (e *EndpointSlice) SetName(name string)    { e.Metadata.Name = name }
+
+// Event custom struct (core/v1 Event; the scheduler and controllers report to it).
+type Event struct {
+	Kind               string          `json:"kind"`
+	APIVersion         string          `json:"apiVersion"`
+	Metadata           ObjectMeta      `json:"metadata"`
+	InvolvedObject     ObjectReference `json:"involvedObject"`
+	Reason             string          `json:"reason,omitempty"`
+	Message            string          `json:"message,omitempty"`
+	Source             EventSource     `json:"source,omitempty"`
+	FirstTimestamp     string          `json:"firstTimestamp,omitempty"`
+	LastTimestamp      string          `json:"lastTimestamp,omitempty"`
+	Count              int32           `json:"count,omitempty"`
+	Type               string          `json:"type,omitempty"`
+	ReportingComponent string          `json:"reportingComponent"`
+	ReportingInstance  string          `json:"reportingInstance"`
+}
+
+func (e Event) GetName() string         { return e.Metadata.Name }
+func (e Event) GetNamespace() string    { return e.Metadata.Namespace }
+func (e Event) ToJSON() ([]byte, error) { return json.Marshal(e) }
+func (e Event) GetKind() string         { return e.Kind }
+func (e *Event) SetName(name string)    { e.Metadata.Name = name }
+
+// ObjectReference points at the object an Event is about.
+type ObjectReference struct {
+	Kind            string `json:"kind,omitempty"`
+	Namespace       string `json:"namespace,omitempty"`
+	Name            string `json:"name,omitempty"`
+	UID             string `json:"uid,omitempty"`
+	APIVersion      string `json:"apiVersion,omitempty"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+	FieldPath       string `json:"fieldPath,omitempty"`
+}
+
+// EventSource names the component that reported an Event.
+type EventSource struct {
+	Component string `json:"component,omitempty"`
+	Host      string `json:"host,omitempty"`
+}
 
 // ListResponse skeleton for resources.
 type ListResponse struct {
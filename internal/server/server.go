@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 
 	"mockernetes/internal/apis"
 	"mockernetes/internal/auth"
@@ -14,14 +15,58 @@ import (
 )
 
 func NewServer() {
+	// Initialize the shared informers that feed the controllers with store change events
+	controllers.InitInformers(storage.DefaultStore)
+	// Pod IPs and node podCIDRs come from the cluster CIDR
+	if err := controllers.InitIPAM(os.Getenv(controllers.ClusterCIDREnv)); err != nil {
+		log.Printf("Failed to initialize IPAM: %v", err)
+		return
+	}
+	// Service cluster IPs come from the service CIDR
+	if err := controllers.InitServiceAllocator(os.Getenv(controllers.ServiceCIDREnv)); err != nil {
+		log.Printf("Failed to initialize service allocator: %v", err)
+		return
+	}
+	// Register the fake Nodes pods are scheduled to
+	if err := controllers.InitNodes(storage.DefaultStore, os.Getenv(controllers.NodeConfigEnv)); err != nil {
+		log.Printf("Failed to register nodes: %v", err)
+		return
+	}
+	// Container images are pulled from the fake image registry
+	if err := controllers.InitImageRegistry(os.Getenv(controllers.ImageConfigEnv)); err != nil {
+		log.Printf("Failed to initialize image registry: %v", err)
+		return
+	}
 	// Initialize the pod controller for lifecycle management
 	controllers.InitPodController(storage.DefaultStore)
+	// Initialize the scheduler that binds pending pods to Nodes
+	controllers.InitScheduler(storage.DefaultStore)
+	// Initialize the node lifecycle controller: kubelet heartbeats, NotReady taints and taint-based eviction
+	controllers.InitNodeLifecycleController(storage.DefaultStore)
 	// Initialize the template registry for pre-defined pod behaviors
 	controllers.InitTemplateRegistry()
+	// Load the simulation scenarios whose templates match pods by name pattern, labels or owner
+	if err := controllers.DefaultTemplateRegistry.LoadScenarioFiles(os.Getenv(controllers.ScenariosEnv)); err != nil {
+		log.Printf("Failed to load scenarios: %v", err)
+		return
+	}
 	// Initialize the ReplicaSet controller for managing ReplicaSets and their pods
 	controllers.InitReplicaSetController(storage.DefaultStore)
 	// Initialize the Deployment controller for managing Deployments and their ReplicaSets
 	controllers.InitDeploymentController(storage.DefaultStore)
+	// Initialize the StatefulSet controller for ordered, stable-identity pods and their claims
+	controllers.InitStatefulSetController(storage.DefaultStore)
+	// Initialize the DaemonSet controller that runs one pod per matching Node
+	controllers.InitDaemonSetController(storage.DefaultStore)
+	// Initialize the Job controller that runs pods to completion and the CronJob controller that creates Jobs on a schedule
+	controllers.InitJobController(storage.DefaultStore)
+	controllers.InitCronJobController(storage.DefaultStore)
+	// Initialize the EndpointSlice controller that publishes the pods selected by Services
+	controllers.InitEndpointSliceController(storage.DefaultStore)
+	// Initialize the Namespace controller that owns the "kubernetes" namespace finalizer
+	controllers.InitNamespaceController(storage.DefaultStore)
+	// Initialize the garbage collector that deletes dependents via ownerReferences
+	controllers.InitGarbageCollector(storage.DefaultStore)
 
 	r := gin.Default()
 
@@ -68,6 +113,9 @@ func wireRoutes(r *gin.Engine) {
 	r.GET("/apis", apis.APIsHandler)
 	r.GET("/api/v1", apis.APIV1Handler)
 	r.GET("/apis/apps/v1", apis.AppsV1Handler)
+	r.GET("/apis/batch/v1", apis.BatchV1Handler)
+	r.GET("/apis/coordination.k8s.io/v1", apis.CoordinationV1Handler)
+	r.GET("/apis/discovery.k8s.io/v1", apis.DiscoveryV1Handler)
 
 	// health/ready + core resources (ns/pods/cms with in-mem storage)
 	// namespaced routes for pods/cms (mock ignores :namespace param; kubectl uses e.g. /namespaces/default/...)
@@ -75,38 +123,153 @@ func wireRoutes(r *gin.Engine) {
 	r.GET("/readyz", readyzHandler)
 	r.GET("/api/v1/namespaces", apis.ListNamespaces)
 	r.POST("/api/v1/namespaces", apis.CreateNamespace)
+	r.GET("/api/v1/namespaces/:namespace", namespaceItem(apis.GetNamespace))
+	r.PUT("/api/v1/namespaces/:namespace", namespaceItem(apis.UpdateNamespace))
+	r.PATCH("/api/v1/namespaces/:namespace", namespaceItem(apis.PatchNamespace))
+	r.DELETE("/api/v1/namespaces/:namespace", namespaceItem(apis.DeleteNamespace))
 	// cluster + namespaced for pods/cms (similarly for apps/v1 deploy/rs below)
 	r.GET("/api/v1/pods", apis.ListPods)
 	r.POST("/api/v1/pods", apis.CreatePod)
 	r.GET("/api/v1/pods/:name", apis.GetPod)
+	r.PUT("/api/v1/pods/:name", apis.UpdatePod)
+	r.PATCH("/api/v1/pods/:name", apis.PatchPod)
 	r.DELETE("/api/v1/pods/:name", apis.DeletePod)
 	r.GET("/api/v1/namespaces/:namespace/pods", apis.ListPods)
 	r.POST("/api/v1/namespaces/:namespace/pods", apis.CreatePod)
 	r.GET("/api/v1/namespaces/:namespace/pods/:name", apis.GetPod)
+	r.PUT("/api/v1/namespaces/:namespace/pods/:name", apis.UpdatePod)
+	r.PATCH("/api/v1/namespaces/:namespace/pods/:name", apis.PatchPod)
 	r.DELETE("/api/v1/namespaces/:namespace/pods/:name", apis.DeletePod)
 	r.GET("/api/v1/configmaps", apis.ListConfigMaps)
 	r.POST("/api/v1/configmaps", apis.CreateConfigMap)
 	r.GET("/api/v1/namespaces/:namespace/configmaps", apis.ListConfigMaps)
 	r.POST("/api/v1/namespaces/:namespace/configmaps", apis.CreateConfigMap)
+	r.GET("/api/v1/namespaces/:namespace/configmaps/:name", apis.GetConfigMap)
+	r.PUT("/api/v1/namespaces/:namespace/configmaps/:name", apis.UpdateConfigMap)
+	r.PATCH("/api/v1/namespaces/:namespace/configmaps/:name", apis.PatchConfigMap)
+	r.DELETE("/api/v1/namespaces/:namespace/configmaps/:name", apis.DeleteConfigMap)
+	r.GET("/api/v1/persistentvolumeclaims", apis.ListPersistentVolumeClaims)
+	r.GET("/api/v1/namespaces/:namespace/persistentvolumeclaims", apis.ListPersistentVolumeClaims)
+	r.POST("/api/v1/namespaces/:namespace/persistentvolumeclaims", apis.CreatePersistentVolumeClaim)
+	r.GET("/api/v1/namespaces/:namespace/persistentvolumeclaims/:name", apis.GetPersistentVolumeClaim)
+	r.PUT("/api/v1/namespaces/:namespace/persistentvolumeclaims/:name", apis.UpdatePersistentVolumeClaim)
+	r.PATCH("/api/v1/namespaces/:namespace/persistentvolumeclaims/:name", apis.PatchPersistentVolumeClaim)
+	r.DELETE("/api/v1/namespaces/:namespace/persistentvolumeclaims/:name", apis.DeletePersistentVolumeClaim)
+	// services get cluster IPs and node ports on create
+	r.GET("/api/v1/services", apis.ListServices)
+	r.GET("/api/v1/namespaces/:namespace/services", apis.ListServices)
+	r.POST("/api/v1/namespaces/:namespace/services", apis.CreateService)
+	r.GET("/api/v1/namespaces/:namespace/services/:name", apis.GetService)
+	r.PUT("/api/v1/namespaces/:namespace/services/:name", apis.UpdateService)
+	r.PATCH("/api/v1/namespaces/:namespace/services/:name", apis.PatchService)
+	r.DELETE("/api/v1/namespaces/:namespace/services/:name", apis.DeleteService)
+	// nodes are cluster-scoped
+	r.GET("/api/v1/nodes", apis.ListNodes)
+	r.POST("/api/v1/nodes", apis.CreateNode)
+	r.GET("/api/v1/nodes/:name", apis.GetNode)
+	r.PUT("/api/v1/nodes/:name", apis.UpdateNode)
+	r.PATCH("/api/v1/nodes/:name", apis.PatchNode)
+	r.DELETE("/api/v1/nodes/:name", apis.DeleteNode)
+	// events (written by the scheduler; kubectl describe lists them per object)
+	r.GET("/api/v1/events", apis.ListEvents)
+	r.GET("/api/v1/namespaces/:namespace/events", apis.ListEvents)
+	r.POST("/api/v1/namespaces/:namespace/events", apis.CreateEvent)
+	r.GET("/api/v1/namespaces/:namespace/events/:name", apis.GetEvent)
+	r.DELETE("/api/v1/namespaces/:namespace/events/:name", apis.DeleteEvent)
 
-	// apps/v1 resources (deployments + replicasets; cluster-scoped paths + namespaced like pods.
+	// apps/v1 resources (deployments, replicasets, statefulsets, daemonsets; cluster-scoped paths + namespaced like pods.
 	// Note: /apis/apps/v1/... for group-version; mirrors pod handling for minimal mock.
 	r.GET("/apis/apps/v1/deployments", apis.ListDeployments)
 	r.POST("/apis/apps/v1/deployments", apis.CreateDeployment)
 	r.GET("/apis/apps/v1/namespaces/:namespace/deployments", apis.ListDeployments)
 	r.POST("/apis/apps/v1/namespaces/:namespace/deployments", apis.CreateDeployment)
+	r.GET("/apis/apps/v1/namespaces/:namespace/deployments/:name", apis.GetDeployment)
+	r.PUT("/apis/apps/v1/namespaces/:namespace/deployments/:name", apis.UpdateDeployment)
+	r.PATCH("/apis/apps/v1/namespaces/:namespace/deployments/:name", apis.PatchDeployment)
+	r.DELETE("/apis/apps/v1/namespaces/:namespace/deployments/:name", apis.DeleteDeployment)
 	r.GET("/apis/apps/v1/replicasets", apis.ListReplicaSets)
 	r.POST("/apis/apps/v1/replicasets", apis.CreateReplicaSet)
 	r.GET("/apis/apps/v1/namespaces/:namespace/replicasets", apis.ListReplicaSets)
 	r.POST("/apis/apps/v1/namespaces/:namespace/replicasets", apis.CreateReplicaSet)
 	r.GET("/apis/apps/v1/namespaces/:namespace/replicasets/:name", apis.GetReplicaSet)
+	r.PUT("/apis/apps/v1/namespaces/:namespace/replicasets/:name", apis.UpdateReplicaSet)
+	r.PATCH("/apis/apps/v1/namespaces/:namespace/replicasets/:name", apis.PatchReplicaSet)
 	r.DELETE("/apis/apps/v1/namespaces/:namespace/replicasets/:name", apis.DeleteReplicaSet)
+	r.GET("/apis/apps/v1/statefulsets", apis.ListStatefulSets)
+	r.POST("/apis/apps/v1/statefulsets", apis.CreateStatefulSet)
+	r.GET("/apis/apps/v1/namespaces/:namespace/statefulsets", apis.ListStatefulSets)
+	r.POST("/apis/apps/v1/namespaces/:namespace/statefulsets", apis.CreateStatefulSet)
+	r.GET("/apis/apps/v1/namespaces/:namespace/statefulsets/:name", apis.GetStatefulSet)
+	r.PUT("/apis/apps/v1/namespaces/:namespace/statefulsets/:name", apis.UpdateStatefulSet)
+	r.PATCH("/apis/apps/v1/namespaces/:namespace/statefulsets/:name", apis.PatchStatefulSet)
+	r.DELETE("/apis/apps/v1/namespaces/:namespace/statefulsets/:name", apis.DeleteStatefulSet)
+	r.GET("/apis/apps/v1/daemonsets", apis.ListDaemonSets)
+	r.POST("/apis/apps/v1/daemonsets", apis.CreateDaemonSet)
+	r.GET("/apis/apps/v1/namespaces/:namespace/daemonsets", apis.ListDaemonSets)
+	r.POST("/apis/apps/v1/namespaces/:namespace/daemonsets", apis.CreateDaemonSet)
+	r.GET("/apis/apps/v1/namespaces/:namespace/daemonsets/:name", apis.GetDaemonSet)
+	r.PUT("/apis/apps/v1/namespaces/:namespace/daemonsets/:name", apis.UpdateDaemonSet)
+	r.PATCH("/apis/apps/v1/namespaces/:namespace/daemonsets/:name", apis.PatchDaemonSet)
+	r.DELETE("/apis/apps/v1/namespaces/:namespace/daemonsets/:name", apis.DeleteDaemonSet)
+	r.GET("/apis/apps/v1/controllerrevisions", apis.ListControllerRevisions)
+	r.GET("/apis/apps/v1/namespaces/:namespace/controllerrevisions", apis.ListControllerRevisions)
+	r.GET("/apis/apps/v1/namespaces/:namespace/controllerrevisions/:name", apis.GetControllerRevision)
+	r.DELETE("/apis/apps/v1/namespaces/:namespace/controllerrevisions/:name", apis.DeleteControllerRevision)
+
+	// batch/v1 resources (jobs, cronjobs; cluster-scoped paths + namespaced like apps/v1)
+	r.GET("/apis/batch/v1/jobs", apis.ListJobs)
+	r.POST("/apis/batch/v1/jobs", apis.CreateJob)
+	r.GET("/apis/batch/v1/namespaces/:namespace/jobs", apis.ListJobs)
+	r.POST("/apis/batch/v1/namespaces/:namespace/jobs", apis.CreateJob)
+	r.GET("/apis/batch/v1/namespaces/:namespace/jobs/:name", apis.GetJob)
+	r.PUT("/apis/batch/v1/namespaces/:namespace/jobs/:name", apis.UpdateJob)
+	r.PATCH("/apis/batch/v1/namespaces/:namespace/jobs/:name", apis.PatchJob)
+	r.DELETE("/apis/batch/v1/namespaces/:namespace/jobs/:name", apis.DeleteJob)
+	r.GET("/apis/batch/v1/cronjobs", apis.ListCronJobs)
+	r.POST("/apis/batch/v1/cronjobs", apis.CreateCronJob)
+	r.GET("/apis/batch/v1/namespaces/:namespace/cronjobs", apis.ListCronJobs)
+	r.POST("/apis/batch/v1/namespaces/:namespace/cronjobs", apis.CreateCronJob)
+	r.GET("/apis/batch/v1/namespaces/:namespace/cronjobs/:name", apis.GetCronJob)
+	r.PUT("/apis/batch/v1/namespaces/:namespace/cronjobs/:name", apis.UpdateCronJob)
+	r.PATCH("/apis/batch/v1/namespaces/:namespace/cronjobs/:name", apis.PatchCronJob)
+	r.DELETE("/apis/batch/v1/namespaces/:namespace/cronjobs/:name", apis.DeleteCronJob)
+
+	// coordination.k8s.io/v1 leases (node heartbeats)
+	r.GET("/apis/coordination.k8s.io/v1/leases", apis.ListLeases)
+	r.GET("/apis/coordination.k8s.io/v1/namespaces/:namespace/leases", apis.ListLeases)
+	r.POST("/apis/coordination.k8s.io/v1/namespaces/:namespace/leases", apis.CreateLease)
+	r.GET("/apis/coordination.k8s.io/v1/namespaces/:namespace/leases/:name", apis.GetLease)
+	r.PUT("/apis/coordination.k8s.io/v1/namespaces/:namespace/leases/:name", apis.UpdateLease)
+	r.DELETE("/apis/coordination.k8s.io/v1/namespaces/:namespace/leases/:name", apis.DeleteLease)
 
-	// Simulation endpoints for configurable pod state transitions
+	// discovery.k8s.io/v1 endpointslices (the endpoints of Services)
+	r.GET("/apis/discovery.k8s.io/v1/endpointslices", apis.ListEndpointSlices)
+	r.GET("/apis/discovery.k8s.io/v1/namespaces/:namespace/endpointslices", apis.ListEndpointSlices)
+	r.POST("/apis/discovery.k8s.io/v1/namespaces/:namespace/endpointslices", apis.CreateEndpointSlice)
+	r.GET("/apis/discovery.k8s.io/v1/namespaces/:namespace/endpointslices/:name", apis.GetEndpointSlice)
+	r.PUT("/apis/discovery.k8s.io/v1/namespaces/:namespace/endpointslices/:name", apis.UpdateEndpointSlice)
+	r.PATCH("/apis/discovery.k8s.io/v1/namespaces/:namespace/endpointslices/:name", apis.PatchEndpointSlice)
+	r.DELETE("/apis/discovery.k8s.io/v1/namespaces/:namespace/endpointslices/:name", apis.DeleteEndpointSlice)
+
+	// Simulation endpoints for configurable pod state transitions and node failures
 	r.POST("/simulate/controller/pod", apis.SimulatePod)
 	r.GET("/simulate/controller/pod", apis.ListActiveTransitions)
 	r.GET("/simulate/controller/pod/:name", apis.GetPodTransition)
 	r.DELETE("/simulate/controller/pod/:name", apis.CancelPodTransition)
+	r.GET("/simulate/controller/node", apis.ListNodeSimulations)
+	r.POST("/simulate/controller/node/:name", apis.SimulateNode)
+	r.GET("/simulate/scenarios", apis.ListScenarios)
+	r.POST("/simulate/scenarios", apis.LoadScenario)
+	r.DELETE("/simulate/scenarios/:name", apis.DeleteScenario)
+}
+
+// namespaceItem adapts namespace item handlers: gin requires the same wildcard name
+// as the namespaced routes (:namespace), while the handlers read the object from :name.
+func namespaceItem(h gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Params = append(c.Params, gin.Param{Key: "name", Value: c.Param("namespace")})
+		h(c)
+	}
 }
 
 func healthzHandler(c *gin.Context) {
@@ -1,8 +1,6 @@
 package storage
 
 import (
-	"encoding/json"
-
 	"mockernetes/internal/resources" // for KubeObject skeleton
 )
 
@@ -11,15 +9,7 @@ import (
 
 // ListConfigMaps returns stored configmaps as []interface{}.
 func (s *InMemoryStore) ListConfigMaps() []interface{} {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	var items []interface{}
-	for _, cmJSON := range s.cmData {
-		var cm map[string]interface{}
-		json.Unmarshal([]byte(cmJSON), &cm)
-		items = append(items, cm)
-	}
-	return items
+	return s.listHelper(s.cmData)
 }
 
 // CreateConfigMap stores a configmap (error if exists).
@@ -27,3 +17,28 @@ func (s *InMemoryStore) ListConfigMaps() []interface{} {
 func (s *InMemoryStore) CreateConfigMap(cm resources.KubeObject) error {
 	return s.createHelper(s.cmData, cm, "configmap")
 }
+
+// GetConfigMap retrieves a configmap by name from storage.
+// Returns the configmap as a map or error if not found.
+func (s *InMemoryStore) GetConfigMap(name string) (map[string]interface{}, error) {
+	return s.getHelper(s.cmData, name, "configmap")
+}
+
+// UpdateConfigMap updates an existing configmap in storage.
+// Returns error if the configmap doesn't exist.
+func (s *InMemoryStore) UpdateConfigMap(cm resources.KubeObject) error {
+	return s.updateHelper(s.cmData, cm, "configmap")
+}
+
+// MutateConfigMap atomically applies fn to the stored configmap (controllers use it for status
+// writes so user-owned metadata such as finalizers is kept). Returns the updated configmap.
+func (s *InMemoryStore) MutateConfigMap(name string, fn func(cm map[string]interface{}) error) (map[string]interface{}, error) {
+	return s.mutateHelper(s.cmData, name, "configmap", fn)
+}
+
+// DeleteConfigMap removes a configmap from storage, or only marks it for deletion while it has finalizers.
+// Returns error if the configmap doesn't exist.
+func (s *InMemoryStore) DeleteConfigMap(name string) error {
+	_, err := s.deleteHelper(s.cmData, name, "configmap")
+	return err
+}
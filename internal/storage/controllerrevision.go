@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"mockernetes/internal/resources" // for KubeObject skeleton
+)
+
+// ControllerRevision-specific storage methods (apps/v1; snapshots of workload pod templates).
+// Skeleton update: Create uses KubeObject.
+
+// ListControllerRevisions returns stored controllerrevisions as []interface{}.
+func (s *InMemoryStore) ListControllerRevisions() []interface{} {
+	return s.listHelper(s.crData)
+}
+
+// CreateControllerRevision stores a controllerrevision (error if exists).
+// Uses resources.ControllerRevision (custom struct impl of KubeObject) for mock control.
+func (s *InMemoryStore) CreateControllerRevision(cr resources.KubeObject) error {
+	return s.createHelper(s.crData, cr, "controllerrevision")
+}
+
+// GetControllerRevision retrieves a controllerrevision by name from storage.
+// Returns the controllerrevision as a map or error if not found.
+func (s *InMemoryStore) GetControllerRevision(name string) (map[string]interface{}, error) {
+	return s.getHelper(s.crData, name, "controllerrevision")
+}
+
+// UpdateControllerRevision updates an existing controllerrevision in storage.
+// Returns error if the controllerrevision doesn't exist.
+func (s *InMemoryStore) UpdateControllerRevision(cr resources.KubeObject) error {
+	return s.updateHelper(s.crData, cr, "controllerrevision")
+}
+
+// MutateControllerRevision atomically applies fn to the stored controllerrevision (controllers use it for status
+// writes so user-owned metadata such as finalizers is kept). Returns the updated controllerrevision.
+func (s *InMemoryStore) MutateControllerRevision(name string, fn func(cr map[string]interface{}) error) (map[string]interface{}, error) {
+	return s.mutateHelper(s.crData, name, "controllerrevision", fn)
+}
+
+// DeleteControllerRevision removes a controllerrevision from storage, or only marks it for deletion while it has finalizers.
+// Returns error if the controllerrevision doesn't exist.
+func (s *InMemoryStore) DeleteControllerRevision(name string) error {
+	_, err := s.deleteHelper(s.crData, name, "controllerrevision")
+	return err
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"mockernetes/internal/resources" // for KubeObject skeleton
+)
+
+// CronJob-specific storage methods (batch/v1).
+// Skeleton update: Create uses KubeObject.
+
+// ListCronJobs returns stored cronjobs as []interface{}.
+func (s *InMemoryStore) ListCronJobs() []interface{} {
+	return s.listHelper(s.cjData)
+}
+
+// CreateCronJob stores a cronjob (error if exists).
+// Uses resources.CronJob (custom struct impl of KubeObject) for mock control.
+func (s *InMemoryStore) CreateCronJob(cronjob resources.KubeObject) error {
+	return s.createHelper(s.cjData, cronjob, "cronjob")
+}
+
+// GetCronJob retrieves a cronjob by name from storage.
+// Returns the cronjob as a map or error if not found.
+func (s *InMemoryStore) GetCronJob(name string) (map[string]interface{}, error) {
+	return s.getHelper(s.cjData, name, "cronjob")
+}
+
+// UpdateCronJob updates an existing cronjob in storage.
+// Returns error if the cronjob doesn't exist.
+func (s *InMemoryStore) UpdateCronJob(cronjob resources.KubeObject) error {
+	return s.updateHelper(s.cjData, cronjob, "cronjob")
+}
+
+// MutateCronJob atomically applies fn to the stored cronjob (controllers use it for status
+// writes so user-owned metadata such as finalizers is kept). Returns the updated cronjob.
+func (s *InMemoryStore) MutateCronJob(name string, fn func(cronjob map[string]interface{}) error) (map[string]interface{}, error) {
+	return s.mutateHelper(s.cjData, name, "cronjob", fn)
+}
+
+// DeleteCronJob removes a cronjob from storage, or only marks it for deletion while it has finalizers.
+// Returns error if the cronjob doesn't exist.
+func (s *InMemoryStore) DeleteCronJob(name string) error {
+	_, err := s.deleteHelper(s.cjData, name, "cronjob")
+	return err
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"mockernetes/internal/resources" // for KubeObject skeleton
+)
+
+// DaemonSet-specific storage methods (apps/v1).
+// Skeleton update: Create uses KubeObject.
+
+// ListDaemonSets returns stored daemonsets as []interface{}.
+func (s *InMemoryStore) ListDaemonSets() []interface{} {
+	return s.listHelper(s.dsData)
+}
+
+// CreateDaemonSet stores a daemonset (error if exists).
+// Uses resources.DaemonSet (custom struct impl of KubeObject) for mock control.
+func (s *InMemoryStore) CreateDaemonSet(daemonset resources.KubeObject) error {
+	return s.createHelper(s.dsData, daemonset, "daemonset")
+}
+
+// GetDaemonSet retrieves a daemonset by name from storage.
+// Returns the daemonset as a map or error if not found.
+func (s *InMemoryStore) GetDaemonSet(name string) (map[string]interface{}, error) {
+	return s.getHelper(s.dsData, name, "daemonset")
+}
+
+// UpdateDaemonSet updates an existing daemonset in storage.
+// Returns error if the daemonset doesn't exist.
+func (s *InMemoryStore) UpdateDaemonSet(daemonset resources.KubeObject) error {
+	return s.updateHelper(s.dsData, daemonset, "daemonset")
+}
+
+// MutateDaemonSet atomically applies fn to the stored daemonset (controllers use it for status
+// writes so user-owned metadata such as finalizers is kept). Returns the updated daemonset.
+func (s *InMemoryStore) MutateDaemonSet(name string, fn func(daemonset map[string]interface{}) error) (map[string]interface{}, error) {
+	return s.mutateHelper(s.dsData, name, "daemonset", fn)
+}
+
+// DeleteDaemonSet removes a daemonset from storage, or only marks it for deletion while it has finalizers.
+// Returns error if the daemonset doesn't exist.
+func (s *InMemoryStore) DeleteDaemonSet(name string) error {
+	_, err := s.deleteHelper(s.dsData, name, "daemonset")
+	return err
+}
@@ -1,9 +1,6 @@
 package storage
 
 import (
-	"encoding/json"
-	"fmt"
-
 	"mockernetes/internal/resources" // for KubeObject skeleton
 )
 
@@ -12,15 +9,7 @@ import (
 
 // ListDeployments returns stored deployments as []interface{} (mirrors pod/cm pattern).
 func (s *InMemoryStore) ListDeployments() []interface{} {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	var items []interface{}
-	for _, dJSON := range s.deployData {
-		var d map[string]interface{}
-		json.Unmarshal([]byte(dJSON), &d)
-		items = append(items, d)
-	}
-	return items
+	return s.listHelper(s.deployData)
 }
 
 // CreateDeployment stores a deployment (error if exists).
@@ -32,56 +21,24 @@ func (s *InMemoryStore) CreateDeployment(deploy resources.KubeObject) error {
 // GetDeployment retrieves a deployment by name from storage.
 // Returns the deployment as a map or error if not found.
 func (s *InMemoryStore) GetDeployment(name string) (map[string]interface{}, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	deployJSON, exists := s.deployData[name]
-	if !exists {
-		return nil, fmt.Errorf("deployment %s not found", name)
-	}
-
-	var deploy map[string]interface{}
-	if err := json.Unmarshal([]byte(deployJSON), &deploy); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal deployment: %w", err)
-	}
-
-	return deploy, nil
+	return s.getHelper(s.deployData, name, "deployment")
 }
 
 // UpdateDeployment updates an existing deployment in storage.
 // Returns error if the deployment doesn't exist.
 func (s *InMemoryStore) UpdateDeployment(deploy resources.KubeObject) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	name := deploy.GetName()
-	if name == "" {
-		return fmt.Errorf("deployment name required")
-	}
-
-	if _, exists := s.deployData[name]; !exists {
-		return fmt.Errorf("deployment %s not found", name)
-	}
-
-	b, err := deploy.ToJSON()
-	if err != nil {
-		return fmt.Errorf("toJSON failed: %w", err)
-	}
+	return s.updateHelper(s.deployData, deploy, "deployment")
+}
 
-	s.deployData[name] = string(b)
-	return nil
+// MutateDeployment atomically applies fn to the stored deployment (controllers use it for status
+// writes so user-owned metadata such as finalizers is kept). Returns the updated deployment.
+func (s *InMemoryStore) MutateDeployment(name string, fn func(deploy map[string]interface{}) error) (map[string]interface{}, error) {
+	return s.mutateHelper(s.deployData, name, "deployment", fn)
 }
 
-// DeleteDeployment removes a deployment from storage.
+// DeleteDeployment removes a deployment from storage, or only marks it for deletion while it has finalizers.
 // Returns error if the deployment doesn't exist.
 func (s *InMemoryStore) DeleteDeployment(name string) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if _, exists := s.deployData[name]; !exists {
-		return fmt.Errorf("deployment %s not found", name)
-	}
-
-	delete(s.deployData, name)
-	return nil
+	_, err := s.deleteHelper(s.deployData, name, "deployment")
+	return err
 }
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"mockernetes/internal/resources" // for KubeObject skeleton
+)
+
+// EndpointSlice-specific storage methods (discovery.k8s.io/v1; the endpointslice controller keeps them in sync with Services).
+// Skeleton update: Create uses KubeObject.
+
+// ListEndpointSlices returns stored endpointslices as []interface{}.
+func (s *InMemoryStore) ListEndpointSlices() []interface{} {
+	return s.listHelper(s.epsData)
+}
+
+// CreateEndpointSlice stores an endpointslice (error if exists).
+// Uses resources.EndpointSlice (custom struct impl of KubeObject) for mock control.
+func (s *InMemoryStore) CreateEndpointSlice(slice resources.KubeObject) error {
+	return s.createHelper(s.epsData, slice, "endpointslice")
+}
+
+// GetEndpointSlice retrieves an endpointslice by name from storage.
+// Returns the endpointslice as a map or error if not found.
+func (s *InMemoryStore) GetEndpointSlice(name string) (map[string]interface{}, error) {
+	return s.getHelper(s.epsData, name, "endpointslice")
+}
+
+// UpdateEndpointSlice updates an existing endpointslice in storage.
+// Returns error if the endpointslice doesn't exist.
+func (s *InMemoryStore) UpdateEndpointSlice(slice resources.KubeObject) error {
+	return s.updateHelper(s.epsData, slice, "endpointslice")
+}
+
+// MutateEndpointSlice atomically applies fn to the stored endpointslice (controllers use it for status
+// writes so user-owned metadata such as finalizers is kept). Returns the updated endpointslice.
+func (s *InMemoryStore) MutateEndpointSlice(name string, fn func(slice map[string]interface{}) error) (map[string]interface{}, error) {
+	return s.mutateHelper(s.epsData, name, "endpointslice", fn)
+}
+
+// DeleteEndpointSlice removes an endpointslice from storage, or only marks it for deletion while it has finalizers.
+// Returns error if the endpointslice doesn't exist.
+func (s *InMemoryStore) DeleteEndpointSlice(name string) error {
+	_, err := s.deleteHelper(s.epsData, name, "endpointslice")
+	return err
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"mockernetes/internal/resources" // for KubeObject skeleton
+)
+
+// Event-specific storage methods (core/v1).
+// Skeleton update: Create uses KubeObject.
+
+// ListEvents returns stored events as []interface{}.
+func (s *InMemoryStore) ListEvents() []interface{} {
+	return s.listHelper(s.eventData)
+}
+
+// CreateEvent stores a event (error if exists).
+// Uses resources.Event (custom struct impl of KubeObject) for mock control.
+func (s *InMemoryStore) CreateEvent(event resources.KubeObject) error {
+	return s.createHelper(s.eventData, event, "event")
+}
+
+// GetEvent retrieves a event by name from storage.
+// Returns the event as a map or error if not found.
+func (s *InMemoryStore) GetEvent(name string) (map[string]interface{}, error) {
+	return s.getHelper(s.eventData, name, "event")
+}
+
+// UpdateEvent updates an existing event in storage.
+// Returns error if the event doesn't exist.
+func (s *InMemoryStore) UpdateEvent(event resources.KubeObject) error {
+	return s.updateHelper(s.eventData, event, "event")
+}
+
+// MutateEvent atomically applies fn to the stored event (controllers use it for status
+// writes so user-owned metadata such as finalizers is kept). Returns the updated event.
+func (s *InMemoryStore) MutateEvent(name string, fn func(event map[string]interface{}) error) (map[string]interface{}, error) {
+	return s.mutateHelper(s.eventData, name, "event", fn)
+}
+
+// DeleteEvent removes a event from storage, or only marks it for deletion while it has finalizers.
+// Returns error if the event doesn't exist.
+func (s *InMemoryStore) DeleteEvent(name string) error {
+	_, err := s.deleteHelper(s.eventData, name, "event")
+	return err
+}
@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// Change notifications for controllers (informers) and watches. Every write that changes
+// storage emits one Event to each Watcher, in commit order; delivery never blocks the store.
+
+// EventType is the kind of change, using the watch event names.
+type EventType string
+
+const (
+	Added    EventType = "ADDED"
+	Modified EventType = "MODIFIED"
+	Deleted  EventType = "DELETED"
+)
+
+// Event describes one change to a stored object. Object is the state after the change (the
+// last state for Deleted); OldObject the state before it (nil for Added). Both are decoded
+// per watcher and may be modified by the receiver.
+type Event struct {
+	Type      EventType
+	Kind      string
+	Name      string
+	Object    map[string]interface{}
+	OldObject map[string]interface{}
+}
+
+// Watcher receives store events on ResultChan until Stop is called.
+type Watcher struct {
+	store   *InMemoryStore
+	ch      chan Event
+	done    chan struct{}
+	mu      sync.Mutex
+	cond    *sync.Cond
+	pending []rawEvent
+	stopped bool
+}
+
+// rawEvent is queued as JSON so the store doesn't decode for every watcher under its lock.
+type rawEvent struct {
+	typ     EventType
+	kind    string
+	name    string
+	objJSON string
+	oldJSON string
+}
+
+// Watch registers a new Watcher for all kinds.
+func (s *InMemoryStore) Watch() *Watcher {
+	w := &Watcher{
+		store: s,
+		ch:    make(chan Event),
+		done:  make(chan struct{}),
+	}
+	w.cond = sync.NewCond(&w.mu)
+
+	s.mu.Lock()
+	s.watchers[w] = struct{}{}
+	s.mu.Unlock()
+
+	go w.pump()
+	return w
+}
+
+// ResultChan returns the channel events are delivered on; it is closed after Stop.
+func (w *Watcher) ResultChan() <-chan Event {
+	return w.ch
+}
+
+// Stop unregisters the watcher and drops undelivered events.
+func (w *Watcher) Stop() {
+	w.store.mu.Lock()
+	delete(w.store.watchers, w)
+	w.store.mu.Unlock()
+
+	w.mu.Lock()
+	if !w.stopped {
+		w.stopped = true
+		close(w.done)
+		w.cond.Broadcast()
+	}
+	w.mu.Unlock()
+}
+
+// push queues an event without blocking (called with the store lock held).
+func (w *Watcher) push(ev rawEvent) {
+	w.mu.Lock()
+	w.pending = append(w.pending, ev)
+	w.mu.Unlock()
+	w.cond.Signal()
+}
+
+// pump delivers queued events in order.
+func (w *Watcher) pump() {
+	defer close(w.ch)
+	for {
+		w.mu.Lock()
+		for len(w.pending) == 0 && !w.stopped {
+			w.cond.Wait()
+		}
+		if w.stopped {
+			w.mu.Unlock()
+			return
+		}
+		raw := w.pending[0]
+		w.pending = w.pending[1:]
+		w.mu.Unlock()
+
+		ev := Event{Type: raw.typ, Kind: raw.kind, Name: raw.name}
+		json.Unmarshal([]byte(raw.objJSON), &ev.Object)
+		if raw.oldJSON != "" {
+			json.Unmarshal([]byte(raw.oldJSON), &ev.OldObject)
+		}
+		select {
+		case w.ch <- ev:
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// notify fans an event out to the watchers (caller holds s.mu).
+func (s *InMemoryStore) notify(typ EventType, helperTyp, name, objJSON, oldJSON string) {
+	if len(s.watchers) == 0 {
+		return
+	}
+	ev := rawEvent{typ: typ, kind: kindOf(helperTyp), name: name, objJSON: objJSON, oldJSON: oldJSON}
+	for w := range s.watchers {
+		w.push(ev)
+	}
+}
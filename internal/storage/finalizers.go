@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"time"
+
+	"mockernetes/internal/resources"
+)
+
+// Finalizer semantics shared by every kind (mirrors the generic registry upstream):
+// - DELETE of an object with finalizers only sets metadata.deletionTimestamp
+//   (deletionGracePeriodSeconds 0) and keeps it in storage;
+// - an update that leaves such an object without finalizers removes it.
+// Namespaces are also held by spec.finalizers (the "kubernetes" finalizer).
+
+// hasFinalizers reports whether metadata.finalizers (or a namespace's spec.finalizers) is non-empty.
+func hasFinalizers(obj map[string]interface{}) bool {
+	if finalizers, ok := resources.NestedSlice(obj, "metadata", "finalizers"); ok && len(finalizers) > 0 {
+		return true
+	}
+	if finalizers, ok := resources.NestedSlice(obj, "spec", "finalizers"); ok && len(finalizers) > 0 {
+		return true
+	}
+	return false
+}
+
+// isFinalized reports whether an object marked for deletion can now be removed: no finalizers
+// left and no grace period pending (pods keep deletionGracePeriodSeconds > 0 until the
+// pod controller has stopped their containers).
+func isFinalized(obj map[string]interface{}) bool {
+	if ts, _ := resources.NestedString(obj, "metadata", "deletionTimestamp"); ts == "" {
+		return false
+	}
+	if grace, ok := resources.NestedInt64(obj, "metadata", "deletionGracePeriodSeconds"); ok && grace > 0 {
+		return false
+	}
+	return !hasFinalizers(obj)
+}
+
+// markDeleted sets deletionTimestamp (kept if already set) with a zero grace period.
+func markDeleted(obj map[string]interface{}) {
+	meta, _ := obj["metadata"].(map[string]interface{})
+	if meta == nil {
+		meta = map[string]interface{}{}
+		obj["metadata"] = meta
+	}
+	if ts, _ := meta["deletionTimestamp"].(string); ts == "" {
+		meta["deletionTimestamp"] = time.Now().UTC().Format(time.RFC3339)
+	}
+	meta["deletionGracePeriodSeconds"] = 0
+}
+
+// preserveServerMetadata copies metadata the clients can't change on update from existing
+// (uid, creationTimestamp when omitted, and the deletion mark).
+func preserveServerMetadata(updated, existing map[string]interface{}) {
+	oldMeta, _ := existing["metadata"].(map[string]interface{})
+	if oldMeta == nil {
+		return
+	}
+	meta, _ := updated["metadata"].(map[string]interface{})
+	if meta == nil {
+		meta = map[string]interface{}{}
+		updated["metadata"] = meta
+	}
+	if ct, _ := meta["creationTimestamp"].(string); ct == "" && oldMeta["creationTimestamp"] != nil {
+		meta["creationTimestamp"] = oldMeta["creationTimestamp"]
+	}
+	if oldMeta["uid"] != nil {
+		meta["uid"] = oldMeta["uid"]
+	}
+	for _, key := range []string{"deletionTimestamp", "deletionGracePeriodSeconds"} {
+		if v, ok := oldMeta[key]; ok {
+			meta[key] = v
+		} else {
+			delete(meta, key)
+		}
+	}
+}
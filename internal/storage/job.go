@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"mockernetes/internal/resources" // for KubeObject skeleton
+)
+
+// Job-specific storage methods (batch/v1).
+// Skeleton update: Create uses KubeObject.
+
+// ListJobs returns stored jobs as []interface{}.
+func (s *InMemoryStore) ListJobs() []interface{} {
+	return s.listHelper(s.jobData)
+}
+
+// CreateJob stores a job (error if exists).
+// Uses resources.Job (custom struct impl of KubeObject) for mock control.
+func (s *InMemoryStore) CreateJob(job resources.KubeObject) error {
+	return s.createHelper(s.jobData, job, "job")
+}
+
+// GetJob retrieves a job by name from storage.
+// Returns the job as a map or error if not found.
+func (s *InMemoryStore) GetJob(name string) (map[string]interface{}, error) {
+	return s.getHelper(s.jobData, name, "job")
+}
+
+// UpdateJob updates an existing job in storage.
+// Returns error if the job doesn't exist.
+func (s *InMemoryStore) UpdateJob(job resources.KubeObject) error {
+	return s.updateHelper(s.jobData, job, "job")
+}
+
+// MutateJob atomically applies fn to the stored job (controllers use it for status
+// writes so user-owned metadata such as finalizers is kept). Returns the updated job.
+func (s *InMemoryStore) MutateJob(name string, fn func(job map[string]interface{}) error) (map[string]interface{}, error) {
+	return s.mutateHelper(s.jobData, name, "job", fn)
+}
+
+// DeleteJob removes a job from storage, or only marks it for deletion while it has finalizers.
+// Returns error if the job doesn't exist.
+func (s *InMemoryStore) DeleteJob(name string) error {
+	_, err := s.deleteHelper(s.jobData, name, "job")
+	return err
+}
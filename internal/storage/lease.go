@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"mockernetes/internal/resources" // for KubeObject skeleton
+)
+
+// Lease-specific storage methods (coordination.k8s.io/v1; the simulated kubelets renew one per Node).
+// Skeleton update: Create uses KubeObject.
+
+// ListLeases returns stored leases as []interface{}.
+func (s *InMemoryStore) ListLeases() []interface{} {
+	return s.listHelper(s.leaseData)
+}
+
+// CreateLease stores a lease (error if exists).
+// Uses resources.Lease (custom struct impl of KubeObject) for mock control.
+func (s *InMemoryStore) CreateLease(lease resources.KubeObject) error {
+	return s.createHelper(s.leaseData, lease, "lease")
+}
+
+// GetLease retrieves a lease by name from storage.
+// Returns the lease as a map or error if not found.
+func (s *InMemoryStore) GetLease(name string) (map[string]interface{}, error) {
+	return s.getHelper(s.leaseData, name, "lease")
+}
+
+// UpdateLease updates an existing lease in storage.
+// Returns error if the lease doesn't exist.
+func (s *InMemoryStore) UpdateLease(lease resources.KubeObject) error {
+	return s.updateHelper(s.leaseData, lease, "lease")
+}
+
+// MutateLease atomically applies fn to the stored lease (controllers use it for status
+// writes so user-owned metadata such as finalizers is kept). Returns the updated lease.
+func (s *InMemoryStore) MutateLease(name string, fn func(lease map[string]interface{}) error) (map[string]interface{}, error) {
+	return s.mutateHelper(s.leaseData, name, "lease", fn)
+}
+
+// DeleteLease removes a lease from storage, or only marks it for deletion while it has finalizers.
+// Returns error if the lease doesn't exist.
+func (s *InMemoryStore) DeleteLease(name string) error {
+	_, err := s.deleteHelper(s.leaseData, name, "lease")
+	return err
+}
@@ -1,8 +1,6 @@
 package storage
 
 import (
-	"encoding/json"
-
 	"mockernetes/internal/resources" // for KubeObject skeleton in Create
 )
 
@@ -12,15 +10,7 @@ import (
 
 // ListNamespaces returns all stored namespaces as []interface{} (unmarshals JSON; includes default).
 func (s *InMemoryStore) ListNamespaces() []interface{} {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	var items []interface{}
-	for _, nsJSON := range s.nsData {
-		var ns map[string]interface{}
-		json.Unmarshal([]byte(nsJSON), &ns)
-		items = append(items, ns)
-	}
-	return items
+	return s.listHelper(s.nsData)
 }
 
 // CreateNamespace stores a namespace (error if exists; for kubectl compat).
@@ -28,3 +18,29 @@ func (s *InMemoryStore) ListNamespaces() []interface{} {
 func (s *InMemoryStore) CreateNamespace(ns resources.KubeObject) error {
 	return s.createHelper(s.nsData, ns, "namespace")
 }
+
+// GetNamespace retrieves a namespace by name from storage.
+// Returns the namespace as a map or error if not found.
+func (s *InMemoryStore) GetNamespace(name string) (map[string]interface{}, error) {
+	return s.getHelper(s.nsData, name, "namespace")
+}
+
+// UpdateNamespace updates an existing namespace in storage.
+// Returns error if the namespace doesn't exist.
+func (s *InMemoryStore) UpdateNamespace(ns resources.KubeObject) error {
+	return s.updateHelper(s.nsData, ns, "namespace")
+}
+
+// MutateNamespace atomically applies fn to the stored namespace (used by the namespace
+// controller to drop its spec finalizer). Returns the updated namespace.
+func (s *InMemoryStore) MutateNamespace(name string, fn func(ns map[string]interface{}) error) (map[string]interface{}, error) {
+	return s.mutateHelper(s.nsData, name, "namespace", fn)
+}
+
+// DeleteNamespace marks a namespace for deletion; it stays (Terminating) until the
+// namespace controller has removed the "kubernetes" finalizer from spec.finalizers.
+// Returns error if the namespace doesn't exist.
+func (s *InMemoryStore) DeleteNamespace(name string) error {
+	_, err := s.deleteHelper(s.nsData, name, "namespace")
+	return err
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"mockernetes/internal/resources" // for KubeObject skeleton
+)
+
+// Node-specific storage methods (v1, cluster-scoped).
+// Skeleton update: Create uses KubeObject.
+
+// ListNodes returns stored nodes as []interface{}.
+func (s *InMemoryStore) ListNodes() []interface{} {
+	return s.listHelper(s.nodeData)
+}
+
+// CreateNode stores a node (error if exists).
+// Uses resources.Node (custom struct impl of KubeObject) for mock control.
+func (s *InMemoryStore) CreateNode(node resources.KubeObject) error {
+	return s.createHelper(s.nodeData, node, "node")
+}
+
+// GetNode retrieves a node by name from storage.
+// Returns the node as a map or error if not found.
+func (s *InMemoryStore) GetNode(name string) (map[string]interface{}, error) {
+	return s.getHelper(s.nodeData, name, "node")
+}
+
+// UpdateNode updates an existing node in storage.
+// Returns error if the node doesn't exist.
+func (s *InMemoryStore) UpdateNode(node resources.KubeObject) error {
+	return s.updateHelper(s.nodeData, node, "node")
+}
+
+// MutateNode atomically applies fn to the stored node (controllers use it for status
+// writes so user-owned metadata such as finalizers is kept). Returns the updated node.
+func (s *InMemoryStore) MutateNode(name string, fn func(node map[string]interface{}) error) (map[string]interface{}, error) {
+	return s.mutateHelper(s.nodeData, name, "node", fn)
+}
+
+// DeleteNode removes a node from storage, or only marks it for deletion while it has finalizers.
+// Returns error if the node doesn't exist.
+func (s *InMemoryStore) DeleteNode(name string) error {
+	_, err := s.deleteHelper(s.nodeData, name, "node")
+	return err
+}
@@ -0,0 +1,165 @@
+package storage
+
+import (
+	"fmt"
+
+	"mockernetes/internal/resources"
+)
+
+// Kind-generic access for controllers that work across resources (garbage collector,
+// namespace deletion). Objects are addressed by API kind ("Pod", "ReplicaSet", ...).
+
+// kindInfo describes a stored kind.
+type kindInfo struct {
+	kind       string
+	typ        string // lower-case name used by the helpers and in error messages
+	resource   string // group-qualified resource, e.g. "deployments.apps"
+	namespaced bool
+}
+
+// storedKinds lists the kinds in storage, in the order they are walked.
+// New kinds are added here and get their map in NewInMemoryStore.
+var storedKinds = []kindInfo{
+	{kind: "Namespace", typ: "namespace", resource: "namespaces"},
+	{kind: "Pod", typ: "pod", resource: "pods", namespaced: true},
+	{kind: "ConfigMap", typ: "configmap", resource: "configmaps", namespaced: true},
+	{kind: "Deployment", typ: "deployment", resource: "deployments.apps", namespaced: true},
+	{kind: "ReplicaSet", typ: "replicaset", resource: "replicasets.apps", namespaced: true},
+	{kind: "StatefulSet", typ: "statefulset", resource: "statefulsets.apps", namespaced: true},
+	{kind: "ControllerRevision", typ: "controllerrevision", resource: "controllerrevisions.apps", namespaced: true},
+	{kind: "PersistentVolumeClaim", typ: "persistentvolumeclaim", resource: "persistentvolumeclaims", namespaced: true},
+	{kind: "DaemonSet", typ: "daemonset", resource: "daemonsets.apps", namespaced: true},
+	{kind: "Node", typ: "node", resource: "nodes"},
+	{kind: "Job", typ: "job", resource: "jobs.batch", namespaced: true},
+	{kind: "CronJob", typ: "cronjob", resource: "cronjobs.batch", namespaced: true},
+	{kind: "Event", typ: "event", resource: "events", namespaced: true},
+	{kind: "Lease", typ: "lease", resource: "leases.coordination.k8s.io", namespaced: true},
+	{kind: "Service", typ: "service", resource: "services", namespaced: true},
+	{kind: "EndpointSlice", typ: "endpointslice", resource: "endpointslices.discovery.k8s.io", namespaced: true},
+}
+
+// dataFor returns the backing map and error-message type name for kind.
+func (s *InMemoryStore) dataFor(kind string) (map[string]string, string, error) {
+	for _, k := range storedKinds {
+		if k.kind == kind {
+			return s.dataMaps[k.typ], k.typ, nil
+		}
+	}
+	return nil, "", fmt.Errorf("unknown kind %q", kind)
+}
+
+// kindOf maps a helper type name ("pod") back to its kind ("Pod").
+func kindOf(typ string) string {
+	for _, k := range storedKinds {
+		if k.typ == typ {
+			return k.kind
+		}
+	}
+	return typ
+}
+
+// Kinds returns every kind held by the store.
+func (s *InMemoryStore) Kinds() []string {
+	kinds := make([]string, 0, len(storedKinds))
+	for _, k := range storedKinds {
+		kinds = append(kinds, k.kind)
+	}
+	return kinds
+}
+
+// NamespacedKinds returns the kinds whose objects live in a namespace.
+func (s *InMemoryStore) NamespacedKinds() []string {
+	var kinds []string
+	for _, k := range storedKinds {
+		if k.namespaced {
+			kinds = append(kinds, k.kind)
+		}
+	}
+	return kinds
+}
+
+// ResourceName returns the group-qualified resource for kind (as used in API messages).
+func ResourceName(kind string) string {
+	for _, k := range storedKinds {
+		if k.kind == kind {
+			return k.resource
+		}
+	}
+	return kind
+}
+
+// ListObjects returns all stored objects of kind.
+func (s *InMemoryStore) ListObjects(kind string) []interface{} {
+	dataMap, _, err := s.dataFor(kind)
+	if err != nil {
+		return []interface{}{}
+	}
+	return s.listHelper(dataMap)
+}
+
+// GetObject retrieves one object of kind by name.
+func (s *InMemoryStore) GetObject(kind, name string) (map[string]interface{}, error) {
+	dataMap, typ, err := s.dataFor(kind)
+	if err != nil {
+		return nil, err
+	}
+	return s.getHelper(dataMap, name, typ)
+}
+
+// MutateObject atomically applies fn to one object of kind (see mutateHelper).
+func (s *InMemoryStore) MutateObject(kind, name string, fn func(obj map[string]interface{}) error) (map[string]interface{}, error) {
+	dataMap, typ, err := s.dataFor(kind)
+	if err != nil {
+		return nil, err
+	}
+	return s.mutateHelper(dataMap, name, typ, fn)
+}
+
+// DeleteObject deletes one object of kind, honoring finalizers (see deleteHelper).
+func (s *InMemoryStore) DeleteObject(kind, name string) error {
+	dataMap, typ, err := s.dataFor(kind)
+	if err != nil {
+		return err
+	}
+	_, err = s.deleteHelper(dataMap, name, typ)
+	return err
+}
+
+// UpdateObject replaces one object of kind (see updateHelper) and returns it as stored.
+func (s *InMemoryStore) UpdateObject(kind string, obj resources.KubeObject) (map[string]interface{}, error) {
+	dataMap, typ, err := s.dataFor(kind)
+	if err != nil {
+		return nil, err
+	}
+	return s.updateResultHelper(dataMap, obj, typ)
+}
+
+// DryRunCreate returns obj as creating it would store it (dryRun=All): the name is checked
+// for conflicts and the server-set metadata filled in, but nothing is written.
+func (s *InMemoryStore) DryRunCreate(kind string, obj resources.KubeObject) (map[string]interface{}, error) {
+	dataMap, typ, err := s.dataFor(kind)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, m, err := prepareCreate(dataMap, obj, typ)
+	return m, err
+}
+
+// DryRunUpdate returns obj as replacing the stored object of kind would store it (dryRun=All),
+// generation included, without writing it.
+func (s *InMemoryStore) DryRunUpdate(kind string, obj resources.KubeObject) (map[string]interface{}, error) {
+	dataMap, typ, err := s.dataFor(kind)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	name, m, err := prepareUpdate(dataMap, obj, typ)
+	if err != nil {
+		return nil, err
+	}
+	setGeneration(m, dataMap[name])
+	return m, nil
+}
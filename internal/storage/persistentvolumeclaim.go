@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"mockernetes/internal/resources" // for KubeObject skeleton
+)
+
+// PersistentVolumeClaim-specific storage methods (core/v1).
+// Skeleton update: Create uses KubeObject.
+
+// ListPersistentVolumeClaims returns stored persistentvolumeclaims as []interface{}.
+func (s *InMemoryStore) ListPersistentVolumeClaims() []interface{} {
+	return s.listHelper(s.pvcData)
+}
+
+// CreatePersistentVolumeClaim stores a persistentvolumeclaim (error if exists).
+// Uses resources.PersistentVolumeClaim (custom struct impl of KubeObject) for mock control.
+func (s *InMemoryStore) CreatePersistentVolumeClaim(pvc resources.KubeObject) error {
+	return s.createHelper(s.pvcData, pvc, "persistentvolumeclaim")
+}
+
+// GetPersistentVolumeClaim retrieves a persistentvolumeclaim by name from storage.
+// Returns the persistentvolumeclaim as a map or error if not found.
+func (s *InMemoryStore) GetPersistentVolumeClaim(name string) (map[string]interface{}, error) {
+	return s.getHelper(s.pvcData, name, "persistentvolumeclaim")
+}
+
+// UpdatePersistentVolumeClaim updates an existing persistentvolumeclaim in storage.
+// Returns error if the persistentvolumeclaim doesn't exist.
+func (s *InMemoryStore) UpdatePersistentVolumeClaim(pvc resources.KubeObject) error {
+	return s.updateHelper(s.pvcData, pvc, "persistentvolumeclaim")
+}
+
+// MutatePersistentVolumeClaim atomically applies fn to the stored persistentvolumeclaim (controllers use it for status
+// writes so user-owned metadata such as finalizers is kept). Returns the updated persistentvolumeclaim.
+func (s *InMemoryStore) MutatePersistentVolumeClaim(name string, fn func(pvc map[string]interface{}) error) (map[string]interface{}, error) {
+	return s.mutateHelper(s.pvcData, name, "persistentvolumeclaim", fn)
+}
+
+// DeletePersistentVolumeClaim removes a persistentvolumeclaim from storage, or only marks it for deletion while it has finalizers.
+// Returns error if the persistentvolumeclaim doesn't exist.
+func (s *InMemoryStore) DeletePersistentVolumeClaim(name string) error {
+	_, err := s.deleteHelper(s.pvcData, name, "persistentvolumeclaim")
+	return err
+}
@@ -13,15 +13,7 @@ import (
 // ListPods returns stored pods as []interface{} (JSON unmarshal for K8s compat).
 // Status/phase is expected to be set by the pod controller, not patched here.
 func (s *InMemoryStore) ListPods() []interface{} {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	items := make([]interface{}, 0, len(s.podData))
-	for _, pJSON := range s.podData {
-		var p map[string]interface{}
-		json.Unmarshal([]byte(pJSON), &p)
-		items = append(items, p)
-	}
-	return items
+	return s.listHelper(s.podData)
 }
 
 // CreatePod stores a pod (error if exists).
@@ -33,58 +25,26 @@ func (s *InMemoryStore) CreatePod(pod resources.KubeObject) error {
 // UpdatePod updates an existing pod in storage.
 // Returns error if the pod doesn't exist.
 func (s *InMemoryStore) UpdatePod(pod resources.KubeObject) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	name := pod.GetName()
-	if name == "" {
-		return fmt.Errorf("pod name required")
-	}
-
-	if _, exists := s.podData[name]; !exists {
-		return fmt.Errorf("pod %s not found", name)
-	}
-
-	b, err := pod.ToJSON()
-	if err != nil {
-		return fmt.Errorf("toJSON failed: %w", err)
-	}
-
-	s.podData[name] = string(b)
-	return nil
+	return s.updateHelper(s.podData, pod, "pod")
 }
 
 // GetPod retrieves a pod by name from storage.
 // Returns the pod as a map or error if not found.
 func (s *InMemoryStore) GetPod(name string) (map[string]interface{}, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	podJSON, exists := s.podData[name]
-	if !exists {
-		return nil, fmt.Errorf("pod %s not found", name)
-	}
-
-	var pod map[string]interface{}
-	if err := json.Unmarshal([]byte(podJSON), &pod); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal pod: %w", err)
-	}
-
-	return pod, nil
+	return s.getHelper(s.podData, name, "pod")
 }
 
-// DeletePod removes a pod from storage.
+// DeletePod removes a pod from storage, or only marks it for deletion while it has finalizers.
 // Returns error if the pod doesn't exist.
 func (s *InMemoryStore) DeletePod(name string) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if _, exists := s.podData[name]; !exists {
-		return fmt.Errorf("pod %s not found", name)
-	}
+	_, err := s.deleteHelper(s.podData, name, "pod")
+	return err
+}
 
-	delete(s.podData, name)
-	return nil
+// MutatePod atomically applies fn to the stored pod (e.g. status updates that must
+// keep metadata such as deletionTimestamp intact). Returns the updated pod.
+func (s *InMemoryStore) MutatePod(name string, fn func(pod map[string]interface{}) error) (map[string]interface{}, error) {
+	return s.mutateHelper(s.podData, name, "pod", fn)
 }
 
 // UpdatePodFromJSON updates a pod directly with JSON bytes.
@@ -97,6 +57,9 @@ func (s *InMemoryStore) UpdatePodFromJSON(name string, jsonData []byte) error {
 		return fmt.Errorf("pod %s not found", name)
 	}
 
-	s.podData[name] = string(jsonData)
-	return nil
+	var pod map[string]interface{}
+	if err := json.Unmarshal(jsonData, &pod); err != nil {
+		return fmt.Errorf("failed to unmarshal pod: %w", err)
+	}
+	return s.putLocked(s.podData, name, "pod", pod)
 }
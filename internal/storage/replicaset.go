@@ -1,9 +1,6 @@
 package storage
 
 import (
-	"encoding/json"
-	"fmt"
-
 	"mockernetes/internal/resources" // for KubeObject skeleton
 )
 
@@ -12,15 +9,7 @@ import (
 
 // ListReplicaSets returns stored replicasets as []interface{}.
 func (s *InMemoryStore) ListReplicaSets() []interface{} {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	var items []interface{}
-	for _, rsJSON := range s.rsData {
-		var rs map[string]interface{}
-		json.Unmarshal([]byte(rsJSON), &rs)
-		items = append(items, rs)
-	}
-	return items
+	return s.listHelper(s.rsData)
 }
 
 // CreateReplicaSet stores a replicaset (error if exists).
@@ -32,56 +21,24 @@ func (s *InMemoryStore) CreateReplicaSet(rs resources.KubeObject) error {
 // GetReplicaSet retrieves a replicaset by name from storage.
 // Returns the replicaset as a map or error if not found.
 func (s *InMemoryStore) GetReplicaSet(name string) (map[string]interface{}, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	rsJSON, exists := s.rsData[name]
-	if !exists {
-		return nil, fmt.Errorf("replicaset %s not found", name)
-	}
-
-	var rs map[string]interface{}
-	if err := json.Unmarshal([]byte(rsJSON), &rs); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal replicaset: %w", err)
-	}
-
-	return rs, nil
+	return s.getHelper(s.rsData, name, "replicaset")
 }
 
 // UpdateReplicaSet updates an existing replicaset in storage.
 // Returns error if the replicaset doesn't exist.
 func (s *InMemoryStore) UpdateReplicaSet(rs resources.KubeObject) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	name := rs.GetName()
-	if name == "" {
-		return fmt.Errorf("replicaset name required")
-	}
-
-	if _, exists := s.rsData[name]; !exists {
-		return fmt.Errorf("replicaset %s not found", name)
-	}
-
-	b, err := rs.ToJSON()
-	if err != nil {
-		return fmt.Errorf("toJSON failed: %w", err)
-	}
+	return s.updateHelper(s.rsData, rs, "replicaset")
+}
 
-	s.rsData[name] = string(b)
-	return nil
+// MutateReplicaSet atomically applies fn to the stored replicaset (controllers use it for status
+// writes so user-owned metadata such as finalizers is kept). Returns the updated replicaset.
+func (s *InMemoryStore) MutateReplicaSet(name string, fn func(rs map[string]interface{}) error) (map[string]interface{}, error) {
+	return s.mutateHelper(s.rsData, name, "replicaset", fn)
 }
 
-// DeleteReplicaSet removes a replicaset from storage.
+// DeleteReplicaSet removes a replicaset from storage, or only marks it for deletion while it has finalizers.
 // Returns error if the replicaset doesn't exist.
 func (s *InMemoryStore) DeleteReplicaSet(name string) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if _, exists := s.rsData[name]; !exists {
-		return fmt.Errorf("replicaset %s not found", name)
-	}
-
-	delete(s.rsData, name)
-	return nil
+	_, err := s.deleteHelper(s.rsData, name, "replicaset")
+	return err
 }
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"mockernetes/internal/resources" // for KubeObject skeleton
+)
+
+// Service-specific storage methods (core/v1; cluster IPs and node ports are allocated by the API layer).
+// Skeleton update: Create uses KubeObject.
+
+// ListServices returns stored services as []interface{}.
+func (s *InMemoryStore) ListServices() []interface{} {
+	return s.listHelper(s.svcData)
+}
+
+// CreateService stores a service (error if exists).
+// Uses resources.Service (custom struct impl of KubeObject) for mock control.
+func (s *InMemoryStore) CreateService(service resources.KubeObject) error {
+	return s.createHelper(s.svcData, service, "service")
+}
+
+// GetService retrieves a service by name from storage.
+// Returns the service as a map or error if not found.
+func (s *InMemoryStore) GetService(name string) (map[string]interface{}, error) {
+	return s.getHelper(s.svcData, name, "service")
+}
+
+// UpdateService updates an existing service in storage.
+// Returns error if the service doesn't exist.
+func (s *InMemoryStore) UpdateService(service resources.KubeObject) error {
+	return s.updateHelper(s.svcData, service, "service")
+}
+
+// MutateService atomically applies fn to the stored service (controllers use it for status
+// writes so user-owned metadata such as finalizers is kept). Returns the updated service.
+func (s *InMemoryStore) MutateService(name string, fn func(service map[string]interface{}) error) (map[string]interface{}, error) {
+	return s.mutateHelper(s.svcData, name, "service", fn)
+}
+
+// DeleteService removes a service from storage, or only marks it for deletion while it has finalizers.
+// Returns error if the service doesn't exist.
+func (s *InMemoryStore) DeleteService(name string) error {
+	_, err := s.deleteHelper(s.svcData, name, "service")
+	return err
+}
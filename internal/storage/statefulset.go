@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"mockernetes/internal/resources" // for KubeObject skeleton
+)
+
+// StatefulSet-specific storage methods (apps/v1).
+// Skeleton update: Create uses KubeObject.
+
+// ListStatefulSets returns stored statefulsets as []interface{}.
+func (s *InMemoryStore) ListStatefulSets() []interface{} {
+	return s.listHelper(s.stsData)
+}
+
+// CreateStatefulSet stores a statefulset (error if exists).
+// Uses resources.StatefulSet (custom struct impl of KubeObject) for mock control.
+func (s *InMemoryStore) CreateStatefulSet(sts resources.KubeObject) error {
+	return s.createHelper(s.stsData, sts, "statefulset")
+}
+
+// GetStatefulSet retrieves a statefulset by name from storage.
+// Returns the statefulset as a map or error if not found.
+func (s *InMemoryStore) GetStatefulSet(name string) (map[string]interface{}, error) {
+	return s.getHelper(s.stsData, name, "statefulset")
+}
+
+// UpdateStatefulSet updates an existing statefulset in storage.
+// Returns error if the statefulset doesn't exist.
+func (s *InMemoryStore) UpdateStatefulSet(sts resources.KubeObject) error {
+	return s.updateHelper(s.stsData, sts, "statefulset")
+}
+
+// MutateStatefulSet atomically applies fn to the stored statefulset (controllers use it for status
+// writes so user-owned metadata such as finalizers is kept). Returns the updated statefulset.
+func (s *InMemoryStore) MutateStatefulSet(name string, fn func(sts map[string]interface{}) error) (map[string]interface{}, error) {
+	return s.mutateHelper(s.stsData, name, "statefulset", fn)
+}
+
+// DeleteStatefulSet removes a statefulset from storage, or only marks it for deletion while it has finalizers.
+// Returns error if the statefulset doesn't exist.
+func (s *InMemoryStore) DeleteStatefulSet(name string) error {
+	_, err := s.deleteHelper(s.stsData, name, "statefulset")
+	return err
+}
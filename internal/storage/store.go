@@ -13,6 +13,24 @@ type InMemoryStore struct {
 	cmData     map[string]string
 	deployData map[string]string
 	rsData     map[string]string
+	stsData    map[string]string
+	crData     map[string]string
+	pvcData    map[string]string
+	dsData     map[string]string
+	nodeData   map[string]string
+	jobData    map[string]string
+	cjData     map[string]string
+	eventData  map[string]string
+	leaseData  map[string]string
+	svcData    map[string]string
+	epsData    map[string]string
+	// dataMaps indexes the maps above by helper type name (see storedKinds)
+	dataMaps map[string]map[string]string
+	// watchers receive change events (see events.go)
+	watchers map[*Watcher]struct{}
+	// recorder is the controllers' event recorder, kept here so it goes with the store
+	recorderOnce sync.Once
+	recorder     interface{}
 }
 
 // DefaultStore singleton (storage only).
@@ -20,6 +38,12 @@ var (
 	DefaultStore = NewInMemoryStore()
 )
 
+// EventRecorder returns the event recorder kept on the store, made by newRecorder on first use.
+func (s *InMemoryStore) EventRecorder(newRecorder func() interface{}) interface{} {
+	s.recorderOnce.Do(func() { s.recorder = newRecorder() })
+	return s.recorder
+}
+
 // NewInMemoryStore inits store (ns default; uses custom resources shapes).
 func NewInMemoryStore() *InMemoryStore {
 	s := &InMemoryStore{
@@ -28,6 +52,36 @@ func NewInMemoryStore() *InMemoryStore {
 		cmData:     make(map[string]string),
 		deployData: make(map[string]string),
 		rsData:     make(map[string]string),
+		stsData:    make(map[string]string),
+		crData:     make(map[string]string),
+		pvcData:    make(map[string]string),
+		dsData:     make(map[string]string),
+		nodeData:   make(map[string]string),
+		jobData:    make(map[string]string),
+		cjData:     make(map[string]string),
+		eventData:  make(map[string]string),
+		leaseData:  make(map[string]string),
+		svcData:    make(map[string]string),
+		epsData:    make(map[string]string),
+		watchers:   make(map[*Watcher]struct{}),
+	}
+	s.dataMaps = map[string]map[string]string{
+		"namespace":             s.nsData,
+		"pod":                   s.podData,
+		"configmap":             s.cmData,
+		"deployment":            s.deployData,
+		"replicaset":            s.rsData,
+		"statefulset":           s.stsData,
+		"controllerrevision":    s.crData,
+		"persistentvolumeclaim": s.pvcData,
+		"daemonset":             s.dsData,
+		"node":                  s.nodeData,
+		"job":                   s.jobData,
+		"cronjob":               s.cjData,
+		"event":                 s.eventData,
+		"lease":                 s.leaseData,
+		"service":               s.svcData,
+		"endpointslice":         s.epsData,
 	}
 	// default NS JSON matching resources.Namespace struct
 	defaultNS := `{"kind":"Namespace","apiVersion":"v1","metadata":{"name":"default"},"spec":{"finalizers":["kubernetes"]},"status":{"phase":"Active"}}`
@@ -1,12 +1,41 @@
 package storage
 
 import (
+	"crypto/rand"
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"time"
 
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
 	"mockernetes/internal/resources" // KubeObject + custom structs (resources pkg owns impls)
 )
 
+// generateName constants match the apiserver's SimpleNameGenerator.
+const (
+	generatedSuffixLength  = 5
+	maxGeneratedNameLength = 63 - generatedSuffixLength
+	// maxGenerateNameAttempts bounds the retries when a generated name collides.
+	maxGenerateNameAttempts = 8
+)
+
+// GenerateName appends a random 5-character suffix to base (truncated so the result fits a DNS label).
+func GenerateName(base string) string {
+	if len(base) > maxGeneratedNameLength {
+		base = base[:maxGeneratedNameLength]
+	}
+	return base + utilrand.String(generatedSuffixLength)
+}
+
+// NewUID returns a random (version 4) UUID for metadata.uid.
+func NewUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
 // Helpers for storage (strict: createHelper/Get; InMemoryStore/maps now in store.go).
 // createHelper internal for resources.KubeObject (uses custom structs for mock control).
 // Note: legacy param name; calls obj.ToJSON() from impl (Namespace/Pod/etc.).
@@ -14,24 +43,255 @@ import (
 func (s *InMemoryStore) createHelper(dataMap map[string]string, obj resources.KubeObject, typ string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	b, err := obj.ToJSON() // uses custom struct impl
+	name, m, err := prepareCreate(dataMap, obj, typ)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(m)
 	if err != nil {
 		return fmt.Errorf("toJSON failed: %w", err)
 	}
+	dataMap[name] = string(b)
+	s.notify(Added, typ, name, string(b), "")
+	return nil
+}
+
+// prepareCreate returns the name and the object obj is stored as: the name picked (from
+// generateName) and checked for conflicts, and the server-set metadata (uid,
+// creationTimestamp, generation) filled in. The caller holds s.mu.
+func prepareCreate(dataMap map[string]string, obj resources.KubeObject, typ string) (string, map[string]interface{}, error) {
+	b, err := obj.ToJSON() // uses custom struct impl
+	if err != nil {
+		return "", nil, fmt.Errorf("toJSON failed: %w", err)
+	}
 	var m map[string]interface{}
 	json.Unmarshal(b, &m)
 	meta, _ := m["metadata"].(map[string]interface{})
 	name, _ := meta["name"].(string)
 	if name == "" {
-		return fmt.Errorf("%s name required", typ)
+		// metadata.generateName: pick a free random name (retry on collision)
+		generateName, _ := meta["generateName"].(string)
+		if generateName == "" {
+			return "", nil, fmt.Errorf("%s name required", typ)
+		}
+		for i := 0; i < maxGenerateNameAttempts; i++ {
+			if candidate := GenerateName(generateName); dataMap[candidate] == "" {
+				name = candidate
+				break
+			}
+		}
+		if name == "" {
+			return "", nil, fmt.Errorf("%s name generation from %q failed after %d attempts", typ, generateName, maxGenerateNameAttempts)
+		}
+		meta["name"] = name
+		// write the name back when the caller passed a pointer (e.g. &pod)
+		if setter, ok := obj.(resources.NameSetter); ok {
+			setter.SetName(name)
+		}
 	}
 	if _, exists := dataMap[name]; exists {
-		return fmt.Errorf("%s %s already exists", typ, name)
+		return "", nil, fmt.Errorf("%s %s already exists", typ, name)
+	}
+	// metadata.uid is server-assigned; ownerReferences (and the garbage collector) key on it.
+	// The API layer may have picked it already (a Job's selector is derived from its uid).
+	if uid, _ := meta["uid"].(string); uid == "" {
+		meta["uid"] = NewUID()
+	}
+	// controllers stamp what they create themselves; API clients leave it to the server
+	if ts, _ := meta["creationTimestamp"].(string); ts == "" {
+		meta["creationTimestamp"] = time.Now().UTC().Format(time.RFC3339)
+	}
+	if _, ok := m["spec"]; ok {
+		meta["generation"] = 1
+	} else {
+		delete(meta, "generation")
+	}
+	return name, m, nil
+}
+
+// mutateHelper applies fn to the decoded object under the write lock and stores the result,
+// so read-modify-write callers (status updates, deletion marks) can't clobber each other.
+// Returns the updated object.
+func (s *InMemoryStore) mutateHelper(dataMap map[string]string, name, typ string, fn func(obj map[string]interface{}) error) (map[string]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	objJSON, exists := dataMap[name]
+	if !exists {
+		return nil, fmt.Errorf("%s %s not found", typ, name)
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(objJSON), &obj); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s: %w", typ, err)
+	}
+	if err := fn(obj); err != nil {
+		return nil, err
+	}
+	if err := s.putLocked(dataMap, name, typ, obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// listHelper decodes every stored object of one kind.
+func (s *InMemoryStore) listHelper(dataMap map[string]string) []interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	items := make([]interface{}, 0, len(dataMap))
+	for _, objJSON := range dataMap {
+		var obj map[string]interface{}
+		json.Unmarshal([]byte(objJSON), &obj)
+		items = append(items, obj)
+	}
+	return items
+}
+
+// getHelper retrieves one object by name as a map.
+func (s *InMemoryStore) getHelper(dataMap map[string]string, name, typ string) (map[string]interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	objJSON, exists := dataMap[name]
+	if !exists {
+		return nil, fmt.Errorf("%s %s not found", typ, name)
+	}
+
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(objJSON), &obj); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s: %w", typ, err)
+	}
+	return obj, nil
+}
+
+// updateHelper replaces an existing object. Server-owned metadata (creationTimestamp,
+// deletionTimestamp, deletionGracePeriodSeconds) is carried over from the stored copy,
+// and an object being deleted is removed once its last finalizer is gone.
+func (s *InMemoryStore) updateHelper(dataMap map[string]string, obj resources.KubeObject, typ string) error {
+	_, err := s.updateResultHelper(dataMap, obj, typ)
+	return err
+}
+
+// updateResultHelper is updateHelper returning the object as stored (as it was last stored
+// when the update finalized it away).
+func (s *InMemoryStore) updateResultHelper(dataMap map[string]string, obj resources.KubeObject, typ string) (map[string]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name, updated, err := prepareUpdate(dataMap, obj, typ)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.putLocked(dataMap, name, typ, updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// prepareUpdate returns the name and the object obj replaces the stored one with, server-owned
+// metadata carried over (putLocked sets the generation). The caller holds s.mu.
+func prepareUpdate(dataMap map[string]string, obj resources.KubeObject, typ string) (string, map[string]interface{}, error) {
+	name := obj.GetName()
+	if name == "" {
+		return "", nil, fmt.Errorf("%s name required", typ)
+	}
+	existingJSON, exists := dataMap[name]
+	if !exists {
+		return "", nil, fmt.Errorf("%s %s not found", typ, name)
+	}
+
+	b, err := obj.ToJSON()
+	if err != nil {
+		return "", nil, fmt.Errorf("toJSON failed: %w", err)
+	}
+	var updated, existing map[string]interface{}
+	if err := json.Unmarshal(b, &updated); err != nil {
+		return "", nil, fmt.Errorf("failed to unmarshal %s: %w", typ, err)
+	}
+	json.Unmarshal([]byte(existingJSON), &existing)
+	preserveServerMetadata(updated, existing)
+	return name, updated, nil
+}
+
+// deleteHelper deletes an object, honoring finalizers: while any remain the object is only
+// marked with deletionTimestamp and stays in storage (returns removed=false).
+func (s *InMemoryStore) deleteHelper(dataMap map[string]string, name, typ string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	objJSON, exists := dataMap[name]
+	if !exists {
+		return false, fmt.Errorf("%s %s not found", typ, name)
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(objJSON), &obj); err != nil {
+		return false, fmt.Errorf("failed to unmarshal %s: %w", typ, err)
+	}
+
+	if !hasFinalizers(obj) {
+		delete(dataMap, name)
+		s.notify(Deleted, typ, name, objJSON, objJSON)
+		return true, nil
+	}
+	markDeleted(obj)
+	return false, s.putLocked(dataMap, name, typ, obj)
+}
+
+// putLocked writes obj (caller holds s.mu), or removes it when it has been fully finalized.
+// Watchers get the matching MODIFIED or DELETED event.
+func (s *InMemoryStore) putLocked(dataMap map[string]string, name, typ string, obj map[string]interface{}) error {
+	oldJSON := dataMap[name]
+	if isFinalized(obj) {
+		delete(dataMap, name)
+		s.notify(Deleted, typ, name, oldJSON, oldJSON)
+		return nil
+	}
+	setGeneration(obj, oldJSON)
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return fmt.Errorf("toJSON failed: %w", err)
+	}
+	if string(b) == oldJSON {
+		// no-op write: nothing to store or report (keeps event-driven controllers from looping)
+		return nil
 	}
 	dataMap[name] = string(b)
+	s.notify(Modified, typ, name, string(b), oldJSON)
 	return nil
 }
 
+// setGeneration carries metadata.generation over from the stored copy and increments it when
+// the spec changed, so controllers can report the generation they acted on (observedGeneration).
+func setGeneration(obj map[string]interface{}, oldJSON string) {
+	meta, _ := obj["metadata"].(map[string]interface{})
+	if meta == nil {
+		return
+	}
+	var old map[string]interface{}
+	json.Unmarshal([]byte(oldJSON), &old)
+	oldMeta, _ := old["metadata"].(map[string]interface{})
+	generation, ok := oldMeta["generation"]
+	if !ok {
+		delete(meta, "generation")
+		return
+	}
+	if !reflect.DeepEqual(normalizeJSON(obj["spec"]), old["spec"]) {
+		n, _ := resources.ToInt64(generation)
+		generation = n + 1
+	}
+	meta["generation"] = generation
+}
+
+// normalizeJSON round-trips v through JSON so it compares equal to a decoded copy.
+func normalizeJSON(v interface{}) interface{} {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out interface{}
+	json.Unmarshal(b, &out)
+	return out
+}
+
 // Get helpers omitted for minimal (extend if needed; placeholder for ns).
 func (s *InMemoryStore) Get(name string) (interface{}, error) {
 	// placeholder, ns only for now